@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/internal/cmd"
+	"github.com/ProtonMail/proton-bridge/internal/events"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/internal/users"
+	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/constants"
+	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/urfave/cli"
+)
+
+var addAccountCommand = cli.Command{ //nolint[gochecknoglobals]
+	Name:  "add-account",
+	Usage: "Add an account without interactive login using a pre-obtained refresh token",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "from-file",
+			Usage: "JSON file with `RefreshToken` (in UID:token format) and `MailboxPassword`",
+		},
+	},
+	Action: addAccount,
+}
+
+func addAccount(context *cli.Context) error {
+	path := context.String("from-file")
+	if path == "" {
+		return cli.NewExitError("Missing --from-file", 4)
+	}
+
+	token, err := users.LoadAccountToken(path)
+	if err != nil {
+		return cli.NewExitError("Cannot load account token: "+err.Error(), 1)
+	}
+
+	cfg := config.New(appName, constants.Version, constants.Revision, cacheVersion)
+	if err := cfg.CreateDirs(); err != nil {
+		return cli.NewExitError("Cannot create necessary folders: "+err.Error(), 1)
+	}
+
+	cmd.SetupKeychain(context, cfg, preferences.New(cfg).Get(preferences.KeychainKey))
+	credentialsStore, err := credentials.NewStore(appName)
+	if err != nil {
+		return cli.NewExitError("Credentials store is not available: "+err.Error(), 1)
+	}
+
+	eventListener := listener.New()
+	events.SetupEvents(eventListener)
+
+	cm := pmapi.NewClientManager(cfg.GetAPIConfig())
+	cm.SetRoundTripper(cfg.GetRoundTripper(cm, eventListener))
+
+	// Nobody else is interested in auth updates here, but the client manager
+	// blocks until they are received.
+	go func() {
+		for range cm.GetAuthUpdateChannel() {
+		}
+	}()
+
+	userID, err := users.AddUserFromToken(cm, credentialsStore, token, true)
+	if err != nil {
+		return cli.NewExitError("Cannot add account: "+err.Error(), 1)
+	}
+
+	fmt.Printf("Account %s added, restart Bridge to start using it\n", userID)
+	return nil
+}
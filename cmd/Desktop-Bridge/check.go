@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/internal/api"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/constants"
+	"github.com/urfave/cli"
+)
+
+// Exit codes of the check command; zero means healthy.
+const (
+	checkDegraded = 1
+	checkDown     = 2
+)
+
+// check asks the running instance about its health over the local API
+// and reports the result by exit code, so it can be used by scripts.
+func check(context *cli.Context) error {
+	cfg := config.New(appName, constants.Version, constants.Revision, cacheVersion)
+
+	tls, err := config.GetTLSClientConfig(cfg)
+	if err != nil {
+		fmt.Println(api.HealthDown)
+		return cli.NewExitError("Cannot load TLS certificate: "+err.Error(), checkDown)
+	}
+
+	// Preferences are only read; defaults are not written to not disturb
+	// the running instance.
+	port := config.NewPreferences(cfg.GetPreferencesPath()).GetInt(preferences.APIPortKey)
+	if port == 0 {
+		port = cfg.GetDefaultAPIPort()
+	}
+
+	health, err := api.GetHealth(port, tls)
+	if err != nil {
+		fmt.Println(api.HealthDown)
+		return cli.NewExitError("Bridge is not running or does not respond: "+err.Error(), checkDown)
+	}
+
+	fmt.Println(health.Status)
+	for _, problem := range health.Problems {
+		fmt.Println(" *", problem)
+	}
+
+	if health.Status != api.HealthOK {
+		return cli.NewExitError("", checkDegraded)
+	}
+	return nil
+}
@@ -37,7 +37,12 @@ package main
 import (
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"runtime/pprof"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ProtonMail/proton-bridge/internal/api"
 	"github.com/ProtonMail/proton-bridge/internal/bridge"
@@ -48,6 +53,7 @@ import (
 	"github.com/ProtonMail/proton-bridge/internal/imap"
 	"github.com/ProtonMail/proton-bridge/internal/preferences"
 	"github.com/ProtonMail/proton-bridge/internal/smtp"
+	"github.com/ProtonMail/proton-bridge/internal/store"
 	"github.com/ProtonMail/proton-bridge/internal/updates"
 	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
@@ -81,7 +87,23 @@ func main() {
 				Usage: "Don't show window after start"},
 			cli.BoolFlag{
 				Name:  "noninteractive",
-				Usage: "Start Bridge entirely noninteractively"},
+				Usage: "Start Bridge entirely noninteractively and fail with exit code 5 when user action is needed"},
+			cli.BoolFlag{
+				Name:  "low-resource",
+				Usage: "Use less CPU and memory at the cost of slower sync, e.g. on ARM single-board computers"},
+			cli.BoolFlag{
+				Name:  "smtp-only",
+				Usage: "Start only the SMTP server and do not sync mailboxes (relay-only mode)"},
+		},
+		[]cli.Command{
+			{
+				Name:   "check",
+				Usage:  "Check health of the running Bridge (exit code 0 healthy, 1 degraded, 2 down)",
+				Action: check,
+			},
+			settingsCommand,
+			socketConnectCommand,
+			addAccountCommand,
 		},
 		run,
 	)
@@ -153,6 +175,16 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 
 	pref := preferences.New(cfg)
 
+	// Log level from preferences is used only when not overridden by the flag.
+	if prefLogLevel := pref.Get(preferences.LogLevelKey); logLevel == "" && prefLogLevel != "" {
+		config.SetLogLevel(prefLogLevel)
+	}
+
+	store.SetLowResourceMode(context.GlobalBool("low-resource"))
+	relayOnly := context.GlobalBool("smtp-only") || pref.GetBool(preferences.SMTPOnlyKey)
+	store.SetRelayOnlyMode(relayOnly)
+	preferences.ApplySyncOptions(pref)
+
 	// Now we can try to proceed with starting the bridge. First we need to ensure
 	// this is the only instance. If not, we will end and focus the existing one.
 	lock, err := singleinstance.CreateLockFile(cfg.GetLockPath())
@@ -166,6 +198,23 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 	}
 	defer lock.Close() //nolint[errcheck]
 
+	updates.SetRolloutBucket(pref.GetInt(preferences.RolloutBucketKey))
+	// Mirrors are comma separated hosts used when the primary one is blocked.
+	updates.SetMirrors(strings.Split(pref.Get(preferences.UpdateMirrorsKey), ","))
+
+	// Update staged in background by previous run is applied before anything
+	// else is started, so the app can quit and let the installer do its job.
+	if pref.GetBool(preferences.SilentUpdatesKey) {
+		if applied, restart, err := updates.ApplyStagedUpdate(); err != nil {
+			log.WithError(err).Error("Cannot apply staged update")
+		} else if applied {
+			if restart {
+				cmd.RestartApp()
+			}
+			return nil
+		}
+	}
+
 	// In case user wants to do CPU or memory profiles...
 	if doCPUProfile := context.GlobalBool("cpu-prof"); doCPUProfile {
 		cmd.StartCPUProfile()
@@ -181,6 +230,7 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 	eventListener := listener.New()
 	events.SetupEvents(eventListener)
 
+	cmd.SetupKeychain(context, cfg, pref.Get(preferences.KeychainKey))
 	credentialsStore, credentialsError := credentials.NewStore(appName)
 	if credentialsError != nil {
 		log.Error("Could not get credentials store: ", credentialsError)
@@ -188,11 +238,38 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 
 	cm := pmapi.NewClientManager(cfg.GetAPIConfig())
 
+	// Trusted TLS pins can be rotated without a new release.
+	if loaded, err := updates.LoadTLSPinsOverride(cfg.GetTLSPinsPath()); err != nil {
+		log.WithError(err).Error("Cannot load TLS pins override")
+	} else if loaded {
+		eventListener.Emit(events.TLSPinsOverrideEvent, cfg.GetTLSPinsPath())
+	} else {
+		if err := updates.LoadStoredTLSPins(cfg.GetSignedTLSPinsPath()); err != nil {
+			log.WithError(err).Warn("Cannot load stored TLS pins")
+		}
+		go func() {
+			defer panicHandler.HandlePanic()
+			if err := updates.UpdateTLSPins(cfg.GetSignedTLSPinsPath()); err != nil {
+				log.WithError(err).Warn("Cannot update TLS pins")
+			}
+		}()
+	}
+
 	// Different build types have different roundtrippers (e.g. we want to enable
 	// TLS fingerprint checks in production builds). GetRoundTripper has a different
 	// implementation depending on whether build flag pmapi_prod is used or not.
 	cm.SetRoundTripper(cfg.GetRoundTripper(cm, eventListener))
 
+	// Frontends show for how long the server paused the communication.
+	cm.SetRateLimitNotifier(func(retryAfter time.Duration) {
+		eventListener.Emit(events.RateLimitEvent, strconv.Itoa(int(retryAfter.Seconds())))
+	})
+
+	// Wrong local clock breaks authentication and TLS; users are warned with the measured offset.
+	cm.SetClockSkewNotifier(func(skew time.Duration) {
+		eventListener.Emit(events.ClockSkewEvent, strconv.Itoa(int(skew.Seconds())))
+	})
+
 	// Cookies must be persisted across restarts.
 	jar, err := cookies.NewCookieJar(pref)
 	if err != nil {
@@ -205,35 +282,93 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 	imapBackend := imap.NewIMAPBackend(panicHandler, eventListener, cfg, bridgeInstance)
 	smtpBackend := smtp.NewSMTPBackend(panicHandler, eventListener, pref, bridgeInstance)
 
+	// Messages held back for undo-send would be lost when quitting.
+	defer smtpBackend.SendPendingNow()
+	panicHandler.SetOnPanic(smtpBackend.SendPendingNow)
+
+	// Startup check of the connection also measures the local clock skew.
 	go func() {
 		defer panicHandler.HandlePanic()
-		apiServer := api.NewAPIServer(pref, tls, cfg.GetTLSCertPath(), cfg.GetTLSKeyPath(), eventListener)
-		apiServer.ListenAndServe()
+		_ = cm.CheckConnection()
 	}()
 
+	// Preferences can be changed without restart by editing the file,
+	// by sending SIGHUP or by calling /reload of the local API.
 	go func() {
 		defer panicHandler.HandlePanic()
-		imapPort := pref.GetInt(preferences.IMAPPortKey)
-		imapServer := imap.NewIMAPServer(debugClient, debugServer, imapPort, tls, imapBackend, eventListener)
-		imapServer.ListenAndServe()
+		reloader := preferences.NewReloader(pref, cfg.GetPreferencesPath(), bridgeInstance)
+		reloader.Watch(eventListener)
+	}()
+
+	go func() {
+		defer panicHandler.HandlePanic()
+		sighupCh := make(chan os.Signal, 1)
+		signal.Notify(sighupCh, syscall.SIGHUP)
+		for range sighupCh {
+			eventListener.Emit(events.ReloadPreferencesEvent, "")
+		}
 	}()
 
+	go func() {
+		defer panicHandler.HandlePanic()
+		updates.StageUpdatesInBackground(func() bool {
+			return pref.GetBool(preferences.SilentUpdatesKey)
+		})
+	}()
+
+	go func() {
+		defer panicHandler.HandlePanic()
+		apiServer := api.NewAPIServer(pref, tls, cfg.GetTLSCertPath(), cfg.GetTLSKeyPath(), eventListener, bridgeInstance)
+		apiServer.ListenAndServe()
+	}()
+
+	// IMAP and SMTP servers reachable from other devices can require client
+	// certificates. The local API keeps using the plain config.
+	serverTLS := tls
+	if clientCAPath := pref.Get(preferences.TLSClientCAKey); clientCAPath != "" {
+		if serverTLS, err = config.GetTLSConfigWithClientAuth(tls, clientCAPath); err != nil {
+			log.WithError(err).Fatal("Cannot load CA certificates of clients")
+		}
+	}
+
+	// In relay-only mode there is no synced store to serve mailboxes from.
+	if relayOnly {
+		log.Info("Relay-only mode, IMAP server is not started")
+	} else {
+		go func() {
+			defer panicHandler.HandlePanic()
+			imapPort := pref.GetInt(preferences.IMAPPortKey)
+			imapServer := imap.NewIMAPServer(debugClient, debugServer, pref.Get(preferences.ListenHostKey), imapPort, serverTLS, imapBackend, eventListener)
+			if useLocalSocket(pref) {
+				imapServer.SetLocalSocketPath(cfg.GetLocalSocketPath("imap"))
+			}
+			imapServer.ListenAndServe()
+		}()
+	}
+
 	go func() {
 		defer panicHandler.HandlePanic()
 		smtpPort := pref.GetInt(preferences.SMTPPortKey)
 		useSSL := pref.GetBool(preferences.SMTPSSLKey)
-		smtpServer := smtp.NewSMTPServer(debugClient || debugServer, smtpPort, useSSL, tls, smtpBackend, eventListener)
+		smtpServer := smtp.NewSMTPServer(debugClient || debugServer, pref.Get(preferences.ListenHostKey), smtpPort, useSSL, serverTLS, smtpBackend, eventListener)
+		if useLocalSocket(pref) {
+			smtpServer.SetLocalSocketPath(cfg.GetLocalSocketPath("smtp"))
+		}
 		smtpServer.ListenAndServe()
 	}()
 
 	// Decide about frontend mode before initializing rest of bridge.
 	var frontendMode string
 
+	// Noninteractive wins over any interactive frontend so scripts
+	// never end up waiting for input, e.g. with --plain from an alias.
 	switch {
-	case context.GlobalBool("cli"):
-		frontendMode = "cli"
 	case context.GlobalBool("noninteractive"):
 		frontendMode = "noninteractive"
+	case context.GlobalBool("plain"):
+		frontendMode = "cli-plain"
+	case context.GlobalBool("cli"):
+		frontendMode = "cli"
 	default:
 		frontendMode = "qt"
 	}
@@ -242,12 +377,11 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 
 	// If we are starting bridge in noninteractive mode, simply block instead of starting a frontend.
 	if frontendMode == "noninteractive" {
-		<-(make(chan struct{}))
-		return nil
+		return runNoninteractive(credentialsError, bridgeInstance, eventListener)
 	}
 
 	showWindowOnStart := !context.GlobalBool("no-window")
-	frontend := frontend.New(constants.Version, constants.BuildVersion, frontendMode, showWindowOnStart, panicHandler, cfg, pref, eventListener, updates, bridgeInstance, smtpBackend)
+	frontend := frontend.New(constants.Version, constants.BuildVersion, frontendMode, showWindowOnStart, panicHandler, cfg, pref, eventListener, updates, bridgeInstance, smtpBackend, smtpBackend)
 
 	// Last part is to start everything.
 	log.Debug("Starting frontend...")
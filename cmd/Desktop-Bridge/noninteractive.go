@@ -0,0 +1,58 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/ProtonMail/proton-bridge/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/internal/events"
+	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/urfave/cli"
+)
+
+// exitCodeInteractionNeeded is returned in noninteractive mode whenever
+// bridge would need to ask the user something.
+const exitCodeInteractionNeeded = 5
+
+// runNoninteractive blocks until something needs user's attention (no usable
+// keychain, no account, account logged out) and then fails with the specific
+// exit code, so a service manager does not keep a useless instance running.
+func runNoninteractive(credentialsError error, b *bridge.Bridge, eventListener listener.Listener) error {
+	if credentialsError != nil {
+		return cli.NewExitError("Keychain is not available: "+credentialsError.Error(), exitCodeInteractionNeeded)
+	}
+
+	users := b.GetUsers()
+	if len(users) == 0 {
+		return cli.NewExitError("No account is set up; add one using --cli first", exitCodeInteractionNeeded)
+	}
+
+	for _, user := range users {
+		if !user.IsConnected() {
+			return cli.NewExitError("Account "+user.Username()+" is logged out and needs to log in again", exitCodeInteractionNeeded)
+		}
+	}
+
+	logoutCh := make(chan string)
+	eventListener.Add(events.LogoutEvent, logoutCh)
+	eventListener.Add(events.ReloginRequiredEvent, logoutCh)
+	eventListener.Add(events.AddressChangedLogoutEvent, logoutCh)
+
+	// Logout and relogin events carry user ID, address change logout carries address.
+	account := <-logoutCh
+	return cli.NewExitError("Account "+account+" was logged out and needs to log in again", exitCodeInteractionNeeded)
+}
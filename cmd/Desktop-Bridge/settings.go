@@ -0,0 +1,119 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/ProtonMail/proton-bridge/internal/cmd"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/internal/settings"
+	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/constants"
+	"github.com/urfave/cli"
+)
+
+var settingsCommand = cli.Command{ //nolint[gochecknoglobals]
+	Name:  "settings",
+	Usage: "Export or import all Bridge settings as a single file",
+	Subcommands: []cli.Command{
+		{
+			Name:      "export",
+			Usage:     "Export preferences and list of accounts (and credentials when password is set) to the file",
+			ArgsUsage: "FILE",
+			Flags:     []cli.Flag{credentialsPasswordFlag},
+			Action:    exportSettings,
+		},
+		{
+			Name:      "import",
+			Usage:     "Import settings from the file exported on another machine",
+			ArgsUsage: "FILE",
+			Flags:     []cli.Flag{credentialsPasswordFlag},
+			Action:    importSettings,
+		},
+	},
+}
+
+var credentialsPasswordFlag = cli.StringFlag{ //nolint[gochecknoglobals]
+	Name:   "credentials-password",
+	Usage:  "Password to encrypt or decrypt account credentials; credentials are skipped without it",
+	EnvVar: "BRIDGE_CREDENTIALS_PASSWORD",
+}
+
+func exportSettings(context *cli.Context) error {
+	path := context.Args().First()
+	if path == "" {
+		return cli.NewExitError("Missing file name", 4)
+	}
+
+	cfg := config.New(appName, constants.Version, constants.Revision, cacheVersion)
+	pref := preferences.New(cfg)
+
+	cmd.SetupKeychain(context, cfg, pref.Get(preferences.KeychainKey))
+	credentialsStore, err := credentials.NewStore(appName)
+	if err != nil {
+		log.WithError(err).Warn("Credentials store is not available")
+	}
+
+	bundle, err := settings.Export(pref, credentialsStore, context.String(credentialsPasswordFlag.Name))
+	if err != nil {
+		return cli.NewExitError("Cannot export settings: "+err.Error(), 1)
+	}
+
+	if err := settings.Write(bundle, path); err != nil {
+		return cli.NewExitError("Cannot write settings: "+err.Error(), 1)
+	}
+
+	fmt.Printf("Settings with %d account(s) exported to %s\n", len(bundle.Accounts), path)
+	if bundle.Credentials == "" {
+		fmt.Println("Credentials were not exported, accounts will need to log in again.")
+	}
+	return nil
+}
+
+func importSettings(context *cli.Context) error {
+	path := context.Args().First()
+	if path == "" {
+		return cli.NewExitError("Missing file name", 4)
+	}
+
+	bundle, err := settings.Read(path)
+	if err != nil {
+		return cli.NewExitError("Cannot read settings: "+err.Error(), 1)
+	}
+
+	cfg := config.New(appName, constants.Version, constants.Revision, cacheVersion)
+	if err := cfg.CreateDirs(); err != nil {
+		return cli.NewExitError("Cannot create necessary folders: "+err.Error(), 1)
+	}
+	pref := preferences.New(cfg)
+
+	cmd.SetupKeychain(context, cfg, pref.Get(preferences.KeychainKey))
+	credentialsStore, err := credentials.NewStore(appName)
+	if err != nil {
+		log.WithError(err).Warn("Credentials store is not available")
+	}
+
+	if err := settings.Import(bundle, pref, credentialsStore, context.String(credentialsPasswordFlag.Name)); err != nil {
+		return cli.NewExitError("Cannot import settings: "+err.Error(), 1)
+	}
+
+	fmt.Printf("Settings imported from %s, restart Bridge to apply all of them\n", path)
+	return nil
+}
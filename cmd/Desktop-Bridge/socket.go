@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io"
+	"net"
+	"os"
+
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/constants"
+	"github.com/ProtonMail/proton-bridge/pkg/localsocket"
+	"github.com/urfave/cli"
+)
+
+// socketConnectCommand is a shim for clients which cannot connect to unix
+// socket directly but can run a command speaking IMAP or SMTP on its standard
+// input and output, e.g. tunnel option of mutt or isync.
+var socketConnectCommand = cli.Command{ //nolint[gochecknoglobals]
+	Name:      "socket-connect",
+	Usage:     "Connect standard input and output to the local socket of running Bridge (needs local socket enabled)",
+	ArgsUsage: "imap|smtp",
+	Action:    socketConnect,
+}
+
+func useLocalSocket(pref *config.Preferences) bool {
+	return localsocket.Supported && pref.GetBool(preferences.LocalSocketKey)
+}
+
+func socketConnect(context *cli.Context) error {
+	protocol := context.Args().First()
+	if protocol != "imap" && protocol != "smtp" {
+		return cli.NewExitError("Protocol must be imap or smtp", 4)
+	}
+
+	cfg := config.New(appName, constants.Version, constants.Revision, cacheVersion)
+
+	conn, err := net.Dial("unix", cfg.GetLocalSocketPath(protocol))
+	if err != nil {
+		return cli.NewExitError("Cannot connect to Bridge: "+err.Error(), 1)
+	}
+	defer conn.Close() //nolint[errcheck]
+
+	go func() {
+		_, _ = io.Copy(conn, os.Stdin)
+		_ = conn.(*net.UnixConn).CloseWrite()
+	}()
+
+	if _, err := io.Copy(os.Stdout, conn); err != nil {
+		return cli.NewExitError("Connection failed: "+err.Error(), 1)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ProtonMail/proton-bridge/internal/transfer"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/constants"
+	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/urfave/cli"
+)
+
+var benchmarkCommand = cli.Command{ //nolint[gochecknoglobals]
+	Name:  "benchmark",
+	Usage: "Measure disk read, decryption and API upload speed and show which one would slow down the planned migration",
+	Flags: []cli.Flag{
+		cli.UintFlag{
+			Name:  "messages",
+			Value: 10000,
+			Usage: "Number of messages of the planned migration"},
+		cli.UintFlag{
+			Name:  "message-size",
+			Value: 75,
+			Usage: "Average size of one message in KB"},
+		cli.StringFlag{
+			Name:  "source",
+			Usage: "Folder with messages to migrate to measure the real disk read speed"},
+	},
+	Action: benchmark,
+}
+
+func benchmark(context *cli.Context) error {
+	messageSize := uint64(context.Uint("message-size")) * 1024
+	if messageSize == 0 {
+		return cli.NewExitError("Message size must be positive", 4)
+	}
+
+	cfg := config.New(appName, constants.Version, constants.Revision, "")
+	if err := cfg.CreateDirs(); err != nil {
+		return cli.NewExitError("Cannot create necessary folders: "+err.Error(), 1)
+	}
+
+	// Without source, synthetic file is written to empty folder on the same
+	// disk as the app data.
+	dir := context.String("source")
+	if dir == "" {
+		tmpDir, err := ioutil.TempDir(cfg.GetTransferDir(), "benchmark")
+		if err != nil {
+			return cli.NewExitError("Cannot create folder: "+err.Error(), 1)
+		}
+		defer os.RemoveAll(tmpDir) //nolint[errcheck]
+		dir = tmpDir
+	}
+
+	cm := pmapi.NewClientManager(cfg.GetAPIConfig())
+	cm.SetRoundTripper(cfg.GetRoundTripper(cm, listener.New()))
+
+	fmt.Println("Measuring, it takes a few seconds...")
+	result := transfer.RunBenchmark(context.Uint("messages"), messageSize, dir, cm.MeasureUploadSpeed)
+	fmt.Println(result)
+
+	return nil
+}
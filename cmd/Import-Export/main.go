@@ -19,17 +19,22 @@ package main
 
 import (
 	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ProtonMail/proton-bridge/internal/cmd"
 	"github.com/ProtonMail/proton-bridge/internal/events"
 	"github.com/ProtonMail/proton-bridge/internal/frontend"
 	"github.com/ProtonMail/proton-bridge/internal/importexport"
+	"github.com/ProtonMail/proton-bridge/internal/transfer"
 	"github.com/ProtonMail/proton-bridge/internal/updates"
 	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
 	"github.com/ProtonMail/proton-bridge/pkg/constants"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/ProtonMail/proton-bridge/pkg/transferplugin"
 	"github.com/allan-simon/go-singleinstance"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -48,7 +53,41 @@ func main() {
 	cmd.Main(
 		"ProtonMail Import-Export",
 		"ProtonMail Import-Export app",
-		nil,
+		[]cli.Flag{
+			cli.StringFlag{
+				Name:  "profile",
+				Value: transfer.ThrottlingNormal.Name,
+				Usage: "Set how hard the transfers push the API (one of " + strings.Join(transfer.ThrottlingProfileNames(), ", ") + "); it gets gentler automatically when rate limited"},
+			cli.IntFlag{
+				Name:  "concurrency",
+				Value: transfer.DefaultConcurrency,
+				Usage: "Set the number of workers uploading messages in parallel; messages of one folder are uploaded in order by one worker, so more workers speed up only imports of several folders"},
+			cli.StringFlag{
+				Name:  "max-bandwidth",
+				Usage: "Limit the upload speed of all transfers together, e.g. 2MB/s (default unlimited)"},
+			cli.StringFlag{
+				Name:  "split-size",
+				Usage: "Start a new numbered MBOX file of exported folder once it would exceed the size, e.g. 2GB (default no split)"},
+			cli.IntFlag{
+				Name:  "split-count",
+				Usage: "Start a new numbered MBOX file of exported folder after the number of messages (default no split)"},
+			cli.BoolFlag{
+				Name:  "resume",
+				Usage: "Continue unfinished transfers and skip already transferred messages without asking"},
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Only report messages, sizes and target mailboxes of transfers without uploading anything"},
+			cli.StringFlag{
+				Name:  "progress-format",
+				Value: "text",
+				Usage: "Print progress of transfers as text or as JSON lines (json) for tools wrapping the app"},
+			cli.StringFlag{
+				Name:  "job",
+				Usage: "Run import or export described by the JSON `FILE` without any frontend and exit"},
+		},
+		[]cli.Command{
+			benchmarkCommand,
+		},
 		run,
 	)
 }
@@ -87,6 +126,36 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 		return cli.NewExitError("Unknown argument", 4)
 	}
 
+	throttling, err := transfer.GetThrottlingProfile(context.GlobalString("profile"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 4)
+	}
+
+	concurrency := context.GlobalInt("concurrency")
+	if concurrency < 1 {
+		return cli.NewExitError("Concurrency must be at least 1", 4)
+	}
+
+	maxBandwidth, err := transfer.ParseBandwidth(context.GlobalString("max-bandwidth"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 4)
+	}
+
+	progressFormat := context.GlobalString("progress-format")
+	if progressFormat != "text" && progressFormat != "json" {
+		return cli.NewExitError("Progress format must be text or json", 4)
+	}
+
+	splitSize, err := transfer.ParseSize(context.GlobalString("split-size"))
+	if err != nil {
+		return cli.NewExitError(err.Error(), 4)
+	}
+
+	splitCount := context.GlobalInt("split-count")
+	if splitCount < 0 {
+		return cli.NewExitError("Split count cannot be negative", 4)
+	}
+
 	// It's safe to get version JSON file even when other instance is running.
 	// (thus we put it before check of presence of other Import-Export instance).
 	updates := updates.NewImportExport(cfg.GetUpdateDir())
@@ -120,6 +189,7 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 	eventListener := listener.New()
 	events.SetupEvents(eventListener)
 
+	cmd.SetupKeychain(context, cfg, "")
 	credentialsStore, credentialsError := credentials.NewStore(appNameDash)
 	if credentialsError != nil {
 		log.Error("Could not get credentials store: ", credentialsError)
@@ -127,16 +197,78 @@ func run(context *cli.Context) (contextError error) { // nolint[funlen]
 
 	cm := pmapi.NewClientManager(cfg.GetAPIConfig())
 
+	// Trusted TLS pins can be rotated without a new release.
+	if loaded, err := updates.LoadTLSPinsOverride(cfg.GetTLSPinsPath()); err != nil {
+		log.WithError(err).Error("Cannot load TLS pins override")
+	} else if loaded {
+		eventListener.Emit(events.TLSPinsOverrideEvent, cfg.GetTLSPinsPath())
+	} else {
+		if err := updates.LoadStoredTLSPins(cfg.GetSignedTLSPinsPath()); err != nil {
+			log.WithError(err).Warn("Cannot load stored TLS pins")
+		}
+		go func() {
+			defer panicHandler.HandlePanic()
+			if err := updates.UpdateTLSPins(cfg.GetSignedTLSPinsPath()); err != nil {
+				log.WithError(err).Warn("Cannot update TLS pins")
+			}
+		}()
+	}
+
 	// Different build types have different roundtrippers (e.g. we want to enable
 	// TLS fingerprint checks in production builds). GetRoundTripper has a different
 	// implementation depending on whether build flag pmapi_prod is used or not.
 	cm.SetRoundTripper(cfg.GetRoundTripper(cm, eventListener))
 
+	// Frontends show for how long the server paused the communication.
+	cm.SetRateLimitNotifier(func(retryAfter time.Duration) {
+		eventListener.Emit(events.RateLimitEvent, strconv.Itoa(int(retryAfter.Seconds())))
+	})
+
+	// Wrong local clock breaks authentication and TLS; users are warned with the measured offset.
+	cm.SetClockSkewNotifier(func(skew time.Duration) {
+		eventListener.Emit(events.ClockSkewEvent, strconv.Itoa(int(skew.Seconds())))
+	})
+
 	importexportInstance := importexport.New(cfg, panicHandler, eventListener, cm, credentialsStore)
+	importexportInstance.SetThrottlingProfile(throttling)
+	importexportInstance.SetConcurrency(concurrency)
+	importexportInstance.SetResume(context.GlobalBool("resume"))
+	importexportInstance.SetDryRun(context.GlobalBool("dry-run"))
+	importexportInstance.SetJSONProgress(progressFormat == "json")
+	importexportInstance.SetMBOXSplit(splitSize, uint(splitCount))
+	transfer.SetMaxBandwidth(maxBandwidth)
+
+	// Progress of transfers is kept across updates which clear the cache.
+	transfer.SetCheckpointsPath(cfg.GetCheckpointsPath())
+
+	// Plugins register their providers when loaded, before any transfer starts.
+	if loaded, err := transferplugin.Load(cfg.GetPluginsDir()); err != nil {
+		log.WithError(err).Error("Cannot load some transfer plugins")
+	} else if len(loaded) > 0 {
+		log.WithField("plugins", transferplugin.Names()).Info("Transfer plugins loaded")
+	}
+
+	// Startup check of the connection also measures the local clock skew.
+	go func() {
+		defer panicHandler.HandlePanic()
+		_ = cm.CheckConnection()
+	}()
+
+	// Jobs run without any frontend, e.g. from cron, and report failure by exit code.
+	if specPath := context.GlobalString("job"); specPath != "" {
+		log.WithField("spec", specPath).Info("Running job")
+		if err := frontend.NewImportExportJob(specPath, panicHandler, importexportInstance).Loop(credentialsError); err != nil {
+			log.WithError(err).Error("Job failed")
+			return cli.NewExitError("Job failed: "+err.Error(), 1)
+		}
+		return nil
+	}
 
 	// Decide about frontend mode before initializing rest of import-export.
 	var frontendMode string
 	switch {
+	case context.GlobalBool("plain"):
+		frontendMode = "cli-plain"
 	case context.GlobalBool("cli"):
 		frontendMode = "cli"
 	default:
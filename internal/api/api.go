@@ -19,12 +19,16 @@
 //
 // API endpoints:
 //  * /focus, see focusHandler
+//  * /reload, see reloadHandler
+//  * /health, see healthHandler
+//  * /metrics, see metricsHandler
 package api
 
 import (
 	"crypto/tls"
-	"fmt"
+	"net"
 	"net/http"
+	"strconv"
 
 	"github.com/ProtonMail/proton-bridge/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/internal/events"
@@ -46,10 +50,11 @@ type apiServer struct {
 	certPath      string
 	keyPath       string
 	eventListener listener.Listener
+	bridge        bridgeStatus
 }
 
 // NewAPIServer returns prepared API server struct.
-func NewAPIServer(pref *config.Preferences, tls *tls.Config, certPath, keyPath string, eventListener listener.Listener) *apiServer { //nolint[golint]
+func NewAPIServer(pref *config.Preferences, tls *tls.Config, certPath, keyPath string, eventListener listener.Listener, bridgeInstance bridgeStatus) *apiServer { //nolint[golint]
 	return &apiServer{
 		host:          bridge.Host,
 		pref:          pref,
@@ -57,6 +62,7 @@ func NewAPIServer(pref *config.Preferences, tls *tls.Config, certPath, keyPath s
 		certPath:      certPath,
 		keyPath:       keyPath,
 		eventListener: eventListener,
+		bridge:        bridgeInstance,
 	}
 }
 
@@ -64,6 +70,9 @@ func NewAPIServer(pref *config.Preferences, tls *tls.Config, certPath, keyPath s
 func (api *apiServer) ListenAndServe() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/focus", wrapper(api, focusHandler))
+	mux.HandleFunc("/reload", wrapper(api, reloadHandler))
+	mux.HandleFunc("/health", wrapper(api, healthHandler))
+	mux.HandleFunc("/metrics", wrapper(api, metricsHandler))
 
 	addr := api.getAddress()
 	server := &http.Server{
@@ -91,5 +100,5 @@ func (api *apiServer) getAddress() string {
 }
 
 func getAPIAddress(host string, port int) string {
-	return fmt.Sprintf("%s:%d", host, port)
+	return net.JoinHostPort(host, strconv.Itoa(port))
 }
@@ -33,6 +33,7 @@ type handlerContext struct {
 	req           *http.Request
 	resp          http.ResponseWriter
 	eventListener listener.Listener
+	bridge        bridgeStatus
 }
 
 func wrapper(api *apiServer, callback handler) httpHandler {
@@ -41,6 +42,7 @@ func wrapper(api *apiServer, callback handler) httpHandler {
 			req:           req,
 			resp:          w,
 			eventListener: api.eventListener,
+			bridge:        api.bridge,
 		}
 		err := callback(ctx)
 		if err != nil {
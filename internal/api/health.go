@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/internal/users"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+)
+
+// Health statuses of the running instance.
+const (
+	HealthOK       = "healthy"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+)
+
+// healthCheckTimeout limits how long the other instance is waited for.
+const healthCheckTimeout = 10 * time.Second
+
+type bridgeStatus interface {
+	CheckConnection() error
+	GetUsers() []*users.User
+	GetRequestStats() []pmapi.EndpointStats
+}
+
+// Health describes state of the running instance.
+type Health struct {
+	Status   string
+	Problems []string `json:",omitempty"`
+}
+
+// healthHandler reports whether bridge can reach the servers and whether all
+// accounts are connected. Any problem makes the instance degraded.
+func healthHandler(ctx handlerContext) error {
+	health := Health{Status: HealthOK}
+
+	if err := ctx.bridge.CheckConnection(); err != nil {
+		health.Problems = append(health.Problems, "no connection to server: "+err.Error())
+	}
+
+	for _, user := range ctx.bridge.GetUsers() {
+		if !user.IsConnected() {
+			health.Problems = append(health.Problems, "account "+user.Username()+" is disconnected")
+			continue
+		}
+		if status, err := user.GetEventLoopStatus(); err == nil && status.IsBehind() {
+			health.Problems = append(health.Problems, "account "+user.Username()+" is behind with processing of changes")
+		}
+	}
+
+	if len(health.Problems) != 0 {
+		health.Status = HealthDegraded
+	}
+
+	ctx.resp.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(ctx.resp).Encode(health)
+}
+
+// GetHealth asks the running instance about its health. Error means
+// the instance is not running or does not respond.
+func GetHealth(port int, tls *tls.Config) (*Health, error) {
+	transport := &http.Transport{TLSClientConfig: tls}
+	client := &http.Client{Transport: transport, Timeout: healthCheckTimeout}
+
+	addr := getAPIAddress(bridge.Host, port)
+	resp, err := client.Get("https://" + addr + "/health")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint[errcheck]
+
+	health := &Health{}
+	if err := json.NewDecoder(resp.Body).Decode(health); err != nil {
+		return nil, err
+	}
+	return health, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"encoding/json"
+)
+
+// EndpointMetrics are request counters of one API endpoint.
+type EndpointMetrics struct {
+	Endpoint         string
+	Requests         int
+	Errors           int
+	Retries          int
+	AverageLatencyMs int64
+	MaxLatencyMs     int64
+}
+
+// metricsHandler returns latency, error and retry counters of requests to
+// the ProtonMail API made since the start, so it is possible to tell whether
+// the slowness is caused by the API.
+func metricsHandler(ctx handlerContext) error {
+	metrics := []EndpointMetrics{}
+	for _, stats := range ctx.bridge.GetRequestStats() {
+		metrics = append(metrics, EndpointMetrics{
+			Endpoint:         stats.Endpoint,
+			Requests:         stats.Requests,
+			Errors:           stats.Errors,
+			Retries:          stats.Retries,
+			AverageLatencyMs: stats.AverageLatency().Milliseconds(),
+			MaxLatencyMs:     stats.MaxLatency.Milliseconds(),
+		})
+	}
+
+	ctx.resp.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(ctx.resp).Encode(metrics)
+}
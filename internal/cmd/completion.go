@@ -0,0 +1,224 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// completionNode is one level of command line: the app itself or a command.
+type completionNode struct {
+	path        []string // Names of commands leading to this node.
+	flags       []cli.Flag
+	subcommands []cli.Command
+	arguments   []string // Fixed choices of argument, if any.
+}
+
+// newCompletionCommand returns command printing completion scripts for app.
+// Commands of the interactive shell (--cli) are not part of the scripts,
+// the shell completes them itself by Tab.
+func newCompletionCommand(app *cli.App) cli.Command {
+	return cli.Command{
+		Name:      "completion",
+		Usage:     "Print shell completion script (bash, zsh or fish)",
+		ArgsUsage: "bash|zsh|fish",
+		Action: func(context *cli.Context) error {
+			name := filepath.Base(os.Args[0])
+			switch context.Args().First() {
+			case "bash":
+				writeBashCompletion(os.Stdout, name, app)
+			case "zsh":
+				// zsh is able to use bash completion scripts directly.
+				fmt.Fprintln(os.Stdout, "autoload -U +X bashcompinit && bashcompinit")
+				writeBashCompletion(os.Stdout, name, app)
+			case "fish":
+				writeFishCompletion(os.Stdout, name, app)
+			default:
+				return cli.NewExitError("Choose one of bash, zsh or fish", 4)
+			}
+			return nil
+		},
+	}
+}
+
+func getCompletionNodes(app *cli.App) []completionNode {
+	// App is already set up, help and version flags and help command
+	// are part of flags and commands.
+	nodes := []completionNode{{
+		flags:       app.Flags,
+		subcommands: app.Commands,
+	}}
+
+	var walk func(path []string, commands []cli.Command)
+	walk = func(path []string, commands []cli.Command) {
+		for _, command := range commands {
+			commandPath := append(append([]string{}, path...), command.Name)
+			nodes = append(nodes, completionNode{
+				path:        commandPath,
+				flags:       append([]cli.Flag{cli.HelpFlag}, command.Flags...),
+				subcommands: command.Subcommands,
+				arguments:   getArgumentChoices(command.ArgsUsage),
+			})
+			walk(commandPath, command.Subcommands)
+		}
+	}
+	walk(nil, app.Commands)
+
+	return nodes
+}
+
+// getArgumentChoices returns choices from usage such as `bash|zsh|fish`.
+func getArgumentChoices(argsUsage string) []string {
+	if !strings.Contains(argsUsage, "|") || strings.Contains(argsUsage, " ") {
+		return nil
+	}
+	return strings.Split(argsUsage, "|")
+}
+
+// flagNames returns long and short names of the flag, e.g. `log-level` and `l`.
+func flagNames(flag cli.Flag) (names []string) {
+	for _, name := range strings.Split(flag.GetName(), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+func flagWithDashes(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+func flagTakesValue(flag cli.Flag) bool {
+	_, isBool := flag.(cli.BoolFlag)
+	return !isBool
+}
+
+func flagUsage(flag cli.Flag) string {
+	switch f := flag.(type) {
+	case cli.BoolFlag:
+		return f.Usage
+	case cli.StringFlag:
+		return f.Usage
+	case cli.IntFlag:
+		return f.Usage
+	}
+	return ""
+}
+
+func writeBashCompletion(w io.Writer, name string, app *cli.App) {
+	function := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(name)
+	nodes := getCompletionNodes(app)
+
+	valueFlagSet := map[string]bool{}
+	for _, node := range nodes {
+		for _, flag := range node.flags {
+			if !flagTakesValue(flag) {
+				continue
+			}
+			for _, flagName := range flagNames(flag) {
+				valueFlagSet[flagWithDashes(flagName)] = true
+			}
+		}
+	}
+	valueFlags := []string{}
+	for valueFlag := range valueFlagSet {
+		valueFlags = append(valueFlags, valueFlag)
+	}
+	sort.Strings(valueFlags)
+
+	fmt.Fprintf(w, "%s() {\n", function)
+	fmt.Fprintf(w, "\tlocal cur cmd i\n")
+	fmt.Fprintf(w, "\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	fmt.Fprintf(w, "\tcmd=\"\"\n")
+	fmt.Fprintf(w, "\tfor ((i = 1; i < COMP_CWORD; i++)); do\n")
+	fmt.Fprintf(w, "\t\tcase \"${COMP_WORDS[i]}\" in\n")
+	if len(valueFlags) != 0 {
+		fmt.Fprintf(w, "\t\t%s) ((i++)) ;;\n", strings.Join(valueFlags, "|"))
+	}
+	fmt.Fprintf(w, "\t\t-*) ;;\n")
+	fmt.Fprintf(w, "\t\t*) cmd=\"${cmd} ${COMP_WORDS[i]}\" ;;\n")
+	fmt.Fprintf(w, "\t\tesac\n")
+	fmt.Fprintf(w, "\tdone\n")
+	fmt.Fprintf(w, "\tcase \"${cmd# }\" in\n")
+	for _, node := range nodes {
+		words := append([]string{}, node.arguments...)
+		for _, command := range node.subcommands {
+			words = append(words, command.Name)
+		}
+		for _, flag := range node.flags {
+			for _, flagName := range flagNames(flag) {
+				words = append(words, flagWithDashes(flagName))
+			}
+		}
+		fmt.Fprintf(w, "\t\"%s\") COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", strings.Join(node.path, " "), strings.Join(words, " "))
+	}
+	fmt.Fprintf(w, "\t*) COMPREPLY=($(compgen -f -- \"$cur\")) ;;\n")
+	fmt.Fprintf(w, "\tesac\n")
+	fmt.Fprintf(w, "}\n")
+	fmt.Fprintf(w, "complete -F %s %s\n", function, name)
+}
+
+func writeFishCompletion(w io.Writer, name string, app *cli.App) {
+	for _, node := range getCompletionNodes(app) {
+		condition := "__fish_use_subcommand"
+		if len(node.path) != 0 {
+			condition = "__fish_seen_subcommand_from " + node.path[len(node.path)-1]
+		}
+
+		for _, command := range node.subcommands {
+			fmt.Fprintf(w, "complete -c %s -f -n '%s' -a %s -d %s\n", name, condition, command.Name, fishQuote(command.Usage))
+		}
+
+		if len(node.arguments) != 0 {
+			fmt.Fprintf(w, "complete -c %s -f -n '%s' -a '%s'\n", name, condition, strings.Join(node.arguments, " "))
+		}
+
+		for _, flag := range node.flags {
+			line := fmt.Sprintf("complete -c %s -n '%s'", name, condition)
+			for _, flagName := range flagNames(flag) {
+				if len(flagName) == 1 {
+					line += " -s " + flagName
+				} else {
+					line += " -l " + flagName
+				}
+			}
+			if flagTakesValue(flag) {
+				line += " -r"
+			}
+			if usage := flagUsage(flag); usage != "" {
+				line += " -d " + fishQuote(usage)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
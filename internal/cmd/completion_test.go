@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func newTestCompletionApp() *cli.App {
+	app := newApp("test", "test app", []cli.Flag{cli.BoolFlag{Name: "extra", Usage: "Extra flag"}}, []cli.Command{{
+		Name: "parent",
+		Subcommands: []cli.Command{{
+			Name:  "child",
+			Flags: []cli.Flag{cli.StringFlag{Name: "value, x"}},
+		}},
+	}}, nil)
+	app.Setup()
+	return app
+}
+
+func TestBashCompletion(t *testing.T) {
+	b := &bytes.Buffer{}
+	writeBashCompletion(b, "test-app", newTestCompletionApp())
+
+	script := b.String()
+	require.Contains(t, script, "complete -F _test_app test-app")
+	require.Contains(t, script, "--log-level|--value|--version-json|-g|-l|-x) ((i++))")
+	require.Contains(t, script, `"parent child") COMPREPLY=($(compgen -W "--help -h --value -x" -- "$cur"))`)
+	require.Contains(t, script, `"completion") COMPREPLY=($(compgen -W "bash zsh fish --help -h" -- "$cur"))`)
+}
+
+func TestFishCompletion(t *testing.T) {
+	b := &bytes.Buffer{}
+	writeFishCompletion(b, "test-app", newTestCompletionApp())
+
+	script := b.String()
+	require.Contains(t, script, "complete -c test-app -n '__fish_use_subcommand' -l extra -d 'Extra flag'\n")
+	require.Contains(t, script, "complete -c test-app -f -n '__fish_seen_subcommand_from parent' -a child -d ''\n")
+	require.Contains(t, script, "complete -c test-app -n '__fish_seen_subcommand_from child' -l value -s x -r\n")
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/keychain"
+	"github.com/urfave/cli"
+)
+
+// Environment variables configuring the keychain. The passphrase of the file
+// keychain is never accepted as a flag so it does not show in process list.
+const (
+	keychainEnvVar           = "PROTONMAIL_KEYCHAIN"
+	keychainKeyFileEnvVar    = "PROTONMAIL_KEYCHAIN_KEY_FILE"
+	keychainPassphraseEnvVar = "PROTONMAIL_KEYCHAIN_PASSPHRASE"
+)
+
+// SetupKeychain selects the keychain helper for the credentials store.
+// The --keychain flag wins over the preferred helper from preferences.
+// The file keychain is configured always so it can be used as a fallback
+// on systems without any native keychain.
+func SetupKeychain(context *cli.Context, cfg *config.Config, preferred string) {
+	if helper := context.GlobalString("keychain"); helper != "" {
+		preferred = helper
+	}
+	keychain.SetPreferredHelper(preferred)
+
+	keychain.SetFileKeychain(keychain.FileKeychainOptions{
+		Path:       cfg.GetKeychainPath(),
+		KeyFile:    context.GlobalString("keychain-key-file"),
+		Passphrase: os.Getenv(keychainPassphraseEnvVar),
+	})
+}
@@ -22,6 +22,7 @@ import (
 	"runtime"
 
 	"github.com/ProtonMail/proton-bridge/pkg/constants"
+	"github.com/ProtonMail/proton-bridge/pkg/keychain"
 	"github.com/getsentry/raven-go"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -37,6 +38,9 @@ var (
 		cli.BoolFlag{
 			Name:  "cli, c",
 			Usage: "Use command line interface"},
+		cli.BoolFlag{
+			Name:  "plain",
+			Usage: "Use command line interface with plain linear output without colors, e.g. for screen readers (implies --cli)"},
 		cli.StringFlag{
 			Name:  "version-json, g",
 			Usage: "Generate json version file"},
@@ -46,11 +50,20 @@ var (
 		cli.BoolFlag{
 			Name:  "cpu-prof, p",
 			Usage: "Generate CPU profile"},
+		cli.StringFlag{
+			Name:   "keychain",
+			EnvVar: keychainEnvVar,
+			Usage:  "Store credentials in the keychain (" + keychain.PassHelper + ", " + keychain.SecretServiceHelper + " or " + keychain.FileHelper + ") instead of the default one"},
+		cli.StringFlag{
+			Name:   "keychain-key-file",
+			EnvVar: keychainKeyFileEnvVar,
+			Usage:  "Protect the file keychain by the content of `FILE` instead of the passphrase from " + keychainPassphraseEnvVar},
 	}
 )
 
 // Main sets up Sentry, filters out unwanted args, creates app and runs it.
-func Main(appName, usage string, extraFlags []cli.Flag, run func(*cli.Context) error) {
+// Commands are optional subcommands; without any, run is called.
+func Main(appName, usage string, extraFlags []cli.Flag, commands []cli.Command, run func(*cli.Context) error) {
 	if err := raven.SetDSN(constants.DSNSentry); err != nil {
 		log.WithError(err).Errorln("Can not setup sentry DSN")
 	}
@@ -59,7 +72,7 @@ func Main(appName, usage string, extraFlags []cli.Flag, run func(*cli.Context) e
 	filterProcessSerialNumberFromArgs()
 	filterRestartNumberFromArgs()
 
-	app := newApp(appName, usage, extraFlags, run)
+	app := newApp(appName, usage, extraFlags, commands, run)
 
 	logrus.SetLevel(logrus.InfoLevel)
 	log.WithField("version", constants.Version).
@@ -75,12 +88,13 @@ func Main(appName, usage string, extraFlags []cli.Flag, run func(*cli.Context) e
 	}
 }
 
-func newApp(appName, usage string, extraFlags []cli.Flag, run func(*cli.Context) error) *cli.App {
+func newApp(appName, usage string, extraFlags []cli.Flag, commands []cli.Command, run func(*cli.Context) error) *cli.App {
 	app := cli.NewApp()
 	app.Name = appName
 	app.Usage = usage
 	app.Version = constants.BuildVersion
-	app.Flags = append(baseFlags, extraFlags...) //nolint[gocritic]
+	app.Flags = append(baseFlags, extraFlags...)               //nolint[gocritic]
+	app.Commands = append(commands, newCompletionCommand(app)) //nolint[gocritic]
 	app.Action = run
 	return app
 }
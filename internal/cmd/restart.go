@@ -23,6 +23,8 @@ import (
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/ProtonMail/proton-bridge/internal/frontend"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
@@ -32,6 +34,9 @@ import (
 const (
 	// After how many crashes app gives up starting.
 	maxAllowedCrashes = 10
+
+	// How long the app waits for the panic callback before it restarts.
+	onPanicTimeout = 30 * time.Second
 )
 
 var (
@@ -88,6 +93,18 @@ type PanicHandler struct {
 	AppName string
 	Config  *config.Config
 	Err     *error // Pointer to error of cli action.
+
+	onPanic     func()
+	onPanicLock sync.Mutex
+}
+
+// SetOnPanic sets callback called before the app restarts after panic,
+// e.g. to send messages which would be lost otherwise.
+func (ph *PanicHandler) SetOnPanic(onPanic func()) {
+	ph.onPanicLock.Lock()
+	defer ph.onPanicLock.Unlock()
+
+	ph.onPanic = onPanic
 }
 
 // HandlePanic should be called in defer to ensure restart of app after error.
@@ -98,6 +115,7 @@ func (ph *PanicHandler) HandlePanic() {
 	}
 
 	config.HandlePanic(ph.Config, fmt.Sprintf("Recover: %v", r))
+	ph.runOnPanic()
 	frontend.HandlePanic(ph.AppName)
 
 	*ph.Err = cli.NewExitError("Panic and restart", 255)
@@ -106,3 +124,32 @@ func (ph *PanicHandler) HandlePanic() {
 	RestartApp()
 	os.Exit(255)
 }
+
+// runOnPanic calls the callback but does not let it block the restart,
+// the app is in unknown state and the callback can hang or panic too.
+func (ph *PanicHandler) runOnPanic() {
+	ph.onPanicLock.Lock()
+	onPanic := ph.onPanic
+	ph.onPanicLock.Unlock()
+
+	if onPanic == nil {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer func() {
+			if r := recover(); r != nil {
+				log.Error("Panic callback failed: ", r)
+			}
+		}()
+		onPanic()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(onPanicTimeout):
+		log.Error("Panic callback timed out")
+	}
+}
@@ -21,14 +21,17 @@ package events
 import (
 	"time"
 
-	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	listenerPkg "github.com/ProtonMail/proton-bridge/pkg/listener"
 )
 
 // Constants of events used by the event listener in bridge.
 const (
 	ErrorEvent                   = "error"
 	CloseConnectionEvent         = "closeConnection"
+	CloseIMAPConnectionEvent     = "closeIMAPConnection"
+	CloseSMTPConnectionEvent     = "closeSMTPConnection"
 	LogoutEvent                  = "logout"
+	ReloginRequiredEvent         = "reloginRequired"
 	AddressChangedEvent          = "addressChanged"
 	AddressChangedLogoutEvent    = "addressChangedLogout"
 	UserRefreshEvent             = "userRefresh"
@@ -41,14 +44,39 @@ const (
 	UpgradeApplicationEvent      = "upgradeApplication"
 	TLSCertIssue                 = "tlsCertPinningIssue"
 	IMAPTLSBadCert               = "imapTLSBadCert"
+	ReloadPreferencesEvent       = "reloadPreferences"
+	LoginLockoutEvent            = "loginLockout"
+	NewMailEvent                 = "newMail"
+	RateLimitEvent               = "rateLimit"
+	SendProgressEvent            = "sendProgress"
+	SendFailedEvent              = "sendFailed"
+	CrossAccountCopyEvent        = "crossAccountCopy"
+	ClockSkewEvent               = "clockSkew"
+	RecipientKeyChangedEvent     = "recipientKeyChanged"
+	SenderDomainWarningEvent     = "senderDomainWarning"
+	TLSPinsOverrideEvent         = "tlsPinsOverride"
 
 	// LogoutEventTimeout is the minimum time to permit between logout events being sent.
 	LogoutEventTimeout = 3 * time.Minute
+
+	// NewMailEventTimeout is the minimum time between new mail events for the same address.
+	NewMailEventTimeout = 1 * time.Minute
 )
 
 // SetupEvents specific to event type and data.
-func SetupEvents(listener listener.Listener) {
+func SetupEvents(listener listenerPkg.Listener) {
 	listener.SetLimit(LogoutEvent, LogoutEventTimeout)
+	listener.SetLimit(ReloginRequiredEvent, LogoutEventTimeout)
+	listener.SetLimit(NewMailEvent, NewMailEventTimeout)
 	listener.SetBuffer(TLSCertIssue)
 	listener.SetBuffer(ErrorEvent)
+	listener.SetBuffer(ClockSkewEvent)
+	listener.SetBuffer(TLSPinsOverrideEvent)
+
+	// Logouts require user action, frontend must not miss them even when
+	// channels are congested by sync traffic.
+	listener.SetPriority(LogoutEvent, listenerPkg.PriorityCritical)
+	listener.SetPriority(ReloginRequiredEvent, listenerPkg.PriorityCritical)
+	listener.SetPriority(AddressChangedLogoutEvent, listenerPkg.PriorityCritical)
+	listener.SetPriority(UpgradeApplicationEvent, listenerPkg.PriorityHigh)
 }
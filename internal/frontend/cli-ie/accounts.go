@@ -20,6 +20,7 @@ package cliie
 import (
 	"strings"
 
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	"github.com/abiosoft/ishell"
 )
 
@@ -68,6 +69,9 @@ func (f *frontendCLI) loginAccount(c *ishell.Context) { // nolint[funlen]
 
 	f.Println("Authenticating ... ")
 	client, auth, err := f.ie.Login(loginName, password)
+	if hvErr, ok := err.(*pmapi.ErrHumanVerificationRequired); ok {
+		client, auth, err = f.loginWithHumanVerification(c, loginName, password, hvErr)
+	}
 	if err != nil {
 		f.processAPIError(err)
 		return
@@ -151,3 +155,21 @@ func (f *frontendCLI) deleteAccounts(c *ishell.Context) {
 	}
 	c.Println("Keychain cleared")
 }
+
+// loginWithHumanVerification asks the user to pass the verification in a browser
+// and repeats the login with the token obtained there.
+func (f *frontendCLI) loginWithHumanVerification(c *ishell.Context, loginName, password string, hvErr *pmapi.ErrHumanVerificationRequired) (pmapi.Client, *pmapi.Auth, error) {
+	f.Println("Server requires human verification. Open the following address in a browser,")
+	f.Println("finish the verification and paste the token you get here.")
+	f.Println("")
+	f.Println(hvErr.VerificationURL())
+	f.Println("")
+
+	token := f.readStringInAttempts("Verification token", c.ReadLine, isNotEmpty)
+	if token == "" {
+		return nil, nil, hvErr
+	}
+
+	f.Println("Authenticating ... ")
+	return f.ie.LoginWithHumanVerification(loginName, password, hvErr.TokenType(), token)
+}
@@ -21,6 +21,7 @@ package cliie
 import (
 	"github.com/ProtonMail/proton-bridge/internal/events"
 	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
+	"github.com/ProtonMail/proton-bridge/internal/transfer"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
 
@@ -35,11 +36,27 @@ var (
 type frontendCLI struct {
 	*ishell.Shell
 
+	panicHandler  types.PanicHandler
 	config        *config.Config
 	eventListener listener.Listener
 	updates       types.Updater
 	ie            types.ImportExporter
 
+	// progress of the last started transfer which runs in the background.
+	progress *transfer.Progress
+
+	// nameNormalization is applied to names of folders created during import.
+	nameNormalization transfer.NameNormalization
+
+	// dateLayout organizes exported EML files into YYYY/MM subfolders.
+	dateLayout bool
+
+	// checksums enables recording and verification of message hashes.
+	checksums bool
+
+	// dedup skips imported messages which are already in the account.
+	dedup bool
+
 	appRestart bool
 }
 
@@ -50,15 +67,19 @@ func New( //nolint[funlen]
 	eventListener listener.Listener,
 	updates types.Updater,
 	ie types.ImportExporter,
+	plain bool,
 ) *frontendCLI { //nolint[golint]
 	fe := &frontendCLI{
 		Shell: ishell.New(),
 
+		panicHandler:  panicHandler,
 		config:        config,
 		eventListener: eventListener,
 		updates:       updates,
 		ie:            ie,
 
+		nameNormalization: transfer.NameNormalizationStrip,
+
 		appRestart: false,
 	}
 
@@ -140,7 +161,7 @@ func New( //nolint[funlen]
 		Aliases: []string{"imp"},
 	}
 	importCmd.AddCmd(&ishell.Cmd{Name: "local",
-		Help:    "import local messages. (aliases: loc)",
+		Help:    "import local messages from EML, MBOX, maildir or PST files. (aliases: loc)",
 		Func:    fe.noAccountWrapper(fe.importLocalMessages),
 		Aliases: []string{"loc"},
 	})
@@ -149,6 +170,19 @@ func New( //nolint[funlen]
 		Func:    fe.noAccountWrapper(fe.importRemoteMessages),
 		Aliases: []string{"rem"},
 	})
+	importCmd.AddCmd(&ishell.Cmd{Name: "proton",
+		Help:    "import messages from another logged in ProtonMail account. Use index or account name of the target as parameter. (alias: pm)",
+		Func:    fe.noAccountWrapper(fe.importProtonMessages),
+		Aliases: []string{"pm"},
+	})
+	importCmd.AddCmd(&ishell.Cmd{Name: "folder-names",
+		Help: "set how names of imported folders breaking ProtonMail naming rules are changed: none, strip (default) or transliterate. (alias: names)",
+		Func: fe.setNameNormalization,
+		Completer: func([]string) []string {
+			return []string{"none", "strip", "transliterate"}
+		},
+		Aliases: []string{"names"},
+	})
 	fe.AddCmd(importCmd)
 
 	exportCmd := &ishell.Cmd{Name: "export",
@@ -163,8 +197,92 @@ func New( //nolint[funlen]
 		Help: "export messages to mbox files.",
 		Func: fe.noAccountWrapper(fe.exportMessagesToMBOX),
 	})
+	exportCmd.AddCmd(&ishell.Cmd{Name: "maildir",
+		Help: "export messages to maildir folders (cur, new and tmp) used by dovecot or offlineimap.",
+		Func: fe.noAccountWrapper(fe.exportMessagesToMaildir),
+	})
+	exportCmd.AddCmd(&ishell.Cmd{Name: "archive",
+		Help:    "export messages as eml files to a single zip, tar, tar.zst or tar.gz archive. (alias: zip)",
+		Aliases: []string{"zip"},
+		Func:    fe.noAccountWrapper(fe.exportMessagesToArchive),
+	})
+	exportCmd.AddCmd(&ishell.Cmd{Name: "webdav",
+		Help:    "export messages as eml files to WebDAV server. (alias: dav)",
+		Aliases: []string{"dav"},
+		Func:    fe.noAccountWrapper(fe.exportMessagesToWebDAV),
+	})
+	exportCmd.AddCmd(&ishell.Cmd{Name: "s3",
+		Help: "export messages as eml files to S3-compatible object store.",
+		Func: fe.noAccountWrapper(fe.exportMessagesToS3),
+	})
+	exportCmd.AddCmd(&ishell.Cmd{Name: "contacts",
+		Help:    "export contacts to vcf files (vCard 4.0). (alias: vcf)",
+		Aliases: []string{"vcf"},
+		Func:    fe.noAccountWrapper(fe.exportContacts),
+	})
+	exportCmd.AddCmd(&ishell.Cmd{Name: "calendars",
+		Help:    "export calendars to ics files, one file per calendar. (alias: ics)",
+		Aliases: []string{"ics"},
+		Func:    fe.noAccountWrapper(fe.exportCalendars),
+	})
+	exportCmd.AddCmd(&ishell.Cmd{Name: "layout",
+		Help: "set how exported eml files are organized: flat (default) with all files directly in the folder, or date with YYYY/MM subfolders per folder.",
+		Func: fe.setExportLayout,
+		Completer: func([]string) []string {
+			return []string{"flat", "date"}
+		},
+	})
 	fe.AddCmd(exportCmd)
 
+	transferCmd := &ishell.Cmd{Name: "transfer",
+		Help:    "control running import or export. (alias: tr)",
+		Aliases: []string{"tr"},
+	}
+	transferCmd.AddCmd(&ishell.Cmd{Name: "status",
+		Help:    "print progress of the transfer. (alias: st)",
+		Aliases: []string{"st"},
+		Func:    fe.printTransferStatus,
+	})
+	transferCmd.AddCmd(&ishell.Cmd{Name: "pause",
+		Help: "pause the transfer. It continues where it stopped even after restart of the app.",
+		Func: fe.pauseTransfer,
+	})
+	transferCmd.AddCmd(&ishell.Cmd{Name: "resume",
+		Help:    "resume paused transfer. (alias: continue)",
+		Aliases: []string{"continue"},
+		Func:    fe.resumeTransfer,
+	})
+	transferCmd.AddCmd(&ishell.Cmd{Name: "stop",
+		Help:    "stop the transfer. (alias: cancel)",
+		Aliases: []string{"cancel"},
+		Func:    fe.stopTransfer,
+	})
+	transferCmd.AddCmd(&ishell.Cmd{Name: "checksums",
+		Help: "record canonical hashes of messages during EML export and verify them by downloading messages back after import: on or off (default). (alias: sum)",
+		Func: fe.setChecksums,
+		Completer: func([]string) []string {
+			return []string{"on", "off"}
+		},
+		Aliases: []string{"sum"},
+	})
+	transferCmd.AddCmd(&ishell.Cmd{Name: "dedup",
+		Help: "skip imported messages which are already in the account, matched by Message-ID and date or by content hash of earlier imports: on or off (default). (alias: deduplicate)",
+		Func: fe.setDedup,
+		Completer: func([]string) []string {
+			return []string{"on", "off"}
+		},
+		Aliases: []string{"deduplicate"},
+	})
+	transferCmd.AddCmd(&ishell.Cmd{Name: "bandwidth",
+		Help: "print or set the maximum upload speed of all transfers, e.g. 2MB/s or unlimited. It applies also to the running transfer. (alias: bw)",
+		Func: fe.setMaxBandwidth,
+		Completer: func([]string) []string {
+			return []string{"unlimited", "512KB/s", "1MB/s", "2MB/s", "5MB/s"}
+		},
+		Aliases: []string{"bw"},
+	})
+	fe.AddCmd(transferCmd)
+
 	// System commands.
 	fe.AddCmd(&ishell.Cmd{Name: "restart",
 		Help: "restart the Import-Export app.",
@@ -177,6 +295,8 @@ func New( //nolint[funlen]
 	}()
 	fe.eventListener.RetryEmit(events.TLSCertIssue)
 	fe.eventListener.RetryEmit(events.ErrorEvent)
+	fe.eventListener.RetryEmit(events.ClockSkewEvent)
+	fe.eventListener.RetryEmit(events.TLSPinsOverrideEvent)
 	return fe
 }
 
@@ -184,8 +304,12 @@ func (f *frontendCLI) watchEvents() {
 	errorCh := f.getEventChannel(events.ErrorEvent)
 	internetOffCh := f.getEventChannel(events.InternetOffEvent)
 	internetOnCh := f.getEventChannel(events.InternetOnEvent)
+	rateLimitCh := f.getEventChannel(events.RateLimitEvent)
+	clockSkewCh := f.getEventChannel(events.ClockSkewEvent)
+	tlsPinsOverrideCh := f.getEventChannel(events.TLSPinsOverrideEvent)
 	addressChangedLogoutCh := f.getEventChannel(events.AddressChangedLogoutEvent)
 	logoutCh := f.getEventChannel(events.LogoutEvent)
+	reloginRequiredCh := f.getEventChannel(events.ReloginRequiredEvent)
 	certIssue := f.getEventChannel(events.TLSCertIssue)
 	for {
 		select {
@@ -195,6 +319,12 @@ func (f *frontendCLI) watchEvents() {
 			f.notifyInternetOff()
 		case <-internetOnCh:
 			f.notifyInternetOn()
+		case seconds := <-rateLimitCh:
+			f.notifyRateLimit(seconds)
+		case seconds := <-clockSkewCh:
+			f.notifyClockSkew(seconds)
+		case path := <-tlsPinsOverrideCh:
+			f.notifyTLSPinsOverride(path)
 		case address := <-addressChangedLogoutCh:
 			f.notifyLogout(address)
 		case userID := <-logoutCh:
@@ -203,6 +333,12 @@ func (f *frontendCLI) watchEvents() {
 				return
 			}
 			f.notifyLogout(user.Username())
+		case userID := <-reloginRequiredCh:
+			user, err := f.ie.GetUser(userID)
+			if err != nil {
+				return
+			}
+			f.notifyReloginRequired(user.Username())
 		case <-certIssue:
 			f.notifyCertIssue()
 		}
@@ -18,11 +18,13 @@
 package cliie
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/ProtonMail/proton-bridge/internal/frontend/i18n"
 	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
 	"github.com/ProtonMail/proton-bridge/internal/transfer"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
@@ -72,6 +74,104 @@ func (f *frontendCLI) importRemoteMessages(c *ishell.Context) {
 	f.transfer(t, err, false, true)
 }
 
+func (f *frontendCLI) importProtonMessages(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	if len(f.ie.GetUsers()) < 2 {
+		f.Println(i18n.T("Both accounts have to be logged in. Log in the source account with the login command first."))
+		return
+	}
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	sourceName := f.readStringInAttempts("Source account (index or username)", c.ReadLine, isNotEmpty)
+	if sourceName == "" {
+		return
+	}
+	sourceUser := f.getUserByIndexOrName(sourceName)
+	if sourceUser == nil {
+		f.printAndLogError("No active account with index or name ", sourceName)
+		return
+	}
+
+	t, err := f.ie.GetProtonImporter(sourceUser.GetPrimaryAddress(), user.GetPrimaryAddress())
+	f.transfer(t, err, false, true)
+}
+
+func (f *frontendCLI) setNameNormalization(c *ishell.Context) {
+	if len(c.Args) != 1 {
+		f.Println(i18n.T("Usage: import folder-names none|strip|transliterate"))
+		return
+	}
+
+	normalization, err := transfer.ParseNameNormalization(c.Args[0])
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	f.nameNormalization = normalization
+	f.Println(i18n.Tf("Names of imported folders will be normalized using %s.", c.Args[0]))
+}
+
+func (f *frontendCLI) setChecksums(c *ishell.Context) {
+	if len(c.Args) != 1 || (c.Args[0] != "on" && c.Args[0] != "off") {
+		f.Println(i18n.T("Usage: transfer checksums on|off"))
+		return
+	}
+
+	f.checksums = c.Args[0] == "on"
+	if f.checksums {
+		f.Println(i18n.T("EML exports will record checksums and imports will verify them."))
+	} else {
+		f.Println(i18n.T("Checksums are neither recorded nor verified."))
+	}
+}
+
+func (f *frontendCLI) setDedup(c *ishell.Context) {
+	if len(c.Args) != 1 || (c.Args[0] != "on" && c.Args[0] != "off") {
+		f.Println(i18n.T("Usage: transfer dedup on|off"))
+		return
+	}
+
+	f.dedup = c.Args[0] == "on"
+	if f.dedup {
+		f.Println(i18n.T("Imports will skip messages which are already in the account."))
+	} else {
+		f.Println(i18n.T("Imports will not check for duplicates."))
+	}
+}
+
+func (f *frontendCLI) setMaxBandwidth(c *ishell.Context) {
+	if len(c.Args) == 0 {
+		f.Println(i18n.Tf("Maximum upload speed is %s.", transfer.FormatBandwidth(transfer.GetMaxBandwidth())))
+		return
+	}
+
+	limit, err := transfer.ParseBandwidth(strings.Join(c.Args, " "))
+	if err != nil {
+		f.printAndLogError(err)
+		return
+	}
+
+	transfer.SetMaxBandwidth(limit)
+	f.Println(i18n.Tf("Maximum upload speed set to %s.", transfer.FormatBandwidth(limit)))
+}
+
+func (f *frontendCLI) setExportLayout(c *ishell.Context) {
+	if len(c.Args) != 1 || (c.Args[0] != "flat" && c.Args[0] != "date") {
+		f.Println(i18n.T("Usage: export layout flat|date"))
+		return
+	}
+
+	f.dateLayout = c.Args[0] == "date"
+	f.Println(i18n.Tf("Exported eml files will use %s layout.", c.Args[0]))
+}
+
 func (f *frontendCLI) exportMessagesToEML(c *ishell.Context) {
 	f.ShowPrompt(false)
 	defer f.ShowPrompt(true)
@@ -98,13 +198,146 @@ func (f *frontendCLI) exportMessagesToMBOX(c *ishell.Context) {
 	f.transfer(t, err, true, false)
 }
 
+func (f *frontendCLI) exportMessagesToMaildir(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user, path := f.getUserAndPath(c, true)
+	if user == nil || path == "" {
+		return
+	}
+
+	t, err := f.ie.GetMaildirExporter(user.GetPrimaryAddress(), path)
+	f.transfer(t, err, true, false)
+}
+
+func (f *frontendCLI) exportMessagesToArchive(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	path := f.readStringInAttempts("Path of archive (.zip, .tar, .tar.zst or .tar.gz)", c.ReadLine, transfer.IsArchivePath)
+	if path == "" {
+		return
+	}
+
+	t, err := f.ie.GetArchiveExporter(user.GetPrimaryAddress(), path)
+	f.transfer(t, err, true, false)
+}
+
+func (f *frontendCLI) exportMessagesToWebDAV(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	url := f.readStringInAttempts("WebDAV URL", c.ReadLine, isNotEmpty)
+	if url == "" {
+		return
+	}
+	f.Print(i18n.T("WebDAV username (empty for none)"), ": ")
+	username := c.ReadLine()
+	password := ""
+	if username != "" {
+		f.Print(i18n.T("WebDAV password"), ": ")
+		password = c.ReadPassword()
+	}
+
+	t, err := f.ie.GetWebDAVExporter(user.GetPrimaryAddress(), url, username, password)
+	f.transfer(t, err, true, false)
+}
+
+func (f *frontendCLI) exportMessagesToS3(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	config := transfer.S3Config{}
+	if config.Endpoint = f.readStringInAttempts("S3 endpoint URL", c.ReadLine, isNotEmpty); config.Endpoint == "" {
+		return
+	}
+	if config.Bucket = f.readStringInAttempts("S3 bucket", c.ReadLine, isNotEmpty); config.Bucket == "" {
+		return
+	}
+	f.Print(i18n.T("S3 region (empty for us-east-1)"), ": ")
+	config.Region = c.ReadLine()
+	f.Print(i18n.T("Path in the bucket (empty for root)"), ": ")
+	config.Prefix = c.ReadLine()
+	if config.AccessKey = f.readStringInAttempts("S3 access key", c.ReadLine, isNotEmpty); config.AccessKey == "" {
+		return
+	}
+	if config.SecretKey = f.readStringInAttempts("S3 secret key", c.ReadPassword, isNotEmpty); config.SecretKey == "" {
+		return
+	}
+
+	t, err := f.ie.GetS3Exporter(user.GetPrimaryAddress(), config)
+	f.transfer(t, err, true, false)
+}
+
+func (f *frontendCLI) exportContacts(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	path := f.readStringInAttempts("Path of vCard files", c.ReadLine, isNotEmpty)
+	if path == "" {
+		return
+	}
+
+	exported, failed, err := f.ie.ExportContacts(user.GetPrimaryAddress(), path)
+	if err != nil {
+		f.printAndLogError("Failed to export contacts: ", err)
+	}
+	f.Println(i18n.Tf("Contacts exported: %d, failed: %d", exported, failed))
+}
+
+func (f *frontendCLI) exportCalendars(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	path := f.readStringInAttempts("Path of ICS files", c.ReadLine, isNotEmpty)
+	if path == "" {
+		return
+	}
+
+	exported, failed, err := f.ie.ExportCalendars(user.GetPrimaryAddress(), path)
+	if err != nil {
+		f.printAndLogError("Failed to export calendars: ", err)
+	}
+	f.Println(i18n.Tf("Events exported: %d, failed: %d", exported, failed))
+}
+
 func (f *frontendCLI) getUserAndPath(c *ishell.Context, createPath bool) (types.User, string) {
 	user := f.askUserByIndexOrName(c)
 	if user == nil {
 		return nil, ""
 	}
 
-	path := f.readStringInAttempts("Path of EML and MBOX files", c.ReadLine, isNotEmpty)
+	prompt := "Path of EML and MBOX files"
+	if !createPath {
+		prompt += ", of maildir or of Outlook PST file"
+	}
+	path := f.readStringInAttempts(prompt, c.ReadLine, isNotEmpty)
 	if path == "" {
 		return nil, ""
 	}
@@ -122,9 +355,36 @@ func (f *frontendCLI) transfer(t *transfer.Transfer, err error, askSkipEncrypted
 		return
 	}
 
+	if f.isTransferRunning() {
+		f.Println(i18n.T("Another transfer is running. Stop it first with `transfer stop`."))
+		return
+	}
+
+	if t.HasSavedState() {
+		if f.ie.IsResumeRequested() {
+			f.Println("Continuing unfinished transfer")
+		} else if !f.yesNoQuestion("Continue unfinished transfer (no starts from the beginning)") {
+			t.ResetState()
+		}
+	}
+
 	if askSkipEncrypted {
 		skipEncryptedMessages := f.yesNoQuestion("Skip encrypted messages")
 		t.SetSkipEncryptedMessages(skipEncryptedMessages)
+
+		if f.yesNoQuestion("Filter exported messages") && !f.setTransferFilter(t) {
+			return
+		}
+	}
+
+	t.SetNameNormalization(f.nameNormalization)
+	t.SetChecksums(f.checksums)
+	t.SetDateLayout(f.dateLayout)
+	if askGlobalMailbox {
+		if err := t.SetDeduplication(f.dedup); err != nil {
+			f.printAndLogError("Failed to set deduplication: ", err)
+			return
+		}
 	}
 
 	if !f.setTransferRules(t) {
@@ -138,11 +398,76 @@ func (f *frontendCLI) transfer(t *transfer.Transfer, err error, askSkipEncrypted
 		}
 	}
 
+	if f.ie.IsDryRunRequested() {
+		f.printDryRun(t)
+		return
+	}
+
+	if !f.confirmTransferEstimate(t) {
+		return
+	}
+
 	progress := t.Start()
-	for range progress.GetUpdateChannel() {
-		f.printTransferProgress(progress)
+	f.progress = progress
+	f.Println(i18n.T("Transfer started. Use `transfer status`, `transfer pause`, `transfer resume` or `transfer stop` to control it."))
+
+	go func() {
+		defer f.panicHandler.HandlePanic()
+
+		pauseReason := ""
+		for range progress.GetUpdateChannel() {
+			if reason := progress.PauseReason(); reason != pauseReason {
+				pauseReason = reason
+				if reason != "" && f.ie.IsJSONProgressRequested() {
+					f.printSnapshot(progress, transfer.ProgressEventPaused)
+				} else if reason != "" {
+					f.Println(i18n.Tf("Transfer is paused: %s. Use `transfer resume` or `transfer stop`.", reason))
+				}
+			}
+		}
+		f.printTransferResult(progress)
+	}()
+}
+
+func (f *frontendCLI) isTransferRunning() bool {
+	return f.progress != nil && f.progress.GetUpdateChannel() != nil
+}
+
+func (f *frontendCLI) printTransferStatus(c *ishell.Context) {
+	if f.progress == nil {
+		f.Println(i18n.T("No transfer was started."))
+		return
+	}
+	if !f.isTransferRunning() {
+		f.printTransferResult(f.progress)
+		return
+	}
+	f.printTransferProgress(f.progress)
+}
+
+func (f *frontendCLI) pauseTransfer(c *ishell.Context) {
+	if !f.isTransferRunning() {
+		f.Println(i18n.T("No transfer is running."))
+		return
+	}
+	f.progress.Pause("paused by user")
+}
+
+func (f *frontendCLI) resumeTransfer(c *ishell.Context) {
+	if !f.isTransferRunning() {
+		f.Println(i18n.T("No transfer is running."))
+		return
 	}
-	f.printTransferResult(progress)
+	f.progress.Resume()
+	f.Println(i18n.T("Transfer resumed."))
+}
+
+func (f *frontendCLI) stopTransfer(c *ishell.Context) {
+	if !f.isTransferRunning() {
+		f.Println(i18n.T("No transfer is running."))
+		return
+	}
+	f.progress.Stop()
 }
 
 func (f *frontendCLI) setTransferGlobalMailbox(t *transfer.Transfer) error {
@@ -153,19 +478,42 @@ func (f *frontendCLI) setTransferGlobalMailbox(t *transfer.Transfer) error {
 		return nil
 	}
 
-	globalMailbox, err := t.CreateTargetMailbox(transfer.Mailbox{
+	globalMailbox := transfer.Mailbox{
 		Name:        labelName,
 		Color:       pmapi.LabelColors[0],
 		IsExclusive: false,
-	})
-	if err != nil {
-		return err
+	}
+	if !f.ie.IsDryRunRequested() {
+		var err error
+		if globalMailbox, err = t.CreateTargetMailbox(globalMailbox); err != nil {
+			return err
+		}
 	}
 
 	t.SetGlobalMailbox(&globalMailbox)
 	return nil
 }
 
+func (f *frontendCLI) setTransferFilter(t *transfer.Transfer) bool {
+	filter := transfer.MessageFilter{}
+	f.Print(i18n.T("Labels or folders, separated by comma (empty for any)"), ": ")
+	filter.Labels = transfer.ParseFilterLabels(f.ReadLine())
+	f.Print(i18n.T("Sender pattern, for example *@example.com (empty for any)"), ": ")
+	filter.From = strings.TrimSpace(f.ReadLine())
+	f.Print(i18n.T("Recipient pattern (empty for any)"), ": ")
+	filter.To = strings.TrimSpace(f.ReadLine())
+	f.Print(i18n.T("Subject regular expression (empty for any)"), ": ")
+	filter.Subject = f.ReadLine()
+	filter.HasAttachment = f.yesNoQuestion("Only messages with attachments")
+
+	if err := t.SetMessageFilter(filter); err != nil {
+		f.printAndLogError("Invalid filter: ", err)
+		return false
+	}
+	f.Println(i18n.Tf("Filter: %s", filter.String()))
+	return true
+}
+
 func (f *frontendCLI) setTransferRules(t *transfer.Transfer) bool {
 	f.Println("Rules:")
 	for _, rule := range t.GetRules() {
@@ -183,34 +531,85 @@ func (f *frontendCLI) setTransferRules(t *transfer.Transfer) bool {
 	return f.yesNoQuestion("Proceed")
 }
 
+func (f *frontendCLI) printDryRun(t *transfer.Transfer) {
+	f.Println(i18n.T("Dry run, walking the source without transferring anything..."))
+	report, err := t.DryRun()
+	if err != nil {
+		f.printAndLogError("Dry run failed: ", err)
+		return
+	}
+	f.Print(report.String())
+}
+
+func (f *frontendCLI) confirmTransferEstimate(t *transfer.Transfer) bool {
+	f.Println(i18n.T("Estimating the size of the transfer..."))
+	estimate, err := t.Estimate()
+	if err != nil {
+		f.printAndLogError("Failed to estimate transfer: ", err)
+		return f.yesNoQuestion("Start transfer anyway")
+	}
+
+	f.Println(i18n.T("Estimated transfer: ") + estimate.String())
+	return f.yesNoQuestion("Start transfer")
+}
+
 func (f *frontendCLI) printTransferProgress(progress *transfer.Progress) {
+	if f.ie.IsJSONProgressRequested() {
+		f.printSnapshot(progress, transfer.ProgressEventProgress)
+		return
+	}
+
 	failed, imported, exported, added, total := progress.GetCounts()
 	if total != 0 {
 		f.Println(fmt.Sprintf("Progress update: %d (%d / %d) / %d, failed: %d", imported, exported, added, total, failed))
 	}
 
-	if progress.IsPaused() {
-		f.Printf("Transfer is paused bacause %s", progress.PauseReason())
-		if !f.yesNoQuestion("Continue (y) or stop (n)") {
-			progress.Stop()
+	if mailbox, message := progress.GetCurrentMessage(); mailbox != "" {
+		for _, folder := range progress.GetFolderCounts() {
+			if folder.Name == mailbox {
+				f.Println(fmt.Sprintf("  %s: %d / %d, failed: %d, current: %s", folder.Name, folder.Imported, folder.Total, folder.Failed, message))
+				break
+			}
 		}
 	}
+
+	if progress.IsPaused() {
+		f.Println(i18n.Tf("Transfer is paused: %s. Use `transfer resume` or `transfer stop`.", progress.PauseReason()))
+	}
 }
 
 func (f *frontendCLI) printTransferResult(progress *transfer.Progress) {
+	if f.ie.IsJSONProgressRequested() {
+		event := transfer.ProgressEventFinished
+		if progress.GetFatalError() != nil || progress.IsStopped() || len(progress.GetFailedMessages()) != 0 {
+			event = transfer.ProgressEventFailed
+		}
+		f.printSnapshot(progress, event)
+		return
+	}
+
 	err := progress.GetFatalError()
 	if err != nil {
-		f.Println("Transfer failed: " + err.Error())
+		f.Println(i18n.T("Transfer failed: ") + i18n.T(err.Error()))
 		return
 	}
 
+	f.Println(i18n.T("Folders:"))
+	for _, folder := range progress.GetFolderCounts() {
+		f.Printf(" %-30s %d / %d, failed: %d\n", folder.Name, folder.Imported, folder.Total, folder.Failed)
+	}
+
+	if duplicates := progress.GetDuplicateCount(); duplicates > 0 {
+		f.Println(i18n.Tf("Skipped %d messages already in the account.", duplicates))
+	}
+
 	statuses := progress.GetFailedMessages()
 	if len(statuses) == 0 {
 		f.Println("Transfer finished!")
 		return
 	}
 
-	f.Println("Transfer finished with errors:")
+	f.Println(i18n.T("Transfer finished with errors:"))
 	for _, messageStatus := range statuses {
 		f.Printf(
 			" %-17s | %-30s | %-30s\n  %s: %s\n",
@@ -218,7 +617,17 @@ func (f *frontendCLI) printTransferResult(progress *transfer.Progress) {
 			messageStatus.From,
 			messageStatus.Subject,
 			messageStatus.SourceID,
-			messageStatus.GetErrorMessage(),
+			i18n.T(messageStatus.GetErrorMessage()),
 		)
 	}
 }
+
+// printSnapshot prints the progress as one JSON line for tools wrapping the app.
+func (f *frontendCLI) printSnapshot(progress *transfer.Progress, event string) {
+	var line bytes.Buffer
+	if err := progress.WriteSnapshot(&line, event); err != nil {
+		f.printAndLogError("Cannot write progress: ", err)
+		return
+	}
+	f.Print(line.String())
+}
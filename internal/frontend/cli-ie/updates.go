@@ -20,6 +20,7 @@ package cliie
 import (
 	"strings"
 
+	"github.com/ProtonMail/proton-bridge/internal/frontend/i18n"
 	"github.com/ProtonMail/proton-bridge/internal/importexport"
 	"github.com/ProtonMail/proton-bridge/internal/updates"
 	"github.com/abiosoft/ishell"
@@ -33,7 +34,7 @@ func (f *frontendCLI) checkUpdates(c *ishell.Context) {
 		return
 	}
 	if isUpToDate {
-		f.Println("Your version is up to date.")
+		f.Println(i18n.T("Your version is up to date."))
 	} else {
 		f.notifyNeedUpgrade()
 		f.Println("")
@@ -18,8 +18,11 @@
 package cliie
 
 import (
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ProtonMail/proton-bridge/internal/frontend/i18n"
 	pmapi "github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	"github.com/fatih/color"
 )
@@ -37,27 +40,31 @@ func isNotEmpty(val string) bool {
 }
 
 func (f *frontendCLI) yesNoQuestion(question string) bool {
-	f.Print(question, "? yes/"+bold("no")+": ")
-	yes := "yes"
+	yes := i18n.T("yes")
+	f.Print(i18n.T(question), "? "+yes+"/"+bold(i18n.T("no"))+": ")
 	answer := strings.ToLower(f.ReadLine())
-	for i := 0; i < len(answer); i++ {
-		if i >= len(yes) || answer[i] != yes[i] {
-			return false // Everything else is false.
-		}
+	if answer == "" {
+		return false // Empty is false.
 	}
-	return len(answer) > 0 // Empty is false.
+	// English answer is always accepted, even with other locale.
+	return strings.HasPrefix(yes, answer) || strings.HasPrefix("yes", answer)
 }
 
 func (f *frontendCLI) readStringInAttempts(title string, readFunc func() string, isOK func(string) bool) (value string) {
-	f.Printf("%s: ", title)
+	translatedTitle := i18n.T(title)
+	f.Printf("%s: ", translatedTitle)
 	value = readFunc()
-	title = strings.ToLower(string(title[0])) + title[1:]
+	if translatedTitle == title {
+		title = strings.ToLower(string(title[0])) + title[1:]
+	} else {
+		title = translatedTitle
+	}
 	for i := 0; !isOK(value); i++ {
 		if i >= maxInputRepeat {
-			f.Println("Too many attempts")
+			f.Println(i18n.T("Too many attempts"))
 			return ""
 		}
-		f.Printf("Please fill %s: ", title)
+		f.Print(i18n.Tf("Please fill %s: ", title))
 		value = readFunc()
 	}
 	return
@@ -65,7 +72,7 @@ func (f *frontendCLI) readStringInAttempts(title string, readFunc func() string,
 
 func (f *frontendCLI) printAndLogError(args ...interface{}) {
 	log.Error(args...)
-	f.Println(args...)
+	f.Println(i18n.Args(args...)...)
 }
 
 func (f *frontendCLI) processAPIError(err error) {
@@ -76,24 +83,51 @@ func (f *frontendCLI) processAPIError(err error) {
 	case pmapi.ErrUpgradeApplication:
 		f.notifyNeedUpgrade()
 	default:
-		f.Println("Server error:", err.Error())
+		f.Println(i18n.T("Server error:"), i18n.T(err.Error()))
 	}
 }
 
 func (f *frontendCLI) notifyInternetOff() {
-	f.Println("Internet connection is not available.")
+	f.Println(i18n.T("Internet connection is not available."))
 }
 
 func (f *frontendCLI) notifyInternetOn() {
-	f.Println("Internet connection is available again.")
+	f.Println(i18n.T("Internet connection is available again."))
+}
+
+func (f *frontendCLI) notifyRateLimit(seconds string) {
+	f.Println(i18n.Tf("Paused by server for %s seconds because of too many requests.", seconds))
+}
+
+func (f *frontendCLI) notifyClockSkew(seconds string) {
+	skew, err := strconv.Atoi(seconds)
+	if err != nil {
+		return
+	}
+	direction := "ahead of"
+	if skew < 0 {
+		skew, direction = -skew, "behind"
+	}
+	f.Println(i18n.Tf("Your computer clock is %s %s the server clock.", time.Duration(skew)*time.Second, direction))
+	f.Println(i18n.T("Login and secure connections may fail. Please correct the date, time and time zone settings."))
+}
+
+func (f *frontendCLI) notifyTLSPinsOverride(path string) {
+	f.Println(i18n.Tf("Trusted TLS pins are loaded from the unsigned local file %s.", path))
+	f.Println(i18n.T("Remove the file unless your administrator placed it there on purpose."))
 }
 
 func (f *frontendCLI) notifyLogout(address string) {
-	f.Printf("Account %s is disconnected. Login to continue using this account with email client.", address)
+	f.Print(i18n.Tf("Account %s is disconnected. Login to continue using this account with email client.", address))
+}
+
+func (f *frontendCLI) notifyReloginRequired(username string) {
+	f.Println(i18n.Tf("Account %s was logged out because its password was changed.", bold(username)))
+	f.Println(i18n.Tf("Local data of the account is kept. Use `login %s` to log in again with the new password.", username))
 }
 
 func (f *frontendCLI) notifyNeedUpgrade() {
-	f.Println("Please download and install the newest version of application from", f.updates.GetDownloadLink())
+	f.Println(i18n.T("Please download and install the newest version of application from"), f.updates.GetDownloadLink())
 }
 
 func (f *frontendCLI) notifyCredentialsError() {
@@ -20,9 +20,9 @@ package cli
 import (
 	"strings"
 
-	"github.com/ProtonMail/proton-bridge/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
 	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	"github.com/abiosoft/ishell"
 )
 
@@ -69,16 +69,25 @@ func (f *frontendCLI) showAccountAddressInfo(user types.User, address string) {
 		smtpSecurity = "SSL"
 	}
 	f.Println(bold("Configuration for " + address))
-	f.Printf("IMAP Settings\nAddress:   %s\nIMAP port: %d\nUsername:  %s\nPassword:  %s\nSecurity:  %s\n",
-		bridge.Host,
-		f.preferences.GetInt(preferences.IMAPPortKey),
-		address,
-		user.GetBridgePassword(),
-		"STARTTLS",
-	)
+	if user.IsIMAPEnabled() {
+		f.Printf("IMAP Settings\nAddress:   %s\nIMAP port: %d\nUsername:  %s\nPassword:  %s\nSecurity:  %s\n",
+			f.preferences.Get(preferences.ListenHostKey),
+			f.preferences.GetInt(preferences.IMAPPortKey),
+			address,
+			user.GetBridgePassword(),
+			"STARTTLS",
+		)
+	} else {
+		f.Println("IMAP is disabled for this account")
+	}
 	f.Println("")
+	if !user.IsSMTPEnabled() {
+		f.Println("SMTP is disabled for this account")
+		f.Println("")
+		return
+	}
 	f.Printf("SMTP Settings\nAddress:   %s\nIMAP port: %d\nUsername:  %s\nPassword:  %s\nSecurity:  %s\n",
-		bridge.Host,
+		f.preferences.Get(preferences.ListenHostKey),
 		f.preferences.GetInt(preferences.SMTPPortKey),
 		address,
 		user.GetBridgePassword(),
@@ -87,10 +96,15 @@ func (f *frontendCLI) showAccountAddressInfo(user types.User, address string) {
 	f.Println("")
 }
 
-func (f *frontendCLI) loginAccount(c *ishell.Context) { // nolint[funlen]
+func (f *frontendCLI) loginAccount(c *ishell.Context) {
 	f.ShowPrompt(false)
 	defer f.ShowPrompt(true)
 
+	_ = f.login(c)
+}
+
+// login adds or connects account and returns it, or nil when it failed.
+func (f *frontendCLI) login(c *ishell.Context) types.User { // nolint[funlen]
 	loginName := ""
 	if len(c.Args) > 0 {
 		user := f.getUserByIndexOrName(c.Args[0])
@@ -102,7 +116,7 @@ func (f *frontendCLI) loginAccount(c *ishell.Context) { // nolint[funlen]
 	if loginName == "" {
 		loginName = f.readStringInAttempts("Username", c.ReadLine, isNotEmpty)
 		if loginName == "" {
-			return
+			return nil
 		}
 	} else {
 		f.Println("Username:", loginName)
@@ -110,26 +124,29 @@ func (f *frontendCLI) loginAccount(c *ishell.Context) { // nolint[funlen]
 
 	password := f.readStringInAttempts("Password", c.ReadPassword, isNotEmpty)
 	if password == "" {
-		return
+		return nil
 	}
 
 	f.Println("Authenticating ... ")
 	client, auth, err := f.bridge.Login(loginName, password)
+	if hvErr, ok := err.(*pmapi.ErrHumanVerificationRequired); ok {
+		client, auth, err = f.loginWithHumanVerification(c, loginName, password, hvErr)
+	}
 	if err != nil {
 		f.processAPIError(err)
-		return
+		return nil
 	}
 
 	if auth.HasTwoFactor() {
 		twoFactor := f.readStringInAttempts("Two factor code", c.ReadLine, isNotEmpty)
 		if twoFactor == "" {
-			return
+			return nil
 		}
 
 		_, err = client.Auth2FA(twoFactor, auth)
 		if err != nil {
 			f.processAPIError(err)
-			return
+			return nil
 		}
 	}
 
@@ -138,7 +155,7 @@ func (f *frontendCLI) loginAccount(c *ishell.Context) { // nolint[funlen]
 		mailboxPassword = f.readStringInAttempts("Mailbox password", c.ReadPassword, isNotEmpty)
 	}
 	if mailboxPassword == "" {
-		return
+		return nil
 	}
 
 	f.Println("Adding account ...")
@@ -146,10 +163,11 @@ func (f *frontendCLI) loginAccount(c *ishell.Context) { // nolint[funlen]
 	if err != nil {
 		log.WithField("username", loginName).WithError(err).Error("Login was unsuccessful")
 		f.Println("Adding account was unsuccessful:", err)
-		return
+		return nil
 	}
 
 	f.Printf("Account %s was added successfully.\n", bold(user.Username()))
+	return user
 }
 
 func (f *frontendCLI) logoutAccount(c *ishell.Context) {
@@ -217,3 +235,255 @@ func (f *frontendCLI) changeMode(c *ishell.Context) {
 	}
 	f.Printf("Address mode for account %s changed to %s\n", user.Username(), newMode)
 }
+
+func (f *frontendCLI) toggleIMAPAccess(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	enable := !user.IsIMAPEnabled()
+	if !f.yesNoQuestion("Are you sure you want to " + bold(enableAction(enable)+" IMAP") + " for account " + bold(user.Username())) {
+		return
+	}
+	if err := user.SetIMAPEnabled(enable); err != nil {
+		f.printAndLogError("Cannot change IMAP access:", err)
+		return
+	}
+	f.Printf("IMAP for account %s is %sd\n", user.Username(), enableAction(enable))
+}
+
+func (f *frontendCLI) toggleSMTPAccess(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	enable := !user.IsSMTPEnabled()
+	if !f.yesNoQuestion("Are you sure you want to " + bold(enableAction(enable)+" SMTP") + " for account " + bold(user.Username())) {
+		return
+	}
+	if err := user.SetSMTPEnabled(enable); err != nil {
+		f.printAndLogError("Cannot change SMTP access:", err)
+		return
+	}
+	f.Printf("SMTP for account %s is %sd\n", user.Username(), enableAction(enable))
+}
+
+func (f *frontendCLI) toggleDraftsUpload(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	enable := !user.IsDraftsUploadEnabled()
+	if !f.yesNoQuestion("Are you sure you want to " + bold(enableAction(enable)+" saving drafts from email clients") + " for account " + bold(user.Username())) {
+		return
+	}
+	if err := user.SetDraftsUploadEnabled(enable); err != nil {
+		f.printAndLogError("Cannot change drafts upload:", err)
+		return
+	}
+	f.Printf("Saving drafts from email clients for account %s is %sd\n", user.Username(), enableAction(enable))
+}
+
+func (f *frontendCLI) toggleAutoCreateMailboxes(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	enable := !user.IsAutoCreateMailboxesEnabled()
+	if !f.yesNoQuestion("Are you sure you want to " + bold(enableAction(enable)+" creating missing folders and labels") + " for account " + bold(user.Username())) {
+		return
+	}
+	if err := user.SetAutoCreateMailboxesEnabled(enable); err != nil {
+		f.printAndLogError("Cannot change creating missing folders:", err)
+		return
+	}
+	f.Printf("Creating missing folders and labels for account %s is %sd\n", user.Username(), enableAction(enable))
+}
+
+func (f *frontendCLI) toggleCrossAccountCopy(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	enable := !user.IsCrossAccountCopyEnabled()
+	if !f.yesNoQuestion("Are you sure you want to " + bold(enableAction(enable)+" importing messages copied from other accounts") + " to account " + bold(user.Username())) {
+		return
+	}
+	if err := user.SetCrossAccountCopyEnabled(enable); err != nil {
+		f.printAndLogError("Cannot change cross-account copy:", err)
+		return
+	}
+	f.Printf("Importing messages copied from other accounts to account %s is %sd\n", user.Username(), enableAction(enable))
+}
+
+func (f *frontendCLI) toggleSpamTraining(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	enable := !user.IsSpamTrainingEnabled()
+	if !f.yesNoQuestion("Are you sure you want to " + bold(enableAction(enable)+" spam filter training") + " for account " + bold(user.Username())) {
+		return
+	}
+	if err := user.SetSpamTrainingEnabled(enable); err != nil {
+		f.printAndLogError("Cannot change spam filter training:", err)
+		return
+	}
+	f.Printf("Spam filter training for account %s is %sd\n", user.Username(), enableAction(enable))
+}
+
+func (f *frontendCLI) changeExpungePolicy(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	f.Printf("Current expunge policy of account %s is %s\n", user.Username(), bold(user.GetExpungePolicy()))
+	isValid := func(policy string) bool {
+		return policy == "delete" || policy == "trash" || policy == "deferred"
+	}
+	policy := f.readStringInAttempts("New policy (delete, trash or deferred)", c.ReadLine, isValid)
+	if policy == "" {
+		return
+	}
+	if err := user.SetExpungePolicy(policy); err != nil {
+		f.printAndLogError("Cannot change expunge policy:", err)
+		return
+	}
+	f.Printf("Expunge policy of account %s is %s\n", user.Username(), policy)
+}
+
+func (f *frontendCLI) changeAutoCopy(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	if address, mode := user.GetAutoCopy(); address == "" {
+		f.Printf("Messages sent by account %s are not copied to any address\n", user.Username())
+	} else {
+		f.Printf("Messages sent by account %s are copied as %s to %s\n", user.Username(), mode, bold(address))
+	}
+
+	address := strings.TrimSpace(f.readStringInAttempts("Address to copy sent messages to (empty to turn off)", c.ReadLine, func(string) bool { return true }))
+	mode := "bcc"
+	if address != "" {
+		isValid := func(mode string) bool {
+			return mode == "bcc" || mode == "cc"
+		}
+		if mode = f.readStringInAttempts("Add the address as bcc or cc", c.ReadLine, isValid); mode == "" {
+			return
+		}
+	}
+
+	if err := user.SetAutoCopy(address, mode); err != nil {
+		f.printAndLogError("Cannot change auto-copy:", err)
+		return
+	}
+	if address == "" {
+		f.Printf("Messages sent by account %s are not copied anymore\n", user.Username())
+		return
+	}
+	f.Printf("Messages sent by account %s are copied as %s to %s\n", user.Username(), mode, address)
+}
+
+func (f *frontendCLI) changeFooter(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	if plain, html := user.GetFooter(); plain == "" && html == "" {
+		f.Printf("No footer is appended to messages sent by account %s\n", user.Username())
+	} else {
+		f.Printf("Plain text footer of account %s:\n%s\n", user.Username(), plain)
+		f.Printf("HTML footer of account %s:\n%s\n", user.Username(), html)
+	}
+
+	f.Println("Enter plain text footer, finish with a line containing only a dot (empty to derive it from HTML):")
+	plain := readMultiLines(c)
+	f.Println("Enter HTML footer, finish with a line containing only a dot (empty to derive it from plain text):")
+	html := readMultiLines(c)
+
+	if err := user.SetFooter(plain, html); err != nil {
+		f.printAndLogError("Cannot change footer:", err)
+		return
+	}
+	if plain == "" && html == "" {
+		f.Printf("No footer is appended to messages sent by account %s anymore\n", user.Username())
+		return
+	}
+	f.Printf("Footer is appended to all messages sent by account %s\n", user.Username())
+}
+
+func (f *frontendCLI) changeAccountProxy(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	current := user.GetProxy()
+	if current == "" {
+		current = "default"
+	}
+	f.Printf("Current proxy of account %s is %s\n", user.Username(), bold(current))
+
+	isValid := func(proxy string) bool {
+		return proxy == "default" || pmapi.ValidateUserProxy(proxy) == nil
+	}
+	proxy := f.readStringInAttempts("New proxy (URL, direct or default)", c.ReadLine, isValid)
+	if proxy == "" {
+		return
+	}
+	if proxy == "default" {
+		proxy = ""
+	}
+	if err := user.SetProxy(proxy); err != nil {
+		f.printAndLogError("Cannot change proxy:", err)
+		return
+	}
+	f.Printf("Proxy of account %s was changed\n", user.Username())
+}
+
+func enableAction(enable bool) string {
+	if enable {
+		return "enable"
+	}
+	return "disable"
+}
+
+// loginWithHumanVerification asks the user to pass the verification in a browser
+// and repeats the login with the token obtained there.
+func (f *frontendCLI) loginWithHumanVerification(c *ishell.Context, loginName, password string, hvErr *pmapi.ErrHumanVerificationRequired) (pmapi.Client, *pmapi.Auth, error) {
+	f.Println("Server requires human verification. Open the following address in a browser,")
+	f.Println("finish the verification and paste the token you get here.")
+	f.Println("")
+	f.Println(hvErr.VerificationURL())
+	f.Println("")
+
+	token := f.readStringInAttempts("Verification token", c.ReadLine, isNotEmpty)
+	if token == "" {
+		return nil, nil, hvErr
+	}
+
+	f.Println("Authenticating ... ")
+	return f.bridge.LoginWithHumanVerification(loginName, password, hvErr.TokenType(), token)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listAppPasswords(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	appPasswords := user.GetAppPasswords()
+	if len(appPasswords) == 0 {
+		f.Printf("Account %s has no app passwords.\n", bold(user.Username()))
+		return
+	}
+
+	spacing := "%-20s %-20s %-20s\n"
+	f.Printf(bold(spacing), "name", "created", "last used")
+	for _, appPassword := range appPasswords {
+		lastUsed := "never"
+		if appPassword.LastUsed != 0 {
+			lastUsed = formatUnixTime(appPassword.LastUsed)
+		}
+		f.Printf(spacing, appPassword.Name, formatUnixTime(appPassword.Created), lastUsed)
+	}
+	f.Println()
+}
+
+func (f *frontendCLI) addAppPassword(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	name := strings.TrimSpace(f.readStringInAttempts("App password name (e.g. email client)", c.ReadLine, isNotEmpty))
+	if name == "" {
+		return
+	}
+
+	password, err := user.AddAppPassword(name)
+	if err != nil {
+		f.printAndLogError("Cannot add app password: ", err)
+		return
+	}
+
+	f.Printf("App password %s for account %s: %s\n", bold(name), bold(user.Username()), password)
+	f.Println("Use it instead of the bridge password in your email client.")
+}
+
+func (f *frontendCLI) revokeAppPassword(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	name := strings.TrimSpace(f.readStringInAttempts("App password name", c.ReadLine, isNotEmpty))
+	if name == "" {
+		return
+	}
+
+	if !f.yesNoQuestion("Are you sure you want to revoke app password " + bold(name) + " of account " + bold(user.Username())) {
+		return
+	}
+
+	if err := user.RevokeAppPassword(name); err != nil {
+		f.printAndLogError("Cannot revoke app password: ", err)
+		return
+	}
+
+	f.Printf("App password %s was revoked.\n", bold(name))
+}
+
+func (f *frontendCLI) rotateBridgePassword(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	if !f.yesNoQuestion("Are you sure you want to replace the bridge password of account " + bold(user.Username()) + ". All email clients using it will have to be reconfigured") {
+		return
+	}
+
+	password, err := user.RotateBridgePassword()
+	if err != nil {
+		f.printAndLogError("Cannot rotate bridge password: ", err)
+		return
+	}
+
+	f.Printf("New bridge password for account %s: %s\n", bold(user.Username()), password)
+	f.Println("App passwords were not changed.")
+}
+
+func formatUnixTime(timestamp int64) string {
+	return time.Unix(timestamp, 0).Format("2006-01-02 15:04")
+}
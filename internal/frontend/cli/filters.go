@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"io/ioutil"
+	"strings"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) showFilters(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	script, err := user.GetFilters()
+	if err != nil {
+		f.printAndLogError("Cannot get filters: ", err)
+		return
+	}
+	if script == "" {
+		f.Printf("Account %s has no local filters.\n", bold(user.Username()))
+		return
+	}
+
+	f.Println(script)
+}
+
+func (f *frontendCLI) loadFilters(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	path := f.readStringInAttempts("Path to Sieve file", c.ReadLine, isNotEmpty)
+	if path == "" {
+		return
+	}
+
+	script, err := ioutil.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		f.printAndLogError("Cannot read filters: ", err)
+		return
+	}
+
+	if err := user.SetFilters(string(script)); err != nil {
+		f.printAndLogError("Cannot set filters: ", err)
+		return
+	}
+
+	f.Printf("Local filters of account %s were set and apply to newly received messages.\n", bold(user.Username()))
+}
+
+func (f *frontendCLI) clearFilters(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	if !f.yesNoQuestion("Are you sure you want to disable local filters of account " + bold(user.Username())) {
+		return
+	}
+
+	if err := user.SetFilters(""); err != nil {
+		f.printAndLogError("Cannot clear filters: ", err)
+		return
+	}
+
+	f.Printf("Local filters of account %s were disabled.\n", bold(user.Username()))
+}
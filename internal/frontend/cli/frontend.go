@@ -19,8 +19,11 @@
 package cli
 
 import (
+	"time"
+
 	"github.com/ProtonMail/proton-bridge/internal/events"
 	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
 
@@ -40,8 +43,11 @@ type frontendCLI struct {
 	eventListener listener.Listener
 	updates       types.Updater
 	bridge        types.Bridger
+	outbox        types.Outbox
 
 	appRestart bool
+	plain      bool // No colors and no ASCII art, e.g. for screen readers.
+	startTime  time.Time
 }
 
 // New returns a new CLI frontend configured with the given options.
@@ -52,6 +58,8 @@ func New( //nolint[funlen]
 	eventListener listener.Listener,
 	updates types.Updater,
 	bridge types.Bridger,
+	outbox types.Outbox,
+	plain bool,
 ) *frontendCLI { //nolint[golint]
 	fe := &frontendCLI{
 		Shell: ishell.New(),
@@ -61,8 +69,11 @@ func New( //nolint[funlen]
 		eventListener: eventListener,
 		updates:       updates,
 		bridge:        bridge,
+		outbox:        outbox,
 
 		appRestart: false,
+		plain:      plain,
+		startTime:  time.Now(),
 	}
 
 	// Clear commands.
@@ -93,20 +104,110 @@ func New( //nolint[funlen]
 		Func:      fe.changeMode,
 		Completer: fe.completeUsernames,
 	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "imap-access",
+		Help:      "enable or disable IMAP for account (send-only). Use index or account name as parameter. (alias: imap)",
+		Aliases:   []string{"imap"},
+		Func:      fe.toggleIMAPAccess,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "smtp-access",
+		Help:      "enable or disable SMTP for account (receive-only). Use index or account name as parameter. (alias: smtp)",
+		Aliases:   []string{"smtp"},
+		Func:      fe.toggleSMTPAccess,
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "drafts-upload",
+		Help:      "enable or disable saving drafts from email clients to the server; when disabled, drafts are synced only from the server. Use index or account name as parameter. (alias: drafts)",
+		Aliases:   []string{"drafts"},
+		Func:      fe.noAccountWrapper(fe.toggleDraftsUpload),
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "auto-create",
+		Help:      "enable or disable creating missing folders and labels when email client copies or appends messages to them. Use index or account name as parameter. (alias: ac)",
+		Aliases:   []string{"ac"},
+		Func:      fe.noAccountWrapper(fe.toggleAutoCreateMailboxes),
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "cross-account-copy",
+		Help:      "allow or refuse importing messages which email client copies from another account. Use index or account name as parameter. (alias: xcopy)",
+		Aliases:   []string{"xcopy"},
+		Func:      fe.noAccountWrapper(fe.toggleCrossAccountCopy),
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "spam-training",
+		Help:      "enable or disable reporting messages moved to or from Spam by email client to the spam filter. Use index or account name as parameter. (alias: spam)",
+		Aliases:   []string{"spam"},
+		Func:      fe.noAccountWrapper(fe.toggleSpamTraining),
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "expunge-policy",
+		Help:      "change what happens with messages deleted by email client: delete (default), trash to move them to Trash, or deferred to delete them a day later. Use index or account name as parameter. (alias: expunge)",
+		Aliases:   []string{"expunge"},
+		Func:      fe.noAccountWrapper(fe.changeExpungePolicy),
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "auto-copy",
+		Help:      "add an address as bcc or cc to every message sent by the account, e.g. for CRM archiving. Use index or account name as parameter. (aliases: auto-bcc, acopy)",
+		Aliases:   []string{"auto-bcc", "acopy"},
+		Func:      fe.noAccountWrapper(fe.changeAutoCopy),
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "footer",
+		Help:      "append plain text and HTML footer, e.g. a legal disclaimer, to every message sent by the account. Use index or account name as parameter. (alias: disclaimer)",
+		Aliases:   []string{"disclaimer"},
+		Func:      fe.noAccountWrapper(fe.changeFooter),
+		Completer: fe.completeUsernames,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "account-proxy",
+		Help:      "set proxy used only by the account: URL with http, https or socks5 scheme, direct to bypass proxy from environment, or default. Use index or account name as parameter. (alias: aproxy)",
+		Aliases:   []string{"aproxy"},
+		Func:      fe.noAccountWrapper(fe.changeAccountProxy),
+		Completer: fe.completeUsernames,
+	})
 	changeCmd.AddCmd(&ishell.Cmd{Name: "port",
 		Help:    "change port numbers of IMAP and SMTP servers. (alias: p)",
 		Aliases: []string{"p"},
 		Func:    fe.changePort,
 	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "listen-host",
+		Help:    "change address of IMAP and SMTP servers, e.g. ::1 on IPv6-only systems or address in local network with mandatory TLS and optional client certificates. (alias: host)",
+		Aliases: []string{"host"},
+		Func:    fe.changeListenHost,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "local-socket",
+		Help:    "allow or disallow local clients to connect through unix socket without the bridge password. (alias: socket)",
+		Aliases: []string{"socket"},
+		Func:    fe.toggleLocalSocket,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "smtp-only",
+		Help:    "start only the SMTP server without IMAP and mailbox sync, or back to both. (alias: relay)",
+		Aliases: []string{"relay"},
+		Func:    fe.toggleSMTPOnly,
+	})
 	changeCmd.AddCmd(&ishell.Cmd{Name: "proxy",
 		Help: "allow or disallow bridge to securely connect to proton via a third party when it is being blocked",
 		Func: fe.toggleAllowProxy,
 	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "silent-updates",
+		Help:    "allow or disallow bridge to download updates in background and install them on next start without asking. (alias: su)",
+		Aliases: []string{"su"},
+		Func:    fe.toggleSilentUpdates,
+	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "notifications",
+		Help:    "choose which notifications to show and set quiet hours. (alias: n)",
+		Aliases: []string{"n"},
+		Func:    fe.changeNotifications,
+	})
 	changeCmd.AddCmd(&ishell.Cmd{Name: "smtp-security",
 		Help:    "change port numbers of IMAP and SMTP servers.(alias: ssl, starttls)",
 		Aliases: []string{"ssl", "starttls"},
 		Func:    fe.changeSMTPSecurity,
 	})
+	changeCmd.AddCmd(&ishell.Cmd{Name: "undo-send-delay",
+		Help:    "change how many seconds sent messages wait before sending so they can be undone. (alias: delay)",
+		Aliases: []string{"delay"},
+		Func:    fe.changeUndoSendDelay,
+	})
 	fe.AddCmd(changeCmd)
 
 	// Check commands.
@@ -121,6 +222,11 @@ func New( //nolint[funlen]
 		Aliases: []string{"i", "con", "connection"},
 		Func:    fe.checkInternetConnection,
 	})
+	checkCmd.AddCmd(&ishell.Cmd{Name: "clock",
+		Help:    "check difference between local and server clock. (alias: time)",
+		Aliases: []string{"time"},
+		Func:    fe.checkClock,
+	})
 	fe.AddCmd(checkCmd)
 
 	// Print info commands.
@@ -143,6 +249,10 @@ func New( //nolint[funlen]
 		Help: "print used resources.",
 		Func: fe.printCredits,
 	})
+	fe.AddCmd(&ishell.Cmd{Name: "stats",
+		Help: "print latency and error counts of requests to the server since start.",
+		Func: fe.printRequestStats,
+	})
 
 	// Account commands.
 	fe.AddCmd(&ishell.Cmd{Name: "list",
@@ -156,6 +266,17 @@ func New( //nolint[funlen]
 		Completer: fe.completeUsernames,
 		Aliases:   []string{"i"},
 	})
+	fe.AddCmd(&ishell.Cmd{Name: "qr",
+		Help:      "print QR code with the configuration for account, or save it when the last parameter is a .png file. (alias: qrcode)",
+		Func:      fe.noAccountWrapper(fe.showAccountQRCode),
+		Completer: fe.completeUsernames,
+		Aliases:   []string{"qrcode"},
+	})
+	fe.AddCmd(&ishell.Cmd{Name: "setup",
+		Help:    "guided setup of keychain, ports, account and email client. (alias: wizard)",
+		Func:    fe.runSetup,
+		Aliases: []string{"wizard"},
+	})
 	fe.AddCmd(&ishell.Cmd{Name: "login",
 		Help:      "login procedure to add or connect account. Optionally use index or account as parameter. (aliases: a, add, con, connect)",
 		Func:      fe.loginAccount,
@@ -175,6 +296,191 @@ func New( //nolint[funlen]
 		Completer: fe.completeUsernames,
 	})
 
+	// App password commands.
+	appPasswordCmd := &ishell.Cmd{Name: "app-password",
+		Help:    "manage additional named bridge passwords, one per email client. (aliases: ap, app-passwords)",
+		Aliases: []string{"ap", "app-passwords"},
+	}
+	appPasswordCmd.AddCmd(&ishell.Cmd{Name: "list",
+		Help:      "print app passwords of account. Use index or account name as parameter. (aliases: l, ls)",
+		Aliases:   []string{"l", "ls"},
+		Func:      fe.noAccountWrapper(fe.listAppPasswords),
+		Completer: fe.completeUsernames,
+	})
+	appPasswordCmd.AddCmd(&ishell.Cmd{Name: "add",
+		Help:      "generate new app password for account. Use index or account name as parameter, the password name is asked for. (aliases: a, new)",
+		Aliases:   []string{"a", "new"},
+		Func:      fe.noAccountWrapper(fe.addAppPassword),
+		Completer: fe.completeUsernames,
+	})
+	appPasswordCmd.AddCmd(&ishell.Cmd{Name: "revoke",
+		Help:      "revoke app password of account. Use index or account name as parameter, the password name is asked for. (aliases: rm, remove)",
+		Aliases:   []string{"rm", "remove"},
+		Func:      fe.noAccountWrapper(fe.revokeAppPassword),
+		Completer: fe.completeUsernames,
+	})
+	appPasswordCmd.AddCmd(&ishell.Cmd{Name: "rotate",
+		Help:      "replace the main bridge password of account with a new one. App passwords are kept. Use index or account name as parameter. (alias: regenerate)",
+		Aliases:   []string{"regenerate"},
+		Func:      fe.noAccountWrapper(fe.rotateBridgePassword),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(appPasswordCmd)
+
+	fe.AddCmd(&ishell.Cmd{Name: "labels",
+		Help:      "print folders and labels of account with their colors and order. Use index or account name as parameter. (alias: folders)",
+		Aliases:   []string{"folders"},
+		Func:      fe.noAccountWrapper(fe.listLabels),
+		Completer: fe.completeUsernames,
+	})
+
+	fe.AddCmd(&ishell.Cmd{Name: "recover",
+		Help:      "recover message permanently deleted by email client in the last 7 days. Use index or account name as parameter. (alias: undelete)",
+		Aliases:   []string{"undelete"},
+		Func:      fe.noAccountWrapper(fe.recoverDeletedMessage),
+		Completer: fe.completeUsernames,
+	})
+
+	problemsCmd := &ishell.Cmd{Name: "problems",
+		Help:      "print messages which failed to sync or build with their folder and error. Use index or account name as parameter. (alias: errors)",
+		Aliases:   []string{"errors"},
+		Func:      fe.noAccountWrapper(fe.listMessageProblems),
+		Completer: fe.completeUsernames,
+	}
+	problemsCmd.AddCmd(&ishell.Cmd{Name: "skip",
+		Help:      "stop downloading permanently broken message, clients get a placeholder instead. Use index or account name and message ID as parameters.",
+		Func:      fe.noAccountWrapper(fe.skipMessage),
+		Completer: fe.completeUsernames,
+	})
+	problemsCmd.AddCmd(&ishell.Cmd{Name: "unskip",
+		Help:      "download message from the skip-list again. Use index or account name and message ID as parameters.",
+		Func:      fe.noAccountWrapper(fe.unskipMessage),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(problemsCmd)
+
+	fe.AddCmd(&ishell.Cmd{Name: "reset-uids",
+		Help:      "change UIDVALIDITY of one mailbox so clients download just this mailbox again. Use index or account name and mailbox name as parameters. (alias: bump-uidvalidity)",
+		Aliases:   []string{"bump-uidvalidity"},
+		Func:      fe.noAccountWrapper(fe.bumpUIDValidity),
+		Completer: fe.completeUsernames,
+	})
+
+	fe.AddCmd(&ishell.Cmd{Name: "mail-merge",
+		Help:      "send personalized copies of message template to recipients from CSV with email column and template variables. Use index or account name as parameter. (alias: merge)",
+		Aliases:   []string{"merge"},
+		Func:      fe.noAccountWrapper(fe.mailMerge),
+		Completer: fe.completeUsernames,
+	})
+
+	recipientsCmd := &ishell.Cmd{Name: "recipients",
+		Help:      "print recipients with public keys cached for sending. Use index or account name as parameter. (alias: keys)",
+		Aliases:   []string{"keys"},
+		Func:      fe.noAccountWrapper(fe.listCachedRecipients),
+		Completer: fe.completeUsernames,
+	}
+	recipientsCmd.AddCmd(&ishell.Cmd{Name: "flush",
+		Help:      "forget cached public keys of recipients so they are looked up again. Use index or account name as parameter.",
+		Func:      fe.noAccountWrapper(fe.flushRecipientKeyCache),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(recipientsCmd)
+
+	fe.AddCmd(&ishell.Cmd{Name: "stats",
+		Help:      "print counts of messages sent and received per address, e.g. to find unused aliases. Use index or account name as parameter. (alias: usage)",
+		Aliases:   []string{"usage"},
+		Func:      fe.noAccountWrapper(fe.printAddressStats),
+		Completer: fe.completeUsernames,
+	})
+
+	fe.AddCmd(&ishell.Cmd{Name: "history",
+		Help:      "print uptime, delay of processing of changes and the last syncs of accounts. Optionally use index or account name as parameter. (alias: syncs)",
+		Aliases:   []string{"syncs"},
+		Func:      fe.noAccountWrapper(fe.printSyncHistory),
+		Completer: fe.completeUsernames,
+	})
+
+	// Saved search commands.
+	savedSearchCmd := &ishell.Cmd{Name: "saved-search",
+		Help:    "manage virtual mailboxes with messages matching a search. (aliases: ss, saved-searches)",
+		Aliases: []string{"ss", "saved-searches"},
+	}
+	savedSearchCmd.AddCmd(&ishell.Cmd{Name: "list",
+		Help:      "print saved searches of account. Use index or account name as parameter. (aliases: l, ls)",
+		Aliases:   []string{"l", "ls"},
+		Func:      fe.noAccountWrapper(fe.listSavedSearches),
+		Completer: fe.completeUsernames,
+	})
+	savedSearchCmd.AddCmd(&ishell.Cmd{Name: "add",
+		Help:      "create saved search mailbox for account. Use index or account name as parameter. (aliases: a, new)",
+		Aliases:   []string{"a", "new"},
+		Func:      fe.noAccountWrapper(fe.addSavedSearch),
+		Completer: fe.completeUsernames,
+	})
+	savedSearchCmd.AddCmd(&ishell.Cmd{Name: "remove",
+		Help:      "remove saved search mailbox of account. Use index or account name as parameter. (aliases: rm, delete)",
+		Aliases:   []string{"rm", "delete"},
+		Func:      fe.noAccountWrapper(fe.removeSavedSearch),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(savedSearchCmd)
+
+	// Local filter commands.
+	filtersCmd := &ishell.Cmd{Name: "filters",
+		Help:    "manage local filters (subset of Sieve) applied on newly received messages. (aliases: sieve)",
+		Aliases: []string{"sieve"},
+	}
+	filtersCmd.AddCmd(&ishell.Cmd{Name: "show",
+		Help:      "print local filters of account. Use index or account name as parameter. (aliases: print)",
+		Aliases:   []string{"print"},
+		Func:      fe.noAccountWrapper(fe.showFilters),
+		Completer: fe.completeUsernames,
+	})
+	filtersCmd.AddCmd(&ishell.Cmd{Name: "load",
+		Help:      "load local filters of account from Sieve file. Use index or account name as parameter. (aliases: set)",
+		Aliases:   []string{"set"},
+		Func:      fe.noAccountWrapper(fe.loadFilters),
+		Completer: fe.completeUsernames,
+	})
+	filtersCmd.AddCmd(&ishell.Cmd{Name: "clear",
+		Help:      "disable local filters of account. Use index or account name as parameter. (aliases: rm, remove)",
+		Aliases:   []string{"rm", "remove"},
+		Func:      fe.noAccountWrapper(fe.clearFilters),
+		Completer: fe.completeUsernames,
+	})
+	fe.AddCmd(filtersCmd)
+
+	// Outbox commands.
+	fe.AddCmd(&ishell.Cmd{Name: "undo-send",
+		Help:    "cancel sending of message waiting for undo-send delay. Optionally use index of message as parameter. (alias: undo)",
+		Aliases: []string{"undo"},
+		Func:    fe.undoSend,
+	})
+	fe.AddCmd(&ishell.Cmd{Name: "retry-send",
+		Help:    "send again message which failed to be sent. Optionally use index of message as parameter. (alias: retry)",
+		Aliases: []string{"retry"},
+		Func:    fe.retrySend,
+	})
+	outboxCmd := &ishell.Cmd{Name: "outbox",
+		Help:    "inspect messages waiting to be sent, being sent or failed to be sent. (alias: out)",
+		Aliases: []string{"out"},
+	}
+	outboxCmd.AddCmd(&ishell.Cmd{Name: "list",
+		Help:    "print messages in outbox. (aliases: l, ls)",
+		Aliases: []string{"l", "ls"},
+		Func:    fe.listOutbox,
+	})
+	outboxCmd.AddCmd(&ishell.Cmd{Name: "retry",
+		Help: "send again failed message. Optionally use index of message as parameter.",
+		Func: fe.retryFromOutbox,
+	})
+	outboxCmd.AddCmd(&ishell.Cmd{Name: "discard",
+		Help:    "cancel queued message or forget failed one; the message stays in Drafts. Optionally use index of message as parameter. (aliases: rm, remove)",
+		Aliases: []string{"rm", "remove"},
+		Func:    fe.discardFromOutbox,
+	})
+	fe.AddCmd(outboxCmd)
+
 	// System commands.
 	fe.AddCmd(&ishell.Cmd{Name: "restart",
 		Help: "restart the bridge.",
@@ -187,6 +493,8 @@ func New( //nolint[funlen]
 	}()
 	fe.eventListener.RetryEmit(events.TLSCertIssue)
 	fe.eventListener.RetryEmit(events.ErrorEvent)
+	fe.eventListener.RetryEmit(events.ClockSkewEvent)
+	fe.eventListener.RetryEmit(events.TLSPinsOverrideEvent)
 	return fe
 }
 
@@ -194,30 +502,77 @@ func (f *frontendCLI) watchEvents() {
 	errorCh := f.getEventChannel(events.ErrorEvent)
 	internetOffCh := f.getEventChannel(events.InternetOffEvent)
 	internetOnCh := f.getEventChannel(events.InternetOnEvent)
+	rateLimitCh := f.getEventChannel(events.RateLimitEvent)
+	clockSkewCh := f.getEventChannel(events.ClockSkewEvent)
+	tlsPinsOverrideCh := f.getEventChannel(events.TLSPinsOverrideEvent)
 	addressChangedCh := f.getEventChannel(events.AddressChangedEvent)
 	addressChangedLogoutCh := f.getEventChannel(events.AddressChangedLogoutEvent)
 	logoutCh := f.getEventChannel(events.LogoutEvent)
+	reloginRequiredCh := f.getEventChannel(events.ReloginRequiredEvent)
 	certIssue := f.getEventChannel(events.TLSCertIssue)
+	loginLockoutCh := f.getEventChannel(events.LoginLockoutEvent)
+	newMailCh := f.getEventChannel(events.NewMailEvent)
+	sendProgressCh := f.getEventChannel(events.SendProgressEvent)
+	sendFailedCh := f.getEventChannel(events.SendFailedEvent)
+	crossAccountCopyCh := f.getEventChannel(events.CrossAccountCopyEvent)
+	recipientKeyChangedCh := f.getEventChannel(events.RecipientKeyChangedEvent)
+	senderDomainWarningCh := f.getEventChannel(events.SenderDomainWarningEvent)
 	for {
 		select {
 		case errorDetails := <-errorCh:
-			f.Println("Bridge failed:", errorDetails)
+			if f.shouldNotify(preferences.NotificationError) {
+				f.Println("Bridge failed:", errorDetails)
+			}
 		case <-internetOffCh:
 			f.notifyInternetOff()
 		case <-internetOnCh:
 			f.notifyInternetOn()
+		case seconds := <-rateLimitCh:
+			f.notifyRateLimit(seconds)
+		case seconds := <-clockSkewCh:
+			f.notifyClockSkew(seconds)
+		case path := <-tlsPinsOverrideCh:
+			f.notifyTLSPinsOverride(path)
 		case address := <-addressChangedCh:
 			f.Printf("Address changed for %s. You may need to reconfigure your email client.", address)
 		case address := <-addressChangedLogoutCh:
-			f.notifyLogout(address)
+			if f.shouldNotify(preferences.NotificationLogout) {
+				f.notifyLogout(address)
+			}
 		case userID := <-logoutCh:
 			user, err := f.bridge.GetUser(userID)
 			if err != nil {
 				return
 			}
-			f.notifyLogout(user.Username())
+			if f.shouldNotify(preferences.NotificationLogout) {
+				f.notifyLogout(user.Username())
+			}
+		case userID := <-reloginRequiredCh:
+			user, err := f.bridge.GetUser(userID)
+			if err != nil {
+				return
+			}
+			f.notifyReloginRequired(user.Username())
+		case address := <-newMailCh:
+			if f.shouldNotify(preferences.NotificationNewMail) {
+				f.Printf("New message received for %s.\n", address)
+			}
+		case progress := <-sendProgressCh:
+			f.Println("Sending", progress)
+		case subject := <-sendFailedCh:
+			if f.shouldNotify(preferences.NotificationError) {
+				f.Printf("Message %s could not be sent. It stays in Drafts, use `retry-send` to send it again.\n", bold(subject))
+			}
+		case addresses := <-crossAccountCopyCh:
+			f.notifyCrossAccountCopy(addresses)
+		case recipient := <-recipientKeyChangedCh:
+			f.Printf("Public key of %s changed since the last message sent there. Verify the new key with the recipient.\n", bold(recipient))
+		case problems := <-senderDomainWarningCh:
+			f.Printf("DNS records of sending domain are not set up for ProtonMail, messages may end up in spam. %s\n", problems)
 		case <-certIssue:
 			f.notifyCertIssue()
+		case username := <-loginLockoutCh:
+			f.notifyLoginLockout(username)
 		}
 	}
 }
@@ -240,6 +595,12 @@ func (f *frontendCLI) Loop(credentialsError error) error {
 		return credentialsError
 	}
 
+	if f.plain {
+		f.Println("Welcome to ProtonMail Bridge interactive shell")
+		f.run()
+		return nil
+	}
+
 	f.Print(`
             Welcome to ProtonMail Bridge interactive shell
                               ___....___
@@ -257,6 +618,26 @@ func (f *frontendCLI) Loop(credentialsError error) error {
       jgs   [ ]                                        [ ]
     ~~^_~^~/   \~^-~^~ _~^-~_^~-^~_^~~-^~_~^~-~_~-^~_^/   \~^ ~~_ ^
 `)
-	f.Run()
+	f.run()
 	return nil
 }
+
+// run starts the interactive shell. On the first start without accounts,
+// the setup is run first.
+func (f *frontendCLI) run() {
+	if f.preferences.GetBool(preferences.FirstStartCLIKey) {
+		if len(f.bridge.GetUsers()) != 0 {
+			f.preferences.SetBool(preferences.FirstStartCLIKey, false)
+		} else {
+			f.Println("Let's set up Bridge. You can run the setup again later using the `setup` command.")
+			if err := f.Process("setup"); err != nil {
+				log.WithError(err).Error("Setup failed")
+			}
+			if f.appRestart {
+				return
+			}
+		}
+	}
+
+	f.Run()
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) printSyncHistory(c *ishell.Context) {
+	f.Printf("Bridge is running since %s (uptime %s).\n",
+		f.startTime.Format(time.RFC822),
+		time.Since(f.startTime).Round(time.Second),
+	)
+
+	users := f.bridge.GetUsers()
+	if len(c.Args) > 0 {
+		user := f.askUserByIndexOrName(c)
+		if user == nil {
+			return
+		}
+		users = []types.User{user}
+	}
+
+	for _, user := range users {
+		f.Println("")
+		f.Println(bold(user.Username()))
+		f.printEventLoopStatus(user)
+
+		history, err := user.GetSyncHistory()
+		if err != nil {
+			f.printAndLogError("Cannot get sync history: ", err)
+			continue
+		}
+		if len(history) == 0 {
+			f.Println("No sync was run yet.")
+			continue
+		}
+
+		f.Printf("%-20s %10s %9s  %s\n", "Start", "Duration", "Messages", "Result")
+		for _, run := range history {
+			result := "completed"
+			if run.Error != "" {
+				result = "failed: " + run.Error
+			}
+			if run.Resumed {
+				result += " (resumed)"
+			}
+			f.Printf("%-20s %10s %9d  %s\n",
+				run.Start.Format("2006-01-02 15:04:05"),
+				run.Duration.Round(time.Second),
+				run.Messages,
+				result,
+			)
+		}
+	}
+}
+
+func (f *frontendCLI) printEventLoopStatus(user types.User) {
+	status, err := user.GetEventLoopStatus()
+	if err != nil {
+		f.printAndLogError("Cannot get event loop status: ", err)
+		return
+	}
+	if !status.Running {
+		f.Println("Changes are not polled, account is not connected.")
+		return
+	}
+
+	lastPoll := "never"
+	if !status.LastPoll.IsZero() {
+		lastPoll = time.Since(status.LastPoll).Round(time.Second).String() + " ago"
+	}
+	f.Printf("Last poll of changes %s, took %s (max %s), backlog %d.\n",
+		lastPoll,
+		status.LastLatency.Round(time.Millisecond),
+		status.MaxLatency.Round(time.Millisecond),
+		status.Backlog,
+	)
+	if status.IsBehind() {
+		f.Println(bold("Bridge is falling behind, some changes might not be visible in clients yet."))
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"github.com/ProtonMail/proton-bridge/internal/store"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listLabels(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	labels, err := user.ListLabels()
+	if err != nil {
+		f.printAndLogError("Cannot list labels: ", err)
+		return
+	}
+	if len(labels) == 0 {
+		f.Printf("Account %s has no folders or labels.\n", bold(user.Username()))
+		return
+	}
+
+	spacing := "%-40s %-8s %5s\n"
+	f.Printf(bold(spacing), "mailbox", "color", "order")
+	for _, label := range labels {
+		prefix := store.UserLabelsPrefix
+		if label.Exclusive == 1 {
+			prefix = store.UserFoldersPrefix
+		}
+		f.Printf(spacing, prefix+label.Name, label.Color, label.Order)
+	}
+	f.Println()
+}
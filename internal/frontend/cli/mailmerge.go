@@ -0,0 +1,164 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/mailmerge"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/abiosoft/ishell"
+)
+
+// defaultMailMergePace is the default pause between two sent messages.
+const defaultMailMergePace = 5 * time.Second
+
+func (f *frontendCLI) mailMerge(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+	if !user.IsSMTPEnabled() {
+		f.Println("SMTP is disabled for the account, enable it first.")
+		return
+	}
+
+	from := user.GetPrimaryAddress()
+	if answer := strings.TrimSpace(f.readStringInAttempts("From address (empty for "+from+")", c.ReadLine, func(string) bool { return true })); answer != "" {
+		from = answer
+	}
+
+	templatePath := f.readStringInAttempts("Path to message template", c.ReadLine, isNotEmpty)
+	if templatePath == "" {
+		return
+	}
+	templateText, err := ioutil.ReadFile(filepath.Clean(templatePath))
+	if err != nil {
+		f.printAndLogError("Cannot read template: ", err)
+		return
+	}
+	tmpl, err := mailmerge.ParseTemplate(string(templateText))
+	if err != nil {
+		f.printAndLogError("Cannot parse template: ", err)
+		return
+	}
+
+	csvPath := f.readStringInAttempts("Path to CSV with recipients", c.ReadLine, isNotEmpty)
+	if csvPath == "" {
+		return
+	}
+	recipients, err := readMailMergeRecipients(csvPath)
+	if err != nil {
+		f.printAndLogError("Cannot read recipients: ", err)
+		return
+	}
+	if len(recipients) == 0 {
+		f.Println("There are no recipients in the CSV.")
+		return
+	}
+
+	// Render the first message to report template errors before sending anything.
+	if _, err := tmpl.Render(from, recipients[0]); err != nil {
+		f.printAndLogError("Cannot fill in template: ", err)
+		return
+	}
+
+	pace := defaultMailMergePace
+	paceAnswer := strings.TrimSpace(f.readStringInAttempts("Seconds between messages (empty for "+strconv.Itoa(int(pace.Seconds()))+")", c.ReadLine, func(string) bool { return true }))
+	if paceAnswer != "" {
+		seconds, err := strconv.Atoi(paceAnswer)
+		if err != nil || seconds < 0 {
+			f.Println("Pause must be a non-negative number of seconds!")
+			return
+		}
+		pace = time.Duration(seconds) * time.Second
+	}
+
+	f.Printf("%d messages will be sent from %s, about one every %s.\n", len(recipients), bold(from), pace)
+	if !f.yesNoQuestion("Send") {
+		return
+	}
+
+	sender := &mailmerge.SMTPSender{
+		Host:     f.getLocalSMTPHost(),
+		Port:     f.preferences.GetInt(preferences.SMTPPortKey),
+		UseSSL:   f.preferences.GetBool(preferences.SMTPSSLKey),
+		Username: from,
+		Password: user.GetBridgePassword(),
+	}
+
+	sentCount := 0
+	results := mailmerge.Run(sender, from, tmpl, recipients, pace, func(result mailmerge.Result) {
+		if result.Err != nil {
+			f.Printf("%s: %s\n", result.Email, result.Err)
+			return
+		}
+		sentCount++
+		f.Printf("%s: sent\n", result.Email)
+	})
+	f.Printf("Sent %d of %d messages.\n", sentCount, len(results))
+
+	reportPath := strings.TrimSuffix(csvPath, filepath.Ext(csvPath)) + "-report-" + time.Now().Format("2006-01-02-150405") + ".csv"
+	if err := writeMailMergeReport(reportPath, results); err != nil {
+		f.printAndLogError("Cannot write report: ", err)
+		return
+	}
+	f.Println("Report saved to", reportPath)
+}
+
+// getLocalSMTPHost returns address on which this computer reaches bridge SMTP.
+func (f *frontendCLI) getLocalSMTPHost() string {
+	host := f.preferences.Get(preferences.ListenHostKey)
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		return "127.0.0.1"
+	}
+	return host
+}
+
+func readMailMergeRecipients(path string) ([]mailmerge.Recipient, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint[errcheck]
+
+	return mailmerge.LoadRecipients(file)
+}
+
+func writeMailMergeReport(path string, results []mailmerge.Result) error {
+	file, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := mailmerge.WriteReport(file, results); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/internal/smtp"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) undoSend(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	sends := f.outbox.ListPendingSends()
+	if len(sends) == 0 {
+		f.Println("There is no message waiting to be sent.")
+		return
+	}
+
+	for idx, send := range sends {
+		f.Printf("%2d: %s from %s to %s (sending in %s)\n",
+			idx,
+			bold(send.Subject),
+			send.From,
+			strings.Join(send.Recipients, ", "),
+			time.Until(send.SendAt).Round(time.Second),
+		)
+	}
+
+	idx := f.askIndex(c, "Index of message to undo", len(sends))
+	if idx < 0 {
+		return
+	}
+
+	if err := f.outbox.UndoSend(sends[idx].MessageID); err != nil {
+		f.printAndLogError("Cannot undo send: ", err)
+		return
+	}
+
+	f.Printf("Sending of %s was undone, the message stays in Drafts.\n", bold(sends[idx].Subject))
+}
+
+func (f *frontendCLI) changeUndoSendDelay(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	current := f.preferences.Get(preferences.UndoSendDelayKey)
+	newDelay := f.readStringInAttempts("Set undo-send delay in seconds, 0 to disable (current "+current+")", c.ReadLine, isNotEmpty)
+	if newDelay == "" {
+		return
+	}
+
+	delay, err := strconv.Atoi(strings.TrimSpace(newDelay))
+	if err != nil || delay < 0 {
+		f.Println("Delay must be a non-negative number of seconds!")
+		return
+	}
+
+	f.preferences.SetInt(preferences.UndoSendDelayKey, delay)
+	f.Println("Undo-send delay set to", delay, "seconds.")
+}
+
+func (f *frontendCLI) retrySend(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	sends := f.outbox.ListFailedSends()
+	if len(sends) == 0 {
+		f.Println("There is no message which failed to be sent.")
+		return
+	}
+
+	for idx, send := range sends {
+		f.Printf("%2d: %s from %s to %s failed %s: %s\n",
+			idx,
+			bold(send.Subject),
+			send.From,
+			strings.Join(send.Recipients, ", "),
+			send.FailedAt.Format(time.RFC822),
+			send.Error,
+		)
+	}
+
+	idx := f.askIndex(c, "Index of message to retry", len(sends))
+	if idx < 0 {
+		return
+	}
+
+	f.Printf("Sending %s...\n", bold(sends[idx].Subject))
+	if err := f.outbox.RetrySend(sends[idx].MessageID); err != nil {
+		f.printAndLogError("Cannot send message: ", err)
+		return
+	}
+
+	f.Printf("Message %s was sent.\n", bold(sends[idx].Subject))
+}
+
+// askIndex returns index from the first argument or asks for it.
+// It returns -1 when there is no valid index.
+func (f *frontendCLI) askIndex(c *ishell.Context, question string, count int) int {
+	arg := ""
+	if len(c.Args) > 0 {
+		arg = c.Args[0]
+	} else {
+		arg = f.readStringInAttempts(question, c.ReadLine, isNotEmpty)
+	}
+	if arg == "" {
+		return -1
+	}
+
+	idx, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || idx < 0 || idx >= count {
+		f.Println("Wrong index!")
+		return -1
+	}
+
+	return idx
+}
+
+func (f *frontendCLI) listOutbox(c *ishell.Context) {
+	f.printOutbox()
+}
+
+// printOutbox prints messages in outbox and returns them for selection.
+func (f *frontendCLI) printOutbox() []smtp.OutboxEntry {
+	entries := f.outbox.ListOutbox()
+	if len(entries) == 0 {
+		f.Println("Outbox is empty.")
+		return nil
+	}
+
+	for idx, entry := range entries {
+		var state string
+		switch entry.State {
+		case smtp.OutboxQueued:
+			state = "queued, sending in " + time.Until(entry.Time).Round(time.Second).String()
+		case smtp.OutboxSending:
+			state = "sending for " + time.Since(entry.Time).Round(time.Second).String()
+		case smtp.OutboxFailed:
+			state = "failed " + entry.Time.Format(time.RFC822) + ": " + entry.Error
+		}
+
+		f.Printf("%2d: %s from %s to %s (%s)\n",
+			idx,
+			bold(entry.Subject),
+			entry.From,
+			strings.Join(entry.Recipients, ", "),
+			state,
+		)
+	}
+
+	return entries
+}
+
+func (f *frontendCLI) retryFromOutbox(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	entries := f.printOutbox()
+	if len(entries) == 0 {
+		return
+	}
+
+	idx := f.askIndex(c, "Index of message to retry", len(entries))
+	if idx < 0 {
+		return
+	}
+	if entries[idx].State != smtp.OutboxFailed {
+		f.Println("Only failed messages can be sent again.")
+		return
+	}
+
+	f.Printf("Sending %s...\n", bold(entries[idx].Subject))
+	if err := f.outbox.RetrySend(entries[idx].MessageID); err != nil {
+		f.printAndLogError("Cannot send message: ", err)
+		return
+	}
+
+	f.Printf("Message %s was sent.\n", bold(entries[idx].Subject))
+}
+
+func (f *frontendCLI) discardFromOutbox(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	entries := f.printOutbox()
+	if len(entries) == 0 {
+		return
+	}
+
+	idx := f.askIndex(c, "Index of message to discard", len(entries))
+	if idx < 0 {
+		return
+	}
+
+	if err := f.outbox.DiscardFromOutbox(entries[idx].MessageID); err != nil {
+		f.printAndLogError("Cannot discard message: ", err)
+		return
+	}
+
+	f.Printf("Message %s was removed from outbox, it stays in Drafts.\n", bold(entries[idx].Subject))
+}
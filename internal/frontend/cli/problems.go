@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strings"
+	"time"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listMessageProblems(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	problems, err := user.ListMessageProblems()
+	if err != nil {
+		f.printAndLogError("Cannot list message problems: ", err)
+		return
+	}
+	if len(problems) == 0 {
+		f.Printf("Account %s has no messages with problems.\n", bold(user.Username()))
+		return
+	}
+
+	spacing := "%-40s %-20s %-19s %-7s %s\n"
+	f.Printf(bold(spacing), "message ID", "folder", "time", "skipped", "error")
+	for _, problem := range problems {
+		folder := problem.Mailbox
+		if folder == "" {
+			folder = "-"
+		}
+		skipped := "no"
+		if problem.Skipped {
+			skipped = "yes"
+		}
+		// Multiple errors are joined over several lines.
+		problemErr := strings.Join(strings.Fields(problem.Error), " ")
+		f.Printf(spacing, problem.MessageID, folder, problem.Time.Format(time.RFC822), skipped, problemErr)
+	}
+	f.Println()
+}
+
+func (f *frontendCLI) skipMessage(c *ishell.Context) {
+	f.setMessageSkipped(c, true)
+}
+
+func (f *frontendCLI) unskipMessage(c *ishell.Context) {
+	f.setMessageSkipped(c, false)
+}
+
+func (f *frontendCLI) setMessageSkipped(c *ishell.Context, skipped bool) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+	// With more accounts, the first argument was the account.
+	if len(f.bridge.GetUsers()) > 1 && len(c.Args) > 0 {
+		c.Args = c.Args[1:]
+	}
+	if len(c.Args) != 1 {
+		f.Println("Please use message ID from the list of problems as parameter.")
+		return
+	}
+
+	if err := user.SetMessageSkipped(c.Args[0], skipped); err != nil {
+		f.printAndLogError("Cannot change skip-list: ", err)
+		return
+	}
+	if skipped {
+		f.Printf("Message %s will not be downloaded anymore.\n", bold(c.Args[0]))
+	} else {
+		f.Printf("Message %s will be downloaded again.\n", bold(c.Args[0]))
+	}
+}
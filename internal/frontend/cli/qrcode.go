@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/pkg/qrcode"
+	"github.com/abiosoft/ishell"
+)
+
+// qrCodePNGScale is the number of pixels per module of saved QR code.
+const qrCodePNGScale = 8
+
+// showAccountQRCode prints QR code with client settings of each address or
+// saves it as PNG when the last argument is file ending with .png.
+func (f *frontendCLI) showAccountQRCode(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	if !user.IsConnected() {
+		f.Printf("Please login to %s to get email client configuration.\n", bold(user.Username()))
+		return
+	}
+
+	pngPath := ""
+	if n := len(c.Args); n > 0 && strings.EqualFold(filepath.Ext(c.Args[n-1]), ".png") {
+		pngPath = c.Args[n-1]
+	}
+
+	addresses := []string{user.GetPrimaryAddress()}
+	if !user.IsCombinedAddressMode() {
+		addresses = user.GetAddresses()
+	}
+
+	host := f.preferences.Get(preferences.ListenHostKey)
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		f.Printf("Bridge listens only on %s, other devices can connect only through a tunnel to this computer.\n", host)
+	}
+
+	for idx, address := range addresses {
+		code, err := qrcode.Encode([]byte(f.getClientSettings(user, address)))
+		if err != nil {
+			f.printAndLogError("Cannot generate QR code for", address+":", err)
+			continue
+		}
+
+		if pngPath == "" {
+			f.Println(bold("Configuration for " + address))
+			f.Println(code.String())
+			continue
+		}
+
+		path := pngPath
+		if len(addresses) > 1 {
+			ext := filepath.Ext(path)
+			path = strings.TrimSuffix(path, ext) + "-" + strconv.Itoa(idx) + ext
+		}
+		if err := writeQRCode(code, path); err != nil {
+			f.printAndLogError("Cannot save QR code:", err)
+			continue
+		}
+		f.Printf("QR code for %s saved to %s. It contains the password, remove it once used.\n", address, path)
+	}
+}
+
+// getClientSettings returns settings as plain text which most QR code
+// readers show as it is and allow to copy.
+func (f *frontendCLI) getClientSettings(user types.User, address string) string {
+	smtpSecurity := "STARTTLS"
+	if f.preferences.GetBool(preferences.SMTPSSLKey) {
+		smtpSecurity = "SSL"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Host: %s\n", f.preferences.Get(preferences.ListenHostKey))
+	if user.IsIMAPEnabled() {
+		fmt.Fprintf(&b, "IMAP port: %d (STARTTLS)\n", f.preferences.GetInt(preferences.IMAPPortKey))
+	}
+	if user.IsSMTPEnabled() {
+		fmt.Fprintf(&b, "SMTP port: %d (%s)\n", f.preferences.GetInt(preferences.SMTPPortKey), smtpSecurity)
+	}
+	fmt.Fprintf(&b, "Username: %s\n", address)
+	fmt.Fprintf(&b, "Password: %s", user.GetBridgePassword())
+	return b.String()
+}
+
+func writeQRCode(code *qrcode.Code, path string) error {
+	file, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if err := code.WritePNG(file, qrCodePNGScale); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
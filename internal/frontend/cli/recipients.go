@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"time"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listCachedRecipients(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	recipients, err := user.ListCachedRecipients()
+	if err != nil {
+		f.printAndLogError("Cannot list cached recipients: ", err)
+		return
+	}
+	if len(recipients) == 0 {
+		f.Printf("Account %s has no recipient keys cached.\n", bold(user.Username()))
+		return
+	}
+
+	spacing := "%-40s %-5v %-8s %-19s %s\n"
+	f.Printf(bold(spacing), "recipient", "keys", "internal", "fetched", "expires")
+	for _, recipient := range recipients {
+		internal := "no"
+		if recipient.IsInternal {
+			internal = "yes"
+		}
+		f.Printf(spacing,
+			recipient.Email,
+			recipient.KeyCount,
+			internal,
+			recipient.FetchedAt.Format(time.RFC822),
+			recipient.ExpiresAt.Format(time.RFC822),
+		)
+	}
+	f.Println()
+}
+
+func (f *frontendCLI) flushRecipientKeyCache(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	count, err := user.FlushRecipientKeyCache()
+	if err != nil {
+		f.printAndLogError("Cannot flush recipient keys: ", err)
+		return
+	}
+	f.Printf("Flushed cached keys of %d recipients of account %s.\n", count, bold(user.Username()))
+}
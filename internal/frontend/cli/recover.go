@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"time"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) recoverDeletedMessage(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+	// With more accounts, the first argument was the account.
+	if len(f.bridge.GetUsers()) > 1 && len(c.Args) > 0 {
+		c.Args = c.Args[1:]
+	}
+
+	tombstones, err := user.ListDeletedMessages()
+	if err != nil {
+		f.printAndLogError("Cannot list deleted messages: ", err)
+		return
+	}
+	if len(tombstones) == 0 {
+		f.Printf("Account %s has no deleted messages to recover.\n", bold(user.Username()))
+		return
+	}
+
+	for idx, tombstone := range tombstones {
+		f.Printf("%2d: %s from %s (deleted %s)\n",
+			idx,
+			bold(tombstone.Subject),
+			tombstone.From,
+			tombstone.DeletedAt.Format(time.RFC822),
+		)
+	}
+
+	idx := f.askIndex(c, "Index of message to recover", len(tombstones))
+	if idx < 0 {
+		return
+	}
+
+	if err := user.RecoverDeletedMessage(tombstones[idx].MessageID); err != nil {
+		f.printAndLogError("Cannot recover message: ", err)
+		return
+	}
+	f.Printf("Message %s was recovered.\n", bold(tombstones[idx].Subject))
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/internal/store"
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) listSavedSearches(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	searches, err := user.ListSavedSearches()
+	if err != nil {
+		f.printAndLogError("Cannot list saved searches: ", err)
+		return
+	}
+	if len(searches) == 0 {
+		f.Printf("Account %s has no saved searches.\n", bold(user.Username()))
+		return
+	}
+
+	spacing := "%-30s %s\n"
+	f.Printf(bold(spacing), "mailbox", "query")
+	for _, search := range searches {
+		f.Printf(spacing, store.SavedSearchesPrefix+search.Name, search.Query)
+	}
+	f.Println()
+}
+
+func (f *frontendCLI) addSavedSearch(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	name := f.readStringInAttempts("Saved search name", c.ReadLine, isNotEmpty)
+	if name == "" {
+		return
+	}
+
+	f.Println("Combine terms is:unread, is:read, is:starred, has:attachment, from:, to:, subject:,")
+	f.Println("after:YYYY-MM-DD, before:YYYY-MM-DD, year:YYYY or words in subject.")
+	query := f.readStringInAttempts("Query", c.ReadLine, isNotEmpty)
+	if query == "" {
+		return
+	}
+
+	if err := user.AddSavedSearch(strings.TrimSpace(name), query); err != nil {
+		f.printAndLogError("Cannot add saved search: ", err)
+		return
+	}
+
+	f.Printf("Mailbox %s was created for account %s.\n", bold(store.SavedSearchesPrefix+strings.TrimSpace(name)), bold(user.Username()))
+}
+
+func (f *frontendCLI) removeSavedSearch(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	name := f.readStringInAttempts("Saved search name", c.ReadLine, isNotEmpty)
+	if name == "" {
+		return
+	}
+
+	if err := user.RemoveSavedSearch(strings.TrimSpace(name)); err != nil {
+		f.printAndLogError("Cannot remove saved search: ", err)
+		return
+	}
+
+	f.Printf("Saved search %s was removed. Messages were not changed.\n", bold(name))
+}
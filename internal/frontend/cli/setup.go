@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strconv"
+
+	"github.com/ProtonMail/proton-bridge/internal/frontend/autoconfig"
+	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/pkg/keychain"
+	"github.com/abiosoft/ishell"
+)
+
+// runSetup guides through the same steps as onboarding in GUI. Settings
+// which need restart are asked first; setup continues after the restart.
+func (f *frontendCLI) runSetup(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	f.Println(bold("Step 1/4: Keychain"))
+	keychainChanged := f.setupKeychain(c)
+
+	f.Println(bold("Step 2/4: Ports"))
+	portsChanged := f.setupPorts(c)
+
+	if keychainChanged || portsChanged {
+		f.Println("Bridge will restart to apply the settings and continue with the setup.")
+		f.appRestart = true
+		f.Stop()
+		return
+	}
+	f.preferences.SetBool(preferences.FirstStartCLIKey, false)
+
+	f.Println(bold("Step 3/4: Account"))
+	user := f.login(c)
+	if user == nil {
+		f.Println("You can add the account later using the `login` command.")
+		return
+	}
+
+	f.Println(bold("Step 4/4: Email client"))
+	f.setupClient(user)
+}
+
+func (f *frontendCLI) setupKeychain(c *ishell.Context) bool {
+	helpers := keychain.AvailableHelpers()
+	if len(helpers) < 2 {
+		f.Println("Credentials will be stored in the system keychain.")
+		return false
+	}
+
+	current := f.preferences.Get(preferences.KeychainKey)
+	if current == "" {
+		current = helpers[0]
+	}
+
+	f.Println("Credentials can be stored in one of these keychains:")
+	for idx, name := range helpers {
+		f.Printf("%2d: %s\n", idx+1, name)
+	}
+
+	isHelperIndex := func(val string) bool {
+		idx, err := strconv.Atoi(val)
+		return val == "" || (err == nil && idx >= 1 && idx <= len(helpers))
+	}
+	choice := f.readStringInAttempts("Choose keychain, empty to keep "+current, c.ReadLine, isHelperIndex)
+	if choice == "" {
+		return false
+	}
+
+	idx, _ := strconv.Atoi(choice)
+	if helpers[idx-1] == current {
+		return false
+	}
+
+	f.preferences.Set(preferences.KeychainKey, helpers[idx-1])
+	return true
+}
+
+func (f *frontendCLI) setupPorts(c *ishell.Context) bool {
+	f.Printf("IMAP server listens on port %s and SMTP server on port %s.\n",
+		f.preferences.Get(preferences.IMAPPortKey),
+		f.preferences.Get(preferences.SMTPPortKey),
+	)
+
+	if !f.yesNoQuestion("Do you want to use different ports") {
+		return false
+	}
+
+	return f.askForPorts(c)
+}
+
+func (f *frontendCLI) setupClient(user types.User) {
+	f.Println("Use the following settings in your email client. The password is generated")
+	f.Println("by Bridge for this computer, do not use your Proton password.")
+	f.Println("")
+
+	if user.IsCombinedAddressMode() {
+		f.showAccountAddressInfo(user, user.GetPrimaryAddress())
+	} else {
+		for _, address := range user.GetAddresses() {
+			f.showAccountAddressInfo(user, address)
+		}
+	}
+
+	f.Println("Your email client will warn that the Bridge certificate is not trusted.")
+	f.Println("It is generated for this computer and it is safe to accept it.")
+
+	imapPort := f.preferences.GetInt(preferences.IMAPPortKey)
+	smtpPort := f.preferences.GetInt(preferences.SMTPPortKey)
+	smtpSSL := f.preferences.GetBool(preferences.SMTPSSLKey)
+	for _, autoConf := range autoconfig.Available() {
+		if !f.yesNoQuestion("Configure " + autoConf.Name() + " automatically") {
+			continue
+		}
+		if err := autoConf.Configure(imapPort, smtpPort, false, smtpSSL, user, 0); err != nil {
+			f.printAndLogError("Cannot configure", autoConf.Name()+":", err)
+		}
+	}
+}
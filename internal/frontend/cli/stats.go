@@ -0,0 +1,48 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"time"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) printAddressStats(c *ishell.Context) {
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+
+	allStats, err := user.GetAddressStats()
+	if err != nil {
+		f.printAndLogError("Cannot get address statistics: ", err)
+		return
+	}
+
+	spacing := "%-40s %8v %8v %s\n"
+	f.Printf(bold(spacing), "address", "sent", "received", "last used")
+	for _, stats := range allStats {
+		lastUsed := "never"
+		if t := stats.LastUsed(); !t.IsZero() {
+			lastUsed = t.Format(time.RFC822)
+		}
+		f.Printf(spacing, stats.Address, stats.Sent, stats.Received, lastUsed)
+	}
+	f.Println("Messages are counted since the bridge started tracking them.")
+}
@@ -18,11 +18,19 @@
 package cli
 
 import (
+	"crypto/tls"
 	"fmt"
+	"net"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/ProtonMail/proton-bridge/internal/frontend/i18n"
 	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/localsocket"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	"github.com/ProtonMail/proton-bridge/pkg/ports"
 	"github.com/abiosoft/ishell"
 )
@@ -47,6 +55,42 @@ func (f *frontendCLI) checkInternetConnection(c *ishell.Context) {
 	}
 }
 
+func (f *frontendCLI) checkClock(c *ishell.Context) {
+	// Clock is measured from server responses, the check makes a fresh one.
+	if err := f.bridge.CheckConnection(); err != nil {
+		f.Println("Can not contact the server, please check your internet connection.")
+		return
+	}
+
+	skew := f.bridge.GetClockSkew()
+	if !pmapi.IsClockSkewed(skew) {
+		f.Println("Clock is in sync with the server.")
+		return
+	}
+	f.Printf("Clock differs from the server clock by %s.\n", describeClockSkew(skew))
+}
+
+func (f *frontendCLI) printRequestStats(c *ishell.Context) {
+	stats := f.bridge.GetRequestStats()
+	if len(stats) == 0 {
+		f.Println("No requests to the server were made yet.")
+		return
+	}
+
+	f.Printf("%-45s %8s %7s %7s %9s %9s\n", "Endpoint", "Requests", "Errors", "Retries", "Avg (ms)", "Max (ms)")
+	for _, endpoint := range stats {
+		f.Printf(
+			"%-45s %8d %7d %7d %9d %9d\n",
+			endpoint.Endpoint,
+			endpoint.Requests,
+			endpoint.Errors,
+			endpoint.Retries,
+			endpoint.AverageLatency().Milliseconds(),
+			endpoint.MaxLatency.Milliseconds(),
+		)
+	}
+}
+
 func (f *frontendCLI) printLogDir(c *ishell.Context) {
 	f.Println("Log files are stored in\n\n ", f.config.GetLogDir())
 }
@@ -97,6 +141,18 @@ func (f *frontendCLI) changePort(c *ishell.Context) {
 	f.ShowPrompt(false)
 	defer f.ShowPrompt(true)
 
+	if f.askForPorts(c) {
+		f.Println("Restarting Bridge...")
+		f.appRestart = true
+		f.Stop()
+	} else {
+		f.Println(i18n.T("Nothing changed"))
+	}
+}
+
+// askForPorts reads and saves new IMAP and SMTP ports and returns whether
+// any of them changed. Bridge has to be restarted to use them.
+func (f *frontendCLI) askForPorts(c *ishell.Context) bool {
 	currentPort = f.preferences.Get(preferences.IMAPPortKey)
 	newIMAPPort := f.readStringInAttempts("Set IMAP port (current "+currentPort+")", c.ReadLine, f.isPortFree)
 	if newIMAPPort == "" {
@@ -113,19 +169,61 @@ func (f *frontendCLI) changePort(c *ishell.Context) {
 
 	if newIMAPPort == newSMTPPort {
 		f.Println("SMTP and IMAP ports must be different!")
+		return false
+	}
+
+	if !imapPortChanged && !smtpPortChanged {
+		return false
+	}
+
+	f.Println("Saving values IMAP:", newIMAPPort, "SMTP:", newSMTPPort)
+	f.preferences.Set(preferences.IMAPPortKey, newIMAPPort)
+	f.preferences.Set(preferences.SMTPPortKey, newSMTPPort)
+	return true
+}
+
+func (f *frontendCLI) changeListenHost(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	currentHost := f.preferences.Get(preferences.ListenHostKey)
+	isIP := func(host string) bool {
+		return net.ParseIP(host) != nil
+	}
+	newHost := f.readStringInAttempts("Set address of IMAP and SMTP servers, e.g. 127.0.0.1, ::1 or address in local network (current "+currentHost+")", c.ReadLine, isIP)
+	if newHost == "" {
+		f.Println(i18n.T("Nothing changed"))
 		return
 	}
 
-	if imapPortChanged || smtpPortChanged {
-		f.Println("Saving values IMAP:", newIMAPPort, "SMTP:", newSMTPPort)
-		f.preferences.Set(preferences.IMAPPortKey, newIMAPPort)
-		f.preferences.Set(preferences.SMTPPortKey, newSMTPPort)
-		f.Println("Restarting Bridge...")
-		f.appRestart = true
-		f.Stop()
-	} else {
-		f.Println("Nothing changed")
+	currentCAPath := f.preferences.Get(preferences.TLSClientCAKey)
+	newCAPath := ""
+	if !ports.IsLoopbackHost(newHost) {
+		f.Println("Other devices will be able to connect. Clients have to use STARTTLS or SSL and trust the bridge certificate.")
+		if !f.yesNoQuestion("Are you sure you want to listen on " + bold(newHost)) {
+			return
+		}
+		if f.yesNoQuestion("Do you want to accept only clients with a certificate issued by your CA") {
+			isCAFile := func(path string) bool {
+				_, err := config.GetTLSConfigWithClientAuth(&tls.Config{}, path)
+				return err == nil
+			}
+			if newCAPath = f.readStringInAttempts("Path to PEM file with CA certificates", c.ReadLine, isCAFile); newCAPath == "" {
+				return
+			}
+		}
 	}
+	if newHost == currentHost && newCAPath == currentCAPath {
+		f.Println(i18n.T("Nothing changed"))
+		return
+	}
+
+	f.Println("Saving address", newHost)
+	f.preferences.Set(preferences.ListenHostKey, newHost)
+	f.preferences.Set(preferences.TLSClientCAKey, newCAPath)
+	f.Println("Restarting Bridge...")
+	f.appRestart = true
+	f.Stop()
 }
 
 func (f *frontendCLI) toggleAllowProxy(c *ishell.Context) {
@@ -144,6 +242,120 @@ func (f *frontendCLI) toggleAllowProxy(c *ishell.Context) {
 	}
 }
 
+func (f *frontendCLI) toggleSilentUpdates(c *ishell.Context) {
+	if f.preferences.GetBool(preferences.SilentUpdatesKey) {
+		f.Println("Bridge is currently set to install updates on start without asking.")
+		if f.yesNoQuestion("Are you sure you want to stop bridge from doing this") {
+			f.preferences.SetBool(preferences.SilentUpdatesKey, false)
+		}
+	} else {
+		f.Println("Bridge is currently set to ask before installing updates.")
+		if f.yesNoQuestion("Are you sure you want to allow bridge to download updates in background and install them on next start") {
+			f.preferences.SetBool(preferences.SilentUpdatesKey, true)
+		}
+	}
+}
+
+func (f *frontendCLI) toggleLocalSocket(c *ishell.Context) {
+	if !localsocket.Supported {
+		f.Println("Local socket is not supported on this system.")
+		return
+	}
+
+	if f.preferences.GetBool(preferences.LocalSocketKey) {
+		f.Println("Bridge is currently listening also on local sockets:")
+		f.printLocalSockets()
+		if !f.yesNoQuestion("Are you sure you want to stop bridge from doing this") {
+			return
+		}
+		f.preferences.SetBool(preferences.LocalSocketKey, false)
+	} else {
+		f.Println("Bridge can listen also on unix sockets accessible only by your system user.")
+		f.Println("Clients connecting through them are trusted and any password is accepted.")
+		if !f.yesNoQuestion("Are you sure you want to allow bridge to do this") {
+			return
+		}
+		f.preferences.SetBool(preferences.LocalSocketKey, true)
+		f.printLocalSockets()
+	}
+
+	f.Println("Restarting Bridge...")
+	f.appRestart = true
+	f.Stop()
+}
+
+func (f *frontendCLI) toggleSMTPOnly(c *ishell.Context) {
+	if f.preferences.GetBool(preferences.SMTPOnlyKey) {
+		f.Println("Bridge is currently running only the SMTP server and does not sync mailboxes.")
+		if !f.yesNoQuestion("Are you sure you want to start also the IMAP server and sync mailboxes") {
+			return
+		}
+		f.preferences.SetBool(preferences.SMTPOnlyKey, false)
+	} else {
+		f.Println("Bridge can run only the SMTP server to relay outgoing messages.")
+		f.Println("The IMAP server will not be started and mailboxes will not be synced.")
+		if !f.yesNoQuestion("Are you sure you want bridge to do this") {
+			return
+		}
+		f.preferences.SetBool(preferences.SMTPOnlyKey, true)
+	}
+
+	f.Println("Restarting Bridge...")
+	f.appRestart = true
+	f.Stop()
+}
+
+func (f *frontendCLI) printLocalSockets() {
+	imapSocket := f.config.GetLocalSocketPath("imap")
+	smtpSocket := f.config.GetLocalSocketPath("smtp")
+	f.Println("  IMAP:", imapSocket)
+	f.Println("  SMTP:", smtpSocket)
+	f.Println("Clients which can run a tunnel command can use `" + os.Args[0] + " socket-connect imap`.")
+	f.Println("The same can be done by socat, e.g.:")
+	f.Println("  socat STDIO UNIX-CONNECT:" + imapSocket)
+}
+
+func (f *frontendCLI) changeNotifications(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	descriptions := map[string]string{
+		preferences.NotificationNewMail: "new mail in inbox",
+		preferences.NotificationError:   "errors",
+		preferences.NotificationUpdate:  "available updates",
+		preferences.NotificationLogout:  "accounts which need login",
+	}
+	for _, notification := range preferences.Notifications {
+		isOn := f.yesNoQuestion("Show notifications about " + bold(descriptions[notification]))
+		f.preferences.SetBool(preferences.NotificationKey(notification), isOn)
+	}
+
+	f.Println("Quiet hours suppress all notifications, e.g. from 22:00 to 07:00. Leave empty to turn them off.")
+	isValidOrEmpty := func(val string) bool {
+		return val == "" || preferences.IsValidQuietHoursTime(val)
+	}
+	f.Print("Start of quiet hours (HH:MM): ")
+	start := strings.TrimSpace(f.ReadLine())
+	if !isValidOrEmpty(start) {
+		f.Println("Wrong time format, quiet hours were not changed")
+		return
+	}
+	end := ""
+	if start != "" {
+		end = strings.TrimSpace(f.readStringInAttempts("End of quiet hours (HH:MM)", f.ReadLine, preferences.IsValidQuietHoursTime))
+		if end == "" {
+			return
+		}
+	}
+	f.preferences.Set(preferences.QuietHoursStartKey, start)
+	f.preferences.Set(preferences.QuietHoursEndKey, end)
+	f.Println("Notification settings saved")
+}
+
+func (f *frontendCLI) shouldNotify(notification string) bool {
+	return preferences.ShouldNotify(f.preferences, notification, time.Now())
+}
+
 func (f *frontendCLI) isPortFree(port string) bool {
 	port = strings.Replace(port, ":", "", -1)
 	if port == "" || port == currentPort {
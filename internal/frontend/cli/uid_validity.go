@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package cli
+
+import (
+	"strings"
+
+	"github.com/abiosoft/ishell"
+)
+
+func (f *frontendCLI) bumpUIDValidity(c *ishell.Context) {
+	f.ShowPrompt(false)
+	defer f.ShowPrompt(true)
+
+	user := f.askUserByIndexOrName(c)
+	if user == nil {
+		return
+	}
+	// With more accounts, the first argument was the account.
+	if len(f.bridge.GetUsers()) > 1 && len(c.Args) > 0 {
+		c.Args = c.Args[1:]
+	}
+	if len(c.Args) == 0 {
+		f.Println("Please use mailbox name, e.g. INBOX or Folders/Work, as parameter.")
+		return
+	}
+	mailboxName := strings.Join(c.Args, " ")
+
+	if !f.yesNoQuestion("Are you sure you want clients to download " + bold(mailboxName) + " again") {
+		return
+	}
+	if err := user.BumpUIDValidity(mailboxName); err != nil {
+		f.printAndLogError("Cannot change UIDVALIDITY: ", err)
+		return
+	}
+	f.Printf("UIDVALIDITY of %s was changed, clients will download it again.\n", bold(mailboxName))
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/ProtonMail/proton-bridge/internal/bridge"
+	"github.com/ProtonMail/proton-bridge/internal/frontend/i18n"
 	"github.com/ProtonMail/proton-bridge/internal/updates"
 	"github.com/abiosoft/ishell"
 )
@@ -33,7 +34,9 @@ func (f *frontendCLI) checkUpdates(c *ishell.Context) {
 		return
 	}
 	if isUpToDate {
-		f.Println("Your version is up to date.")
+		f.Println(i18n.T("Your version is up to date."))
+	} else if !f.updates.IsRolledOut(latestVersionInfo) {
+		f.Println("Version", latestVersionInfo.Version, "is available but not rolled out to this installation yet. It will be offered to you later.")
 	} else {
 		f.notifyNeedUpgrade()
 		f.Println("")
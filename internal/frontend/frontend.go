@@ -24,11 +24,13 @@ import (
 	"github.com/ProtonMail/proton-bridge/internal/frontend/cli"
 	cliie "github.com/ProtonMail/proton-bridge/internal/frontend/cli-ie"
 	"github.com/ProtonMail/proton-bridge/internal/frontend/qt"
+	"github.com/ProtonMail/proton-bridge/internal/frontend/job"
 	qtie "github.com/ProtonMail/proton-bridge/internal/frontend/qt-ie"
 	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
 	"github.com/ProtonMail/proton-bridge/internal/importexport"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/fatih/color"
 	"github.com/sirupsen/logrus"
 )
 
@@ -51,7 +53,7 @@ func HandlePanic(appName string) {
 	_ = notify.Push("Fatal Error", "The "+appName+" has encountered a fatal error. ", "/frontend/icon/icon.png", notificator.UR_CRITICAL)
 }
 
-// New returns initialized frontend based on `frontendType`, which can be `cli` or `qt`.
+// New returns initialized frontend based on `frontendType`, which can be `cli`, `cli-plain` or `qt`.
 func New(
 	version,
 	buildVersion,
@@ -64,9 +66,10 @@ func New(
 	updates types.Updater,
 	bridge *bridge.Bridge,
 	noEncConfirmator types.NoEncConfirmator,
+	outbox types.Outbox,
 ) Frontend {
 	bridgeWrap := types.NewBridgeWrap(bridge)
-	return new(version, buildVersion, frontendType, showWindowOnStart, panicHandler, config, preferences, eventListener, updates, bridgeWrap, noEncConfirmator)
+	return new(version, buildVersion, frontendType, showWindowOnStart, panicHandler, config, preferences, eventListener, updates, bridgeWrap, noEncConfirmator, outbox)
 }
 
 func new(
@@ -81,16 +84,18 @@ func new(
 	updates types.Updater,
 	bridge types.Bridger,
 	noEncConfirmator types.NoEncConfirmator,
+	outbox types.Outbox,
 ) Frontend {
 	switch frontendType {
-	case "cli":
-		return cli.New(panicHandler, config, preferences, eventListener, updates, bridge)
+	case "cli", "cli-plain":
+		setPlainOutput(frontendType)
+		return cli.New(panicHandler, config, preferences, eventListener, updates, bridge, outbox, frontendType == "cli-plain")
 	default:
 		return qt.New(version, buildVersion, showWindowOnStart, panicHandler, config, preferences, eventListener, updates, bridge, noEncConfirmator)
 	}
 }
 
-// NewImportExport returns initialized frontend based on `frontendType`, which can be `cli` or `qt`.
+// NewImportExport returns initialized frontend based on `frontendType`, which can be `cli`, `cli-plain` or `qt`.
 func NewImportExport(
 	version,
 	buildVersion,
@@ -116,9 +121,28 @@ func newImportExport(
 	ie types.ImportExporter,
 ) Frontend {
 	switch frontendType {
-	case "cli":
-		return cliie.New(panicHandler, config, eventListener, updates, ie)
+	case "cli", "cli-plain":
+		setPlainOutput(frontendType)
+		return cliie.New(panicHandler, config, eventListener, updates, ie, frontendType == "cli-plain")
 	default:
 		return qtie.New(version, buildVersion, panicHandler, config, eventListener, updates, ie)
 	}
 }
+
+// setPlainOutput turns off colors for `cli-plain`, including those printed
+// by the shell itself, e.g. in help. Colors are global for the process.
+func setPlainOutput(frontendType string) {
+	if frontendType == "cli-plain" {
+		color.NoColor = true
+	}
+}
+
+// NewImportExportJob returns frontend which runs import or export described
+// by JSON spec at `specPath` without any interaction.
+func NewImportExportJob(
+	specPath string,
+	panicHandler types.PanicHandler,
+	ie *importexport.ImportExport,
+) Frontend {
+	return job.New(panicHandler, types.NewImportExportWrap(ie), specPath)
+}
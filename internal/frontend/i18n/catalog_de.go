@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+var catalogDE = map[string]string{ //nolint[gochecknoglobals]
+	// Common prompts.
+	"yes":                         "ja",
+	"no":                          "nein",
+	"Too many attempts":           "Zu viele Versuche",
+	"Please fill %s: ":            "Bitte %s eingeben: ",
+	"Username":                    "Benutzername",
+	"Password":                    "Passwort",
+	"Two factor code":             "Zwei-Faktor-Code",
+	"Mailbox password":            "Postfach-Passwort",
+	"Server error:":               "Serverfehler:",
+	"Nothing changed":             "Nichts geändert",
+	"Your version is up to date.": "Ihre Version ist aktuell.",
+
+	// Notifications.
+	"Internet connection is not available.":                                                                            "Keine Internetverbindung verfügbar.",
+	"Internet connection is available again.":                                                                          "Die Internetverbindung ist wieder verfügbar.",
+	"Paused by server for %s seconds because of too many requests.":                                                    "Vom Server wegen zu vieler Anfragen für %s Sekunden pausiert.",
+	"Account %s is disconnected. Login to continue using this account with email client.":                              "Konto %s ist getrennt. Melden Sie sich an, um dieses Konto weiter mit dem E-Mail-Programm zu nutzen.",
+	"Please download and install the newest version of application from":                                               "Bitte laden Sie die neueste Version der Anwendung herunter und installieren Sie sie von",
+	"Too many failed login attempts to account %s from an email client.\n":                                             "Zu viele fehlgeschlagene Anmeldeversuche am Konto %s von einem E-Mail-Programm.\n",
+	"Logins to this account are refused for a while. If it was not you, someone may be guessing your bridge password.": "Anmeldungen an diesem Konto werden eine Weile abgelehnt. Falls Sie es nicht waren, versucht möglicherweise jemand, Ihr Bridge-Passwort zu erraten.",
+
+	// Import-Export.
+	"Failed to init transferrer: ":                                     "Übertragung konnte nicht gestartet werden: ",
+	"Failed to create global mailbox: ":                                "Gemeinsamer Ordner konnte nicht erstellt werden: ",
+	"Transfer failed: ":                                                "Übertragung fehlgeschlagen: ",
+	"Transfer finished with errors:":                                   "Übertragung mit Fehlern beendet:",
+	"Estimating the size of the transfer...":                           "Größe der Übertragung wird geschätzt...",
+	"Failed to estimate transfer: ":                                    "Übertragung konnte nicht geschätzt werden: ",
+	"Estimated transfer: ":                                             "Geschätzte Übertragung: ",
+	"Start transfer anyway":                                            "Übertragung trotzdem starten",
+	"Start transfer":                                                   "Übertragung starten",
+	"Another transfer is running. Stop it first with `transfer stop`.": "Eine andere Übertragung läuft. Beenden Sie sie zuerst mit `transfer stop`.",
+	"Continue unfinished transfer (no starts from the beginning)":      "Unvollständige Übertragung fortsetzen (nein beginnt von vorne)",
+	"Transfer started. Use `transfer status`, `transfer pause`, `transfer resume` or `transfer stop` to control it.": "Übertragung gestartet. Verwenden Sie `transfer status`, `transfer pause`, `transfer resume` oder `transfer stop`, um sie zu steuern.",
+	"Transfer is paused: %s. Use `transfer resume` or `transfer stop`.":                                              "Übertragung pausiert: %s. Verwenden Sie `transfer resume` oder `transfer stop`.",
+	"No transfer was started.": "Es wurde keine Übertragung gestartet.",
+	"No transfer is running.":  "Es läuft keine Übertragung.",
+	"Transfer resumed.":        "Übertragung fortgesetzt.",
+	"Both accounts have to be logged in. Log in the source account with the login command first.": "Beide Konten müssen angemeldet sein. Melden Sie zuerst das Quellkonto mit dem Befehl login an.",
+	"Folders:": "Ordner:",
+
+	// Transfer errors.
+	"skipping encrypted message":                      "verschlüsselte Nachricht wird übersprungen",
+	"mailbox is already created":                      "Ordner existiert bereits",
+	"import ended with no result":                     "Import ohne Ergebnis beendet",
+	"no report found":                                 "kein Bericht gefunden",
+	"rule can have only one exclusive target mailbox": "Regel darf nur einen exklusiven Zielordner haben",
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package i18n provides translations of text frontend messages.
+//
+// Messages are looked up by their English text, so untranslated messages
+// are simply shown in English. Locale is taken from BRIDGE_LANG and then
+// from the standard LC_ALL, LC_MESSAGES and LANG environment variables.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// EnvVar is environment variable which overrides the system locale.
+const EnvVar = "BRIDGE_LANG"
+
+var (
+	catalog     map[string]string //nolint[gochecknoglobals]
+	catalogLock sync.RWMutex      //nolint[gochecknoglobals]
+	initOnce    sync.Once         //nolint[gochecknoglobals]
+)
+
+// SetLocale selects catalog for the locale, e.g. `de` or `de_DE.UTF-8`.
+// Unknown locale means no translation.
+func SetLocale(locale string) {
+	// Explicitly set locale is not overridden by environment later.
+	initOnce.Do(func() {})
+
+	catalogLock.Lock()
+	defer catalogLock.Unlock()
+
+	catalog = catalogs[parseLanguage(locale)]
+}
+
+// GetLocaleFromEnv returns locale set by environment variables.
+func GetLocaleFromEnv() string {
+	for _, envVar := range []string{EnvVar, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale := os.Getenv(envVar); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
+
+// parseLanguage returns language part of locale such as `de_DE.UTF-8@euro`.
+func parseLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "_.@-"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ToLower(locale)
+}
+
+// T returns translation of the message or the message itself.
+func T(message string) string {
+	initOnce.Do(func() {
+		catalog = catalogs[parseLanguage(GetLocaleFromEnv())]
+	})
+
+	catalogLock.RLock()
+	defer catalogLock.RUnlock()
+
+	if translation, ok := catalog[message]; ok {
+		return translation
+	}
+	return message
+}
+
+// Tf translates the format and formats it with args.
+func Tf(format string, args ...interface{}) string {
+	return fmt.Sprintf(T(format), args...)
+}
+
+// Args translates all strings and errors in args, e.g. for Println.
+func Args(args ...interface{}) []interface{} {
+	translated := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			translated[i] = T(v)
+		case error:
+			translated[i] = T(v.Error())
+		default:
+			translated[i] = arg
+		}
+	}
+	return translated
+}
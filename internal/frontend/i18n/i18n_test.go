@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package i18n
+
+import (
+	"errors"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestParseLanguage(t *testing.T) {
+	for locale, want := range map[string]string{
+		"":            "",
+		"de":          "de",
+		"de_DE.UTF-8": "de",
+		"DE-at":       "de",
+		"fr_FR@euro":  "fr",
+		"C":           "c",
+	} {
+		r.Equal(t, want, parseLanguage(locale), locale)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	defer SetLocale("")
+
+	SetLocale("de_DE.UTF-8")
+	r.Equal(t, "ja", T("yes"))
+	r.Equal(t, "not in catalog", T("not in catalog"))
+	r.Equal(t, "Bitte Passwort eingeben: ", Tf("Please fill %s: ", T("Password")))
+	r.Equal(t, []interface{}{"Serverfehler:", "kein Bericht gefunden", 42}, Args("Server error:", errors.New("no report found"), 42))
+
+	SetLocale("xx")
+	r.Equal(t, "yes", T("yes"))
+}
+
+func TestEveryLanguageHasSameFormatVerbs(t *testing.T) {
+	for language, catalog := range catalogs {
+		for message, translation := range catalog {
+			r.Equal(t, countVerbs(message), countVerbs(translation), "%s: %q", language, message)
+		}
+	}
+}
+
+func countVerbs(s string) (n int) {
+	for i := 0; i < len(s)-1; i++ {
+		if s[i] == '%' {
+			n++
+			i++
+		}
+	}
+	return
+}
@@ -0,0 +1,457 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package job provides frontend running one import or export described by
+// JSON spec without any user interaction, e.g. from cron or CI scripts.
+package job
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/frontend/types"
+	"github.com/ProtonMail/proton-bridge/internal/transfer"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.WithField("pkg", "frontend/job") //nolint[gochecknoglobals]
+
+const (
+	// Transfer pauses on errors, e.g. when connection is lost. Without
+	// user to resume it, it is resumed after retryDelay up to maxRetries.
+	retryDelay = 30 * time.Second
+	maxRetries = 10
+
+	progressInterval = 10 * time.Second
+)
+
+type frontendJob struct {
+	panicHandler types.PanicHandler
+	ie           types.ImportExporter
+	specPath     string
+
+	// jsonProgress replaces human-readable output by JSON lines.
+	jsonProgress bool
+}
+
+// New returns a frontend which runs the job from specPath.
+func New(panicHandler types.PanicHandler, ie types.ImportExporter, specPath string) *frontendJob { //nolint[golint]
+	return &frontendJob{
+		panicHandler: panicHandler,
+		ie:           ie,
+		specPath:     specPath,
+	}
+}
+
+// IsAppRestarting returns false as job never restarts the app.
+func (f *frontendJob) IsAppRestarting() bool {
+	return false
+}
+
+// Loop runs the job and returns error when it could not be finished
+// or when any message failed to transfer.
+func (f *frontendJob) Loop(credentialsError error) error {
+	if credentialsError != nil {
+		return errors.Wrap(credentialsError, "credentials store is not available")
+	}
+
+	spec, err := LoadSpec(f.specPath)
+	if err != nil {
+		return err
+	}
+
+	t, err := f.getTransfer(spec)
+	if err != nil {
+		return errors.Wrap(err, "failed to init transfer")
+	}
+
+	dryRun := spec.DryRun || f.ie.IsDryRunRequested()
+	f.jsonProgress = f.ie.IsJSONProgressRequested()
+
+	if err := f.setUpTransfer(t, spec, dryRun); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return f.dryRun(t)
+	}
+
+	f.printRules(t)
+
+	if estimate, err := t.Estimate(); err != nil {
+		log.WithError(err).Warn("Failed to estimate transfer")
+	} else if f.jsonProgress {
+		log.Info("Estimated transfer: ", estimate.String())
+	} else {
+		fmt.Println("Estimated transfer:", estimate.String())
+	}
+
+	return f.run(t.Start())
+}
+
+func (f *frontendJob) getTransfer(spec *Spec) (*transfer.Transfer, error) {
+	address, err := f.getAddress(spec.Account)
+	if err != nil {
+		return nil, err
+	}
+
+	if loc := spec.Import; loc != nil {
+		switch loc.Type {
+		case "local":
+			return f.ie.GetLocalImporter(address, loc.Path)
+		case "imap":
+			return f.ie.GetRemoteImporter(address, loc.Username, loc.Password, loc.Host, loc.Port)
+		case "plugin":
+			return f.ie.GetPluginImporter(address, loc.Path)
+		default:
+			sourceAddress, err := f.getAddress(loc.Account)
+			if err != nil {
+				return nil, err
+			}
+			return f.ie.GetProtonImporter(sourceAddress, address)
+		}
+	}
+
+	if spec.Exports != nil {
+		targets := []transfer.TargetProvider{}
+		for _, loc := range spec.Exports {
+			target, err := newExportTarget(loc)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, target)
+		}
+		return f.ie.GetMultiExporter(address, targets)
+	}
+
+	loc := spec.Export
+	switch loc.Type {
+	case "eml":
+		return f.ie.GetEMLExporter(address, loc.Path)
+	case "mbox":
+		return f.ie.GetMBOXExporter(address, loc.Path)
+	case "maildir":
+		return f.ie.GetMaildirExporter(address, loc.Path)
+	case "archive":
+		return f.ie.GetArchiveExporter(address, loc.Path)
+	case "webdav":
+		return f.ie.GetWebDAVExporter(address, loc.URL, loc.Username, loc.Password)
+	case "plugin":
+		return f.ie.GetPluginExporter(address, loc.Path)
+	default:
+		return f.ie.GetS3Exporter(address, *loc.S3)
+	}
+}
+
+// newExportTarget creates target of multi-target export. Single export
+// is created by import-export which sets up the target itself.
+func newExportTarget(loc *Location) (transfer.TargetProvider, error) {
+	switch loc.Type {
+	case "eml":
+		return transfer.NewEMLProvider(loc.Path), nil
+	case "mbox":
+		return transfer.NewMBOXProvider(loc.Path), nil
+	case "maildir":
+		return transfer.NewMaildirProvider(loc.Path), nil
+	case "archive":
+		return transfer.NewArchiveProvider(loc.Path)
+	case "webdav":
+		return transfer.NewWebDAVProvider(loc.URL, loc.Username, loc.Password)
+	case "plugin":
+		return transfer.NewPluginTargetProvider(loc.Path)
+	default:
+		return transfer.NewS3Provider(*loc.S3)
+	}
+}
+
+// getAddress returns primary address of logged in account with the given
+// username or address.
+func (f *frontendJob) getAddress(account string) (string, error) {
+	for _, user := range f.ie.GetUsers() {
+		if !user.IsConnected() {
+			continue
+		}
+		if user.Username() == account {
+			return user.GetPrimaryAddress(), nil
+		}
+		for _, address := range user.GetAddresses() {
+			if address == account {
+				return user.GetPrimaryAddress(), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("account %s is not logged in", account)
+}
+
+// setUpTransfer applies the spec to the transfer. With dryRun, missing
+// target mailboxes are only named, not created.
+func (f *frontendJob) setUpTransfer(t *transfer.Transfer, spec *Spec, dryRun bool) error {
+	if !spec.Resume && !f.ie.IsResumeRequested() {
+		t.ResetState()
+	}
+
+	t.SetSkipEncryptedMessages(spec.SkipEncrypted)
+	t.SetChecksums(spec.Checksums)
+	t.SetDateLayout(spec.DateLayout)
+	if spec.FolderNames != "" {
+		normalization, _ := transfer.ParseNameNormalization(spec.FolderNames)
+		t.SetNameNormalization(normalization)
+	}
+
+	fromTime, toTime, _ := spec.timeLimit()
+	if spec.Mappings == nil {
+		t.SetGlobalTimeLimit(fromTime, toTime)
+	} else if err := f.setMappings(t, spec.Mappings, fromTime, toTime, dryRun); err != nil {
+		return err
+	}
+
+	if spec.Deduplicate {
+		if err := t.SetDeduplication(true); err != nil {
+			return errors.Wrap(err, "failed to set deduplication")
+		}
+	}
+
+	if spec.Filter != nil {
+		if err := t.SetMessageFilter(*spec.Filter); err != nil {
+			return errors.Wrap(err, "failed to set message filter")
+		}
+	}
+
+	if spec.Label != "" {
+		label := transfer.Mailbox{
+			Name:        spec.Label,
+			Color:       pmapi.LabelColors[0],
+			IsExclusive: false,
+		}
+		if !dryRun {
+			var err error
+			if label, err = t.CreateTargetMailbox(label); err != nil {
+				return errors.Wrap(err, "failed to create label")
+			}
+		}
+		t.SetGlobalMailbox(&label)
+	}
+
+	return nil
+}
+
+func (f *frontendJob) setMappings(t *transfer.Transfer, mappings map[string][]string, fromTime, toTime int64, dryRun bool) error {
+	targetMailboxes, err := t.TargetMailboxes()
+	if err != nil {
+		return errors.Wrap(err, "failed to list target mailboxes")
+	}
+
+	found := map[string]bool{}
+	for _, rule := range t.GetRules() {
+		targetNames, ok := mappings[rule.SourceMailbox.Name]
+		if !ok {
+			t.UnsetRule(rule.SourceMailbox)
+			continue
+		}
+		found[rule.SourceMailbox.Name] = true
+
+		var targets []transfer.Mailbox
+		for _, name := range targetNames {
+			target, err := getOrCreateMailbox(t, targetMailboxes, name, dryRun)
+			if err != nil {
+				return err
+			}
+			targets = append(targets, target)
+		}
+
+		if err := t.SetRule(rule.SourceMailbox, targets, fromTime, toTime); err != nil {
+			return errors.Wrapf(err, "failed to map %s", rule.SourceMailbox.Name)
+		}
+	}
+
+	for name := range mappings {
+		if !found[name] {
+			return fmt.Errorf("source mailbox %s does not exist", name)
+		}
+	}
+
+	return nil
+}
+
+func getOrCreateMailbox(t *transfer.Transfer, mailboxes []transfer.Mailbox, name string, dryRun bool) (transfer.Mailbox, error) {
+	for _, mailbox := range mailboxes {
+		if mailbox.Name == name {
+			return mailbox, nil
+		}
+	}
+
+	mailbox := transfer.Mailbox{
+		Name:        name,
+		Color:       pmapi.LabelColors[0],
+		IsExclusive: true,
+	}
+	if dryRun {
+		return mailbox, nil
+	}
+
+	mailbox, err := t.CreateTargetMailbox(mailbox)
+	if err != nil {
+		return transfer.Mailbox{}, errors.Wrapf(err, "failed to create mailbox %s", name)
+	}
+	return mailbox, nil
+}
+
+// dryRun prints what the transfer would do without starting it.
+func (f *frontendJob) dryRun(t *transfer.Transfer) error {
+	fmt.Println("Dry run, nothing will be transferred.")
+	report, err := t.DryRun()
+	if err != nil {
+		return errors.Wrap(err, "dry run failed")
+	}
+	fmt.Print(report.String())
+	return nil
+}
+
+func (f *frontendJob) printRules(t *transfer.Transfer) {
+	if f.jsonProgress {
+		for _, rule := range t.GetRules() {
+			if rule.Active {
+				log.Info("Rule: ", rule.String())
+			}
+		}
+		return
+	}
+
+	fmt.Println("Rules:")
+	for _, rule := range t.GetRules() {
+		if rule.Active {
+			fmt.Println(" ", rule.String())
+		}
+	}
+}
+
+// run waits until the transfer finishes, prints progress regularly and
+// resumes it when it is paused due to an error.
+func (f *frontendJob) run(progress *transfer.Progress) error {
+	updateCh := progress.GetUpdateChannel()
+	if updateCh == nil {
+		return f.printResult(progress)
+	}
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	retries := 0
+	var retryCh <-chan time.Time
+	checkPause := func() {
+		if !progress.IsPaused() || retryCh != nil {
+			return
+		}
+		f.printSnapshot(progress, transfer.ProgressEventPaused)
+		if retries >= maxRetries {
+			log.Error("Too many errors, stopping the transfer")
+			progress.Stop()
+			return
+		}
+		retries++
+		log.WithField("reason", progress.PauseReason()).Warn("Transfer paused, resuming in ", retryDelay)
+		retryCh = time.After(retryDelay)
+	}
+
+	for {
+		select {
+		case _, ok := <-updateCh:
+			if !ok {
+				return f.printResult(progress)
+			}
+			checkPause()
+		case <-retryCh:
+			retryCh = nil
+			progress.Resume()
+		case <-ticker.C:
+			if f.jsonProgress {
+				f.printSnapshot(progress, transfer.ProgressEventProgress)
+			} else {
+				failed, imported, exported, added, total := progress.GetCounts()
+				fmt.Printf("Progress: %d (%d / %d) / %d, failed: %d\n", imported, exported, added, total, failed)
+			}
+			checkPause()
+		}
+	}
+}
+
+// printSnapshot writes the progress as JSON line when requested.
+func (f *frontendJob) printSnapshot(progress *transfer.Progress, event string) {
+	if !f.jsonProgress {
+		return
+	}
+	if err := progress.WriteSnapshot(os.Stdout, event); err != nil {
+		log.WithError(err).Error("Cannot write progress")
+	}
+}
+
+func (f *frontendJob) printResult(progress *transfer.Progress) error {
+	if f.jsonProgress {
+		return f.printJSONResult(progress)
+	}
+
+	if err := progress.GetFatalError(); err != nil {
+		return errors.Wrap(err, "transfer failed")
+	}
+
+	fmt.Println("Folders:")
+	for _, folder := range progress.GetFolderCounts() {
+		fmt.Printf(" %-30s %d / %d, failed: %d\n", folder.Name, folder.Imported, folder.Total, folder.Failed)
+	}
+
+	statuses := progress.GetFailedMessages()
+	for _, status := range statuses {
+		fmt.Printf(" %s | %s | %s: %s\n", status.Time.Format("Jan 02 2006 15:04"), status.From, status.Subject, status.GetErrorMessage())
+	}
+	if len(statuses) != 0 {
+		return fmt.Errorf("%d messages failed, see %s", len(statuses), progress.FileReport())
+	}
+
+	if progress.IsStopped() {
+		return errors.New("transfer was stopped")
+	}
+
+	fmt.Println("Transfer finished!")
+	return nil
+}
+
+// printJSONResult writes the final snapshot and returns the same errors
+// as printResult.
+func (f *frontendJob) printJSONResult(progress *transfer.Progress) error {
+	if err := progress.GetFatalError(); err != nil {
+		f.printSnapshot(progress, transfer.ProgressEventFailed)
+		return errors.Wrap(err, "transfer failed")
+	}
+
+	statuses := progress.GetFailedMessages()
+	if len(statuses) != 0 || progress.IsStopped() {
+		f.printSnapshot(progress, transfer.ProgressEventFailed)
+	} else {
+		f.printSnapshot(progress, transfer.ProgressEventFinished)
+	}
+
+	if len(statuses) != 0 {
+		return fmt.Errorf("%d messages failed, see %s", len(statuses), progress.FileReport())
+	}
+	if progress.IsStopped() {
+		return errors.New("transfer was stopped")
+	}
+	return nil
+}
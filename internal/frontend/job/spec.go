@@ -0,0 +1,258 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/transfer"
+	"github.com/pkg/errors"
+)
+
+// dateLayout is the format of `from` and `to` dates in the spec.
+const dateLayout = "2006-01-02"
+
+// Spec describes one import or export. Exactly one of Import, Export and
+// Exports has to be set. Password and secret key values can reference environment
+// variables, e.g. `$IMAP_PASSWORD`, so the file does not need to hold them.
+type Spec struct {
+	// Account is username or address of logged in account which is the
+	// target of import or the source of export.
+	Account string `json:"account"`
+
+	Import *Location `json:"import,omitempty"`
+	Export *Location `json:"export,omitempty"`
+
+	// Exports are several targets written in one pass, e.g. EML folder
+	// and S3 bucket. Each message is downloaded only once.
+	Exports []*Location `json:"exports,omitempty"`
+
+	// Mappings maps names of source mailboxes to names of target mailboxes.
+	// When set, only listed mailboxes are transferred; missing targets are
+	// created. Otherwise, mailboxes are matched by name.
+	Mappings map[string][]string `json:"mappings,omitempty"`
+
+	// From and To limit messages by date (YYYY-MM-DD), both are inclusive.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+
+	// Label is added to all imported messages, if set.
+	Label string `json:"label,omitempty"`
+
+	// Filter limits exported messages by labels, addresses, subject or
+	// attachments. It is supported only for export.
+	Filter *transfer.MessageFilter `json:"filter,omitempty"`
+
+	SkipEncrypted bool   `json:"skipEncrypted,omitempty"`
+	Checksums     bool   `json:"checksums,omitempty"`
+	DateLayout    bool   `json:"dateLayout,omitempty"`
+	FolderNames   string `json:"folderNames,omitempty"`
+	Deduplicate   bool   `json:"deduplicate,omitempty"`
+
+	// Resume continues unfinished transfer instead of starting over.
+	Resume bool `json:"resume,omitempty"`
+	// DryRun only prints what would be transferred.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// Location is the source of import or one target of export.
+type Location struct {
+	// Type is local, imap, proton or plugin for import and eml, mbox,
+	// maildir, archive, webdav, s3 or plugin for export. Path of plugin type is
+	// the location passed to the plugin, e.g., path or URL.
+	Type string `json:"type"`
+
+	Path     string `json:"path,omitempty"`
+	Account  string `json:"account,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     string `json:"port,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	S3 *transfer.S3Config `json:"s3,omitempty"`
+}
+
+// LoadSpec reads and validates the spec from JSON file.
+func LoadSpec(path string) (*Spec, error) {
+	f, err := os.Open(path) //nolint[gosec]
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	spec := &Spec{}
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(spec); err != nil {
+		return nil, errors.Wrap(err, "invalid job spec")
+	}
+
+	if err := spec.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid job spec")
+	}
+
+	spec.expandSecrets()
+
+	return spec, nil
+}
+
+func (spec *Spec) validate() error {
+	if spec.Account == "" {
+		return errors.New("account is missing")
+	}
+
+	directions := 0
+	for _, isSet := range []bool{spec.Import != nil, spec.Export != nil, spec.Exports != nil} {
+		if isSet {
+			directions++
+		}
+	}
+	if directions != 1 {
+		return errors.New("exactly one of import, export and exports has to be set")
+	}
+
+	exportTypes := []string{"eml", "mbox", "maildir", "archive", "webdav", "s3", "plugin"}
+	switch {
+	case spec.Import != nil:
+		if err := spec.Import.validate("local", "imap", "proton", "plugin"); err != nil {
+			return errors.Wrap(err, "import")
+		}
+	case spec.Export != nil:
+		if err := spec.Export.validate(exportTypes...); err != nil {
+			return errors.Wrap(err, "export")
+		}
+	default:
+		if len(spec.Exports) < 2 {
+			return errors.New("exports need at least two targets, use export for one")
+		}
+		for i, loc := range spec.Exports {
+			if loc == nil {
+				return fmt.Errorf("exports %d is empty", i+1)
+			}
+			if err := loc.validate(exportTypes...); err != nil {
+				return errors.Wrapf(err, "exports %d", i+1)
+			}
+		}
+	}
+
+	if _, _, err := spec.timeLimit(); err != nil {
+		return err
+	}
+
+	if spec.FolderNames != "" {
+		if _, err := transfer.ParseNameNormalization(spec.FolderNames); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (loc *Location) validate(types ...string) error {
+	known := false
+	for _, t := range types {
+		known = known || t == loc.Type
+	}
+	if !known {
+		return fmt.Errorf("unknown type %q, use one of %v", loc.Type, types)
+	}
+
+	missing := ""
+	switch loc.Type {
+	case "local", "eml", "mbox", "maildir", "archive", "plugin":
+		if loc.Path == "" {
+			missing = "path"
+		}
+	case "imap":
+		switch {
+		case loc.Host == "":
+			missing = "host"
+		case loc.Port == "":
+			missing = "port"
+		case loc.Username == "":
+			missing = "username"
+		}
+	case "proton":
+		if loc.Account == "" {
+			missing = "account"
+		}
+	case "webdav":
+		if loc.URL == "" {
+			missing = "url"
+		}
+	case "s3":
+		if loc.S3 == nil || loc.S3.Endpoint == "" || loc.S3.Bucket == "" {
+			missing = "s3 endpoint and bucket"
+		}
+	}
+	if missing != "" {
+		return fmt.Errorf("%s is missing for %s", missing, loc.Type)
+	}
+
+	if loc.Type == "archive" && !transfer.IsArchivePath(loc.Path) {
+		return errors.New("archive path has to end with .zip, .tar, .tar.zst or .tar.gz")
+	}
+
+	return nil
+}
+
+func (spec *Spec) expandSecrets() {
+	for _, loc := range append([]*Location{spec.Import, spec.Export}, spec.Exports...) {
+		if loc == nil {
+			continue
+		}
+		loc.Password = os.ExpandEnv(loc.Password)
+		if loc.S3 != nil {
+			loc.S3.SecretKey = os.ExpandEnv(loc.S3.SecretKey)
+		}
+	}
+}
+
+// timeLimit returns unix times of the date range or zeros for no limit.
+func (spec *Spec) timeLimit() (fromTime, toTime int64, err error) {
+	if spec.From == "" && spec.To == "" {
+		return 0, 0, nil
+	}
+
+	if spec.From != "" {
+		from, err := time.ParseInLocation(dateLayout, spec.From, time.Local)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "invalid from date")
+		}
+		fromTime = from.Unix()
+	}
+
+	toTime = time.Now().Unix()
+	if spec.To != "" {
+		to, err := time.ParseInLocation(dateLayout, spec.To, time.Local)
+		if err != nil {
+			return 0, 0, errors.Wrap(err, "invalid to date")
+		}
+		toTime = to.AddDate(0, 0, 1).Unix() - 1
+	}
+
+	if fromTime > toTime {
+		return 0, 0, errors.New("from date is after to date")
+	}
+
+	return fromTime, toTime, nil
+}
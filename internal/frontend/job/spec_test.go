@@ -0,0 +1,100 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package job
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestSpec(t *testing.T, content string) (*Spec, error) {
+	dir, err := ioutil.TempDir("", "job")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	path := filepath.Join(dir, "job.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+
+	return LoadSpec(path)
+}
+
+func TestLoadSpec(t *testing.T) {
+	require.NoError(t, os.Setenv("JOB_TEST_PASSWORD", "secret"))
+	defer os.Unsetenv("JOB_TEST_PASSWORD") //nolint[errcheck]
+
+	spec, err := loadTestSpec(t, `{
+		"account": "user@pm.me",
+		"import": {"type": "imap", "host": "imap.example.com", "port": "993", "username": "user", "password": "$JOB_TEST_PASSWORD"},
+		"mappings": {"INBOX": ["Inbox"], "Work": ["Folders/Work", "Labels/Imported"]},
+		"from": "2020-01-01",
+		"to": "2020-01-31"
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, "secret", spec.Import.Password)
+	require.Equal(t, []string{"Folders/Work", "Labels/Imported"}, spec.Mappings["Work"])
+
+	fromTime, toTime, err := spec.timeLimit()
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2020, 1, 1, 0, 0, 0, 0, time.Local).Unix(), fromTime)
+	require.Equal(t, time.Date(2020, 2, 1, 0, 0, 0, 0, time.Local).Unix()-1, toTime)
+}
+
+func TestLoadSpecWithExports(t *testing.T) {
+	require.NoError(t, os.Setenv("JOB_TEST_SECRET_KEY", "secret"))
+	defer os.Unsetenv("JOB_TEST_SECRET_KEY") //nolint[errcheck]
+
+	spec, err := loadTestSpec(t, `{
+		"account": "user@pm.me",
+		"exports": [
+			{"type": "eml", "path": "/tmp/backup"},
+			{"type": "s3", "s3": {"endpoint": "https://s3.example.com", "bucket": "backup", "secretKey": "$JOB_TEST_SECRET_KEY"}}
+		]
+	}`)
+	require.NoError(t, err)
+	require.Len(t, spec.Exports, 2)
+	require.Equal(t, "secret", spec.Exports[1].S3.SecretKey)
+}
+
+func TestLoadInvalidSpec(t *testing.T) {
+	tests := map[string]string{
+		"no account":         `{"export": {"type": "eml", "path": "/tmp"}}`,
+		"no direction":       `{"account": "user"}`,
+		"both directions":    `{"account": "user", "import": {"type": "local", "path": "/tmp"}, "export": {"type": "eml", "path": "/tmp"}}`,
+		"unknown type":       `{"account": "user", "export": {"type": "local", "path": "/tmp"}}`,
+		"missing path":       `{"account": "user", "export": {"type": "mbox"}}`,
+		"wrong archive":      `{"account": "user", "export": {"type": "archive", "path": "/tmp/backup.rar"}}`,
+		"missing s3":         `{"account": "user", "export": {"type": "s3"}}`,
+		"missing plugin":     `{"account": "user", "import": {"type": "plugin"}}`,
+		"wrong date":         `{"account": "user", "export": {"type": "eml", "path": "/tmp"}, "from": "01/01/2020"}`,
+		"reversed dates":     `{"account": "user", "export": {"type": "eml", "path": "/tmp"}, "from": "2020-02-01", "to": "2020-01-01"}`,
+		"unknown field":      `{"account": "user", "export": {"type": "eml", "path": "/tmp"}, "foo": true}`,
+		"wrong folderNames":  `{"account": "user", "export": {"type": "eml", "path": "/tmp"}, "folderNames": "upper"}`,
+		"single exports":     `{"account": "user", "exports": [{"type": "eml", "path": "/tmp"}]}`,
+		"export and exports": `{"account": "user", "export": {"type": "eml", "path": "/tmp"}, "exports": [{"type": "eml", "path": "/tmp"}, {"type": "mbox", "path": "/tmp"}]}`,
+		"invalid exports":    `{"account": "user", "exports": [{"type": "eml", "path": "/tmp"}, {"type": "mbox"}]}`,
+	}
+	for name, content := range tests {
+		_, err := loadTestSpec(t, content)
+		require.Error(t, err, name)
+	}
+}
@@ -254,6 +254,51 @@ Column {
             }
         }
 
+        Rectangle {
+            id: protocolsWrapper
+            anchors {
+                left  : parent.left
+                right : parent.right
+            }
+            visible : mainaccRow.state=="expanded"
+            height  : 2*Style.accounts.heightAddrRow/3
+            color   : Style.accounts.backgroundExpanded
+
+            ClickIconText {
+                id: imapToggle
+                anchors {
+                    top        : protocolsWrapper.top
+                    left       : protocolsWrapper.left
+                    leftMargin : Style.accounts.leftMarginAddr+Style.main.leftMargin
+                }
+                textColor   : Style.main.textBlue
+                iconText    : isIMAPEnabled ? Style.fa.toggle_on : Style.fa.toggle_off
+                iconOnRight : false
+                text        : isIMAPEnabled ?
+                qsTr("IMAP enabled", "Text of button disabling IMAP access of the account.") :
+                qsTr("IMAP disabled", "Text of button enabling IMAP access of the account.")
+
+                onClicked: go.toggleIMAPEnabled(root.iAccount)
+            }
+
+            ClickIconText {
+                id: smtpToggle
+                anchors {
+                    top         : protocolsWrapper.top
+                    right       : protocolsWrapper.right
+                    rightMargin : Style.main.rightMargin
+                }
+                textColor   : Style.main.textBlue
+                iconText    : isSMTPEnabled ? Style.fa.toggle_on : Style.fa.toggle_off
+                iconOnRight : false
+                text        : isSMTPEnabled ?
+                qsTr("SMTP enabled", "Text of button disabling SMTP access of the account.") :
+                qsTr("SMTP disabled", "Text of button enabling SMTP access of the account.")
+
+                onClicked: go.toggleSMTPEnabled(root.iAccount)
+            }
+        }
+
         Repeater {
             id: repeaterAddresses
             model: ["one", "two"]
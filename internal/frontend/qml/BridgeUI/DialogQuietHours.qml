@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Dialog to set quiet hours when no notifications are shown
+
+import QtQuick 2.8
+import BridgeUI 1.0
+import ProtonUI 1.0
+
+Dialog {
+    id: root
+
+    title : qsTr("Set quiet hours", "title of dialog to set time when no notifications are shown")
+    subtitle : qsTr("No notifications are shown between start and end (HH:MM). Leave both empty to turn quiet hours off.", "description of quiet hours dialog")
+
+    Column {
+        id: dialogMessage
+        property int heightInputs : quietStart.height + middleSep.height + quietEnd.height + buttonSep.height + buttonRow.height
+
+        Rectangle { color : "transparent"; width : Style.main.dummy; height : (root.height-dialogMessage.heightInputs)/1.6 }
+
+        InputField {
+            id: quietStart
+            iconText        : Style.fa.moon_o
+            label           : qsTr("Start", "entry field for start of quiet hours")
+            placeholderText : "22:00"
+        }
+
+        Rectangle { id:middleSep; color : "transparent"; width : Style.main.dummy; height : Style.dialog.heightSeparator }
+
+        InputField {
+            id: quietEnd
+            iconText        : Style.fa.clock_o
+            label           : qsTr("End", "entry field for end of quiet hours")
+            placeholderText : "07:00"
+        }
+
+        Rectangle { id:buttonSep; color : "transparent"; width : Style.main.dummy; height : 2*Style.dialog.heightSeparator }
+
+        Row {
+            id: buttonRow
+            anchors.horizontalCenter: parent.horizontalCenter
+            spacing: Style.dialog.spacing
+            ButtonRounded {
+                id:buttonNo
+                color_main: Style.dialog.text
+                fa_icon: Style.fa.times
+                text: qsTr("Cancel", "dismisses current action")
+                onClicked : root.hide()
+            }
+            ButtonRounded {
+                id: buttonYes
+                color_main: Style.dialog.text
+                color_minor: Style.main.textBlue
+                isOpaque: true
+                fa_icon: Style.fa.check
+                text: qsTr("Okay", "confirms and dismisses a notification")
+                onClicked : root.confirmed()
+            }
+        }
+    }
+
+    function confirmed() {
+        if (go.setQuietHours(quietStart.text, quietEnd.text)) {
+            root.hide()
+            return
+        }
+        root.warning.text = qsTr("Please use format HH:MM for both times, e.g. 22:00.", "if the user enters invalid start or end of quiet hours")
+        root.warning.visible = true
+    }
+
+    onShow : {
+        quietStart.text = go.quietHoursStart
+        quietEnd.text = go.quietHoursEnd
+        root.warning.visible = false
+    }
+
+    Shortcut {
+        sequence: StandardKey.Cancel
+        onActivated: root.hide()
+    }
+
+    Shortcut {
+        sequence: "Enter"
+        onActivated: root.confirmed()
+    }
+}
@@ -305,6 +305,10 @@ Window {
         id: dialogChangePort
     }
 
+    DialogQuietHours {
+        id: dialogQuietHours
+    }
+
     DialogConnectionTroubleshoot {
         id: dialogConnectionTroubleshoot
     }
@@ -95,6 +95,129 @@ Item {
                 }
             }
 
+            ButtonIconText {
+                id: notificationSettings
+                property bool isShown : false
+                text: qsTr("Notifications", "button to open the notification settings list in the settings page")
+                leftIcon.text  : Style.fa.bell
+                rightIcon {
+                    font.pointSize : Style.settings.toggleSize * Style.pt
+                    text  : isShown ? Style.fa.chevron_circle_up  : Style.fa.chevron_circle_right
+                    color : isShown ? Style.main.textDisabled : Style.main.textBlue
+                }
+
+                Accessible.description: (
+                    isShown ?
+                    qsTr("Hide", "Click to hide the notification settings") :
+                    qsTr("Show", "Click to show the notification settings")
+                ) + " " + text
+                onClicked: {
+                    isShown = !isShown
+                }
+            }
+
+            ButtonIconText {
+                id: notifyNewMail
+                visible: notificationSettings.isShown
+                text: qsTr("New mail in inbox", "label for toggle of notifications about new messages")
+                leftIcon.text  : Style.fa.envelope
+                rightIcon {
+                    font.pointSize : Style.settings.toggleSize * Style.pt
+                    text  : go.isNotifyNewMail ? Style.fa.toggle_on  : Style.fa.toggle_off
+                    color : go.isNotifyNewMail ? Style.main.textBlue : Style.main.textDisabled
+                }
+                Accessible.description: (
+                    go.isNotifyNewMail == false ?
+                    qsTr("Enable"  , "Click to show notifications about new messages") :
+                    qsTr("Disable" , "Click to hide notifications about new messages")
+                ) + " " + text
+                onClicked: {
+                    go.toggleNotification("new_mail")
+                }
+            }
+
+            ButtonIconText {
+                id: notifyError
+                visible: notificationSettings.isShown
+                text: qsTr("Errors", "label for toggle of notifications about errors")
+                leftIcon.text  : Style.fa.exclamation_triangle
+                rightIcon {
+                    font.pointSize : Style.settings.toggleSize * Style.pt
+                    text  : go.isNotifyError ? Style.fa.toggle_on  : Style.fa.toggle_off
+                    color : go.isNotifyError ? Style.main.textBlue : Style.main.textDisabled
+                }
+                Accessible.description: (
+                    go.isNotifyError == false ?
+                    qsTr("Enable"  , "Click to show notifications about errors") :
+                    qsTr("Disable" , "Click to hide notifications about errors")
+                ) + " " + text
+                onClicked: {
+                    go.toggleNotification("error")
+                }
+            }
+
+            ButtonIconText {
+                id: notifyUpdate
+                visible: notificationSettings.isShown
+                text: qsTr("Available updates", "label for toggle of notifications about updates")
+                leftIcon.text  : Style.fa.download
+                rightIcon {
+                    font.pointSize : Style.settings.toggleSize * Style.pt
+                    text  : go.isNotifyUpdate ? Style.fa.toggle_on  : Style.fa.toggle_off
+                    color : go.isNotifyUpdate ? Style.main.textBlue : Style.main.textDisabled
+                }
+                Accessible.description: (
+                    go.isNotifyUpdate == false ?
+                    qsTr("Enable"  , "Click to show notifications about updates") :
+                    qsTr("Disable" , "Click to hide notifications about updates")
+                ) + " " + text
+                onClicked: {
+                    go.toggleNotification("update")
+                }
+            }
+
+            ButtonIconText {
+                id: notifyLogout
+                visible: notificationSettings.isShown
+                text: qsTr("Accounts which need login", "label for toggle of notifications about logged out accounts")
+                leftIcon.text  : Style.fa.sign_in
+                rightIcon {
+                    font.pointSize : Style.settings.toggleSize * Style.pt
+                    text  : go.isNotifyLogout ? Style.fa.toggle_on  : Style.fa.toggle_off
+                    color : go.isNotifyLogout ? Style.main.textBlue : Style.main.textDisabled
+                }
+                Accessible.description: (
+                    go.isNotifyLogout == false ?
+                    qsTr("Enable"  , "Click to show notifications about logged out accounts") :
+                    qsTr("Disable" , "Click to hide notifications about logged out accounts")
+                ) + " " + text
+                onClicked: {
+                    go.toggleNotification("logout")
+                }
+            }
+
+            ButtonIconText {
+                id: quietHours
+                visible: notificationSettings.isShown
+                text: (
+                    go.quietHoursStart == "" ?
+                    qsTr("Quiet hours are off", "button to set quiet hours when they are not set") :
+                    qsTr("Quiet hours %1 - %2", "button to set quiet hours, shows start and end").arg(go.quietHoursStart).arg(go.quietHoursEnd)
+                )
+                leftIcon.text  : Style.fa.moon_o
+                rightIcon {
+                    text : qsTr("Change", "clickable link next to quiet hours button in settings")
+                    color: Style.main.text
+                    font {
+                        pointSize : Style.settings.fontSize * Style.pt
+                        underline : true
+                    }
+                }
+                onClicked: {
+                    dialogQuietHours.show()
+                }
+            }
+
             ButtonIconText {
                 id: advancedSettings
                 property bool isAdvanced : !go.isDefaultPort
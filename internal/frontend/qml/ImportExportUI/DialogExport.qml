@@ -37,6 +37,7 @@ Dialog {
 
     property string address
     property alias finish: finish
+    property string estimate : "" // non-empty when waiting for confirmation
 
     property string msgClearUnfished: qsTr ("Remove already exported files.")
 
@@ -87,12 +88,16 @@ Dialog {
             }
 
             Column {
-                spacing: (inputRow.columnHeight - dateRangeInput.height - outputFormatInput.height - outputPathInput.height - buttonRow.height -  infotipEncrypted.height) / 4
+                spacing: (inputRow.columnHeight - dateRangeInput.height - messageFilterInput.height - outputFormatInput.height - outputPathInput.height - buttonRow.height -  infotipEncrypted.height) / 5
 
                 DateRange{
                     id: dateRangeInput
                 }
 
+                ExportFilter {
+                    id: messageFilterInput
+                }
+
                 OutputFormat {
                     id: outputFormatInput
                 }
@@ -136,10 +141,18 @@ Dialog {
                         onClicked : root.cancel()
                     }
 
+                    AccessibleText {
+                        visible: root.estimate != ""
+                        anchors.verticalCenter: buttonNext.verticalCenter
+                        text: qsTr("Estimated: %1", "todo").arg(root.estimate)
+                        font.pointSize: Style.dialog.textSize * Style.pt
+                        color : Style.main.text
+                    }
+
                     ButtonRounded {
                         id: buttonNext
                         fa_icon: Style.fa.check
-                        text: qsTr("Export","todo")
+                        text: root.estimate == "" ? qsTr("Export","todo") : qsTr("Confirm export","todo")
                         enabled: transferRules != 0
                         color_main: Style.dialog.background
                         color_minor: enabled ? Style.dialog.textBlue : Style.main.textDisabled
@@ -237,12 +250,28 @@ Dialog {
             }
         }
 
-        Row {
+        AccessibleText {
+            id: folderProgressExport
+            visible: go.progressFolder != "" && !progressbarExport.isFinished
             anchors {
                 top: progressbarExport.bottom
                 topMargin : Style.dialog.heightSeparator
                 horizontalCenter: parent.horizontalCenter
             }
+            text: qsTr("%1: message %2 of %3 (%4)","todo").arg(go.progressFolder).arg(go.progressFolderDone).arg(go.progressFolderTotal).arg(go.progressCurrentItem)
+            elide: Text.ElideMiddle
+            width: progressbarExport.width
+            horizontalAlignment: Text.AlignHCenter
+            font.pointSize: Style.dialog.textSize * Style.pt
+            color : Style.main.textDisabled
+        }
+
+        Row {
+            anchors {
+                top: folderProgressExport.visible ? folderProgressExport.bottom : progressbarExport.bottom
+                topMargin : Style.dialog.heightSeparator
+                horizontalCenter: parent.horizontalCenter
+            }
             spacing: Style.dialog.rightMargin
 
             ButtonRounded {
@@ -375,7 +404,7 @@ Dialog {
     onCancel : {
         switch (root.currentIndex) {
             case 0 :
-            case 1 : root.hide(); break;
+            case 1 : root.estimate = ""; root.hide(); break;
             case 2 : // progress bar 
             go.cancelProcess();
             // no break
@@ -394,6 +423,22 @@ Dialog {
             root.clear_status()
             root.hide()
             break
+            case 1: // options
+            // First click shows the estimation, second one confirms it.
+            if (root.estimate == "") {
+                dateRangeInput.applyRange()
+                var filterError = messageFilterInput.apply()
+                if (filterError != "") {
+                    errorPopup.show(qsTr("Invalid filter: %1", "todo").arg(filterError))
+                    return
+                }
+                root.estimate = go.estimateTransfer()
+                if (root.estimate != "") return
+            }
+            root.estimate = ""
+            incrementCurrentIndex()
+            timer.start()
+            break
             case 0: // loading structure
             dateRangeInput.getRange()
             //no break
@@ -418,6 +463,7 @@ Dialog {
         timer.interval = 300
         timer.start()
         dateRangeInput.allDates = true
+        messageFilterInput.clear()
     }
 
     Connections {
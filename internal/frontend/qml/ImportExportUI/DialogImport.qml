@@ -39,6 +39,7 @@ Dialog {
     property bool   isFromFile : inputEmail.text == "" && root.inputPath != ""
     property bool   isFromIMAP : inputEmail.text != ""
     property bool   paused : false
+    property string estimate : "" // non-empty when waiting for confirmation
 
     property string msgDontShowAgain : qsTr("Do not show this message again")
 
@@ -382,10 +383,18 @@ Dialog {
                     onClicked  : root.cancel()
                 }
 
+                AccessibleText {
+                    visible: root.estimate != ""
+                    anchors.verticalCenter: buttonNextThree.verticalCenter
+                    text: qsTr("Estimated: %1", "todo").arg(root.estimate)
+                    font.pointSize: Style.dialog.textSize * Style.pt
+                    color : Style.main.text
+                }
+
                 ButtonRounded {
                     id: buttonNextThree
                     fa_icon     : Style.fa.check
-                    text        : qsTr("Import", "todo")
+                    text        : root.estimate == "" ? qsTr("Import", "todo") : qsTr("Confirm import", "todo")
                     color_main  : Style.dialog.background
                     color_minor : Style.dialog.textBlue
                     isOpaque    : true
@@ -483,6 +492,17 @@ Dialog {
                 }
             }
 
+            AccessibleText {
+                visible: go.progressFolder != "" && !progressbarImport.isFinished
+                anchors.horizontalCenter: parent.horizontalCenter
+                text: qsTr("%1: message %2 of %3 (%4)","todo").arg(go.progressFolder).arg(go.progressFolderDone).arg(go.progressFolderTotal).arg(go.progressCurrentItem)
+                elide: Text.ElideMiddle
+                width: progressbarImport.width
+                horizontalAlignment: Text.AlignHCenter
+                font.pointSize: Style.dialog.textSize * Style.pt
+                color : Style.main.textDisabled
+            }
+
             Text {
                 property int fails: go.progressFails
                 visible: fails > 0
@@ -865,6 +885,7 @@ Dialog {
             case DialogImport.Page.SelectSourceType:
             case DialogImport.Page.SourceToTarget:
             case DialogImport.Page.Report:
+            root.estimate = ""
             root.hide()
             break
             case DialogImport.Page.Progress:
@@ -887,6 +908,12 @@ Dialog {
 
                 case DialogImport.Page.SourceToTarget:
                 globalDateRange.applyRange()
+                // First click shows the estimation, second one confirms it.
+                if (root.estimate == "") {
+                    root.estimate = go.estimateTransfer()
+                    if (root.estimate != "") return
+                }
+                root.estimate = ""
                 if (globalLabels.labelSelected) {
                     var isOK = go.createLabelOrFolder(
                         winMain.dialogImport.address,
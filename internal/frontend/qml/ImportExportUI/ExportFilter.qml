@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// optional filter of exported emails
+import QtQuick 2.8
+import QtQuick.Controls 2.2
+import ProtonUI 1.0
+import ImportExportUI 1.0
+
+Column {
+    id: root
+    spacing: Style.dialog.spacing
+
+    property real fieldWidth : 11*Style.dialog.fontSize
+
+    // apply sets the filter of the export and returns an error message if
+    // the filter is not valid.
+    function apply() {
+        return go.setMessageFilter(
+            inputLabels.text,
+            inputFrom.text,
+            inputTo.text,
+            inputSubject.text,
+            attachmentBox.checked
+        )
+    }
+
+    function clear() {
+        inputLabels.text      = ""
+        inputFrom.text        = ""
+        inputTo.text          = ""
+        inputSubject.text     = ""
+        attachmentBox.checked = false
+    }
+
+    Text {
+        id: filterLabel
+        font {
+            pointSize: Style.dialog.fontSize * Style.pt
+            bold: true
+        }
+        color: Style.dialog.text
+        text: qsTr("Export only emails matching:", "todo")
+
+        InfoToolTip {
+            info: qsTr("Labels and folders are separated by comma", "todo") + "\n" + qsTr("Sender and recipient accept * wildcard, e.g. *@example.com", "todo") + "\n" + qsTr("Subject is a regular expression", "todo") + "\n" + qsTr("Empty fields match all emails", "todo")
+            anchors {
+                left: parent.right
+                leftMargin: Style.dialog.spacing
+                verticalCenter: parent.verticalCenter
+            }
+        }
+    }
+
+    Grid {
+        columns       : 2
+        rowSpacing    : Style.dialog.spacing
+        columnSpacing : Style.main.leftMargin
+
+        TextField {
+            id: inputLabels
+            placeholderText: qsTr("Labels or folders", "todo")
+            width           : root.fieldWidth
+            height          : Style.dialog.button
+            leftPadding     : Style.dialog.spacing
+            rightPadding    : Style.dialog.spacing
+            selectByMouse   : true
+            color           : Style.main.text
+            font.pointSize  : Style.dialog.fontSize * Style.pt
+            background      : Rectangle {
+                color        : Style.transparent
+                radius       : Style.dialog.radiusButton
+                border.color : Style.dialog.line
+                border.width : Style.dialog.borderInput
+            }
+        }
+
+        TextField {
+            id: inputFrom
+            placeholderText: qsTr("Sender", "todo")
+            width           : root.fieldWidth
+            height          : Style.dialog.button
+            leftPadding     : Style.dialog.spacing
+            rightPadding    : Style.dialog.spacing
+            selectByMouse   : true
+            color           : Style.main.text
+            font.pointSize  : Style.dialog.fontSize * Style.pt
+            background      : Rectangle {
+                color        : Style.transparent
+                radius       : Style.dialog.radiusButton
+                border.color : Style.dialog.line
+                border.width : Style.dialog.borderInput
+            }
+        }
+
+        TextField {
+            id: inputTo
+            placeholderText: qsTr("Recipient", "todo")
+            width           : root.fieldWidth
+            height          : Style.dialog.button
+            leftPadding     : Style.dialog.spacing
+            rightPadding    : Style.dialog.spacing
+            selectByMouse   : true
+            color           : Style.main.text
+            font.pointSize  : Style.dialog.fontSize * Style.pt
+            background      : Rectangle {
+                color        : Style.transparent
+                radius       : Style.dialog.radiusButton
+                border.color : Style.dialog.line
+                border.width : Style.dialog.borderInput
+            }
+        }
+
+        TextField {
+            id: inputSubject
+            placeholderText: qsTr("Subject", "todo")
+            width           : root.fieldWidth
+            height          : Style.dialog.button
+            leftPadding     : Style.dialog.spacing
+            rightPadding    : Style.dialog.spacing
+            selectByMouse   : true
+            color           : Style.main.text
+            font.pointSize  : Style.dialog.fontSize * Style.pt
+            background      : Rectangle {
+                color        : Style.transparent
+                radius       : Style.dialog.radiusButton
+                border.color : Style.dialog.line
+                border.width : Style.dialog.borderInput
+            }
+        }
+    }
+
+    CheckBoxLabel {
+        id: attachmentBox
+        text: qsTr("Only emails with attachments", "todo")
+    }
+}
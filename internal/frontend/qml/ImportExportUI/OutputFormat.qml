@@ -34,7 +34,7 @@ Column {
         text: qsTr("Select format of exported email:")
 
         InfoToolTip {
-            info: qsTr("MBOX exports one file for each folder", "todo") + "\n" + qsTr("EML exports one file for each email", "todo")
+            info: qsTr("MBOX exports one file for each folder", "todo") + "\n" + qsTr("EML exports one file for each email", "todo") + "\n" + qsTr("ZIP exports one archive with EML files", "todo")
             anchors {
                 left: parent.right
                 leftMargin: Style.dialog.spacing
@@ -50,7 +50,7 @@ Column {
         }
 
         Repeater {
-            model: [ "MBOX", "EML" ]
+            model: [ "MBOX", "EML", "ZIP" ]
             delegate : RadioButton {
                 id: radioDelegate
                 checked: modelData=="MBOX"
@@ -64,6 +64,28 @@ Item {
                 onClicked: go.openLogs()
             }
 
+            ButtonIconText {
+                id: bandwidth
+                anchors.left: parent.left
+                // Presets are cycled on click, the first one is no limit.
+                property var limits : ["unlimited", "10MB/s", "5MB/s", "2MB/s", "1MB/s", "512KB/s"]
+                property int current : 0
+                text: qsTr("Upload speed limit")
+                leftIcon.text  : Style.fa.tachometer
+                rightIcon {
+                    text : go.maxBandwidth
+                    color: Style.main.text
+                    font {
+                        pointSize : Style.settings.fontSize * Style.pt
+                        underline : true
+                    }
+                }
+                onClicked: {
+                    current = (current + 1) % limits.length
+                    go.changeMaxBandwidth(limits[current])
+                }
+            }
+
             ButtonIconText {
                 id: bugreport
                 anchors.left: parent.left
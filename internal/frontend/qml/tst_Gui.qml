@@ -232,9 +232,9 @@ Window {
 
         ListModel{
             id: accountsModel
-            ListElement{ account : "bridge"                                           ; status : "connected";    isExpanded: false; isCombinedAddressMode: false; hostname : "127.0.0.1"; password : "ZI9tKp+ryaxmbpn2E12"; security : "StarTLS"; portSMTP : 1025; portIMAP : 1143; aliases : "bridge@pm.com;bridge2@pm.com;theHorriblySlowMurderWithExtremelyInefficientWeapon@youtube.com" }
-            ListElement{ account : "exteremelongnamewhichmustbeeladed@protonmail.com" ; status : "connected";    isExpanded: true;  isCombinedAddressMode: true;  hostname : "127.0.0.1"; password : "ZI9tKp+ryaxmbpn2E12"; security : "StarTLS"; portSMTP : 1025; portIMAP : 1143; aliases : "bridge@pm.com;bridge2@pm.com;hu@hu.hu"                                                        }
-            ListElement{ account : "bridge2@protonmail.com"                           ; status : "disconnected"; isExpanded: false; isCombinedAddressMode: false; hostname : "127.0.0.1"; password : "ZI9tKp+ryaxmbpn2E12"; security : "StarTLS"; portSMTP : 1025; portIMAP : 1143; aliases : "bridge@pm.com;bridge2@pm.com;hu@hu.hu"                                                        }
+            ListElement{ account : "bridge"                                           ; status : "connected";    isExpanded: false; isCombinedAddressMode: false; isIMAPEnabled: true; isSMTPEnabled: true; hostname : "127.0.0.1"; password : "ZI9tKp+ryaxmbpn2E12"; security : "StarTLS"; portSMTP : 1025; portIMAP : 1143; aliases : "bridge@pm.com;bridge2@pm.com;theHorriblySlowMurderWithExtremelyInefficientWeapon@youtube.com" }
+            ListElement{ account : "exteremelongnamewhichmustbeeladed@protonmail.com" ; status : "connected";    isExpanded: true;  isCombinedAddressMode: true;  isIMAPEnabled: true; isSMTPEnabled: false; hostname : "127.0.0.1"; password : "ZI9tKp+ryaxmbpn2E12"; security : "StarTLS"; portSMTP : 1025; portIMAP : 1143; aliases : "bridge@pm.com;bridge2@pm.com;hu@hu.hu"                                                        }
+            ListElement{ account : "bridge2@protonmail.com"                           ; status : "disconnected"; isExpanded: false; isCombinedAddressMode: false; isIMAPEnabled: true; isSMTPEnabled: true; hostname : "127.0.0.1"; password : "ZI9tKp+ryaxmbpn2E12"; security : "StarTLS"; portSMTP : 1025; portIMAP : 1143; aliases : "bridge@pm.com;bridge2@pm.com;hu@hu.hu"                                                        }
         }
 
         Component.onCompleted : {
@@ -496,6 +496,14 @@ Window {
         function openDownloadLink(){
         }
 
+        function toggleIMAPEnabled(iAccount){
+            accountsModel.get(iAccount).isIMAPEnabled = !accountsModel.get(iAccount).isIMAPEnabled
+        }
+
+        function toggleSMTPEnabled(iAccount){
+            accountsModel.get(iAccount).isSMTPEnabled = !accountsModel.get(iAccount).isSMTPEnabled
+        }
+
         function switchAddressMode(username){
             for (var iAcc=0; iAcc < accountsModel.count; iAcc++) {
                 if (accountsModel.get(iAcc).account == username ) {
@@ -592,6 +600,32 @@ Window {
 
         property bool isReportingOutgoingNoEnc : true
 
+        property bool isNotifyNewMail : true
+        property bool isNotifyError : true
+        property bool isNotifyUpdate : true
+        property bool isNotifyLogout : true
+        property string quietHoursStart : ""
+        property string quietHoursEnd : ""
+
+        function toggleNotification(notification) {
+            switch (notification) {
+                case "new_mail" : go.isNotifyNewMail = !go.isNotifyNewMail; break;
+                case "error"    : go.isNotifyError   = !go.isNotifyError;   break;
+                case "update"   : go.isNotifyUpdate  = !go.isNotifyUpdate;  break;
+                case "logout"   : go.isNotifyLogout  = !go.isNotifyLogout;  break;
+            }
+            console.log("Notification toggled ", notification)
+        }
+
+        function setQuietHours(start, end) {
+            var format = /^([01][0-9]|2[0-3]):[0-5][0-9]$/
+            if (!(start == "" && end == "") && !(format.test(start) && format.test(end))) return false
+            go.quietHoursStart = start
+            go.quietHoursEnd = end
+            console.log("Quiet hours set ", start, end)
+            return true
+        }
+
         function toggleIsReportingOutgoingNoEnc() {
             go.isReportingOutgoingNoEnc = !go.isReportingOutgoingNoEnc
             console.log("Reporting changed to ", go.isReportingOutgoingNoEnc)
@@ -843,6 +843,10 @@ Window {
         property string progressDescription: "nothing"
         property string progressInit: "init"
         property int total: 42
+        property string progressFolder: "INBOX"
+        property int progressFolderDone: 12
+        property int progressFolderTotal: 30
+        property string progressCurrentItem: "Hello world"
         property string importLogFileName: "importLogFileName not set"
 
         signal toggleMainWin(int systX, int systY, int systW, int systH)
@@ -1186,6 +1190,21 @@ Window {
             workAndClose("startExport")
         }
 
+        property string maxBandwidth : "unlimited"
+        function changeMaxBandwidth(limit) {
+            go.maxBandwidth = limit
+            return true
+        }
+
+        function setMessageFilter(labels, from, to, subject, hasAttachment) {
+            console.log ("Message filter: ", labels, from, to, subject, hasAttachment)
+            return ""
+        }
+
+        function estimateTransfer() {
+            return "42 messages, 1.2 MB, less than a minute"
+        }
+
         function startImport(address) {
             workAndClose("startImport")
         }
@@ -20,6 +20,11 @@
 package qtie
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/ProtonMail/proton-bridge/internal/transfer"
 	"github.com/pkg/errors"
 )
@@ -27,6 +32,7 @@ import (
 const (
 	TypeEML  = "EML"
 	TypeMBOX = "MBOX"
+	TypeZIP  = "ZIP"
 )
 
 func (f *FrontendQt) LoadStructureForExport(addressOrID string) {
@@ -69,12 +75,41 @@ func (f *FrontendQt) StartExport(rootPath, login, fileType string, attachEncrypt
 	} else if fileType == TypeMBOX {
 
 		target = transfer.NewMBOXProvider(rootPath)
+	} else if fileType == TypeZIP {
+		archiveName := fmt.Sprintf("ProtonMail-export-%s.zip", time.Now().Format("2006-01-02-150405"))
+		var err error
+		if target, err = transfer.NewArchiveProvider(filepath.Join(rootPath, archiveName)); err != nil {
+			log.WithError(err).Error("Failed to create archive")
+			return
+		}
+		// Archive is always created from scratch, it cannot continue.
+		f.transfer.ResetState()
 	} else {
 		log.Errorln("Wrong file format:", fileType)
 		return
 	}
 	f.transfer.ChangeTarget(target)
 	f.transfer.SetSkipEncryptedMessages(!attachEncryptedBody)
+	if f.dryRunInsteadOfStart() {
+		return
+	}
 	progress := f.transfer.Start()
 	f.setProgressManager(progress)
 }
+
+// setMessageFilter limits the next export to matching messages. Empty values
+// match everything. It returns error description for invalid filter.
+func (f *FrontendQt) setMessageFilter(labels, from, to, subject string, hasAttachment bool) string {
+	filter := transfer.MessageFilter{
+		Labels:        transfer.ParseFilterLabels(labels),
+		From:          strings.TrimSpace(from),
+		To:            strings.TrimSpace(to),
+		Subject:       subject,
+		HasAttachment: hasAttachment,
+	}
+	if err := f.transfer.SetMessageFilter(filter); err != nil {
+		log.WithError(err).Warn("Cannot set message filter")
+		return err.Error()
+	}
+	return ""
+}
@@ -121,18 +121,23 @@ func (f *FrontendQt) Loop(setupError error) (err error) {
 func (f *FrontendQt) watchEvents() {
 	internetOffCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.InternetOffEvent)
 	internetOnCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.InternetOnEvent)
+	rateLimitCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.RateLimitEvent)
 	restartBridgeCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.RestartBridgeEvent)
 	addressChangedCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.AddressChangedEvent)
 	addressChangedLogoutCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.AddressChangedLogoutEvent)
 	logoutCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.LogoutEvent)
+	reloginRequiredCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.ReloginRequiredEvent)
 	updateApplicationCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.UpgradeApplicationEvent)
 	newUserCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.UserRefreshEvent)
+	tlsPinsOverrideCh := qtcommon.MakeAndRegisterEvent(f.eventListener, events.TLSPinsOverrideEvent)
 	for {
 		select {
 		case <-internetOffCh:
 			f.Qml.SetConnectionStatus(false)
 		case <-internetOnCh:
 			f.Qml.SetConnectionStatus(true)
+		case seconds := <-rateLimitCh:
+			f.SendNotification(TabGlobal, "Paused by server for "+seconds+" seconds because of too many requests.")
 		case <-restartBridgeCh:
 			f.Qml.SetIsRestarting(true)
 			f.App.Quit()
@@ -146,11 +151,19 @@ func (f *FrontendQt) watchEvents() {
 				return
 			}
 			f.Qml.NotifyLogout(user.Username())
+		case userID := <-reloginRequiredCh:
+			user, err := f.ie.GetUser(userID)
+			if err != nil {
+				return
+			}
+			f.Qml.NotifyLogout(user.Username())
 		case <-updateApplicationCh:
 			f.Qml.ProcessFinished()
 			f.Qml.NotifyUpdate()
 		case <-newUserCh:
 			f.Qml.LoadAccounts()
+		case path := <-tlsPinsOverrideCh:
+			f.SendNotification(TabGlobal, "Trusted TLS pins are loaded from the unsigned local file "+path+".")
 		}
 	}
 }
@@ -194,6 +207,8 @@ func (f *FrontendQt) qtSetupQmlAndStructures() {
 	if f.notifyHasNoKeychain {
 		f.Qml.NotifyHasNoKeychain()
 	}
+
+	f.eventListener.RetryEmit(events.TLSPinsOverrideEvent)
 }
 
 // QtExecute in main for starting Qt application
@@ -236,6 +251,18 @@ func (f *FrontendQt) openLogs() {
 	go open.Run(f.config.GetLogDir())
 }
 
+// changeMaxBandwidth sets the upload limit of all transfers, including the running one.
+func (f *FrontendQt) changeMaxBandwidth(limit string) bool {
+	bytesPerSecond, err := transfer.ParseBandwidth(limit)
+	if err != nil {
+		log.WithError(err).Warn("Cannot change maximum bandwidth")
+		return false
+	}
+	transfer.SetMaxBandwidth(bytesPerSecond)
+	f.Qml.SetMaxBandwidth(transfer.FormatBandwidth(bytesPerSecond))
+	return true
+}
+
 func (f *FrontendQt) openReport() {
 	go open.Run(f.Qml.ImportLogFileName())
 }
@@ -306,6 +333,21 @@ func (f *FrontendQt) showError(code int, err error) {
 	f.Qml.NotifyError(code)
 }
 
+// dryRunInsteadOfStart logs the dry-run report and returns true when dry-run
+// was requested, in which case the transfer must not be started.
+func (f *FrontendQt) dryRunInsteadOfStart() bool {
+	if !f.ie.IsDryRunRequested() {
+		return false
+	}
+	report, err := f.transfer.DryRun()
+	if err != nil {
+		f.showError(errUnknownError, err)
+		return true
+	}
+	log.Info("Dry run, nothing was transferred:\n", report.String())
+	return true
+}
+
 func (f *FrontendQt) emitEvent(evType, msg string) {
 	f.eventListener.Emit(evType, msg)
 }
@@ -320,6 +362,8 @@ func (f *FrontendQt) setProgressManager(progress *transfer.Progress) {
 		progress.Stop()
 	})
 	f.Qml.SetProgress(0)
+	f.Qml.SetProgressFolder("")
+	f.Qml.SetProgressCurrentItem("")
 
 	go func() {
 		log.Trace("Start reading updates")
@@ -343,6 +387,7 @@ func (f *FrontendQt) setProgressManager(progress *transfer.Progress) {
 			}
 			f.Qml.SetProgressFails(int(failed))
 			f.Qml.SetProgressDescription(progress.PauseReason())
+			f.setFolderProgress(progress)
 			if total > 0 {
 				newProgress := float32(imported+failed) / float32(total)
 				if newProgress >= 0 && newProgress != f.Qml.Progress() {
@@ -360,6 +405,37 @@ func (f *FrontendQt) setProgressManager(progress *transfer.Progress) {
 	}()
 }
 
+// EstimateTransfer returns pre-flight estimation of the prepared transfer
+// or empty string if it cannot be estimated.
+func (f *FrontendQt) EstimateTransfer() string {
+	if f.transfer == nil {
+		return ""
+	}
+	estimate, err := f.transfer.Estimate()
+	if err != nil {
+		log.WithError(err).Warn("Failed to estimate transfer")
+		return ""
+	}
+	if f.transfer.HasSavedState() {
+		return estimate.String() + ", continuing unfinished transfer"
+	}
+	return estimate.String()
+}
+
+// setFolderProgress shows progress of the folder currently being transferred.
+func (f *FrontendQt) setFolderProgress(progress *transfer.Progress) {
+	mailbox, message := progress.GetCurrentMessage()
+	for _, folder := range progress.GetFolderCounts() {
+		if folder.Name == mailbox {
+			f.Qml.SetProgressFolder(folder.Name)
+			f.Qml.SetProgressFolderDone(int(folder.Imported + folder.Failed))
+			f.Qml.SetProgressFolderTotal(int(folder.Total))
+			f.Qml.SetProgressCurrentItem(message)
+			return
+		}
+	}
+}
+
 // StartUpdate is identical to bridge
 func (f *FrontendQt) StartUpdate() {
 	progress := make(chan updates.Progress)
@@ -422,6 +498,7 @@ func (f *FrontendQt) isNewVersionAvailable(showMessage bool) {
 func (f *FrontendQt) resetSource() {
 	if f.transfer != nil {
 		f.transfer.ResetRules()
+		f.transfer.ResetState()
 		if err := f.loadStructuresForImport(); err != nil {
 			log.WithError(err).Error("Cannot reload structures after reseting rules.")
 		}
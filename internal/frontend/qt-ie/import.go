@@ -82,6 +82,10 @@ func (f *FrontendQt) StartImport(email string) { // TODO email not needed
 	f.Qml.SetTotal(1)
 	f.Qml.SetImportLogFileName("")
 
+	if f.dryRunInsteadOfStart() {
+		return
+	}
+
 	progress := f.transfer.Start()
 
 	f.Qml.SetImportLogFileName(progress.FileReport())
@@ -22,6 +22,7 @@ package qtie
 import (
 	"runtime"
 
+	"github.com/ProtonMail/proton-bridge/internal/transfer"
 	"github.com/therecipe/qt/core"
 )
 
@@ -45,7 +46,12 @@ type GoQMLInterface struct {
 	_ string  `property:progressDescription`
 	_ int     `property:progressFails`
 	_ int     `property:total`
+	_ string  `property:progressFolder`
+	_ int     `property:progressFolderDone`
+	_ int     `property:progressFolderTotal`
+	_ string  `property:progressCurrentItem`
 	_ string  `property:importLogFileName`
+	_ string  `property:maxBandwidth`
 
 	_ string `property:"programTitle"`
 	_ string `property:"newversion"`
@@ -94,7 +100,10 @@ type GoQMLInterface struct {
 	_ func(username string, name string, color string, isLabel bool, sourceID string) bool `slot:"createLabelOrFolder"`
 	_ func(fpath, address, fileType string, attachEncryptedBody bool)                      `slot:"startExport"`
 	_ func(email string)                                                                   `slot:"startImport"`
+	_ func() string                                                                        `slot:"estimateTransfer"`
 	_ func()                                                                               `slot:"resetSource"`
+	_ func(limit string) bool                                                              `slot:"changeMaxBandwidth"`
+	_ func(labels, from, to, subject string, hasAttachment bool) string                    `slot:"setMessageFilter"`
 
 	_ func(isFromIMAP bool, sourcePath, sourceEmail, sourcePassword, sourceServe, sourcePort, targetAddress string) `slot:"setupAndLoadForImport"`
 
@@ -181,6 +190,10 @@ func (s *GoQMLInterface) SetFrontend(f *FrontendQt) {
 
 	s.ConnectStartExport(f.StartExport)
 	s.ConnectStartImport(f.StartImport)
+	s.ConnectEstimateTransfer(f.EstimateTransfer)
+	s.ConnectChangeMaxBandwidth(f.changeMaxBandwidth)
+	s.ConnectSetMessageFilter(f.setMessageFilter)
+	s.SetMaxBandwidth(transfer.FormatBandwidth(transfer.GetMaxBandwidth()))
 
 	s.ConnectCheckPathStatus(CheckPathStatus)
 
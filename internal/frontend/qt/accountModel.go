@@ -41,6 +41,8 @@ type AccountInfo struct {
 	_ string `property:"aliases"`
 	_ bool   `property:"isExpanded"`
 	_ bool   `property:"isCombinedAddressMode"`
+	_ bool   `property:"isIMAPEnabled"`
+	_ bool   `property:"isSMTPEnabled"`
 }
 
 // Constants for data map.
@@ -57,6 +59,8 @@ const (
 	Aliases
 	IsExpanded
 	IsCombinedAddressMode
+	IsIMAPEnabled
+	IsSMTPEnabled
 )
 
 // Registration of new metatype before creating instance.
@@ -114,6 +118,8 @@ func (s *AccountsModel) init() {
 		Aliases:               NewQByteArrayFromString("aliases"),
 		IsExpanded:            NewQByteArrayFromString("isExpanded"),
 		IsCombinedAddressMode: NewQByteArrayFromString("isCombinedAddressMode"),
+		IsIMAPEnabled:         NewQByteArrayFromString("isIMAPEnabled"),
+		IsSMTPEnabled:         NewQByteArrayFromString("isSMTPEnabled"),
 	})
 	// Basic QAbstractListModel methods.
 	s.ConnectData(s.data)
@@ -171,6 +177,10 @@ func (s *AccountsModel) data(index *core.QModelIndex, role int) *core.QVariant {
 		return NewQVariantBool(p.IsExpanded())
 	case IsCombinedAddressMode:
 		return NewQVariantBool(p.IsCombinedAddressMode())
+	case IsIMAPEnabled:
+		return NewQVariantBool(p.IsIMAPEnabled())
+	case IsSMTPEnabled:
+		return NewQVariantBool(p.IsSMTPEnabled())
 	default:
 		return core.NewQVariant()
 	}
@@ -70,6 +70,8 @@ func (s *FrontendQt) loadAccounts() {
 		acc_info.SetAliases(strings.Join(user.GetAddresses(), ";"))
 		acc_info.SetIsExpanded(user.ID() == s.userIDAdded)
 		acc_info.SetIsCombinedAddressMode(user.IsCombinedAddressMode())
+		acc_info.SetIsIMAPEnabled(user.IsIMAPEnabled())
+		acc_info.SetIsSMTPEnabled(user.IsSMTPEnabled())
 
 		s.Accounts.addAccount(acc_info)
 	}
@@ -179,18 +179,25 @@ func (s *FrontendQt) watchEvents() {
 	noActiveKeyForRecipientCh := s.getEventChannel(events.NoActiveKeyForRecipientEvent)
 	internetOffCh := s.getEventChannel(events.InternetOffEvent)
 	internetOnCh := s.getEventChannel(events.InternetOnEvent)
+	rateLimitCh := s.getEventChannel(events.RateLimitEvent)
 	secondInstanceCh := s.getEventChannel(events.SecondInstanceEvent)
 	restartBridgeCh := s.getEventChannel(events.RestartBridgeEvent)
 	addressChangedCh := s.getEventChannel(events.AddressChangedEvent)
 	addressChangedLogoutCh := s.getEventChannel(events.AddressChangedLogoutEvent)
 	logoutCh := s.getEventChannel(events.LogoutEvent)
+	reloginRequiredCh := s.getEventChannel(events.ReloginRequiredEvent)
 	updateApplicationCh := s.getEventChannel(events.UpgradeApplicationEvent)
 	newUserCh := s.getEventChannel(events.UserRefreshEvent)
 	certIssue := s.getEventChannel(events.TLSCertIssue)
 	imapCertIssue := s.getEventChannel(events.IMAPTLSBadCert)
+	newMailCh := s.getEventChannel(events.NewMailEvent)
+	tlsPinsOverrideCh := s.getEventChannel(events.TLSPinsOverrideEvent)
 	for {
 		select {
 		case errorDetails := <-errorCh:
+			if !s.shouldNotify(preferences.NotificationError) {
+				continue
+			}
 			imapIssue := strings.Contains(errorDetails, "IMAP failed")
 			smtpIssue := strings.Contains(errorDetails, "SMTP failed")
 			s.Qml.NotifyPortIssue(imapIssue, smtpIssue)
@@ -205,6 +212,8 @@ func (s *FrontendQt) watchEvents() {
 			s.Qml.SetConnectionStatus(false)
 		case <-internetOnCh:
 			s.Qml.SetConnectionStatus(true)
+		case seconds := <-rateLimitCh:
+			s.SendNotification(TabAccount, "Paused by server for "+seconds+" seconds because of too many requests.")
 		case <-secondInstanceCh:
 			s.Qml.ShowWindow()
 		case <-restartBridgeCh:
@@ -213,26 +222,50 @@ func (s *FrontendQt) watchEvents() {
 		case address := <-addressChangedCh:
 			s.Qml.NotifyAddressChanged(address)
 		case address := <-addressChangedLogoutCh:
-			s.Qml.NotifyAddressChangedLogout(address)
+			if s.shouldNotify(preferences.NotificationLogout) {
+				s.Qml.NotifyAddressChangedLogout(address)
+			}
 		case userID := <-logoutCh:
 			user, err := s.bridge.GetUser(userID)
 			if err != nil {
 				return
 			}
+			if s.shouldNotify(preferences.NotificationLogout) {
+				s.Qml.NotifyLogout(user.Username())
+			}
+		case userID := <-reloginRequiredCh:
+			user, err := s.bridge.GetUser(userID)
+			if err != nil {
+				return
+			}
+			// Logout notification leads the user to the login dialog; the local
+			// store is kept, so the account continues where it stopped.
 			s.Qml.NotifyLogout(user.Username())
 		case <-updateApplicationCh:
 			s.Qml.ProcessFinished()
-			s.Qml.NotifyUpdate()
+			if s.shouldNotify(preferences.NotificationUpdate) {
+				s.Qml.NotifyUpdate()
+			}
+		case address := <-newMailCh:
+			if s.shouldNotify(preferences.NotificationNewMail) {
+				s.SendNotification(TabAccount, "New message received for "+address)
+			}
 		case <-newUserCh:
 			s.Qml.LoadAccounts()
 		case <-certIssue:
 			s.Qml.ShowCertIssue()
 		case <-imapCertIssue:
 			s.Qml.ShowIMAPCertTroubleshoot()
+		case path := <-tlsPinsOverrideCh:
+			s.SendNotification(TabSettings, "Trusted TLS pins are loaded from the unsigned local file "+path+".")
 		}
 	}
 }
 
+func (s *FrontendQt) shouldNotify(notification string) bool {
+	return preferences.ShouldNotify(s.preferences, notification, time.Now())
+}
+
 func (s *FrontendQt) getEventChannel(event string) <-chan string {
 	ch := make(chan string)
 	s.eventListener.Add(event, ch)
@@ -351,10 +384,14 @@ func (s *FrontendQt) qtExecute(Procedure func(*FrontendQt) error) error {
 
 	s.eventListener.RetryEmit(events.TLSCertIssue)
 	s.eventListener.RetryEmit(events.ErrorEvent)
+	s.eventListener.RetryEmit(events.TLSPinsOverrideEvent)
 
 	// Set reporting of outgoing email without encryption.
 	s.Qml.SetIsReportingOutgoingNoEnc(s.preferences.GetBool(preferences.ReportOutgoingNoEncKey))
 
+	// Notifications and quiet hours.
+	s.loadNotificationSettings()
+
 	// IMAP/SMTP ports.
 	s.Qml.SetIsDefaultPort(
 		s.config.GetDefaultIMAPPort() == s.preferences.GetInt(preferences.IMAPPortKey) &&
@@ -401,6 +438,14 @@ func (s *FrontendQt) isNewVersionAvailable(showMessage bool) {
 			return
 		}
 		s.Qml.SetConnectionStatus(true) // If we are here connection is ok.
+		if !isUpToDate && !s.updates.IsRolledOut(latestVersionInfo) {
+			log.WithField("version", latestVersionInfo.Version).Info("New version is not rolled out for this install yet")
+			s.Qml.SetUpdateState("upToDate")
+			if showMessage {
+				s.SendNotification(TabHelp, "Version "+latestVersionInfo.Version+" is available but not rolled out to this installation yet. It will be offered to you later.")
+			}
+			return
+		}
 		if isUpToDate {
 			s.Qml.SetUpdateState("upToDate")
 			if showMessage {
@@ -575,6 +620,44 @@ func (s *FrontendQt) switchAddressModeUser(iAccount int) {
 	s.userIDAdded = userID
 }
 
+// toggleIMAPEnabled allows or denies IMAP access of the account,
+// e.g. for send-only service accounts.
+func (s *FrontendQt) toggleIMAPEnabled(iAccount int) {
+	userID := s.Accounts.get(iAccount).UserID()
+	user, err := s.bridge.GetUser(userID)
+	if err != nil {
+		log.Error("Get user for IMAP toggle failed: ", err)
+		s.SendNotification(TabAccount, s.Qml.GenericErrSeeLogs())
+		return
+	}
+	if err := user.SetIMAPEnabled(!user.IsIMAPEnabled()); err != nil {
+		log.Error("Changing IMAP access failed: ", err)
+		s.SendNotification(TabAccount, s.Qml.GenericErrSeeLogs())
+		return
+	}
+	s.userIDAdded = userID
+	s.loadAccounts()
+}
+
+// toggleSMTPEnabled allows or denies SMTP access of the account,
+// e.g. for receive-only service accounts.
+func (s *FrontendQt) toggleSMTPEnabled(iAccount int) {
+	userID := s.Accounts.get(iAccount).UserID()
+	user, err := s.bridge.GetUser(userID)
+	if err != nil {
+		log.Error("Get user for SMTP toggle failed: ", err)
+		s.SendNotification(TabAccount, s.Qml.GenericErrSeeLogs())
+		return
+	}
+	if err := user.SetSMTPEnabled(!user.IsSMTPEnabled()); err != nil {
+		log.Error("Changing SMTP access failed: ", err)
+		s.SendNotification(TabAccount, s.Qml.GenericErrSeeLogs())
+		return
+	}
+	s.userIDAdded = userID
+	s.loadAccounts()
+}
+
 func (s *FrontendQt) autostartError(err error) {
 	if strings.Contains(err.Error(), "permission denied") {
 		s.Qml.FailedAutostartCode("permission")
@@ -593,6 +676,38 @@ func (s *FrontendQt) toggleIsReportingOutgoingNoEnc() {
 	s.Qml.SetIsReportingOutgoingNoEnc(shouldReport)
 }
 
+func (s *FrontendQt) loadNotificationSettings() {
+	s.Qml.SetIsNotifyNewMail(s.preferences.GetBool(preferences.NotificationKey(preferences.NotificationNewMail)))
+	s.Qml.SetIsNotifyError(s.preferences.GetBool(preferences.NotificationKey(preferences.NotificationError)))
+	s.Qml.SetIsNotifyUpdate(s.preferences.GetBool(preferences.NotificationKey(preferences.NotificationUpdate)))
+	s.Qml.SetIsNotifyLogout(s.preferences.GetBool(preferences.NotificationKey(preferences.NotificationLogout)))
+	s.Qml.SetQuietHoursStart(s.preferences.Get(preferences.QuietHoursStartKey))
+	s.Qml.SetQuietHoursEnd(s.preferences.Get(preferences.QuietHoursEndKey))
+}
+
+func (s *FrontendQt) toggleNotification(notification string) {
+	key := preferences.NotificationKey(notification)
+	s.preferences.SetBool(key, !s.preferences.GetBool(key))
+	s.loadNotificationSettings()
+}
+
+// setQuietHours saves quiet hours in format HH:MM; both empty turn them off.
+// It returns false when the values are not valid and nothing was saved.
+func (s *FrontendQt) setQuietHours(start, end string) bool {
+	start = strings.TrimSpace(start)
+	end = strings.TrimSpace(end)
+
+	isOff := start == "" && end == ""
+	if !isOff && (!preferences.IsValidQuietHoursTime(start) || !preferences.IsValidQuietHoursTime(end)) {
+		return false
+	}
+
+	s.preferences.Set(preferences.QuietHoursStartKey, start)
+	s.preferences.Set(preferences.QuietHoursEndKey, end)
+	s.loadNotificationSettings()
+	return true
+}
+
 func (s *FrontendQt) shouldSendAnswer(messageID string, shouldSend bool) {
 	s.noEncConfirmator.ConfirmNoEncryption(messageID, shouldSend)
 }
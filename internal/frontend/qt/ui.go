@@ -111,6 +111,8 @@ type GoQMLInterface struct {
 	_ func(iAccount int)                         `slot:"logoutAccount"`
 	_ func(iAccount int, iAddress int)           `slot:"configureAppleMail"`
 	_ func(iAccount int)                         `signal:"switchAddressMode"`
+	_ func(iAccount int)                         `slot:"toggleIMAPEnabled"`
+	_ func(iAccount int)                         `slot:"toggleSMTPEnabled"`
 
 	_ func(login, password string) int      `slot:"login"`
 	_ func(twoFacAuth string) int           `slot:"auth2FA"`
@@ -130,6 +132,14 @@ type GoQMLInterface struct {
 	_ bool                                    `property:"isReportingOutgoingNoEnc"`
 	_ func()                                  `slot:"toggleIsReportingOutgoingNoEnc"`
 	_ func(messageID string, shouldSend bool) `slot:"shouldSendAnswer"`
+	_ bool                                    `property:"isNotifyNewMail"`
+	_ bool                                    `property:"isNotifyError"`
+	_ bool                                    `property:"isNotifyUpdate"`
+	_ bool                                    `property:"isNotifyLogout"`
+	_ string                                  `property:"quietHoursStart"`
+	_ string                                  `property:"quietHoursEnd"`
+	_ func(notification string)               `slot:"toggleNotification"`
+	_ func(start, end string) bool            `slot:"setQuietHours"`
 	_ func(messageID, subject string)         `signal:"showOutgoingNoEncPopup"`
 	_ func(x, y float32)                      `signal:"setOutgoingNoEncPopupCoord"`
 	_ func(x, y float32)                      `slot:"saveOutgoingNoEncPopupCoord"`
@@ -175,6 +185,8 @@ func (s *GoQMLInterface) SetFrontend(f *FrontendQt) {
 	s.ConnectErrorSystray(ErrorSystray)
 	s.ConnectNormalSystray(NormalSystray)
 	s.ConnectSwitchAddressMode(f.switchAddressModeUser)
+	s.ConnectToggleIMAPEnabled(f.toggleIMAPEnabled)
+	s.ConnectToggleSMTPEnabled(f.toggleSMTPEnabled)
 
 	s.SetGoos(runtime.GOOS)
 	s.SetIsRestarting(false)
@@ -188,6 +200,8 @@ func (s *GoQMLInterface) SetFrontend(f *FrontendQt) {
 
 	s.ConnectToggleIsReportingOutgoingNoEnc(f.toggleIsReportingOutgoingNoEnc)
 	s.ConnectShouldSendAnswer(f.shouldSendAnswer)
+	s.ConnectToggleNotification(f.toggleNotification)
+	s.ConnectSetQuietHours(f.setQuietHours)
 	s.ConnectSaveOutgoingNoEncPopupCoord(f.saveOutgoingNoEncPopupCoord)
 	s.ConnectStartUpdate(f.StartUpdate)
 }
@@ -21,9 +21,13 @@ package types
 import (
 	"github.com/ProtonMail/proton-bridge/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/internal/importexport"
+	"github.com/ProtonMail/proton-bridge/internal/smtp"
+	"github.com/ProtonMail/proton-bridge/internal/store"
 	"github.com/ProtonMail/proton-bridge/internal/transfer"
 	"github.com/ProtonMail/proton-bridge/internal/updates"
+	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"time"
 )
 
 // PanicHandler is an interface of a type that can be used to gracefully handle panics which occur.
@@ -36,6 +40,7 @@ type Updater interface {
 	CheckIsUpToDate() (isUpToDate bool, latestVersion updates.VersionInfo, err error)
 	GetDownloadLink() string
 	GetLocalVersion() updates.VersionInfo
+	IsRolledOut(version updates.VersionInfo) bool
 	StartUpgrade(currentStatus chan<- updates.Progress)
 }
 
@@ -43,15 +48,29 @@ type NoEncConfirmator interface {
 	ConfirmNoEncryption(string, bool)
 }
 
+// Outbox is an interface of messages accepted by SMTP which were not sent yet.
+type Outbox interface {
+	ListPendingSends() []smtp.PendingSend
+	UndoSend(messageID string) error
+	ListFailedSends() []smtp.FailedSend
+	RetrySend(messageID string) error
+	DiscardFailedSend(messageID string) error
+	ListOutbox() []smtp.OutboxEntry
+	DiscardFromOutbox(messageID string) error
+}
+
 // UserManager is an interface of users needed by frontend.
 type UserManager interface {
 	Login(username, password string) (pmapi.Client, *pmapi.Auth, error)
+	LoginWithHumanVerification(username, password, tokenType, token string) (pmapi.Client, *pmapi.Auth, error)
 	FinishLogin(client pmapi.Client, auth *pmapi.Auth, mailboxPassword string) (User, error)
 	GetUsers() []User
 	GetUser(query string) (User, error)
 	DeleteUser(userID string, clearCache bool) error
 	ClearData() error
 	CheckConnection() error
+	GetRequestStats() []pmapi.EndpointStats
+	GetClockSkew() time.Duration
 }
 
 // User is an interface of user needed by frontend.
@@ -64,6 +83,46 @@ type User interface {
 	GetAddresses() []string
 	GetBridgePassword() string
 	SwitchAddressMode() error
+	IsIMAPEnabled() bool
+	IsSMTPEnabled() bool
+	SetIMAPEnabled(enabled bool) error
+	GetProxy() string
+	SetProxy(proxy string) error
+	SetSMTPEnabled(enabled bool) error
+	GetAppPasswords() []credentials.AppPassword
+	AddAppPassword(name string) (string, error)
+	RevokeAppPassword(name string) error
+	RotateBridgePassword() (string, error)
+	ListLabels() ([]*pmapi.Label, error)
+	ListSavedSearches() ([]store.SavedSearch, error)
+	AddSavedSearch(name, query string) error
+	RemoveSavedSearch(name string) error
+	GetFilters() (string, error)
+	SetFilters(script string) error
+	IsDraftsUploadEnabled() bool
+	SetDraftsUploadEnabled(enabled bool) error
+	IsAutoCreateMailboxesEnabled() bool
+	SetAutoCreateMailboxesEnabled(enabled bool) error
+	IsCrossAccountCopyEnabled() bool
+	SetCrossAccountCopyEnabled(enabled bool) error
+	IsSpamTrainingEnabled() bool
+	SetSpamTrainingEnabled(enabled bool) error
+	GetExpungePolicy() string
+	SetExpungePolicy(policy string) error
+	GetAutoCopy() (address, mode string)
+	SetAutoCopy(address, mode string) error
+	GetFooter() (plain, html string)
+	SetFooter(plain, html string) error
+	GetAddressStats() ([]store.AddressStats, error)
+	ListDeletedMessages() ([]store.Tombstone, error)
+	RecoverDeletedMessage(messageID string) error
+	ListMessageProblems() ([]store.Problem, error)
+	SetMessageSkipped(messageID string, skipped bool) error
+	BumpUIDValidity(mailboxName string) error
+	ListCachedRecipients() ([]store.CachedRecipient, error)
+	FlushRecipientKeyCache() (int, error)
+	GetSyncHistory() ([]store.SyncRun, error)
+	GetEventLoopStatus() (store.EventLoopStatus, error)
 	Logout() error
 }
 
@@ -110,10 +169,23 @@ type ImportExporter interface {
 
 	GetLocalImporter(string, string) (*transfer.Transfer, error)
 	GetRemoteImporter(string, string, string, string, string) (*transfer.Transfer, error)
+	GetProtonImporter(string, string) (*transfer.Transfer, error)
+	GetPluginImporter(string, string) (*transfer.Transfer, error)
 	GetEMLExporter(string, string) (*transfer.Transfer, error)
 	GetMBOXExporter(string, string) (*transfer.Transfer, error)
+	GetMaildirExporter(string, string) (*transfer.Transfer, error)
+	GetArchiveExporter(string, string) (*transfer.Transfer, error)
+	GetWebDAVExporter(string, string, string, string) (*transfer.Transfer, error)
+	GetS3Exporter(string, transfer.S3Config) (*transfer.Transfer, error)
+	GetPluginExporter(string, string) (*transfer.Transfer, error)
+	GetMultiExporter(string, []transfer.TargetProvider) (*transfer.Transfer, error)
+	ExportContacts(string, string) (int, int, error)
+	ExportCalendars(string, string) (int, int, error)
 	ReportBug(osType, osVersion, description, accountName, address, emailClient string) error
 	ReportFile(osType, osVersion, accountName, address string, logdata []byte) error
+	IsResumeRequested() bool
+	IsDryRunRequested() bool
+	IsJSONProgressRequested() bool
 }
 
 type importExportWrap struct {
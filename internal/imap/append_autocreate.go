@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	imapserver "github.com/emersion/go-imap/server"
+)
+
+// appendAutoCreate is the standard APPEND which first creates the missing
+// target mailbox if the user enabled it. Many clients do not handle TRYCREATE
+// response and simply fail to save the message.
+type appendAutoCreate struct {
+	imapserver.Append
+}
+
+func (cmd *appendAutoCreate) Handle(conn imapserver.Conn) error {
+	if user, ok := conn.Context().User.(*imapUser); ok {
+		if err := user.createMailboxForAppend(cmd.Mailbox); err != nil {
+			return err
+		}
+	}
+
+	return cmd.Append.Handle(conn)
+}
+
+type appendAutoCreateExtension struct{}
+
+func newAppendAutoCreateExtension() imapserver.Extension {
+	return &appendAutoCreateExtension{}
+}
+
+func (ext *appendAutoCreateExtension) Capabilities(c imapserver.Conn) []string {
+	return nil
+}
+
+func (ext *appendAutoCreateExtension) Command(name string) imapserver.HandlerFactory {
+	if name == "APPEND" {
+		return func() imapserver.Handler {
+			return &appendAutoCreate{}
+		}
+	}
+
+	return nil
+}
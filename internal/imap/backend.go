@@ -26,7 +26,9 @@ import (
 	imapid "github.com/ProtonMail/go-imap-id"
 	"github.com/ProtonMail/proton-bridge/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/internal/events"
+	"github.com/ProtonMail/proton-bridge/internal/users"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/ProtonMail/proton-bridge/pkg/localsocket"
 	"github.com/emersion/go-imap"
 	goIMAPBackend "github.com/emersion/go-imap/backend"
 	"github.com/sirupsen/logrus"
@@ -47,10 +49,6 @@ type imapBackend struct {
 
 	lastMailClient       imapid.ID
 	lastMailClientLocker sync.Locker
-
-	imapCache     map[string]map[string]string
-	imapCachePath string
-	imapCacheLock *sync.RWMutex
 }
 
 // NewIMAPBackend returns struct implementing go-imap/backend interface.
@@ -89,9 +87,6 @@ func newIMAPBackend(
 
 		lastMailClient:       imapid.ID{imapid.FieldName: clientNone},
 		lastMailClientLocker: &sync.Mutex{},
-
-		imapCachePath: cfg.GetIMAPCachePath(),
-		imapCacheLock: &sync.RWMutex{},
 	}
 }
 
@@ -152,17 +147,28 @@ func (ib *imapBackend) deleteUser(address string) {
 }
 
 // Login authenticates a user.
-func (ib *imapBackend) Login(_ *imap.ConnInfo, username, password string) (goIMAPBackend.User, error) {
+// Connections through local socket are already authenticated by peer
+// credentials and so the bridge password is not checked for them.
+func (ib *imapBackend) Login(connInfo *imap.ConnInfo, username, password string) (goIMAPBackend.User, error) {
 	// Called from go-imap in goroutines - we need to handle panics for each function.
 	defer ib.panicHandler.HandlePanic()
 
+	client := clientAddress(connInfo)
+
 	imapUser, err := ib.getUser(username)
 	if err != nil {
 		log.WithError(err).Warn("Cannot get user")
+		if !isLocalSocket(connInfo) {
+			if lockoutErr := ib.bridge.CheckUnknownUserLogin(client); lockoutErr != nil {
+				return nil, lockoutErr
+			}
+		}
 		return nil, err
 	}
 
-	if err := imapUser.user.CheckBridgeLogin(password); err != nil {
+	if isLocalSocket(connInfo) {
+		log.WithField("username", username).Debug("Trusting local socket connection")
+	} else if err := imapUser.user.CheckBridgeLogin(client, password); err != nil {
 		log.WithError(err).Error("Could not check bridge password")
 		_ = imapUser.Logout()
 		// Apple Mail sometimes generates a lot of requests very quickly.
@@ -172,6 +178,12 @@ func (ib *imapBackend) Login(_ *imap.ConnInfo, username, password string) (goIMA
 		return nil, err
 	}
 
+	if !imapUser.user.IsIMAPEnabled() {
+		log.WithField("username", username).Warn("IMAP is disabled for the account")
+		_ = imapUser.Logout()
+		return nil, users.ErrIMAPDisabled
+	}
+
 	// The update channel should be nil until we try to login to IMAP for the first time
 	// so that it doesn't make bridge slow for users who are only using bridge for SMTP
 	// (otherwise the store will be locked for 1 sec per email during synchronization).
@@ -213,6 +225,7 @@ func (ib *imapBackend) setLastMailClient(id imapid.ID) {
 func (ib *imapBackend) monitorDisconnectedUsers() {
 	ch := make(chan string)
 	ib.eventListener.Add(events.CloseConnectionEvent, ch)
+	ib.eventListener.Add(events.CloseIMAPConnectionEvent, ch)
 
 	for address := range ch {
 		// delete the user to ensure future imap login attempts use the latest bridge user
@@ -228,3 +241,15 @@ func (ib *imapBackend) upgradeError(err error) {
 		ib.eventListener.Emit(events.IMAPTLSBadCert, err.Error())
 	}
 }
+
+func isLocalSocket(connInfo *imap.ConnInfo) bool {
+	return connInfo != nil && localsocket.IsLocal(connInfo.LocalAddr)
+}
+
+// clientAddress identifies the connection for limiting of failed logins.
+func clientAddress(connInfo *imap.ConnInfo) string {
+	if connInfo == nil || connInfo.RemoteAddr == nil {
+		return ""
+	}
+	return connInfo.RemoteAddr.String()
+}
@@ -26,17 +26,19 @@ import (
 type configProvider interface {
 	GetEventsPath() string
 	GetDBDir() string
-	GetIMAPCachePath() string
 }
 
 type bridger interface {
 	SetCurrentClient(clientName, clientVersion string)
 	GetUser(query string) (bridgeUser, error)
+	GetUsers() []bridgeUser
+	CheckUnknownUserLogin(client string) error
 }
 
 type bridgeUser interface {
 	ID() string
-	CheckBridgeLogin(password string) error
+	CheckBridgeLogin(client, password string) error
+	IsIMAPEnabled() bool
 	IsCombinedAddressMode() bool
 	GetAddressID(address string) (string, error)
 	GetPrimaryAddress() string
@@ -46,6 +48,7 @@ type bridgeUser interface {
 	CloseConnection(address string)
 	GetStore() storeUserProvider
 	GetTemporaryPMAPIClient() pmapi.Client
+	HasMessage(apiID string) bool
 }
 
 type bridgeWrap struct {
@@ -67,6 +70,14 @@ func (b *bridgeWrap) GetUser(query string) (bridgeUser, error) {
 	return newBridgeUserWrap(user), nil
 }
 
+func (b *bridgeWrap) GetUsers() []bridgeUser {
+	users := []bridgeUser{}
+	for _, user := range b.Bridge.GetUsers() {
+		users = append(users, newBridgeUserWrap(user))
+	}
+	return users
+}
+
 type bridgeUserWrap struct {
 	*users.User
 }
@@ -78,3 +89,13 @@ func newBridgeUserWrap(bridgeUser *users.User) *bridgeUserWrap {
 func (u *bridgeUserWrap) GetStore() storeUserProvider {
 	return newStoreUserWrap(u.User.GetStore())
 }
+
+// HasMessage returns whether the message belongs to the user. Users without
+// a store, e.g. logged out, have no messages.
+func (u *bridgeUserWrap) HasMessage(apiID string) bool {
+	store := u.User.GetStore()
+	if store == nil {
+		return false
+	}
+	return store.HasMessage(apiID)
+}
@@ -26,6 +26,11 @@ import (
 	backendMessage "github.com/ProtonMail/proton-bridge/pkg/message"
 )
 
+const (
+	defaultSizeLimit = 100 * 1000 * 1000
+	MinSizeLimit     = 30 * 1000 * 1000 // The smallest cache still fitting the biggest message.
+)
+
 type key struct {
 	ID        string
 	Timestamp int64
@@ -47,7 +52,7 @@ type cachedMessage struct {
 //nolint[gochecknoglobals]
 var (
 	cacheTimeLimit = int64(1 * 60 * 60 * 1000) // milliseconds
-	cacheSizeLimit = 100 * 1000 * 1000         // B - MUST be larger than email max size limit (~ 25 MB)
+	cacheSizeLimit = defaultSizeLimit          // B - MUST be larger than email max size limit (~ 25 MB)
 	mailCache      = make(map[string]cachedMessage)
 
 	// cacheMutex takes care of one single operation, whereas buildMutex takes
@@ -75,6 +80,22 @@ func timestamp() int64 {
 	return time.Now().UnixNano() / int64(time.Millisecond)
 }
 
+// SetSizeLimit changes the maximum size of cached messages. Zero sets
+// the default; values below the size of the biggest message are raised.
+func SetSizeLimit(limit int) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	switch {
+	case limit == 0:
+		cacheSizeLimit = defaultSizeLimit
+	case limit < MinSizeLimit:
+		cacheSizeLimit = MinSizeLimit
+	default:
+		cacheSizeLimit = limit
+	}
+}
+
 func Clear() {
 	mailCache = make(map[string]cachedMessage)
 }
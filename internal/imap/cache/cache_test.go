@@ -97,3 +97,16 @@ func TestConcurency(t *testing.T) {
 		go SaveMail(fmt.Sprintf("%s%d", testUID, i), msg, bs)
 	}
 }
+
+func TestSetSizeLimit(t *testing.T) {
+	defer SetSizeLimit(0)
+
+	SetSizeLimit(50 * 1000 * 1000)
+	require.Equal(t, 50*1000*1000, cacheSizeLimit)
+
+	SetSizeLimit(1000)
+	require.Equal(t, MinSizeLimit, cacheSizeLimit)
+
+	SetSizeLimit(0)
+	require.Equal(t, defaultSizeLimit, cacheSizeLimit)
+}
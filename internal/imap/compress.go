@@ -0,0 +1,174 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"compress/flate"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/emersion/go-imap"
+	imapserver "github.com/emersion/go-imap/server"
+)
+
+// COMPRESS=DEFLATE extension (RFC 4978) makes the rest of the connection
+// compressed in both directions. It helps mostly to clients syncing over
+// a network instead of the local loopback.
+const (
+	compressCapability = "COMPRESS=DEFLATE"
+	compressDeflate    = "DEFLATE"
+
+	codeCompressionActive imap.StatusRespCode = "COMPRESSIONACTIVE"
+)
+
+type compressExtension struct {
+	// compressed holds connections already upgraded to compression.
+	compressed     map[imapserver.Conn]bool
+	compressedLock sync.Mutex
+}
+
+func newCompressExtension() *compressExtension {
+	return &compressExtension{
+		compressed: map[imapserver.Conn]bool{},
+	}
+}
+
+func (ext *compressExtension) Capabilities(c imapserver.Conn) []string {
+	if c.Context().State&imap.AuthenticatedState != 0 {
+		return []string{compressCapability}
+	}
+	return nil
+}
+
+func (ext *compressExtension) Command(name string) imapserver.HandlerFactory {
+	if name == "COMPRESS" {
+		return func() imapserver.Handler { return &compress{ext: ext} }
+	}
+	return nil
+}
+
+// setCompressed marks the connection as compressed and returns whether it
+// was compressed already.
+func (ext *compressExtension) setCompressed(conn imapserver.Conn) bool {
+	ext.compressedLock.Lock()
+	defer ext.compressedLock.Unlock()
+
+	if ext.compressed[conn] {
+		return true
+	}
+	ext.compressed[conn] = true
+
+	go func() {
+		<-conn.Context().LoggedOut
+		ext.compressedLock.Lock()
+		delete(ext.compressed, conn)
+		ext.compressedLock.Unlock()
+	}()
+	return false
+}
+
+// compress is the COMPRESS command. The connection is upgraded after the OK
+// response is sent, the same way as STARTTLS does it.
+type compress struct {
+	ext       *compressExtension
+	mechanism string
+}
+
+func (cmd *compress) Parse(fields []interface{}) error {
+	if len(fields) != 1 {
+		return errors.New("COMPRESS needs exactly one mechanism")
+	}
+	mechanism, err := imap.ParseString(fields[0])
+	if err != nil {
+		return err
+	}
+	cmd.mechanism = strings.ToUpper(mechanism)
+	return nil
+}
+
+func (cmd *compress) Handle(conn imapserver.Conn) error {
+	if conn.Context().User == nil {
+		return imapserver.ErrNotAuthenticated
+	}
+	if cmd.mechanism != compressDeflate {
+		return errors.New("unsupported compression mechanism")
+	}
+	if cmd.ext.setCompressed(conn) {
+		return imapserver.ErrStatusResp(&imap.StatusResp{
+			Type: imap.StatusRespNo,
+			Code: codeCompressionActive,
+			Info: "Compression is already active",
+		})
+	}
+
+	return imapserver.ErrStatusResp(&imap.StatusResp{
+		Type: imap.StatusRespOk,
+		Info: "DEFLATE active",
+	})
+}
+
+func (cmd *compress) Upgrade(conn imapserver.Conn) error {
+	return conn.Upgrade(func(sock net.Conn) (net.Conn, error) {
+		conn.WaitReady()
+		return newDeflateConn(sock)
+	})
+}
+
+// deflateConn is a connection compressed by raw DEFLATE (RFC 1951).
+type deflateConn struct {
+	net.Conn
+
+	r io.ReadCloser
+	w *flate.Writer
+}
+
+func newDeflateConn(conn net.Conn) (net.Conn, error) {
+	w, err := flate.NewWriter(conn, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	return &deflateConn{
+		Conn: conn,
+		r:    flate.NewReader(conn),
+		w:    w,
+	}, nil
+}
+
+func (c *deflateConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+// Write compresses the data and flushes them right away, otherwise the other
+// side would wait for the rest of the response forever.
+func (c *deflateConn) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, c.w.Flush()
+}
+
+// Close closes the connection without finishing the DEFLATE stream which
+// would need to write to the other side which might not read anymore.
+func (c *deflateConn) Close() error {
+	_ = c.r.Close()
+	return c.Conn.Close()
+}
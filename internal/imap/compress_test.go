@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressParse(t *testing.T) {
+	cmd := &compress{}
+	require.NoError(t, cmd.Parse([]interface{}{"deflate"}))
+	require.Equal(t, compressDeflate, cmd.mechanism)
+
+	require.Error(t, (&compress{}).Parse([]interface{}{}))
+	require.Error(t, (&compress{}).Parse([]interface{}{"DEFLATE", "GZIP"}))
+}
+
+func TestDeflateConn(t *testing.T) {
+	serverSock, clientSock := net.Pipe()
+
+	server, err := newDeflateConn(serverSock)
+	require.NoError(t, err)
+	client, err := newDeflateConn(clientSock)
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = server.Write([]byte("* OK first\r\n"))
+		_, _ = server.Write([]byte("* OK second\r\n"))
+	}()
+
+	// Every write must be readable on its own without waiting for more data.
+	r := bufio.NewReader(client)
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "* OK first\r\n", line)
+	line, err = r.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "* OK second\r\n", line)
+
+	go func() {
+		_, _ = client.Write([]byte("a001 NOOP\r\n"))
+	}()
+	line, err = bufio.NewReader(server).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "a001 NOOP\r\n", line)
+
+	require.NoError(t, client.Close())
+	require.NoError(t, server.Close())
+}
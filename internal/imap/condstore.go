@@ -0,0 +1,581 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/responses"
+	imapserver "github.com/emersion/go-imap/server"
+)
+
+// The CONDSTORE and QRESYNC extension (RFC 7162) DOES NOT implement the full
+// RFC! Excluded parts are:
+// * MODSEQ search criterion.
+// * MODSEQ in unsolicited FETCH responses: updates are broadcast to all
+//   connections regardless of what they enabled.
+// * VANISHED instead of EXPUNGE for connections which enabled QRESYNC:
+//   clients still receive standard EXPUNGE responses for the same reason.
+// * Sequence match data of the SELECT QRESYNC parameter which is optional.
+
+const (
+	enableCapability    = "ENABLE"
+	condstoreCapability = "CONDSTORE"
+	qresyncCapability   = "QRESYNC"
+
+	fetchModSeq         imap.FetchItem  = "MODSEQ"
+	statusHighestModSeq imap.StatusItem = "HIGHESTMODSEQ"
+
+	codeHighestModSeq imap.StatusRespCode = "HIGHESTMODSEQ"
+	codeModified      imap.StatusRespCode = "MODIFIED"
+
+	modifierChangedSince   = "CHANGEDSINCE"
+	modifierUnchangedSince = "UNCHANGEDSINCE"
+	modifierVanished       = "VANISHED"
+)
+
+var errQResyncNotEnabled = errors.New("QRESYNC is not enabled") //nolint[gochecknoglobals]
+
+func formatModSeq(modSeq uint64) imap.RawString {
+	return imap.RawString(strconv.FormatUint(modSeq, 10))
+}
+
+func parseModSeq(field interface{}) (uint64, error) {
+	var s string
+	switch field := field.(type) {
+	case string:
+		s = field
+	case imap.RawString:
+		s = string(field)
+	case uint32:
+		return uint64(field), nil
+	default:
+		return 0, errors.New("mod-sequence must be a number")
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+type condstoreExtension struct {
+	// enabled maps connections which enabled CONDSTORE to whether they
+	// enabled also QRESYNC.
+	enabled     map[imapserver.Conn]bool
+	enabledLock sync.Mutex
+}
+
+func newCondstoreExtension() *condstoreExtension {
+	return &condstoreExtension{
+		enabled: map[imapserver.Conn]bool{},
+	}
+}
+
+func (ext *condstoreExtension) Capabilities(c imapserver.Conn) []string {
+	if c.Context().State&imap.AuthenticatedState != 0 {
+		return []string{enableCapability, condstoreCapability, qresyncCapability}
+	}
+	return nil
+}
+
+func (ext *condstoreExtension) Command(name string) imapserver.HandlerFactory {
+	switch name {
+	case "ENABLE":
+		return func() imapserver.Handler { return &condstoreEnable{ext: ext} }
+	case "SELECT", "EXAMINE":
+		return func() imapserver.Handler {
+			cmd := &condstoreSelect{ext: ext}
+			cmd.ReadOnly = name == "EXAMINE"
+			return cmd
+		}
+	case "FETCH":
+		return func() imapserver.Handler { return &condstoreFetch{ext: ext} }
+	case "STORE":
+		return func() imapserver.Handler { return &condstoreStore{ext: ext} }
+	}
+	return nil
+}
+
+// enable remembers the connection enabled CONDSTORE (and QRESYNC) until
+// it is closed.
+func (ext *condstoreExtension) enable(conn imapserver.Conn, qresync bool) {
+	ext.enabledLock.Lock()
+	defer ext.enabledLock.Unlock()
+
+	wasQResync, ok := ext.enabled[conn]
+	ext.enabled[conn] = wasQResync || qresync
+	if ok {
+		return
+	}
+
+	go func() {
+		<-conn.Context().LoggedOut
+		ext.enabledLock.Lock()
+		delete(ext.enabled, conn)
+		ext.enabledLock.Unlock()
+	}()
+}
+
+func (ext *condstoreExtension) isEnabled(conn imapserver.Conn) (condstore, qresync bool) {
+	ext.enabledLock.Lock()
+	defer ext.enabledLock.Unlock()
+
+	qresync, condstore = ext.enabled[conn]
+	return
+}
+
+// condstoreEnable is the ENABLE command (RFC 5161) knowing only CONDSTORE
+// and QRESYNC.
+type condstoreEnable struct {
+	ext          *condstoreExtension
+	capabilities []string
+}
+
+func (cmd *condstoreEnable) Parse(fields []interface{}) error {
+	if len(fields) == 0 {
+		return errors.New("no capability to enable")
+	}
+	for _, field := range fields {
+		capability, err := imap.ParseString(field)
+		if err != nil {
+			return err
+		}
+		cmd.capabilities = append(cmd.capabilities, strings.ToUpper(capability))
+	}
+	return nil
+}
+
+func (cmd *condstoreEnable) Handle(conn imapserver.Conn) error {
+	if conn.Context().User == nil {
+		return imapserver.ErrNotAuthenticated
+	}
+
+	enabled := []interface{}{imap.RawString("ENABLED")}
+	for _, capability := range cmd.capabilities {
+		switch capability {
+		case condstoreCapability:
+			cmd.ext.enable(conn, false)
+		case qresyncCapability:
+			cmd.ext.enable(conn, true)
+		default:
+			continue
+		}
+		enabled = append(enabled, imap.RawString(capability))
+	}
+
+	return conn.WriteResp(imap.NewUntaggedResp(enabled))
+}
+
+type qresyncParams struct {
+	uidValidity uint32
+	modSeq      uint64
+	knownUIDs   *imap.SeqSet
+}
+
+// condstoreSelect is SELECT or EXAMINE which reports the highest
+// mod-sequence of the mailbox and accepts CONDSTORE and QRESYNC parameters.
+type condstoreSelect struct {
+	imapserver.Select
+	ext *condstoreExtension
+
+	condstore bool
+	qresync   *qresyncParams
+}
+
+func (cmd *condstoreSelect) Parse(fields []interface{}) error {
+	if err := cmd.Select.Parse(fields); err != nil {
+		return err
+	}
+	if len(fields) < 2 {
+		return nil
+	}
+
+	params, ok := fields[1].([]interface{})
+	if !ok || len(params) == 0 {
+		return errors.New("SELECT parameters must be a list")
+	}
+	name, err := imap.ParseString(params[0])
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToUpper(name) {
+	case condstoreCapability:
+		cmd.condstore = true
+	case qresyncCapability:
+		if len(params) < 2 {
+			return errors.New("missing QRESYNC parameters")
+		}
+		cmd.qresync, err = parseQResyncParams(params[1])
+		return err
+	default:
+		return errors.New("unknown SELECT parameter")
+	}
+	return nil
+}
+
+func parseQResyncParams(field interface{}) (*qresyncParams, error) {
+	fields, ok := field.([]interface{})
+	if !ok || len(fields) < 2 {
+		return nil, errors.New("QRESYNC parameters must be a list of UIDVALIDITY and mod-sequence")
+	}
+
+	params := &qresyncParams{}
+	var err error
+	if params.uidValidity, err = imap.ParseNumber(fields[0]); err != nil {
+		return nil, err
+	}
+	if params.modSeq, err = parseModSeq(fields[1]); err != nil {
+		return nil, err
+	}
+	if len(fields) > 2 {
+		knownUIDs, err := imap.ParseString(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		if params.knownUIDs, err = imap.ParseSeqSet(knownUIDs); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+func (cmd *condstoreSelect) Handle(conn imapserver.Conn) error {
+	if cmd.qresync != nil {
+		if _, qresync := cmd.ext.isEnabled(conn); !qresync {
+			return errQResyncNotEnabled
+		}
+	}
+	if cmd.condstore {
+		cmd.ext.enable(conn, false)
+	}
+
+	err := cmd.Select.Handle(conn)
+	if statusErr, ok := err.(*imap.ErrStatusResp); !ok || statusErr.Resp.Type != imap.StatusRespOk {
+		return err
+	}
+	mailbox, ok := conn.Context().Mailbox.(*imapMailbox)
+	if !ok {
+		return err
+	}
+
+	highestModSeq, modSeqErr := mailbox.storeMailbox.GetHighestModSeq()
+	if modSeqErr != nil {
+		return modSeqErr
+	}
+	if writeErr := conn.WriteResp(&imap.StatusResp{
+		Type:      imap.StatusRespOk,
+		Code:      codeHighestModSeq,
+		Arguments: []interface{}{formatModSeq(highestModSeq)},
+		Info:      "Highest",
+	}); writeErr != nil {
+		return writeErr
+	}
+
+	// Changes are sent only when the client knows the same UIDs.
+	if cmd.qresync != nil && cmd.qresync.uidValidity == mailbox.storeMailbox.UIDValidity() {
+		knownUIDs := cmd.qresync.knownUIDs
+		if knownUIDs == nil {
+			knownUIDs, _ = imap.ParseSeqSet("1:*")
+		}
+		if resyncErr := resync(conn, mailbox, knownUIDs, cmd.qresync.modSeq); resyncErr != nil {
+			return resyncErr
+		}
+	}
+
+	return err
+}
+
+// resync sends UIDs of removed messages and flags of changed messages within
+// the UID set since the mod-sequence.
+func resync(conn imapserver.Conn, mailbox *imapMailbox, uidSet *imap.SeqSet, modSeq uint64) error {
+	if err := writeVanished(conn, mailbox, uidSet, modSeq); err != nil {
+		return err
+	}
+
+	items := []imap.FetchItem{imap.FetchUid, imap.FetchFlags, fetchModSeq}
+	return writeFetch(conn, func(ch chan<- *imap.Message) error {
+		return mailbox.listMessages(true, uidSet, items, modSeq, ch)
+	})
+}
+
+func writeVanished(conn imapserver.Conn, mailbox *imapMailbox, uidSet *imap.SeqSet, modSeq uint64) error {
+	uids, err := mailbox.storeMailbox.GetVanishedUIDs(modSeq)
+	if err != nil {
+		return err
+	}
+
+	vanished := &imap.SeqSet{}
+	for _, uid := range uids {
+		if uidSet.Contains(uid) {
+			vanished.AddNum(uid)
+		}
+	}
+	if vanished.Empty() {
+		return nil
+	}
+
+	return conn.WriteResp(imap.NewUntaggedResp([]interface{}{
+		imap.RawString(modifierVanished),
+		[]interface{}{imap.RawString("EARLIER")},
+		imap.RawString(vanished.String()),
+	}))
+}
+
+func writeFetch(conn imapserver.Conn, list func(chan<- *imap.Message) error) error {
+	ch := make(chan *imap.Message)
+	done := make(chan error, 1)
+	go func() {
+		done <- conn.WriteResp(&responses.Fetch{Messages: ch})
+		// Make sure to drain the message channel.
+		for range ch {
+		}
+	}()
+
+	if err := list(ch); err != nil {
+		return err
+	}
+	return <-done
+}
+
+// condstoreFetch is FETCH which supports the MODSEQ item and CHANGEDSINCE
+// and VANISHED modifiers.
+type condstoreFetch struct {
+	imapserver.Fetch
+	ext *condstoreExtension
+
+	changedSince uint64
+	vanished     bool
+}
+
+func (cmd *condstoreFetch) Parse(fields []interface{}) error {
+	if len(fields) > 2 {
+		modifiers, ok := fields[2].([]interface{})
+		if !ok {
+			return errors.New("FETCH modifiers must be a list")
+		}
+		for i := 0; i < len(modifiers); i++ {
+			name, err := imap.ParseString(modifiers[i])
+			if err != nil {
+				return err
+			}
+			switch strings.ToUpper(name) {
+			case modifierChangedSince:
+				if i++; i >= len(modifiers) {
+					return errors.New("missing CHANGEDSINCE mod-sequence")
+				}
+				if cmd.changedSince, err = parseModSeq(modifiers[i]); err != nil {
+					return err
+				}
+			case modifierVanished:
+				cmd.vanished = true
+			default:
+				return errors.New("unknown FETCH modifier")
+			}
+		}
+		fields = fields[:2]
+	}
+
+	return cmd.Fetch.Parse(fields)
+}
+
+func (cmd *condstoreFetch) Handle(conn imapserver.Conn) error {
+	return cmd.handle(false, conn)
+}
+
+func (cmd *condstoreFetch) UidHandle(conn imapserver.Conn) error { //nolint[golint]
+	cmd.addItem(imap.FetchUid)
+	return cmd.handle(true, conn)
+}
+
+func (cmd *condstoreFetch) addItem(item imap.FetchItem) {
+	for _, existing := range cmd.Items {
+		if existing == item {
+			return
+		}
+	}
+	cmd.Items = append(cmd.Items, item)
+}
+
+func (cmd *condstoreFetch) handle(uid bool, conn imapserver.Conn) error {
+	ctx := conn.Context()
+	if ctx.Mailbox == nil {
+		return imapserver.ErrNoMailboxSelected
+	}
+	mailbox, ok := ctx.Mailbox.(*imapMailbox)
+	if !ok {
+		if uid {
+			return cmd.Fetch.UidHandle(conn)
+		}
+		return cmd.Fetch.Handle(conn)
+	}
+
+	if cmd.vanished {
+		if _, qresync := cmd.ext.isEnabled(conn); !qresync {
+			return errQResyncNotEnabled
+		}
+		if !uid || cmd.changedSince == 0 {
+			return errors.New("VANISHED is allowed only in UID FETCH with CHANGEDSINCE")
+		}
+	}
+
+	for _, item := range cmd.Items {
+		if item == fetchModSeq {
+			cmd.ext.enable(conn, false)
+		}
+	}
+	if cmd.changedSince != 0 {
+		cmd.ext.enable(conn, false)
+	}
+	if condstore, _ := cmd.ext.isEnabled(conn); condstore {
+		cmd.addItem(fetchModSeq)
+	}
+
+	if cmd.vanished {
+		if err := writeVanished(conn, mailbox, cmd.SeqSet, cmd.changedSince); err != nil {
+			return err
+		}
+	}
+
+	return writeFetch(conn, func(ch chan<- *imap.Message) error {
+		return mailbox.listMessages(uid, cmd.SeqSet, cmd.Items, cmd.changedSince, ch)
+	})
+}
+
+// condstoreStore is STORE with the UNCHANGEDSINCE modifier. Messages changed
+// after the mod-sequence are not updated and are reported by MODIFIED code.
+type condstoreStore struct {
+	imapserver.Store
+	ext *condstoreExtension
+
+	unchangedSince *uint64
+}
+
+func (cmd *condstoreStore) Parse(fields []interface{}) error {
+	if len(fields) > 1 {
+		if modifiers, ok := fields[1].([]interface{}); ok {
+			if len(modifiers) != 2 {
+				return errors.New("STORE modifier must be UNCHANGEDSINCE with mod-sequence")
+			}
+			name, err := imap.ParseString(modifiers[0])
+			if err != nil {
+				return err
+			}
+			if strings.ToUpper(name) != modifierUnchangedSince {
+				return errors.New("unknown STORE modifier")
+			}
+			unchangedSince, err := parseModSeq(modifiers[1])
+			if err != nil {
+				return err
+			}
+			cmd.unchangedSince = &unchangedSince
+			fields = append([]interface{}{fields[0]}, fields[2:]...)
+		}
+	}
+
+	return cmd.Store.Parse(fields)
+}
+
+func (cmd *condstoreStore) Handle(conn imapserver.Conn) error {
+	return cmd.handle(false, conn)
+}
+
+func (cmd *condstoreStore) UidHandle(conn imapserver.Conn) error { //nolint[golint]
+	return cmd.handle(true, conn)
+}
+
+func (cmd *condstoreStore) handle(uid bool, conn imapserver.Conn) error {
+	store := cmd.Store.Handle
+	if uid {
+		store = cmd.Store.UidHandle
+	}
+
+	mailbox, ok := conn.Context().Mailbox.(*imapMailbox)
+	if cmd.unchangedSince == nil || !ok {
+		return store(conn)
+	}
+	cmd.ext.enable(conn, false)
+
+	unchanged, modified, err := mailbox.splitByModSeq(uid, cmd.SeqSet, *cmd.unchangedSince)
+	if err != nil {
+		return err
+	}
+
+	if !unchanged.Empty() {
+		cmd.SeqSet = unchanged
+		if err := store(conn); err != nil {
+			return err
+		}
+	}
+
+	if modified.Empty() {
+		return nil
+	}
+	return imapserver.ErrStatusResp(&imap.StatusResp{
+		Type:      imap.StatusRespOk,
+		Code:      codeModified,
+		Arguments: []interface{}{imap.RawString(modified.String())},
+		Info:      "Conditional STORE failed",
+	})
+}
+
+// splitByModSeq splits messages in the set to those not changed and those
+// changed after the mod-sequence. Returned sets use the same numbers as
+// the input, i.e. UIDs or sequence numbers.
+func (im *imapMailbox) splitByModSeq(isUID bool, seqSet *imap.SeqSet, modSeq uint64) (unchanged, modified *imap.SeqSet, err error) {
+	unchanged, modified = &imap.SeqSet{}, &imap.SeqSet{}
+
+	for _, seq := range seqSet.Set {
+		err = im.storeMailbox.IterateAPIIDs(isUID, seq.Start, seq.Stop, listMessagesPageSize, func(apiIDs []string) error {
+			for _, apiID := range apiIDs {
+				storeMessage, err := im.storeMailbox.GetMessage(apiID)
+				if err != nil {
+					return err
+				}
+
+				var num uint32
+				if isUID {
+					num, err = storeMessage.UID()
+				} else {
+					num, err = storeMessage.SequenceNumber()
+				}
+				if err != nil {
+					return err
+				}
+
+				messageModSeq, err := im.storeMailbox.GetModSeq(apiID)
+				if err != nil {
+					return err
+				}
+
+				if messageModSeq > modSeq {
+					modified.AddNum(num)
+				} else {
+					unchanged.AddNum(num)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return unchanged, modified, nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"testing"
+
+	"github.com/emersion/go-imap"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCondstoreFetchParse(t *testing.T) {
+	cmd := &condstoreFetch{}
+	require.NoError(t, cmd.Parse([]interface{}{"1:*", []interface{}{"FLAGS"}, []interface{}{"CHANGEDSINCE", "12345678901", "VANISHED"}}))
+	require.Equal(t, uint64(12345678901), cmd.changedSince)
+	require.True(t, cmd.vanished)
+	require.Equal(t, []imap.FetchItem{imap.FetchFlags}, cmd.Items)
+
+	cmd = &condstoreFetch{}
+	require.NoError(t, cmd.Parse([]interface{}{"1", "MODSEQ"}))
+	require.Equal(t, uint64(0), cmd.changedSince)
+	require.Equal(t, []imap.FetchItem{fetchModSeq}, cmd.Items)
+
+	require.Error(t, (&condstoreFetch{}).Parse([]interface{}{"1", "FLAGS", []interface{}{"CHANGEDSINCE"}}))
+	require.Error(t, (&condstoreFetch{}).Parse([]interface{}{"1", "FLAGS", []interface{}{"UNKNOWN"}}))
+}
+
+func TestCondstoreStoreParse(t *testing.T) {
+	cmd := &condstoreStore{}
+	require.NoError(t, cmd.Parse([]interface{}{"1:5", []interface{}{"UNCHANGEDSINCE", "42"}, "+FLAGS.SILENT", []interface{}{`\Seen`}}))
+	require.Equal(t, uint64(42), *cmd.unchangedSince)
+	require.Equal(t, imap.StoreItem("+FLAGS.SILENT"), cmd.Item)
+	require.Equal(t, "1:5", cmd.SeqSet.String())
+
+	cmd = &condstoreStore{}
+	require.NoError(t, cmd.Parse([]interface{}{"1", "FLAGS", []interface{}{`\Seen`}}))
+	require.Nil(t, cmd.unchangedSince)
+}
+
+func TestCondstoreSelectParse(t *testing.T) {
+	cmd := &condstoreSelect{}
+	require.NoError(t, cmd.Parse([]interface{}{"INBOX", []interface{}{"CONDSTORE"}}))
+	require.True(t, cmd.condstore)
+	require.Nil(t, cmd.qresync)
+
+	cmd = &condstoreSelect{}
+	require.NoError(t, cmd.Parse([]interface{}{"INBOX", []interface{}{"QRESYNC", []interface{}{"67890007", "90060115194045000", "41,43:211"}}}))
+	require.Equal(t, uint32(67890007), cmd.qresync.uidValidity)
+	require.Equal(t, uint64(90060115194045000), cmd.qresync.modSeq)
+	require.Equal(t, "41,43:211", cmd.qresync.knownUIDs.String())
+
+	require.Error(t, (&condstoreSelect{}).Parse([]interface{}{"INBOX", []interface{}{"QRESYNC"}}))
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"github.com/ProtonMail/proton-bridge/internal/events"
+	"github.com/emersion/go-imap"
+	imapserver "github.com/emersion/go-imap/server"
+)
+
+// crossAccountCopyCode is the response code of APPEND refused because the
+// message belongs to another bridge account. Clients showing more accounts
+// together copy or move messages between them by APPEND which would silently
+// import a duplicate. CANNOT (RFC 5530) says the operation can never succeed,
+// so clients do not retry it.
+const crossAccountCopyCode = imap.StatusRespCode("CANNOT")
+
+const crossAccountCopyInfo = "Messages cannot be copied between different ProtonMail accounts. " +
+	"Enable cross-account copy in Bridge to import them instead."
+
+// checkCrossAccountCopy returns the error for APPEND of a message from
+// another bridge account unless the user allowed to import such messages.
+func (im *imapMailbox) checkCrossAccountCopy(internalID string) error {
+	for _, user := range im.user.backend.bridge.GetUsers() {
+		if user.ID() == im.storeUser.UserID() || !user.HasMessage(internalID) {
+			continue
+		}
+
+		im.user.backend.eventListener.Emit(events.CrossAccountCopyEvent, user.GetPrimaryAddress()+":"+im.storeAddress.AddressString())
+
+		if im.storeUser.IsCrossAccountCopyEnabled() {
+			im.log.WithField("sourceUserID", user.ID()).Info("Importing message copied from another account")
+			return nil
+		}
+
+		im.log.WithField("sourceUserID", user.ID()).Warn("Refusing to copy message from another account")
+		return imapserver.ErrStatusResp(&imap.StatusResp{
+			Type: imap.StatusRespNo,
+			Code: crossAccountCopyCode,
+			Info: crossAccountCopyInfo,
+		})
+	}
+
+	return nil
+}
@@ -17,11 +17,28 @@
 
 package imap
 
-import "github.com/sirupsen/logrus"
+import (
+	"github.com/ProtonMail/proton-bridge/internal/store"
+	"github.com/ProtonMail/proton-bridge/pkg/parallel"
+	"github.com/sirupsen/logrus"
+)
 
 const (
-	fetchMessagesWorkers    = 100 // In how many workers to fetch message (group list on IMAP).
-	fetchAttachmentsWorkers = 50  // In how many workers to fetch attachments (for one message).
+	// Attachments bigger than this are not buffered by fetch workers but
+	// streamed into the message one by one to keep memory usage low.
+	maxBufferedAttachmentSize = 5 * 1024 * 1024
+
+	// Built messages bigger than this are written to a temporary file and
+	// streamed to the client from there instead of being kept in memory.
+	maxInMemoryMessageSize = 5 * 1024 * 1024
+
+	// Clients using LITERAL+ send literals without waiting for the server,
+	// therefore the size has to be limited before reading them.
+	maxLiteralSize = 50 * 1024 * 1024
+
+	// FETCH reads message IDs from the store and builds responses in pages
+	// of this size, so only one page is held in memory at a time.
+	listMessagesPageSize = 1000
 
 	clientAppleMail   = "Mac OS X Mail"             //nolint[deadcode]
 	clientThunderbird = "Thunderbird"               //nolint[deadcode]
@@ -33,3 +50,12 @@ const (
 var (
 	log = logrus.WithField("pkg", "imap") //nolint[gochecknoglobals]
 )
+
+// getFetchAttachmentsWorkers returns in how many workers to fetch attachments
+// of one message.
+func getFetchAttachmentsWorkers() int {
+	if store.IsLowResourceMode() {
+		return 2
+	}
+	return parallel.ScaledWorkers(10, 50)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	imapserver "github.com/emersion/go-imap/server"
+)
+
+// localSocketExtension allows plain text authentication on connections
+// accepted by the local socket. Whether the server allows it is decided by
+// the listen address, but the local socket shares the same server and its
+// traffic never leaves the machine, so it needs no TLS.
+type localSocketExtension struct {
+	authMechanisms []string
+}
+
+func newLocalSocketExtension(authMechanisms ...string) imapserver.ConnExtension {
+	return &localSocketExtension{authMechanisms: authMechanisms}
+}
+
+func (ext *localSocketExtension) Capabilities(c imapserver.Conn) []string {
+	return nil
+}
+
+func (ext *localSocketExtension) Command(name string) imapserver.HandlerFactory {
+	return nil
+}
+
+func (ext *localSocketExtension) NewConn(c imapserver.Conn) imapserver.Conn {
+	if !isLocalSocket(c.Info()) {
+		return c
+	}
+	return &localSocketConn{Conn: c, authMechanisms: ext.authMechanisms}
+}
+
+// localSocketConn is treated as secure; authentication is enabled and
+// STARTTLS is not offered. The extension must be enabled before others
+// wrapping connections, so that they call these methods too.
+type localSocketConn struct {
+	imapserver.Conn
+
+	authMechanisms []string
+}
+
+func (c *localSocketConn) IsTLS() bool {
+	return true
+}
+
+func (c *localSocketConn) Capabilities() []string {
+	caps := []string{}
+	for _, capability := range c.Conn.Capabilities() {
+		switch capability {
+		case "STARTTLS":
+		case "LOGINDISABLED":
+			for _, mechanism := range c.authMechanisms {
+				caps = append(caps, "AUTH="+mechanism)
+			}
+		default:
+			caps = append(caps, capability)
+		}
+	}
+	return caps
+}
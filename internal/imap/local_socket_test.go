@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-imap/backend/memory"
+	imapserver "github.com/emersion/go-imap/server"
+	"github.com/emersion/go-sasl"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalSocketAllowsInsecureAuth(t *testing.T) {
+	s := imapserver.New(memory.New())
+	s.TLSConfig = &tls.Config{} //nolint[gosec]
+	s.AllowInsecureAuth = false
+	s.Enable(newLocalSocketExtension(sasl.Plain))
+	defer s.Close() //nolint[errcheck]
+
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	dir, err := ioutil.TempDir("", "imap-socket")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+	socketListener, err := net.Listen("unix", filepath.Join(dir, "imap.sock"))
+	require.NoError(t, err)
+	go func() { _ = s.Serve(tcpListener) }()
+	go func() { _ = s.Serve(socketListener) }()
+
+	capabilities, login := testLocalSocketLogin(t, "tcp", tcpListener.Addr().String())
+	require.Contains(t, capabilities, "STARTTLS")
+	require.Contains(t, capabilities, "LOGINDISABLED")
+	require.True(t, strings.HasPrefix(login, "a2 NO"), login)
+
+	capabilities, login = testLocalSocketLogin(t, "unix", socketListener.Addr().String())
+	require.NotContains(t, capabilities, "STARTTLS")
+	require.NotContains(t, capabilities, "LOGINDISABLED")
+	require.Contains(t, capabilities, "AUTH=PLAIN")
+	require.True(t, strings.HasPrefix(login, "a2 OK"), login)
+}
+
+func testLocalSocketLogin(t *testing.T, network, address string) (capabilities, login string) {
+	conn, err := net.Dial(network, address)
+	require.NoError(t, err)
+	defer conn.Close() //nolint[errcheck]
+
+	r := bufio.NewReader(conn)
+	_, err = r.ReadString('\n')
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("a1 CAPABILITY\r\n"))
+	require.NoError(t, err)
+	capabilities, err = r.ReadString('\n')
+	require.NoError(t, err)
+	_, err = r.ReadString('\n')
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("a2 LOGIN username password\r\n"))
+	require.NoError(t, err)
+	for !strings.HasPrefix(login, "a2 ") {
+		login, err = r.ReadString('\n')
+		require.NoError(t, err)
+	}
+
+	return capabilities, login
+}
@@ -125,22 +125,36 @@ func (im *imapMailbox) Status(items []imap.StatusItem) (*imap.MailboxStatus, err
 		message.ThunderbirdNonJunkFlag,
 	}
 
-	dbTotal, dbUnread, dbUnreadSeqNum, err := im.storeMailbox.GetCounts()
+	if _, ok := status.Items[statusHighestModSeq]; ok {
+		highestModSeq, err := im.storeMailbox.GetHighestModSeq()
+		if err != nil {
+			return nil, err
+		}
+		status.Items[statusHighestModSeq] = formatModSeq(highestModSeq)
+	}
+
+	// Status is answered from the cache kept up to date by the event loop,
+	// so clients polling many mailboxes do not scan the database every time.
+	dbTotal, dbUnread, dbUnreadSeqNum, uidNext, err := im.storeMailbox.GetStatus()
 	l.WithFields(logrus.Fields{
 		"total":        dbTotal,
 		"unread":       dbUnread,
 		"unreadSeqNum": dbUnreadSeqNum,
+		"uidNext":      uidNext,
 		"err":          err,
 	}).Debug("DB counts")
-	if err == nil {
-		status.Messages = uint32(dbTotal)
-		status.Unseen = uint32(dbUnread)
-		status.UnseenSeqNum = uint32(dbUnreadSeqNum)
+	if err != nil {
+		// Counts are optional, only the next UID is required.
+		if status.UidNext, err = im.storeMailbox.GetNextUID(); err != nil {
+			return nil, err
+		}
+		return status, nil
 	}
 
-	if status.UidNext, err = im.storeMailbox.GetNextUID(); err != nil {
-		return nil, err
-	}
+	status.Messages = uint32(dbTotal)
+	status.Unseen = uint32(dbUnread)
+	status.UnseenSeqNum = uint32(dbUnreadSeqNum)
+	status.UidNext = uidNext
 
 	return status, nil
 }
@@ -151,14 +165,7 @@ func (im *imapMailbox) SetSubscribed(subscribed bool) error {
 	// Called from go-imap in goroutines - we need to handle panics for each function.
 	defer im.panicHandler.HandlePanic()
 
-	label := im.storeMailbox.LabelID()
-	if subscribed && !im.user.isSubscribed(label) {
-		im.user.removeFromCache(SubscriptionException, label)
-	}
-	if !subscribed && im.user.isSubscribed(label) {
-		im.user.addToCache(SubscriptionException, label)
-	}
-	return nil
+	return im.user.storeUser.SetSubscribed(im.storeMailbox.LabelID(), subscribed)
 }
 
 // Check requests a checkpoint of the currently selected mailbox. A checkpoint
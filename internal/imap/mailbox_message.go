@@ -32,6 +32,7 @@ import (
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/ProtonMail/proton-bridge/internal/imap/cache"
 	"github.com/ProtonMail/proton-bridge/internal/imap/uidplus"
+	"github.com/ProtonMail/proton-bridge/internal/store"
 	"github.com/ProtonMail/proton-bridge/pkg/message"
 	"github.com/ProtonMail/proton-bridge/pkg/parallel"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
@@ -42,6 +43,8 @@ import (
 	openpgperrors "golang.org/x/crypto/openpgp/errors"
 )
 
+var errMessageSkipped = errors.New("message is in the skip-list, remove it from there to download it again") //nolint[gochecknoglobals]
+
 type doNotCacheError struct{ e error }
 
 func (dnc *doNotCacheError) Error() string { return dnc.e.Error() }
@@ -96,7 +99,15 @@ func (im *imapMailbox) CreateMessage(flags []string, date time.Time, body imap.L
 
 	// "Drafts" needs to call special API routes.
 	// Clients always append the whole message again and remove the old one.
-	if im.storeMailbox.LabelID() == pmapi.DraftLabel {
+	// Some clients keep drafts in their own folder and mark them by the flag
+	// only; those have to be created as drafts too, otherwise they would be
+	// imported as ordinary messages never shown in drafts of the web client.
+	isDraftsMailbox := im.storeMailbox.LabelID() == pmapi.DraftLabel
+	if isDraftsMailbox || isStringInList(flags, imap.DraftFlag) {
+		if !im.user.storeUser.IsDraftsUploadEnabled() {
+			return store.ErrDraftsUploadDisabled
+		}
+
 		// Sender address needs to be sanitised (drafts need to match cases exactly).
 		m.Sender.Address = pmapi.ConstructAddress(m.Sender.Address, addr.Email)
 
@@ -105,6 +116,11 @@ func (im *imapMailbox) CreateMessage(flags []string, date time.Time, body imap.L
 			return errors.Wrap(err, "failed to create draft")
 		}
 
+		if !isDraftsMailbox {
+			im.log.WithField("draftID", draft.ID).Info("Draft appended outside of Drafts was saved to Drafts")
+			return nil
+		}
+
 		targetSeq := im.storeMailbox.GetUIDList([]string{draft.ID})
 		return uidplus.AppendResponse(im.storeMailbox.UIDValidity(), targetSeq)
 	}
@@ -170,6 +186,12 @@ func (im *imapMailbox) CreateMessage(flags []string, date time.Time, body imap.L
 		}
 	}
 
+	if internalID != "" {
+		if err := im.checkCrossAccountCopy(internalID); err != nil {
+			return err
+		}
+	}
+
 	im.log.Info("Importing external message")
 	if err := im.importMessage(m, readers, kr); err != nil {
 		im.log.Error("Import failed: ", err)
@@ -237,6 +259,12 @@ func (im *imapMailbox) getMessage(storeMessage storeMessageProvider, items []ima
 			if err != nil {
 				return nil, err
 			}
+		case fetchModSeq:
+			var modSeq uint64
+			if modSeq, err = im.storeMailbox.GetModSeq(storeMessage.ID()); err != nil {
+				return nil, err
+			}
+			msg.Items[fetchModSeq] = []interface{}{formatModSeq(modSeq)}
 		default:
 			s := item
 
@@ -258,18 +286,38 @@ func (im *imapMailbox) getMessage(storeMessage storeMessageProvider, items []ima
 	return msg, err
 }
 
+// getBodyStructure returns the built message. It has to be released by
+// releaseMessage once it is not needed.
 func (im *imapMailbox) getBodyStructure(storeMessage storeMessageProvider) (
 	structure *message.BodyStructure,
-	bodyReader *bytes.Reader, err error,
+	bodyReader messageReader, err error,
 ) {
 	m := storeMessage.Message()
+	if im.storeUser.IsMessageSkipped(m.ID) {
+		return im.buildSkippedMessage(m)
+	}
+
 	id := im.storeUser.UserID() + m.ID
 	cache.BuildLock(id)
-	if bodyReader, structure = cache.LoadMail(id); bodyReader.Len() == 0 || structure == nil {
-		var body []byte
+	var cached *bytes.Reader
+	if cached, structure = cache.LoadMail(id); cached.Len() != 0 && structure != nil {
+		bodyReader = cached
+	} else {
+		var body *messageBuffer
+		reserved := buildMemoryEstimate(m)
+		store.AcquireMemory(reserved)
 		structure, body, err = im.buildMessage(m)
-		if err == nil && structure != nil && len(body) > 0 {
-			m.Size = int64(len(body))
+		store.ReleaseMemory(reserved)
+		switch err {
+		case nil:
+			im.storeUser.ClearProblem(m.ID)
+		case pmapi.ErrAPINotReachable, pmapi.ErrInvalidToken, pmapi.ErrUpgradeApplication:
+			// Connection problems are not related to the message.
+		default:
+			im.storeUser.RecordProblem(m.ID, im.name, err)
+		}
+		if err == nil && structure != nil && body.Len() > 0 {
+			m.Size = body.Len()
 			if err := storeMessage.SetSize(m.Size); err != nil {
 				im.log.WithError(err).
 					WithField("newSize", m.Size).
@@ -281,22 +329,63 @@ func (im *imapMailbox) getBodyStructure(storeMessage storeMessageProvider) (
 					WithField("msgID", m.ID).
 					Warn("Cannot update header while building")
 			}
-			// Drafts can change and we don't want to cache them.
-			if !isMessageInDraftFolder(m) {
-				cache.SaveMail(id, body, structure)
+			// Drafts can change and we don't want to cache them. Messages
+			// written to a temporary file are too big for the cache.
+			if data, ok := body.Bytes(); ok && !isMessageInDraftFolder(m) {
+				cache.SaveMail(id, data, structure)
 			}
-			bodyReader = bytes.NewReader(body)
+			bodyReader = body.reader()
 		}
 		if _, ok := err.(*doNotCacheError); ok {
 			im.log.WithField("msgID", m.ID).Errorf("do not cache message: %v", err)
 			err = nil
-			bodyReader = bytes.NewReader(body)
+			bodyReader = body.reader()
+		}
+		if bodyReader == nil {
+			if body != nil {
+				_ = body.Close()
+			}
+			bodyReader = cached
 		}
 	}
 	cache.BuildUnlock(id)
 	return structure, bodyReader, err
 }
 
+// buildSkippedMessage returns placeholder for message in the skip-list
+// without downloading it. It is not cached so it is replaced by the real
+// message once the message is removed from the skip-list.
+func (im *imapMailbox) buildSkippedMessage(m *pmapi.Message) (*message.BodyStructure, messageReader, error) {
+	placeholder := *m
+	placeholder.NumAttachments = 0
+	placeholder.Header = mail.Header{}
+	for key, values := range m.Header {
+		placeholder.Header[key] = append([]string{}, values...)
+	}
+
+	if err := message.CustomMessage(&placeholder, errMessageSkipped, false); err != nil {
+		return nil, nil, err
+	}
+
+	structure, body, err := im.buildMessageInner(&placeholder, nil)
+	if err != nil {
+		_ = body.Close()
+		return nil, nil, err
+	}
+	return structure, body.reader(), nil
+}
+
+// buildMemoryEstimate returns how much memory building of the message takes:
+// the encrypted body, the decrypted one and the built message are all
+// in memory at the same time, unless the message is written to a file.
+func buildMemoryEstimate(m *pmapi.Message) int64 {
+	const minEstimate = 64 * 1024
+	if estimate := 3 * m.Size; estimate > minEstimate {
+		return estimate
+	}
+	return minEstimate
+}
+
 func isMessageInDraftFolder(m *pmapi.Message) bool {
 	for _, labelID := range m.LabelIDs {
 		if labelID == pmapi.DraftLabel {
@@ -311,7 +400,7 @@ func isMessageInDraftFolder(m *pmapi.Message) bool {
 func (im *imapMailbox) getMessageBodySection(storeMessage storeMessageProvider, section *imap.BodySectionName) (literal imap.Literal, err error) { // nolint[funlen]
 	var (
 		structure  *message.BodyStructure
-		bodyReader *bytes.Reader
+		bodyReader messageReader
 		header     textproto.MIMEHeader
 		response   []byte
 	)
@@ -325,7 +414,7 @@ func (im *imapMailbox) getMessageBodySection(storeMessage storeMessageProvider,
 		header = message.GetHeader(m)
 		// We need to ensure we use the correct content-type,
 		// otherwise AppleMail expects `text/plain` in HTML mails.
-		if header.Get("Content-Type") == "" {
+		if header.Get("Content-Type") == "" && !im.storeUser.IsMessageSkipped(m.ID) {
 			if err = im.fetchMessage(m); err != nil {
 				return
 			}
@@ -344,14 +433,17 @@ func (im *imapMailbox) getMessageBodySection(storeMessage storeMessageProvider,
 			return
 		}
 
+		// Message content is streamed from the built message which is
+		// released once the literal is written to the client.
+		var content *io.SectionReader
 		switch {
 		case section.Specifier == imap.EntireSpecifier && len(section.Path) == 0:
 			//  An empty section specification refers to the entire message, including the header.
-			response, err = structure.GetSection(bodyReader, section.Path)
+			content, err = structure.GetSectionReader(bodyReader, section.Path)
 		case section.Specifier == imap.TextSpecifier || (section.Specifier == imap.EntireSpecifier && len(section.Path) != 0):
 			// The TEXT specifier refers to the content of the message (or section), omitting the [RFC-2822] header.
 			// Non-empty section with no specifier (imap.EntireSpecifier) refers to section content without header.
-			response, err = structure.GetSectionContent(bodyReader, section.Path)
+			content, err = structure.GetSectionContentReader(bodyReader, section.Path)
 		case section.Specifier == imap.MIMESpecifier:
 			// The MIME part specifier refers to the [MIME-IMB] header for this part.
 			fallthrough
@@ -360,6 +452,11 @@ func (im *imapMailbox) getMessageBodySection(storeMessage storeMessageProvider,
 		default:
 			err = errors.New("Unknown specifier " + string(section.Specifier))
 		}
+
+		if content != nil && err == nil {
+			return newMessageLiteral(bodyReader, content, section.Partial), nil
+		}
+		releaseMessage(bodyReader)
 	}
 
 	if err != nil {
@@ -479,6 +576,40 @@ func (im *imapMailbox) writeAttachmentBody(w io.Writer, m *pmapi.Message, att *p
 	return
 }
 
+// writeAttachmentPart streams the attachment as a new part of mw without
+// keeping the whole decrypted attachment in memory.
+func (im *imapMailbox) writeAttachmentPart(mw *multipart.Writer, m *pmapi.Message, att *pmapi.Attachment) (err error) {
+	// Retrieve encrypted attachment.
+	r, err := im.user.client().GetAttachment(att.ID)
+	if err != nil {
+		return
+	}
+	defer r.Close() //nolint[errcheck]
+
+	kr, err := im.user.client().KeyRingForAddressID(m.AddressID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get keyring for address ID")
+	}
+
+	// Decryption can rename the attachment so the header is created after it.
+	dr, errWrite := message.DecryptAttachment(kr, att, r)
+
+	p, err := mw.CreatePart(message.GetAttachmentHeader(att))
+	if err != nil {
+		return
+	}
+
+	if errWrite == nil {
+		errWrite = message.EncodeAttachmentBody(p, dr)
+	}
+	if errWrite != nil {
+		// Returning an error here makes certain mail clients behave badly,
+		// trying to retrieve the message again and again.
+		im.log.Warn("Cannot write attachment body: ", errWrite)
+	}
+	return nil
+}
+
 func (im *imapMailbox) writeRelatedPart(p io.Writer, m *pmapi.Message, inlines []*pmapi.Attachment) (err error) {
 	related := multipart.NewWriter(p)
 
@@ -499,16 +630,9 @@ func (im *imapMailbox) writeRelatedPart(p io.Writer, m *pmapi.Message, inlines [
 	_, _ = buf.WriteTo(p)
 
 	for _, inline := range inlines {
-		buf = &bytes.Buffer{}
-		if err = im.writeAttachmentBody(buf, m, inline); err != nil {
+		if err = im.writeAttachmentPart(related, m, inline); err != nil {
 			return
 		}
-
-		h := message.GetAttachmentHeader(inline)
-		if p, err = related.CreatePart(h); err != nil {
-			return
-		}
-		_, _ = buf.WriteTo(p)
 	}
 
 	_ = related.Close()
@@ -552,7 +676,7 @@ func (im *imapMailbox) setMessageContentType(m *pmapi.Message) (multipartType in
 }
 
 // buildMessage from PM to IMAP.
-func (im *imapMailbox) buildMessage(m *pmapi.Message) (structure *message.BodyStructure, msgBody []byte, err error) {
+func (im *imapMailbox) buildMessage(m *pmapi.Message) (structure *message.BodyStructure, msgBody *messageBuffer, err error) {
 	im.log.Trace("Building message")
 
 	var errNoCache doNotCacheError
@@ -585,6 +709,9 @@ func (im *imapMailbox) buildMessage(m *pmapi.Message) (structure *message.BodySt
 	// message than error because it will not be fixed and users would
 	// get error message all the time and could not see some messages.
 	structure, msgBody, err = im.buildMessageInner(m, kr)
+	if err != nil && msgBody != nil {
+		_ = msgBody.Close()
+	}
 	if err == pmapi.ErrAPINotReachable || err == pmapi.ErrInvalidToken || err == pmapi.ErrUpgradeApplication {
 		return nil, nil, err
 	} else if err != nil {
@@ -594,6 +721,7 @@ func (im *imapMailbox) buildMessage(m *pmapi.Message) (structure *message.BodySt
 		}
 		structure, msgBody, err = im.buildMessageInner(m, kr)
 		if err != nil {
+			_ = msgBody.Close()
 			return nil, nil, err
 		}
 	}
@@ -603,13 +731,16 @@ func (im *imapMailbox) buildMessage(m *pmapi.Message) (structure *message.BodySt
 	return structure, msgBody, err
 }
 
-func (im *imapMailbox) buildMessageInner(m *pmapi.Message, kr *crypto.KeyRing) (structure *message.BodyStructure, msgBody []byte, err error) { // nolint[funlen]
+// buildMessageInner writes the message to messageBuffer which has to be
+// closed by the caller, also when an error is returned.
+func (im *imapMailbox) buildMessageInner(m *pmapi.Message, kr *crypto.KeyRing) (structure *message.BodyStructure, msgBody *messageBuffer, err error) { // nolint[funlen]
 	multipartType, err := im.setMessageContentType(m)
 	if err != nil {
 		return
 	}
 
-	tmpBuf := &bytes.Buffer{}
+	tmpBuf := &messageBuffer{}
+	msgBody = tmpBuf
 	mainHeader := message.GetHeader(m)
 	if err = writeHeader(tmpBuf, mainHeader); err != nil {
 		return
@@ -665,6 +796,10 @@ func (im *imapMailbox) buildMessageInner(m *pmapi.Message, kr *crypto.KeyRing) (
 
 		processCallback := func(value interface{}) (interface{}, error) {
 			att := value.(*pmapi.Attachment)
+			if att.Size > maxBufferedAttachmentSize {
+				// Streamed in collectCallback.
+				return nil, nil
+			}
 
 			buf := &bytes.Buffer{}
 			if err = im.writeAttachmentBody(buf, m, att); err != nil {
@@ -674,9 +809,12 @@ func (im *imapMailbox) buildMessageInner(m *pmapi.Message, kr *crypto.KeyRing) (
 		}
 
 		collectCallback := func(idx int, value interface{}) error {
-			buf := value.(*bytes.Buffer)
-			defer buf.Reset()
 			att := atts[idx]
+			buf, ok := value.(*bytes.Buffer)
+			if !ok {
+				return im.writeAttachmentPart(mw, m, att)
+			}
+			defer buf.Reset()
 
 			attachmentHeader := message.GetAttachmentHeader(att)
 			if partWriter, err = mw.CreatePart(attachmentHeader); err != nil {
@@ -687,7 +825,7 @@ func (im *imapMailbox) buildMessageInner(m *pmapi.Message, kr *crypto.KeyRing) (
 			return nil
 		}
 
-		err = parallel.RunParallel(fetchAttachmentsWorkers, input, processCallback, collectCallback)
+		err = parallel.RunParallel(getFetchAttachmentsWorkers(), input, processCallback, collectCallback)
 		if err != nil {
 			return
 		}
@@ -697,9 +835,7 @@ func (im *imapMailbox) buildMessageInner(m *pmapi.Message, kr *crypto.KeyRing) (
 		fmt.Fprintf(tmpBuf, "\r\n\r\nUknown multipart type: %d\r\n\r\n", multipartType)
 	}
 
-	// We need to copy buffer before building body structure.
-	msgBody = tmpBuf.Bytes()
-	structure, err = message.NewBodyStructure(tmpBuf)
+	structure, err = message.NewBodyStructure(tmpBuf.reader())
 	if err != nil {
 		// NOTE: We need to set structure if it fails and is empty.
 		if structure == nil {
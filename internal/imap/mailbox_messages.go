@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/ProtonMail/proton-bridge/internal/imap/uidplus"
+	"github.com/ProtonMail/proton-bridge/internal/store"
 	"github.com/ProtonMail/proton-bridge/pkg/message"
 	"github.com/ProtonMail/proton-bridge/pkg/parallel"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
@@ -62,6 +63,7 @@ func (im *imapMailbox) setFlags(messageIDs, flags []string) error {
 	flagged := false
 	deleted := false
 	spam := false
+	var snoozeUntil time.Time
 
 	for _, f := range flags {
 		switch f {
@@ -73,6 +75,10 @@ func (im *imapMailbox) setFlags(messageIDs, flags []string) error {
 			deleted = true
 		case message.AppleMailJunkFlag, message.ThunderbirdJunkFlag:
 			spam = true
+		default:
+			if until, ok := message.ParseSnoozedFlag(f); ok {
+				snoozeUntil = until
+			}
 		}
 	}
 
@@ -102,6 +108,14 @@ func (im *imapMailbox) setFlags(messageIDs, flags []string) error {
 		_ = spamMailbox.UnlabelMessages(messageIDs)
 	}
 
+	if !snoozeUntil.IsZero() {
+		if err := im.storeMailbox.SnoozeMessages(messageIDs, snoozeUntil); err != nil {
+			log.WithError(err).Warn("Cannot snooze messages")
+		}
+	} else {
+		_ = im.storeMailbox.UnsnoozeMessages(messageIDs)
+	}
+
 	return nil
 }
 
@@ -144,6 +158,20 @@ func (im *imapMailbox) addOrRemoveFlags(operation imap.FlagsOp, messageIDs, flag
 			case imap.RemoveFlags:
 				_ = storeMailbox.UnlabelMessages(messageIDs)
 			}
+		default:
+			// Keyword $Snoozed-YYYYMMDD maps to snooze for clients without native support.
+			until, ok := message.ParseSnoozedFlag(f)
+			if !ok {
+				break
+			}
+			switch operation {
+			case imap.AddFlags:
+				if err := im.storeMailbox.SnoozeMessages(messageIDs, until); err != nil {
+					log.WithError(err).Warn("Cannot snooze messages")
+				}
+			case imap.RemoveFlags:
+				_ = im.storeMailbox.UnsnoozeMessages(messageIDs)
+			}
 		}
 	}
 
@@ -181,7 +209,7 @@ func (im *imapMailbox) labelMessages(uid bool, seqSet *imap.SeqSet, targetLabel
 	// messages can be removed from source during labeling (e.g. folder1 -> folder2).
 	sourceSeqSet := im.storeMailbox.GetUIDList(messageIDs)
 
-	targetStoreMailbox, err := im.storeAddress.GetMailbox(targetLabel)
+	targetStoreMailbox, err := im.storeAddress.GetOrCreateMailbox(targetLabel)
 	if err != nil {
 		return err
 	}
@@ -373,7 +401,13 @@ func (im *imapMailbox) SearchMessages(isUID bool, criteria *imap.SearchCriteria)
 // 3501 section 6.4.5 for a list of items that can be requested.
 //
 // Messages must be sent to msgResponse. When the function returns, msgResponse must be closed.
-func (im *imapMailbox) ListMessages(isUID bool, seqSet *imap.SeqSet, items []imap.FetchItem, msgResponse chan<- *imap.Message) (err error) { //nolint[funlen]
+func (im *imapMailbox) ListMessages(isUID bool, seqSet *imap.SeqSet, items []imap.FetchItem, msgResponse chan<- *imap.Message) error {
+	return im.listMessages(isUID, seqSet, items, 0, msgResponse)
+}
+
+// listMessages is ListMessages which skips messages not changed after the
+// mod-sequence changedSince, unless it is zero (CONDSTORE FETCH modifier).
+func (im *imapMailbox) listMessages(isUID bool, seqSet *imap.SeqSet, items []imap.FetchItem, changedSince uint64, msgResponse chan<- *imap.Message) (err error) { //nolint[funlen]
 	defer func() {
 		close(msgResponse)
 		if err != nil {
@@ -388,33 +422,19 @@ func (im *imapMailbox) ListMessages(isUID bool, seqSet *imap.SeqSet, items []ima
 
 	l := log.WithField("cmd", "ListMessages")
 
-	apiIDs, err := im.apiIDsFromSeqSet(isUID, seqSet)
-	if err != nil {
-		err = fmt.Errorf("list messages seq: %v", err)
-		l.WithField("seq", seqSet).Error(err)
-		return err
-	}
-
-	// From RFC: UID range of 559:* always includes the UID of the last message
-	// in the mailbox, even if 559 is higher than any assigned UID value.
-	// See: https://tools.ietf.org/html/rfc3501#page-61
-	if isUID && seqSet.Dynamic() && len(apiIDs) == 0 {
-		l.Debug("Requesting empty UID dynamic fetch, adding latest message")
-		apiID, err := im.storeMailbox.GetLatestAPIID()
-		if err != nil {
-			return nil
-		}
-		apiIDs = []string{apiID}
-	}
-
-	input := make([]interface{}, len(apiIDs))
-	for i, apiID := range apiIDs {
-		input[i] = apiID
-	}
-
 	processCallback := func(value interface{}) (interface{}, error) {
 		apiID := value.(string)
 
+		if changedSince != 0 {
+			modSeq, err := im.storeMailbox.GetModSeq(apiID)
+			if err != nil {
+				return nil, err
+			}
+			if modSeq <= changedSince {
+				return nil, nil
+			}
+		}
+
 		storeMessage, err := im.storeMailbox.GetMessage(apiID)
 		if err != nil {
 			err = fmt.Errorf("list message from db: %v", err)
@@ -447,14 +467,48 @@ func (im *imapMailbox) ListMessages(isUID bool, seqSet *imap.SeqSet, items []ima
 	}
 
 	collectCallback := func(idx int, value interface{}) error {
+		if value == nil {
+			return nil
+		}
 		msg := value.(*imap.Message)
 		msgResponse <- msg
 		return nil
 	}
 
-	err = parallel.RunParallel(fetchMessagesWorkers, input, processCallback, collectCallback)
-	if err != nil {
-		return err
+	// Messages are listed page by page straight from the store so that
+	// e.g. `FETCH 1:* (FLAGS)` of a huge folder does not build the whole
+	// response in memory before the first message is sent to the client.
+	listed := 0
+	listPage := func(apiIDs []string) error {
+		listed += len(apiIDs)
+
+		input := make([]interface{}, len(apiIDs))
+		for i, apiID := range apiIDs {
+			input[i] = apiID
+		}
+
+		return parallel.RunParallel(store.GetSyncOptions().BodyWorkers, input, processCallback, collectCallback)
+	}
+
+	for _, seq := range seqSet.Set {
+		if err = im.storeMailbox.IterateAPIIDs(isUID, seq.Start, seq.Stop, listMessagesPageSize, listPage); err != nil {
+			l.WithField("seq", seqSet).WithError(err).Error("Cannot list messages")
+			return err
+		}
+	}
+
+	// From RFC: UID range of 559:* always includes the UID of the last message
+	// in the mailbox, even if 559 is higher than any assigned UID value.
+	// See: https://tools.ietf.org/html/rfc3501#page-61
+	if isUID && seqSet.Dynamic() && listed == 0 {
+		l.Debug("Requesting empty UID dynamic fetch, adding latest message")
+		apiID, err := im.storeMailbox.GetLatestAPIID()
+		if err != nil {
+			return nil
+		}
+		if err = listPage([]string{apiID}); err != nil {
+			return err
+		}
 	}
 
 	if len(markAsReadIDs) > 0 {
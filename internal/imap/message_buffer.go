@@ -0,0 +1,162 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// messageReader is a built message. Readers backed by a temporary file
+// implement io.Closer as well and must be closed by releaseMessage.
+type messageReader interface {
+	io.ReadSeeker
+	io.ReaderAt
+	Size() int64
+}
+
+// messageBuffer is written by the message builder. Small messages stay in
+// memory so they can be cached; bigger ones are written to a temporary file.
+type messageBuffer struct {
+	mem     bytes.Buffer
+	file    *os.File
+	removed bool
+	size    int64
+}
+
+func (b *messageBuffer) Write(p []byte) (n int, err error) {
+	if b.file == nil && b.mem.Len()+len(p) > maxInMemoryMessageSize {
+		if err = b.spill(); err != nil {
+			return 0, err
+		}
+	}
+
+	if b.file != nil {
+		n, err = b.file.Write(p)
+	} else {
+		n, err = b.mem.Write(p)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *messageBuffer) spill() error {
+	file, err := ioutil.TempFile("", "bridge-message-")
+	if err != nil {
+		return err
+	}
+
+	// Removed file stays readable until it is closed and nothing is left
+	// behind after a crash. Windows does not allow it; the file is removed
+	// on close there.
+	b.file, b.removed = file, os.Remove(file.Name()) == nil
+
+	_, err = b.mem.WriteTo(file)
+	b.mem = bytes.Buffer{}
+	return err
+}
+
+// Len returns the size of the built message.
+func (b *messageBuffer) Len() int64 {
+	return b.size
+}
+
+// Bytes returns the built message if it is kept in memory.
+func (b *messageBuffer) Bytes() ([]byte, bool) {
+	if b.file != nil {
+		return nil, false
+	}
+	return b.mem.Bytes(), true
+}
+
+// reader returns the built message. The buffer must not be used afterwards.
+func (b *messageBuffer) reader() messageReader {
+	if b.file == nil {
+		return bytes.NewReader(b.mem.Bytes())
+	}
+	return &fileMessage{
+		SectionReader: io.NewSectionReader(b.file, 0, b.size),
+		buf:           b,
+	}
+}
+
+// Close removes the temporary file, if any.
+func (b *messageBuffer) Close() error {
+	if b == nil || b.file == nil {
+		return nil
+	}
+
+	err := b.file.Close()
+	if !b.removed {
+		_ = os.Remove(b.file.Name())
+	}
+	b.file = nil
+	return err
+}
+
+type fileMessage struct {
+	*io.SectionReader
+	buf *messageBuffer
+}
+
+func (m *fileMessage) Close() error {
+	return m.buf.Close()
+}
+
+// releaseMessage closes the message if it is backed by a temporary file.
+func releaseMessage(r messageReader) {
+	if closer, ok := r.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// messageLiteral streams the section of the built message to the client.
+// The message is released once the literal is read completely.
+type messageLiteral struct {
+	r      *io.SectionReader
+	closer messageReader
+}
+
+func newMessageLiteral(message messageReader, section *io.SectionReader, partial []int) *messageLiteral {
+	if len(partial) == 2 {
+		from, length := int64(partial[0]), int64(partial[1])
+		if from > section.Size() {
+			from, length = section.Size(), 0
+		}
+		if from+length > section.Size() {
+			length = section.Size() - from
+		}
+		section = io.NewSectionReader(section, from, length)
+	}
+	return &messageLiteral{r: section, closer: message}
+}
+
+func (l *messageLiteral) Read(p []byte) (n int, err error) {
+	n, err = l.r.Read(p)
+	if err != nil && l.closer != nil {
+		releaseMessage(l.closer)
+		l.closer = nil
+	}
+	return n, err
+}
+
+func (l *messageLiteral) Len() int {
+	return int(l.r.Size())
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package imap
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageBufferInMemory(t *testing.T) {
+	buf := &messageBuffer{}
+	_, err := buf.Write([]byte("Subject: test\r\n\r\nbody"))
+	require.NoError(t, err)
+
+	data, ok := buf.Bytes()
+	require.True(t, ok)
+	require.Equal(t, "Subject: test\r\n\r\nbody", string(data))
+
+	_, isCloser := buf.reader().(io.Closer)
+	require.False(t, isCloser)
+}
+
+func TestMessageBufferSpillsToFile(t *testing.T) {
+	part := bytes.Repeat([]byte("a"), maxInMemoryMessageSize/2+1)
+
+	buf := &messageBuffer{}
+	for i := 0; i < 3; i++ {
+		_, err := buf.Write(part)
+		require.NoError(t, err)
+	}
+
+	_, ok := buf.Bytes()
+	require.False(t, ok)
+	require.Equal(t, int64(3*len(part)), buf.Len())
+
+	reader := buf.reader()
+	literal := newMessageLiteral(reader, io.NewSectionReader(reader, 0, reader.Size()), nil)
+	require.Equal(t, 3*len(part), literal.Len())
+
+	data, err := ioutil.ReadAll(literal)
+	require.NoError(t, err)
+	require.Equal(t, bytes.Repeat(part, 3), data)
+
+	// Literal released the file once it was read.
+	require.Nil(t, buf.file)
+}
+
+func TestMessageLiteralPartial(t *testing.T) {
+	reader := bytes.NewReader([]byte("0123456789"))
+	section := io.NewSectionReader(reader, 2, 6)
+
+	for _, test := range []struct {
+		partial []int
+		want    string
+	}{
+		{nil, "234567"},
+		{[]int{1, 3}, "345"},
+		{[]int{4, 10}, "67"},
+		{[]int{10, 2}, ""},
+	} {
+		literal := newMessageLiteral(reader, io.NewSectionReader(section, 0, section.Size()), test.partial)
+		require.Equal(t, len(test.want), literal.Len())
+
+		data, err := ioutil.ReadAll(literal)
+		require.NoError(t, err)
+		require.Equal(t, test.want, string(data))
+	}
+}
@@ -22,14 +22,16 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
 	imapid "github.com/ProtonMail/go-imap-id"
-	"github.com/ProtonMail/proton-bridge/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/internal/events"
 	"github.com/ProtonMail/proton-bridge/internal/imap/uidplus"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/ProtonMail/proton-bridge/pkg/localsocket"
+	"github.com/ProtonMail/proton-bridge/pkg/ports"
 	"github.com/emersion/go-imap"
 	imapappendlimit "github.com/emersion/go-imap-appendlimit"
 	imapidle "github.com/emersion/go-imap-idle"
@@ -47,17 +49,21 @@ type imapServer struct {
 	eventListener listener.Listener
 	debugClient   bool
 	debugServer   bool
+	socketPath    string
 }
 
 // NewIMAPServer constructs a new IMAP server configured with the given options.
-func NewIMAPServer(debugClient, debugServer bool, port int, tls *tls.Config, imapBackend *imapBackend, eventListener listener.Listener) *imapServer { //nolint[golint]
+func NewIMAPServer(debugClient, debugServer bool, host string, port int, tls *tls.Config, imapBackend *imapBackend, eventListener listener.Listener) *imapServer { //nolint[golint]
 	s := imapserver.New(imapBackend)
-	s.Addr = fmt.Sprintf("%v:%v", bridge.Host, port)
+	s.Addr = net.JoinHostPort(host, strconv.Itoa(port))
 	s.TLSConfig = tls
-	s.AllowInsecureAuth = true
+	// Passwords must not go over the network in plain text. The local socket
+	// shares the server and is allowed per connection by localSocketExtension.
+	s.AllowInsecureAuth = ports.IsLoopbackHost(host)
 	s.ErrorLog = newServerErrorLogger("server-imap")
 	s.AutoLogout = 30 * time.Minute
 	s.UpgradeError = imapBackend.upgradeError
+	s.MaxLiteralSize = maxLiteralSize
 
 	serverID := imapid.ID{
 		imapid.FieldName:       "ProtonMail",
@@ -89,7 +95,7 @@ func NewIMAPServer(debugClient, debugServer bool, port int, tls *tls.Config, ima
 		})
 
 		return sasl.NewLoginServer(func(address, password string) error {
-			user, err := conn.Server().Backend.Login(nil, address, password)
+			user, err := conn.Server().Backend.Login(conn.Info(), address, password)
 			if err != nil {
 				return err
 			}
@@ -102,6 +108,8 @@ func NewIMAPServer(debugClient, debugServer bool, port int, tls *tls.Config, ima
 	})
 
 	s.Enable(
+		// Must be first to be the innermost wrapper of connections.
+		newLocalSocketExtension(sasl.Plain, sasl.Login),
 		imapidle.NewExtension(),
 		imapmove.NewExtension(),
 		imapspecialuse.NewExtension(),
@@ -110,6 +118,9 @@ func NewIMAPServer(debugClient, debugServer bool, port int, tls *tls.Config, ima
 		imapappendlimit.NewExtension(),
 		imapunselect.NewExtension(),
 		uidplus.NewExtension(),
+		newAppendAutoCreateExtension(),
+		newCondstoreExtension(),
+		newCompressExtension(),
 	)
 
 	return &imapServer{
@@ -120,6 +131,13 @@ func NewIMAPServer(debugClient, debugServer bool, port int, tls *tls.Config, ima
 	}
 }
 
+// SetLocalSocketPath makes the server listen also on unix socket at path.
+// Clients connected through it are authenticated by their user ID
+// and do not need the bridge password.
+func (s *imapServer) SetLocalSocketPath(path string) {
+	s.socketPath = path
+}
+
 // Starts the server.
 func (s *imapServer) ListenAndServe() {
 	go s.monitorDisconnectedUsers()
@@ -132,6 +150,17 @@ func (s *imapServer) ListenAndServe() {
 		return
 	}
 
+	if s.socketPath != "" {
+		// Server can be served only once, otherwise updates would be
+		// split between listeners, so both listeners are merged.
+		if socket, err := localsocket.Listen(s.socketPath); err != nil {
+			log.WithError(err).Error("Cannot listen on IMAP local socket")
+		} else {
+			log.Info("IMAP server listening at ", s.socketPath)
+			l = localsocket.Merge(l, socket)
+		}
+	}
+
 	err = s.server.Serve(&debugListener{
 		Listener: l,
 		server:   s,
@@ -154,6 +183,7 @@ func (s *imapServer) Close() {
 func (s *imapServer) monitorDisconnectedUsers() {
 	ch := make(chan string)
 	s.eventListener.Add(events.CloseConnectionEvent, ch)
+	s.eventListener.Add(events.CloseIMAPConnectionEvent, ch)
 
 	for address := range ch {
 		address := address
@@ -20,6 +20,7 @@ package imap
 import (
 	"io"
 	"net/mail"
+	"time"
 
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/ProtonMail/proton-bridge/internal/imap/uidplus"
@@ -32,8 +33,17 @@ type storeUserProvider interface {
 	GetSpace() (usedSpace, maxSpace uint, err error)
 	GetMaxUpload() (uint, error)
 
+	IsSubscribed(labelID string) bool
+	SetSubscribed(labelID string, subscribed bool) error
+
 	GetAddress(addressID string) (storeAddressProvider, error)
 
+	IsDraftsUploadEnabled() bool
+	IsAutoCreateMailboxesEnabled() bool
+	IsCrossAccountCopyEnabled() bool
+	RecordProblem(messageID, mailbox string, err error)
+	ClearProblem(messageID string)
+	IsMessageSkipped(messageID string) bool
 	CreateDraft(
 		kr *crypto.KeyRing,
 		message *pmapi.Message,
@@ -51,6 +61,7 @@ type storeAddressProvider interface {
 	CreateMailbox(name string) error
 	ListMailboxes() []storeMailboxProvider
 	GetMailbox(name string) (storeMailboxProvider, error)
+	GetOrCreateMailbox(name string) (storeMailboxProvider, error)
 }
 
 type storeMailboxProvider interface {
@@ -66,9 +77,14 @@ type storeMailboxProvider interface {
 
 	GetAPIIDsFromUIDRange(start, stop uint32) ([]string, error)
 	GetAPIIDsFromSequenceRange(start, stop uint32) ([]string, error)
+	IterateAPIIDs(isUID bool, start, stop uint32, pageSize int, callback func(apiIDs []string) error) error
 	GetLatestAPIID() (string, error)
+	GetHighestModSeq() (uint64, error)
+	GetModSeq(apiID string) (uint64, error)
+	GetVanishedUIDs(sinceModSeq uint64) ([]uint32, error)
 	GetNextUID() (uint32, error)
 	GetCounts() (dbTotal, dbUnread, dbUnreadSeqNum uint, err error)
+	GetStatus() (dbTotal, dbUnread, dbUnreadSeqNum uint, uidNext uint32, err error)
 	GetUIDList(apiIDs []string) *uidplus.OrderedSeq
 	GetUIDByHeader(header *mail.Header) uint32
 	GetDelimiter() string
@@ -81,6 +97,8 @@ type storeMailboxProvider interface {
 	MarkMessagesUnread(apiID []string) error
 	MarkMessagesStarred(apiID []string) error
 	MarkMessagesUnstarred(apiID []string) error
+	SnoozeMessages(apiID []string, until time.Time) error
+	UnsnoozeMessages(apiID []string) error
 	ImportMessage(msg *pmapi.Message, body []byte, labelIDs []string) error
 	DeleteMessages(apiID []string) error
 }
@@ -139,6 +157,14 @@ func (s *storeAddressWrap) GetMailbox(name string) (storeMailboxProvider, error)
 	return newStoreMailboxWrap(mailbox), nil
 }
 
+func (s *storeAddressWrap) GetOrCreateMailbox(name string) (storeMailboxProvider, error) {
+	mailbox, err := s.Address.GetOrCreateMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+	return newStoreMailboxWrap(mailbox), nil
+}
+
 type storeMailboxWrap struct {
 	*store.Mailbox
 }
@@ -78,26 +78,6 @@ func newIMAPUser(
 	}, err
 }
 
-func (iu *imapUser) isSubscribed(labelID string) bool {
-	subscriptionExceptions := iu.backend.getCacheList(iu.storeUser.UserID(), SubscriptionException)
-	exceptions := strings.Split(subscriptionExceptions, ";")
-
-	for _, exception := range exceptions {
-		if exception == labelID {
-			return false
-		}
-	}
-	return true
-}
-
-func (iu *imapUser) removeFromCache(label, value string) {
-	iu.backend.removeFromCache(iu.storeUser.UserID(), label, value)
-}
-
-func (iu *imapUser) addToCache(label, value string) {
-	iu.backend.addToCache(iu.storeUser.UserID(), label, value)
-}
-
 // Username returns this user's username.
 func (iu *imapUser) Username() string {
 	// Called from go-imap in goroutines - we need to handle panics for each function.
@@ -114,7 +94,7 @@ func (iu *imapUser) ListMailboxes(showOnlySubcribed bool) ([]goIMAPBackend.Mailb
 
 	mailboxes := []goIMAPBackend.Mailbox{}
 	for _, storeMailbox := range iu.storeAddress.ListMailboxes() {
-		if showOnlySubcribed && !iu.isSubscribed(storeMailbox.LabelID()) {
+		if showOnlySubcribed && !iu.storeUser.IsSubscribed(storeMailbox.LabelID()) {
 			continue
 		}
 		mailbox := newIMAPMailbox(iu.panicHandler, iu, storeMailbox)
@@ -145,6 +125,17 @@ func (iu *imapUser) GetMailbox(name string) (mb goIMAPBackend.Mailbox, err error
 	return newIMAPMailbox(iu.panicHandler, iu, storeMailbox), nil
 }
 
+// createMailboxForAppend creates the target mailbox of APPEND if it does not
+// exist and auto-creating is enabled. Otherwise the standard APPEND handler
+// reports the missing mailbox.
+func (iu *imapUser) createMailboxForAppend(name string) error {
+	_, err := iu.storeAddress.GetOrCreateMailbox(name)
+	if err != nil && iu.storeUser.IsAutoCreateMailboxesEnabled() {
+		return err
+	}
+	return nil
+}
+
 // CreateMailbox creates a new mailbox.
 func (iu *imapUser) CreateMailbox(name string) error {
 	// Called from go-imap in goroutines - we need to handle panics for each function.
@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package importexport
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+)
+
+// calendarEventsPageSize is the number of events fetched at once.
+const calendarEventsPageSize = 100
+
+// icsTextEscaper escapes value of TEXT property, see RFC 5545 3.3.11.
+var icsTextEscaper = strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\n", "\\n") //nolint[gochecknoglobals]
+
+// ExportCalendars writes every calendar of the account with given address
+// to the directory at path as a separate ICS file. Events which cannot be
+// decrypted are skipped and counted as failed. Time zones are referenced by
+// IANA names only, VTIMEZONE components are not included.
+func (ie *ImportExport) ExportCalendars(address, path string) (exported, failed int, err error) {
+	user, err := ie.Users.GetUser(address)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return 0, 0, err
+	}
+
+	client := ie.clientManager.GetClient(user.ID())
+
+	calendars, err := client.ListCalendars()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to get calendars")
+	}
+
+	usedNames := map[string]bool{}
+	for _, calendar := range calendars {
+		fileName := getCalendarFileName(calendar, usedNames)
+
+		calendarExported, calendarFailed, err := exportCalendar(client, calendar, filepath.Join(path, fileName))
+		exported += calendarExported
+		failed += calendarFailed
+		if err != nil {
+			return exported, failed, errors.Wrapf(err, "failed to export calendar %s", calendar.Name)
+		}
+	}
+
+	log.WithField("exported", exported).WithField("failed", failed).Info("Calendars exported")
+
+	return exported, failed, nil
+}
+
+func getCalendarFileName(calendar *pmapi.Calendar, usedNames map[string]bool) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(calendar.Name)
+	if name == "" || usedNames[name] {
+		name = strings.TrimSpace(name + " " + calendar.ID)
+	}
+	usedNames[name] = true
+	return name + ".ics"
+}
+
+func exportCalendar(client pmapi.Client, calendar *pmapi.Calendar, path string) (exported, failed int, err error) {
+	bootstrap, err := client.GetCalendarBootstrap(calendar.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	kr, err := client.UnlockCalendarKeyRing(bootstrap)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	w := bufio.NewWriter(f)
+	if _, err := w.WriteString(
+		"BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"PRODID:-//ProtonMail//Import-Export//EN\r\n" +
+			"X-WR-CALNAME:" + icsTextEscaper.Replace(calendar.Name) + "\r\n",
+	); err != nil {
+		return 0, 0, err
+	}
+
+	for page := 0; ; page++ {
+		events, err := client.ListCalendarEvents(calendar.ID, page, calendarEventsPageSize)
+		if err != nil {
+			return exported, failed, err
+		}
+
+		for _, event := range events {
+			vevent, err := getVEvent(kr, event)
+			if err != nil {
+				log.WithError(err).WithField("eventID", event.ID).Warn("Event cannot be exported")
+				failed++
+				continue
+			}
+			if _, err := w.WriteString(vevent); err != nil {
+				return exported, failed, err
+			}
+			exported++
+		}
+
+		if len(events) < calendarEventsPageSize {
+			break
+		}
+	}
+
+	if _, err := w.WriteString("END:VCALENDAR\r\n"); err != nil {
+		return exported, failed, err
+	}
+
+	return exported, failed, w.Flush()
+}
+
+func getVEvent(kr *crypto.KeyRing, event *pmapi.CalendarEvent) (string, error) {
+	cards, err := pmapi.DecryptCalendarEvent(kr, event)
+	if err != nil {
+		return "", err
+	}
+	return pmapi.CalendarEventToVEvent(cards)
+}
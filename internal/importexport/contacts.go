@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package importexport
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+)
+
+// contactsExportPageSize is the number of contacts with all cards fetched at once.
+const contactsExportPageSize = 50
+
+// ExportContacts writes all contacts of the account with given address to
+// the directory at path, one vCard 4.0 file per contact. Contacts which
+// cannot be decrypted or parsed are skipped and counted as failed.
+func (ie *ImportExport) ExportContacts(address, path string) (exported, failed int, err error) {
+	user, err := ie.Users.GetUser(address)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := os.MkdirAll(path, os.ModePerm); err != nil {
+		return 0, 0, err
+	}
+
+	client := ie.clientManager.GetClient(user.ID())
+
+	for page := 0; ; page++ {
+		contacts, err := client.GetContactsForExport(page, contactsExportPageSize)
+		if err != nil {
+			return exported, failed, errors.Wrap(err, "failed to get contacts")
+		}
+
+		for _, contact := range contacts {
+			if err := exportContact(client, contact, path); err != nil {
+				log.WithError(err).WithField("contactID", contact.ID).Warn("Contact cannot be exported")
+				failed++
+				continue
+			}
+			exported++
+		}
+
+		if len(contacts) < contactsExportPageSize {
+			break
+		}
+	}
+
+	log.WithField("exported", exported).WithField("failed", failed).Info("Contacts exported")
+
+	return exported, failed, nil
+}
+
+func exportContact(client pmapi.Client, contact pmapi.Contact, path string) error {
+	cards, err := client.DecryptAndVerifyCards(contact.Cards)
+	if err == pmapi.ErrCardVerificationFailed {
+		// Data are still readable, the contact was probably signed by an old key.
+		log.WithField("contactID", contact.ID).Warn("Exporting contact with invalid signature")
+	} else if err != nil {
+		return err
+	}
+
+	data, err := pmapi.ContactToVCard(cards)
+	if err != nil {
+		return err
+	}
+
+	fileName := filepath.Base(contact.ID) + ".vcf"
+	return ioutil.WriteFile(filepath.Join(path, fileName), data, 0600)
+}
@@ -26,11 +26,14 @@ import (
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/pkg/errors"
 	logrus "github.com/sirupsen/logrus"
 )
 
 var (
 	log = logrus.WithField("pkg", "importexport") //nolint[gochecknoglobals]
+
+	ErrSameAccount = errors.New("source and target account must be different")
 )
 
 type ImportExport struct {
@@ -39,6 +42,16 @@ type ImportExport struct {
 	config        Configer
 	panicHandler  users.PanicHandler
 	clientManager users.ClientManager
+
+	throttling  transfer.ThrottlingProfile
+	concurrency int
+	resume      bool
+	dryRun      bool
+
+	jsonProgress bool
+
+	mboxSplitSize  uint64
+	mboxSplitCount uint
 }
 
 func New(
@@ -55,9 +68,64 @@ func New(
 		config:        config,
 		panicHandler:  panicHandler,
 		clientManager: clientManager,
+
+		throttling:  transfer.ThrottlingNormal,
+		concurrency: transfer.DefaultConcurrency,
 	}
 }
 
+// SetResume makes frontends continue unfinished transfers
+// without asking whether to start from the beginning.
+func (ie *ImportExport) SetResume(resume bool) {
+	ie.resume = resume
+}
+
+// IsResumeRequested returns whether unfinished transfers should continue.
+func (ie *ImportExport) IsResumeRequested() bool {
+	return ie.resume
+}
+
+// SetDryRun makes frontends only report what would be transferred
+// instead of starting the transfer.
+func (ie *ImportExport) SetDryRun(dryRun bool) {
+	ie.dryRun = dryRun
+}
+
+// IsDryRunRequested returns whether transfers should be only reported.
+func (ie *ImportExport) IsDryRunRequested() bool {
+	return ie.dryRun
+}
+
+// SetJSONProgress makes frontends write progress of transfers as JSON
+// lines to stdout instead of human-readable text.
+func (ie *ImportExport) SetJSONProgress(jsonProgress bool) {
+	ie.jsonProgress = jsonProgress
+}
+
+// IsJSONProgressRequested returns whether progress should be written as JSON lines.
+func (ie *ImportExport) IsJSONProgressRequested() bool {
+	return ie.jsonProgress
+}
+
+// SetMBOXSplit sets limits of files of MBOX exports after which the next
+// numbered file is started. Zero means no limit.
+func (ie *ImportExport) SetMBOXSplit(maxSize uint64, maxCount uint) {
+	ie.mboxSplitSize = maxSize
+	ie.mboxSplitCount = maxCount
+}
+
+// SetThrottlingProfile sets the throttling profile used by all transfers
+// from or to ProtonMail.
+func (ie *ImportExport) SetThrottlingProfile(profile transfer.ThrottlingProfile) {
+	ie.throttling = profile
+}
+
+// SetConcurrency sets the number of workers uploading messages
+// to ProtonMail in parallel. Each source folder is uploaded by one worker.
+func (ie *ImportExport) SetConcurrency(workers int) {
+	ie.concurrency = workers
+}
+
 // ReportBug reports a new bug from the user.
 func (ie *ImportExport) ReportBug(osType, osVersion, description, accountName, address, emailClient string) error {
 	c := ie.clientManager.GetAnonymousClient()
@@ -113,9 +181,46 @@ func (ie *ImportExport) ReportFile(osType, osVersion, accountName, address strin
 	return nil
 }
 
-// GetLocalImporter returns transferrer from local EML or MBOX structure to ProtonMail account.
+// GetLocalImporter returns transferrer from local EML or MBOX structure,
+// from Maildir, or from Outlook PST file, to ProtonMail account. Plugins
+// matching the path have precedence.
 func (ie *ImportExport) GetLocalImporter(address, path string) (*transfer.Transfer, error) {
+	if transfer.IsPluginSource(path) {
+		return ie.GetPluginImporter(address, path)
+	}
+
+	target, err := ie.getPMAPIProvider(address)
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.IsPSTPath(path) {
+		source := transfer.NewPSTProvider(path)
+		if err := transfer.CreateMissingLabels(source, target); err != nil {
+			return nil, errors.Wrap(err, "failed to create labels for categories")
+		}
+		return transfer.New(ie.panicHandler, newImportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+	}
+
+	if transfer.IsMaildirPath(path) {
+		source := transfer.NewMaildirProvider(path)
+		return transfer.New(ie.panicHandler, newImportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+	}
+
 	source := transfer.NewLocalProvider(path)
+	if err := transfer.RestoreExportedMailboxes(source, target); err != nil {
+		return nil, errors.Wrap(err, "failed to create exported folders and labels")
+	}
+	return transfer.New(ie.panicHandler, newImportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+}
+
+// GetPluginImporter returns transferrer from the source of plugin matching
+// the location to ProtonMail account.
+func (ie *ImportExport) GetPluginImporter(address, location string) (*transfer.Transfer, error) {
+	source, err := transfer.NewPluginSourceProvider(location)
+	if err != nil {
+		return nil, err
+	}
 	target, err := ie.getPMAPIProvider(address)
 	if err != nil {
 		return nil, err
@@ -136,6 +241,27 @@ func (ie *ImportExport) GetRemoteImporter(address, username, password, host, por
 	return transfer.New(ie.panicHandler, newImportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
 }
 
+// GetProtonImporter returns transferrer from one ProtonMail account to another
+// one without any intermediate export to disk. Both accounts have to be logged
+// in. Folders and labels missing in the target account are created first.
+func (ie *ImportExport) GetProtonImporter(sourceAddress, targetAddress string) (*transfer.Transfer, error) {
+	source, err := ie.getPMAPIProvider(sourceAddress)
+	if err != nil {
+		return nil, err
+	}
+	target, err := ie.getPMAPIProvider(targetAddress)
+	if err != nil {
+		return nil, err
+	}
+	if source.ID() == target.ID() {
+		return nil, ErrSameAccount
+	}
+	if err := transfer.CreateMissingMailboxes(source, target); err != nil {
+		return nil, errors.Wrap(err, "failed to create folders and labels")
+	}
+	return transfer.New(ie.panicHandler, newImportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+}
+
 // GetEMLExporter returns transferrer from ProtonMail account to local EML structure.
 func (ie *ImportExport) GetEMLExporter(address, path string) (*transfer.Transfer, error) {
 	source, err := ie.getPMAPIProvider(address)
@@ -153,6 +279,112 @@ func (ie *ImportExport) GetMBOXExporter(address, path string) (*transfer.Transfe
 		return nil, err
 	}
 	target := transfer.NewMBOXProvider(path)
+	target.SetSplit(ie.mboxSplitSize, ie.mboxSplitCount)
+	return transfer.New(ie.panicHandler, newExportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+}
+
+// GetPluginExporter returns transferrer from ProtonMail account to the target
+// of plugin matching the location.
+func (ie *ImportExport) GetPluginExporter(address, location string) (*transfer.Transfer, error) {
+	source, err := ie.getPMAPIProvider(address)
+	if err != nil {
+		return nil, err
+	}
+	target, err := transfer.NewPluginTargetProvider(location)
+	if err != nil {
+		return nil, err
+	}
+	return transfer.New(ie.panicHandler, newExportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+}
+
+// GetMaildirExporter returns transferrer from ProtonMail account to local Maildir structure.
+func (ie *ImportExport) GetMaildirExporter(address, path string) (*transfer.Transfer, error) {
+	source, err := ie.getPMAPIProvider(address)
+	if err != nil {
+		return nil, err
+	}
+	target := transfer.NewMaildirProvider(path)
+	return transfer.New(ie.panicHandler, newExportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+}
+
+// GetArchiveExporter returns transferrer from ProtonMail account to EML files
+// in a single ZIP or TAR archive.
+func (ie *ImportExport) GetArchiveExporter(address, path string) (*transfer.Transfer, error) {
+	source, err := ie.getPMAPIProvider(address)
+	if err != nil {
+		return nil, err
+	}
+	target, err := transfer.NewArchiveProvider(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := transfer.New(ie.panicHandler, newExportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+	if err != nil {
+		return nil, err
+	}
+	// Archive is always created from scratch, it cannot continue.
+	t.ResetState()
+	return t, nil
+}
+
+// GetWebDAVExporter returns transferrer from ProtonMail account to EML files
+// uploaded to WebDAV server.
+func (ie *ImportExport) GetWebDAVExporter(address, url, username, password string) (*transfer.Transfer, error) {
+	target, err := transfer.NewWebDAVProvider(url, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return ie.getRemoteExporter(address, target)
+}
+
+// GetS3Exporter returns transferrer from ProtonMail account to EML files
+// uploaded to S3-compatible object store.
+func (ie *ImportExport) GetS3Exporter(address string, config transfer.S3Config) (*transfer.Transfer, error) {
+	target, err := transfer.NewS3Provider(config)
+	if err != nil {
+		return nil, err
+	}
+	return ie.getRemoteExporter(address, target)
+}
+
+// GetMultiExporter returns transferrer from ProtonMail account to several
+// targets at once. Each message is downloaded only once.
+func (ie *ImportExport) GetMultiExporter(address string, targets []transfer.TargetProvider) (*transfer.Transfer, error) {
+	source, err := ie.getPMAPIProvider(address)
+	if err != nil {
+		return nil, err
+	}
+
+	hasArchive := false
+	for _, target := range targets {
+		switch target := target.(type) {
+		case *transfer.MBOXProvider:
+			target.SetSplit(ie.mboxSplitSize, ie.mboxSplitCount)
+		case *transfer.ArchiveProvider:
+			hasArchive = true
+		}
+	}
+
+	target, err := transfer.NewMultiTargetProvider(targets...)
+	if err != nil {
+		return nil, err
+	}
+	t, err := transfer.New(ie.panicHandler, newExportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
+	if err != nil {
+		return nil, err
+	}
+	// Archive cannot continue, everything has to be exported again.
+	if hasArchive {
+		t.ResetState()
+	}
+	return t, nil
+}
+
+func (ie *ImportExport) getRemoteExporter(address string, target *transfer.RemoteProvider) (*transfer.Transfer, error) {
+	source, err := ie.getPMAPIProvider(address)
+	if err != nil {
+		return nil, err
+	}
 	return transfer.New(ie.panicHandler, newExportMetricsManager(ie), ie.config.GetLogDir(), ie.config.GetTransferDir(), source, target)
 }
 
@@ -167,5 +399,11 @@ func (ie *ImportExport) getPMAPIProvider(address string) (*transfer.PMAPIProvide
 		log.WithError(err).Info("Address does not exist, using all addresses")
 	}
 
-	return transfer.NewPMAPIProvider(ie.config.GetAPIConfig(), ie.clientManager, user.ID(), addressID)
+	provider, err := transfer.NewPMAPIProvider(ie.config.GetAPIConfig(), ie.clientManager, user.ID(), addressID)
+	if err != nil {
+		return nil, err
+	}
+	provider.SetThrottlingProfile(ie.throttling)
+	provider.SetConcurrency(ie.concurrency)
+	return provider, nil
 }
@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package mailmerge sends personalized copies of one message to a list of
+// recipients, each filled in with its own variables.
+package mailmerge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/textproto"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EmailColumn is the mandatory CSV column with addresses of recipients.
+const EmailColumn = "email"
+
+var (
+	ErrNoEmailColumn = errors.New("CSV has no email column")
+	ErrNoSubject     = errors.New("template has no Subject header")
+)
+
+// Recipient holds the variables of one CSV row keyed by column names.
+type Recipient map[string]string
+
+// Email returns the address of the recipient.
+func (r Recipient) Email() string {
+	return r[EmailColumn]
+}
+
+// Template is a message with headers and body which can use variables
+// of recipients in the text/template syntax, e.g. `Hello {{.name}}`.
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate parses the template. Missing variables are errors so no
+// message is sent with an empty placeholder.
+func ParseTemplate(text string) (*Template, error) {
+	tmpl, err := template.New("mailmerge").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse template")
+	}
+	return &Template{tmpl: tmpl}, nil
+}
+
+// LoadRecipients reads recipients from CSV with a header row. Column names
+// are lower-cased and one of them has to be EmailColumn.
+func LoadRecipients(r io.Reader) ([]Recipient, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read CSV")
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoEmailColumn
+	}
+
+	columns := rows[0]
+	hasEmail := false
+	for i, column := range columns {
+		columns[i] = strings.ToLower(strings.TrimSpace(column))
+		hasEmail = hasEmail || columns[i] == EmailColumn
+	}
+	if !hasEmail {
+		return nil, ErrNoEmailColumn
+	}
+
+	recipients := []Recipient{}
+	for _, row := range rows[1:] {
+		recipient := Recipient{}
+		for i, value := range row {
+			recipient[columns[i]] = strings.TrimSpace(value)
+		}
+		if recipient.Email() == "" {
+			continue
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// Render builds the message for the recipient sent from the address.
+// Headers of the template are kept, From, To, Date and MIME headers are
+// set by the mail-merge.
+func (t *Template) Render(from string, recipient Recipient) ([]byte, error) {
+	var text bytes.Buffer
+	if err := t.tmpl.Execute(&text, recipient); err != nil {
+		return nil, errors.Wrap(err, "failed to fill in template")
+	}
+
+	reader := bufio.NewReader(&text)
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "failed to read template headers")
+	}
+	if header.Get("Subject") == "" {
+		return nil, ErrNoSubject
+	}
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	header.Set("From", from)
+	header.Set("To", recipient.Email())
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Mime-Version", "1.0")
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var msg bytes.Buffer
+	for _, key := range keys {
+		for _, value := range header[key] {
+			fmt.Fprintf(&msg, "%s: %s\r\n", key, mime.QEncoding.Encode("utf-8", value))
+		}
+	}
+	msg.WriteString("\r\n")
+	msg.Write(bytes.ReplaceAll(bytes.ReplaceAll(body, []byte("\r\n"), []byte("\n")), []byte("\n"), []byte("\r\n")))
+	return msg.Bytes(), nil
+}
+
+// Sender sends one prepared message.
+type Sender interface {
+	Send(from string, to []string, msg []byte) error
+}
+
+// Result is the outcome of sending to one recipient.
+type Result struct {
+	Email  string
+	SentAt time.Time
+	Err    error
+}
+
+// Run sends the template to all recipients one by one waiting pace between
+// them so the account is not rate-limited. Failed recipients do not stop
+// the run. Every result is passed to onResult as soon as it is known.
+func Run(sender Sender, from string, tmpl *Template, recipients []Recipient, pace time.Duration, onResult func(Result)) []Result {
+	results := make([]Result, 0, len(recipients))
+	for i, recipient := range recipients {
+		if i > 0 && pace > 0 {
+			time.Sleep(pace)
+		}
+
+		result := Result{Email: recipient.Email()}
+		msg, err := tmpl.Render(from, recipient)
+		if err == nil {
+			err = sender.Send(from, []string{recipient.Email()}, msg)
+		}
+		result.Err = err
+		result.SentAt = time.Now()
+
+		results = append(results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+	return results
+}
+
+// WriteReport writes results as CSV with email, status, time and error.
+func WriteReport(w io.Writer, results []Result) error {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write([]string{EmailColumn, "status", "time", "error"}); err != nil {
+		return err
+	}
+	for _, result := range results {
+		status, errText := "sent", ""
+		if result.Err != nil {
+			status, errText = "failed", result.Err.Error()
+		}
+		if err := csvWriter.Write([]string{result.Email, status, result.SentAt.Format(time.RFC3339), errText}); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailmerge
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testTemplate = `Subject: News for {{.name}}
+Reply-To: office@pm.me
+
+Dear {{.name}},
+your code is {{.code}}.
+`
+
+func TestLoadRecipients(t *testing.T) {
+	recipients, err := LoadRecipients(strings.NewReader("Name, EMAIL ,code\nAlice,alice@pm.me,1\nNobody,,2\nBob, bob@pm.me ,3\n"))
+	require.NoError(t, err)
+	require.Equal(t, []Recipient{
+		{"name": "Alice", "email": "alice@pm.me", "code": "1"},
+		{"name": "Bob", "email": "bob@pm.me", "code": "3"},
+	}, recipients)
+
+	_, err = LoadRecipients(strings.NewReader("name,address\nAlice,alice@pm.me\n"))
+	require.Equal(t, ErrNoEmailColumn, err)
+}
+
+func TestRender(t *testing.T) {
+	tmpl, err := ParseTemplate(testTemplate)
+	require.NoError(t, err)
+
+	msg, err := tmpl.Render("office@pm.me", Recipient{"name": "Žofie", "email": "zofie@pm.me", "code": "42"})
+	require.NoError(t, err)
+
+	text := string(msg)
+	require.Contains(t, text, "From: office@pm.me\r\n")
+	require.Contains(t, text, "To: zofie@pm.me\r\n")
+	require.Contains(t, text, "Reply-To: office@pm.me\r\n")
+	require.Contains(t, text, "Subject: =?utf-8?q?News_for_=C5=BDofie?=\r\n")
+	require.Contains(t, text, "Content-Type: text/plain; charset=utf-8\r\n")
+	require.True(t, strings.HasSuffix(text, "\r\n\r\nDear Žofie,\r\nyour code is 42.\r\n"))
+
+	_, err = tmpl.Render("office@pm.me", Recipient{"name": "Alice", "email": "alice@pm.me"})
+	require.Error(t, err)
+
+	noSubject, err := ParseTemplate("To: someone\n\nHello")
+	require.NoError(t, err)
+	_, err = noSubject.Render("office@pm.me", Recipient{"email": "alice@pm.me"})
+	require.Equal(t, ErrNoSubject, err)
+}
+
+type testSender struct {
+	sent []string
+}
+
+func (s *testSender) Send(from string, to []string, msg []byte) error {
+	if to[0] == "bob@pm.me" {
+		return errors.New("rejected")
+	}
+	s.sent = append(s.sent, to...)
+	return nil
+}
+
+func TestRun(t *testing.T) {
+	tmpl, err := ParseTemplate(testTemplate)
+	require.NoError(t, err)
+
+	recipients := []Recipient{
+		{"name": "Alice", "email": "alice@pm.me", "code": "1"},
+		{"name": "Bob", "email": "bob@pm.me", "code": "2"},
+		{"name": "Cecil", "email": "cecil@pm.me"},
+		{"name": "Dan", "email": "dan@pm.me", "code": "4"},
+	}
+
+	sender := &testSender{}
+	reported := 0
+	results := Run(sender, "office@pm.me", tmpl, recipients, 0, func(Result) { reported++ })
+
+	require.Equal(t, []string{"alice@pm.me", "dan@pm.me"}, sender.sent)
+	require.Equal(t, 4, reported)
+	require.Len(t, results, 4)
+	require.NoError(t, results[0].Err)
+	require.EqualError(t, results[1].Err, "rejected")
+	require.Error(t, results[2].Err)
+	require.NoError(t, results[3].Err)
+
+	var report bytes.Buffer
+	require.NoError(t, WriteReport(&report, results))
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	require.Len(t, lines, 5)
+	require.Equal(t, "email,status,time,error", lines[0])
+	require.True(t, strings.HasPrefix(lines[2], "bob@pm.me,failed,"))
+	require.True(t, strings.HasSuffix(lines[2], ",rejected"))
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package mailmerge
+
+import (
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPSender sends messages through the local bridge SMTP server so they
+// go through the same encryption and sending as from any mail client.
+type SMTPSender struct {
+	Host     string
+	Port     int
+	UseSSL   bool
+	Username string
+	Password string
+}
+
+// Send implements Sender by opening a new connection for every message.
+func (s *SMTPSender) Send(from string, to []string, msg []byte) error {
+	addr := net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+	// Bridge uses self-signed certificate and connection never leaves
+	// this computer.
+	tlsConfig := &tls.Config{ServerName: s.Host, InsecureSkipVerify: true} //nolint[gosec]
+
+	var conn net.Conn
+	var err error
+	if s.UseSSL {
+		conn, err = tls.Dial("tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.Dial("tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer client.Close() //nolint[errcheck]
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !s.UseSSL {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+		return err
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
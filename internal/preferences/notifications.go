@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package preferences
+
+import (
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+)
+
+// Types of desktop notifications which can be turned on or off separately.
+const (
+	NotificationNewMail = "new_mail"
+	NotificationError   = "error"
+	NotificationUpdate  = "update"
+	NotificationLogout  = "logout"
+)
+
+// Notifications is the list of all types of desktop notifications.
+var Notifications = []string{ //nolint[gochecknoglobals]
+	NotificationNewMail,
+	NotificationError,
+	NotificationUpdate,
+	NotificationLogout,
+}
+
+const quietHoursFormat = "15:04"
+
+// NotificationKey returns preferences key for notification type.
+func NotificationKey(notification string) string {
+	return "notify_" + notification
+}
+
+// ShouldNotify returns whether notification of given type should be shown
+// at the given time, i.e. it is turned on and it is not in quiet hours.
+func ShouldNotify(pref *config.Preferences, notification string, now time.Time) bool {
+	if !pref.GetBool(NotificationKey(notification)) {
+		return false
+	}
+
+	return !IsQuietHours(pref.Get(QuietHoursStartKey), pref.Get(QuietHoursEndKey), now)
+}
+
+// IsQuietHours returns whether the time of day of now is between start and
+// end (in format HH:MM). Quiet hours can span midnight, e.g. 22:00 to 07:00.
+// Empty or malformed value turns quiet hours off.
+func IsQuietHours(start, end string, now time.Time) bool {
+	startTime, err := time.Parse(quietHoursFormat, start)
+	if err != nil {
+		return false
+	}
+
+	endTime, err := time.Parse(quietHoursFormat, end)
+	if err != nil {
+		return false
+	}
+
+	startMinute := startTime.Hour()*60 + startTime.Minute()
+	endMinute := endTime.Hour()*60 + endTime.Minute()
+	nowMinute := now.Hour()*60 + now.Minute()
+
+	if startMinute <= endMinute {
+		return nowMinute >= startMinute && nowMinute < endMinute
+	}
+
+	return nowMinute >= startMinute || nowMinute < endMinute
+}
+
+// IsValidQuietHoursTime returns whether value can be used as start or end of quiet hours.
+func IsValidQuietHoursTime(value string) bool {
+	_, err := time.Parse(quietHoursFormat, value)
+	return err == nil
+}
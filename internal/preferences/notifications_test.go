@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package preferences
+
+import (
+	"testing"
+	"time"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestIsQuietHours(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2020, 1, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		start, end string
+		now        time.Time
+		want       bool
+	}{
+		{"", "", at(12, 0), false},
+		{"bad", "07:00", at(3, 0), false},
+		{"12:00", "14:00", at(11, 59), false},
+		{"12:00", "14:00", at(12, 0), true},
+		{"12:00", "14:00", at(14, 0), false},
+		{"22:00", "07:00", at(23, 30), true},
+		{"22:00", "07:00", at(3, 0), true},
+		{"22:00", "07:00", at(7, 0), false},
+		{"22:00", "07:00", at(12, 0), false},
+	}
+
+	for _, test := range tests {
+		r.Equal(t, test.want, IsQuietHours(test.start, test.end, test.now), "%s-%s at %s", test.start, test.end, test.now)
+	}
+}
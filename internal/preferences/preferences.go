@@ -19,9 +19,12 @@
 package preferences
 
 import (
+	"math/rand"
 	"strconv"
 	"time"
 
+	"github.com/ProtonMail/proton-bridge/internal/imap/cache"
+	"github.com/ProtonMail/proton-bridge/internal/store"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
 	"github.com/sirupsen/logrus"
 )
@@ -30,16 +33,36 @@ import (
 const (
 	FirstStartKey          = "first_time_start"
 	FirstStartGUIKey       = "first_time_start_gui"
+	FirstStartCLIKey       = "first_time_start_cli"
 	NextHeartbeatKey       = "next_heartbeat"
 	APIPortKey             = "user_port_api"
 	IMAPPortKey            = "user_port_imap"
 	SMTPPortKey            = "user_port_smtp"
 	SMTPSSLKey             = "user_ssl_smtp"
+	ListenHostKey          = "listen_host"
 	AllowProxyKey          = "allow_proxy"
 	AutostartKey           = "autostart"
 	CookiesKey             = "cookies"
 	ReportOutgoingNoEncKey = "report_outgoing_email_without_encryption"
 	LastVersionKey         = "last_used_version"
+	LogLevelKey            = "log_level"
+	QuietHoursStartKey     = "notifications_quiet_hours_start"
+	QuietHoursEndKey       = "notifications_quiet_hours_end"
+	SilentUpdatesKey       = "silent_updates"
+	RolloutBucketKey       = "rollout_bucket"
+	UpdateMirrorsKey       = "update_mirrors"
+	SyncPagesInFlightKey   = "sync_pages_in_flight"
+	SyncPageSizeKey        = "sync_page_size"
+	SyncBodyWorkersKey     = "sync_body_workers"
+	CountsIntervalKey      = "counts_recalculation_interval" // In seconds.
+	EventPollIntervalKey   = "event_poll_interval"           // In seconds.
+	UndoSendDelayKey       = "smtp_undo_send_delay"          // In seconds.
+	FailedSendsKey         = "smtp_failed_sends"
+	MemoryBudgetKey        = "memory_budget_mb" // Zero means no limit.
+	KeychainKey            = "preferred_keychain"
+	LocalSocketKey         = "local_socket"
+	SMTPOnlyKey            = "smtp_only"
+	TLSClientCAKey         = "tls_client_ca" // Path to PEM file, empty means no client certificates.
 )
 
 type configProvider interface {
@@ -65,15 +88,70 @@ func New(cfg configProvider) (pref *config.Preferences) {
 func setDefaults(preferences *config.Preferences, cfg configProvider) {
 	preferences.SetDefault(FirstStartKey, "true")
 	preferences.SetDefault(FirstStartGUIKey, "true")
+	preferences.SetDefault(FirstStartCLIKey, "true")
 	preferences.SetDefault(NextHeartbeatKey, strconv.FormatInt(time.Now().Unix(), 10))
 	preferences.SetDefault(APIPortKey, strconv.Itoa(cfg.GetDefaultAPIPort()))
 	preferences.SetDefault(IMAPPortKey, strconv.Itoa(cfg.GetDefaultIMAPPort()))
 	preferences.SetDefault(SMTPPortKey, strconv.Itoa(cfg.GetDefaultSMTPPort()))
+	preferences.SetDefault(ListenHostKey, "127.0.0.1")
 	preferences.SetDefault(AllowProxyKey, "true")
 	preferences.SetDefault(AutostartKey, "true")
 	preferences.SetDefault(ReportOutgoingNoEncKey, "false")
 	preferences.SetDefault(LastVersionKey, "")
+	preferences.SetDefault(SilentUpdatesKey, "false")
+	preferences.SetDefault(UpdateMirrorsKey, "")
+	preferences.SetDefault(KeychainKey, "")
+	preferences.SetDefault(LocalSocketKey, "false")
+	preferences.SetDefault(SMTPOnlyKey, "false")
+	preferences.SetDefault(TLSClientCAKey, "")
+
+	syncOptions := store.DefaultSyncOptions()
+	preferences.SetDefault(SyncPagesInFlightKey, strconv.Itoa(syncOptions.PagesInFlight))
+	preferences.SetDefault(SyncPageSizeKey, strconv.Itoa(syncOptions.PageSize))
+	preferences.SetDefault(SyncBodyWorkersKey, strconv.Itoa(syncOptions.BodyWorkers))
+	preferences.SetDefault(CountsIntervalKey, "60")
+	preferences.SetDefault(EventPollIntervalKey, "30")
+	preferences.SetDefault(MemoryBudgetKey, "0")
+	preferences.SetDefault(UndoSendDelayKey, "0")
+	preferences.SetDefault(RolloutBucketKey, strconv.Itoa(rand.New(rand.NewSource(time.Now().UnixNano())).Intn(100))) //nolint[gosec]
+
+	for _, notification := range Notifications {
+		preferences.SetDefault(NotificationKey(notification), "true")
+	}
 
 	// By default, stick to STARTTLS. If the user uses catalina+applemail they'll have to change to SSL.
 	preferences.SetDefault(SMTPSSLKey, "false")
 }
+
+// syncOptionKeys are the preferences applied by ApplySyncOptions.
+var syncOptionKeys = []string{ //nolint[gochecknoglobals]
+	SyncPagesInFlightKey,
+	SyncPageSizeKey,
+	SyncBodyWorkersKey,
+	CountsIntervalKey,
+	EventPollIntervalKey,
+	MemoryBudgetKey,
+}
+
+// ApplySyncOptions sets store sync options, the intervals of mailbox
+// counts recalculation and event polling and the memory budget from
+// preferences. It is called at start and again by Reloader whenever
+// any of syncOptionKeys changes.
+func ApplySyncOptions(pref *config.Preferences) {
+	store.SetSyncOptions(store.SyncOptions{
+		PagesInFlight: pref.GetInt(SyncPagesInFlightKey),
+		PageSize:      pref.GetInt(SyncPageSizeKey),
+		BodyWorkers:   pref.GetInt(SyncBodyWorkersKey),
+	})
+	store.SetCountsRecalculationInterval(time.Duration(pref.GetInt(CountsIntervalKey)) * time.Second)
+	store.SetPollInterval(time.Duration(pref.GetInt(EventPollIntervalKey)) * time.Second)
+
+	// Cached messages take a quarter of the budget, the rest is for buffers.
+	memoryBudget := int64(pref.GetInt(MemoryBudgetKey)) * 1000 * 1000
+	store.SetMemoryBudget(memoryBudget)
+	if store.IsLowResourceMode() {
+		cache.SetSizeLimit(cache.MinSizeLimit)
+	} else {
+		cache.SetSizeLimit(int(memoryBudget / 4))
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package preferences
+
+import (
+	"os"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/events"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/ProtonMail/proton-bridge/pkg/listener"
+)
+
+// watchFileInterval is how often the preferences file is checked for changes.
+const watchFileInterval = 10 * time.Second
+
+type proxyAllower interface {
+	AllowProxy()
+	DisallowProxy()
+}
+
+// Reloader applies changes of the preferences file without restarting the app.
+// Only values which are safe to change at runtime are applied; the rest
+// (e.g. ports) is loaded but takes effect after the next start.
+type Reloader struct {
+	pref  *config.Preferences
+	path  string
+	proxy proxyAllower
+
+	lastModTime time.Time
+}
+
+// NewReloader returns a reloader for preferences stored at path.
+func NewReloader(pref *config.Preferences, path string, proxy proxyAllower) *Reloader {
+	r := &Reloader{
+		pref:  pref,
+		path:  path,
+		proxy: proxy,
+	}
+	r.lastModTime = r.getModTime()
+	return r
+}
+
+// Reload loads the preferences file again and applies changed values.
+func (r *Reloader) Reload() error {
+	changed, err := r.pref.Reload()
+	if err != nil {
+		return err
+	}
+
+	log.WithField("keys", changed).Info("Preferences reloaded")
+
+	syncOptionsChanged := false
+	for _, key := range changed {
+		switch key {
+		case LogLevelKey:
+			if level := r.pref.Get(LogLevelKey); level != "" {
+				config.SetLogLevel(level)
+			}
+		case AllowProxyKey:
+			if r.pref.GetBool(AllowProxyKey) {
+				r.proxy.AllowProxy()
+			} else {
+				r.proxy.DisallowProxy()
+			}
+		default:
+			if isSyncOptionKey(key) {
+				syncOptionsChanged = true
+			}
+		}
+	}
+
+	// Applied once even when more sync options changed at the same time.
+	if syncOptionsChanged {
+		ApplySyncOptions(r.pref)
+	}
+
+	return nil
+}
+
+// Watch reloads preferences every time ReloadPreferencesEvent is emitted
+// or the preferences file is modified. It blocks forever.
+func (r *Reloader) Watch(eventListener listener.Listener) {
+	reloadCh := make(chan string)
+	eventListener.Add(events.ReloadPreferencesEvent, reloadCh)
+
+	ticker := time.NewTicker(watchFileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-reloadCh:
+		case <-ticker.C:
+			modTime := r.getModTime()
+			if modTime.Equal(r.lastModTime) {
+				continue
+			}
+		}
+
+		r.lastModTime = r.getModTime()
+		if err := r.Reload(); err != nil {
+			log.WithError(err).Warn("Cannot reload preferences")
+		}
+	}
+}
+
+func isSyncOptionKey(key string) bool {
+	for _, syncKey := range syncOptionKeys {
+		if key == syncKey {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reloader) getModTime() time.Time {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package preferences
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/internal/store"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestReloadAppliesSyncOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "preferences")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	path := filepath.Join(dir, "prefs.json")
+	pref := config.NewPreferences(path)
+	pref.SetInt(MemoryBudgetKey, 0)
+	defer store.SetMemoryBudget(0)
+
+	reloader := NewReloader(pref, path, nil)
+
+	r.NoError(t, ioutil.WriteFile(path, []byte(`{"memory_budget_mb": "100"}`), 0600))
+	r.NoError(t, reloader.Reload())
+	r.Equal(t, int64(100*1000*1000), store.GetMemoryBudget())
+}
+
+func TestIsSyncOptionKey(t *testing.T) {
+	r.True(t, isSyncOptionKey(EventPollIntervalKey))
+	r.True(t, isSyncOptionKey(SyncPageSizeKey))
+	r.False(t, isSyncOptionKey(LogLevelKey))
+}
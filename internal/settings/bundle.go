@@ -0,0 +1,242 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package settings provides export and import of all bridge settings
+// as a single bundle file, e.g. to move bridge to a new machine.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/ProtonMail/gopenpgp/v2/helper"
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+const bundleVersion = 1
+
+var (
+	log = logrus.WithField("pkg", "settings") //nolint[gochecknoglobals]
+
+	ErrUnknownVersion = errors.New("unknown version of settings bundle")
+
+	// machineSpecificKeys are not exported because they describe
+	// the current install rather than user's choice.
+	machineSpecificKeys = map[string]bool{ //nolint[gochecknoglobals]
+		preferences.FirstStartKey:    true,
+		preferences.FirstStartGUIKey: true,
+		preferences.FirstStartCLIKey: true,
+		preferences.KeychainKey:      true,
+		preferences.NextHeartbeatKey: true,
+		preferences.CookiesKey:       true,
+		preferences.LastVersionKey:   true,
+		preferences.RolloutBucketKey: true,
+	}
+)
+
+type credentialsStorer interface {
+	List() (userIDs []string, err error)
+	Get(userID string) (*credentials.Credentials, error)
+	Import(creds *credentials.Credentials) error
+	Delete(userID string) error
+}
+
+// exportedCredentials is credentials of one user as stored in keychain.
+type exportedCredentials struct {
+	UserID string
+	Secret string
+}
+
+// Bundle is content of exported settings file.
+type Bundle struct {
+	Version     int
+	Preferences map[string]string
+	Accounts    []string // Usernames, for information only.
+
+	// Credentials is password encrypted PGP message containing JSON list
+	// of marshalled credentials. It is empty unless requested.
+	Credentials string `json:",omitempty"`
+}
+
+// Export creates bundle with preferences and list of accounts. Credentials are
+// included only when password is not empty; they are encrypted with it.
+func Export(pref *config.Preferences, credStore credentialsStorer, password string) (*Bundle, error) {
+	bundle := &Bundle{
+		Version:     bundleVersion,
+		Preferences: map[string]string{},
+	}
+
+	for key, value := range pref.GetAll() {
+		if !machineSpecificKeys[key] {
+			bundle.Preferences[key] = value
+		}
+	}
+
+	// Without keychain only preferences can be exported.
+	userIDs, err := credStore.List()
+	if err != nil {
+		if password != "" {
+			return nil, err
+		}
+		log.WithError(err).Warn("Cannot list accounts, exporting only preferences")
+		return bundle, nil
+	}
+
+	secrets := []exportedCredentials{}
+	for _, userID := range userIDs {
+		creds, err := credStore.Get(userID)
+		if err != nil {
+			log.WithError(err).WithField("user", userID).Warn("Skipping account which cannot be loaded")
+			continue
+		}
+		bundle.Accounts = append(bundle.Accounts, creds.Name)
+		secrets = append(secrets, exportedCredentials{UserID: creds.UserID, Secret: creds.Marshal()})
+	}
+
+	if password == "" {
+		return bundle, nil
+	}
+
+	plaintext, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	if bundle.Credentials, err = helper.EncryptMessageWithPassword([]byte(password), string(plaintext)); err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// Import applies preferences from bundle and, when password is given,
+// stores credentials. Accounts are loaded on the next start. Everything is
+// validated first and credentials are stored before preferences, so failed
+// import leaves the current settings as they were.
+func Import(bundle *Bundle, pref *config.Preferences, credStore credentialsStorer, password string) error {
+	if bundle.Version != bundleVersion {
+		return ErrUnknownVersion
+	}
+
+	var creds []*credentials.Credentials
+	if bundle.Credentials != "" && password != "" {
+		var err error
+		if creds, err = decryptCredentials(bundle.Credentials, password); err != nil {
+			return err
+		}
+	} else if password != "" {
+		log.Warn("Password given but bundle does not contain credentials")
+	}
+
+	if err := importCredentials(credStore, creds); err != nil {
+		return err
+	}
+
+	for key, value := range bundle.Preferences {
+		if !machineSpecificKeys[key] {
+			pref.Set(key, value)
+		}
+	}
+
+	return nil
+}
+
+// importCredentials stores all credentials or, when any of them cannot be
+// stored, restores the ones which were already replaced.
+func importCredentials(credStore credentialsStorer, creds []*credentials.Credentials) error {
+	previous := map[string]*credentials.Credentials{}
+	imported := []string{}
+
+	for _, c := range creds {
+		if old, err := credStore.Get(c.UserID); err == nil {
+			previous[c.UserID] = old
+		}
+
+		if err := credStore.Import(c); err != nil {
+			restoreCredentials(credStore, imported, previous)
+			return err
+		}
+
+		imported = append(imported, c.UserID)
+	}
+
+	return nil
+}
+
+func restoreCredentials(credStore credentialsStorer, userIDs []string, previous map[string]*credentials.Credentials) {
+	for _, userID := range userIDs {
+		var err error
+		if old, ok := previous[userID]; ok {
+			err = credStore.Import(old)
+		} else {
+			err = credStore.Delete(userID)
+		}
+		if err != nil {
+			log.WithError(err).WithField("user", userID).Error("Cannot restore credentials after failed import")
+		}
+	}
+}
+
+func decryptCredentials(armored, password string) ([]*credentials.Credentials, error) {
+	plaintext, err := helper.DecryptMessageWithPassword([]byte(password), armored)
+	if err != nil {
+		return nil, err
+	}
+
+	var secrets []exportedCredentials
+	if err := json.Unmarshal([]byte(plaintext), &secrets); err != nil {
+		return nil, err
+	}
+
+	creds := make([]*credentials.Credentials, 0, len(secrets))
+	for _, secret := range secrets {
+		c := &credentials.Credentials{UserID: secret.UserID}
+		if err := c.Unmarshal(secret.Secret); err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+
+	return creds, nil
+}
+
+// Write saves bundle as JSON file.
+func Write(bundle *Bundle, path string) error {
+	b, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+// Read loads bundle from JSON file.
+func Read(path string) (*Bundle, error) {
+	b, err := ioutil.ReadFile(path) //nolint[gosec]
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &Bundle{}
+	if err := json.Unmarshal(b, bundle); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
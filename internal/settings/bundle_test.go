@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package settings
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
+	"github.com/ProtonMail/proton-bridge/pkg/config"
+	r "github.com/stretchr/testify/require"
+)
+
+type fakeCredStore struct {
+	creds     map[string]*credentials.Credentials
+	listErr   error
+	importErr map[string]error
+}
+
+func (s *fakeCredStore) List() (userIDs []string, err error) {
+	for userID := range s.creds {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, s.listErr
+}
+
+func (s *fakeCredStore) Get(userID string) (*credentials.Credentials, error) {
+	creds, ok := s.creds[userID]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return creds, nil
+}
+
+func (s *fakeCredStore) Import(creds *credentials.Credentials) error {
+	if err := s.importErr[creds.UserID]; err != nil {
+		return err
+	}
+	s.creds[creds.UserID] = creds
+	return nil
+}
+
+func (s *fakeCredStore) Delete(userID string) error {
+	delete(s.creds, userID)
+	return nil
+}
+
+func newTestPreferences(t *testing.T) (*config.Preferences, func()) {
+	dir, err := ioutil.TempDir("", "settings")
+	r.NoError(t, err)
+	return config.NewPreferences(filepath.Join(dir, "prefs.json")), func() { _ = os.RemoveAll(dir) }
+}
+
+func TestExportImport(t *testing.T) {
+	srcPref, cleanSrc := newTestPreferences(t)
+	defer cleanSrc()
+	srcPref.SetInt(preferences.IMAPPortKey, 1234)
+	srcPref.Set(preferences.RolloutBucketKey, "42")
+
+	srcStore := &fakeCredStore{creds: map[string]*credentials.Credentials{
+		"user": {
+			UserID:          "user",
+			Name:            "username",
+			Emails:          "user@pm.me",
+			APIToken:        "token",
+			MailboxPassword: "mailbox",
+			BridgePassword:  "bridge",
+			Version:         "v1",
+		},
+	}}
+
+	bundle, err := Export(srcPref, srcStore, "secret")
+	r.NoError(t, err)
+	r.Equal(t, []string{"username"}, bundle.Accounts)
+	r.Equal(t, "1234", bundle.Preferences[preferences.IMAPPortKey])
+	r.NotContains(t, bundle.Preferences, preferences.RolloutBucketKey)
+	r.NotEmpty(t, bundle.Credentials)
+	r.NotContains(t, bundle.Credentials, "mailbox")
+
+	path := filepath.Join(os.TempDir(), "bundle-test.json")
+	r.NoError(t, Write(bundle, path))
+	defer os.Remove(path) //nolint[errcheck]
+
+	bundle, err = Read(path)
+	r.NoError(t, err)
+
+	dstPref, cleanDst := newTestPreferences(t)
+	defer cleanDst()
+	dstStore := &fakeCredStore{creds: map[string]*credentials.Credentials{}}
+
+	r.Error(t, Import(bundle, dstPref, dstStore, "wrong"))
+	r.Empty(t, dstStore.creds)
+	r.Equal(t, 0, dstPref.GetInt(preferences.IMAPPortKey))
+
+	r.NoError(t, Import(bundle, dstPref, dstStore, "secret"))
+	r.Equal(t, 1234, dstPref.GetInt(preferences.IMAPPortKey))
+	r.Equal(t, srcStore.creds["user"], dstStore.creds["user"])
+}
+
+func TestImportFailedCredentialsKeepsSettings(t *testing.T) {
+	srcPref, cleanSrc := newTestPreferences(t)
+	defer cleanSrc()
+	srcPref.SetInt(preferences.IMAPPortKey, 1234)
+
+	srcStore := &fakeCredStore{creds: map[string]*credentials.Credentials{
+		"user1": {UserID: "user1", Name: "new1", Version: "v1"},
+		"user2": {UserID: "user2", Name: "new2", Version: "v1"},
+		"user3": {UserID: "user3", Name: "new3", Version: "v1"},
+	}}
+
+	bundle, err := Export(srcPref, srcStore, "secret")
+	r.NoError(t, err)
+
+	dstPref, cleanDst := newTestPreferences(t)
+	defer cleanDst()
+	dstPref.SetInt(preferences.IMAPPortKey, 1143)
+
+	oldCreds := &credentials.Credentials{UserID: "user1", Name: "old1", Version: "v1"}
+	dstStore := &fakeCredStore{
+		creds: map[string]*credentials.Credentials{"user1": oldCreds},
+		// One of three fails; which ones were imported before depends on map order.
+		importErr: map[string]error{"user2": errors.New("keychain failed")},
+	}
+
+	r.Error(t, Import(bundle, dstPref, dstStore, "secret"))
+	r.Equal(t, 1143, dstPref.GetInt(preferences.IMAPPortKey))
+	r.Equal(t, map[string]*credentials.Credentials{"user1": oldCreds}, dstStore.creds)
+}
+
+func TestExportWithoutCredentials(t *testing.T) {
+	pref, clean := newTestPreferences(t)
+	defer clean()
+
+	store := &fakeCredStore{listErr: errors.New("no keychain")}
+
+	bundle, err := Export(pref, store, "")
+	r.NoError(t, err)
+	r.Empty(t, bundle.Credentials)
+
+	_, err = Export(pref, store, "secret")
+	r.Error(t, err)
+}
+
+func TestImportUnknownVersion(t *testing.T) {
+	pref, clean := newTestPreferences(t)
+	defer clean()
+
+	r.Equal(t, ErrUnknownVersion, Import(&Bundle{Version: 42}, pref, nil, ""))
+}
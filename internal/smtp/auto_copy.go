@@ -0,0 +1,45 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"net/mail"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/internal/store"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+)
+
+// addAutoCopy adds the auto-copy address to the envelope recipients and,
+// in CC mode, to visible recipients of the message. BCC is filled in later
+// for every envelope recipient not listed in headers.
+func addAutoCopy(m *pmapi.Message, to []string, address, mode string) []string {
+	if address == "" {
+		return to
+	}
+	for _, recipient := range to {
+		if strings.EqualFold(recipient, address) {
+			return to
+		}
+	}
+
+	if mode == store.AutoCopyCC {
+		m.CCList = append(m.CCList, &mail.Address{Address: address})
+	}
+	return append(to, address)
+}
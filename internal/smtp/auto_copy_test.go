@@ -0,0 +1,43 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/internal/store"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddAutoCopy(t *testing.T) {
+	m := &pmapi.Message{ToList: []*mail.Address{{Address: "bob@pm.me"}}}
+
+	require.Equal(t, []string{"bob@pm.me"}, addAutoCopy(m, []string{"bob@pm.me"}, "", store.AutoCopyBCC))
+
+	// Already a recipient.
+	require.Equal(t, []string{"crm@pm.me"}, addAutoCopy(m, []string{"crm@pm.me"}, "CRM@pm.me", store.AutoCopyCC))
+	require.Empty(t, m.CCList)
+
+	require.Equal(t, []string{"bob@pm.me", "crm@pm.me"}, addAutoCopy(m, []string{"bob@pm.me"}, "crm@pm.me", store.AutoCopyBCC))
+	require.Empty(t, m.CCList)
+
+	require.Equal(t, []string{"bob@pm.me", "crm@pm.me"}, addAutoCopy(m, []string{"bob@pm.me"}, "crm@pm.me", store.AutoCopyCC))
+	require.Equal(t, []*mail.Address{{Address: "crm@pm.me"}}, m.CCList)
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/ProtonMail/proton-bridge/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/internal/preferences"
+	"github.com/ProtonMail/proton-bridge/internal/users"
 	"github.com/ProtonMail/proton-bridge/pkg/config"
 	"github.com/ProtonMail/proton-bridge/pkg/confirmer"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
@@ -41,6 +42,8 @@ type smtpBackend struct {
 	bridge        bridger
 	confirmer     *confirmer.Confirmer
 	sendRecorder  *sendRecorder
+	outbox        *outbox
+	domainChecker *domainChecker
 }
 
 // NewSMTPBackend returns struct implementing go-smtp/backend interface.
@@ -66,11 +69,42 @@ func newSMTPBackend(
 		bridge:        bridge,
 		confirmer:     confirmer.New(),
 		sendRecorder:  newSendRecorder(),
+		outbox:        newOutbox(preferences),
+		domainChecker: newDomainChecker(),
 	}
 }
 
 // Login authenticates a user.
 func (sb *smtpBackend) Login(username, password string) (goSMTPBackend.User, error) {
+	return sb.login("", username, password, false)
+}
+
+// ClientLogin authenticates a user. The client identifies the connection
+// so failed logins of one client do not lock out the others.
+func (sb *smtpBackend) ClientLogin(client, username, password string) (goSMTPBackend.User, error) {
+	return sb.login(client, username, password, false)
+}
+
+// LocalSocketBackend returns backend for connections through local socket.
+// Those are authenticated by peer credentials already, so it does not check
+// the bridge password.
+func (sb *smtpBackend) LocalSocketBackend() goSMTPBackend.Backend {
+	return &localSocketBackend{smtpBackend: sb}
+}
+
+type localSocketBackend struct {
+	*smtpBackend
+}
+
+func (lb *localSocketBackend) Login(username, password string) (goSMTPBackend.User, error) {
+	return lb.login("", username, password, true)
+}
+
+func (lb *localSocketBackend) ClientLogin(client, username, password string) (goSMTPBackend.User, error) {
+	return lb.login(client, username, password, true)
+}
+
+func (sb *smtpBackend) login(client, username, password string, trusted bool) (goSMTPBackend.User, error) {
 	// Called from go-smtp in goroutines - we need to handle panics for each function.
 	defer sb.panicHandler.HandlePanic()
 	username = strings.ToLower(username)
@@ -78,15 +112,26 @@ func (sb *smtpBackend) Login(username, password string) (goSMTPBackend.User, err
 	user, err := sb.bridge.GetUser(username)
 	if err != nil {
 		log.Warn("Cannot get user: ", err)
+		if !trusted {
+			if lockoutErr := sb.bridge.CheckUnknownUserLogin(client); lockoutErr != nil {
+				return nil, lockoutErr
+			}
+		}
 		return nil, err
 	}
-	if err := user.CheckBridgeLogin(password); err != nil {
+	if trusted {
+		log.WithField("username", username).Debug("Trusting local socket connection")
+	} else if err := user.CheckBridgeLogin(client, password); err != nil {
 		log.WithError(err).Error("Could not check bridge password")
 		// Apple Mail sometimes generates a lot of requests very quickly. It's good practice
 		// to have a timeout after bad logins so that we can slow those requests down a little bit.
 		time.Sleep(10 * time.Second)
 		return nil, err
 	}
+	if !user.IsSMTPEnabled() {
+		log.WithField("username", username).Warn("SMTP is disabled for the account")
+		return nil, users.ErrSMTPDisabled
+	}
 	// Client can log in only using address so we can properly close all SMTP connections.
 	addressID, err := user.GetAddressID(username)
 	if err != nil {
@@ -97,7 +142,7 @@ func (sb *smtpBackend) Login(username, password string) (goSMTPBackend.User, err
 	if user.IsCombinedAddressMode() {
 		addressID = ""
 	}
-	return newSMTPUser(sb.panicHandler, sb.eventListener, sb, user, addressID)
+	return newSMTPUser(sb.panicHandler, sb.eventListener, sb, user, username, addressID)
 }
 
 func (sb *smtpBackend) shouldReportOutgoingNoEnc() bool {
@@ -109,3 +154,77 @@ func (sb *smtpBackend) ConfirmNoEncryption(messageID string, shouldSend bool) {
 		logrus.WithError(err).Error("Failed to set confirmation value")
 	}
 }
+
+// getUndoSendDelay returns how long accepted messages wait before sending.
+func (sb *smtpBackend) getUndoSendDelay() time.Duration {
+	delay := time.Duration(sb.preferences.GetInt(preferences.UndoSendDelayKey)) * time.Second
+	switch {
+	case delay < 0:
+		return 0
+	case delay > maxUndoSendDelay:
+		return maxUndoSendDelay
+	}
+	return delay
+}
+
+// ListPendingSends returns messages waiting for the undo-send delay to pass.
+func (sb *smtpBackend) ListPendingSends() []PendingSend {
+	return sb.outbox.list()
+}
+
+// UndoSend cancels sending of the message. The message stays in drafts.
+func (sb *smtpBackend) UndoSend(messageID string) error {
+	ps, err := sb.outbox.cancel(messageID)
+	if err != nil {
+		return err
+	}
+
+	log.WithField("messageID", messageID).Info("Sending was undone, message stays in drafts")
+
+	// Allow the client to send the same message again right away.
+	sb.sendRecorder.removeMessage(ps.recorderHash)
+
+	return nil
+}
+
+// SendPendingNow sends messages waiting for the undo-send delay immediately.
+// It has to be called on shutdown and from the panic handler as pending
+// sends are not persisted.
+func (sb *smtpBackend) SendPendingNow() {
+	if sends := sb.outbox.list(); len(sends) > 0 {
+		log.WithField("count", len(sends)).Info("Sending messages waiting for undo-send delay before quitting")
+	}
+	sb.outbox.flush()
+}
+
+// ListFailedSends returns messages which could not be sent.
+func (sb *smtpBackend) ListFailedSends() []FailedSend {
+	return sb.outbox.listFailed()
+}
+
+// RetrySend sends the failed message again.
+func (sb *smtpBackend) RetrySend(messageID string) error {
+	return sb.outbox.retry(messageID)
+}
+
+// DiscardFailedSend forgets the failure. The message stays in drafts.
+func (sb *smtpBackend) DiscardFailedSend(messageID string) error {
+	return sb.outbox.discard(messageID)
+}
+
+// ListOutbox returns all queued, being sent and failed messages.
+func (sb *smtpBackend) ListOutbox() []OutboxEntry {
+	return sb.outbox.entries()
+}
+
+// DiscardFromOutbox cancels the queued message or forgets the failed one.
+// In both cases the message stays in drafts.
+func (sb *smtpBackend) DiscardFromOutbox(messageID string) error {
+	if sb.outbox.isSending(messageID) {
+		return ErrSendInProgress
+	}
+	if err := sb.UndoSend(messageID); err != ErrNoPendingSend {
+		return err
+	}
+	return sb.DiscardFailedSend(messageID)
+}
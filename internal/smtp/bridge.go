@@ -25,10 +25,12 @@ import (
 
 type bridger interface {
 	GetUser(query string) (bridgeUser, error)
+	CheckUnknownUserLogin(client string) error
 }
 
 type bridgeUser interface {
-	CheckBridgeLogin(password string) error
+	CheckBridgeLogin(client, password string) error
+	IsSMTPEnabled() bool
 	IsCombinedAddressMode() bool
 	GetAddressID(address string) (string, error)
 	GetTemporaryPMAPIClient() pmapi.Client
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"net"
+	"strings"
+	"sync"
+)
+
+const (
+	protonSPFInclude   = "include:_spf.protonmail.ch"
+	protonDKIMSelector = "protonmail._domainkey."
+	dmarcPrefix        = "_dmarc."
+)
+
+// domainChecker checks DNS records of custom domains before the first
+// message is sent from them. Misconfigured SPF, DKIM or DMARC does not make
+// sending fail, messages only silently end up in spam of recipients.
+type domainChecker struct {
+	lock      sync.Mutex
+	checked   map[string]bool
+	lookupTXT func(name string) ([]string, error)
+}
+
+func newDomainChecker() *domainChecker {
+	return &domainChecker{
+		checked:   map[string]bool{},
+		lookupTXT: net.LookupTXT,
+	}
+}
+
+// checkOnce returns problems found in DNS records of the domain. Every
+// domain is checked only once unless the DNS lookup itself fails.
+func (c *domainChecker) checkOnce(domain string) (problems []string, err error) {
+	domain = strings.ToLower(domain)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.checked[domain] {
+		return nil, nil
+	}
+	if problems, err = c.check(domain); err != nil {
+		return nil, err
+	}
+	c.checked[domain] = true
+	return problems, nil
+}
+
+func (c *domainChecker) check(domain string) ([]string, error) {
+	problems := []string{}
+
+	spf, err := c.lookupRecord(domain, "v=spf1")
+	if err != nil {
+		return nil, err
+	}
+	if spf == "" {
+		problems = append(problems, "SPF record is missing")
+	} else if !strings.Contains(strings.ToLower(spf), protonSPFInclude) {
+		problems = append(problems, "SPF record does not contain "+protonSPFInclude)
+	}
+
+	dkim, err := c.lookupRecord(protonDKIMSelector+domain, "v=DKIM1")
+	if err != nil {
+		return nil, err
+	}
+	if dkim == "" {
+		problems = append(problems, "DKIM record "+protonDKIMSelector+domain+" is missing")
+	}
+
+	dmarc, err := c.lookupRecord(dmarcPrefix+domain, "v=DMARC1")
+	if err != nil {
+		return nil, err
+	}
+	if dmarc == "" {
+		problems = append(problems, "DMARC record is missing")
+	}
+
+	return problems, nil
+}
+
+// lookupRecord returns the first TXT record of name starting with prefix,
+// or empty string if there is none. Non-existing name is not an error.
+func (c *domainChecker) lookupRecord(name, prefix string) (string, error) {
+	records, err := c.lookupTXT(name)
+	if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	for _, record := range records {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(record)), strings.ToLower(prefix)) {
+			return record, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDomainChecker(records map[string][]string, lookups *int) *domainChecker {
+	c := newDomainChecker()
+	c.lookupTXT = func(name string) ([]string, error) {
+		*lookups++
+		if name == "offline.com" {
+			return nil, errors.New("no network")
+		}
+		if txt, ok := records[name]; ok {
+			return txt, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return c
+}
+
+func TestDomainCheckerValidDomain(t *testing.T) {
+	lookups := 0
+	c := newTestDomainChecker(map[string][]string{
+		"example.com":                       {"google-site-verification=xyz", "v=spf1 include:_spf.protonmail.ch mx ~all"},
+		"protonmail._domainkey.example.com": {"v=DKIM1; k=rsa; p=MIGf"},
+		"_dmarc.example.com":                {"v=DMARC1; p=none"},
+	}, &lookups)
+
+	problems, err := c.checkOnce("Example.com")
+	require.NoError(t, err)
+	require.Empty(t, problems)
+	require.Equal(t, 3, lookups)
+
+	// Second send from the same domain is not checked again.
+	problems, err = c.checkOnce("example.com")
+	require.NoError(t, err)
+	require.Empty(t, problems)
+	require.Equal(t, 3, lookups)
+}
+
+func TestDomainCheckerMisconfiguredDomain(t *testing.T) {
+	lookups := 0
+	c := newTestDomainChecker(map[string][]string{
+		"example.com": {"v=spf1 include:_spf.google.com ~all"},
+	}, &lookups)
+
+	problems, err := c.checkOnce("example.com")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"SPF record does not contain include:_spf.protonmail.ch",
+		"DKIM record protonmail._domainkey.example.com is missing",
+		"DMARC record is missing",
+	}, problems)
+
+	problems, err = c.checkOnce("other.com")
+	require.NoError(t, err)
+	require.Equal(t, "SPF record is missing", problems[0])
+}
+
+func TestDomainCheckerLookupFailure(t *testing.T) {
+	lookups := 0
+	c := newTestDomainChecker(nil, &lookups)
+
+	_, err := c.checkOnce("offline.com")
+	require.Error(t, err)
+
+	// Failed check is repeated with the next message.
+	_, err = c.checkOnce("offline.com")
+	require.Error(t, err)
+	require.Equal(t, 2, lookups)
+}
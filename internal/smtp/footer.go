@@ -0,0 +1,157 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"bytes"
+	"html"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/jaytaylor/html2text"
+)
+
+// footer is appended to every outgoing message, e.g. for compliance
+// disclaimers which clients cannot be relied on to add.
+type footer struct {
+	plain, html string
+}
+
+// newFooter returns footer with both variants. The missing variant is
+// derived from the other one.
+func newFooter(plain, htmlFooter string) footer {
+	if plain == "" && htmlFooter != "" {
+		var err error
+		if plain, err = html2text.FromString(htmlFooter); err != nil {
+			log.WithError(err).Warn("Cannot convert HTML footer to plain text")
+		}
+	}
+	if htmlFooter == "" && plain != "" {
+		htmlFooter = "<div>" + strings.Replace(html.EscapeString(plain), "\n", "<br>\n", -1) + "</div>"
+	}
+	return footer{plain: plain, html: htmlFooter}
+}
+
+func (f footer) isEmpty() bool {
+	return f.plain == "" && f.html == ""
+}
+
+// appendToBody appends the variant matching mimeType to the body. HTML
+// footer is placed at the end of the document body.
+func (f footer) appendToBody(body, mimeType string) string {
+	if mimeType != pmapi.ContentTypeHTML {
+		return f.appendToPlain(body)
+	}
+	if i := strings.LastIndex(strings.ToLower(body), "</body>"); i >= 0 {
+		return body[:i] + f.html + "\n" + body[i:]
+	}
+	return body + "\n" + f.html
+}
+
+func (f footer) appendToPlain(body string) string {
+	newLine := "\n"
+	if strings.Contains(body, "\r\n") {
+		newLine = "\r\n"
+	}
+	plain := strings.Replace(f.plain, "\n", newLine, -1)
+	return strings.TrimRight(body, "\r\n") + newLine + newLine + plain + newLine
+}
+
+// appendToMIME wraps the original MIME message into multipart/mixed with
+// the footer as the second inline part. The MIME body is sent as it is to
+// PGP/MIME recipients, therefore the text parts cannot be changed without
+// rebuilding the whole message.
+func (f footer) appendToMIME(mimeBody string) (string, error) {
+	rawHeader, body := splitMIMEHeader(mimeBody)
+
+	var header, contentHeader []string
+	isContentField, hasVersion := false, false
+	for _, line := range strings.SplitAfter(rawHeader, "\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			name := strings.ToLower(strings.TrimSpace(strings.SplitN(line, ":", 2)[0]))
+			isContentField = name == "content-type" || name == "content-transfer-encoding"
+			hasVersion = hasVersion || name == "mime-version"
+		}
+		if isContentField {
+			contentHeader = append(contentHeader, line)
+		} else {
+			header = append(header, line)
+		}
+	}
+	if len(contentHeader) == 0 {
+		contentHeader = []string{"Content-Type: text/plain; charset=us-ascii\r\n"}
+	}
+	if !hasVersion {
+		header = append(header, "MIME-Version: 1.0\r\n")
+	}
+
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+
+	// The first part keeps original content headers and body untouched.
+	if _, err := buf.WriteString("--" + mw.Boundary() + "\r\n" + strings.Join(contentHeader, "") + "\r\n" + body + "\r\n"); err != nil {
+		return "", err
+	}
+
+	footerPart, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+		"Content-Disposition":       {"inline"},
+	})
+	if err != nil {
+		return "", err
+	}
+	qp := quotedprintable.NewWriter(footerPart)
+	if _, err := qp.Write([]byte(strings.Replace(f.plain, "\n", "\r\n", -1))); err != nil {
+		return "", err
+	}
+	if err := qp.Close(); err != nil {
+		return "", err
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	return strings.Join(header, "") +
+		"Content-Type: multipart/mixed; boundary=\"" + mw.Boundary() + "\"\r\n" +
+		"\r\n" + buf.String(), nil
+}
+
+// splitMIMEHeader splits the message at the first empty line. The header
+// keeps its last line ending, the body is returned without the separator.
+func splitMIMEHeader(mimeBody string) (header, body string) {
+	for i := 0; i < len(mimeBody); i++ {
+		if mimeBody[i] != '\n' {
+			continue
+		}
+		rest := mimeBody[i+1:]
+		if strings.HasPrefix(rest, "\r\n") {
+			return mimeBody[:i+1], rest[2:]
+		}
+		if strings.HasPrefix(rest, "\n") {
+			return mimeBody[:i+1], rest[1:]
+		}
+	}
+	return mimeBody, ""
+}
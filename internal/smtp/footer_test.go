@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFooter(t *testing.T) {
+	require.True(t, newFooter("", "").isEmpty())
+
+	f := newFooter("Confidential\n<b>", "")
+	require.Equal(t, "<div>Confidential<br>\n&lt;b&gt;</div>", f.html)
+
+	f = newFooter("", "<p>Confidential</p>")
+	require.Equal(t, "Confidential", f.plain)
+}
+
+func TestFooterAppendToBody(t *testing.T) {
+	f := footer{plain: "Confidential\nLegal", html: "<p>Confidential</p>"}
+
+	require.Equal(t, "Hello\r\n\r\nConfidential\r\nLegal\r\n", f.appendToBody("Hello\r\n", pmapi.ContentTypePlainText))
+	require.Equal(t, "<html><BODY>Hi<p>Confidential</p>\n</BODY></html>", f.appendToBody("<html><BODY>Hi</BODY></html>", pmapi.ContentTypeHTML))
+	require.Equal(t, "Hi\n<p>Confidential</p>", f.appendToBody("Hi", pmapi.ContentTypeHTML))
+}
+
+func TestFooterAppendToMIME(t *testing.T) {
+	f := footer{plain: "Confidential"}
+
+	original := "From: Alice <alice@pm.me>\r\n" +
+		"Content-Type: text/plain;\r\n\tcharset=utf-8\r\n" +
+		"Subject: Hello\r\n" +
+		"\r\n" +
+		"Hello Bob\r\n"
+
+	mimeBody, err := f.appendToMIME(original)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(mimeBody))
+	require.NoError(t, err)
+	require.Equal(t, "Hello", msg.Header.Get("Subject"))
+	require.Equal(t, "1.0", msg.Header.Get("MIME-Version"))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	require.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+
+	part, err := mr.NextPart()
+	require.NoError(t, err)
+	require.Equal(t, "text/plain; charset=utf-8", part.Header.Get("Content-Type"))
+	body, err := ioutil.ReadAll(part)
+	require.NoError(t, err)
+	require.Equal(t, "Hello Bob\r\n", string(body))
+
+	part, err = mr.NextPart()
+	require.NoError(t, err)
+	body, err = ioutil.ReadAll(part)
+	require.NoError(t, err)
+	require.Equal(t, "Confidential", string(body))
+}
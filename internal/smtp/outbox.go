@@ -0,0 +1,375 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/internal/preferences"
+)
+
+// maxUndoSendDelay caps the delay so clients do not wait for a send forever.
+const maxUndoSendDelay = 2 * time.Minute
+
+var (
+	// ErrNoPendingSend is returned when the message is not waiting for sending
+	// anymore, i.e., it is already being sent or it was cancelled.
+	ErrNoPendingSend = errors.New("message is not waiting to be sent")
+
+	// ErrNoFailedSend is returned when there is no failure record of the message.
+	ErrNoFailedSend = errors.New("message has no failed send")
+
+	// ErrCannotRetrySend is returned for failures recorded before the bridge
+	// was restarted; the prepared request is lost and the message has to be
+	// sent again from the email client.
+	ErrCannotRetrySend = errors.New("message cannot be retried, send it again from your email client")
+
+	// ErrSendInProgress is returned when the message is just being sent.
+	ErrSendInProgress = errors.New("message is being sent")
+)
+
+// States of messages in the outbox.
+const (
+	OutboxQueued  = "queued"
+	OutboxSending = "sending"
+	OutboxFailed  = "failed"
+)
+
+// OutgoingMessage describes a message sent by an email client.
+type OutgoingMessage struct {
+	MessageID  string
+	From       string
+	Subject    string
+	Recipients []string
+}
+
+// PendingSend is a message accepted from an email client which is held back
+// for the undo-send delay before it is sent via the API.
+type PendingSend struct {
+	OutgoingMessage
+	SendAt time.Time
+}
+
+// FailedSend is a message which could not be sent via the API.
+// Its draft is kept so it can be sent again.
+type FailedSend struct {
+	OutgoingMessage
+	Error    string
+	FailedAt time.Time
+
+	// CanRetry is false when the failure comes from before the bridge restart.
+	CanRetry bool `json:"-"`
+}
+
+// OutboxEntry is a message in the outbox in any state.
+type OutboxEntry struct {
+	OutgoingMessage
+	State string
+
+	// Time is when the message will be sent, started sending or failed
+	// depending on the state.
+	Time  time.Time
+	Error string
+}
+
+type pendingSend struct {
+	PendingSend
+	recorderHash string
+	cancel       chan struct{}
+	send         func()
+}
+
+type failedSend struct {
+	FailedSend
+	retry func() error
+}
+
+type getterSetter interface {
+	Get(string) string
+	Set(string, string)
+}
+
+// outbox keeps messages which were accepted but not sent yet, messages
+// being sent and records of messages which failed to be sent.
+type outbox struct {
+	lock    sync.Mutex
+	pending map[string]*pendingSend
+	sending map[string]OutboxEntry
+	failed  map[string]*failedSend
+	prefs   getterSetter
+}
+
+func newOutbox(prefs getterSetter) *outbox {
+	o := &outbox{
+		pending: map[string]*pendingSend{},
+		sending: map[string]OutboxEntry{},
+		failed:  map[string]*failedSend{},
+		prefs:   prefs,
+	}
+	o.loadFailed()
+	return o
+}
+
+// schedule calls send after the delay unless the message is cancelled.
+func (o *outbox) schedule(ps *pendingSend, delay time.Duration, panicHandler panicHandler, send func()) {
+	ps.SendAt = time.Now().Add(delay)
+	ps.cancel = make(chan struct{})
+	ps.send = send
+
+	o.lock.Lock()
+	o.pending[ps.MessageID] = ps
+	o.lock.Unlock()
+
+	go func() {
+		defer panicHandler.HandlePanic()
+
+		select {
+		case <-ps.cancel:
+			return
+		case <-time.After(delay):
+		}
+
+		// Cancel could come just now; the one removing it from outbox wins.
+		if !o.remove(ps.MessageID) {
+			return
+		}
+
+		send()
+	}()
+}
+
+// cancel stops the pending send and returns it.
+func (o *outbox) cancel(messageID string) (*pendingSend, error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	ps, ok := o.pending[messageID]
+	if !ok {
+		return nil, ErrNoPendingSend
+	}
+	delete(o.pending, messageID)
+	close(ps.cancel)
+
+	return ps, nil
+}
+
+// flush sends all pending messages right away without waiting for the delay.
+// Timers live only in memory, so this has to be called before the bridge quits
+// otherwise accepted messages would never be sent.
+func (o *outbox) flush() {
+	o.lock.Lock()
+	pending := make([]*pendingSend, 0, len(o.pending))
+	for messageID, ps := range o.pending {
+		pending = append(pending, ps)
+		delete(o.pending, messageID)
+		close(ps.cancel)
+	}
+	o.lock.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].SendAt.Before(pending[j].SendAt) })
+
+	for _, ps := range pending {
+		ps.send()
+	}
+}
+
+func (o *outbox) remove(messageID string) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if _, ok := o.pending[messageID]; !ok {
+		return false
+	}
+	delete(o.pending, messageID)
+	return true
+}
+
+// list returns pending sends ordered by the time of sending.
+func (o *outbox) list() []PendingSend {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	sends := make([]PendingSend, 0, len(o.pending))
+	for _, ps := range o.pending {
+		sends = append(sends, ps.PendingSend)
+	}
+	sort.Slice(sends, func(i, j int) bool { return sends[i].SendAt.Before(sends[j].SendAt) })
+
+	return sends
+}
+
+// send marks the message as being sent while the send function runs.
+func (o *outbox) send(msg OutgoingMessage, send func() error) error {
+	o.lock.Lock()
+	o.sending[msg.MessageID] = OutboxEntry{OutgoingMessage: msg, State: OutboxSending, Time: time.Now()}
+	o.lock.Unlock()
+
+	defer func() {
+		o.lock.Lock()
+		delete(o.sending, msg.MessageID)
+		o.lock.Unlock()
+	}()
+
+	return send()
+}
+
+// entries returns all messages in the outbox: queued first, then being sent
+// and failed last, each group ordered by time.
+func (o *outbox) entries() []OutboxEntry {
+	entries := []OutboxEntry{}
+
+	for _, ps := range o.list() {
+		entries = append(entries, OutboxEntry{OutgoingMessage: ps.OutgoingMessage, State: OutboxQueued, Time: ps.SendAt})
+	}
+
+	o.lock.Lock()
+	sending := make([]OutboxEntry, 0, len(o.sending))
+	for _, entry := range o.sending {
+		sending = append(sending, entry)
+	}
+	o.lock.Unlock()
+	sort.Slice(sending, func(i, j int) bool { return sending[i].Time.Before(sending[j].Time) })
+	entries = append(entries, sending...)
+
+	for _, fs := range o.listFailed() {
+		entries = append(entries, OutboxEntry{OutgoingMessage: fs.OutgoingMessage, State: OutboxFailed, Time: fs.FailedAt, Error: fs.Error})
+	}
+
+	return entries
+}
+
+func (o *outbox) isSending(messageID string) bool {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	_, ok := o.sending[messageID]
+	return ok
+}
+
+// addFailed records the failure. The retry function is kept in memory only.
+func (o *outbox) addFailed(msg OutgoingMessage, sendErr error, retry func() error) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.failed[msg.MessageID] = &failedSend{
+		FailedSend: FailedSend{
+			OutgoingMessage: msg,
+			Error:           sendErr.Error(),
+			FailedAt:        time.Now(),
+			CanRetry:        retry != nil,
+		},
+		retry: retry,
+	}
+	o.saveFailed()
+}
+
+// listFailed returns failed sends ordered by the time of failure.
+func (o *outbox) listFailed() []FailedSend {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	sends := make([]FailedSend, 0, len(o.failed))
+	for _, fs := range o.failed {
+		sends = append(sends, fs.FailedSend)
+	}
+	sort.Slice(sends, func(i, j int) bool { return sends[i].FailedAt.Before(sends[j].FailedAt) })
+
+	return sends
+}
+
+// retry sends the failed message again. The record is kept if it fails again.
+func (o *outbox) retry(messageID string) error {
+	o.lock.Lock()
+	fs, ok := o.failed[messageID]
+	if ok && fs.retry != nil {
+		// Removed while retrying so two retries cannot run at the same time.
+		delete(o.failed, messageID)
+	}
+	o.lock.Unlock()
+
+	if !ok {
+		return ErrNoFailedSend
+	}
+	if fs.retry == nil {
+		return ErrCannotRetrySend
+	}
+
+	err := o.send(fs.OutgoingMessage, fs.retry)
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if err != nil {
+		fs.Error = err.Error()
+		fs.FailedAt = time.Now()
+		o.failed[messageID] = fs
+	}
+	o.saveFailed()
+
+	return err
+}
+
+// discard forgets the failure record. The draft itself is not touched.
+func (o *outbox) discard(messageID string) error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if _, ok := o.failed[messageID]; !ok {
+		return ErrNoFailedSend
+	}
+	delete(o.failed, messageID)
+	o.saveFailed()
+
+	return nil
+}
+
+func (o *outbox) loadFailed() {
+	b := o.prefs.Get(preferences.FailedSendsKey)
+	if b == "" {
+		return
+	}
+
+	var sends []FailedSend
+	if err := json.Unmarshal([]byte(b), &sends); err != nil {
+		log.WithError(err).Warn("Cannot load failed sends")
+		return
+	}
+
+	for _, send := range sends {
+		o.failed[send.MessageID] = &failedSend{FailedSend: send}
+	}
+}
+
+func (o *outbox) saveFailed() {
+	sends := make([]FailedSend, 0, len(o.failed))
+	for _, fs := range o.failed {
+		sends = append(sends, fs.FailedSend)
+	}
+
+	b, err := json.Marshal(sends)
+	if err != nil {
+		log.WithError(err).Warn("Cannot save failed sends")
+		return
+	}
+
+	o.prefs.Set(preferences.FailedSendsKey, string(b))
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPanicHandler struct{}
+
+func (testPanicHandler) HandlePanic() {}
+
+type testPrefs map[string]string
+
+func (p testPrefs) Get(key string) string { return p[key] }
+
+func (p testPrefs) Set(key, value string) { p[key] = value }
+
+func TestOutbox_SendAfterDelay(t *testing.T) {
+	o := newOutbox(testPrefs{})
+	sent := make(chan struct{})
+
+	o.schedule(&pendingSend{PendingSend: PendingSend{OutgoingMessage: OutgoingMessage{MessageID: "msg"}}}, 10*time.Millisecond, testPanicHandler{}, func() { close(sent) })
+	assert.Len(t, o.list(), 1)
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		assert.Fail(t, "message was not sent")
+	}
+	assert.Len(t, o.list(), 0)
+
+	_, err := o.cancel("msg")
+	assert.Equal(t, ErrNoPendingSend, err)
+}
+
+func TestOutbox_Cancel(t *testing.T) {
+	o := newOutbox(testPrefs{})
+	sent := make(chan struct{})
+
+	o.schedule(&pendingSend{PendingSend: PendingSend{OutgoingMessage: OutgoingMessage{MessageID: "msg"}}, recorderHash: "hash"}, 50*time.Millisecond, testPanicHandler{}, func() { close(sent) })
+
+	ps, err := o.cancel("msg")
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", ps.recorderHash)
+	assert.Len(t, o.list(), 0)
+
+	select {
+	case <-sent:
+		assert.Fail(t, "cancelled message was sent")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestOutbox_Flush(t *testing.T) {
+	o := newOutbox(testPrefs{})
+	sent := []string{}
+
+	o.schedule(&pendingSend{PendingSend: PendingSend{OutgoingMessage: OutgoingMessage{MessageID: "later"}}}, time.Minute, testPanicHandler{}, func() { sent = append(sent, "later") })
+	o.schedule(&pendingSend{PendingSend: PendingSend{OutgoingMessage: OutgoingMessage{MessageID: "sooner"}}}, time.Second, testPanicHandler{}, func() { sent = append(sent, "sooner") })
+
+	o.flush()
+	assert.Equal(t, []string{"sooner", "later"}, sent)
+	assert.Len(t, o.list(), 0)
+
+	_, err := o.cancel("sooner")
+	assert.Equal(t, ErrNoPendingSend, err)
+}
+
+func TestOutbox_ListOrderedBySendTime(t *testing.T) {
+	o := newOutbox(testPrefs{})
+	o.schedule(&pendingSend{PendingSend: PendingSend{OutgoingMessage: OutgoingMessage{MessageID: "later"}}}, time.Minute, testPanicHandler{}, func() {})
+	o.schedule(&pendingSend{PendingSend: PendingSend{OutgoingMessage: OutgoingMessage{MessageID: "sooner"}}}, time.Second, testPanicHandler{}, func() {})
+
+	sends := o.list()
+	assert.Equal(t, "sooner", sends[0].MessageID)
+	assert.Equal(t, "later", sends[1].MessageID)
+
+	_, _ = o.cancel("later")
+	_, _ = o.cancel("sooner")
+}
+
+func TestOutbox_RetryFailedSend(t *testing.T) {
+	o := newOutbox(testPrefs{})
+	sendErr := errors.New("server error")
+	attempts := 0
+
+	o.addFailed(OutgoingMessage{MessageID: "msg"}, sendErr, func() error {
+		attempts++
+		if attempts == 1 {
+			return sendErr
+		}
+		return nil
+	})
+
+	failed := o.listFailed()
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "server error", failed[0].Error)
+	assert.True(t, failed[0].CanRetry)
+
+	assert.Equal(t, sendErr, o.retry("msg"))
+	assert.Len(t, o.listFailed(), 1)
+
+	assert.NoError(t, o.retry("msg"))
+	assert.Len(t, o.listFailed(), 0)
+	assert.Equal(t, ErrNoFailedSend, o.retry("msg"))
+}
+
+func TestOutbox_FailedSendsSurviveRestart(t *testing.T) {
+	prefs := testPrefs{}
+	o := newOutbox(prefs)
+	o.addFailed(OutgoingMessage{MessageID: "msg", Subject: "Hello"}, errors.New("server error"), func() error { return nil })
+
+	o = newOutbox(prefs)
+	failed := o.listFailed()
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "Hello", failed[0].Subject)
+	assert.False(t, failed[0].CanRetry)
+	assert.Equal(t, ErrCannotRetrySend, o.retry("msg"))
+
+	assert.NoError(t, o.discard("msg"))
+	assert.Len(t, newOutbox(prefs).listFailed(), 0)
+}
+
+func TestOutbox_Entries(t *testing.T) {
+	o := newOutbox(testPrefs{})
+	o.schedule(&pendingSend{PendingSend: PendingSend{OutgoingMessage: OutgoingMessage{MessageID: "queued"}}}, time.Minute, testPanicHandler{}, func() {})
+	o.addFailed(OutgoingMessage{MessageID: "failed"}, errors.New("server error"), nil)
+
+	sending := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		_ = o.send(OutgoingMessage{MessageID: "sending"}, func() error {
+			close(sending)
+			<-done
+			return nil
+		})
+	}()
+	<-sending
+
+	entries := o.entries()
+	assert.Len(t, entries, 3)
+	assert.Equal(t, OutboxQueued, entries[0].State)
+	assert.Equal(t, OutboxSending, entries[1].State)
+	assert.Equal(t, OutboxFailed, entries[2].State)
+	assert.Equal(t, "server error", entries[2].Error)
+	assert.True(t, o.isSending("sending"))
+
+	close(done)
+	_, _ = o.cancel("queued")
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package smtp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/ProtonMail/proton-bridge/internal/events"
+	"github.com/ProtonMail/proton-bridge/pkg/listener"
+)
+
+// largeMessageSize is the size of attachments from which the progress
+// of sending is reported. Smaller messages are sent before anyone notices.
+const largeMessageSize = 1 << 20
+
+// sendProgress emits progress of uploading attachments of one message.
+type sendProgress struct {
+	eventListener listener.Listener
+	subject       string
+
+	lock     sync.Mutex
+	sizes    []int
+	total    int
+	uploaded int
+	started  int
+}
+
+// newSendProgress reads the attachments and returns readers which report
+// the upload progress. The store uploads attachments one by one and reads
+// each of them just before its upload, so once the next attachment is read,
+// the previous one was uploaded.
+func newSendProgress(eventListener listener.Listener, subject string, attReaders []io.Reader) (*sendProgress, []io.Reader, error) {
+	p := &sendProgress{
+		eventListener: eventListener,
+		subject:       subject,
+	}
+
+	readers := make([]io.Reader, len(attReaders))
+	for idx, r := range attReaders {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.sizes = append(p.sizes, len(b))
+		p.total += len(b)
+		readers[idx] = &progressReader{Reader: bytes.NewReader(b), onStart: p.attachmentStarted}
+	}
+
+	return p, readers, nil
+}
+
+func (p *sendProgress) isLarge() bool {
+	return p.total >= largeMessageSize
+}
+
+func (p *sendProgress) attachmentStarted() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.started > 0 {
+		p.uploaded += p.sizes[p.started-1]
+	}
+	p.started++
+
+	p.emitf("uploading attachment %d/%d (%d%%)", p.started, len(p.sizes), p.percent())
+}
+
+// attachmentsUploaded is called once the draft with all attachments is created.
+func (p *sendProgress) attachmentsUploaded() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.uploaded = p.total
+	p.emitf("attachments uploaded (100%%), sending")
+}
+
+func (p *sendProgress) sent() {
+	p.emitf("sent")
+}
+
+func (p *sendProgress) percent() int {
+	if p.total == 0 {
+		return 100
+	}
+	return p.uploaded * 100 / p.total
+}
+
+func (p *sendProgress) emitf(format string, args ...interface{}) {
+	if !p.isLarge() {
+		return
+	}
+	p.eventListener.Emit(events.SendProgressEvent, p.subject+": "+fmt.Sprintf(format, args...))
+}
+
+type progressReader struct {
+	io.Reader
+	onStart func()
+	once    sync.Once
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	r.once.Do(r.onStart)
+	return r.Reader.Read(b)
+}
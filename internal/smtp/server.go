@@ -20,12 +20,17 @@ package smtp
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
-	"github.com/ProtonMail/proton-bridge/internal/bridge"
 	"github.com/ProtonMail/proton-bridge/internal/events"
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
+	"github.com/ProtonMail/proton-bridge/pkg/localsocket"
+	"github.com/ProtonMail/proton-bridge/pkg/ports"
 	"github.com/emersion/go-sasl"
 	goSMTP "github.com/emersion/go-smtp"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -33,15 +38,42 @@ type smtpServer struct {
 	server        *goSMTP.Server
 	eventListener listener.Listener
 	useSSL        bool
+
+	socketPath     string
+	socketServer   *goSMTP.Server
+	socketListener net.Listener
+}
+
+// localSocketBackender is implemented by backends which can serve
+// connections authenticated by peer credentials.
+type localSocketBackender interface {
+	LocalSocketBackend() goSMTP.Backend
+}
+
+// clientLoginBackender is implemented by backends which limit failed logins
+// per client connection.
+type clientLoginBackender interface {
+	ClientLogin(client, username, password string) (goSMTP.User, error)
 }
 
 // NewSMTPServer returns an SMTP server configured with the given options.
-func NewSMTPServer(debug bool, port int, useSSL bool, tls *tls.Config, smtpBackend goSMTP.Backend, eventListener listener.Listener) *smtpServer { //nolint[golint]
+func NewSMTPServer(debug bool, host string, port int, useSSL bool, tls *tls.Config, smtpBackend goSMTP.Backend, eventListener listener.Listener) *smtpServer { //nolint[golint]
+	s := newGoSMTPServer(debug, host, tls, smtpBackend)
+	s.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+
+	return &smtpServer{
+		server:        s,
+		eventListener: eventListener,
+		useSSL:        useSSL,
+	}
+}
+
+func newGoSMTPServer(debug bool, host string, tls *tls.Config, smtpBackend goSMTP.Backend) *goSMTP.Server {
 	s := goSMTP.NewServer(smtpBackend)
-	s.Addr = fmt.Sprintf("%v:%v", bridge.Host, port)
 	s.TLSConfig = tls
-	s.Domain = bridge.Host
-	s.AllowInsecureAuth = true
+	s.Domain = host
+	// Passwords must not go over the network in plain text.
+	s.AllowInsecureAuth = ports.IsLoopbackHost(host)
 
 	if debug {
 		s.Debug = logrus.
@@ -49,23 +81,48 @@ func NewSMTPServer(debug bool, port int, useSSL bool, tls *tls.Config, smtpBacke
 			WriterLevel(logrus.DebugLevel)
 	}
 
-	s.EnableAuth(sasl.Login, func(conn *goSMTP.Conn) sasl.Server {
-		return sasl.NewLoginServer(func(address, password string) error {
-			user, err := conn.Server().Backend.Login(address, password)
-			if err != nil {
-				return err
+	s.EnableAuth(sasl.Plain, func(conn *goSMTP.Conn) sasl.Server {
+		return sasl.NewPlainServer(func(identity, username, password string) error {
+			if identity != "" && identity != username {
+				return errors.New("identities not supported")
 			}
+			return login(conn, username, password)
+		})
+	})
 
-			conn.SetUser(user)
-			return nil
+	s.EnableAuth(sasl.Login, func(conn *goSMTP.Conn) sasl.Server {
+		return sasl.NewLoginServer(func(address, password string) error {
+			return login(conn, address, password)
 		})
 	})
 
-	return &smtpServer{
-		server:        s,
-		eventListener: eventListener,
-		useSSL:        useSSL,
+	return s
+}
+
+// login authenticates the connection. go-smtp does not tell the backend the
+// address of the client, so the connection itself identifies the client.
+func login(conn *goSMTP.Conn, username, password string) error {
+	var user goSMTP.User
+	var err error
+
+	if backend, ok := conn.Server().Backend.(clientLoginBackender); ok {
+		user, err = backend.ClientLogin(fmt.Sprintf("%p", conn), username, password)
+	} else {
+		user, err = conn.Server().Backend.Login(username, password)
+	}
+	if err != nil {
+		return err
 	}
+
+	conn.SetUser(user)
+	return nil
+}
+
+// SetLocalSocketPath makes the server listen also on unix socket at path.
+// Clients connected through it are authenticated by their user ID
+// and do not need the bridge password.
+func (s *smtpServer) SetLocalSocketPath(path string) {
+	s.socketPath = path
 }
 
 // Starts the server.
@@ -73,6 +130,10 @@ func (s *smtpServer) ListenAndServe() {
 	go s.monitorDisconnectedUsers()
 	l := log.WithField("useSSL", s.useSSL).WithField("address", s.server.Addr)
 
+	if s.socketPath != "" {
+		s.listenLocalSocket()
+	}
+
 	l.Info("SMTP server is starting")
 	var err error
 	if s.useSSL {
@@ -90,23 +151,77 @@ func (s *smtpServer) ListenAndServe() {
 	l.Info("SMTP server stopped")
 }
 
+// listenLocalSocket serves local socket by separate server because go-smtp
+// does not tell the backend through which listener the client came.
+func (s *smtpServer) listenLocalSocket() {
+	backender, ok := s.server.Backend.(localSocketBackender)
+	if !ok {
+		log.Error("SMTP backend does not support local socket")
+		return
+	}
+
+	socket, err := localsocket.Listen(s.socketPath)
+	if err != nil {
+		log.WithError(err).Error("Cannot listen on SMTP local socket")
+		return
+	}
+
+	// Connections through socket are plain; TLS would not add anything there.
+	s.socketServer = newGoSMTPServer(s.server.Debug != nil, s.server.Domain, nil, backender.LocalSocketBackend())
+	s.socketServer.AllowInsecureAuth = true
+	s.socketListener = socket
+
+	log.Info("SMTP server listening at ", s.socketPath)
+	go func() {
+		if err := s.socketServer.Serve(socket); err != nil {
+			log.WithError(err).Info("SMTP local socket server stopped")
+		}
+	}()
+}
+
 // Stops the server.
 func (s *smtpServer) Close() {
 	s.server.Close()
+
+	if s.socketListener != nil {
+		_ = s.socketListener.Close()
+		s.socketServer.ForEachConn(func(conn *goSMTP.Conn) {
+			_ = conn.Close()
+		})
+	}
 }
 
 func (s *smtpServer) monitorDisconnectedUsers() {
 	ch := make(chan string)
 	s.eventListener.Add(events.CloseConnectionEvent, ch)
 
-	for address := range ch {
+	// Only SMTP connections of the user are closed, e.g. when SMTP is disabled.
+	smtpCh := make(chan string)
+	s.eventListener.Add(events.CloseSMTPConnectionEvent, smtpCh)
+
+	for {
+		var address string
+		onlyUser := false
+		select {
+		case address = <-ch:
+		case address = <-smtpCh:
+			onlyUser = true
+		}
+
 		log.Info("Disconnecting all open SMTP connections for ", address)
 		disconnectUser := func(conn *goSMTP.Conn) {
 			connUser := conn.User()
-			if connUser != nil {
-				_ = conn.Close()
+			if connUser == nil {
+				return
+			}
+			if su, ok := connUser.(*smtpUser); onlyUser && !(ok && strings.EqualFold(su.username, address)) {
+				return
 			}
+			_ = conn.Close()
 		}
 		s.server.ForEachConn(disconnectUser)
+		if s.socketServer != nil {
+			s.socketServer.ForEachConn(disconnectUser)
+		}
 	}
 }
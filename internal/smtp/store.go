@@ -33,4 +33,10 @@ type storeUserProvider interface {
 		attachedPublicKeyName string,
 		parentID string) (*pmapi.Message, []*pmapi.Attachment, error)
 	SendMessage(messageID string, req *pmapi.SendMessageReq) error
+	CheckRecipientKeys(email string, fingerprints []string) (previous []string, changed bool, err error)
+	GetCachedRecipientKeys(email string) (keys []pmapi.PublicKey, isInternal, ok bool)
+	CacheRecipientKeys(email string, keys []pmapi.PublicKey, isInternal bool)
+	GetAutoCopy() (address, mode string)
+	GetFooter() (plain, html string)
+	RecordSentMessage(address string) error
 }
@@ -44,6 +44,7 @@ type smtpUser struct {
 	backend       *smtpBackend
 	user          bridgeUser
 	storeUser     storeUserProvider
+	username      string
 	addressID     string
 }
 
@@ -53,6 +54,7 @@ func newSMTPUser(
 	eventListener listener.Listener,
 	smtpBackend *smtpBackend,
 	user bridgeUser,
+	username string,
 	addressID string,
 ) (goSMTPBackend.User, error) {
 	storeUser := user.GetStore()
@@ -66,6 +68,7 @@ func newSMTPUser(
 		backend:       smtpBackend,
 		user:          user,
 		storeUser:     storeUser,
+		username:      username,
 		addressID:     addressID,
 	}, nil
 }
@@ -75,6 +78,22 @@ func (su *smtpUser) client() pmapi.Client {
 	return su.user.GetTemporaryPMAPIClient()
 }
 
+// checkSenderDomain warns when the custom domain of the sender is missing
+// DNS records needed for delivery. The message is sent anyway.
+func (su *smtpUser) checkSenderDomain(email string) {
+	domain := email[strings.LastIndex(email, "@")+1:]
+	problems, err := su.backend.domainChecker.checkOnce(domain)
+	if err != nil {
+		log.WithError(err).WithField("domain", domain).Warn("Cannot check DNS records of sender domain")
+		return
+	}
+	if len(problems) == 0 {
+		return
+	}
+	log.WithField("domain", domain).WithField("problems", problems).Warn("Sender domain is not configured properly")
+	su.eventListener.Emit(events.SenderDomainWarningEvent, domain+": "+strings.Join(problems, "; "))
+}
+
 // Send sends an email from the given address to the given addresses with the given body.
 func (su *smtpUser) getSendPreferences(
 	recipient, messageMIMEType string,
@@ -142,7 +161,44 @@ func (su *smtpUser) getContactVCardData(recipient string) (meta *ContactMetadata
 }
 
 func (su *smtpUser) getAPIKeyData(recipient string) (apiKeys []pmapi.PublicKey, isInternal bool, err error) {
-	return su.client().GetPublicKeysForEmail(recipient)
+	// Bulk sending to the same recipients would otherwise ask for keys every time.
+	if apiKeys, isInternal, ok := su.storeUser.GetCachedRecipientKeys(recipient); ok {
+		return apiKeys, isInternal, nil
+	}
+
+	if apiKeys, isInternal, err = su.client().GetPublicKeysForEmail(recipient); err != nil {
+		return
+	}
+
+	su.checkRecipientKeys(recipient, apiKeys)
+	su.storeUser.CacheRecipientKeys(recipient, apiKeys, isInternal)
+	return
+}
+
+// checkRecipientKeys warns the user when keys of the recipient are not the
+// same as the last time. The message is sent anyway, it is only a notice.
+func (su *smtpUser) checkRecipientKeys(recipient string, apiKeys []pmapi.PublicKey) {
+	fingerprints := []string{}
+	for _, apiKey := range apiKeys {
+		key, err := crypto.NewKeyFromArmored(apiKey.PublicKey)
+		if err != nil {
+			continue
+		}
+		fingerprints = append(fingerprints, key.GetFingerprint())
+	}
+
+	previous, changed, err := su.storeUser.CheckRecipientKeys(recipient, fingerprints)
+	if err != nil {
+		log.WithError(err).Warn("Cannot check keys of recipient")
+		return
+	}
+	if changed {
+		log.WithField("recipient", recipient).
+			WithField("previous", previous).
+			WithField("current", fingerprints).
+			Warn("Public key of recipient changed")
+		su.eventListener.Emit(events.RecipientKeyChangedEvent, recipient)
+	}
 }
 
 // Send sends an email from the given address to the given addresses with the given body.
@@ -161,6 +217,10 @@ func (su *smtpUser) Send(from string, to []string, messageReader io.Reader) (err
 		return
 	}
 
+	if addr.Type == pmapi.CustomAddress {
+		su.checkSenderDomain(addr.Email)
+	}
+
 	kr, err := su.client().KeyRingForAddressID(addr.ID)
 	if err != nil {
 		return
@@ -186,6 +246,14 @@ func (su *smtpUser) Send(from string, to []string, messageReader io.Reader) (err
 	if err != nil {
 		return
 	}
+
+	if footer := newFooter(su.storeUser.GetFooter()); !footer.isEmpty() {
+		message.Body = footer.appendToBody(message.Body, message.MIMEType)
+		plainBody = footer.appendToPlain(plainBody)
+		if mimeBody, err = footer.appendToMIME(mimeBody); err != nil {
+			return errors.Wrap(err, "failed to add footer")
+		}
+	}
 	clearBody := message.Body
 
 	externalID := message.Header.Get("Message-Id")
@@ -193,6 +261,9 @@ func (su *smtpUser) Send(from string, to []string, messageReader io.Reader) (err
 
 	draftID, parentID := su.handleReferencesHeader(message)
 
+	autoCopyAddress, autoCopyMode := su.storeUser.GetAutoCopy()
+	to = addAutoCopy(message, to, autoCopyAddress, autoCopyMode)
+
 	if err = su.handleSenderAndRecipients(message, addr, from, to); err != nil {
 		return err
 	}
@@ -232,6 +303,11 @@ func (su *smtpUser) Send(from string, to []string, messageReader io.Reader) (err
 		return nil
 	}
 
+	progress, attReaders, err := newSendProgress(su.eventListener, message.Subject, attReaders)
+	if err != nil {
+		return err
+	}
+
 	su.backend.sendRecorder.addMessage(sendRecorderMessageHash)
 	message, atts, err := su.storeUser.CreateDraft(kr, message, attReaders, attachedPublicKey, attachedPublicKeyName, parentID)
 	if err != nil {
@@ -241,6 +317,7 @@ func (su *smtpUser) Send(from string, to []string, messageReader io.Reader) (err
 	}
 	su.backend.sendRecorder.setMessageID(sendRecorderMessageHash, message.ID)
 	log.WithField("messageID", message.ID).Debug("Draft was created successfully")
+	progress.attachmentsUploaded()
 
 	// We always have to create a new draft even if there already is one,
 	// because clients don't necessarily save the draft before sending, which
@@ -390,7 +467,50 @@ func (su *smtpUser) Send(from string, to []string, messageReader io.Reader) (err
 		req.Packages = append(req.Packages, pkg)
 	}
 
-	return su.storeUser.SendMessage(message.ID, req)
+	outgoing := OutgoingMessage{
+		MessageID:  message.ID,
+		From:       from,
+		Subject:    message.Subject,
+		Recipients: to,
+	}
+
+	delay := su.backend.getUndoSendDelay()
+	if delay == 0 {
+		return su.sendMessage(outgoing, req, progress)
+	}
+
+	// Message is accepted right away and sent later unless the user undoes it.
+	ps := &pendingSend{
+		PendingSend:  PendingSend{OutgoingMessage: outgoing},
+		recorderHash: sendRecorderMessageHash,
+	}
+	su.backend.outbox.schedule(ps, delay, su.panicHandler, func() {
+		_ = su.sendMessage(outgoing, req, progress)
+	})
+	log.WithField("messageID", outgoing.MessageID).WithField("delay", delay).Info("Message will be sent after undo-send delay")
+
+	return nil
+}
+
+// sendMessage sends the prepared draft. When it fails, the failure is recorded
+// so the user can see the message did not go out and can retry it.
+func (su *smtpUser) sendMessage(msg OutgoingMessage, req *pmapi.SendMessageReq, progress *sendProgress) error {
+	send := func() error {
+		return su.storeUser.SendMessage(msg.MessageID, req)
+	}
+
+	if err := su.backend.outbox.send(msg, send); err != nil {
+		log.WithError(err).WithField("messageID", msg.MessageID).Error("Message could not be sent")
+		su.backend.outbox.addFailed(msg, err, send)
+		su.eventListener.Emit(events.SendFailedEvent, msg.Subject)
+		return err
+	}
+
+	progress.sent()
+	if err := su.storeUser.RecordSentMessage(msg.From); err != nil {
+		log.WithError(err).Warn("Failed to update address statistics")
+	}
+	return nil
 }
 
 func (su *smtpUser) handleReferencesHeader(m *pmapi.Message) (draftID, parentID string) {
@@ -79,7 +79,7 @@ func (storeAddress *Address) init(foldersAndLabels []*pmapi.Label) (err error) {
 
 			storeAddress.mailboxes[label.ID] = mailbox
 		}
-		return nil
+		return storeAddress.txInitSavedSearches(tx)
 	})
 
 	return
@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AddressStats counts messages sent from and received to the address
+// since the bridge started tracking them.
+type AddressStats struct {
+	Address      string
+	Sent         uint
+	Received     uint
+	LastSent     time.Time
+	LastReceived time.Time
+}
+
+// LastUsed returns when the address last sent or received a message.
+func (stats AddressStats) LastUsed() time.Time {
+	if stats.LastSent.After(stats.LastReceived) {
+		return stats.LastSent
+	}
+	return stats.LastReceived
+}
+
+// RecordSentMessage counts message sent from the address.
+func (store *Store) RecordSentMessage(address string) error {
+	return store.updateAddressStats(address, func(stats *AddressStats) {
+		stats.Sent++
+		stats.LastSent = time.Now()
+	})
+}
+
+// recordReceivedMessage counts message received to the address.
+func (store *Store) recordReceivedMessage(addressID string) error {
+	address := store.client().Addresses().ByID(addressID)
+	if address == nil {
+		return nil
+	}
+	return store.updateAddressStats(address.Email, func(stats *AddressStats) {
+		stats.Received++
+		stats.LastReceived = time.Now()
+	})
+}
+
+func (store *Store) updateAddressStats(address string, update func(*AddressStats)) error {
+	key := []byte(strings.ToLower(address))
+	return store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(addressStatsBucket)
+
+		stats := AddressStats{Address: string(key)}
+		if raw := b.Get(key); raw != nil {
+			if err := json.Unmarshal(raw, &stats); err != nil {
+				return err
+			}
+		}
+		update(&stats)
+
+		raw, err := json.Marshal(&stats)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, raw)
+	})
+}
+
+// GetAddressStats returns statistics of all addresses of the account,
+// including those which were not used yet, and of removed addresses with
+// recorded messages. Statistics are sorted by address.
+func (store *Store) GetAddressStats() ([]AddressStats, error) {
+	byAddress := map[string]AddressStats{}
+
+	if addrs, err := store.GetAddressInfo(); err == nil {
+		for _, addr := range addrs {
+			address := strings.ToLower(addr.Address)
+			byAddress[address] = AddressStats{Address: address}
+		}
+	}
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(addressStatsBucket).ForEach(func(k, v []byte) error {
+			var stats AddressStats
+			if err := json.Unmarshal(v, &stats); err != nil {
+				return err
+			}
+			byAddress[string(k)] = stats
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	allStats := make([]AddressStats, 0, len(byAddress))
+	for _, stats := range byAddress {
+		allStats = append(allStats, stats)
+	}
+	sort.Slice(allStats, func(i, j int) bool {
+		return allStats[i].Address < allStats[j].Address
+	})
+	return allStats, nil
+}
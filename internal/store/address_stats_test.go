@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddressStats(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+	m.client.EXPECT().Addresses().Return(pmapi.AddressList{{ID: addrID1, Email: addr1}})
+
+	require.NoError(t, m.store.RecordSentMessage("NiceAddress@pm.me"))
+	require.NoError(t, m.store.RecordSentMessage(addr1))
+	require.NoError(t, m.store.recordReceivedMessage(addrID1))
+	require.NoError(t, m.store.RecordSentMessage("removed@pm.me"))
+
+	allStats, err := m.store.GetAddressStats()
+	require.NoError(t, err)
+
+	byAddress := map[string]AddressStats{}
+	for _, stats := range allStats {
+		byAddress[stats.Address] = stats
+	}
+
+	require.Equal(t, uint(2), byAddress[addr1].Sent)
+	require.Equal(t, uint(1), byAddress[addr1].Received)
+	require.False(t, byAddress[addr1].LastUsed().IsZero())
+
+	require.Equal(t, uint(1), byAddress["removed@pm.me"].Sent)
+}
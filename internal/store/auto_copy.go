@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"net/mail"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Auto-copy modes deciding how the configured address is added to
+// every sent message.
+const (
+	AutoCopyBCC = "bcc"
+	AutoCopyCC  = "cc"
+)
+
+// ErrUnknownAutoCopyMode is returned when setting unsupported mode.
+var ErrUnknownAutoCopyMode = errors.New("unknown auto-copy mode, use bcc or cc")
+
+// GetAutoCopy returns the address added to every message sent through
+// SMTP and whether it is added as BCC or CC. Empty address means no copy.
+func (store *Store) GetAutoCopy() (address, mode string) {
+	return store.getStringSetting(autoCopyAddressKey, ""), store.getStringSetting(autoCopyModeKey, AutoCopyBCC)
+}
+
+// SetAutoCopy sets the address added to every sent message, e.g. for CRM
+// archiving. Empty address turns the copy off.
+func (store *Store) SetAutoCopy(address, mode string) error {
+	if mode != AutoCopyBCC && mode != AutoCopyCC {
+		return ErrUnknownAutoCopyMode
+	}
+	if address = strings.TrimSpace(address); address != "" {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return errors.Wrap(err, "invalid auto-copy address")
+		}
+		address = parsed.Address
+	}
+
+	if err := store.setStringSetting(autoCopyAddressKey, address, ""); err != nil {
+		return err
+	}
+	return store.setStringSetting(autoCopyModeKey, mode, AutoCopyBCC)
+}
@@ -20,6 +20,7 @@ package store
 import (
 	"encoding/json"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -69,6 +70,43 @@ func (c *Cache) setEventID(userID, eventID string) error {
 	return c.saveCache()
 }
 
+// subscriptionExceptionsKey is the cache key under which older versions kept
+// semicolon-separated label IDs of unsubscribed mailboxes.
+const subscriptionExceptionsKey = "subscription_exceptions"
+
+// getSubscriptionExceptions returns label IDs of mailboxes unsubscribed by
+// older versions which kept them in the cache file instead of the database.
+func (c *Cache) getSubscriptionExceptions(userID string) []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_ = c.loadCache()
+
+	var labelIDs []string
+	for _, labelID := range strings.Split(c.cache[userID][subscriptionExceptionsKey], ";") {
+		if labelID != "" {
+			labelIDs = append(labelIDs, labelID)
+		}
+	}
+
+	return labelIDs
+}
+
+// clearSubscriptionExceptions removes subscription exceptions of the user
+// from the cache once they have been imported to the database.
+func (c *Cache) clearSubscriptionExceptions(userID string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.cache[userID][subscriptionExceptionsKey]; !ok {
+		return nil
+	}
+
+	delete(c.cache[userID], subscriptionExceptionsKey)
+
+	return c.saveCache()
+}
+
 func (c *Cache) loadCache() error {
 	if c.cache != nil {
 		return nil
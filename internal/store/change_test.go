@@ -87,6 +87,33 @@ func TestDeleteMessageIMAPUpdate(t *testing.T) {
 	close(updates)
 }
 
+func TestEventLoopNewMessageIMAPUpdates(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	updates := make(chan imapBackend.Update)
+
+	m.newStoreNoEvents(true)
+	m.store.SetIMAPUpdateChannel(updates)
+
+	// Client IDLE-ing in INBOX learns about the new message by EXISTS.
+	go checkIMAPUpdates(t, updates, []func(interface{}) bool{
+		checkMessageUpdate(addr1, "INBOX", 1, 1),
+		checkMailboxUpdate(addr1, "INBOX", 1),
+	})
+
+	msg := getTestMessage("msg1", "Test message 1", addrID1, 0, []string{pmapi.InboxLabel})
+	require.NoError(t, m.store.eventLoop.processEvent(&pmapi.Event{
+		EventID: "event1",
+		Messages: []*pmapi.EventMessage{{
+			EventItem: pmapi.EventItem{ID: "msg1", Action: pmapi.EventCreate},
+			Created:   msg,
+		}},
+	}))
+
+	close(updates)
+}
+
 func checkIMAPUpdates(t *testing.T, updates chan imapBackend.Update, checkFunctions []func(interface{}) bool) {
 	idx := 0
 	for update := range updates {
@@ -127,3 +154,16 @@ func checkMessageDelete(username, mailbox string, seqNum int) func(interface{})
 		}
 	}
 }
+
+func checkMailboxUpdate(username, mailbox string, messages int) func(interface{}) bool {
+	return func(update interface{}) bool {
+		switch u := update.(type) {
+		case *imapBackend.MailboxUpdate:
+			return (u.Update.Username() == username &&
+				u.Update.Mailbox() == mailbox &&
+				u.MailboxStatus.Messages == uint32(messages))
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+// IsCrossAccountCopyEnabled returns whether messages copied to this account
+// from another bridge account by email clients are imported. Otherwise such
+// copy is refused with a clear error.
+func (store *Store) IsCrossAccountCopyEnabled() bool {
+	return store.getBoolSetting(crossAccountCopyKey, false)
+}
+
+// SetCrossAccountCopyEnabled sets whether messages copied from another bridge account are imported.
+func (store *Store) SetCrossAccountCopyEnabled(enabled bool) error {
+	return store.setBoolSetting(crossAccountCopyKey, enabled, false)
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import "github.com/pkg/errors"
+
+// ErrDraftsUploadDisabled is returned when a client saves a draft but drafts
+// are synced only from the server to clients.
+var ErrDraftsUploadDisabled = errors.New("saving drafts from email clients is disabled")
+
+// IsDraftsUploadEnabled returns whether drafts saved by email clients are
+// uploaded to the server. When disabled, drafts are synced only one way,
+// from the server to clients.
+func (store *Store) IsDraftsUploadEnabled() bool {
+	return store.getBoolSetting(draftsUploadKey, true)
+}
+
+// SetDraftsUploadEnabled sets whether drafts saved by email clients are uploaded to the server.
+func (store *Store) SetDraftsUploadEnabled(enabled bool) error {
+	return store.setBoolSetting(draftsUploadKey, enabled, true)
+}
@@ -19,6 +19,8 @@ package store
 
 import (
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	bridgeEvents "github.com/ProtonMail/proton-bridge/internal/events"
@@ -28,9 +30,38 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const pollInterval = 30 * time.Second
+// Bounds and default of the interval of event polling.
+const (
+	defaultPollInterval = 30 * time.Second
+	minPollInterval     = 10 * time.Second
+	maxPollInterval     = 15 * time.Minute
+)
+
 const pollIntervalSpread = 5 * time.Second
 
+var pollInterval = int64(defaultPollInterval) //nolint[gochecknoglobals]
+
+// SetPollInterval changes how often events are polled. Longer interval means
+// fewer requests to the API but new mail shows up later. Zero means
+// the default. The API offers no push or long-polling of events, so polling
+// is the only option.
+func SetPollInterval(interval time.Duration) {
+	switch {
+	case interval == 0:
+		interval = defaultPollInterval
+	case interval < minPollInterval:
+		interval = minPollInterval
+	case interval > maxPollInterval:
+		interval = maxPollInterval
+	}
+
+	atomic.StoreInt64(&pollInterval, int64(interval))
+}
+
+func getPollInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&pollInterval))
+}
+
 type eventLoop struct {
 	cache          *Cache
 	currentEventID string
@@ -43,6 +74,11 @@ type eventLoop struct {
 
 	pollCounter int
 
+	// status is kept for monitoring of latency and backlog of events.
+	status     EventLoopStatus
+	statusLock sync.Mutex
+	wasBehind  bool
+
 	log *logrus.Entry
 
 	store  *Store
@@ -129,6 +165,10 @@ func (loop *eventLoop) start() {
 	loop.notifyStopCh = make(chan struct{})
 	loop.isRunning = true
 
+	loop.statusLock.Lock()
+	loop.status.Started = time.Now()
+	loop.statusLock.Unlock()
+
 	events := make(chan *pmapi.Event)
 	defer close(events)
 
@@ -146,13 +186,15 @@ func (loop *eventLoop) start() {
 
 // loop is the main body of the event loop.
 func (loop *eventLoop) loop() {
-	t := time.NewTicker(pollInterval - pollIntervalSpread)
-	defer t.Stop()
-
 	for {
+		// Timer is created every time so the change of the interval is
+		// used right away.
+		t := time.NewTimer(getPollInterval() - pollIntervalSpread)
+
 		var eventProcessedCh chan struct{}
 		select {
 		case <-loop.stopCh:
+			t.Stop()
 			close(loop.notifyStopCh)
 			return
 		case <-t.C:
@@ -160,6 +202,7 @@ func (loop *eventLoop) loop() {
 			time.Sleep(time.Duration(rand.Intn(2*int(pollIntervalSpread.Milliseconds()))) * time.Millisecond)
 		case eventProcessedCh = <-loop.pollCh:
 			// We don't want to wait here. Polling should happen instantly.
+			t.Stop()
 		}
 
 		// Before we fetch the first event, check whether this is the first time we've
@@ -181,6 +224,7 @@ func (loop *eventLoop) loop() {
 		if eventProcessedCh != nil {
 			eventProcessedCh <- struct{}{}
 		}
+		loop.checkBehind()
 		if err != nil {
 			loop.log.WithError(err).Error("Cannot process event, stopping event loop")
 			// When event loop stops, the only way to start it again is by login.
@@ -196,6 +240,10 @@ func (loop *eventLoop) loop() {
 
 		if more {
 			go loop.pollNow()
+		} else {
+			loop.store.recalculateCountsIfDue()
+			loop.store.processDeferredDeletions()
+			loop.store.pruneTombstones()
 		}
 	}
 }
@@ -252,6 +300,7 @@ func (loop *eventLoop) processNextEvent() (more bool, err error) { // nolint[fun
 	}
 	loop.pollCounter++
 
+	pollStart := time.Now()
 	var event *pmapi.Event
 	if event, err = loop.client().GetEvent(loop.currentEventID); err != nil {
 		return false, errors.Wrap(err, "failed to get event")
@@ -287,6 +336,8 @@ func (loop *eventLoop) processNextEvent() (more bool, err error) { // nolint[fun
 		}
 	}
 
+	loop.recordPoll(event, time.Since(pollStart))
+
 	return event.More == 1, err
 }
 
@@ -307,6 +358,13 @@ func (loop *eventLoop) processEvent(event *pmapi.Event) (err error) {
 		}
 	}
 
+	if IsRelayOnlyMode() {
+		if len(event.Notices) != 0 {
+			loop.processNotices(eventLog, event.Notices)
+		}
+		return err
+	}
+
 	if len(event.Labels) != 0 {
 		if err = loop.processLabels(eventLog, event.Labels); err != nil {
 			return errors.Wrap(err, "failed to process label events")
@@ -432,6 +490,18 @@ func (loop *eventLoop) processMessages(eventLog *logrus.Entry, messages []*pmapi
 				return errors.Wrap(err, "failed to put message into DB")
 			}
 
+			if message.Created.Unread == 1 && message.Created.HasLabelID(pmapi.InboxLabel) {
+				loop.events.Emit(bridgeEvents.NewMailEvent, loop.user.GetPrimaryAddress())
+			}
+
+			if message.Created.Has(pmapi.FlagReceived) {
+				if err := loop.store.recordReceivedMessage(message.Created.AddressID); err != nil {
+					msgLog.WithError(err).Warn("Failed to update address statistics")
+				}
+			}
+
+			loop.store.queueFilters(message.Created)
+
 		case pmapi.EventUpdate, pmapi.EventUpdateFlags:
 			msgLog.Debug("Processing EventUpdate(Flags) for message")
 
@@ -452,6 +522,7 @@ func (loop *eventLoop) processMessages(eventLog *logrus.Entry, messages []*pmapi
 				if msg, err = loop.client().GetMessage(message.ID); err != nil {
 					if _, ok := err.(*pmapi.ErrUnprocessableEntity); ok {
 						msgLog.WithError(err).Warn("Skipping message update because message exists neither in local DB nor on API")
+						loop.store.RecordProblem(message.ID, "", err)
 						err = nil
 						continue
 					}
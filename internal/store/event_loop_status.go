@@ -0,0 +1,123 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+)
+
+const (
+	// eventLoopBacklogThreshold is how many polls in a row can end with more
+	// events still waiting on the API before the loop is falling behind.
+	eventLoopBacklogThreshold = 10
+
+	// eventLoopStaleThreshold is how long the loop can go without successful
+	// poll before it is falling behind, e.g. because of API errors. With long
+	// poll interval, three missed polls are needed.
+	eventLoopStaleThreshold = 5 * time.Minute
+)
+
+// EventLoopStatus describes how the event loop keeps up with the API events.
+type EventLoopStatus struct {
+	Running     bool
+	Started     time.Time
+	LastEventID string
+	LastPoll    time.Time     // Time of the last successful poll.
+	LastLatency time.Duration // Time to fetch and process the last event.
+	MaxLatency  time.Duration
+	Backlog     int // Polls in a row which had more events waiting.
+}
+
+// IsBehind returns whether changes on the server might be missing in clients
+// because the loop cannot keep up with events or cannot poll them at all.
+func (status EventLoopStatus) IsBehind() bool {
+	if !status.Running {
+		return false
+	}
+	if status.Backlog >= eventLoopBacklogThreshold {
+		return true
+	}
+
+	lastSuccess := status.LastPoll
+	if lastSuccess.IsZero() {
+		lastSuccess = status.Started
+	}
+	staleThreshold := eventLoopStaleThreshold
+	if interval := 3 * getPollInterval(); interval > staleThreshold {
+		staleThreshold = interval
+	}
+	return time.Since(lastSuccess) > staleThreshold
+}
+
+// GetEventLoopStatus returns the status of the event loop. Empty status is
+// returned when the account is not connected and there is no event loop.
+func (store *Store) GetEventLoopStatus() EventLoopStatus {
+	if store.eventLoop == nil {
+		return EventLoopStatus{}
+	}
+	return store.eventLoop.getStatus()
+}
+
+func (loop *eventLoop) getStatus() EventLoopStatus {
+	loop.statusLock.Lock()
+	defer loop.statusLock.Unlock()
+
+	status := loop.status
+	status.Running = loop.isRunning
+	return status
+}
+
+func (loop *eventLoop) recordPoll(event *pmapi.Event, latency time.Duration) {
+	loop.statusLock.Lock()
+	defer loop.statusLock.Unlock()
+
+	loop.status.LastEventID = event.EventID
+	loop.status.LastPoll = time.Now()
+	loop.status.LastLatency = latency
+	if latency > loop.status.MaxLatency {
+		loop.status.MaxLatency = latency
+	}
+	if event.More == 1 {
+		loop.status.Backlog++
+	} else {
+		loop.status.Backlog = 0
+	}
+}
+
+// checkBehind logs once when the loop starts to fall behind and when it
+// catches up again.
+func (loop *eventLoop) checkBehind() {
+	status := loop.getStatus()
+	isBehind := status.IsBehind()
+	if isBehind == loop.wasBehind {
+		return
+	}
+	loop.wasBehind = isBehind
+
+	l := loop.log.
+		WithField("backlog", status.Backlog).
+		WithField("lastPoll", status.LastPoll).
+		WithField("lastLatency", status.LastLatency)
+	if isBehind {
+		l.Warn("Event loop is falling behind, changes might be missing in clients")
+	} else {
+		l.Info("Event loop caught up")
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLoopStatusBacklog(t *testing.T) {
+	loop := &eventLoop{isRunning: true, log: log}
+	loop.status.Started = time.Now()
+
+	for i := 0; i < eventLoopBacklogThreshold; i++ {
+		require.False(t, loop.getStatus().IsBehind())
+		loop.recordPoll(&pmapi.Event{EventID: "event", More: 1}, time.Duration(i)*time.Millisecond)
+	}
+	status := loop.getStatus()
+	require.True(t, status.IsBehind())
+	require.Equal(t, eventLoopBacklogThreshold, status.Backlog)
+	require.Equal(t, time.Duration(eventLoopBacklogThreshold-1)*time.Millisecond, status.MaxLatency)
+
+	loop.recordPoll(&pmapi.Event{EventID: "lastEvent"}, time.Millisecond)
+	status = loop.getStatus()
+	require.False(t, status.IsBehind())
+	require.Equal(t, "lastEvent", status.LastEventID)
+	require.Equal(t, time.Millisecond, status.LastLatency)
+}
+
+func TestEventLoopStatusStale(t *testing.T) {
+	status := EventLoopStatus{
+		Running: true,
+		Started: time.Now().Add(-2 * eventLoopStaleThreshold),
+	}
+	require.True(t, status.IsBehind())
+
+	status.LastPoll = time.Now()
+	require.False(t, status.IsBehind())
+
+	status.LastPoll = time.Now().Add(-2 * eventLoopStaleThreshold)
+	require.True(t, status.IsBehind())
+
+	status.Running = false
+	require.False(t, status.IsBehind())
+}
+
+func TestEventLoopStatusStaleWithLongPollInterval(t *testing.T) {
+	SetPollInterval(maxPollInterval)
+	defer SetPollInterval(0)
+
+	status := EventLoopStatus{
+		Running:  true,
+		LastPoll: time.Now().Add(-2 * eventLoopStaleThreshold),
+	}
+	require.False(t, status.IsBehind())
+
+	status.LastPoll = time.Now().Add(-4 * maxPollInterval)
+	require.True(t, status.IsBehind())
+}
+
+func TestSetPollInterval(t *testing.T) {
+	defer SetPollInterval(0)
+
+	SetPollInterval(time.Second)
+	require.Equal(t, minPollInterval, getPollInterval())
+
+	SetPollInterval(time.Hour)
+	require.Equal(t, maxPollInterval, getPollInterval())
+
+	SetPollInterval(time.Minute)
+	require.Equal(t, time.Minute, getPollInterval())
+
+	SetPollInterval(0)
+	require.Equal(t, defaultPollInterval, getPollInterval())
+}
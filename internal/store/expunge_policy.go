@@ -0,0 +1,196 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// ExpungePolicy decides what happens with messages which email client
+// deletes (flags as \Deleted and expunges).
+type ExpungePolicy string
+
+const (
+	// ExpungePolicyDelete removes messages from the mailbox right away and
+	// deletes them permanently when removed from Trash or Spam.
+	ExpungePolicyDelete ExpungePolicy = "delete"
+	// ExpungePolicyTrash moves messages from folders to Trash instead.
+	ExpungePolicyTrash ExpungePolicy = "trash"
+	// ExpungePolicyDeferred applies the delete policy only after
+	// the grace period passes.
+	ExpungePolicyDeferred ExpungePolicy = "deferred"
+
+	// ExpungeGracePeriod is how long deferred deletions wait.
+	ExpungeGracePeriod = 24 * time.Hour
+)
+
+// ErrUnknownExpungePolicy is returned when setting unsupported policy.
+var ErrUnknownExpungePolicy = errors.New("unknown expunge policy, use delete, trash or deferred")
+
+type deferredDeletion struct {
+	AddressID string
+	LabelID   string
+	MessageID string
+	DeleteAt  time.Time
+}
+
+func (deletion deferredDeletion) key() []byte {
+	return []byte(strings.Join([]string{deletion.AddressID, deletion.LabelID, deletion.MessageID}, "/"))
+}
+
+// GetExpungePolicy returns the policy used when email client deletes messages.
+func (store *Store) GetExpungePolicy() ExpungePolicy {
+	return ExpungePolicy(store.getStringSetting(expungePolicyKey, string(ExpungePolicyDelete)))
+}
+
+// SetExpungePolicy sets the policy used when email client deletes messages.
+func (store *Store) SetExpungePolicy(policy ExpungePolicy) error {
+	switch policy {
+	case ExpungePolicyDelete, ExpungePolicyTrash, ExpungePolicyDeferred:
+	default:
+		return ErrUnknownExpungePolicy
+	}
+	return store.setStringSetting(expungePolicyKey, string(policy), string(ExpungePolicyDelete))
+}
+
+// shouldMoveToTrash returns whether messages deleted from this mailbox are
+// moved to Trash under the trash policy. Removing message from a label or
+// from virtual mailboxes never moves the message, and deleting from Trash
+// itself is permanent.
+func (storeMailbox *Mailbox) shouldMoveToTrash() bool {
+	switch storeMailbox.labelID {
+	case pmapi.TrashLabel, pmapi.AllMailLabel, pmapi.AllSentLabel, pmapi.AllDraftsLabel:
+		return false
+	}
+	return !storeMailbox.IsLabel()
+}
+
+// deferDeletion remembers messages to be deleted once the grace period passes.
+// The messages stay in the mailbox meanwhile.
+func (storeMailbox *Mailbox) deferDeletion(apiIDs []string) error {
+	deleteAt := time.Now().Add(ExpungeGracePeriod)
+	return storeMailbox.store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deferredDelBucket)
+		for _, apiID := range apiIDs {
+			deletion := deferredDeletion{
+				AddressID: storeMailbox.storeAddress.addressID,
+				LabelID:   storeMailbox.labelID,
+				MessageID: apiID,
+				DeleteAt:  deleteAt,
+			}
+			// Deleting again does not prolong the grace period.
+			if b.Get(deletion.key()) != nil {
+				continue
+			}
+			raw, err := json.Marshal(deletion)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(deletion.key(), raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// processDeferredDeletions deletes messages whose grace period passed.
+// Messages which are not in the mailbox anymore are forgotten.
+func (store *Store) processDeferredDeletions() {
+	due := map[*Mailbox][]deferredDeletion{}
+	processed := [][]byte{}
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deferredDelBucket).ForEach(func(k, v []byte) error {
+			// Keys are valid only during the transaction.
+			k = append([]byte{}, k...)
+
+			var deletion deferredDeletion
+			if err := json.Unmarshal(v, &deletion); err != nil {
+				processed = append(processed, k)
+				return nil //nolint[nilerr] Broken record cannot be processed anyway.
+			}
+			if time.Now().Before(deletion.DeleteAt) {
+				return nil
+			}
+
+			mailbox := store.getDeferredDeletionMailbox(deletion)
+			msg, err := store.txGetMessage(tx, deletion.MessageID)
+			if mailbox == nil || err != nil || !msg.HasLabelID(deletion.LabelID) {
+				processed = append(processed, k)
+				return nil //nolint[nilerr] Message was moved or deleted meanwhile.
+			}
+			due[mailbox] = append(due[mailbox], deletion)
+			return nil
+		})
+	})
+	if err != nil {
+		store.log.WithError(err).Error("Cannot load deferred deletions")
+		return
+	}
+
+	for mailbox, deletions := range due {
+		apiIDs := []string{}
+		for _, deletion := range deletions {
+			apiIDs = append(apiIDs, deletion.MessageID)
+		}
+		if err := mailbox.deleteMessages(apiIDs); err != nil {
+			mailbox.log.WithError(err).Warn("Cannot process deferred deletion, will retry")
+			continue
+		}
+		for _, deletion := range deletions {
+			processed = append(processed, deletion.key())
+		}
+	}
+
+	if len(processed) == 0 {
+		return
+	}
+	if err := store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deferredDelBucket)
+		for _, k := range processed {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		store.log.WithError(err).Error("Cannot remove processed deferred deletions")
+	}
+}
+
+func (store *Store) getDeferredDeletionMailbox(deletion deferredDeletion) *Mailbox {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	storeAddress, ok := store.addresses[deletion.AddressID]
+	if !ok {
+		return nil
+	}
+	mailbox, ok := storeAddress.mailboxes[deletion.LabelID]
+	if !ok {
+		return nil
+	}
+	return mailbox
+}
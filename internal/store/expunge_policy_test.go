@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestExpungePolicyTrash(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	require.Equal(t, ExpungePolicyDelete, m.store.GetExpungePolicy())
+	require.Equal(t, ErrUnknownExpungePolicy, m.store.SetExpungePolicy("shred"))
+	require.NoError(t, m.store.SetExpungePolicy(ExpungePolicyTrash))
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+
+	inbox, err := m.store.getMailbox("INBOX")
+	require.NoError(t, err)
+	m.client.EXPECT().LabelMessages([]string{"msg1"}, pmapi.TrashLabel).Return(nil)
+	require.NoError(t, inbox.DeleteMessages([]string{"msg1"}))
+}
+
+func TestExpungePolicyDeferred(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+	require.NoError(t, m.store.SetExpungePolicy(ExpungePolicyDeferred))
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+
+	inbox, err := m.store.getMailbox("INBOX")
+	require.NoError(t, err)
+	require.NoError(t, inbox.DeleteMessages([]string{"msg1", "msg2"}))
+
+	// Nothing is due yet.
+	m.store.processDeferredDeletions()
+
+	// Pretend the grace period passed; msg2 was moved away meanwhile.
+	setDeferredDeletionsDue(t, m)
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.ArchiveLabel})
+
+	m.client.EXPECT().UnlabelMessages([]string{"msg1"}, pmapi.InboxLabel).Return(nil)
+	m.store.processDeferredDeletions()
+
+	// Processed deletions are forgotten.
+	m.store.processDeferredDeletions()
+}
+
+func setDeferredDeletionsDue(t *testing.T, m *mocksForStore) {
+	require.NoError(t, m.store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deferredDelBucket)
+		deletions := []deferredDeletion{}
+		if err := b.ForEach(func(k, v []byte) error {
+			var deletion deferredDeletion
+			require.NoError(t, json.Unmarshal(v, &deletion))
+			deletions = append(deletions, deletion)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, deletion := range deletions {
+			deletion.DeleteAt = time.Now().Add(-time.Minute)
+			raw, err := json.Marshal(deletion)
+			require.NoError(t, err)
+			if err := b.Put(deletion.key(), raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/ProtonMail/proton-bridge/pkg/sieve"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var filtersScriptKey = []byte("sieve") //nolint[gochecknoglobals]
+
+// filterQueueSize is the number of messages waiting for the filter worker.
+// Messages over this limit are not filtered so the event loop never blocks.
+const filterQueueSize = 1000
+
+// GetFilters returns the local filtering script or empty string if not set.
+func (store *Store) GetFilters() (script string, err error) {
+	err = store.db.View(func(tx *bolt.Tx) error {
+		script = string(tx.Bucket(filtersBucket).Get(filtersScriptKey))
+		return nil
+	})
+	return
+}
+
+// SetFilters validates and saves the local filtering script (subset of
+// Sieve) evaluated on newly received messages. Empty script disables it.
+func (store *Store) SetFilters(script string) error {
+	var parsed *sieve.Script
+	if strings.TrimSpace(script) != "" {
+		var err error
+		if parsed, err = sieve.Parse(script); err != nil {
+			return errors.Wrap(err, "invalid filters")
+		}
+	}
+
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		if parsed == nil {
+			return tx.Bucket(filtersBucket).Delete(filtersScriptKey)
+		}
+		return tx.Bucket(filtersBucket).Put(filtersScriptKey, []byte(script))
+	})
+	if err != nil {
+		return err
+	}
+
+	store.lock.Lock()
+	store.filters = parsed
+	store.lock.Unlock()
+
+	return nil
+}
+
+// loadFilters parses the saved filtering script.
+// Broken script is only logged so the store can still start.
+func (store *Store) loadFilters() {
+	script, err := store.GetFilters()
+	if err != nil || script == "" {
+		return
+	}
+
+	if store.filters, err = sieve.Parse(script); err != nil {
+		store.log.WithError(err).Error("Cannot parse local filters, filtering is disabled")
+	}
+}
+
+// shouldFilterMessage returns whether the message was just delivered,
+// i.e. it is a received message which server filters left in inbox.
+func shouldFilterMessage(msg *pmapi.Message) bool {
+	return msg.Has(pmapi.FlagReceived) && msg.HasLabelID(pmapi.InboxLabel)
+}
+
+// filterJob is a message with actions of local filters waiting to be applied.
+type filterJob struct {
+	msgID   string
+	actions []sieve.Action
+}
+
+// queueFilters evaluates local filters on the message and queues resulting
+// actions for the filter worker, so API calls do not block the event loop.
+// The store is updated by the events of these changes.
+func (store *Store) queueFilters(msg *pmapi.Message) {
+	actions := store.evaluateFilters(msg)
+	if len(actions) == 0 {
+		return
+	}
+
+	select {
+	case store.filterQueue <- filterJob{msgID: msg.ID, actions: actions}:
+	default:
+		store.log.WithField("msgID", msg.ID).Warn("Local filter queue is full, message is not filtered")
+	}
+}
+
+func (store *Store) evaluateFilters(msg *pmapi.Message) []sieve.Action {
+	store.lock.RLock()
+	filters := store.filters
+	store.lock.RUnlock()
+
+	if filters == nil || !shouldFilterMessage(msg) {
+		return nil
+	}
+
+	return filters.Evaluate(filterMessage{msg})
+}
+
+// runFilterWorker applies queued filter actions until the store is closed.
+func (store *Store) runFilterWorker() {
+	for {
+		select {
+		case job := <-store.filterQueue:
+			store.applyFilterActions(job)
+		case <-store.filterStopCh:
+			return
+		}
+	}
+}
+
+// stopFilterWorker can be called more times, e.g. by Close and Remove.
+func (store *Store) stopFilterWorker() {
+	select {
+	case <-store.filterStopCh:
+	default:
+		close(store.filterStopCh)
+	}
+}
+
+// applyFilterActions applies actions via the API. Failures are only logged
+// so one wrong rule does not stop other actions.
+func (store *Store) applyFilterActions(job filterJob) {
+	l := store.log.WithField("msgID", job.msgID)
+
+	for _, action := range job.actions {
+		l := l.WithField("action", action.Type).WithField("argument", action.Argument)
+		l.Debug("Applying local filter")
+
+		var err error
+		switch action.Type {
+		case sieve.ActionFileInto:
+			err = store.fileIntoByFilter(job.msgID, action.Argument)
+		case sieve.ActionAddFlag:
+			if action.Argument == `\Seen` {
+				err = store.client().MarkMessagesRead([]string{job.msgID})
+			} else {
+				err = store.client().LabelMessages([]string{job.msgID}, pmapi.StarredLabel)
+			}
+		}
+		if err != nil {
+			l.WithError(err).Warn("Cannot apply local filter")
+		}
+	}
+}
+
+func (store *Store) fileIntoByFilter(apiID, mailboxName string) error {
+	mailbox, err := store.getMailbox(mailboxName)
+	if err != nil {
+		return err
+	}
+
+	if mailbox.IsSavedSearch() || mailbox.labelID == pmapi.AllMailLabel {
+		return ErrSavedSearchOpNotAllowed
+	}
+
+	// Folders are exclusive so labeling by a folder also removes the message from inbox.
+	return store.client().LabelMessages([]string{apiID}, mailbox.labelID)
+}
+
+// filterMessage provides metadata of the message to filters.
+type filterMessage struct {
+	msg *pmapi.Message
+}
+
+func (m filterMessage) HeaderValues(name string) []string {
+	switch textproto.CanonicalMIMEHeaderKey(name) {
+	case "Subject":
+		return []string{m.msg.Subject}
+	case "From", "To", "Cc", "Bcc", "Reply-To":
+		var values []string
+		for _, address := range m.Addresses(name) {
+			values = append(values, address.String())
+		}
+		return values
+	}
+
+	if m.msg.Header == nil {
+		return nil
+	}
+	return m.msg.Header[textproto.CanonicalMIMEHeaderKey(name)]
+}
+
+func (m filterMessage) Addresses(name string) []*mail.Address {
+	var addresses []*mail.Address
+
+	switch textproto.CanonicalMIMEHeaderKey(name) {
+	case "From":
+		if m.msg.Sender != nil {
+			addresses = []*mail.Address{m.msg.Sender}
+		}
+	case "To":
+		addresses = m.msg.ToList
+	case "Cc":
+		addresses = m.msg.CCList
+	case "Bcc":
+		addresses = m.msg.BCCList
+	case "Reply-To":
+		addresses = m.msg.ReplyTos
+	default:
+		for _, value := range m.HeaderValues(name) {
+			if list, err := mail.ParseAddressList(value); err == nil {
+				addresses = append(addresses, list...)
+			}
+		}
+	}
+
+	return addresses
+}
+
+func (m filterMessage) Size() int64 {
+	return m.msg.Size
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetFilters(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	require.Error(t, m.store.SetFilters(`discard;`))
+
+	script := `if header :contains "subject" "invoice" { fileinto "Archive"; }`
+	require.NoError(t, m.store.SetFilters(script))
+	saved, err := m.store.GetFilters()
+	require.NoError(t, err)
+	require.Equal(t, script, saved)
+
+	require.NoError(t, m.store.SetFilters(""))
+	saved, err = m.store.GetFilters()
+	require.NoError(t, err)
+	require.Equal(t, "", saved)
+	require.Nil(t, m.store.filters)
+}
+
+func TestApplyFilters(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	require.NoError(t, m.store.SetFilters(`
+		if address :domain "from" "company.com" { addflag "\\Seen"; }
+		if header :contains "subject" "invoice" { fileinto "Archive"; stop; }
+		addflag "\\Flagged";
+	`))
+
+	msg := getTestMessage("msg1", "Invoice 12", "boss@company.com", 1, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	msg.Flags = pmapi.FlagReceived
+
+	done := make(chan struct{})
+	m.client.EXPECT().MarkMessagesRead([]string{"msg1"})
+	m.client.EXPECT().LabelMessages([]string{"msg1"}, pmapi.ArchiveLabel).Do(func(_ []string, _ string) { close(done) })
+	m.store.queueFilters(msg)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "filter actions were not applied")
+	}
+
+	// Sent messages and messages not in inbox are not filtered.
+	msg.Flags = pmapi.FlagSent
+	require.Empty(t, m.store.evaluateFilters(msg))
+
+	msg.Flags = pmapi.FlagReceived
+	msg.LabelIDs = []string{pmapi.AllMailLabel, pmapi.ArchiveLabel}
+	require.Empty(t, m.store.evaluateFilters(msg))
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import "strings"
+
+// GetFooter returns the plain text and HTML footer appended to every
+// message sent through SMTP. Empty values mean no footer.
+func (store *Store) GetFooter() (plain, html string) {
+	return store.getStringSetting(footerPlainKey, ""), store.getStringSetting(footerHTMLKey, "")
+}
+
+// SetFooter sets the footer, e.g. a legal disclaimer, appended to every
+// sent message. When only one variant is set, the other one is derived
+// from it while sending. Empty values turn the footer off.
+func (store *Store) SetFooter(plain, html string) error {
+	if err := store.setStringSetting(footerPlainKey, strings.TrimSpace(plain), ""); err != nil {
+		return err
+	}
+	return store.setStringSetting(footerHTMLKey, strings.TrimSpace(html), "")
+}
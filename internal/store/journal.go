@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// journalEntry records messages whose metadata was written but which might
+// not be in the mailbox buckets yet. It is written in the same transaction
+// as the metadata and removed in the same transaction as mailboxes are
+// updated, so a leftover entry means the second transaction never finished.
+type journalEntry struct {
+	MessageIDs []string
+}
+
+func (store *Store) txBeginJournal(tx *bolt.Tx, msgs []*pmapi.Message) ([]byte, error) {
+	b := tx.Bucket(journalBucket)
+
+	seq, err := b.NextSequence()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get journal sequence")
+	}
+
+	entry := journalEntry{MessageIDs: make([]string, 0, len(msgs))}
+	for _, msg := range msgs {
+		entry.MessageIDs = append(entry.MessageIDs, msg.ID)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot marshal journal entry")
+	}
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+
+	return key, b.Put(key, data)
+}
+
+func txCommitJournal(tx *bolt.Tx, key []byte) error {
+	return tx.Bucket(journalBucket).Delete(key)
+}
+
+// recoverStore finishes writes interrupted by a crash or power loss and
+// removes references to messages without metadata from mailboxes, so the
+// store stays usable without a full resync.
+func (store *Store) recoverStore() error {
+	// Mailbox status cannot be counted with dangling messages, therefore
+	// they have to be removed before the journal is replayed.
+	if err := store.removeDanglingMessages(); err != nil {
+		return errors.Wrap(err, "cannot remove dangling messages")
+	}
+
+	if err := store.replayJournal(); err != nil {
+		return errors.Wrap(err, "cannot replay journal")
+	}
+
+	return nil
+}
+
+func (store *Store) replayJournal() error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(journalBucket)
+
+		var keys [][]byte
+		var apiIDs []string
+		err := b.ForEach(func(k, v []byte) error {
+			var entry journalEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				store.log.WithError(err).Warn("Skipping corrupted journal entry")
+			}
+			keys = append(keys, append([]byte{}, k...))
+			apiIDs = append(apiIDs, entry.MessageIDs...)
+			return nil
+		})
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+
+		store.log.WithField("entries", len(keys)).Warn("Store was not closed cleanly, replaying journal")
+
+		msgs := []*pmapi.Message{}
+		for _, apiID := range apiIDs {
+			msg, err := store.txGetMessage(tx, apiID)
+			if err != nil {
+				continue
+			}
+			msgs = append(msgs, msg)
+		}
+
+		for _, a := range store.addresses {
+			if err := a.txCreateOrUpdateMessages(tx, msgs); err != nil {
+				return err
+			}
+		}
+
+		for _, key := range keys {
+			if err := txCommitJournal(tx, key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// removeDanglingMessages deletes messages from mailbox buckets which are
+// not present in metadata. Such state cannot be repaired by the event loop
+// and previously required a full resync.
+func (store *Store) removeDanglingMessages() error {
+	dangling := map[*Mailbox][]string{}
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		metaBucket := tx.Bucket(metadataBucket)
+
+		for _, a := range store.addresses {
+			for _, mbx := range a.mailboxes {
+				err := mbx.txGetAPIIDsBucket(tx).ForEach(func(k, _ []byte) error {
+					if metaBucket.Get(k) == nil {
+						dangling[mbx] = append(dangling[mbx], string(k))
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil || len(dangling) == 0 {
+		return err
+	}
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+		for mbx, apiIDs := range dangling {
+			mbx.log.WithField("count", len(apiIDs)).Warn("Removing messages without metadata from mailbox")
+
+			for _, apiID := range apiIDs {
+				if err := mbx.txDeleteMessage(tx, apiID); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestRecoverStore(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+
+	// Simulate crash after metadata were written but before mailboxes were
+	// updated, and metadata lost for message still present in mailboxes.
+	msg2 := getTestMessage("msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	clearNonMetadata(msg2)
+	require.NoError(t, m.store.db.Update(func(tx *bolt.Tx) error {
+		if err := m.store.txPutMessage(tx.Bucket(metadataBucket), msg2); err != nil {
+			return err
+		}
+		if _, err := m.store.txBeginJournal(tx, []*pmapi.Message{msg2}); err != nil {
+			return err
+		}
+		return tx.Bucket(metadataBucket).Delete([]byte("msg1"))
+	}))
+
+	require.NoError(t, m.store.recoverStore())
+
+	checkAllMessageIDs(t, m, []string{"msg2"})
+	checkMailboxMessageIDs(t, m, pmapi.InboxLabel, []wantID{{"msg2", 2}})
+	checkMailboxMessageIDs(t, m, pmapi.AllMailLabel, []wantID{{"msg2", 2}})
+
+	require.NoError(t, m.store.db.View(func(tx *bolt.Tx) error {
+		require.Equal(t, 0, tx.Bucket(journalBucket).Stats().KeyN)
+		return nil
+	}))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import "sync/atomic"
+
+var lowResourceMode int32 //nolint[gochecknoglobals]
+
+// lowResourceSyncOptions are the highest options used in low-resource mode.
+func lowResourceSyncOptions() SyncOptions {
+	return SyncOptions{
+		PagesInFlight: 2,
+		PageSize:      50,
+		BodyWorkers:   4,
+	}
+}
+
+// SetLowResourceMode makes stores friendly to low-power devices such as
+// ARM single-board computers: sync and fetch run with few workers and
+// mailbox counts are not recalculated in the background. It should be
+// set before sync options are.
+func SetLowResourceMode(enabled bool) {
+	value := int32(0)
+	if enabled {
+		value = 1
+	}
+
+	log.WithField("enabled", enabled).Info("Setting low-resource mode")
+	atomic.StoreInt32(&lowResourceMode, value)
+}
+
+// IsLowResourceMode returns whether the low-resource mode is enabled.
+func IsLowResourceMode() bool {
+	return atomic.LoadInt32(&lowResourceMode) == 1
+}
+
+// capSyncOptions lowers options above the low-resource ones.
+func capSyncOptions(opts SyncOptions) SyncOptions {
+	low := lowResourceSyncOptions()
+	if opts.PagesInFlight > low.PagesInFlight {
+		opts.PagesInFlight = low.PagesInFlight
+	}
+	if opts.PageSize > low.PageSize {
+		opts.PageSize = low.PageSize
+	}
+	if opts.BodyWorkers > low.BodyWorkers {
+		opts.BodyWorkers = low.BodyWorkers
+	}
+	return opts
+}
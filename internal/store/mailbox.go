@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	"github.com/sirupsen/logrus"
@@ -37,6 +38,12 @@ type Mailbox struct {
 	labelName   string
 	color       string
 
+	// filter is set only for virtual mailboxes of saved searches.
+	filter *searchFilter
+
+	status     *mailboxStatus
+	statusLock sync.Mutex
+
 	log *logrus.Entry
 }
 
@@ -142,6 +149,12 @@ func initMailboxBucket(tx *bolt.Tx, bucketName []byte) error {
 	if _, err := bucket.CreateBucketIfNotExists(apiIDsBucket); err != nil {
 		return err
 	}
+	if _, err := bucket.CreateBucketIfNotExists(modSeqsBucket); err != nil {
+		return err
+	}
+	if _, err := bucket.CreateBucketIfNotExists(vanishedBucket); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -161,9 +174,10 @@ func (storeMailbox *Mailbox) Color() string {
 	return storeMailbox.color
 }
 
-// UIDValidity returns the current value of structure version.
+// UIDValidity returns the current value of structure version increased
+// by bumps of the mailbox.
 func (storeMailbox *Mailbox) UIDValidity() uint32 {
-	return storeMailbox.store.getMailboxesVersion()
+	return storeMailbox.store.getMailboxesVersion() + storeMailbox.getUIDValidityBump()
 }
 
 // IsFolder returns whether the mailbox is a folder (has "Folders/" prefix).
@@ -189,6 +203,10 @@ func (storeMailbox *Mailbox) Rename(newName string) error {
 		return fmt.Errorf("cannot rename system mailboxes")
 	}
 
+	if storeMailbox.IsSavedSearch() {
+		return ErrSavedSearchOpNotAllowed
+	}
+
 	if storeMailbox.IsFolder() {
 		if !strings.HasPrefix(newName, UserFoldersPrefix) {
 			return fmt.Errorf("cannot rename folder to non-folder")
@@ -211,7 +229,11 @@ func (storeMailbox *Mailbox) Rename(newName string) error {
 // Delete deletes the mailbox by calling an API.
 // Deletion has to be propagated to all the same mailboxes in all addresses.
 // The propagation is processed by the event loop.
+// Saved search is removed locally right away.
 func (storeMailbox *Mailbox) Delete() error {
+	if storeMailbox.IsSavedSearch() {
+		return storeMailbox.store.RemoveSavedSearch(strings.TrimPrefix(storeMailbox.labelName, SavedSearchesPrefix))
+	}
 	return storeMailbox.storeAddress.deleteMailbox(storeMailbox.labelID)
 }
 
@@ -224,6 +246,9 @@ func (storeMailbox *Mailbox) GetDelimiter() string {
 // This is called from the event loop.
 func (storeMailbox *Mailbox) deleteMailboxEvent() error {
 	return storeMailbox.db().Update(func(tx *bolt.Tx) error {
+		if err := tx.Bucket(mboxVersionBucket).Delete(storeMailbox.getBucketName()); err != nil {
+			return err
+		}
 		return tx.Bucket(mailboxesBucket).DeleteBucket(storeMailbox.getBucketName())
 	})
 }
@@ -0,0 +1,64 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import "fmt"
+
+// IsAutoCreateMailboxesEnabled returns whether folders and labels which
+// clients copy or append messages to are created when they do not exist.
+func (store *Store) IsAutoCreateMailboxesEnabled() bool {
+	return store.getBoolSetting(autoCreateMailboxKey, false)
+}
+
+// SetAutoCreateMailboxesEnabled sets whether missing folders and labels are
+// created on copy or append. Many clients do not handle TRYCREATE well.
+func (store *Store) SetAutoCreateMailboxesEnabled(enabled bool) error {
+	return store.setBoolSetting(autoCreateMailboxKey, enabled, false)
+}
+
+// GetOrCreateMailbox returns the mailbox with the given name. When it does
+// not exist and auto-creating is enabled, it is created first.
+func (storeAddress *Address) GetOrCreateMailbox(name string) (*Mailbox, error) {
+	mailbox, err := storeAddress.GetMailbox(name)
+	if err == nil || !storeAddress.store.IsAutoCreateMailboxesEnabled() {
+		return mailbox, err
+	}
+
+	if err := storeAddress.store.autoCreateMailbox(name); err != nil {
+		return nil, err
+	}
+
+	return storeAddress.GetMailbox(name)
+}
+
+// autoCreateMailbox creates the mailbox via the API and adds it to the store
+// right away instead of waiting for the event, so the client can use it
+// in the same command.
+func (store *Store) autoCreateMailbox(name string) error {
+	label, err := store.createLabel(name)
+	if err != nil {
+		return err
+	}
+	if label == nil {
+		return fmt.Errorf("mailbox %v cannot be created", name)
+	}
+
+	store.log.WithField("name", name).Info("Missing mailbox was created")
+
+	return store.createOrUpdateMailboxEvent(label)
+}
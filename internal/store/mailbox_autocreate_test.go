@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOrCreateMailbox(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	address, err := m.store.GetAddress(addrID1)
+	require.NoError(t, err)
+
+	_, err = address.GetOrCreateMailbox("Folders/New")
+	require.Error(t, err)
+
+	require.NoError(t, m.store.SetAutoCreateMailboxesEnabled(true))
+	require.True(t, m.store.IsAutoCreateMailboxesEnabled())
+
+	m.client.EXPECT().CreateLabel(gomock.Any()).DoAndReturn(func(label *pmapi.Label) (*pmapi.Label, error) {
+		require.Equal(t, "New", label.Name)
+		require.Equal(t, 1, label.Exclusive)
+		label.ID = "folderID"
+		return label, nil
+	})
+
+	mailbox, err := address.GetOrCreateMailbox("Folders/New")
+	require.NoError(t, err)
+	require.Equal(t, "folderID", mailbox.LabelID())
+}
@@ -76,6 +76,77 @@ func (storeMailbox *Mailbox) GetAPIIDsFromSequenceRange(start, stop uint32) (api
 	return
 }
 
+// IterateAPIIDs passes API IDs in the UID range (or in the sequence range
+// when isUID is false) to callback in pages of at most pageSize IDs. Every
+// page is read in its own transaction so that huge mailboxes are never held
+// in memory and a slow client does not block the database. A null stop means
+// no stop. Sequence numbers are counted across pages, therefore a message
+// removed in the meantime shifts the rest of the range.
+func (storeMailbox *Mailbox) IterateAPIIDs(isUID bool, start, stop uint32, pageSize int, callback func(apiIDs []string) error) error {
+	if stop == 0 {
+		stop = ^uint32(0)
+	}
+
+	var lastKey []byte
+	var seqNum uint32
+	done := false
+
+	for !done {
+		var apiIDs []string
+
+		err := storeMailbox.db().View(func(tx *bolt.Tx) error {
+			c := storeMailbox.txGetIMAPIDsBucket(tx).Cursor()
+
+			var k, v []byte
+			switch {
+			case lastKey != nil:
+				if k, v = c.Seek(lastKey); k != nil && bytes.Equal(k, lastKey) {
+					k, v = c.Next()
+				}
+			case isUID:
+				k, v = c.Seek(itob(start))
+			default:
+				k, v = c.First()
+			}
+
+			for ; k != nil && len(apiIDs) < pageSize; k, v = c.Next() {
+				lastKey = append(lastKey[:0], k...)
+				seqNum++
+
+				index := seqNum
+				if isUID {
+					index = btoi(k)
+				}
+				if index < start {
+					continue
+				}
+				if index > stop {
+					done = true
+					return nil
+				}
+				apiIDs = append(apiIDs, string(v))
+			}
+
+			if k == nil {
+				done = true
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(apiIDs) == 0 {
+			continue
+		}
+		if err := callback(apiIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetLatestAPIID returns the latest message API ID which still exists.
 // Info: not the latest IMAP UID which can be already removed.
 func (storeMailbox *Mailbox) GetLatestAPIID() (apiID string, err error) {
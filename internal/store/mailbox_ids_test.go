@@ -50,6 +50,43 @@ func TestGetSequenceNumberAndGetUID(t *testing.T) {
 	checkMailboxMessageIDs(t, m, pmapi.AllMailLabel, []wantID{{"msg1", 1}, {"msg2", 2}, {"msg3", 3}, {"msg4", 4}})
 }
 
+func TestIterateAPIIDs(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg3", "Test message 3", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg4", "Test message 4", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg5", "Test message 5", addrID1, 0, []string{pmapi.AllMailLabel})
+	require.NoError(t, m.store.deleteMessageEvent("msg2"))
+
+	storeMailbox := m.store.addresses[addrID1].mailboxes[pmapi.AllMailLabel]
+
+	tests := []struct {
+		isUID       bool
+		start, stop uint32
+		wantPages   [][]string
+	}{
+		{false, 1, 0, [][]string{{"msg1", "msg3"}, {"msg4", "msg5"}}},
+		{false, 2, 3, [][]string{{"msg3", "msg4"}}},
+		{true, 1, 0, [][]string{{"msg1", "msg3"}, {"msg4", "msg5"}}},
+		{true, 2, 4, [][]string{{"msg3", "msg4"}}},
+		{true, 5, 5, [][]string{{"msg5"}}},
+		{true, 6, 0, nil},
+	}
+	for _, tc := range tests {
+		var pages [][]string
+		require.NoError(t, storeMailbox.IterateAPIIDs(tc.isUID, tc.start, tc.stop, 2, func(apiIDs []string) error {
+			pages = append(pages, apiIDs)
+			return nil
+		}))
+		require.Equal(t, tc.wantPages, pages, "uid %v, range %v:%v", tc.isUID, tc.start, tc.stop)
+	}
+}
+
 // checkMailboxMessageIDs checks that the mailbox contains all API IDs with correct sequence numbers and UIDs.
 // wantIDs is map from IMAP UID to API ID. Sequence number is detected automatically by order of the ID in the map.
 func checkMailboxMessageIDs(t *testing.T, m *mocksForStore, mailboxLabel string, wantIDs []wantID) {
@@ -18,13 +18,18 @@
 package store
 
 import (
+	"time"
+
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	bolt "go.etcd.io/bbolt"
 )
 
-var ErrAllMailOpNotAllowed = errors.New("operation not allowed for 'All Mail' folder")
+var (
+	ErrAllMailOpNotAllowed = errors.New("operation not allowed for 'All Mail' folder")
+	ErrSnoozeTimeInPast    = errors.New("cannot snooze message to the past")
+)
 
 // GetMessage returns the `pmapi.Message` struct wrapped in `StoreMessage`
 // tied to this mailbox.
@@ -49,6 +54,10 @@ func (storeMailbox *Mailbox) FetchMessage(apiID string) (*Message, error) {
 // ImportMessage imports the message by calling an API.
 // It has to be propagated to all mailboxes which is done by the event loop.
 func (storeMailbox *Mailbox) ImportMessage(msg *pmapi.Message, body []byte, labelIDs []string) error {
+	if storeMailbox.IsSavedSearch() {
+		return ErrSavedSearchOpNotAllowed
+	}
+
 	defer storeMailbox.pollNow()
 
 	if storeMailbox.labelID != pmapi.AllMailLabel {
@@ -88,7 +97,11 @@ func (storeMailbox *Mailbox) LabelMessages(apiIDs []string) error {
 	if storeMailbox.labelID == pmapi.AllMailLabel {
 		return ErrAllMailOpNotAllowed
 	}
+	if storeMailbox.IsSavedSearch() {
+		return ErrSavedSearchOpNotAllowed
+	}
 	defer storeMailbox.pollNow()
+	storeMailbox.trainSpamFilter(apiIDs)
 	return storeMailbox.client().LabelMessages(apiIDs, storeMailbox.labelID)
 }
 
@@ -104,6 +117,9 @@ func (storeMailbox *Mailbox) UnlabelMessages(apiIDs []string) error {
 	if storeMailbox.labelID == pmapi.AllMailLabel {
 		return ErrAllMailOpNotAllowed
 	}
+	if storeMailbox.IsSavedSearch() {
+		return ErrSavedSearchOpNotAllowed
+	}
 	defer storeMailbox.pollNow()
 	return storeMailbox.client().UnlabelMessages(apiIDs, storeMailbox.labelID)
 }
@@ -170,9 +186,50 @@ func (storeMailbox *Mailbox) MarkMessagesUnstarred(apiIDs []string) error {
 	return storeMailbox.client().UnlabelMessages(apiIDs, pmapi.StarredLabel)
 }
 
+// SnoozeMessages snoozes messages until the given time by calling an API.
+// It has to be propagated to all mailboxes which is done by the event loop.
+func (storeMailbox *Mailbox) SnoozeMessages(apiIDs []string, until time.Time) error {
+	log.WithFields(logrus.Fields{
+		"messages": apiIDs,
+		"label":    storeMailbox.labelID,
+		"mailbox":  storeMailbox.Name,
+		"until":    until,
+	}).Trace("Snoozing messages")
+	if !until.After(time.Now()) {
+		return ErrSnoozeTimeInPast
+	}
+	defer storeMailbox.pollNow()
+	return storeMailbox.client().SnoozeMessages(apiIDs, until.Unix())
+}
+
+// UnsnoozeMessages brings snoozed messages back by calling an API.
+// Messages which are not snoozed are skipped.
+// It has to be propagated to all mailboxes which is done by the event loop.
+func (storeMailbox *Mailbox) UnsnoozeMessages(apiIDs []string) error {
+	log.WithFields(logrus.Fields{
+		"messages": apiIDs,
+		"label":    storeMailbox.labelID,
+		"mailbox":  storeMailbox.Name,
+	}).Trace("Unsnoozing messages")
+
+	ids := []string{}
+	for _, apiID := range apiIDs {
+		if message, _ := storeMailbox.store.getMessageFromDB(apiID); message != nil && message.HasLabelID(pmapi.SnoozedLabel) {
+			ids = append(ids, apiID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	defer storeMailbox.pollNow()
+	return storeMailbox.client().UnsnoozeMessages(ids)
+}
+
 // DeleteMessages deletes messages.
-// If the mailbox is All Mail or All Sent, it does nothing.
+// If the mailbox is All Mail, All Sent or a saved search, it does nothing.
 // If the mailbox is Trash or Spam and message is not in any other mailbox, messages is deleted.
+// A copy of such message is kept for a while to be recovered, see Tombstone.
 // In all other cases the message is only removed from the mailbox.
 func (storeMailbox *Mailbox) DeleteMessages(apiIDs []string) error {
 	log.WithFields(logrus.Fields{
@@ -182,6 +239,26 @@ func (storeMailbox *Mailbox) DeleteMessages(apiIDs []string) error {
 	}).Trace("Deleting messages")
 	defer storeMailbox.pollNow()
 
+	if storeMailbox.IsSavedSearch() {
+		return nil
+	}
+
+	switch storeMailbox.store.GetExpungePolicy() {
+	case ExpungePolicyDeferred:
+		return storeMailbox.deferDeletion(apiIDs)
+	case ExpungePolicyTrash:
+		if storeMailbox.shouldMoveToTrash() {
+			return storeMailbox.client().LabelMessages(apiIDs, pmapi.TrashLabel)
+		}
+	case ExpungePolicyDelete:
+	}
+
+	return storeMailbox.deleteMessages(apiIDs)
+}
+
+// deleteMessages removes messages from the mailbox or deletes them
+// permanently as described in DeleteMessages.
+func (storeMailbox *Mailbox) deleteMessages(apiIDs []string) error {
 	switch storeMailbox.labelID {
 	case pmapi.AllMailLabel, pmapi.AllSentLabel:
 		break
@@ -215,6 +292,7 @@ func (storeMailbox *Mailbox) DeleteMessages(apiIDs []string) error {
 			}
 		}
 		if len(messageIDsToDelete) > 0 {
+			storeMailbox.store.createTombstones(messageIDsToDelete)
 			if err := storeMailbox.client().DeleteMessages(messageIDsToDelete); err != nil {
 				return err
 			}
@@ -253,6 +331,12 @@ func (storeMailbox *Mailbox) txSkipAndRemoveFromMailbox(tx *bolt.Tx, msg *pmapi.
 		return
 	}
 
+	// Virtual mailbox contains all messages matching the saved search.
+	if storeMailbox.IsSavedSearch() {
+		skipAndRemove = !storeMailbox.filter.matches(msg)
+		return
+	}
+
 	// If the message belongs in this mailbox, don't skip/remove it.
 	for _, labelID := range msg.LabelIDs {
 		if labelID == storeMailbox.labelID {
@@ -291,6 +375,9 @@ func (storeMailbox *Mailbox) txCreateOrUpdateMessages(tx *bolt.Tx, msgs []*pmapi
 			uidb := apiBucket.Get([]byte(msg.ID))
 
 			if uidb != nil {
+				if err := storeMailbox.txBumpModSeq(tx, uidb); err != nil {
+					return err
+				}
 				if imapBucket == nil {
 					imapBucket = storeMailbox.txGetIMAPIDsBucket(tx)
 				}
@@ -312,7 +399,7 @@ func (storeMailbox *Mailbox) txCreateOrUpdateMessages(tx *bolt.Tx, msgs []*pmapi
 		if imapBucket == nil {
 			imapBucket = storeMailbox.txGetIMAPIDsBucket(tx)
 		}
-		uid, err := storeMailbox.txGetNextUID(imapBucket, true)
+		uid, err := storeMailbox.txGetUIDForNewMessage(tx, imapBucket, msg.ID)
 		if err != nil {
 			return errors.Wrap(err, "cannot generate new UID")
 		}
@@ -324,6 +411,9 @@ func (storeMailbox *Mailbox) txCreateOrUpdateMessages(tx *bolt.Tx, msgs []*pmapi
 		if err = apiBucket.Put([]byte(msg.ID), uidb); err != nil {
 			return errors.Wrap(err, "cannot add to API bucket")
 		}
+		if err = storeMailbox.txBumpModSeq(tx, uidb); err != nil {
+			return err
+		}
 
 		seqNum, err := storeMailbox.txGetSequenceNumberOfUID(imapBucket, uidb)
 		if err != nil {
@@ -340,6 +430,7 @@ func (storeMailbox *Mailbox) txCreateOrUpdateMessages(tx *bolt.Tx, msgs []*pmapi
 	}
 
 	if shouldSendMailboxUpdate {
+		storeMailbox.store.txInvalidateMailboxStatus(tx, storeMailbox.labelID)
 		if err := storeMailbox.txMailboxStatusUpdate(tx); err != nil {
 			return err
 		}
@@ -357,6 +448,7 @@ func (storeMailbox *Mailbox) txDeleteMessage(tx *bolt.Tx, apiID string) error {
 	if uidb == nil {
 		return nil
 	}
+	storeMailbox.store.txInvalidateMailboxStatus(tx, storeMailbox.labelID)
 
 	imapBucket := storeMailbox.txGetIMAPIDsBucket(tx)
 
@@ -365,6 +457,10 @@ func (storeMailbox *Mailbox) txDeleteMessage(tx *bolt.Tx, apiID string) error {
 		storeMailbox.log.WithField("apiID", apiID).WithError(seqNumErr).Warn("Cannot get seqNum of deleting message")
 	}
 
+	if err := storeMailbox.txMarkVanished(tx, uidb); err != nil {
+		return errors.Wrap(err, "cannot mark message as vanished")
+	}
+
 	if err := imapBucket.Delete(uidb); err != nil {
 		return errors.Wrap(err, "cannot delete from IMAP bucket")
 	}
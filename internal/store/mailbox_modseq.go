@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Mod-sequences (RFC 7162) are counted per mailbox by the sequence of its
+// mod_seqs bucket. Every change of a message in the mailbox assigns it the
+// next value; messages without a record were not changed since the store
+// started tracking them and have the lowest mod-sequence.
+const lowestModSeq = uint64(1)
+
+func modSeqToBytes(modSeq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, modSeq)
+	return b
+}
+
+func bytesToModSeq(b []byte) uint64 {
+	if len(b) != 8 {
+		return lowestModSeq
+	}
+	return binary.BigEndian.Uint64(b)
+}
+
+// txGetModSeqsBucket returns the bucket mapping IMAP UID to mod-sequence.
+func (storeMailbox *Mailbox) txGetModSeqsBucket(tx *bolt.Tx) *bolt.Bucket {
+	return storeMailbox.txGetBucket(tx).Bucket(modSeqsBucket)
+}
+
+// txGetVanishedBucket returns the bucket mapping IMAP UID of removed message
+// to the mod-sequence of the removal.
+func (storeMailbox *Mailbox) txGetVanishedBucket(tx *bolt.Tx) *bolt.Bucket {
+	return storeMailbox.txGetBucket(tx).Bucket(vanishedBucket)
+}
+
+// txBumpModSeq assigns the next mod-sequence of the mailbox to the message.
+func (storeMailbox *Mailbox) txBumpModSeq(tx *bolt.Tx, uidb []byte) error {
+	b := storeMailbox.txGetModSeqsBucket(tx)
+	modSeq, err := b.NextSequence()
+	if err != nil {
+		return errors.Wrap(err, "cannot generate mod-sequence")
+	}
+	return b.Put(uidb, modSeqToBytes(lowestModSeq+modSeq))
+}
+
+// txMarkVanished remembers the removal of the message for clients
+// resynchronizing by QRESYNC.
+func (storeMailbox *Mailbox) txMarkVanished(tx *bolt.Tx, uidb []byte) error {
+	modSeqs := storeMailbox.txGetModSeqsBucket(tx)
+	modSeq, err := modSeqs.NextSequence()
+	if err != nil {
+		return errors.Wrap(err, "cannot generate mod-sequence")
+	}
+	if err := modSeqs.Delete(uidb); err != nil {
+		return err
+	}
+	return storeMailbox.txGetVanishedBucket(tx).Put(uidb, modSeqToBytes(lowestModSeq+modSeq))
+}
+
+// GetHighestModSeq returns the highest mod-sequence of the mailbox.
+func (storeMailbox *Mailbox) GetHighestModSeq() (modSeq uint64, err error) {
+	err = storeMailbox.db().View(func(tx *bolt.Tx) error {
+		modSeq = lowestModSeq + storeMailbox.txGetModSeqsBucket(tx).Sequence()
+		return nil
+	})
+	return
+}
+
+// GetModSeq returns the mod-sequence of the message.
+func (storeMailbox *Mailbox) GetModSeq(apiID string) (modSeq uint64, err error) {
+	err = storeMailbox.db().View(func(tx *bolt.Tx) error {
+		uidb := storeMailbox.txGetAPIIDsBucket(tx).Get([]byte(apiID))
+		if uidb == nil {
+			return ErrNoSuchAPIID
+		}
+		modSeq = bytesToModSeq(storeMailbox.txGetModSeqsBucket(tx).Get(uidb))
+		return nil
+	})
+	return
+}
+
+// GetVanishedUIDs returns UIDs of messages removed from the mailbox after
+// the mod-sequence in increasing order.
+func (storeMailbox *Mailbox) GetVanishedUIDs(sinceModSeq uint64) (uids []uint32, err error) {
+	err = storeMailbox.db().View(func(tx *bolt.Tx) error {
+		return storeMailbox.txGetVanishedBucket(tx).ForEach(func(k, v []byte) error {
+			if bytesToModSeq(v) > sinceModSeq {
+				uids = append(uids, btoi(k))
+			}
+			return nil
+		})
+	})
+	return
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModSeq(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	storeMailbox := m.store.addresses[addrID1].mailboxes[pmapi.AllMailLabel]
+
+	highest, err := storeMailbox.GetHighestModSeq()
+	require.NoError(t, err)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel})
+
+	modSeq1, err := storeMailbox.GetModSeq("msg1")
+	require.NoError(t, err)
+	modSeq2, err := storeMailbox.GetModSeq("msg2")
+	require.NoError(t, err)
+	require.True(t, highest < modSeq1 && modSeq1 < modSeq2)
+
+	// Flag change is a new modification.
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 1, []string{pmapi.AllMailLabel})
+	modSeq1, err = storeMailbox.GetModSeq("msg1")
+	require.NoError(t, err)
+	require.True(t, modSeq1 > modSeq2)
+
+	highest, err = storeMailbox.GetHighestModSeq()
+	require.NoError(t, err)
+	require.Equal(t, modSeq1, highest)
+
+	require.NoError(t, m.store.deleteMessageEvent("msg2"))
+
+	vanished, err := storeMailbox.GetVanishedUIDs(highest)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{2}, vanished)
+
+	highest, err = storeMailbox.GetHighestModSeq()
+	require.NoError(t, err)
+	vanished, err = storeMailbox.GetVanishedUIDs(highest)
+	require.NoError(t, err)
+	require.Empty(t, vanished)
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// mailboxStatus holds values needed for IMAP STATUS of one mailbox.
+type mailboxStatus struct {
+	version      uint64
+	total        uint
+	unread       uint
+	unseenSeqNum uint
+	uidNext      uint32
+}
+
+// Bounds and defaults of the background recalculation of mailbox counts.
+const (
+	defaultCountsRecalculationInterval = time.Minute
+	minCountsRecalculationInterval     = defaultPollInterval
+	maxCountsRecalculationInterval     = 24 * time.Hour
+
+	// countsRecalculationIdleTime is how long no message may change before
+	// the recalculation runs so it does not compete with sync or bursts of events.
+	countsRecalculationIdleTime = 10 * time.Second
+)
+
+var countsRecalculationInterval = int64(defaultCountsRecalculationInterval) //nolint[gochecknoglobals]
+
+// SetCountsRecalculationInterval changes how often counts of changed
+// mailboxes are recalculated in the background. Zero means the default.
+func SetCountsRecalculationInterval(interval time.Duration) {
+	switch {
+	case interval == 0:
+		interval = defaultCountsRecalculationInterval
+	case interval < minCountsRecalculationInterval:
+		interval = minCountsRecalculationInterval
+	case interval > maxCountsRecalculationInterval:
+		interval = maxCountsRecalculationInterval
+	}
+
+	log.WithField("interval", interval).Info("Setting counts recalculation interval")
+	atomic.StoreInt64(&countsRecalculationInterval, int64(interval))
+}
+
+func getCountsRecalculationInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&countsRecalculationInterval))
+}
+
+// txInvalidateMailboxStatus invalidates cached statuses of mailboxes with
+// given labels once the transaction is committed.
+func (store *Store) txInvalidateMailboxStatus(tx *bolt.Tx, labelIDs ...string) {
+	tx.OnCommit(func() {
+		store.statusLock.Lock()
+		defer store.statusLock.Unlock()
+
+		for _, labelID := range labelIDs {
+			store.statusVersions[labelID]++
+		}
+		store.lastMessageChange = time.Now()
+	})
+}
+
+func (store *Store) getStatusVersion(labelID string) uint64 {
+	store.statusLock.Lock()
+	defer store.statusLock.Unlock()
+
+	return store.statusVersions[labelID]
+}
+
+// recalculateCountsIfDue recalculates counts of changed mailboxes when the
+// interval since the last recalculation has passed and the store is idle.
+func (store *Store) recalculateCountsIfDue() {
+	if IsLowResourceMode() {
+		return
+	}
+
+	store.statusLock.Lock()
+	isDue := time.Since(store.lastCountsRecalculation) >= getCountsRecalculationInterval()
+	isIdle := time.Since(store.lastMessageChange) >= countsRecalculationIdleTime
+	store.statusLock.Unlock()
+
+	if !isDue || !isIdle {
+		return
+	}
+
+	store.lock.RLock()
+	isSyncRunning := store.isSyncRunning
+	store.lock.RUnlock()
+
+	if isSyncRunning {
+		return
+	}
+
+	store.recalculateCounts()
+
+	store.statusLock.Lock()
+	store.lastCountsRecalculation = time.Now()
+	store.statusLock.Unlock()
+}
+
+// recalculateCounts computes statuses of mailboxes changed since the last
+// calculation, so clients polling STATUS get the answer without scanning
+// the database. Unchanged mailboxes are skipped.
+func (store *Store) recalculateCounts() {
+	store.lock.RLock()
+	var mailboxes []*Mailbox
+	for _, a := range store.addresses {
+		for _, m := range a.mailboxes {
+			mailboxes = append(mailboxes, m)
+		}
+	}
+	store.lock.RUnlock()
+
+	recalculated := 0
+	for _, m := range mailboxes {
+		if !m.isStatusStale() {
+			continue
+		}
+		if _, _, _, _, err := m.GetStatus(); err != nil {
+			m.log.WithError(err).Warn("Cannot recalculate mailbox counts")
+			continue
+		}
+		recalculated++
+	}
+
+	store.log.WithField("mailboxes", recalculated).Debug("Mailbox counts recalculated")
+}
+
+func (storeMailbox *Mailbox) isStatusStale() bool {
+	version := storeMailbox.store.getStatusVersion(storeMailbox.labelID)
+
+	storeMailbox.statusLock.Lock()
+	defer storeMailbox.statusLock.Unlock()
+
+	return storeMailbox.status == nil || storeMailbox.status.version != version
+}
+
+// GetStatus returns counts and the next UID of the mailbox. Values are cached
+// until a message in the store changes.
+func (storeMailbox *Mailbox) GetStatus() (total, unread, unseenSeqNum uint, uidNext uint32, err error) {
+	version := storeMailbox.store.getStatusVersion(storeMailbox.labelID)
+
+	storeMailbox.statusLock.Lock()
+	defer storeMailbox.statusLock.Unlock()
+
+	status := storeMailbox.status
+	if status == nil || status.version != version {
+		if status, err = storeMailbox.getStatusFromDB(version); err != nil {
+			return
+		}
+		storeMailbox.status = status
+	}
+
+	return status.total, status.unread, status.unseenSeqNum, status.uidNext, nil
+}
+
+func (storeMailbox *Mailbox) getStatusFromDB(version uint64) (status *mailboxStatus, err error) {
+	status = &mailboxStatus{version: version}
+	err = storeMailbox.db().View(func(tx *bolt.Tx) error {
+		var err error
+		if status.total, status.unread, status.unseenSeqNum, err = storeMailbox.txGetCounts(tx); err != nil {
+			return err
+		}
+		status.uidNext, err = storeMailbox.txGetNextUID(storeMailbox.txGetIMAPIDsBucket(tx), false)
+		return err
+	})
+	return
+}
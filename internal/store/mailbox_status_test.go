@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailboxStatusCache(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+	inbox := m.store.addresses[addrID1].mailboxes[pmapi.InboxLabel]
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 1, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	checkMailboxStatus(t, inbox, 1, 1, 2)
+
+	// Cached value is returned as long as nothing changes.
+	version := inbox.status.version
+	checkMailboxStatus(t, inbox, 1, 1, 2)
+	require.Equal(t, version, inbox.status.version)
+
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	checkMailboxStatus(t, inbox, 2, 1, 3)
+
+	require.NoError(t, m.store.deleteMessageEvent("msg1"))
+	checkMailboxStatus(t, inbox, 1, 0, 3)
+}
+
+func TestMailboxStatusInvalidatedOnlyForChangedLabels(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+	inbox := m.store.addresses[addrID1].mailboxes[pmapi.InboxLabel]
+	sent := m.store.addresses[addrID1].mailboxes[pmapi.SentLabel]
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 1, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	checkMailboxStatus(t, inbox, 1, 1, 2)
+	checkMailboxStatus(t, sent, 0, 0, 1)
+
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.SentLabel})
+	require.False(t, inbox.isStatusStale())
+	require.True(t, sent.isStatusStale())
+
+	// Recalculation waits until the store is idle.
+	m.store.statusLock.Lock()
+	m.store.lastCountsRecalculation = time.Time{}
+	m.store.statusLock.Unlock()
+	m.store.recalculateCountsIfDue()
+	require.True(t, sent.isStatusStale())
+
+	m.store.statusLock.Lock()
+	m.store.lastMessageChange = m.store.lastMessageChange.Add(-countsRecalculationIdleTime)
+	m.store.statusLock.Unlock()
+	m.store.recalculateCountsIfDue()
+	require.False(t, sent.isStatusStale())
+	checkMailboxStatus(t, sent, 1, 0, 2)
+}
+
+func checkMailboxStatus(t *testing.T, mailbox *Mailbox, wantTotal, wantUnread uint, wantUIDNext uint32) {
+	total, unread, _, uidNext, err := mailbox.GetStatus()
+	require.NoError(t, err)
+	require.Equal(t, wantTotal, total)
+	require.Equal(t, wantUnread, unread)
+	require.Equal(t, wantUIDNext, uidNext)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// BumpUIDValidity changes UIDVALIDITY of the mailbox with the given name in
+// all addresses and assigns new UIDs to its messages. Clients then download
+// only this mailbox again, which is enough when its UIDs are suspected to be
+// wrong and removing the whole cache would be too much.
+func (store *Store) BumpUIDValidity(mailboxName string) error {
+	var mailboxes []*Mailbox
+	for _, storeAddress := range store.addresses {
+		if storeMailbox, err := storeAddress.GetMailbox(mailboxName); err == nil {
+			mailboxes = append(mailboxes, storeMailbox)
+		}
+	}
+	if len(mailboxes) == 0 {
+		return errors.Errorf("mailbox %s does not exist", mailboxName)
+	}
+
+	for _, storeMailbox := range mailboxes {
+		if err := storeMailbox.bumpUIDValidity(); err != nil {
+			return errors.Wrap(err, "failed to bump UIDVALIDITY")
+		}
+	}
+	return nil
+}
+
+// bumpUIDValidity increases the mailbox's own part of UIDVALIDITY and
+// recreates its UIDs from the metadata bucket.
+func (storeMailbox *Mailbox) bumpUIDValidity() error {
+	storeMailbox.log.Info("Bumping UIDVALIDITY")
+
+	return storeMailbox.db().Update(func(tx *bolt.Tx) error {
+		bump := storeMailbox.txGetUIDValidityBump(tx)
+		if err := storeMailbox.txSetUIDValidityBump(tx, bump+1); err != nil {
+			return err
+		}
+
+		if err := txTruncateMailboxBucket(storeMailbox.txGetBucket(tx)); err != nil {
+			return err
+		}
+
+		msgs := []*pmapi.Message{}
+		err := tx.Bucket(metadataBucket).ForEach(func(k, v []byte) error {
+			msg := &pmapi.Message{}
+			if err := json.Unmarshal(v, msg); err != nil {
+				return err
+			}
+			msgs = append(msgs, msg)
+
+			// Same batching as when all mailboxes are rebuilt.
+			if len(msgs) == 10000 {
+				if err := storeMailbox.txCreateOrUpdateMessages(tx, msgs); err != nil {
+					return err
+				}
+				msgs = []*pmapi.Message{}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := storeMailbox.txCreateOrUpdateMessages(tx, msgs); err != nil {
+			return err
+		}
+
+		storeMailbox.store.txInvalidateMailboxStatus(tx, storeMailbox.labelID)
+		return nil
+	})
+}
+
+// txGetUIDValidityBump returns how many times UIDVALIDITY of the mailbox was
+// bumped. It is added to the version of all mailboxes.
+func (storeMailbox *Mailbox) txGetUIDValidityBump(tx *bolt.Tx) uint32 {
+	if bump := tx.Bucket(mboxVersionBucket).Get(storeMailbox.getBucketName()); bump != nil {
+		return btoi(bump)
+	}
+	return 0
+}
+
+func (storeMailbox *Mailbox) txSetUIDValidityBump(tx *bolt.Tx, bump uint32) error {
+	return tx.Bucket(mboxVersionBucket).Put(storeMailbox.getBucketName(), itob(bump))
+}
+
+func (storeMailbox *Mailbox) getUIDValidityBump() (bump uint32) {
+	_ = storeMailbox.db().View(func(tx *bolt.Tx) error {
+		bump = storeMailbox.txGetUIDValidityBump(tx)
+		return nil
+	})
+	return
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBumpUIDValidity(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	require.NoError(t, m.store.deleteMessageEvent("msg1"))
+	insertMessage(t, m, "msg3", "Test message 3", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+
+	inbox := m.store.addresses[addrID1].mailboxes[pmapi.InboxLabel]
+	allMail := m.store.addresses[addrID1].mailboxes[pmapi.AllMailLabel]
+	inboxUIDValidity := inbox.UIDValidity()
+	allMailUIDValidity := allMail.UIDValidity()
+
+	require.NoError(t, m.store.BumpUIDValidity("INBOX"))
+	require.Error(t, m.store.BumpUIDValidity("Folders/Missing"))
+
+	require.Equal(t, inboxUIDValidity+1, inbox.UIDValidity())
+	require.Equal(t, allMailUIDValidity, allMail.UIDValidity())
+
+	apiIDs, err := inbox.GetAPIIDsFromUIDRange(1, 2)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"msg2", "msg3"}, apiIDs)
+
+	uid, err := allMail.getUID("msg3")
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), uid)
+}
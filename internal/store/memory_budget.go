@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import "sync"
+
+// syncPageMessageSize is the estimated memory taken by metadata of one message
+// while the synced page is processed.
+const syncPageMessageSize = 8 * 1024
+
+// memoryBudget limits memory taken by buffers of sync and FETCH pipelines.
+// Who needs more memory than is left waits until others release theirs.
+type memoryBudget struct {
+	lock  sync.Mutex
+	freed *sync.Cond
+	limit int64 // Zero means no limit.
+	used  int64
+}
+
+var budget = newMemoryBudget() //nolint[gochecknoglobals]
+
+func newMemoryBudget() *memoryBudget {
+	b := &memoryBudget{}
+	b.freed = sync.NewCond(&b.lock)
+	return b
+}
+
+// SetMemoryBudget sets the limit of memory in bytes used by buffers when
+// syncing and building messages. Zero disables the limit.
+func SetMemoryBudget(limit int64) {
+	if limit < 0 {
+		limit = 0
+	}
+
+	log.WithField("limit", limit).Info("Setting memory budget")
+
+	budget.lock.Lock()
+	budget.limit = limit
+	budget.lock.Unlock()
+
+	budget.freed.Broadcast()
+}
+
+// GetMemoryBudget returns the limit set by SetMemoryBudget.
+func GetMemoryBudget() int64 {
+	budget.lock.Lock()
+	defer budget.lock.Unlock()
+
+	return budget.limit
+}
+
+// AcquireMemory blocks until the size fits in the memory budget and reserves it.
+// Buffer bigger than whole budget is let through once nothing else is reserved.
+// Every call must be followed by ReleaseMemory with the same size.
+func AcquireMemory(size int64) {
+	budget.lock.Lock()
+	defer budget.lock.Unlock()
+
+	for budget.limit > 0 && budget.used > 0 && budget.used+size > budget.limit {
+		budget.freed.Wait()
+	}
+
+	budget.used += size
+}
+
+// ReleaseMemory returns memory reserved by AcquireMemory back to the budget.
+func ReleaseMemory(size int64) {
+	budget.lock.Lock()
+	budget.used -= size
+	budget.lock.Unlock()
+
+	budget.freed.Broadcast()
+}
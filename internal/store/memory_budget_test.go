@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryBudgetBlocksUntilReleased(t *testing.T) {
+	SetMemoryBudget(100)
+	defer SetMemoryBudget(0)
+
+	AcquireMemory(60)
+
+	acquired := make(chan struct{})
+	go func() {
+		AcquireMemory(60)
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("memory over budget was acquired")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	ReleaseMemory(60)
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("memory was not acquired after release")
+	}
+	ReleaseMemory(60)
+}
+
+func TestMemoryBudgetLetsThroughBigBuffer(t *testing.T) {
+	SetMemoryBudget(100)
+	defer SetMemoryBudget(0)
+
+	// Buffer bigger than the whole budget would wait forever otherwise.
+	AcquireMemory(500)
+	ReleaseMemory(500)
+
+	SetMemoryBudget(0)
+	AcquireMemory(500)
+	AcquireMemory(500)
+	ReleaseMemory(1000)
+
+	require.Equal(t, int64(0), GetMemoryBudget())
+}
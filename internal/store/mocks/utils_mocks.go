@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	listener "github.com/ProtonMail/proton-bridge/pkg/listener"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
 	time "time"
@@ -104,3 +105,15 @@ func (mr *MockListenerMockRecorder) SetLimit(arg0, arg1 interface{}) *gomock.Cal
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLimit", reflect.TypeOf((*MockListener)(nil).SetLimit), arg0, arg1)
 }
+
+// SetPriority mocks base method
+func (m *MockListener) SetPriority(arg0 string, arg1 listener.Priority) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPriority", arg0, arg1)
+}
+
+// SetPriority indicates an expected call of SetPriority
+func (mr *MockListenerMockRecorder) SetPriority(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPriority", reflect.TypeOf((*MockListener)(nil).SetPriority), arg0, arg1)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Problem is the last error which happened while syncing or building
+// the message. It is kept until the message is built successfully or deleted.
+type Problem struct {
+	MessageID string
+	Mailbox   string
+	Error     string
+	Time      time.Time
+	Skipped   bool `json:"-"`
+}
+
+// RecordProblem stores the error of the message so it can be listed later.
+// Failure is only logged as it must not break the operation which failed.
+func (store *Store) RecordProblem(messageID, mailbox string, problemErr error) {
+	raw, err := json.Marshal(&Problem{
+		MessageID: messageID,
+		Mailbox:   mailbox,
+		Error:     problemErr.Error(),
+		Time:      time.Now(),
+	})
+	if err == nil {
+		err = store.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(problemsBucket).Put([]byte(messageID), raw)
+		})
+	}
+	if err != nil {
+		store.log.WithError(err).WithField("msgID", messageID).Warn("Cannot record message problem")
+	}
+}
+
+// ClearProblem removes the recorded error of the message, if any.
+// It is called after every successful build, therefore the write
+// transaction is opened only when there is something to remove.
+func (store *Store) ClearProblem(messageID string) {
+	var found bool
+	_ = store.db.View(func(tx *bolt.Tx) error {
+		found = tx.Bucket(problemsBucket).Get([]byte(messageID)) != nil
+		return nil
+	})
+	if !found {
+		return
+	}
+
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(problemsBucket).Delete([]byte(messageID))
+	})
+	if err != nil {
+		store.log.WithError(err).WithField("msgID", messageID).Warn("Cannot clear message problem")
+	}
+}
+
+// ListProblems returns recorded errors of messages, the most recent first.
+func (store *Store) ListProblems() (problems []Problem, err error) {
+	err = store.db.View(func(tx *bolt.Tx) error {
+		skipped := tx.Bucket(skippedBucket)
+		return tx.Bucket(problemsBucket).ForEach(func(k, v []byte) error {
+			var problem Problem
+			if err := json.Unmarshal(v, &problem); err != nil {
+				return err
+			}
+			problem.Skipped = skipped.Get(k) != nil
+			problems = append(problems, problem)
+			return nil
+		})
+	})
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Time.After(problems[j].Time)
+	})
+	return
+}
+
+// IsMessageSkipped returns whether building of the message was turned off
+// by the user because it fails every time.
+func (store *Store) IsMessageSkipped(messageID string) (skipped bool) {
+	err := store.db.View(func(tx *bolt.Tx) error {
+		skipped = tx.Bucket(skippedBucket).Get([]byte(messageID)) != nil
+		return nil
+	})
+	if err != nil {
+		store.log.WithError(err).Warn("Cannot read skipped state")
+	}
+	return
+}
+
+// SetMessageSkipped adds the message to or removes it from the skip-list.
+// Skipped messages are not downloaded and clients get only a placeholder.
+func (store *Store) SetMessageSkipped(messageID string, skipped bool) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(metadataBucket).Get([]byte(messageID)) == nil {
+			return ErrNoSuchAPIID
+		}
+		b := tx.Bucket(skippedBucket)
+		if skipped {
+			return b.Put([]byte(messageID), []byte{})
+		}
+		return b.Delete([]byte(messageID))
+	})
+}
+
+// txDeleteProblem forgets everything about the deleted message.
+func txDeleteProblem(tx *bolt.Tx, messageID string) error {
+	if err := tx.Bucket(skippedBucket).Delete([]byte(messageID)); err != nil {
+		return err
+	}
+	return tx.Bucket(problemsBucket).Delete([]byte(messageID))
+}
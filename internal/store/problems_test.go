@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageProblems(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+
+	m.store.RecordProblem("msg1", "INBOX", errors.New("cannot decrypt"))
+	m.store.RecordProblem("msg2", "INBOX", errors.New("cannot parse"))
+	m.store.RecordProblem("msg1", "All Mail", errors.New("cannot build"))
+
+	problems, err := m.store.ListProblems()
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	require.Equal(t, "msg1", problems[0].MessageID)
+	require.Equal(t, "All Mail", problems[0].Mailbox)
+	require.Equal(t, "cannot build", problems[0].Error)
+
+	m.store.ClearProblem("msg1")
+	m.store.ClearProblem("msg3")
+	require.NoError(t, m.store.deleteMessageEvent("msg2"))
+
+	problems, err = m.store.ListProblems()
+	require.NoError(t, err)
+	require.Empty(t, problems)
+}
+
+func TestSkippedMessages(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+
+	require.Equal(t, ErrNoSuchAPIID, m.store.SetMessageSkipped("msg2", true))
+
+	m.store.RecordProblem("msg1", "INBOX", errors.New("cannot decrypt"))
+	require.NoError(t, m.store.SetMessageSkipped("msg1", true))
+	require.True(t, m.store.IsMessageSkipped("msg1"))
+
+	problems, err := m.store.ListProblems()
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	require.True(t, problems[0].Skipped)
+
+	require.NoError(t, m.store.SetMessageSkipped("msg1", false))
+	require.False(t, m.store.IsMessageSkipped("msg1"))
+
+	require.NoError(t, m.store.SetMessageSkipped("msg1", true))
+	require.NoError(t, m.store.deleteMessageEvent("msg1"))
+	require.False(t, m.store.IsMessageSkipped("msg1"))
+}
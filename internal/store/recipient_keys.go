@@ -0,0 +1,172 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+// RecipientKeys are fingerprints of public keys last used to send
+// a message to the recipient.
+type RecipientKeys struct {
+	Email        string
+	Fingerprints []string
+	UpdatedAt    time.Time
+}
+
+// CheckRecipientKeys remembers fingerprints of keys used for sending to
+// the email and reports whether they changed unexpectedly, returning
+// the previous fingerprints. Usual key rotation keeps at least one of
+// the old keys for a while, so only a complete replacement is reported.
+func (store *Store) CheckRecipientKeys(email string, fingerprints []string) (previous []string, changed bool, err error) {
+	if len(fingerprints) == 0 {
+		return nil, false, nil
+	}
+
+	key := []byte(strings.ToLower(email))
+	err = store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(recipientKeysBucket)
+
+		if raw := b.Get(key); raw != nil {
+			var known RecipientKeys
+			if err := json.Unmarshal(raw, &known); err != nil {
+				return err
+			}
+			previous = known.Fingerprints
+			changed = !hasCommonFingerprint(previous, fingerprints)
+		}
+
+		raw, err := json.Marshal(&RecipientKeys{
+			Email:        string(key),
+			Fingerprints: fingerprints,
+			UpdatedAt:    time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		return b.Put(key, raw)
+	})
+	return
+}
+
+func hasCommonFingerprint(a, b []string) bool {
+	for _, fa := range a {
+		for _, fb := range b {
+			if strings.EqualFold(fa, fb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recipientKeyCacheTTL is how long looked up public keys of a recipient
+// are used before asking the API again.
+const recipientKeyCacheTTL = 10 * time.Minute
+
+type cachedRecipientKeys struct {
+	keys       []pmapi.PublicKey
+	isInternal bool
+	fetchedAt  time.Time
+}
+
+// CachedRecipient describes public keys of a recipient held in the cache.
+type CachedRecipient struct {
+	Email      string
+	KeyCount   int
+	IsInternal bool
+	FetchedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// GetCachedRecipientKeys returns public keys of the email looked up less
+// than recipientKeyCacheTTL ago.
+func (store *Store) GetCachedRecipientKeys(email string) (keys []pmapi.PublicKey, isInternal, ok bool) {
+	store.recipientKeyCacheLock.Lock()
+	defer store.recipientKeyCacheLock.Unlock()
+
+	email = strings.ToLower(email)
+	cached, ok := store.recipientKeyCache[email]
+	if !ok {
+		return nil, false, false
+	}
+	if time.Since(cached.fetchedAt) > recipientKeyCacheTTL {
+		delete(store.recipientKeyCache, email)
+		return nil, false, false
+	}
+	return cached.keys, cached.isInternal, true
+}
+
+// CacheRecipientKeys remembers public keys of the email looked up from API.
+func (store *Store) CacheRecipientKeys(email string, keys []pmapi.PublicKey, isInternal bool) {
+	store.recipientKeyCacheLock.Lock()
+	defer store.recipientKeyCacheLock.Unlock()
+
+	if store.recipientKeyCache == nil {
+		store.recipientKeyCache = map[string]cachedRecipientKeys{}
+	}
+	store.recipientKeyCache[strings.ToLower(email)] = cachedRecipientKeys{
+		keys:       keys,
+		isInternal: isInternal,
+		fetchedAt:  time.Now(),
+	}
+}
+
+// ListCachedRecipients returns not expired entries of the recipient key
+// cache sorted by email.
+func (store *Store) ListCachedRecipients() []CachedRecipient {
+	store.recipientKeyCacheLock.Lock()
+	defer store.recipientKeyCacheLock.Unlock()
+
+	recipients := []CachedRecipient{}
+	for email, cached := range store.recipientKeyCache {
+		expiresAt := cached.fetchedAt.Add(recipientKeyCacheTTL)
+		if time.Now().After(expiresAt) {
+			delete(store.recipientKeyCache, email)
+			continue
+		}
+		recipients = append(recipients, CachedRecipient{
+			Email:      email,
+			KeyCount:   len(cached.keys),
+			IsInternal: cached.isInternal,
+			FetchedAt:  cached.fetchedAt,
+			ExpiresAt:  expiresAt,
+		})
+	}
+	sort.Slice(recipients, func(i, j int) bool {
+		return recipients[i].Email < recipients[j].Email
+	})
+	return recipients
+}
+
+// FlushRecipientKeyCache forgets all cached public keys so the next send
+// looks them up again. It returns the number of flushed recipients.
+func (store *Store) FlushRecipientKeyCache() int {
+	store.recipientKeyCacheLock.Lock()
+	defer store.recipientKeyCacheLock.Unlock()
+
+	count := len(store.recipientKeyCache)
+	store.recipientKeyCache = nil
+	return count
+}
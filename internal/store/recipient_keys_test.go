@@ -0,0 +1,90 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckRecipientKeys(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	previous, changed, err := m.store.CheckRecipientKeys("Alice@pm.me", []string{"aaa", "bbb"})
+	require.NoError(t, err)
+	require.False(t, changed)
+	require.Nil(t, previous)
+
+	// Rotation keeps one of the old keys.
+	_, changed, err = m.store.CheckRecipientKeys("alice@pm.me", []string{"ccc", "BBB"})
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	previous, changed, err = m.store.CheckRecipientKeys("alice@pm.me", []string{"ddd"})
+	require.NoError(t, err)
+	require.True(t, changed)
+	require.Equal(t, []string{"ccc", "BBB"}, previous)
+
+	// Recipient without keys is not tracked.
+	_, changed, err = m.store.CheckRecipientKeys("bob@example.com", nil)
+	require.NoError(t, err)
+	require.False(t, changed)
+}
+
+func TestRecipientKeyCache(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	_, _, ok := m.store.GetCachedRecipientKeys("alice@pm.me")
+	require.False(t, ok)
+
+	m.store.CacheRecipientKeys("Alice@pm.me", []pmapi.PublicKey{{PublicKey: "key"}}, true)
+	m.store.CacheRecipientKeys("bob@pm.me", nil, false)
+
+	keys, isInternal, ok := m.store.GetCachedRecipientKeys("alice@PM.me")
+	require.True(t, ok)
+	require.True(t, isInternal)
+	require.Equal(t, []pmapi.PublicKey{{PublicKey: "key"}}, keys)
+
+	recipients := m.store.ListCachedRecipients()
+	require.Len(t, recipients, 2)
+	require.Equal(t, "alice@pm.me", recipients[0].Email)
+	require.Equal(t, 1, recipients[0].KeyCount)
+	require.Equal(t, "bob@pm.me", recipients[1].Email)
+
+	// Expired entry is looked up again.
+	expired := m.store.recipientKeyCache["bob@pm.me"]
+	expired.fetchedAt = time.Now().Add(-recipientKeyCacheTTL - time.Second)
+	m.store.recipientKeyCache["bob@pm.me"] = expired
+	_, _, ok = m.store.GetCachedRecipientKeys("bob@pm.me")
+	require.False(t, ok)
+	require.Len(t, m.store.ListCachedRecipients(), 1)
+
+	require.Equal(t, 1, m.store.FlushRecipientKeyCache())
+	_, _, ok = m.store.GetCachedRecipientKeys("alice@pm.me")
+	require.False(t, ok)
+	require.Empty(t, m.store.ListCachedRecipients())
+}
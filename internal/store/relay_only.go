@@ -0,0 +1,41 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import "sync/atomic"
+
+var relayOnlyMode int32 //nolint[gochecknoglobals]
+
+// SetRelayOnlyMode makes stores serve only the needs of the SMTP server:
+// mailbox sync is never started and label, message and count events are
+// ignored. Address events are still processed so that sending keeps
+// working with up-to-date addresses.
+func SetRelayOnlyMode(enabled bool) {
+	value := int32(0)
+	if enabled {
+		value = 1
+	}
+
+	log.WithField("enabled", enabled).Info("Setting relay-only mode")
+	atomic.StoreInt32(&relayOnlyMode, value)
+}
+
+// IsRelayOnlyMode returns whether the relay-only mode is enabled.
+func IsRelayOnlyMode() bool {
+	return atomic.LoadInt32(&relayOnlyMode) == 1
+}
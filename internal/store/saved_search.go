@@ -0,0 +1,380 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// savedSearchLabelIDPrefix distinguishes IDs of virtual mailboxes
+// from API label IDs.
+const savedSearchLabelIDPrefix = "saved-search:"
+
+var (
+	ErrSavedSearchExists         = errors.New("saved search with this name already exists")
+	ErrSavedSearchNotFound       = errors.New("saved search not found")
+	ErrInvalidSavedSearch        = errors.New("invalid saved search")
+	ErrSavedSearchOpNotAllowed   = errors.New("operation not allowed for saved search mailbox")
+	errSavedSearchCreateOverIMAP = errors.New("saved searches can be created only in bridge")
+)
+
+// SavedSearch is a virtual mailbox listing all messages matching the query.
+type SavedSearch struct {
+	Name  string
+	Query string
+}
+
+func getSavedSearchLabelID(name string) string {
+	return savedSearchLabelIDPrefix + name
+}
+
+// IsSavedSearch returns whether the mailbox is a virtual one backed by a saved search.
+func (storeMailbox *Mailbox) IsSavedSearch() bool {
+	return storeMailbox.filter != nil
+}
+
+// ListSavedSearches returns all saved searches sorted by name.
+func (store *Store) ListSavedSearches() (searches []SavedSearch, err error) {
+	err = store.db.View(func(tx *bolt.Tx) error {
+		searches, err = txListSavedSearches(tx)
+		return err
+	})
+	return
+}
+
+func txListSavedSearches(tx *bolt.Tx) (searches []SavedSearch, err error) {
+	err = tx.Bucket(savedSearchesBucket).ForEach(func(k, v []byte) error {
+		searches = append(searches, SavedSearch{Name: string(k), Query: string(v)})
+		return nil
+	})
+	sort.Slice(searches, func(i, j int) bool { return searches[i].Name < searches[j].Name })
+	return
+}
+
+// AddSavedSearch stores a new saved search and creates its virtual mailbox
+// in every address, filled with matching messages which are already synced.
+func (store *Store) AddSavedSearch(name, query string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Contains(name, PathDelimiter) {
+		return errors.Wrap(ErrInvalidSavedSearch, "name must be non-empty and without "+PathDelimiter)
+	}
+
+	if _, err := parseSearchFilter(query); err != nil {
+		return err
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	log.WithField("name", name).WithField("query", query).Info("Adding saved search")
+
+	search := SavedSearch{Name: name, Query: query}
+	created := map[*Address]*Mailbox{}
+
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(savedSearchesBucket)
+		if b.Get([]byte(name)) != nil {
+			return ErrSavedSearchExists
+		}
+		if err := b.Put([]byte(name), []byte(query)); err != nil {
+			return err
+		}
+
+		for _, a := range store.addresses {
+			mailbox, err := txNewSavedSearchMailbox(tx, a, search)
+			if err != nil {
+				return err
+			}
+			created[a] = mailbox
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for a, mailbox := range created {
+		a.mailboxes[mailbox.labelID] = mailbox
+		store.imapMailboxCreated(a.address, mailbox.labelName)
+	}
+
+	return nil
+}
+
+// RemoveSavedSearch removes the saved search and its virtual mailboxes.
+// Messages themselves are not touched.
+func (store *Store) RemoveSavedSearch(name string) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	log.WithField("name", name).Info("Removing saved search")
+
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(savedSearchesBucket)
+		if b.Get([]byte(name)) == nil {
+			return ErrSavedSearchNotFound
+		}
+		return b.Delete([]byte(name))
+	})
+	if err != nil {
+		return err
+	}
+
+	labelID := getSavedSearchLabelID(name)
+	_ = store.SetSubscribed(labelID, true)
+
+	for _, a := range store.addresses {
+		if err := a.deleteMailboxEvent(labelID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// txInitSavedSearches adds virtual mailboxes of all saved searches to the address.
+func (storeAddress *Address) txInitSavedSearches(tx *bolt.Tx) error {
+	searches, err := txListSavedSearches(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, search := range searches {
+		mailbox, err := txNewSavedSearchMailbox(tx, storeAddress, search)
+		if err != nil {
+			storeAddress.log.WithError(err).WithField("search", search.Name).Error("Could not init saved search mailbox")
+			continue
+		}
+		storeAddress.mailboxes[mailbox.labelID] = mailbox
+	}
+
+	return nil
+}
+
+func txNewSavedSearchMailbox(tx *bolt.Tx, storeAddress *Address, search SavedSearch) (*Mailbox, error) {
+	filter, err := parseSearchFilter(search.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	mb, err := txNewMailbox(tx, storeAddress, getSavedSearchLabelID(search.Name), SavedSearchesPrefix, search.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	mb.filter = filter
+
+	// The mailbox is materialized only when it is empty, i.e., when it was
+	// just created. Later it is kept up to date by processing events the same
+	// way as any other mailbox.
+	if total, _, _, err := mb.txGetCounts(tx); err != nil || total != 0 {
+		return mb, err
+	}
+
+	err = tx.Bucket(metadataBucket).ForEach(func(k, v []byte) error {
+		msg := &pmapi.Message{}
+		if err := json.Unmarshal(v, msg); err != nil {
+			return err
+		}
+		if !filter.matches(msg) {
+			return nil
+		}
+		return mb.txCreateOrUpdateMessages(tx, []*pmapi.Message{msg})
+	})
+
+	return mb, err
+}
+
+// txGetSavedSearchLabelIDs returns IDs of all virtual mailboxes.
+func txGetSavedSearchLabelIDs(tx *bolt.Tx) (labelIDs []string) {
+	_ = tx.Bucket(savedSearchesBucket).ForEach(func(k, _ []byte) error {
+		labelIDs = append(labelIDs, getSavedSearchLabelID(string(k)))
+		return nil
+	})
+	return
+}
+
+// searchFilter is a parsed saved search query. All conditions must match.
+// Only message metadata is used, so bodies never need to be fetched.
+type searchFilter struct {
+	unread        *bool
+	starred       *bool
+	hasAttachment bool
+	from          []string
+	to            []string
+	subject       []string
+	after         time.Time
+	before        time.Time
+}
+
+// parseSearchFilter parses query composed of whitespace separated terms:
+// is:unread, is:read, is:starred, is:unstarred, has:attachment, from:text,
+// to:text, subject:text, after:YYYY-MM-DD, before:YYYY-MM-DD and year:YYYY.
+// Terms without a key are searched in the subject. Values with spaces can be
+// quoted, e.g. from:"John Doe".
+func parseSearchFilter(query string) (*searchFilter, error) {
+	terms := splitSearchQuery(query)
+	if len(terms) == 0 {
+		return nil, errors.Wrap(ErrInvalidSavedSearch, "query is empty")
+	}
+
+	f := &searchFilter{}
+	for _, term := range terms {
+		key, value := "", term
+		if i := strings.Index(term, ":"); i > 0 {
+			key, value = strings.ToLower(term[:i]), term[i+1:]
+		}
+		value = strings.Trim(value, `"`)
+		if value == "" {
+			return nil, errors.Wrapf(ErrInvalidSavedSearch, "missing value of %q", term)
+		}
+
+		if err := f.addTerm(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (f *searchFilter) addTerm(key, value string) error { //nolint[funlen]
+	var err error
+
+	switch key {
+	case "":
+		f.subject = append(f.subject, strings.ToLower(value))
+	case "is":
+		yes, no := true, false
+		switch strings.ToLower(value) {
+		case "unread":
+			f.unread = &yes
+		case "read":
+			f.unread = &no
+		case "starred":
+			f.starred = &yes
+		case "unstarred":
+			f.starred = &no
+		default:
+			return errors.Wrapf(ErrInvalidSavedSearch, "unknown is:%s", value)
+		}
+	case "has":
+		if strings.ToLower(value) != "attachment" {
+			return errors.Wrapf(ErrInvalidSavedSearch, "unknown has:%s", value)
+		}
+		f.hasAttachment = true
+	case "from":
+		f.from = append(f.from, strings.ToLower(value))
+	case "to":
+		f.to = append(f.to, strings.ToLower(value))
+	case "subject":
+		f.subject = append(f.subject, strings.ToLower(value))
+	case "after":
+		if f.after, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return errors.Wrapf(ErrInvalidSavedSearch, "wrong date %q", value)
+		}
+	case "before":
+		if f.before, err = time.ParseInLocation("2006-01-02", value, time.Local); err != nil {
+			return errors.Wrapf(ErrInvalidSavedSearch, "wrong date %q", value)
+		}
+	case "year":
+		year, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(ErrInvalidSavedSearch, "wrong year %q", value)
+		}
+		f.after = time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
+		f.before = f.after.AddDate(1, 0, 0)
+	default:
+		return errors.Wrapf(ErrInvalidSavedSearch, "unknown term %q", key)
+	}
+
+	return nil
+}
+
+func splitSearchQuery(query string) (terms []string) {
+	inQuotes := false
+	return strings.FieldsFunc(query, func(r rune) bool {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		return !inQuotes && unicode.IsSpace(r)
+	})
+}
+
+func (f *searchFilter) matches(msg *pmapi.Message) bool { //nolint[gocyclo]
+	if f.unread != nil && *f.unread != (msg.Unread == 1) {
+		return false
+	}
+
+	if f.starred != nil && *f.starred != msg.HasLabelID(pmapi.StarredLabel) {
+		return false
+	}
+
+	if f.hasAttachment && msg.NumAttachments == 0 && len(msg.Attachments) == 0 {
+		return false
+	}
+
+	msgTime := time.Unix(msg.Time, 0)
+	if !f.after.IsZero() && msgTime.Before(f.after) {
+		return false
+	}
+	if !f.before.IsZero() && !msgTime.Before(f.before) {
+		return false
+	}
+
+	subject := strings.ToLower(msg.Subject)
+	for _, text := range f.subject {
+		if !strings.Contains(subject, text) {
+			return false
+		}
+	}
+
+	for _, text := range f.from {
+		if !addressContains(text, msg.Sender) {
+			return false
+		}
+	}
+
+	recipients := append(append(append([]*mail.Address{}, msg.ToList...), msg.CCList...), msg.BCCList...)
+	for _, text := range f.to {
+		if !addressContains(text, recipients...) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func addressContains(text string, addresses ...*mail.Address) bool {
+	for _, address := range addresses {
+		if address == nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(address.Name), text) || strings.Contains(strings.ToLower(address.Address), text) {
+			return true
+		}
+	}
+	return false
+}
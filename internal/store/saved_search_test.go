@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchFilterMatches(t *testing.T) {
+	msg := &pmapi.Message{
+		Subject:        "Quarterly report",
+		Unread:         1,
+		Sender:         &mail.Address{Name: "The Boss", Address: "boss@pm.me"},
+		ToList:         []*mail.Address{{Address: "me@pm.me"}},
+		Time:           time.Date(2023, 5, 1, 12, 0, 0, 0, time.Local).Unix(),
+		NumAttachments: 1,
+		LabelIDs:       []string{pmapi.AllMailLabel, pmapi.InboxLabel},
+	}
+
+	tests := []struct {
+		query     string
+		wantMatch bool
+	}{
+		{"is:unread from:boss", true},
+		{`from:"the boss"`, true},
+		{"is:read from:boss", false},
+		{"is:starred", false},
+		{"has:attachment year:2023", true},
+		{"year:2022", false},
+		{"after:2023-04-30 before:2023-05-02", true},
+		{"before:2023-05-01", false},
+		{"to:me@pm.me report", true},
+		{"subject:invoice", false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.query, func(t *testing.T) {
+			filter, err := parseSearchFilter(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.wantMatch, filter.matches(msg))
+		})
+	}
+}
+
+func TestParseSearchFilterInvalid(t *testing.T) {
+	for _, query := range []string{"", "is:important", "has:link", "after:yesterday", "label:work", "from:"} {
+		_, err := parseSearchFilter(query)
+		require.Error(t, err, query)
+	}
+}
+
+func TestSavedSearchMailbox(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Report 1", addrID1, 1, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	insertMessage(t, m, "msg2", "Report 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.ArchiveLabel})
+	insertMessage(t, m, "msg3", "Other", addrID1, 1, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+
+	require.NoError(t, m.store.AddSavedSearch("Unread reports", "is:unread report"))
+	require.Equal(t, ErrSavedSearchExists, m.store.AddSavedSearch("Unread reports", "is:unread"))
+
+	mailbox, err := m.store.getMailbox(SavedSearchesPrefix + "Unread reports")
+	require.NoError(t, err)
+	require.True(t, mailbox.IsSavedSearch())
+	checkSavedSearchMessageIDs(t, mailbox, []string{"msg1"})
+
+	// Membership follows changes of messages.
+	insertMessage(t, m, "msg1", "Report 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	insertMessage(t, m, "msg2", "Report 2", addrID1, 1, []string{pmapi.AllMailLabel, pmapi.ArchiveLabel})
+	checkSavedSearchMessageIDs(t, mailbox, []string{"msg2"})
+
+	require.Equal(t, ErrSavedSearchOpNotAllowed, mailbox.LabelMessages([]string{"msg3"}))
+
+	searches, err := m.store.ListSavedSearches()
+	require.NoError(t, err)
+	require.Equal(t, []SavedSearch{{Name: "Unread reports", Query: "is:unread report"}}, searches)
+
+	require.NoError(t, mailbox.Delete())
+	require.False(t, m.store.hasMailbox(SavedSearchesPrefix+"Unread reports"))
+	require.Equal(t, ErrSavedSearchNotFound, m.store.RemoveSavedSearch("Unread reports"))
+}
+
+func checkSavedSearchMessageIDs(t *testing.T, mailbox *Mailbox, wantIDs []string) {
+	apiIDs, err := mailbox.GetAPIIDsFromSequenceRange(1, 0)
+	require.NoError(t, err)
+	require.Equal(t, wantIDs, apiIDs)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	draftsUploadKey      = []byte("drafts_upload")         //nolint[gochecknoglobals]
+	autoCreateMailboxKey = []byte("auto_create_mailboxes") //nolint[gochecknoglobals]
+	crossAccountCopyKey  = []byte("cross_account_copy")    //nolint[gochecknoglobals]
+	spamTrainingKey      = []byte("spam_training")         //nolint[gochecknoglobals]
+	expungePolicyKey     = []byte("expunge_policy")        //nolint[gochecknoglobals]
+	autoCopyAddressKey   = []byte("auto_copy_address")     //nolint[gochecknoglobals]
+	autoCopyModeKey      = []byte("auto_copy_mode")        //nolint[gochecknoglobals]
+	footerPlainKey       = []byte("footer_plain")          //nolint[gochecknoglobals]
+	footerHTMLKey        = []byte("footer_html")           //nolint[gochecknoglobals]
+)
+
+// getBoolSetting returns the value of the setting or defaultValue when it is not set.
+func (store *Store) getBoolSetting(key []byte, defaultValue bool) bool {
+	value := defaultValue
+	err := store.db.View(func(tx *bolt.Tx) error {
+		if raw := tx.Bucket(settingsBucket).Get(key); raw != nil {
+			parsed, err := strconv.ParseBool(string(raw))
+			if err != nil {
+				return err
+			}
+			value = parsed
+		}
+		return nil
+	})
+	if err != nil {
+		store.log.WithError(err).WithField("key", string(key)).Warn("Cannot get setting")
+		return defaultValue
+	}
+	return value
+}
+
+// setBoolSetting saves the value of the setting. The default value is not
+// stored at all so changing the default later applies to everyone.
+func (store *Store) setBoolSetting(key []byte, value, defaultValue bool) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		if value == defaultValue {
+			return tx.Bucket(settingsBucket).Delete(key)
+		}
+		return tx.Bucket(settingsBucket).Put(key, []byte(strconv.FormatBool(value)))
+	})
+}
+
+// getStringSetting returns the value of the setting or defaultValue when it is not set.
+func (store *Store) getStringSetting(key []byte, defaultValue string) string {
+	value := defaultValue
+	err := store.db.View(func(tx *bolt.Tx) error {
+		if raw := tx.Bucket(settingsBucket).Get(key); raw != nil {
+			value = string(raw)
+		}
+		return nil
+	})
+	if err != nil {
+		store.log.WithError(err).WithField("key", string(key)).Warn("Cannot get setting")
+		return defaultValue
+	}
+	return value
+}
+
+// setStringSetting saves the value of the setting. As with bool settings,
+// the default value is not stored.
+func (store *Store) setStringSetting(key []byte, value, defaultValue string) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		if value == defaultValue {
+			return tx.Bucket(settingsBucket).Delete(key)
+		}
+		return tx.Bucket(settingsBucket).Put(key, []byte(value))
+	})
+}
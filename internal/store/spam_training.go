@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import "github.com/ProtonMail/proton-bridge/pkg/pmapi"
+
+// IsSpamTrainingEnabled returns whether moving messages to or from Spam
+// by email clients is reported to the spam filter.
+func (store *Store) IsSpamTrainingEnabled() bool {
+	return store.getBoolSetting(spamTrainingKey, true)
+}
+
+// SetSpamTrainingEnabled sets whether moves to or from Spam train the spam filter.
+func (store *Store) SetSpamTrainingEnabled(enabled bool) error {
+	return store.setBoolSetting(spamTrainingKey, enabled, true)
+}
+
+// trainSpamFilter reports messages moved to Spam as spam and messages moved
+// from Spam to Inbox, Archive or a folder as not spam. Moving to Trash is
+// not a classification. The move itself does not depend on the result.
+func (storeMailbox *Mailbox) trainSpamFilter(apiIDs []string) {
+	toSpam := storeMailbox.labelID == pmapi.SpamLabel
+	toHam := storeMailbox.labelID == pmapi.InboxLabel || storeMailbox.labelID == pmapi.ArchiveLabel || storeMailbox.IsFolder()
+	if !toSpam && !toHam {
+		return
+	}
+	if !storeMailbox.store.IsSpamTrainingEnabled() {
+		return
+	}
+
+	// Only messages changing the classification are reported.
+	reportIDs := []string{}
+	for _, apiID := range apiIDs {
+		msg, err := storeMailbox.store.getMessageFromDB(apiID)
+		if err != nil {
+			continue
+		}
+		if msg.HasLabelID(pmapi.SpamLabel) != toSpam {
+			reportIDs = append(reportIDs, apiID)
+		}
+	}
+	if len(reportIDs) == 0 {
+		return
+	}
+
+	var err error
+	if toSpam {
+		err = storeMailbox.client().MarkMessagesSpam(reportIDs)
+	} else {
+		err = storeMailbox.client().MarkMessagesHam(reportIDs)
+	}
+	if err != nil {
+		storeMailbox.log.WithError(err).WithField("spam", toSpam).Warn("Cannot train spam filter")
+	}
+}
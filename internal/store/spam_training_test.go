@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrainSpamFilter(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "inboxMsg", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.InboxLabel})
+	insertMessage(t, m, "spamMsg", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel, pmapi.SpamLabel})
+
+	spam, err := m.store.getMailbox("Spam")
+	require.NoError(t, err)
+	inbox, err := m.store.getMailbox("INBOX")
+	require.NoError(t, err)
+
+	m.client.EXPECT().MarkMessagesSpam([]string{"inboxMsg"}).Return(nil)
+	m.client.EXPECT().LabelMessages([]string{"inboxMsg", "spamMsg"}, pmapi.SpamLabel).Return(nil)
+	require.NoError(t, spam.LabelMessages([]string{"inboxMsg", "spamMsg"}))
+
+	m.client.EXPECT().MarkMessagesHam([]string{"spamMsg"}).Return(nil)
+	m.client.EXPECT().LabelMessages([]string{"inboxMsg", "spamMsg"}, pmapi.InboxLabel).Return(nil)
+	require.NoError(t, inbox.LabelMessages([]string{"inboxMsg", "spamMsg"}))
+
+	require.NoError(t, m.store.SetSpamTrainingEnabled(false))
+	require.False(t, m.store.IsSpamTrainingEnabled())
+
+	m.client.EXPECT().LabelMessages([]string{"inboxMsg"}, pmapi.SpamLabel).Return(nil)
+	require.NoError(t, spam.LabelMessages([]string{"inboxMsg"}))
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/ProtonMail/proton-bridge/pkg/listener"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/ProtonMail/proton-bridge/pkg/sieve"
 	imapBackend "github.com/emersion/go-imap/backend"
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
@@ -44,6 +45,10 @@ const (
 	UserFoldersMailboxName = "Folders"
 	// UserFoldersPrefix contains name with delimiter for IMAP
 	UserFoldersPrefix = UserFoldersMailboxName + PathDelimiter
+	// SavedSearchesMailboxName for IMAP
+	SavedSearchesMailboxName = "Searches"
+	// SavedSearchesPrefix contains name with delimiter for IMAP
+	SavedSearchesPrefix = SavedSearchesMailboxName + PathDelimiter
 )
 
 var (
@@ -60,25 +65,69 @@ var (
 	//   * mode -> string split or combined
 	// * mailboxes_version
 	//     * version -> uint32 value
+	//     * {addressID+mailboxID} -> uint32 count of UIDVALIDITY bumps of the mailbox added to version
 	// * sync_state
 	//   * sync_state -> string timestamp when it was last synced (when missing, sync should be ongoing)
 	//   * ids_ranges -> json array of groups with start and end message ID (when missing, there is no ongoing sync)
 	//   * ids_to_be_deleted -> json array of message IDs to be deleted after sync (when missing, there is no ongoing sync)
+	//   * history -> json array of the last sync runs
 	// * mailboxes
 	//   * {addressID+mailboxID}
 	//     * imap_ids
 	//       * {imapUID} -> string messageID
 	//     * api_ids
 	//       * {messageID} -> uint32 imapUID
-	metadataBucket    = []byte("metadata")          //nolint[gochecknoglobals]
-	countsBucket      = []byte("counts")            //nolint[gochecknoglobals]
-	addressInfoBucket = []byte("address_info")      //nolint[gochecknoglobals]
-	addressModeBucket = []byte("address_mode")      //nolint[gochecknoglobals]
-	syncStateBucket   = []byte("sync_state")        //nolint[gochecknoglobals]
-	mailboxesBucket   = []byte("mailboxes")         //nolint[gochecknoglobals]
-	imapIDsBucket     = []byte("imap_ids")          //nolint[gochecknoglobals]
-	apiIDsBucket      = []byte("api_ids")           //nolint[gochecknoglobals]
-	mboxVersionBucket = []byte("mailboxes_version") //nolint[gochecknoglobals]
+	//     * mod_seqs
+	//       * {imapUID} -> uint64 mod-sequence of the last change (missing means the lowest)
+	//     * vanished
+	//       * {imapUID} -> uint64 mod-sequence of the removal of the message
+	//     * reserved_uids
+	//       * {messageID} -> uint32 imapUID from the UID map to be reused when the message is synced again
+	// * unsubscribed
+	//   * {labelID} -> empty value (mailboxes are subscribed unless listed here)
+	// * saved_searches
+	//   * {name} -> string query of virtual mailbox
+	// * filters
+	//   * sieve -> string script of local filters
+	// * settings
+	//   * {setting} -> bool or string value of account setting (missing means default), e.g. drafts_upload
+	// * deferred_deletions
+	//   * {addressID/labelID/messageID} -> json deferredDeletion with time when the message is deleted
+	// * tombstones
+	//   * {deletedAt/messageID} -> json Tombstone with encrypted copy of permanently deleted message
+	// * journal
+	//   * {sequence} -> json journalEntry with message IDs whose mailboxes might not be updated yet
+	// * problems
+	//   * {messageID} -> json Problem with the last sync or build error of the message
+	// * skipped_messages
+	//   * {messageID} -> empty value (message is not built, clients get a placeholder)
+	// * recipient_keys
+	//   * {email} -> json RecipientKeys with fingerprints of keys last used for sending
+	// * address_stats
+	//   * {email} -> json AddressStats with counts of sent and received messages
+	metadataBucket      = []byte("metadata")           //nolint[gochecknoglobals]
+	countsBucket        = []byte("counts")             //nolint[gochecknoglobals]
+	addressInfoBucket   = []byte("address_info")       //nolint[gochecknoglobals]
+	addressModeBucket   = []byte("address_mode")       //nolint[gochecknoglobals]
+	syncStateBucket     = []byte("sync_state")         //nolint[gochecknoglobals]
+	mailboxesBucket     = []byte("mailboxes")          //nolint[gochecknoglobals]
+	imapIDsBucket       = []byte("imap_ids")           //nolint[gochecknoglobals]
+	apiIDsBucket        = []byte("api_ids")            //nolint[gochecknoglobals]
+	modSeqsBucket       = []byte("mod_seqs")           //nolint[gochecknoglobals]
+	vanishedBucket      = []byte("vanished")           //nolint[gochecknoglobals]
+	reservedUIDsBucket  = []byte("reserved_uids")      //nolint[gochecknoglobals]
+	mboxVersionBucket   = []byte("mailboxes_version")  //nolint[gochecknoglobals]
+	unsubscribedBucket  = []byte("unsubscribed")       //nolint[gochecknoglobals]
+	savedSearchesBucket = []byte("saved_searches")     //nolint[gochecknoglobals]
+	filtersBucket       = []byte("filters")            //nolint[gochecknoglobals]
+	settingsBucket      = []byte("settings")           //nolint[gochecknoglobals]
+	deferredDelBucket   = []byte("deferred_deletions") //nolint[gochecknoglobals]
+	tombstonesBucket    = []byte("tombstones")         //nolint[gochecknoglobals]
+	journalBucket       = []byte("journal")            //nolint[gochecknoglobals]
+	problemsBucket      = []byte("problems")           //nolint[gochecknoglobals]
+	skippedBucket       = []byte("skipped_messages")   //nolint[gochecknoglobals]
+	recipientKeysBucket = []byte("recipient_keys")     //nolint[gochecknoglobals]
+	addressStatsBucket  = []byte("address_stats")      //nolint[gochecknoglobals]
 
 	// ErrNoSuchAPIID when mailbox does not have API ID.
 	ErrNoSuchAPIID = errors.New("no such api id") //nolint[gochecknoglobals]
@@ -107,6 +156,23 @@ type Store struct {
 	isSyncRunning bool
 	syncCooldown  cooldown
 	addressMode   addressMode
+
+	// filters are local rules applied on newly received messages.
+	// Their actions are applied by the filter worker.
+	filters      *sieve.Script
+	filterQueue  chan filterJob
+	filterStopCh chan struct{}
+
+	// statusVersions are increased with every change of messages in a label
+	// to invalidate cached statuses of its mailboxes.
+	statusVersions          map[string]uint64
+	statusLock              sync.Mutex
+	lastMessageChange       time.Time
+	lastCountsRecalculation time.Time
+
+	// recipientKeyCache holds public keys of recipients for sending.
+	recipientKeyCache     map[string]cachedRecipientKeys
+	recipientKeyCacheLock sync.Mutex
 }
 
 // New creates or opens a store for the given `user`.
@@ -148,10 +214,15 @@ func New(
 		db:            bdb,
 		lock:          &sync.RWMutex{},
 		log:           l,
+
+		statusVersions: map[string]uint64{},
+
+		filterQueue:  make(chan filterJob, filterQueueSize),
+		filterStopCh: make(chan struct{}),
 	}
 
 	// Minimal increase is event pollInterval, doubles every failed retry up to 5 minutes.
-	store.syncCooldown.setExponentialWait(pollInterval, 2, 5*time.Minute)
+	store.syncCooldown.setExponentialWait(getPollInterval(), 2, 5*time.Minute)
 
 	if err = store.init(firstInit); err != nil {
 		l.WithError(err).Error("Could not initialise store, attempting to close")
@@ -162,6 +233,11 @@ func New(
 		return
 	}
 
+	go func() {
+		defer store.panicHandler.HandlePanic()
+		store.runFilterWorker()
+	}()
+
 	if user.IsConnected() {
 		store.eventLoop = newEventLoop(cache, store, user, events)
 		go func() {
@@ -215,6 +291,50 @@ func openBoltDatabase(filePath string) (db *bolt.DB, err error) {
 			return
 		}
 
+		if _, err = tx.CreateBucketIfNotExists(unsubscribedBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(savedSearchesBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(filtersBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(settingsBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(deferredDelBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(tombstonesBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(journalBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(problemsBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(skippedBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(recipientKeysBucket); err != nil {
+			return
+		}
+
+		if _, err = tx.CreateBucketIfNotExists(addressStatsBucket); err != nil {
+			return
+		}
+
 		return
 	}
 
@@ -243,6 +363,9 @@ func (store *Store) init(firstInit bool) (err error) {
 		if err != nil {
 			return errors.Wrap(err, "first init setting store address mode")
 		}
+		if err = store.importUIDMap(); err != nil {
+			store.log.WithError(err).Error("Could not import UID map, clients will download all messages again")
+		}
 	} else if store.addressMode, err = store.getAddressMode(); err != nil {
 		store.log.WithError(err).Error("Store address mode is unknown, setting to combined mode")
 		if err = store.setAddressMode(combinedMode); err != nil {
@@ -250,6 +373,10 @@ func (store *Store) init(firstInit bool) (err error) {
 		}
 	}
 
+	if err = store.importSubscriptionExceptions(); err != nil {
+		store.log.WithError(err).Error("Could not import unsubscribed mailboxes from cache")
+	}
+
 	store.log.WithField("mode", store.addressMode).Debug("Initialising store")
 
 	labels, err := store.initCounts()
@@ -263,6 +390,12 @@ func (store *Store) init(firstInit bool) (err error) {
 		return
 	}
 
+	if recoverErr := store.recoverStore(); recoverErr != nil {
+		store.log.WithError(recoverErr).Error("Could not recover store, full resync might be needed")
+	}
+
+	store.loadFilters()
+
 	return err
 }
 
@@ -365,10 +498,15 @@ func (store *Store) CloseEventLoop() {
 
 func (store *Store) close() error {
 	store.CloseEventLoop()
+	store.stopFilterWorker()
+	if err := store.exportUIDMap(); err != nil {
+		store.log.WithError(err).Warn("Could not export UID map")
+	}
 	return store.db.Close()
 }
 
-// Remove closes and removes the database file and clears the cache file.
+// Remove closes and removes the database file with its UID map and clears
+// the cache file.
 func (store *Store) Remove() (err error) {
 	store.lock.Lock()
 	defer store.lock.Unlock()
@@ -388,7 +526,7 @@ func (store *Store) Remove() (err error) {
 	return result.ErrorOrNil()
 }
 
-// RemoveStore removes the database file and clears the cache file.
+// RemoveStore removes the database file with its UID map and clears the cache file.
 func RemoveStore(cache *Cache, path, userID string) error {
 	var result *multierror.Error
 
@@ -401,5 +539,9 @@ func RemoveStore(cache *Cache, path, userID string) error {
 		result = multierror.Append(result, errors.Wrap(err, "failed to remove database file"))
 	}
 
+	if err := os.RemoveAll(getUIDMapPath(path)); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "failed to remove UID map"))
+	}
+
 	return result.ErrorOrNil()
 }
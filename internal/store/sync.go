@@ -26,9 +26,8 @@ import (
 )
 
 const (
-	syncMinPagesPerWorker  = 20
-	syncMessagesMaxWorkers = 20
-	maxFilterPageSize      = 150
+	syncMinPagesPerWorker = 20
+	maxFilterPageSize     = 150
 )
 
 type storeSynchronizer interface {
@@ -102,10 +101,11 @@ func findIDRanges(labelID string, api messageLister, syncState *syncState) error
 
 	syncState.initIDRanges()
 
-	pages := int(math.Ceil(float64(count) / float64(maxFilterPageSize)))
+	opts := GetSyncOptions()
+	pages := int(math.Ceil(float64(count) / float64(opts.PageSize)))
 	workers := (pages / syncMinPagesPerWorker) + 1
-	if workers > syncMessagesMaxWorkers {
-		workers = syncMessagesMaxWorkers
+	if workers > opts.PagesInFlight {
+		workers = opts.PagesInFlight
 	}
 
 	if workers == 1 {
@@ -114,7 +114,7 @@ func findIDRanges(labelID string, api messageLister, syncState *syncState) error
 
 	step := int(math.Round(float64(pages) / float64(workers)))
 	// Increment steps in case there are more steps than max # of workers (due to rounding).
-	if (step*syncMessagesMaxWorkers)+1 < pages {
+	if (step*opts.PagesInFlight)+1 < pages {
 		step++
 	}
 
@@ -142,7 +142,7 @@ func getSplitIDAndCount(labelID string, api messageLister, page int) (string, in
 		LabelID:  labelID,
 		Sort:     sort,
 		Desc:     &desc,
-		PageSize: maxFilterPageSize,
+		PageSize: GetSyncOptions().PageSize,
 		Page:     page,
 		Limit:    1,
 	}
@@ -157,6 +157,39 @@ func getSplitIDAndCount(labelID string, api messageLister, page int) (string, in
 	return messages[0].ID, total, nil
 }
 
+// syncPage fetches one page of messages and saves them to the store.
+// Pages in flight are limited by the memory budget.
+func syncPage(
+	filter *pmapi.MessagesFilter,
+	store storeSynchronizer,
+	api messageLister,
+	syncState *syncState,
+) ([]*pmapi.Message, error) {
+	reserved := int64(filter.PageSize) * syncPageMessageSize
+	AcquireMemory(reserved)
+	defer ReleaseMemory(reserved)
+
+	messages, _, err := api.ListMessages(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list messages")
+	}
+
+	if len(messages) == 0 {
+		return messages, nil
+	}
+
+	for _, m := range messages {
+		syncState.doNotDeleteMessageID(m.ID)
+	}
+	syncState.save()
+
+	if err := store.createOrUpdateMessagesEvent(messages); err != nil {
+		return nil, errors.Wrap(err, "failed to create or update messages")
+	}
+
+	return messages, nil
+}
+
 func syncBatch( //nolint[funlen]
 	labelID string,
 	store storeSynchronizer,
@@ -166,6 +199,7 @@ func syncBatch( //nolint[funlen]
 	shouldStop *int,
 ) error {
 	log.WithField("start", idRange.StartID).WithField("stop", idRange.StopID).Info("Starting sync batch")
+	pageSize := GetSyncOptions().PageSize
 	for {
 		if *shouldStop == 1 || idRange.isFinished() {
 			break
@@ -177,7 +211,7 @@ func syncBatch( //nolint[funlen]
 			LabelID:  labelID,
 			Sort:     sort,
 			Desc:     &desc,
-			PageSize: maxFilterPageSize,
+			PageSize: pageSize,
 			Page:     0,
 
 			// Messages with BeginID and EndID are included. We will process
@@ -189,24 +223,15 @@ func syncBatch( //nolint[funlen]
 
 		log.WithField("begin", filter.BeginID).WithField("end", filter.EndID).Debug("Fetching page")
 
-		messages, _, err := api.ListMessages(filter)
+		messages, err := syncPage(filter, store, api, syncState)
 		if err != nil {
-			return errors.Wrap(err, "failed to list messages")
+			return err
 		}
 
 		if len(messages) == 0 {
 			break
 		}
 
-		for _, m := range messages {
-			syncState.doNotDeleteMessageID(m.ID)
-		}
-		syncState.save()
-
-		if err := store.createOrUpdateMessagesEvent(messages); err != nil {
-			return errors.Wrap(err, "failed to create or update messages")
-		}
-
 		pageLastMessageID := messages[len(messages)-1].ID
 		if !desc {
 			idRange.setStartID(pageLastMessageID)
@@ -214,7 +239,7 @@ func syncBatch( //nolint[funlen]
 			idRange.setStopID(pageLastMessageID)
 		}
 
-		if len(messages) < maxFilterPageSize {
+		if len(messages) < pageSize {
 			break
 		}
 	}
@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	syncHistoryKey = "history"
+
+	// maxSyncHistory is the number of the last sync runs kept in history.
+	maxSyncHistory = 20
+)
+
+// SyncRun is a record about one sync of the store with the server.
+type SyncRun struct {
+	Start    time.Time
+	Duration time.Duration
+	Messages int64
+	Resumed  bool   // Whether the run continued an interrupted sync.
+	Error    string // Empty when the sync finished successfully.
+}
+
+// syncRun counts messages synced while passing them to the store.
+type syncRun struct {
+	storeSynchronizer
+	record   SyncRun
+	messages int64
+}
+
+func newSyncRun(store storeSynchronizer, resumed bool) *syncRun {
+	return &syncRun{
+		storeSynchronizer: store,
+		record:            SyncRun{Start: time.Now(), Resumed: resumed},
+	}
+}
+
+func (run *syncRun) createOrUpdateMessagesEvent(msgs []*pmapi.Message) error {
+	if err := run.storeSynchronizer.createOrUpdateMessagesEvent(msgs); err != nil {
+		return err
+	}
+	atomic.AddInt64(&run.messages, int64(len(msgs)))
+	return nil
+}
+
+func (run *syncRun) finish(err error) SyncRun {
+	run.record.Duration = time.Since(run.record.Start)
+	run.record.Messages = atomic.LoadInt64(&run.messages)
+	if err != nil {
+		run.record.Error = err.Error()
+	}
+	return run.record
+}
+
+// GetSyncHistory returns the last sync runs, the most recent first.
+func (store *Store) GetSyncHistory() (history []SyncRun, err error) {
+	err = store.db.View(func(tx *bolt.Tx) error {
+		history, err = txGetSyncHistory(tx)
+		return err
+	})
+	return
+}
+
+func (store *Store) recordSyncRun(run SyncRun) {
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		history, err := txGetSyncHistory(tx)
+		if err != nil {
+			store.log.WithError(err).Warn("Sync history is broken, starting new one")
+		}
+
+		history = append([]SyncRun{run}, history...)
+		if len(history) > maxSyncHistory {
+			history = history[:maxSyncHistory]
+		}
+
+		raw, err := json.Marshal(history)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(syncStateBucket).Put([]byte(syncHistoryKey), raw)
+	})
+	if err != nil {
+		store.log.WithError(err).Error("Cannot record sync run")
+	}
+}
+
+func txGetSyncHistory(tx *bolt.Tx) ([]SyncRun, error) {
+	history := []SyncRun{}
+	raw := tx.Bucket(syncStateBucket).Get([]byte(syncHistoryKey))
+	if raw == nil {
+		return history, nil
+	}
+	if err := json.Unmarshal(raw, &history); err != nil {
+		return []SyncRun{}, err
+	}
+	return history, nil
+}
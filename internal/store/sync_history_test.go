@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncRunCountsMessages(t *testing.T) {
+	run := newSyncRun(newSyncer(), true)
+
+	require.NoError(t, run.createOrUpdateMessagesEvent([]*pmapi.Message{{ID: "1"}, {ID: "2"}}))
+	require.NoError(t, run.createOrUpdateMessagesEvent([]*pmapi.Message{{ID: "3"}}))
+
+	record := run.finish(errors.New("connection lost"))
+	require.Equal(t, int64(3), record.Messages)
+	require.True(t, record.Resumed)
+	require.Equal(t, "connection lost", record.Error)
+}
+
+func TestSyncHistoryKeepsLastRuns(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	// The first sync of the new store is recorded too.
+	require.Eventually(t, func() bool {
+		history, err := m.store.GetSyncHistory()
+		return err == nil && len(history) == 1 && history[0].Error == ""
+	}, time.Second, 10*time.Millisecond)
+
+	for i := 0; i < maxSyncHistory+5; i++ {
+		m.store.recordSyncRun(SyncRun{Messages: int64(i)})
+	}
+
+	history, err := m.store.GetSyncHistory()
+	require.NoError(t, err)
+	require.Len(t, history, maxSyncHistory)
+	require.Equal(t, int64(maxSyncHistory+4), history[0].Messages)
+	require.Equal(t, int64(5), history[maxSyncHistory-1].Messages)
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"sync"
+
+	"github.com/ProtonMail/proton-bridge/pkg/parallel"
+)
+
+// Bounds of sync options. Values outside of them are clamped.
+const (
+	minSyncPagesInFlight = 1
+	maxSyncPagesInFlight = 50
+	minFilterPageSize    = 10
+	minBodyWorkers       = 1
+	maxBodyWorkers       = 200
+)
+
+// SyncOptions tunes how hard the store hits the API during sync.
+type SyncOptions struct {
+	// PagesInFlight is the maximum number of message pages requested at once.
+	PagesInFlight int
+
+	// PageSize is the number of messages requested in one page.
+	PageSize int
+
+	// BodyWorkers is the number of message bodies fetched in parallel
+	// when a client downloads messages over IMAP.
+	BodyWorkers int
+}
+
+var (
+	syncOptions     = DefaultSyncOptions() //nolint[gochecknoglobals]
+	syncOptionsLock = &sync.RWMutex{}      //nolint[gochecknoglobals]
+)
+
+// DefaultSyncOptions returns the options used unless set otherwise.
+// Worker pools scale with the number of usable CPUs.
+func DefaultSyncOptions() SyncOptions {
+	if IsLowResourceMode() {
+		return lowResourceSyncOptions()
+	}
+	return SyncOptions{
+		PagesInFlight: parallel.ScaledWorkers(5, 20),
+		PageSize:      maxFilterPageSize,
+		BodyWorkers:   parallel.ScaledWorkers(25, 100),
+	}
+}
+
+// SetSyncOptions changes options of all stores. Zero values are replaced
+// by defaults and the rest is clamped to sane bounds. The change takes effect
+// with the next sync or fetch.
+func SetSyncOptions(opts SyncOptions) {
+	def := DefaultSyncOptions()
+	opts.PagesInFlight = clampOption(opts.PagesInFlight, minSyncPagesInFlight, maxSyncPagesInFlight, def.PagesInFlight)
+	opts.PageSize = clampOption(opts.PageSize, minFilterPageSize, maxFilterPageSize, def.PageSize)
+	opts.BodyWorkers = clampOption(opts.BodyWorkers, minBodyWorkers, maxBodyWorkers, def.BodyWorkers)
+	if IsLowResourceMode() {
+		opts = capSyncOptions(opts)
+	}
+
+	syncOptionsLock.Lock()
+	defer syncOptionsLock.Unlock()
+
+	log.WithField("options", opts).Info("Setting sync options")
+	syncOptions = opts
+}
+
+// GetSyncOptions returns currently used options.
+func GetSyncOptions() SyncOptions {
+	syncOptionsLock.RLock()
+	defer syncOptionsLock.RUnlock()
+
+	return syncOptions
+}
+
+func clampOption(value, min, max, def int) int {
+	switch {
+	case value == 0:
+		return def
+	case value < min:
+		return min
+	case value > max:
+		return max
+	default:
+		return value
+	}
+}
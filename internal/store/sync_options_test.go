@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSyncOptions(t *testing.T) {
+	defer SetSyncOptions(DefaultSyncOptions())
+
+	SetSyncOptions(SyncOptions{PagesInFlight: 1000, PageSize: 1, BodyWorkers: 0})
+
+	require.Equal(t, SyncOptions{
+		PagesInFlight: maxSyncPagesInFlight,
+		PageSize:      minFilterPageSize,
+		BodyWorkers:   DefaultSyncOptions().BodyWorkers,
+	}, GetSyncOptions())
+}
+
+func TestSetSyncOptionsInLowResourceMode(t *testing.T) {
+	defer SetSyncOptions(DefaultSyncOptions())
+	SetLowResourceMode(true)
+	defer SetLowResourceMode(false)
+
+	SetSyncOptions(SyncOptions{PagesInFlight: 20, PageSize: 20, BodyWorkers: 100})
+
+	require.Equal(t, SyncOptions{
+		PagesInFlight: lowResourceSyncOptions().PagesInFlight,
+		PageSize:      20,
+		BodyWorkers:   lowResourceSyncOptions().BodyWorkers,
+	}, GetSyncOptions())
+	require.Equal(t, lowResourceSyncOptions(), DefaultSyncOptions())
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	pkgMessage "github.com/ProtonMail/proton-bridge/pkg/message"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// TombstoneRetention is how long permanently deleted messages can be recovered.
+const TombstoneRetention = 7 * 24 * time.Hour
+
+// ErrNoSuchTombstone is returned when recovering message which was not
+// deleted by bridge or whose retention already passed.
+var ErrNoSuchTombstone = errors.New("no such deleted message")
+
+// Tombstone is a local copy of message permanently deleted via bridge.
+// Body is encrypted by the address key, ready to be imported again.
+type Tombstone struct {
+	MessageID string
+	AddressID string
+	Subject   string
+	From      string
+	Time      int64
+	Unread    int
+	Flags     int64
+	LabelIDs  []string
+	DeletedAt time.Time
+	Body      []byte `json:",omitempty"`
+}
+
+// Keys are prefixed by time of deletion to prune old ones cheaply.
+func (tombstone *Tombstone) key() []byte {
+	return []byte(fmt.Sprintf("%020d/%s", tombstone.DeletedAt.Unix(), tombstone.MessageID))
+}
+
+// createTombstones keeps copies of messages before they are deleted
+// permanently. Failure is only logged so the deletion is not blocked.
+func (store *Store) createTombstones(apiIDs []string) {
+	for _, apiID := range apiIDs {
+		if err := store.createTombstone(apiID); err != nil {
+			store.log.WithError(err).WithField("msg", apiID).Warn("Cannot keep copy of deleted message")
+		}
+	}
+}
+
+func (store *Store) createTombstone(apiID string) error {
+	msg, err := store.client().GetMessage(apiID)
+	if err != nil {
+		return errors.Wrap(err, "failed to get message")
+	}
+
+	body, err := store.buildTombstoneBody(msg)
+	if err != nil {
+		return err
+	}
+
+	from := ""
+	if msg.Sender != nil {
+		from = msg.Sender.String()
+	}
+
+	return store.saveTombstone(&Tombstone{
+		MessageID: msg.ID,
+		AddressID: msg.AddressID,
+		Subject:   msg.Subject,
+		From:      from,
+		Time:      msg.Time,
+		Unread:    msg.Unread,
+		Flags:     msg.Flags,
+		LabelIDs:  msg.LabelIDs,
+		DeletedAt: time.Now(),
+		Body:      body,
+	})
+}
+
+// buildTombstoneBody builds the whole message including attachments
+// and encrypts it again the same way as messages being imported.
+func (store *Store) buildTombstoneBody(msg *pmapi.Message) ([]byte, error) {
+	kr, err := store.client().KeyRingForAddressID(msg.AddressID)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get key ring")
+	}
+
+	builder := pkgMessage.NewBuilder(store.client(), msg)
+	builder.EncryptedToHTML = false
+	_, raw, err := builder.BuildMessage()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build message")
+	}
+
+	parsed, _, _, attachmentReaders, err := pkgMessage.Parse(bytes.NewReader(raw), "", "")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse message")
+	}
+
+	return pkgMessage.BuildEncrypted(parsed, attachmentReaders, kr)
+}
+
+func (store *Store) saveTombstone(tombstone *Tombstone) error {
+	raw, err := json.Marshal(tombstone)
+	if err != nil {
+		return err
+	}
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tombstonesBucket).Put(tombstone.key(), raw)
+	})
+}
+
+// ListTombstones returns messages which can be recovered, the most recently
+// deleted first. Bodies are not included.
+func (store *Store) ListTombstones() (tombstones []Tombstone, err error) {
+	err = store.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(tombstonesBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var tombstone Tombstone
+			if err := json.Unmarshal(v, &tombstone); err != nil {
+				return err
+			}
+			tombstone.Body = nil
+			tombstones = append(tombstones, tombstone)
+		}
+		return nil
+	})
+	return
+}
+
+// RecoverTombstone imports the deleted message again. Message deleted from
+// Trash is recovered to Inbox as its original folder is not known.
+func (store *Store) RecoverTombstone(apiID string) error {
+	var key []byte
+	var tombstone Tombstone
+	err := store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(tombstonesBucket).ForEach(func(k, v []byte) error {
+			if key != nil || !strings.HasSuffix(string(k), "/"+apiID) {
+				return nil
+			}
+			key = append([]byte{}, k...)
+			return json.Unmarshal(v, &tombstone)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return ErrNoSuchTombstone
+	}
+
+	res, err := store.client().Import([]*pmapi.ImportMsgReq{{
+		AddressID: tombstone.AddressID,
+		Body:      tombstone.Body,
+		Unread:    tombstone.Unread,
+		Flags:     tombstone.Flags,
+		Time:      tombstone.Time,
+		LabelIDs:  getRecoveredLabelIDs(tombstone.LabelIDs),
+	}})
+	if err != nil {
+		return errors.Wrap(err, "failed to import message")
+	}
+	if len(res) > 0 && res[0].Error != nil {
+		return errors.Wrap(res[0].Error, "failed to import message")
+	}
+
+	store.eventLoop.pollNow()
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(tombstonesBucket).Delete(key)
+	})
+}
+
+func getRecoveredLabelIDs(labelIDs []string) []string {
+	recovered := []string{}
+	hasFolder := false
+	for _, labelID := range labelIDs {
+		switch labelID {
+		case pmapi.TrashLabel, pmapi.AllMailLabel, pmapi.AllSentLabel, pmapi.AllDraftsLabel:
+			continue
+		case pmapi.InboxLabel, pmapi.SentLabel, pmapi.ArchiveLabel, pmapi.SpamLabel:
+			hasFolder = true
+		}
+		recovered = append(recovered, labelID)
+	}
+	if !hasFolder {
+		recovered = append(recovered, pmapi.InboxLabel)
+	}
+	return recovered
+}
+
+// pruneTombstones removes tombstones older than the retention.
+func (store *Store) pruneTombstones() {
+	limit := []byte(fmt.Sprintf("%020d/", time.Now().Add(-TombstoneRetention).Unix()))
+	err := store.db.Update(func(tx *bolt.Tx) error {
+		c := tx.Bucket(tombstonesBucket).Cursor()
+		for k, _ := c.First(); k != nil && bytes.Compare(k, limit) < 0; k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		store.log.WithError(err).Error("Cannot prune deleted messages")
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstones(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	require.NoError(t, m.store.saveTombstone(&Tombstone{
+		MessageID: "old",
+		DeletedAt: time.Now().Add(-TombstoneRetention - time.Hour),
+	}))
+	require.NoError(t, m.store.saveTombstone(&Tombstone{
+		MessageID: "msg1",
+		AddressID: addrID1,
+		Subject:   "Deleted",
+		LabelIDs:  []string{pmapi.AllMailLabel, pmapi.TrashLabel, "customLabel"},
+		DeletedAt: time.Now(),
+		Body:      []byte("encrypted body"),
+	}))
+
+	m.store.pruneTombstones()
+
+	tombstones, err := m.store.ListTombstones()
+	require.NoError(t, err)
+	require.Len(t, tombstones, 1)
+	require.Equal(t, "Deleted", tombstones[0].Subject)
+	require.Nil(t, tombstones[0].Body)
+
+	require.Equal(t, ErrNoSuchTombstone, m.store.RecoverTombstone("old"))
+
+	m.client.EXPECT().Import(gomock.Any()).DoAndReturn(func(reqs []*pmapi.ImportMsgReq) ([]*pmapi.ImportMsgRes, error) {
+		require.Equal(t, []byte("encrypted body"), reqs[0].Body)
+		require.Equal(t, []string{"customLabel", pmapi.InboxLabel}, reqs[0].LabelIDs)
+		return []*pmapi.ImportMsgRes{{MessageID: "recovered"}}, nil
+	})
+	require.NoError(t, m.store.RecoverTombstone("msg1"))
+
+	tombstones, err = m.store.ListTombstones()
+	require.NoError(t, err)
+	require.Empty(t, tombstones)
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// uidMap is a snapshot of IMAP UIDs assigned to messages in all mailboxes.
+// It is kept in a file next to the database and survives clearing of the
+// cache, so the database can be built again with the same UIDVALIDITY and
+// UIDs and clients don't have to download all messages again. It is removed
+// together with the account.
+type uidMap struct {
+	AddressMode      addressMode
+	MailboxesVersion uint32
+	Mailboxes        map[string]*mailboxUIDMap
+}
+
+// mailboxUIDMap holds UIDs of messages in one mailbox and the last assigned
+// UID and mod-sequence, so new messages never reuse already seen values.
+// Vanished maps UIDs of removed messages to the mod-sequence of the removal
+// for QRESYNC clients which did not learn about it yet.
+type mailboxUIDMap struct {
+	UIDValidityBump uint32
+	LastUID         uint64
+	LastModSeq      uint64
+	UIDs            map[string]uint32
+	Vanished        map[uint32]uint64
+}
+
+// getUIDMapPath returns the path of the UID map file of the database.
+// The suffix must match the one kept by config when clearing data.
+func getUIDMapPath(dbPath string) string {
+	return strings.TrimSuffix(dbPath, filepath.Ext(dbPath)) + ".uids.json"
+}
+
+// exportUIDMap writes UIDs of all mailboxes to the UID map file.
+// UIDs restored from the previous map which were not used yet are
+// exported as well.
+func (store *Store) exportUIDMap() error {
+	uids := &uidMap{
+		AddressMode:      store.addressMode,
+		MailboxesVersion: store.readMailboxesVersion(),
+		Mailboxes:        map[string]*mailboxUIDMap{},
+	}
+
+	err := store.db.View(func(tx *bolt.Tx) error {
+		versions := tx.Bucket(mboxVersionBucket)
+		mbs := tx.Bucket(mailboxesBucket)
+		return mbs.ForEach(func(name, v []byte) error {
+			mb := mbs.Bucket(name)
+			if v != nil || mb == nil || mb.Bucket(imapIDsBucket) == nil {
+				return nil
+			}
+
+			mbUIDs := &mailboxUIDMap{
+				LastUID:  mb.Bucket(imapIDsBucket).Sequence(),
+				UIDs:     map[string]uint32{},
+				Vanished: map[uint32]uint64{},
+			}
+			if bump := versions.Get(name); bump != nil {
+				mbUIDs.UIDValidityBump = btoi(bump)
+			}
+			if modSeqs := mb.Bucket(modSeqsBucket); modSeqs != nil {
+				mbUIDs.LastModSeq = modSeqs.Sequence()
+			}
+			if vanished := mb.Bucket(vanishedBucket); vanished != nil {
+				if err := vanished.ForEach(func(uidb, modSeqb []byte) error {
+					mbUIDs.Vanished[btoi(uidb)] = bytesToModSeq(modSeqb)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			if reserved := mb.Bucket(reservedUIDsBucket); reserved != nil {
+				if err := reserved.ForEach(func(apiID, uidb []byte) error {
+					mbUIDs.UIDs[string(apiID)] = btoi(uidb)
+					return nil
+				}); err != nil {
+					return err
+				}
+			}
+			if err := mb.Bucket(imapIDsBucket).ForEach(func(uidb, apiID []byte) error {
+				mbUIDs.UIDs[string(apiID)] = btoi(uidb)
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			uids.Mailboxes[string(name)] = mbUIDs
+			return nil
+		})
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to read UIDs")
+	}
+
+	data, err := json.Marshal(uids)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first to never leave a broken map behind.
+	path := getUIDMapPath(store.filePath)
+	if err := ioutil.WriteFile(path+".tmp", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// importUIDMap prepares the new database to assign the same UIDs as were
+// exported from the previous one. Messages get their previous UID when they
+// are synced again; all other messages get UIDs above the last exported one.
+func (store *Store) importUIDMap() error {
+	data, err := ioutil.ReadFile(getUIDMapPath(store.filePath))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	uids := &uidMap{}
+	if err := json.Unmarshal(data, uids); err != nil {
+		return errors.Wrap(err, "failed to parse UID map")
+	}
+
+	// Mailboxes of the other mode contain different messages and the UIDs
+	// cannot be reused. Clients have to be told to drop them by UIDVALIDITY
+	// higher than any mailbox had before.
+	if uids.AddressMode != store.addressMode {
+		store.log.WithField("mode", uids.AddressMode).Warn("UID map belongs to other address mode")
+		maxBump := uint32(0)
+		for _, mbUIDs := range uids.Mailboxes {
+			if mbUIDs.UIDValidityBump > maxBump {
+				maxBump = mbUIDs.UIDValidityBump
+			}
+		}
+		return store.writeMailboxesVersion(uids.MailboxesVersion + maxBump + 1)
+	}
+
+	if err := store.writeMailboxesVersion(uids.MailboxesVersion); err != nil {
+		return err
+	}
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+		for name, mbUIDs := range uids.Mailboxes {
+			if err := initMailboxBucket(tx, []byte(name)); err != nil {
+				return err
+			}
+			if mbUIDs.UIDValidityBump != 0 {
+				if err := tx.Bucket(mboxVersionBucket).Put([]byte(name), itob(mbUIDs.UIDValidityBump)); err != nil {
+					return err
+				}
+			}
+			mb := tx.Bucket(mailboxesBucket).Bucket([]byte(name))
+
+			if err := mb.Bucket(imapIDsBucket).SetSequence(mbUIDs.LastUID); err != nil {
+				return err
+			}
+			if err := mb.Bucket(modSeqsBucket).SetSequence(mbUIDs.LastModSeq); err != nil {
+				return err
+			}
+
+			reserved, err := mb.CreateBucketIfNotExists(reservedUIDsBucket)
+			if err != nil {
+				return err
+			}
+			for apiID, uid := range mbUIDs.UIDs {
+				if err := reserved.Put([]byte(apiID), itob(uid)); err != nil {
+					return err
+				}
+			}
+
+			vanished := mb.Bucket(vanishedBucket)
+			for uid, modSeq := range mbUIDs.Vanished {
+				if err := vanished.Put(itob(uid), modSeqToBytes(modSeq)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// releaseReservedUIDs is called after the first sync of the new database.
+// UIDs restored from the UID map whose messages did not come back were
+// removed meanwhile; they are marked as vanished for QRESYNC clients.
+func (store *Store) releaseReservedUIDs() error {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	return store.db.Update(func(tx *bolt.Tx) error {
+		for _, address := range store.addresses {
+			for _, storeMailbox := range address.mailboxes {
+				if err := storeMailbox.txReleaseReservedUIDs(tx); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (storeMailbox *Mailbox) txReleaseReservedUIDs(tx *bolt.Tx) error {
+	mb := storeMailbox.txGetBucket(tx)
+	reserved := mb.Bucket(reservedUIDsBucket)
+	if reserved == nil {
+		return nil
+	}
+
+	imapBucket := storeMailbox.txGetIMAPIDsBucket(tx)
+
+	var vanished [][]byte
+	if err := reserved.ForEach(func(apiID, uidb []byte) error {
+		if imapBucket.Get(uidb) == nil {
+			vanished = append(vanished, append([]byte{}, uidb...))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, uidb := range vanished {
+		if err := storeMailbox.txMarkVanished(tx, uidb); err != nil {
+			return err
+		}
+	}
+
+	return mb.DeleteBucket(reservedUIDsBucket)
+}
+
+// txGetUIDForNewMessage returns the UID the message had in the previous
+// database, or the next UID of the mailbox if it is a new message.
+func (storeMailbox *Mailbox) txGetUIDForNewMessage(tx *bolt.Tx, imapBucket *bolt.Bucket, apiID string) (uint32, error) {
+	if reserved := storeMailbox.txGetBucket(tx).Bucket(reservedUIDsBucket); reserved != nil {
+		if uidb := reserved.Get([]byte(apiID)); uidb != nil {
+			uid := btoi(uidb)
+			if err := reserved.Delete([]byte(apiID)); err != nil {
+				return 0, err
+			}
+			// UID must not be used by other message if the map was stale.
+			if imapBucket.Get(uidb) == nil {
+				return uid, nil
+			}
+		}
+	}
+	return storeMailbox.txGetNextUID(imapBucket, true)
+}
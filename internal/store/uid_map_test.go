@@ -0,0 +1,160 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUIDMapSurvivesDatabaseRemoval(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg3", "Test message 3", addrID1, 0, []string{pmapi.AllMailLabel})
+	insertMessage(t, m, "msg4", "Test message 4", addrID1, 0, []string{pmapi.AllMailLabel})
+	require.NoError(t, m.store.deleteMessageEvent("msg3"))
+
+	storeMailbox := m.store.addresses[addrID1].mailboxes[pmapi.AllMailLabel]
+	uidValidity := storeMailbox.UIDValidity()
+	highestModSeq, err := storeMailbox.GetHighestModSeq()
+	require.NoError(t, err)
+
+	removeDatabase(t, m)
+
+	// msg4 was removed while there was no database.
+	m.newStoreSyncingMessages(true, []*pmapi.Message{
+		getTestMessage("msg5", "Test message 5", addrID1, 0, []string{pmapi.AllMailLabel}),
+		getTestMessage("msg2", "Test message 2", addrID1, 0, []string{pmapi.AllMailLabel}),
+		getTestMessage("msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel}),
+	})
+
+	storeMailbox = m.store.addresses[addrID1].mailboxes[pmapi.AllMailLabel]
+	require.Equal(t, uidValidity, storeMailbox.UIDValidity())
+
+	// Reserved UIDs which did not come back are released after the sync.
+	require.Eventually(t, func() bool {
+		vanished, err := storeMailbox.GetVanishedUIDs(highestModSeq)
+		return err == nil && len(vanished) == 1 && vanished[0] == 4
+	}, time.Second, 10*time.Millisecond)
+
+	for apiID, wantUID := range map[string]uint32{"msg1": 1, "msg2": 2, "msg5": 5} {
+		uid, err := storeMailbox.getUID(apiID)
+		require.NoError(t, err)
+		require.Equal(t, wantUID, uid, apiID)
+	}
+
+	modSeq, err := storeMailbox.GetModSeq("msg2")
+	require.NoError(t, err)
+	require.True(t, modSeq > highestModSeq)
+
+	vanished, err := storeMailbox.GetVanishedUIDs(0)
+	require.NoError(t, err)
+	require.Equal(t, []uint32{3, 4}, vanished)
+}
+
+func TestUIDMapRemovedWithStore(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addrID1, 0, []string{pmapi.AllMailLabel})
+	require.NoError(t, m.store.exportUIDMap())
+
+	uidMapPath := getUIDMapPath(m.store.filePath)
+	require.FileExists(t, uidMapPath)
+
+	require.NoError(t, m.store.Remove())
+	_, err := os.Stat(uidMapPath)
+	require.True(t, os.IsNotExist(err))
+}
+
+// removeDatabase removes the database file the same way as clearing
+// the cache does.
+func removeDatabase(t *testing.T, m *mocksForStore) {
+	require.NoError(t, m.store.Close())
+	require.NoError(t, os.Remove(m.store.filePath))
+}
+
+// newStoreSyncingMessages creates the store the same way as newStoreNoEvents
+// but the first sync downloads the given messages.
+func (mocks *mocksForStore) newStoreSyncingMessages(combinedMode bool, messages []*pmapi.Message) {
+	mocks.user.EXPECT().ID().Return("userID").AnyTimes()
+	mocks.user.EXPECT().IsConnected().Return(true)
+	mocks.user.EXPECT().IsCombinedAddressMode().Return(combinedMode)
+
+	mocks.clientManager.EXPECT().GetClient("userID").AnyTimes().Return(mocks.client)
+
+	mocks.client.EXPECT().Addresses().Return(pmapi.AddressList{
+		{ID: addrID1, Email: addr1, Type: pmapi.OriginalAddress, Receive: pmapi.CanReceive},
+		{ID: addrID2, Email: addr2, Type: pmapi.AliasAddress, Receive: pmapi.CanReceive},
+	})
+	mocks.client.EXPECT().ListLabels()
+	mocks.client.EXPECT().CountMessages("")
+	mocks.client.EXPECT().GetEvent(gomock.Any()).
+		Return(&pmapi.Event{
+			EventID: "latestEventID",
+		}, nil).AnyTimes()
+	mocks.client.EXPECT().ListMessages(gomock.Any()).Return(messages, len(messages), nil).AnyTimes()
+
+	var err error
+	mocks.store, err = New(
+		mocks.panicHandler,
+		mocks.user,
+		mocks.clientManager,
+		mocks.events,
+		filepath.Join(mocks.tmpDir, "mailbox-test.db"),
+		mocks.cache,
+	)
+	require.NoError(mocks.tb, err)
+}
+
+func TestUIDMapOfOtherAddressMode(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	insertMessage(t, m, "msg1", "Test message 1", addr1, 0, []string{pmapi.AllMailLabel})
+	uidValidity := m.store.addresses[addrID1].mailboxes[pmapi.AllMailLabel].UIDValidity()
+
+	removeDatabase(t, m)
+	m.newStoreNoEvents(false)
+
+	msg := getTestMessage("msg2", "Test message 2", addr1, 0, []string{pmapi.AllMailLabel})
+	msg.AddressID = addrID1
+	require.NoError(t, m.store.createOrUpdateMessageEvent(msg))
+
+	storeMailbox := m.store.addresses[addrID1].mailboxes[pmapi.AllMailLabel]
+	require.NotEqual(t, uidValidity, storeMailbox.UIDValidity())
+
+	uid, err := storeMailbox.getUID("msg2")
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), uid)
+}
@@ -163,26 +163,8 @@ func (store *Store) truncateMailboxesBucket() (err error) {
 	tx := func(tx *bolt.Tx) (err error) {
 		mbs := tx.Bucket(mailboxesBucket)
 
-		return mbs.ForEach(func(addrIDMailbox, _ []byte) (err error) {
-			addr := mbs.Bucket(addrIDMailbox)
-
-			if err = addr.DeleteBucket(imapIDsBucket); err != nil {
-				return
-			}
-
-			if _, err = addr.CreateBucketIfNotExists(imapIDsBucket); err != nil {
-				return
-			}
-
-			if err = addr.DeleteBucket(apiIDsBucket); err != nil {
-				return
-			}
-
-			if _, err = addr.CreateBucketIfNotExists(apiIDsBucket); err != nil {
-				return
-			}
-
-			return
+		return mbs.ForEach(func(addrIDMailbox, _ []byte) error {
+			return txTruncateMailboxBucket(mbs.Bucket(addrIDMailbox))
 		})
 	}
 
@@ -235,3 +217,40 @@ func (store *Store) initMailboxesBucket() error {
 		return nil
 	})
 }
+
+// txTruncateMailboxBucket removes all messages and their UIDs from the bucket
+// of one mailbox.
+func txTruncateMailboxBucket(addr *bolt.Bucket) (err error) {
+	if err = addr.DeleteBucket(imapIDsBucket); err != nil {
+		return
+	}
+
+	if _, err = addr.CreateBucketIfNotExists(imapIDsBucket); err != nil {
+		return
+	}
+
+	if err = addr.DeleteBucket(apiIDsBucket); err != nil {
+		return
+	}
+
+	if _, err = addr.CreateBucketIfNotExists(apiIDsBucket); err != nil {
+		return
+	}
+
+	// Mod-sequences belong to the old UIDs.
+	for _, bucket := range [][]byte{modSeqsBucket, vanishedBucket} {
+		if err = addr.DeleteBucket(bucket); err != nil && err != bolt.ErrBucketNotFound {
+			return
+		}
+		if _, err = addr.CreateBucketIfNotExists(bucket); err != nil {
+			return
+		}
+	}
+
+	// UIDs from the UID map are not valid with the new UIDVALIDITY.
+	if err = addr.DeleteBucket(reservedUIDsBucket); err != nil && err != bolt.ErrBucketNotFound {
+		return
+	}
+
+	return nil
+}
@@ -28,12 +28,19 @@ import (
 // createMailbox creates the mailbox via the API.
 // The store mailbox is created later by processing an event.
 func (store *Store) createMailbox(name string) error {
+	_, err := store.createLabel(name)
+	return err
+}
+
+// createLabel creates the label for the mailbox via the API and returns it.
+// No label is created for mailboxes in the root.
+func (store *Store) createLabel(name string) (*pmapi.Label, error) {
 	defer store.eventLoop.pollNow()
 
 	log.WithField("name", name).Debug("Creating mailbox")
 
 	if store.hasMailbox(name) {
-		return fmt.Errorf("mailbox %v already exists", name)
+		return nil, fmt.Errorf("mailbox %v already exists", name)
 	}
 
 	color := store.leastUsedColor()
@@ -46,22 +53,23 @@ func (store *Store) createMailbox(name string) error {
 	case strings.HasPrefix(name, UserFoldersPrefix):
 		name = strings.TrimPrefix(name, UserFoldersPrefix)
 		exclusive = 1
+	case strings.HasPrefix(name, SavedSearchesPrefix):
+		return nil, errSavedSearchCreateOverIMAP
 	default:
 		// Ideally we would throw an error here, but then Outlook for
 		// macOS keeps trying to make an IMAP Drafts folder and popping
 		// up the error to the user.
 		store.log.WithField("name", name).
 			Warn("Ignoring creation of new mailbox in IMAP root")
-		return nil
+		return nil, nil
 	}
 
-	_, err := store.client().CreateLabel(&pmapi.Label{
+	return store.client().CreateLabel(&pmapi.Label{
 		Name:      name,
 		Color:     color,
 		Exclusive: exclusive,
 		Type:      pmapi.LabelTypeMailbox,
 	})
-	return err
 }
 
 // allAddressesHaveMailbox returns whether each address has a mailbox with the given labelID.
@@ -105,6 +113,24 @@ func (store *Store) getMailbox(name string) (*Mailbox, error) {
 	return nil, fmt.Errorf("mailbox %s does not exist", name)
 }
 
+// ListLabels returns user's folders and labels with their colors sorted
+// by the order set in the web client.
+func (store *Store) ListLabels() ([]*pmapi.Label, error) {
+	labels, err := store.getLabelsFromLocalStorage()
+	if err != nil {
+		return nil, err
+	}
+
+	userLabels := []*pmapi.Label{}
+	for _, label := range labels {
+		if !pmapi.IsSystemLabel(label.ID) {
+			userLabels = append(userLabels, label)
+		}
+	}
+
+	return userLabels, nil
+}
+
 // leastUsedColor returns the least used color to be used for a newly created folder or label.
 func (store *Store) leastUsedColor() string {
 	store.lock.RLock()
@@ -211,6 +237,7 @@ func (store *Store) deleteMailboxEvent(labelID string) error {
 	defer store.lock.Unlock()
 
 	_ = store.removeMailboxCount(labelID)
+	_ = store.SetSubscribed(labelID, true)
 
 	for _, a := range store.addresses {
 		if err := a.deleteMailboxEvent(labelID); err != nil {
@@ -132,6 +132,12 @@ func (store *Store) getAllMessageIDs() (apiIDs []string, err error) {
 	return
 }
 
+// HasMessage returns whether the message with the API ID is in the store.
+func (store *Store) HasMessage(apiID string) bool {
+	_, err := store.getMessageFromDB(apiID)
+	return err == nil
+}
+
 // getMessageFromDB returns pmapi struct of message by API ID.
 func (store *Store) getMessageFromDB(apiID string) (msg *pmapi.Message, err error) {
 	err = store.db.View(func(tx *bolt.Tx) error {
@@ -165,6 +171,8 @@ func (store *Store) txPutMessage(metaBucket *bolt.Bucket, onlyMeta *pmapi.Messag
 	if err != nil {
 		return errors.Wrap(err, "cannot add to metadata bucket")
 	}
+	store.txInvalidateMailboxStatus(metaBucket.Tx(), onlyMeta.LabelIDs...)
+	store.txInvalidateMailboxStatus(metaBucket.Tx(), txGetSavedSearchLabelIDs(metaBucket.Tx())...)
 	return nil
 }
 
@@ -203,14 +211,13 @@ func (store *Store) createOrUpdateMessagesEvent(msgs []*pmapi.Message) error { /
 		return err
 	}
 
-	// Updating metadata and mailboxes is not atomic, but this is OK.
-	// The worst case scenario is we have metadata but not updated mailboxes
-	// which is OK as without information in mailboxes IMAP we will never ask
-	// for metadata. Also, when doing the operation again, it will simply
-	// update the metadata.
-	// The reason to split is efficiency--it's more memory efficient.
+	// Updating metadata and mailboxes is split into two transactions as it is
+	// more memory efficient. Metadata are always written first so mailboxes
+	// never reference missing messages, and the journal entry written along
+	// with them lets recoverStore finish the mailboxes after a crash.
 
 	// Update metadata.
+	var journalKey []byte
 	err = store.db.Update(func(tx *bolt.Tx) error {
 		metaBucket := tx.Bucket(metadataBucket)
 		for _, msg := range msgs {
@@ -219,7 +226,8 @@ func (store *Store) createOrUpdateMessagesEvent(msgs []*pmapi.Message) error { /
 				return err
 			}
 		}
-		return nil
+		journalKey, err = store.txBeginJournal(tx, msgs)
+		return err
 	})
 	if err != nil {
 		return err
@@ -227,6 +235,9 @@ func (store *Store) createOrUpdateMessagesEvent(msgs []*pmapi.Message) error { /
 
 	// Update mailboxes.
 	err = store.db.Update(func(tx *bolt.Tx) error {
+		if err := txCommitJournal(tx, journalKey); err != nil {
+			return err
+		}
 		for _, a := range store.addresses {
 			if err := a.txCreateOrUpdateMessages(tx, msgs); err != nil {
 				store.log.WithError(err).Error("cannot update maiboxes")
@@ -305,6 +316,10 @@ func (store *Store) deleteMessagesEvent(apiIDs []string) error {
 				return err
 			}
 
+			if err := txDeleteProblem(tx, apiID); err != nil {
+				return err
+			}
+
 			for _, a := range store.addresses {
 				if err := a.txDeleteMessage(tx, apiID); err != nil {
 					return err
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// IsSubscribed returns whether the mailbox with given label ID is subscribed.
+// Mailboxes are subscribed by default; only unsubscribed ones are persisted.
+func (store *Store) IsSubscribed(labelID string) (subscribed bool) {
+	subscribed = true
+
+	tx := func(tx *bolt.Tx) error {
+		subscribed = tx.Bucket(unsubscribedBucket).Get([]byte(labelID)) == nil
+		return nil
+	}
+
+	if err := store.db.View(tx); err != nil {
+		store.log.WithError(err).Warn("Cannot read subscription state")
+	}
+
+	return
+}
+
+// SetSubscribed subscribes or unsubscribes the mailbox with given label ID.
+// The state is shared by all addresses of the account and survives restarts.
+func (store *Store) SetSubscribed(labelID string, subscribed bool) error {
+	return store.db.Update(func(tx *bolt.Tx) error {
+		return txSetSubscribed(tx, labelID, subscribed)
+	})
+}
+
+func txSetSubscribed(tx *bolt.Tx, labelID string, subscribed bool) error {
+	b := tx.Bucket(unsubscribedBucket)
+	if subscribed {
+		return b.Delete([]byte(labelID))
+	}
+	return b.Put([]byte(labelID), []byte{})
+}
+
+// importSubscriptionExceptions moves mailboxes unsubscribed by older versions
+// from the cache file to the database. It is done only once because the
+// exceptions are removed from the cache afterwards.
+func (store *Store) importSubscriptionExceptions() error {
+	labelIDs := store.cache.getSubscriptionExceptions(store.UserID())
+	if len(labelIDs) == 0 {
+		return nil
+	}
+
+	store.log.WithField("count", len(labelIDs)).Info("Importing unsubscribed mailboxes from cache")
+
+	if err := store.db.Update(func(tx *bolt.Tx) error {
+		for _, labelID := range labelIDs {
+			if err := txSetSubscribed(tx, labelID, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return store.cache.clearSubscriptionExceptions(store.UserID())
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package store
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscription(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	require.True(t, m.store.IsSubscribed(pmapi.InboxLabel))
+	require.True(t, m.store.IsSubscribed(pmapi.SpamLabel))
+
+	require.NoError(t, m.store.SetSubscribed(pmapi.SpamLabel, false))
+	require.True(t, m.store.IsSubscribed(pmapi.InboxLabel))
+	require.False(t, m.store.IsSubscribed(pmapi.SpamLabel))
+
+	// Unsubscribing twice is not an error.
+	require.NoError(t, m.store.SetSubscribed(pmapi.SpamLabel, false))
+	require.False(t, m.store.IsSubscribed(pmapi.SpamLabel))
+
+	require.NoError(t, m.store.SetSubscribed(pmapi.SpamLabel, true))
+	require.True(t, m.store.IsSubscribed(pmapi.SpamLabel))
+}
+
+func TestSubscriptionResetWhenMailboxDeleted(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	m.newStoreNoEvents(true)
+
+	require.NoError(t, m.store.SetSubscribed("labelID", false))
+	require.False(t, m.store.IsSubscribed("labelID"))
+
+	require.NoError(t, m.store.deleteMailboxEvent("labelID"))
+	require.True(t, m.store.IsSubscribed("labelID"))
+}
+
+func TestSubscriptionImportFromCache(t *testing.T) {
+	m, clear := initMocks(t)
+	defer clear()
+
+	require.NoError(t, ioutil.WriteFile(m.cache.path, []byte(`{"userID":{"events":"eventID","subscription_exceptions":"labelID1;labelID2"}}`), 0600))
+
+	m.newStoreNoEvents(true)
+
+	require.True(t, m.store.IsSubscribed(pmapi.InboxLabel))
+	require.False(t, m.store.IsSubscribed("labelID1"))
+	require.False(t, m.store.IsSubscribed("labelID2"))
+
+	// Exceptions are imported only once.
+	require.Empty(t, m.cache.getSubscriptionExceptions("userID"))
+
+	cacheData, err := ioutil.ReadFile(m.cache.path)
+	require.NoError(t, err)
+	require.NotContains(t, string(cacheData), subscriptionExceptionsKey)
+}
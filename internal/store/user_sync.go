@@ -75,7 +75,7 @@ func (store *Store) isSynced(countsOnAPI []*pmapi.MessagesCount) (bool, error) {
 				)
 			}
 
-			mboxTot, mboxUnread, _, err := mbox.GetCounts()
+			mboxTot, mboxUnread, _, _, err := mbox.GetStatus()
 			if err != nil {
 				errW := errors.Wrap(err, "cannot count messages")
 				store.log.
@@ -116,6 +116,11 @@ func (store *Store) isSynced(countsOnAPI []*pmapi.MessagesCount) (bool, error) {
 //  * Database has only syncStateKey with time when database was last synced.
 //    `triggerSync` will reset it and start full sync again.
 func (store *Store) triggerSync() {
+	if IsRelayOnlyMode() {
+		store.log.Debug("Store sync skipped in relay-only mode")
+		return
+	}
+
 	syncState := store.loadSyncState()
 
 	// We first clear the last sync state in case this sync fails.
@@ -152,7 +157,9 @@ func (store *Store) triggerSync() {
 
 		store.log.WithField("isIncomplete", syncState.isIncomplete()).Info("Store sync started")
 
-		err := syncAllMail(store.panicHandler, store, func() messageLister { return store.client() }, syncState)
+		run := newSyncRun(store, syncState.isIncomplete())
+		err := syncAllMail(store.panicHandler, run, func() messageLister { return store.client() }, syncState)
+		store.recordSyncRun(run.finish(err))
 		if err != nil {
 			log.WithError(err).Error("Store sync failed")
 			store.syncCooldown.increaseWaitTime()
@@ -161,6 +168,14 @@ func (store *Store) triggerSync() {
 
 		store.syncCooldown.reset()
 		syncState.setFinishTime()
+
+		if err := store.releaseReservedUIDs(); err != nil {
+			store.log.WithError(err).Warn("Could not release reserved UIDs")
+		}
+
+		if err := store.exportUIDMap(); err != nil {
+			store.log.WithError(err).Warn("Could not export UID map")
+		}
 	}()
 }
 
@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bandwidth is shared by all transfers and their workers so the limit
+// applies to the whole app, not to every upload separately.
+var bandwidth = &bandwidthLimiter{} //nolint[gochecknoglobals]
+
+// SetMaxBandwidth sets the maximum upload speed in bytes per second.
+// Zero means unlimited. It can be changed while the transfer is running.
+func SetMaxBandwidth(bytesPerSecond uint64) {
+	bandwidth.setRate(bytesPerSecond)
+}
+
+// GetMaxBandwidth returns the maximum upload speed in bytes per second.
+func GetMaxBandwidth() uint64 {
+	return bandwidth.getRate()
+}
+
+// ParseBandwidth parses limit like `2MB/s`, `512 KB` or `unlimited`.
+// Units are multiples of 1024 to match sizes shown in estimates.
+func ParseBandwidth(limit string) (uint64, error) {
+	value := strings.ToUpper(strings.TrimSpace(limit))
+	value = strings.TrimSuffix(value, "/S")
+	value = strings.TrimSuffix(value, "PS")
+	bytesPerSecond, ok := parseSize(value)
+	if !ok {
+		return 0, fmt.Errorf("invalid bandwidth %q, use for example 2MB/s or unlimited", limit)
+	}
+	return bytesPerSecond, nil
+}
+
+// ParseSize parses size like `2GB`, `512 KB` or `off`, which is zero.
+// Units are multiples of 1024 as with bandwidth.
+func ParseSize(size string) (uint64, error) {
+	bytes, ok := parseSize(strings.ToUpper(strings.TrimSpace(size)))
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q, use for example 2GB or off", size)
+	}
+	return bytes, nil
+}
+
+// parseSize parses upper-cased trimmed size.
+func parseSize(value string) (uint64, bool) {
+	if value == "" || value == "0" || value == "UNLIMITED" || value == "OFF" {
+		return 0, true
+	}
+
+	multiplier := uint64(1)
+	for exp, prefix := range []string{"K", "M", "G"} {
+		for _, suffix := range []string{prefix + "IB", prefix + "B", prefix} {
+			if strings.HasSuffix(value, suffix) {
+				value = strings.TrimSuffix(value, suffix)
+				multiplier = 1 << (10 * uint(exp+1))
+				break
+			}
+		}
+		if multiplier != 1 {
+			break
+		}
+	}
+	if multiplier == 1 {
+		value = strings.TrimSuffix(value, "B")
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return 0, false
+	}
+	return uint64(number * float64(multiplier)), true
+}
+
+// FormatBandwidth returns human readable limit.
+func FormatBandwidth(bytesPerSecond uint64) string {
+	if bytesPerSecond == 0 {
+		return "unlimited"
+	}
+	return formatSize(bytesPerSecond) + "/s"
+}
+
+// bandwidthLimiter delays callers so the average speed of all of them
+// together does not exceed the rate.
+type bandwidthLimiter struct {
+	lock sync.Mutex
+	rate uint64
+	next time.Time
+}
+
+func (l *bandwidthLimiter) setRate(rate uint64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.rate = rate
+	l.next = time.Time{}
+}
+
+func (l *bandwidthLimiter) getRate() uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.rate
+}
+
+// wait blocks until size bytes can be sent. Time is reserved at most
+// one second at once, therefore a changed rate is applied also to big
+// requests which are already waiting.
+func (l *bandwidthLimiter) wait(size uint64) {
+	for size > 0 {
+		delay, reserved := l.reserve(size)
+		if reserved == 0 {
+			return
+		}
+		time.Sleep(delay)
+		size -= reserved
+	}
+}
+
+func (l *bandwidthLimiter) reserve(size uint64) (time.Duration, uint64) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.rate == 0 {
+		return 0, 0
+	}
+	if size > l.rate {
+		size = l.rate
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(size) / float64(l.rate) * float64(time.Second)))
+	return l.next.Sub(now), size
+}
+
+// bandwidthReader limits reading of uploaded data, e.g. attachments.
+type bandwidthReader struct {
+	io.Reader
+}
+
+func (r *bandwidthReader) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	bandwidth.wait(uint64(n))
+	return
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		limit   string
+		want    uint64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"unlimited", 0, false},
+		{"0", 0, false},
+		{"2MB/s", 2 * 1024 * 1024, false},
+		{"512 KB", 512 * 1024, false},
+		{"1.5MiB/s", 3 * 512 * 1024, false},
+		{"1g", 1024 * 1024 * 1024, false},
+		{"800Bps", 800, false},
+		{"fast", 0, true},
+		{"-1MB/s", 0, true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.limit, func(t *testing.T) {
+			got, err := ParseBandwidth(tc.limit)
+			if tc.wantErr {
+				r.Error(t, err)
+				return
+			}
+			r.NoError(t, err)
+			r.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	size, err := ParseSize("2GB")
+	r.NoError(t, err)
+	r.Equal(t, uint64(2*1024*1024*1024), size)
+
+	size, err = ParseSize("off")
+	r.NoError(t, err)
+	r.Equal(t, uint64(0), size)
+
+	_, err = ParseSize("2MB/s")
+	r.Error(t, err)
+}
+
+func TestFormatBandwidth(t *testing.T) {
+	r.Equal(t, "unlimited", FormatBandwidth(0))
+	r.Equal(t, "2.0 MB/s", FormatBandwidth(2*1024*1024))
+}
+
+func TestBandwidthLimiterIsShared(t *testing.T) {
+	limiter := &bandwidthLimiter{}
+	limiter.setRate(10000)
+
+	start := time.Now()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter.wait(500)
+		}()
+	}
+	wg.Wait()
+
+	r.True(t, time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestBandwidthLimiterUnlimited(t *testing.T) {
+	limiter := &bandwidthLimiter{}
+
+	start := time.Now()
+	limiter.wait(1024 * 1024 * 1024)
+	r.True(t, time.Since(start) < 100*time.Millisecond)
+}
+
+func TestBandwidthLimiterRateChangeWhileWaiting(t *testing.T) {
+	limiter := &bandwidthLimiter{}
+	limiter.setRate(1000)
+
+	done := make(chan struct{})
+	go func() {
+		limiter.wait(10000)
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	limiter.setRate(0)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("limit change was not applied to the waiting upload")
+	}
+}
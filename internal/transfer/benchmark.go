@@ -0,0 +1,245 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+)
+
+// Names of the measured stages of the transfer.
+const (
+	BenchmarkDiskRead   = "Disk read"
+	BenchmarkDecryption = "Decryption"
+	BenchmarkUpload     = "API upload"
+)
+
+// Amount of synthetic data used for each stage. Bigger samples are more
+// precise but the benchmark should take only a few seconds.
+const (
+	benchmarkDiskSample       = 64 * 1024 * 1024
+	benchmarkDecryptionSample = 8 * 1024 * 1024
+	benchmarkUploadSample     = 4 * 1024 * 1024
+)
+
+// BenchmarkStage is the measured throughput of one stage of the transfer.
+type BenchmarkStage struct {
+	Name           string
+	BytesPerSecond float64
+	Err            error
+}
+
+// Benchmark holds measured stages together with the planned migration.
+type Benchmark struct {
+	Messages    uint
+	MessageSize uint64
+	Stages      []BenchmarkStage
+}
+
+// Uploader sends the given amount of data and returns bytes per second.
+type Uploader func(size int) (float64, error)
+
+// RunBenchmark measures disk read of files in dir, decryption of synthetic
+// messages of messageSize and API upload. When dir has no files to read,
+// synthetic file is written there first; it might be read from the OS cache
+// then, so pointing dir to the migration source gives more realistic speed.
+func RunBenchmark(messages uint, messageSize uint64, dir string, upload Uploader) Benchmark {
+	b := Benchmark{
+		Messages:    messages,
+		MessageSize: messageSize,
+	}
+
+	speed, err := measureDiskRead(dir, benchmarkDiskSample)
+	b.Stages = append(b.Stages, BenchmarkStage{Name: BenchmarkDiskRead, BytesPerSecond: speed, Err: err})
+
+	speed, err = measureDecryption(int(messageSize), benchmarkDecryptionSample)
+	b.Stages = append(b.Stages, BenchmarkStage{Name: BenchmarkDecryption, BytesPerSecond: speed, Err: err})
+
+	speed, err = upload(benchmarkUploadSample)
+	b.Stages = append(b.Stages, BenchmarkStage{Name: BenchmarkUpload, BytesPerSecond: speed, Err: err})
+
+	return b
+}
+
+// Duration returns how long the planned migration would take at the speed of the stage.
+func (b Benchmark) Duration(stage BenchmarkStage) time.Duration {
+	if stage.Err != nil || stage.BytesPerSecond <= 0 {
+		return 0
+	}
+	total := float64(b.Messages) * float64(b.MessageSize)
+	return time.Duration(total / stage.BytesPerSecond * float64(time.Second))
+}
+
+// Bottleneck returns the slowest successfully measured stage or nil.
+func (b Benchmark) Bottleneck() *BenchmarkStage {
+	var slowest *BenchmarkStage
+	for i := range b.Stages {
+		stage := &b.Stages[i]
+		if stage.Err != nil {
+			continue
+		}
+		if slowest == nil || stage.BytesPerSecond < slowest.BytesPerSecond {
+			slowest = stage
+		}
+	}
+	return slowest
+}
+
+// String returns the benchmark result in human readable form.
+func (b Benchmark) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "Planned migration: %d messages, %s\n", b.Messages, formatSize(uint64(b.Messages)*b.MessageSize))
+	for _, stage := range b.Stages {
+		if stage.Err != nil {
+			fmt.Fprintf(&sb, "%-12s failed: %v\n", stage.Name, stage.Err)
+			continue
+		}
+		fmt.Fprintf(&sb, "%-12s %12s/s  %s\n", stage.Name, formatSize(uint64(stage.BytesPerSecond)), formatBenchmarkDuration(b.Duration(stage)))
+	}
+
+	if bottleneck := b.Bottleneck(); bottleneck != nil {
+		fmt.Fprintf(&sb, "Bottleneck: %s, migration would take %s", bottleneck.Name, formatBenchmarkDuration(b.Duration(*bottleneck)))
+	} else {
+		sb.WriteString("Bottleneck: unknown, no stage was measured")
+	}
+
+	return sb.String()
+}
+
+func formatBenchmarkDuration(duration time.Duration) string {
+	if duration < time.Second {
+		return "less than a second"
+	}
+	return "about " + duration.Round(time.Second).String()
+}
+
+// measureDiskRead reads up to sample bytes of files in dir.
+func measureDiskRead(dir string, sample int64) (float64, error) {
+	var paths []string
+	_ = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() && info.Size() > 0 {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+
+	if len(paths) == 0 {
+		path, err := writeSyntheticFile(dir, sample)
+		if err != nil {
+			return 0, err
+		}
+		defer os.Remove(path) //nolint[errcheck]
+		paths = []string{path}
+	}
+
+	var read int64
+	start := time.Now()
+	for _, path := range paths {
+		n, err := readFile(path, sample-read)
+		if err != nil {
+			return 0, err
+		}
+		if read += n; read >= sample {
+			break
+		}
+	}
+
+	return float64(read) / time.Since(start).Seconds(), nil
+}
+
+func readFile(path string, limit int64) (int64, error) {
+	f, err := os.Open(path) //nolint[gosec]
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	return io.Copy(ioutil.Discard, io.LimitReader(f, limit))
+}
+
+func writeSyntheticFile(dir string, size int64) (string, error) {
+	f, err := ioutil.TempFile(dir, "benchmark")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	if _, err := io.CopyN(f, rand.Reader, size); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// measureDecryption decrypts sample bytes split to messages of messageSize.
+// RSA key is used as it is the slower and still the most common key type.
+func measureDecryption(messageSize, sample int) (float64, error) {
+	if messageSize <= 0 {
+		return 0, errors.New("message size must be positive")
+	}
+
+	key, err := crypto.GenerateKey("Benchmark", "benchmark@localhost", "rsa", 2048)
+	if err != nil {
+		return 0, err
+	}
+	keyRing, err := crypto.NewKeyRing(key)
+	if err != nil {
+		return 0, err
+	}
+
+	body := bytes.Repeat([]byte("Lorem ipsum dolor sit amet.\r\n"), messageSize/29+1)[:messageSize]
+
+	count := sample / messageSize
+	if count == 0 {
+		count = 1
+	}
+
+	encrypted := make([]*crypto.PGPMessage, 0, count)
+	for i := 0; i < count; i++ {
+		msg, err := keyRing.Encrypt(crypto.NewPlainMessage(body), nil)
+		if err != nil {
+			return 0, err
+		}
+		encrypted = append(encrypted, msg)
+	}
+
+	start := time.Now()
+	for _, msg := range encrypted {
+		if _, err := keyRing.Decrypt(msg, nil, 0); err != nil {
+			return 0, err
+		}
+	}
+
+	return float64(count*messageSize) / time.Since(start).Seconds(), nil
+}
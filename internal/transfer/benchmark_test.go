@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestBenchmarkBottleneck(t *testing.T) {
+	b := Benchmark{
+		Messages:    1000,
+		MessageSize: 1024 * 1024,
+		Stages: []BenchmarkStage{
+			{Name: BenchmarkDiskRead, BytesPerSecond: 100 * 1024 * 1024},
+			{Name: BenchmarkDecryption, BytesPerSecond: 10 * 1024 * 1024},
+			{Name: BenchmarkUpload, Err: errors.New("offline")},
+		},
+	}
+
+	r.Equal(t, BenchmarkDecryption, b.Bottleneck().Name)
+	r.Equal(t, 100*time.Second, b.Duration(b.Stages[1]))
+	r.Equal(t, time.Duration(0), b.Duration(b.Stages[2]))
+	r.Contains(t, b.String(), "API upload   failed: offline")
+	r.Contains(t, b.String(), "Bottleneck: Decryption, migration would take about 1m40s")
+
+	r.Nil(t, Benchmark{}.Bottleneck())
+}
+
+func TestMeasureDiskRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "benchmark")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	// Empty folder is measured with synthetic file which is removed afterwards.
+	speed, err := measureDiskRead(dir, 1024*1024)
+	r.NoError(t, err)
+	r.True(t, speed > 0)
+	files, err := ioutil.ReadDir(dir)
+	r.NoError(t, err)
+	r.Empty(t, files)
+
+	r.NoError(t, ioutil.WriteFile(filepath.Join(dir, "msg.eml"), []byte("Subject: test\r\n\r\nbody"), 0600))
+	speed, err = measureDiskRead(dir, 1024*1024)
+	r.NoError(t, err)
+	r.True(t, speed > 0)
+}
+
+func TestMeasureDecryption(t *testing.T) {
+	speed, err := measureDecryption(10*1024, 20*1024)
+	r.NoError(t, err)
+	r.True(t, speed > 0)
+
+	_, err = measureDecryption(0, 20*1024)
+	r.Error(t, err)
+}
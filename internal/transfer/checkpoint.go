@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const checkpointsFileName = "checkpoints.db"
+
+var (
+	checkpointsPath      string                          //nolint[gochecknoglobals]
+	checkpointStores     = map[string]*checkpointStore{} //nolint[gochecknoglobals]
+	checkpointStoresLock = &sync.Mutex{}                 //nolint[gochecknoglobals]
+)
+
+// SetCheckpointsPath sets the database file where progress of all transfers
+// is persisted. By default, the file is in the folder with transfer rules.
+func SetCheckpointsPath(path string) {
+	checkpointStoresLock.Lock()
+	defer checkpointStoresLock.Unlock()
+
+	checkpointsPath = path
+}
+
+// checkpointStore persists IDs of transferred messages. Each transfer has
+// own bucket named by transfer ID with message IDs as keys.
+type checkpointStore struct {
+	db *bolt.DB
+}
+
+// openCheckpointStore returns the store used by all transfers. It is opened
+// only once as bolt does not allow to open the same file twice.
+func openCheckpointStore(rulesDir string) (*checkpointStore, error) {
+	checkpointStoresLock.Lock()
+	defer checkpointStoresLock.Unlock()
+
+	path := checkpointsPath
+	if path == "" {
+		path = filepath.Join(rulesDir, checkpointsFileName)
+	}
+
+	if store, ok := checkpointStores[path]; ok {
+		return store, nil
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	store := &checkpointStore{db: db}
+	checkpointStores[path] = store
+	return store, nil
+}
+
+func (cs *checkpointStore) load(transferID string) (map[string]bool, error) {
+	done := map[string]bool{}
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(transferID))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, _ []byte) error {
+			done[string(k)] = true
+			return nil
+		})
+	})
+	return done, err
+}
+
+func (cs *checkpointStore) markDone(transferID string, messageIDs ...string) error {
+	return cs.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(transferID))
+		if err != nil {
+			return err
+		}
+		for _, messageID := range messageIDs {
+			if err := b.Put([]byte(messageID), []byte{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (cs *checkpointStore) clear(transferID string) error {
+	return cs.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(transferID)); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		return nil
+	})
+}
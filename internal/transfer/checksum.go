@@ -0,0 +1,156 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	pkgMessage "github.com/ProtonMail/proton-bridge/pkg/message"
+	"github.com/pkg/errors"
+)
+
+// checksumsFileName is the manifest with canonical hashes of exported
+// messages, one `<hash>  <path>` line per file. The hash is not the hash
+// of the file itself but of the message content which survives upload
+// to ProtonMail and download back, see canonicalMessageHash.
+const checksumsFileName = "checksums.txt"
+
+// checksummer is implemented by providers which can record or verify
+// canonical hashes of messages.
+type checksummer interface {
+	SetChecksums(bool)
+}
+
+// canonicalMessageHash returns hash of the message content independent
+// of the MIME structure, encoding or line endings, i.e., of parts which
+// are kept when the message is imported and exported again.
+func canonicalMessageHash(body []byte) (hash string, err error) {
+	// Old message parser is panicking in some cases.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic while parse: %v", r)
+		}
+	}()
+
+	message, _, _, attachmentReaders, err := pkgMessage.Parse(bytes.NewReader(body), "", "")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse message")
+	}
+
+	h := sha256.New()
+	writeField := func(name, value string) {
+		_, _ = fmt.Fprintf(h, "%s:%d:%s\n", name, len(value), value)
+	}
+
+	writeField("message-id", strings.Trim(message.ExternalID, "<>"))
+	writeField("date", fmt.Sprint(message.Time))
+	writeField("subject", message.Subject)
+	writeField("from", canonicalAddresses([]*mail.Address{message.Sender}))
+	writeField("to", canonicalAddresses(message.ToList))
+	writeField("cc", canonicalAddresses(message.CCList))
+	writeField("body", canonicalText(message.Body))
+
+	for i, attachment := range message.Attachments {
+		if i >= len(attachmentReaders) {
+			break
+		}
+		content, err := ioutil.ReadAll(attachmentReaders[i])
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read attachment")
+		}
+		writeField("attachment", fmt.Sprintf("%s:%x", attachment.Name, sha256.Sum256(content)))
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func canonicalAddresses(addresses []*mail.Address) string {
+	canonical := []string{}
+	for _, address := range addresses {
+		if address != nil {
+			canonical = append(canonical, strings.ToLower(address.Address))
+		}
+	}
+	return strings.Join(canonical, ",")
+}
+
+// canonicalText unifies line endings and drops trailing white spaces
+// which are not preserved by all MIME encodings.
+func canonicalText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// appendChecksum adds the hash of all files at paths relative to root
+// into the manifest in root.
+func appendChecksum(root, hash string, paths []string) error {
+	f, err := os.OpenFile(filepath.Join(root, checksumsFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600) //nolint[gosec]
+	if err != nil {
+		return err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	for _, path := range paths {
+		if _, err := fmt.Fprintf(f, "%s  %s\n", hash, filepath.ToSlash(path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadChecksums returns hashes from the manifest in root by relative path
+// of the file. Missing manifest means there is nothing to verify.
+func loadChecksums(root string) (map[string]string, error) {
+	checksums := map[string]string{}
+
+	f, err := os.Open(filepath.Join(root, checksumsFileName)) //nolint[gosec]
+	if os.IsNotExist(err) {
+		return checksums, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	return checksums, readChecksums(f, checksums)
+}
+
+func readChecksums(r io.Reader, checksums map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "  ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		// Later lines win, interrupted export appends when resumed.
+		checksums[filepath.FromSlash(parts[1])] = parts[0]
+	}
+	return scanner.Err()
+}
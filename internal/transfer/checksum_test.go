@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+const checksumTestPlainMessage = `From: Sender <Sender@example.com>
+To: rcpt@example.com
+Subject: Hello
+Date: Mon, 02 Jan 2006 15:04:05 +0000
+Message-Id: <msg@example.com>
+Content-Type: text/plain; charset=utf-8
+
+Hello world   
+with trailing spaces.
+`
+
+const checksumTestQPMessage = `From: "Sender" <sender@example.com>
+To: <rcpt@example.com>
+Subject: =?utf-8?q?Hello?=
+Date: Mon, 02 Jan 2006 15:04:05 +0000
+Message-Id: <msg@example.com>
+Content-Type: text/plain; charset=utf-8
+Content-Transfer-Encoding: quoted-printable
+
+Hello world
+with trailing=20spaces.
+`
+
+func TestCanonicalMessageHashIgnoresEncoding(t *testing.T) {
+	plainHash, err := canonicalMessageHash([]byte(strings.ReplaceAll(checksumTestPlainMessage, "\n", "\r\n")))
+	r.NoError(t, err)
+
+	qpHash, err := canonicalMessageHash([]byte(checksumTestQPMessage))
+	r.NoError(t, err)
+	r.Equal(t, plainHash, qpHash)
+
+	otherHash, err := canonicalMessageHash([]byte(strings.Replace(checksumTestPlainMessage, "Hello world", "Hello there", 1)))
+	r.NoError(t, err)
+	r.NotEqual(t, plainHash, otherHash)
+}
+
+func TestEMLProviderChecksumsRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eml")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	target := newTestEMLProvider(dir)
+	target.SetChecksums(true)
+	r.NoError(t, os.MkdirAll(filepath.Join(dir, "Foo"), os.ModePerm))
+	r.NoError(t, target.writeFile(Message{ID: "msg", Body: []byte(checksumTestPlainMessage), Targets: []Mailbox{{Name: "Foo"}}}))
+
+	expectedHash, err := canonicalMessageHash([]byte(checksumTestPlainMessage))
+	r.NoError(t, err)
+
+	checksums, err := loadChecksums(dir)
+	r.NoError(t, err)
+	r.Equal(t, map[string]string{filepath.Join("Foo", "msg.eml"): expectedHash}, checksums)
+}
+
+func TestLoadChecksumsWithoutManifest(t *testing.T) {
+	checksums, err := loadChecksums("testdata/eml")
+	r.NoError(t, err)
+	r.Empty(t, checksums)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+)
+
+// ErrDeduplicationNotSupported is returned when deduplication is requested
+// for target which cannot be searched for existing messages.
+var ErrDeduplicationNotSupported = errors.New("deduplication is supported only for import to ProtonMail")
+
+// deduplicator is implemented by target providers which can skip messages
+// already present in the target.
+type deduplicator interface {
+	setDedupIndex(*dedupIndex)
+}
+
+// dedupIndex keeps canonical hashes of messages imported to the target,
+// see canonicalMessageHash. Unlike the transfer state, the index is shared
+// by all transfers to the same target and never cleared, so running the
+// import again, even from another source, does not create duplicates.
+type dedupIndex struct {
+	lock        sync.Locker
+	bucket      string
+	checkpoints *checkpointStore
+	hashes      map[string]bool
+}
+
+// loadDedupIndex loads hashes of messages imported to the target with
+// `targetID`. Without checkpoint store, the index is kept in memory only.
+func loadDedupIndex(checkpoints *checkpointStore, targetID string) *dedupIndex {
+	index := &dedupIndex{
+		lock:        &sync.Mutex{},
+		bucket:      "dedup-" + targetID,
+		checkpoints: checkpoints,
+		hashes:      map[string]bool{},
+	}
+
+	if checkpoints == nil {
+		return index
+	}
+	if hashes, err := checkpoints.load(index.bucket); err != nil {
+		log.WithError(err).Warn("Problem to read deduplication index")
+	} else {
+		index.hashes = hashes
+	}
+	return index
+}
+
+func (i *dedupIndex) has(hash string) bool {
+	if i == nil || hash == "" {
+		return false
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return i.hashes[hash]
+}
+
+func (i *dedupIndex) add(hash string) {
+	if i == nil || hash == "" {
+		return
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.hashes[hash] = true
+
+	if i.checkpoints == nil {
+		return
+	}
+	if err := i.checkpoints.markDone(i.bucket, hash); err != nil {
+		log.WithError(err).Warn("Problem to write deduplication index")
+	}
+}
+
+// setDedupIndex enables skipping of messages already in the account.
+func (p *PMAPIProvider) setDedupIndex(index *dedupIndex) {
+	p.dedup = index
+}
+
+// findDuplicate returns whether the message is already in the account,
+// either imported before with the same canonical hash, or delivered
+// otherwise with the same Message-ID and date. The hash is returned to be
+// recorded once the message is imported.
+func (p *PMAPIProvider) findDuplicate(progress *Progress, msg Message) (hash string, isDuplicate bool) {
+	hash, err := canonicalMessageHash(msg.Body)
+	if err != nil {
+		log.WithError(err).WithField("msg", msg.ID).Warning("Failed to compute hash for deduplication")
+		return "", false
+	}
+	if p.dedup.has(hash) {
+		return hash, true
+	}
+
+	header, err := getMessageHeader(msg.Body)
+	if err != nil {
+		return hash, false
+	}
+	externalID := strings.Trim(header.Get("Message-Id"), " <>")
+	if externalID == "" {
+		return hash, false
+	}
+
+	var messages []*pmapi.Message
+	progress.callWrap(func() error {
+		messages, _, err = p.listMessages(&pmapi.MessagesFilter{ExternalID: externalID})
+		return err
+	})
+
+	date, dateErr := header.Date()
+	for _, message := range messages {
+		// Message-ID should be unique, but not all clients generate
+		// it properly, therefore date is checked as well if present.
+		if dateErr != nil || message.Time == date.Unix() {
+			return hash, true
+		}
+	}
+	return hash, false
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	gomock "github.com/golang/mock/gomock"
+	a "github.com/stretchr/testify/assert"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestDedupIndexPersisted(t *testing.T) {
+	path, err := ioutil.TempDir("", "dedup")
+	r.NoError(t, err)
+	defer os.RemoveAll(path) //nolint[errcheck]
+
+	checkpoints, err := openCheckpointStore(path)
+	r.NoError(t, err)
+
+	index := loadDedupIndex(checkpoints, "user")
+	r.False(t, index.has("hash1"))
+	index.add("hash1")
+	index.add("")
+	r.True(t, index.has("hash1"))
+	r.False(t, index.has(""))
+
+	r.True(t, loadDedupIndex(checkpoints, "user").has("hash1"))
+	r.False(t, loadDedupIndex(checkpoints, "otherUser").has("hash1"))
+
+	var noIndex *dedupIndex
+	noIndex.add("hash1")
+	r.False(t, noIndex.has("hash1"))
+}
+
+func TestPMAPIProviderTransferFromDedup(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	date := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	withMessageID := append([]byte("Message-Id: <msg3@pm.test>\r\nDate: "+date.Format(time.RFC1123Z)+"\r\n"), getTestMsgBody("msg3")...)
+
+	m.pmapiClient.EXPECT().KeyRingForAddressID(gomock.Any()).Return(m.keyring, nil).AnyTimes()
+	m.pmapiClient.EXPECT().ListMessages(&pmapi.MessagesFilter{ExternalID: "msg3@pm.test"}).Return([]*pmapi.Message{
+		{ID: "existing", Time: date.Unix()},
+	}, 1, nil)
+	m.pmapiClient.EXPECT().Import(gomock.Any()).DoAndReturn(func(requests []*pmapi.ImportMsgReq) ([]*pmapi.ImportMsgRes, error) {
+		r.Len(t, requests, 1)
+		r.True(t, bytes.Contains(requests[0].Body, []byte("msg2")))
+		return []*pmapi.ImportMsgRes{{MessageID: "imported2"}}, nil
+	})
+
+	provider, err := NewPMAPIProvider(m.pmapiConfig, m.clientManager, "user", "addressID")
+	r.NoError(t, err)
+
+	index := loadDedupIndex(nil, "user")
+	hash1, err := canonicalMessageHash(getTestMsgBody("msg1"))
+	r.NoError(t, err)
+	index.add(hash1)
+	provider.setDedupIndex(index)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupPMAPIRules(rules)
+
+	progress := newProgress(log, nil)
+	drainProgressUpdateChannel(&progress)
+
+	messages := []Message{
+		{ID: "msg1", Body: getTestMsgBody("msg1"), Targets: []Mailbox{{ID: pmapi.InboxLabel}}},
+		{ID: "msg2", Body: getTestMsgBody("msg2"), Targets: []Mailbox{{ID: pmapi.InboxLabel}}},
+		{ID: "msg3", Body: withMessageID, Targets: []Mailbox{{ID: pmapi.InboxLabel}}},
+	}
+	ch := make(chan Message)
+	go func() {
+		for _, message := range messages {
+			progress.addMessage(message.ID, nil)
+			progress.messageExported(message.ID, []byte(""), nil)
+			ch <- message
+		}
+		close(ch)
+	}()
+	go func() {
+		provider.TransferFrom(rules, &progress, ch)
+		progress.finish()
+	}()
+
+	a.Eventually(t, func() bool {
+		return progress.updateCh == nil
+	}, 3*time.Second, 10*time.Millisecond, "Waiting for imported messages timed out")
+
+	r.Empty(t, progress.GetFailedMessages())
+	r.Equal(t, uint(2), progress.GetDuplicateCount())
+
+	hash2, err := canonicalMessageHash(getTestMsgBody("msg2"))
+	r.NoError(t, err)
+	r.True(t, index.has(hash2))
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DryRunReport describes what the transfer would do.
+type DryRunReport struct {
+	Rules []*DryRunRule
+	Total Estimate
+}
+
+// DryRunRule holds messages found at the source for one active rule.
+type DryRunRule struct {
+	Rule  *Rule
+	Count uint
+	Size  uint64
+}
+
+// String returns report in human readable form, one line per rule.
+func (r DryRunReport) String() string {
+	b := &strings.Builder{}
+	for _, rule := range r.Rules {
+		targets := strings.Join(rule.Rule.TargetMailboxNames(), ", ")
+		fmt.Fprintf(b, "%-30s → %s", rule.Rule.SourceMailbox.Name, targets)
+		if rule.Rule.HasTimeLimit() {
+			fmt.Fprintf(b, " (%s - %s)", rule.Rule.FromDate(), rule.Rule.ToDate())
+		}
+		fmt.Fprintf(b, ": %d messages, %s\n", rule.Count, formatSize(rule.Size))
+	}
+	fmt.Fprintf(b, "Total: %s\n", r.Total.String())
+	return b.String()
+}
+
+// DryRun walks the source the same way as Start, including mappings and
+// time limits, but messages are only counted and nothing is imported.
+// Neither the saved state nor checkpoints are changed.
+func (t *Transfer) DryRun() (DryRunReport, error) {
+	t.rules.propagateGlobalTime()
+
+	report := DryRunReport{}
+	rulesBySource := map[string]*DryRunRule{}
+	for _, rule := range t.rules.getSortedRules() {
+		if !rule.Active {
+			continue
+		}
+		dryRunRule := &DryRunRule{Rule: rule}
+		if t.rules.globalMailbox != nil {
+			withGlobal := *rule
+			withGlobal.TargetMailboxes = append(append([]Mailbox{}, rule.TargetMailboxes...), *t.rules.globalMailbox)
+			dryRunRule.Rule = &withGlobal
+		}
+		report.Rules = append(report.Rules, dryRunRule)
+		rulesBySource[rule.SourceMailbox.Hash()] = dryRunRule
+	}
+
+	progress := newProgress(log.WithField("id", t.id), nil)
+	progress.cleanUpdateCh()
+
+	ch := make(chan Message)
+	go func() {
+		defer t.panicHandler.HandlePanic()
+
+		t.source.TransferTo(t.rules, &progress, ch)
+		close(ch)
+	}()
+
+	var count uint
+	var size uint64
+	for msg := range ch {
+		if rule, ok := rulesBySource[msg.Source.Hash()]; ok {
+			rule.Count++
+			rule.Size += uint64(len(msg.Body))
+		}
+		count++
+		size += uint64(len(msg.Body))
+	}
+
+	if err := progress.GetFatalError(); err != nil {
+		return DryRunReport{}, err
+	}
+
+	report.Total = newEstimate(count, size)
+	return report, nil
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	m.panicHandler.EXPECT().HandlePanic().AnyTimes()
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupEMLRules(rules)
+	rules.setGlobalMailbox(&Mailbox{Name: "Imported"})
+
+	transfer := &Transfer{
+		panicHandler: m.panicHandler,
+		id:           "dryrun",
+		rules:        rules,
+		source:       newTestEMLProvider(""),
+	}
+
+	report, err := transfer.DryRun()
+	r.NoError(t, err)
+
+	r.Len(t, report.Rules, 2)
+	for _, rule := range report.Rules {
+		name := rule.Rule.SourceMailbox.Name
+		r.Equal(t, []string{name, "Imported"}, rule.Rule.TargetMailboxNames())
+		r.Equal(t, uint(1), rule.Count)
+		r.NotZero(t, rule.Size)
+		r.Contains(t, report.String(), name+", Imported: 1 messages")
+	}
+	r.Equal(t, uint(2), report.Total.Count)
+	r.Equal(t, report.Rules[0].Size+report.Rules[1].Size, report.Total.Size)
+
+	// Rule itself is not changed by the global mailbox.
+	r.Equal(t, []string{"Inbox"}, rules.getRule(Mailbox{Name: "Inbox"}).TargetMailboxNames())
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"time"
+)
+
+// Rough speed of the transfer used for the duration estimation. Real speed
+// depends mostly on the connection and on the target provider.
+const (
+	estimatedTimePerMessage = 150 * time.Millisecond
+	estimatedBytesPerSecond = 1024 * 1024
+)
+
+// Estimate holds pre-flight estimation of the transfer.
+type Estimate struct {
+	Count    uint
+	Size     uint64 // Approximate size of all messages in bytes.
+	Duration time.Duration
+}
+
+func newEstimate(count uint, size uint64) Estimate {
+	duration := time.Duration(count)*estimatedTimePerMessage +
+		time.Duration(size/estimatedBytesPerSecond)*time.Second
+
+	return Estimate{
+		Count:    count,
+		Size:     size,
+		Duration: duration,
+	}
+}
+
+// String returns estimation in human readable form.
+func (e Estimate) String() string {
+	duration := e.Duration.Round(time.Minute)
+	if duration < time.Minute {
+		return fmt.Sprintf("%d messages, %s, less than a minute", e.Count, formatSize(e.Size))
+	}
+	return fmt.Sprintf("%d messages, %s, about %s", e.Count, formatSize(e.Size), duration)
+}
+
+func formatSize(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"testing"
+	"time"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestEstimate(t *testing.T) {
+	estimate := newEstimate(1000, 100*1024*1024)
+	r.Equal(t, 1000*estimatedTimePerMessage+100*time.Second, estimate.Duration)
+	r.Equal(t, "1000 messages, 100.0 MB, about 4m0s", estimate.String())
+
+	r.Equal(t, "1 messages, 10 B, less than a minute", newEstimate(1, 10).String())
+}
+
+func TestFormatSize(t *testing.T) {
+	r.Equal(t, "0 B", formatSize(0))
+	r.Equal(t, "1023 B", formatSize(1023))
+	r.Equal(t, "1.5 KB", formatSize(1536))
+	r.Equal(t, "2.0 GB", formatSize(2*1024*1024*1024))
+}
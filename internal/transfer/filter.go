@@ -0,0 +1,201 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"net/mail"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/pkg/errors"
+)
+
+// ErrFilterNotSupported is returned when filtering messages of a source
+// other than ProtonMail.
+var ErrFilterNotSupported = errors.New("message filters are supported only for export from ProtonMail")
+
+// MessageFilter selects messages exported from ProtonMail on top of the
+// source mailboxes and time limits of rules. Empty fields do not filter.
+type MessageFilter struct {
+	// Labels are names of labels or folders of which the message needs
+	// to have at least one.
+	Labels []string `json:"labels,omitempty"`
+	// From is pattern of the sender address with `*` wildcards,
+	// for example `*@example.com`.
+	From string `json:"from,omitempty"`
+	// To is pattern matched the same way against To, Cc and Bcc recipients.
+	To string `json:"to,omitempty"`
+	// Subject is regular expression matched case-insensitively.
+	Subject string `json:"subject,omitempty"`
+	// HasAttachment selects only messages with attachments.
+	HasAttachment bool `json:"hasAttachment,omitempty"`
+}
+
+// IsEmpty returns whether the filter selects all messages.
+func (f MessageFilter) IsEmpty() bool {
+	return len(f.Labels) == 0 && f.From == "" && f.To == "" && f.Subject == "" && !f.HasAttachment
+}
+
+// String returns textual representation for users.
+func (f MessageFilter) String() string {
+	parts := []string{}
+	if len(f.Labels) != 0 {
+		parts = append(parts, "labels: "+strings.Join(f.Labels, ", "))
+	}
+	if f.From != "" {
+		parts = append(parts, "from: "+f.From)
+	}
+	if f.To != "" {
+		parts = append(parts, "to: "+f.To)
+	}
+	if f.Subject != "" {
+		parts = append(parts, "subject: /"+f.Subject+"/")
+	}
+	if f.HasAttachment {
+		parts = append(parts, "with attachment")
+	}
+	if len(parts) == 0 {
+		return "all messages"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// messageFilter is MessageFilter prepared for matching.
+type messageFilter struct {
+	labelIDs      map[string]bool
+	from, to      string
+	subject       *regexp.Regexp
+	hasAttachment bool
+}
+
+// ParseFilterLabels splits comma separated list of label or folder names.
+func ParseFilterLabels(value string) []string {
+	labels := []string{}
+	for _, label := range strings.Split(value, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// newMessageFilter compiles the filter. Label names are resolved to IDs
+// using mailboxes of the source.
+func newMessageFilter(filter MessageFilter, mailboxes []Mailbox) (*messageFilter, error) {
+	compiled := &messageFilter{
+		from:          strings.ToLower(strings.TrimSpace(filter.From)),
+		to:            strings.ToLower(strings.TrimSpace(filter.To)),
+		hasAttachment: filter.HasAttachment,
+	}
+
+	for _, pattern := range []string{compiled.from, compiled.to} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid address pattern %q", pattern)
+		}
+	}
+
+	if filter.Subject != "" {
+		subject, err := regexp.Compile("(?i)" + filter.Subject)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid subject expression")
+		}
+		compiled.subject = subject
+	}
+
+	if len(filter.Labels) != 0 {
+		compiled.labelIDs = map[string]bool{}
+		for _, name := range filter.Labels {
+			labelID := ""
+			for _, mailbox := range mailboxes {
+				if strings.EqualFold(mailbox.Name, strings.TrimSpace(name)) {
+					labelID = mailbox.ID
+					break
+				}
+			}
+			if labelID == "" {
+				return nil, fmt.Errorf("unknown label %q", name)
+			}
+			compiled.labelIDs[labelID] = true
+		}
+	}
+
+	return compiled, nil
+}
+
+// attachmentsFilter returns the part of the filter done by API.
+func (f *messageFilter) attachmentsFilter() *bool {
+	if f == nil || !f.hasAttachment {
+		return nil
+	}
+	hasAttachment := true
+	return &hasAttachment
+}
+
+// match returns whether the message metadata pass the filter.
+// Nil filter passes everything.
+func (f *messageFilter) match(msg *pmapi.Message) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.hasAttachment && msg.NumAttachments == 0 {
+		return false
+	}
+
+	if f.labelIDs != nil {
+		hasLabel := false
+		for _, labelID := range msg.LabelIDs {
+			hasLabel = hasLabel || f.labelIDs[labelID]
+		}
+		if !hasLabel {
+			return false
+		}
+	}
+
+	if f.from != "" && (msg.Sender == nil || !matchAddressPattern(f.from, msg.Sender.Address)) {
+		return false
+	}
+
+	if f.to != "" {
+		matched := false
+		for _, list := range [][]*mail.Address{msg.ToList, msg.CCList, msg.BCCList} {
+			for _, recipient := range list {
+				matched = matched || matchAddressPattern(f.to, recipient.Address)
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if f.subject != nil && !f.subject.MatchString(msg.Subject) {
+		return false
+	}
+
+	return true
+}
+
+// matchAddressPattern matches lower-cased pattern with `*` wildcards
+// against the address.
+func matchAddressPattern(pattern, address string) bool {
+	matched, _ := path.Match(pattern, strings.ToLower(address))
+	return matched
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestMessageFilter(t *testing.T) {
+	mailboxes := []Mailbox{
+		{ID: pmapi.InboxLabel, Name: "INBOX"},
+		{ID: "labelID", Name: "Invoices"},
+	}
+
+	msg := &pmapi.Message{
+		Subject:        "Invoice 2020/11",
+		Sender:         &mail.Address{Address: "Billing@Example.com"},
+		ToList:         []*mail.Address{{Address: "me@pm.me"}},
+		CCList:         []*mail.Address{{Address: "accounting@pm.me"}},
+		LabelIDs:       []string{pmapi.AllMailLabel, "labelID"},
+		NumAttachments: 1,
+	}
+
+	tests := []struct {
+		filter    MessageFilter
+		wantMatch bool
+	}{
+		{MessageFilter{}, true},
+		{MessageFilter{Labels: []string{"invoices"}}, true},
+		{MessageFilter{Labels: []string{"Inbox", "Invoices"}}, true},
+		{MessageFilter{Labels: []string{"Inbox"}}, false},
+		{MessageFilter{From: "*@example.com"}, true},
+		{MessageFilter{From: "billing@example.com"}, true},
+		{MessageFilter{From: "*@pm.me"}, false},
+		{MessageFilter{To: "accounting@*"}, true},
+		{MessageFilter{To: "boss@pm.me"}, false},
+		{MessageFilter{Subject: `^invoice \d+`}, true},
+		{MessageFilter{Subject: "receipt"}, false},
+		{MessageFilter{HasAttachment: true}, true},
+		{MessageFilter{From: "*@example.com", Subject: "receipt"}, false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.filter.String(), func(t *testing.T) {
+			filter, err := newMessageFilter(tc.filter, mailboxes)
+			r.NoError(t, err)
+			r.Equal(t, tc.wantMatch, filter.match(msg))
+		})
+	}
+
+	withoutAttachment := *msg
+	withoutAttachment.NumAttachments = 0
+	filter, err := newMessageFilter(MessageFilter{HasAttachment: true}, mailboxes)
+	r.NoError(t, err)
+	r.False(t, filter.match(&withoutAttachment))
+	r.True(t, *filter.attachmentsFilter())
+
+	var noFilter *messageFilter
+	r.True(t, noFilter.match(msg))
+	r.Nil(t, noFilter.attachmentsFilter())
+}
+
+func TestMessageFilterInvalid(t *testing.T) {
+	_, err := newMessageFilter(MessageFilter{Labels: []string{"Unknown"}}, nil)
+	r.Error(t, err)
+
+	_, err = newMessageFilter(MessageFilter{Subject: "("}, nil)
+	r.Error(t, err)
+
+	_, err = newMessageFilter(MessageFilter{From: "[a"}, nil)
+	r.Error(t, err)
+}
+
+func TestParseFilterLabels(t *testing.T) {
+	r.Equal(t, []string{"Work", "Family trips"}, ParseFilterLabels(" Work, ,Family trips,"))
+	r.Equal(t, []string{}, ParseFilterLabels(""))
+}
@@ -32,9 +32,71 @@ var systemFolderMapping = map[string]string{ //nolint[gochecknoglobals]
 	"sent mail": "Sent",
 	"draft":     "Drafts",
 	"important": "Starred",
+
+	// Outlook.
+	"sent items":    "Sent",
+	"deleted items": "Trash",
+	"junk email":    "Spam",
+	"junk e-mail":   "Spam",
 	// Add more translations.
 }
 
+// CreateMissingMailboxes creates all non-system mailboxes of the source which
+// do not exist at the target yet, so the transfer keeps the structure of
+// folders and labels. It is meant for transfers between two accounts of the
+// same kind, e.g., from one ProtonMail account to another one.
+func CreateMissingMailboxes(source SourceProvider, target TargetProvider) error {
+	return createMissingMailboxes(source, target, func(Mailbox) bool { return true })
+}
+
+// RestoreExportedMailboxes creates mailboxes missing at the target which
+// were exported from ProtonMail together with their metadata, e.g., color.
+// Other local folders are left for the transfer rules to decide.
+func RestoreExportedMailboxes(source SourceProvider, target TargetProvider) error {
+	return createMissingMailboxes(source, target, func(mailbox Mailbox) bool { return mailbox.Color != "" })
+}
+
+// CreateMissingLabels creates labels of the source missing at the target,
+// e.g., categories of Outlook. Folders are left for the transfer rules.
+func CreateMissingLabels(source SourceProvider, target TargetProvider) error {
+	return createMissingMailboxes(source, target, func(mailbox Mailbox) bool { return !mailbox.IsExclusive })
+}
+
+func createMissingMailboxes(source SourceProvider, target TargetProvider, shouldCreate func(Mailbox) bool) error {
+	sourceMailboxes, err := source.Mailboxes(false, false)
+	if err != nil {
+		return err
+	}
+
+	targetMailboxes, err := target.Mailboxes(true, false)
+	if err != nil {
+		return err
+	}
+
+	existingNames := map[string]bool{}
+	for _, mailbox := range targetMailboxes {
+		existingNames[strings.ToLower(mailbox.Name)] = true
+	}
+
+	for _, mailbox := range sourceMailboxes {
+		if mailbox.IsSystemFolder() || existingNames[strings.ToLower(mailbox.Name)] || !shouldCreate(mailbox) {
+			continue
+		}
+
+		log.WithField("mailbox", mailbox.Name).Debug("Creating missing mailbox")
+		if _, err := target.CreateMailbox(Mailbox{
+			Name:        mailbox.Name,
+			Color:       mailbox.Color,
+			Order:       mailbox.Order,
+			IsExclusive: mailbox.IsExclusive,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // LeastUsedColor is intended to return color for creating a new inbox or label
 func LeastUsedColor(mailboxes []Mailbox) string {
 	usedColors := []string{}
@@ -49,6 +111,7 @@ type Mailbox struct {
 	ID          string
 	Name        string
 	Color       string
+	Order       int
 	IsExclusive bool
 }
 
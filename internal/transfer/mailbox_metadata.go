@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// mailboxMetadataFileName is the file in the root of local exports keeping
+// colors, order and type of exported folders and labels, so migrated
+// accounts keep their visual organization after import.
+const mailboxMetadataFileName = "mailboxes.json"
+
+type mailboxMetadata struct {
+	Color       string `json:"color"`
+	Order       int    `json:"order"`
+	IsExclusive bool   `json:"exclusive"`
+}
+
+// writeMailboxMetadata saves metadata of source folders and labels under
+// the names of target mailboxes. System folders have nothing to keep.
+func writeMailboxMetadata(root string, rules transferRules) error {
+	metadata := map[string]mailboxMetadata{}
+	for rule := range rules.iterateActiveRules() {
+		if rule.SourceMailbox.IsSystemFolder() || rule.SourceMailbox.Color == "" {
+			continue
+		}
+		for _, mailbox := range rule.TargetMailboxes {
+			metadata[mailbox.Name] = mailboxMetadata{
+				Color:       rule.SourceMailbox.Color,
+				Order:       rule.SourceMailbox.Order,
+				IsExclusive: rule.SourceMailbox.IsExclusive,
+			}
+		}
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(root, mailboxMetadataFileName), data, 0600)
+}
+
+// applyMailboxMetadata fills colors, order and type of mailboxes from
+// the metadata of previous export, if there is any.
+func applyMailboxMetadata(root string, mailboxes []Mailbox) {
+	data, err := ioutil.ReadFile(filepath.Join(root, mailboxMetadataFileName)) //nolint[gosec]
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(err).Warn("Cannot read mailbox metadata")
+		}
+		return
+	}
+
+	metadata := map[string]mailboxMetadata{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		log.WithError(err).Warn("Cannot parse mailbox metadata")
+		return
+	}
+
+	for idx := range mailboxes {
+		if m, ok := metadata[mailboxes[idx].Name]; ok {
+			mailboxes[idx].Color = m.Color
+			mailboxes[idx].Order = m.Order
+			mailboxes[idx].IsExclusive = m.IsExclusive
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestMailboxMetadataRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mailbox-metadata")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	_ = rules.setRule(Mailbox{ID: "0", Name: "Inbox"}, []Mailbox{{Name: "Inbox"}}, 0, 0)
+	_ = rules.setRule(Mailbox{ID: "label", Name: "Work", Color: "#7272a7", Order: 3, IsExclusive: true}, []Mailbox{{Name: "Work"}}, 0, 0)
+
+	r.NoError(t, writeMailboxMetadata(dir, rules))
+	r.FileExists(t, filepath.Join(dir, mailboxMetadataFileName))
+
+	mailboxes := []Mailbox{{Name: "Inbox"}, {Name: "Work"}, {Name: "Other"}}
+	applyMailboxMetadata(dir, mailboxes)
+	r.Equal(t, []Mailbox{
+		{Name: "Inbox"},
+		{Name: "Work", Color: "#7272a7", Order: 3, IsExclusive: true},
+		{Name: "Other"},
+	}, mailboxes)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/emersion/go-imap/utf7"
+	"github.com/pkg/errors"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NameNormalization says how mailbox names violating ProtonMail naming rules
+// are changed before the mailbox is created.
+type NameNormalization int
+
+const (
+	// NameNormalizationNone keeps the name as it is; creation of mailbox
+	// with an invalid name fails.
+	NameNormalizationNone NameNormalization = iota
+	// NameNormalizationStrip removes characters not allowed in names.
+	NameNormalizationStrip
+	// NameNormalizationTransliterate strips like NameNormalizationStrip and
+	// also removes diacritics, e.g. "Účty" becomes "Ucty". Letters without
+	// ASCII base, such as CJK, are kept.
+	NameNormalizationTransliterate
+)
+
+// maxMailboxNameLength is the maximal number of characters of a label or folder name.
+const maxMailboxNameLength = 100
+
+// ErrInvalidMailboxName is returned when nothing is left of the name after normalization.
+var ErrInvalidMailboxName = errors.New("mailbox name is empty after normalization")
+
+var nameNormalizations = map[string]NameNormalization{ //nolint[gochecknoglobals]
+	"none":          NameNormalizationNone,
+	"strip":         NameNormalizationStrip,
+	"transliterate": NameNormalizationTransliterate,
+}
+
+// ParseNameNormalization returns normalization by its name (none, strip or transliterate).
+func ParseNameNormalization(name string) (NameNormalization, error) {
+	if normalization, ok := nameNormalizations[strings.ToLower(name)]; ok {
+		return normalization, nil
+	}
+	return NameNormalizationNone, errors.Errorf("unknown name normalization %q", name)
+}
+
+// normalizeMailboxName returns name acceptable by ProtonMail. Names in IMAP
+// modified UTF-7 (e.g. folders of Thunderbird profile) are always decoded.
+func normalizeMailboxName(name string, normalization NameNormalization) (string, error) {
+	name = decodeModifiedUTF7(name)
+
+	if normalization == NameNormalizationNone {
+		return name, nil
+	}
+
+	if normalization == NameNormalizationTransliterate {
+		removeDiacritics := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+		if transliterated, _, err := transform.String(removeDiacritics, name); err == nil {
+			name = transliterated
+		}
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		// Slashes usually separate hierarchy which ProtonMail does not have.
+		case r == '/' || r == '\\':
+			return '-'
+		case r == '<' || r == '>' || r == unicode.ReplacementChar:
+			return -1
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.IsControl(r) || !unicode.IsPrint(r):
+			return -1
+		}
+		return r
+	}, name)
+
+	name = strings.Join(strings.Fields(name), " ")
+
+	if nameRunes := []rune(name); len(nameRunes) > maxMailboxNameLength {
+		name = strings.TrimSpace(string(nameRunes[:maxMailboxNameLength]))
+	}
+
+	if name == "" {
+		return "", ErrInvalidMailboxName
+	}
+
+	return name, nil
+}
+
+// decodeModifiedUTF7 decodes name encoded in IMAP modified UTF-7. Names which
+// are not valid modified UTF-7 (e.g. "Tom & Jerry") are returned unchanged.
+func decodeModifiedUTF7(name string) string {
+	if !strings.Contains(name, "&") {
+		return name
+	}
+
+	decoded, err := utf7.Encoding.NewDecoder().String(name)
+	if err != nil {
+		return name
+	}
+
+	return decoded
+}
@@ -18,6 +18,7 @@
 package transfer
 
 import (
+	"strings"
 	"testing"
 
 	r "github.com/stretchr/testify/require"
@@ -109,3 +110,64 @@ func TestFindMatchingMailboxes(t *testing.T) {
 		})
 	}
 }
+
+type testTargetProvider struct {
+	mailboxes []Mailbox
+}
+
+func (p *testTargetProvider) ID() string { return "test" }
+
+func (p *testTargetProvider) Mailboxes(bool, bool) ([]Mailbox, error) { return p.mailboxes, nil }
+
+func (p *testTargetProvider) DefaultMailboxes(Mailbox) []Mailbox { return nil }
+
+func (p *testTargetProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
+	mailbox.ID = mailbox.Name
+	p.mailboxes = append(p.mailboxes, mailbox)
+	return mailbox, nil
+}
+
+func (p *testTargetProvider) TransferFrom(transferRules, *Progress, <-chan Message) {}
+
+func TestCreateMissingMailboxes(t *testing.T) {
+	source := newTestLocalProvider("")
+	target := &testTargetProvider{mailboxes: []Mailbox{{ID: "0", Name: "INBOX", IsExclusive: true}}}
+
+	r.NoError(t, CreateMissingMailboxes(source, target))
+	r.Equal(t, []Mailbox{
+		{ID: "0", Name: "INBOX", IsExclusive: true},
+		{ID: "Foo", Name: "Foo"},
+	}, target.mailboxes)
+
+	// Nothing is created the second time.
+	r.NoError(t, CreateMissingMailboxes(source, target))
+	r.Len(t, target.mailboxes, 2)
+}
+
+func TestNormalizeMailboxName(t *testing.T) {
+	tests := []struct {
+		name          string
+		normalization NameNormalization
+		want          string
+		wantErr       error
+	}{
+		{"Inbox", NameNormalizationStrip, "Inbox", nil},
+		{"Tom & Jerry", NameNormalizationStrip, "Tom & Jerry", nil},
+		{"&AMk-t&AOk-", NameNormalizationNone, "Été", nil},
+		{"&AMk-t&AOk-", NameNormalizationTransliterate, "Ete", nil},
+		{"Work/Projects", NameNormalizationNone, "Work/Projects", nil},
+		{"Work/Projects", NameNormalizationStrip, "Work-Projects", nil},
+		{" <Bad>\tname\x00 ", NameNormalizationStrip, "Bad name", nil},
+		{"Účty 日本", NameNormalizationTransliterate, "Ucty 日本", nil},
+		{"<>", NameNormalizationStrip, "", ErrInvalidMailboxName},
+		{strings.Repeat("a", 120), NameNormalizationStrip, strings.Repeat("a", 100), nil},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := normalizeMailboxName(tc.name, tc.normalization)
+			r.Equal(t, tc.wantErr, err)
+			r.Equal(t, tc.want, got)
+		})
+	}
+}
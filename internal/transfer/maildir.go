@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"sort"
+	"strings"
+)
+
+// maildirInfoSeparator separates the unique name of a Maildir file from
+// its info part, e.g., `1600000000.M1P2.host:2,FS`.
+const maildirInfoSeparator = ":2,"
+
+// applyMaildirFlags translates the standard flags from the info part of
+// a Maildir file name into the state of the message. Messages without
+// the info part (typically in `new`) are unread. Unknown flags, including
+// P (passed), are ignored.
+func applyMaildirFlags(msg *Message, fileName string) {
+	msg.Unread = true
+
+	idx := strings.LastIndex(fileName, maildirInfoSeparator)
+	if idx < 0 {
+		return
+	}
+
+	for _, flag := range fileName[idx+len(maildirInfoSeparator):] {
+		switch flag {
+		case 'S':
+			msg.Unread = false
+		case 'R':
+			msg.Answered = true
+		case 'F':
+			msg.Starred = true
+		case 'T':
+			msg.Deleted = true
+		case 'D':
+			msg.Draft = true
+		}
+	}
+}
+
+// maildirFlags returns the info part of a Maildir file name with flags
+// from the state of the message. Flags are in ASCII order as required.
+func maildirFlags(msg Message) string {
+	flags := []string{}
+	if msg.Draft {
+		flags = append(flags, "D")
+	}
+	if msg.Starred {
+		flags = append(flags, "F")
+	}
+	if msg.Answered {
+		flags = append(flags, "R")
+	}
+	if !msg.Unread {
+		flags = append(flags, "S")
+	}
+	if msg.Deleted {
+		flags = append(flags, "T")
+	}
+	sort.Strings(flags)
+	return maildirInfoSeparator + strings.Join(flags, "")
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestApplyMaildirFlags(t *testing.T) {
+	tests := []struct {
+		fileName string
+		want     Message
+	}{
+		{"1600000000.M1P2.host", Message{Unread: true}},
+		{"1600000000.M1P2.host:2,", Message{Unread: true}},
+		{"1600000000.M1P2.host:2,S", Message{}},
+		{"1600000000.M1P2.host:2,FPRST", Message{Answered: true, Starred: true, Deleted: true}},
+		{"1600000000.M1P2.host:2,D", Message{Unread: true, Draft: true}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.fileName, func(t *testing.T) {
+			msg := Message{}
+			applyMaildirFlags(&msg, tc.fileName)
+			r.Equal(t, tc.want, msg)
+		})
+	}
+}
+
+func TestMaildirFlags(t *testing.T) {
+	tests := []struct {
+		msg  Message
+		want string
+	}{
+		{Message{Unread: true}, ":2,"},
+		{Message{}, ":2,S"},
+		{Message{Answered: true, Starred: true, Deleted: true}, ":2,FRST"},
+		{Message{Unread: true, Draft: true, Starred: true}, ":2,DF"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.want, func(t *testing.T) {
+			flags := maildirFlags(tc.msg)
+			r.Equal(t, tc.want, flags)
+
+			// Flags survive the round trip.
+			msg := Message{}
+			applyMaildirFlags(&msg, "1600000000.M1P2.host"+flags)
+			r.Equal(t, tc.msg, msg)
+		})
+	}
+}
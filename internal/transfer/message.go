@@ -31,6 +31,15 @@ type Message struct {
 	Body    []byte
 	Source  Mailbox
 	Targets []Mailbox
+
+	// State of the message at the source, if the source keeps it.
+	Answered bool
+	Starred  bool
+	Deleted  bool
+	Draft    bool
+
+	// Checksum is the canonical hash recorded during export, if any.
+	Checksum string
 }
 
 // MessageStatus holds status for message used by progress manager.
@@ -40,12 +49,16 @@ type MessageStatus struct {
 	SourceID  string    // Message ID at the source.
 	targetID  string    // Message ID at the target (if any).
 	bodyHash  string    // Hash of the message body.
+	bodySize  uint64    // Size of the exported message body.
 
 	exported  bool
 	imported  bool
+	duplicate bool // Skipped as already present in the target.
 	exportErr error
 	importErr error
 
+	importReports int // How many targets reported import so far.
+
 	// Info about message displayed to user.
 	// This is needed only for failed messages, but we cannot know in advance
 	// which message will fail. We could clear it once the message passed
@@ -8,6 +8,7 @@ import (
 	pmapi "github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockPanicHandler is a mock of PanicHandler interface
@@ -82,6 +83,20 @@ func (mr *MockClientManagerMockRecorder) CheckConnection() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckConnection", reflect.TypeOf((*MockClientManager)(nil).CheckConnection))
 }
 
+// GetRateLimitDelay mocks base method
+func (m *MockClientManager) GetRateLimitDelay() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRateLimitDelay")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetRateLimitDelay indicates an expected call of GetRateLimitDelay
+func (mr *MockClientManagerMockRecorder) GetRateLimitDelay() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRateLimitDelay", reflect.TypeOf((*MockClientManager)(nil).GetRateLimitDelay))
+}
+
 // GetClient mocks base method
 func (m *MockClientManager) GetClient(arg0 string) pmapi.Client {
 	m.ctrl.T.Helper()
@@ -20,6 +20,7 @@ package transfer
 import (
 	"crypto/sha256"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -38,10 +39,16 @@ type Progress struct { //nolint[maligned]
 	messageCounted  bool
 	messageCounts   map[string]uint
 	messageStatuses map[string]*MessageStatus
+	currentID       string
 	pauseReason     string
 	isStopped       bool
 	fatalError      error
 	fileReport      *fileReport
+	state           *transferState
+	startedAt       time.Time
+
+	// importsPerMessage is how many targets report import of each message.
+	importsPerMessage int
 }
 
 func newProgress(log *logrus.Entry, fileReport *fileReport) Progress {
@@ -53,6 +60,7 @@ func newProgress(log *logrus.Entry, fileReport *fileReport) Progress {
 		messageCounts:   map[string]uint{},
 		messageStatuses: map[string]*MessageStatus{},
 		fileReport:      fileReport,
+		startedAt:       time.Now(),
 	}
 }
 
@@ -76,6 +84,11 @@ func (p *Progress) finish() {
 
 	log.Debug("Progress finished")
 	p.cleanUpdateCh()
+
+	// Keep the state of stopped transfer to continue with it next time.
+	if !p.isStopped {
+		p.state.clear()
+	}
 }
 
 // fatal should be called once there is error with no possible continuation.
@@ -128,6 +141,30 @@ func (p *Progress) addMessage(messageID string, rule *Rule) {
 		rule:      rule,
 		SourceID:  messageID,
 	}
+	p.currentID = messageID
+}
+
+// skipTransferred should be called before exporting the message. If the
+// message was transferred by the previous run of the same transfer, it is
+// added as already imported and the provider should skip it.
+func (p *Progress) skipTransferred(messageID string, rule *Rule) bool {
+	if !p.state.isDone(messageID) {
+		return false
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.update()
+
+	p.log.WithField("id", messageID).Trace("Message skipped as already transferred")
+	p.messageStatuses[messageID] = &MessageStatus{
+		eventTime: time.Now(),
+		rule:      rule,
+		SourceID:  messageID,
+		exported:  true,
+		imported:  true,
+	}
+	return true
 }
 
 // messageExported should be called right before message is exported.
@@ -149,6 +186,7 @@ func (p *Progress) messageExported(messageID string, body []byte, err error) {
 	}
 
 	if len(body) > 0 {
+		status.bodySize = uint64(len(body))
 		status.bodyHash = fmt.Sprintf("%x", sha256.Sum256(body))
 
 		if header, err := getMessageHeader(body); err != nil {
@@ -164,7 +202,17 @@ func (p *Progress) messageExported(messageID string, body []byte, err error) {
 	}
 }
 
+// setImportsPerMessage should be called by target writing each message
+// to several targets before any message is imported.
+func (p *Progress) setImportsPerMessage(count int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.importsPerMessage = count
+}
+
 // messageImported should be called right after message is imported.
+// With several targets, message is imported once all of them reported it.
 func (p *Progress) messageImported(messageID, importID string, err error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
@@ -176,16 +224,43 @@ func (p *Progress) messageImported(messageID, importID string, err error) {
 	}
 	log.Debug("Message imported")
 
-	p.messageStatuses[messageID].targetID = importID
-	p.messageStatuses[messageID].importErr = err
-	if err == nil {
-		p.messageStatuses[messageID].imported = true
+	status := p.messageStatuses[messageID]
+	if importID != "" {
+		status.targetID = importID
+	}
+	if status.importErr == nil {
+		status.importErr = err
+	}
+	status.importReports++
+	if status.importReports < p.importsPerMessage {
+		return
+	}
+
+	if status.importErr == nil {
+		status.imported = true
+		p.state.markDone(messageID)
 	}
 
 	// Import is the last step, now we can log the result to the report file.
 	p.logMessage(messageID)
 }
 
+// messageDuplicate should be called instead of messageImported when
+// the message is not imported because it is already in the target.
+func (p *Progress) messageDuplicate(messageID string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	defer p.update()
+
+	p.log.WithField("id", messageID).Debug("Message skipped as duplicate")
+
+	p.messageStatuses[messageID].imported = true
+	p.messageStatuses[messageID].duplicate = true
+	p.state.markDone(messageID)
+
+	p.logMessage(messageID)
+}
+
 // logMessage writes message status to log file.
 func (p *Progress) logMessage(messageID string) {
 	if p.fileReport == nil {
@@ -342,6 +417,96 @@ func (p *Progress) GetCounts() (failed, imported, exported, added, total uint) {
 	return
 }
 
+// GetDuplicateCount returns the number of messages skipped because they
+// were already in the target.
+func (p *Progress) GetDuplicateCount() (duplicates uint) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, status := range p.messageStatuses {
+		if status.duplicate {
+			duplicates++
+		}
+	}
+	return
+}
+
+// FolderCounts holds counts of processed messages of one source mailbox.
+type FolderCounts struct {
+	Name                                     string
+	Failed, Imported, Exported, Added, Total uint
+}
+
+// GetFolderCounts returns counts of exported and imported messages per
+// source mailbox sorted by the mailbox name.
+func (p *Progress) GetFolderCounts() []FolderCounts {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.updateCh != nil && !p.messageCounted {
+		return nil
+	}
+
+	includeMissing := p.updateCh == nil
+
+	countsPerFolder := map[string]*FolderCounts{}
+	getFolder := func(name string) *FolderCounts {
+		if _, ok := countsPerFolder[name]; !ok {
+			countsPerFolder[name] = &FolderCounts{Name: name}
+		}
+		return countsPerFolder[name]
+	}
+
+	for mailbox, mailboxCount := range p.messageCounts {
+		getFolder(mailbox).Total = mailboxCount
+	}
+	for _, status := range p.messageStatuses {
+		if status.rule == nil {
+			continue
+		}
+		folder := getFolder(status.rule.SourceMailbox.Name)
+		folder.Added++
+		if status.exported {
+			folder.Exported++
+		}
+		if status.imported {
+			folder.Imported++
+		}
+		if status.hasError(includeMissing) {
+			folder.Failed++
+		}
+	}
+
+	folders := []FolderCounts{}
+	for _, folder := range countsPerFolder {
+		folders = append(folders, *folder)
+	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].Name < folders[j].Name
+	})
+	return folders
+}
+
+// GetCurrentMessage returns source mailbox and description (subject or ID
+// when subject is not known yet) of the last message added to the process.
+func (p *Progress) GetCurrentMessage() (mailbox, description string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	status, ok := p.messageStatuses[p.currentID]
+	if !ok {
+		return "", ""
+	}
+	if status.rule != nil {
+		mailbox = status.rule.SourceMailbox.Name
+	}
+	description = status.Subject
+	if description == "" {
+		description = status.SourceID
+	}
+	return mailbox, description
+}
+
 // GenerateBugReport generates similar file to import log except private information.
 func (p *Progress) GenerateBugReport() []byte {
 	bugReport := bugReport{}
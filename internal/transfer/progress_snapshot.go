@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+)
+
+// Progress events written as JSON lines.
+const (
+	ProgressEventProgress = "progress"
+	ProgressEventPaused   = "paused"
+	ProgressEventFinished = "finished"
+	ProgressEventFailed   = "failed"
+)
+
+// ProgressSnapshot is the state of the transfer in a moment meant for
+// tools wrapping the app which cannot parse human-readable output.
+type ProgressSnapshot struct {
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Processed  uint      `json:"processed"`
+	Exported   uint      `json:"exported"`
+	Added      uint      `json:"added"`
+	Total      uint      `json:"total"`
+	Failed     uint      `json:"failed"`
+	Duplicates uint      `json:"duplicates,omitempty"`
+	Bytes      uint64    `json:"bytes"`
+	ETASeconds int64     `json:"etaSeconds,omitempty"`
+	Folder     string    `json:"folder,omitempty"`
+	Current    string    `json:"current,omitempty"`
+	Paused     string    `json:"paused,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	FailedIDs  []string  `json:"failedIds,omitempty"`
+}
+
+// GetSnapshot returns current counts, exported bytes, estimated remaining
+// time and IDs of failed messages labelled by the event.
+func (p *Progress) GetSnapshot(event string) ProgressSnapshot {
+	failed, imported, exported, added, total := p.GetCounts()
+	folder, current := p.GetCurrentMessage()
+
+	snapshot := ProgressSnapshot{
+		Event:      event,
+		Time:       time.Now(),
+		Processed:  imported,
+		Exported:   exported,
+		Added:      added,
+		Total:      total,
+		Failed:     failed,
+		Duplicates: p.GetDuplicateCount(),
+		Folder:     folder,
+		Current:    current,
+		Paused:     p.PauseReason(),
+	}
+	if err := p.GetFatalError(); err != nil {
+		snapshot.Error = err.Error()
+	}
+
+	p.lock.Lock()
+	for _, status := range p.messageStatuses {
+		snapshot.Bytes += status.bodySize
+	}
+	startedAt := p.startedAt
+	isRunning := p.updateCh != nil
+	p.lock.Unlock()
+
+	for _, status := range p.GetFailedMessages() {
+		snapshot.FailedIDs = append(snapshot.FailedIDs, status.SourceID)
+	}
+	sort.Strings(snapshot.FailedIDs)
+
+	// Failed messages are done as well, they only did not make it.
+	if done := imported + failed; isRunning && done > 0 && total > done {
+		elapsed := time.Since(startedAt)
+		snapshot.ETASeconds = int64((elapsed * time.Duration(total-done) / time.Duration(done)).Seconds())
+	}
+
+	return snapshot
+}
+
+// WriteSnapshot writes the snapshot as one line of JSON.
+func (p *Progress) WriteSnapshot(w io.Writer, event string) error {
+	return json.NewEncoder(w).Encode(p.GetSnapshot(event))
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestProgressSnapshot(t *testing.T) {
+	progress := newProgress(log, nil)
+	drainProgressUpdateChannel(&progress)
+	progress.startedAt = time.Now().Add(-10 * time.Second)
+
+	rule := &Rule{SourceMailbox: Mailbox{Name: "Inbox"}}
+	progress.updateCount("Inbox", 4)
+	progress.countsFinal()
+
+	progress.addMessage("msg1", rule)
+	progress.messageExported("msg1", []byte("12345"), nil)
+	progress.messageImported("msg1", "", nil)
+
+	progress.addMessage("msg2", rule)
+	progress.messageExported("msg2", []byte("123"), nil)
+	progress.messageImported("msg2", "", errors.New("failed import"))
+
+	snapshot := progress.GetSnapshot(ProgressEventProgress)
+	r.Equal(t, ProgressEventProgress, snapshot.Event)
+	r.Equal(t, uint(1), snapshot.Processed)
+	r.Equal(t, uint(1), snapshot.Failed)
+	r.Equal(t, uint(4), snapshot.Total)
+	r.Equal(t, uint64(8), snapshot.Bytes)
+	r.Equal(t, "Inbox", snapshot.Folder)
+	r.Equal(t, "msg2", snapshot.Current)
+	r.Equal(t, []string{"msg2"}, snapshot.FailedIDs)
+	// Two of four messages took ten seconds.
+	r.InDelta(t, 10, snapshot.ETASeconds, 1)
+
+	progress.finish()
+
+	var buf bytes.Buffer
+	r.NoError(t, progress.WriteSnapshot(&buf, ProgressEventFinished))
+	r.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1])
+
+	var decoded map[string]interface{}
+	r.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	r.Equal(t, "finished", decoded["event"])
+	r.Equal(t, float64(8), decoded["bytes"])
+	r.NotContains(t, decoded, "etaSeconds")
+}
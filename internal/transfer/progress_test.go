@@ -84,6 +84,36 @@ func TestProgressAddingMessages(t *testing.T) {
 	}, errorsMap)
 }
 
+func TestProgressFolderCounts(t *testing.T) {
+	progress := newProgress(log, nil)
+	drainProgressUpdateChannel(&progress)
+
+	inboxRule := &Rule{SourceMailbox: Mailbox{Name: "inbox"}}
+	sentRule := &Rule{SourceMailbox: Mailbox{Name: "sent"}}
+
+	progress.updateCount("inbox", 3)
+	progress.updateCount("sent", 1)
+	progress.countsFinal()
+
+	progress.addMessage("msg1", inboxRule)
+	progress.messageExported("msg1", []byte(""), nil)
+	progress.messageImported("msg1", "", nil)
+
+	progress.addMessage("msg2", inboxRule)
+	progress.messageExported("msg2", []byte(""), errors.New("failed export"))
+
+	progress.addMessage("msg3", sentRule)
+
+	r.Equal(t, []FolderCounts{
+		{Name: "inbox", Failed: 1, Imported: 1, Exported: 1, Added: 2, Total: 3},
+		{Name: "sent", Added: 1, Total: 1},
+	}, progress.GetFolderCounts())
+
+	mailbox, description := progress.GetCurrentMessage()
+	r.Equal(t, "sent", mailbox)
+	r.Equal(t, "msg3", description)
+}
+
 func TestProgressFinish(t *testing.T) {
 	progress := newProgress(log, nil)
 	drainProgressUpdateChannel(&progress)
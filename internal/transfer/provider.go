@@ -32,6 +32,10 @@ type Provider interface {
 type SourceProvider interface {
 	Provider
 
+	// Estimate returns count and approximate size of messages matching
+	// active rules without exporting them.
+	Estimate(transferRules) (count uint, size uint64, err error)
+
 	// TransferTo exports messages based on rules to channel.
 	TransferTo(transferRules, *Progress, chan<- Message)
 }
@@ -49,3 +53,14 @@ type TargetProvider interface {
 	// TransferFrom imports messages from channel.
 	TransferFrom(transferRules, *Progress, <-chan Message)
 }
+
+// dateLayouter is implemented by target providers which can organize
+// exported files by date.
+type dateLayouter interface {
+	SetDateLayout(bool)
+}
+
+// nameNormalizer is implemented by target providers with naming rules for mailboxes.
+type nameNormalizer interface {
+	SetNameNormalization(NameNormalization)
+}
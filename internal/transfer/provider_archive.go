@@ -0,0 +1,227 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedArchive is returned for archive path with unknown extension.
+var ErrUnsupportedArchive = errors.New("unsupported archive format, use .zip, .tar, .tar.zst or .tar.gz")
+
+// ArchiveProvider implements export to EML files stored in a single archive.
+// Messages are streamed directly to the archive without writing them to
+// the disk first, which is much faster than creating many small files.
+type ArchiveProvider struct {
+	path string
+}
+
+// NewArchiveProvider creates ArchiveProvider. Format is chosen by
+// the extension of the path.
+func NewArchiveProvider(path string) (*ArchiveProvider, error) {
+	if !IsArchivePath(path) {
+		return nil, ErrUnsupportedArchive
+	}
+	return &ArchiveProvider{
+		path: path,
+	}, nil
+}
+
+// IsArchivePath returns whether path has extension of supported archive.
+func IsArchivePath(path string) bool {
+	path = strings.ToLower(path)
+	for _, ext := range []string{".zip", ".tar", ".tar.zst", ".tzst", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ID is used for generating transfer ID by combining source and target ID.
+// It returns the same ID as EML provider to share rules with EML export.
+func (p *ArchiveProvider) ID() string {
+	return "local" //nolint[goconst]
+}
+
+// Mailboxes returns nothing, archive is used only as target.
+func (p *ArchiveProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox, error) {
+	return nil, nil
+}
+
+// DefaultMailboxes returns the default mailboxes for default rules if no other is found.
+func (p *ArchiveProvider) DefaultMailboxes(sourceMailbox Mailbox) []Mailbox {
+	return []Mailbox{{
+		Name: sourceMailbox.Name,
+	}}
+}
+
+// CreateMailbox does nothing. Folders are created dynamically during the export.
+func (p *ArchiveProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
+	return mailbox, nil
+}
+
+// TransferFrom writes messages from channel to the archive.
+func (p *ArchiveProvider) TransferFrom(rules transferRules, progress *Progress, ch <-chan Message) {
+	log.Info("Started transfer from channel to archive")
+	defer log.Info("Finished transfer from channel to archive")
+
+	archive, err := p.create()
+	if err != nil {
+		progress.fatal(err)
+		return
+	}
+
+	for msg := range ch {
+		if progress.shouldStop() {
+			break
+		}
+
+		err := p.writeMessage(archive, msg)
+		progress.messageImported(msg.ID, "", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		progress.fatal(errors.Wrap(err, "failed to finish archive"))
+	}
+}
+
+func (p *ArchiveProvider) create() (archiveWriter, error) {
+	f, err := os.Create(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	lowerPath := strings.ToLower(p.path)
+	switch {
+	case strings.HasSuffix(lowerPath, ".zip"):
+		return &zipArchive{file: f, writer: zip.NewWriter(f)}, nil
+	case strings.HasSuffix(lowerPath, ".tar.zst"), strings.HasSuffix(lowerPath, ".tzst"):
+		zstdWriter, err := zstd.NewWriter(f)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return &tarArchive{file: f, compressor: zstdWriter, writer: tar.NewWriter(zstdWriter)}, nil
+	case strings.HasSuffix(lowerPath, ".tar.gz"), strings.HasSuffix(lowerPath, ".tgz"):
+		gzipWriter := gzip.NewWriter(f)
+		return &tarArchive{file: f, compressor: gzipWriter, writer: tar.NewWriter(gzipWriter)}, nil
+	default:
+		return &tarArchive{file: f, writer: tar.NewWriter(f)}, nil
+	}
+}
+
+func (p *ArchiveProvider) writeMessage(archive archiveWriter, msg Message) error {
+	fileName := filepath.Base(msg.ID)
+	if filepath.Ext(fileName) != ".eml" {
+		fileName += ".eml"
+	}
+
+	msgTime := time.Now()
+	if header, err := getMessageHeader(msg.Body); err == nil {
+		if date, err := header.Date(); err == nil {
+			msgTime = date
+		}
+	}
+
+	var err error
+	for _, mailbox := range msg.Targets {
+		// Archives always use forward slashes no matter the OS.
+		name := path.Join(mailbox.Name, fileName)
+		if localErr := archive.writeFile(name, msgTime, msg.Body); localErr != nil {
+			err = multierror.Append(err, localErr)
+		}
+	}
+	return err
+}
+
+type archiveWriter interface {
+	writeFile(name string, modTime time.Time, body []byte) error
+	Close() error
+}
+
+type zipArchive struct {
+	file   io.Closer
+	writer *zip.Writer
+}
+
+func (a *zipArchive) writeFile(name string, modTime time.Time, body []byte) error {
+	w, err := a.writer.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (a *zipArchive) Close() error {
+	if err := a.writer.Close(); err != nil {
+		_ = a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
+
+type tarArchive struct {
+	file       io.Closer
+	compressor io.WriteCloser // Optional.
+	writer     *tar.Writer
+}
+
+func (a *tarArchive) writeFile(name string, modTime time.Time, body []byte) error {
+	if err := a.writer.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(body)),
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := a.writer.Write(body)
+	return err
+}
+
+func (a *tarArchive) Close() error {
+	err := a.writer.Close()
+	if a.compressor != nil {
+		if compressorErr := a.compressor.Close(); err == nil {
+			err = compressorErr
+		}
+	}
+	if fileErr := a.file.Close(); err == nil {
+		err = fileErr
+	}
+	return err
+}
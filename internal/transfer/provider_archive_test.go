@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestArchiveProviderUnsupported(t *testing.T) {
+	_, err := NewArchiveProvider("export.rar")
+	r.Equal(t, ErrUnsupportedArchive, err)
+}
+
+func TestArchiveProviderTransferFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	for _, name := range []string{"export.zip", "export.tar", "export.tar.zst", "export.tar.gz"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(dir, name)
+			provider, err := NewArchiveProvider(archivePath)
+			r.NoError(t, err)
+
+			rules, rulesClose := newTestRules(t)
+			defer rulesClose()
+			setupEMLRules(rules)
+
+			testTransferFrom(t, rules, provider, []Message{
+				{ID: "Foo/msg.eml", Body: getTestMsgBody("msg"), Targets: []Mailbox{{Name: "Foo"}}},
+				{ID: "msg2", Body: getTestMsgBody("msg"), Targets: []Mailbox{{Name: "Inbox"}, {Name: "Foo"}}},
+			})
+
+			r.Equal(t, []string{"Foo/msg.eml", "Inbox/msg2.eml", "Foo/msg2.eml"}, readArchiveFileNames(t, archivePath))
+		})
+	}
+}
+
+func readArchiveFileNames(t *testing.T, archivePath string) (names []string) {
+	if filepath.Ext(archivePath) == ".zip" {
+		zipReader, err := zip.OpenReader(archivePath)
+		r.NoError(t, err)
+		defer zipReader.Close() //nolint[errcheck]
+
+		for _, file := range zipReader.File {
+			names = append(names, file.Name)
+		}
+		return names
+	}
+
+	f, err := os.Open(archivePath) //nolint[gosec]
+	r.NoError(t, err)
+	defer f.Close() //nolint[errcheck]
+
+	var reader io.Reader = f
+	switch filepath.Ext(archivePath) {
+	case ".gz":
+		reader, err = gzip.NewReader(f)
+		r.NoError(t, err)
+	case ".zst":
+		zstdReader, err := zstd.NewReader(f)
+		r.NoError(t, err)
+		defer zstdReader.Close()
+		reader = zstdReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		r.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	return names
+}
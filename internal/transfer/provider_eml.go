@@ -19,7 +19,9 @@ package transfer
 
 // EMLProvider implements import and export to/from EML file structure.
 type EMLProvider struct {
-	root string
+	root       string
+	checksums  bool
+	dateLayout bool
 }
 
 // NewEMLProvider creates EMLProvider.
@@ -29,6 +31,19 @@ func NewEMLProvider(root string) *EMLProvider {
 	}
 }
 
+// SetChecksums sets whether canonical hashes of messages are recorded
+// during export and passed along with messages during import.
+func (p *EMLProvider) SetChecksums(enabled bool) {
+	p.checksums = enabled
+}
+
+// SetDateLayout sets whether exported messages are stored in `YYYY/MM`
+// subfolders of each mailbox folder instead of directly in the folder.
+// Import recognizes both layouts no matter of this option.
+func (p *EMLProvider) SetDateLayout(enabled bool) {
+	p.dateLayout = enabled
+}
+
 // ID is used for generating transfer ID by combining source and target ID.
 // We want to keep the same rules for import from or export to local files
 // no matter exact path, therefore it returns constant. The same as EML.
@@ -63,6 +78,7 @@ func (p *EMLProvider) Mailboxes(includeEmpty, includeAllMail bool) (mailboxes []
 			IsExclusive: false,
 		})
 	}
+	applyMailboxMetadata(p.root, mailboxes)
 
 	return mailboxes, nil
 }
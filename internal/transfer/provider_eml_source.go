@@ -40,6 +40,14 @@ func (p *EMLProvider) TransferTo(rules transferRules, progress *Progress, ch cha
 		return
 	}
 
+	checksums := map[string]string{}
+	if p.checksums {
+		if checksums, err = loadChecksums(p.root); err != nil {
+			progress.fatal(errors.Wrap(err, "failed to load checksums"))
+			return
+		}
+	}
+
 	// This list is not filtered by time but instead going throgh each file
 	// twice or keeping all in memory we will tell rough estimation which
 	// will be updated during processing each file.
@@ -56,10 +64,36 @@ func (p *EMLProvider) TransferTo(rules transferRules, progress *Progress, ch cha
 		// No error guaranteed by getFilePathsPerFolder.
 		rule, _ := rules.getRuleBySourceMailboxName(folderName)
 		log.WithField("rule", rule).Debug("Processing rule")
-		p.exportMessages(rule, filePaths, progress, ch)
+		p.exportMessages(rule, filePaths, checksums, progress, ch)
 	}
 }
 
+// Estimate returns count and size of EML files of active rules.
+// Time limits are not taken into account as that would need to read
+// every file.
+func (p *EMLProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	filePathsPerFolder, err := p.getFilePathsPerFolder(rules)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for folderName, filePaths := range filePathsPerFolder {
+		// No error guaranteed by getFilePathsPerFolder.
+		if rule, _ := rules.getRuleBySourceMailboxName(folderName); !rule.Active {
+			continue
+		}
+		for _, filePath := range filePaths {
+			info, err := os.Stat(filepath.Join(p.root, filePath))
+			if err != nil {
+				return 0, 0, err
+			}
+			count++
+			size += uint64(info.Size())
+		}
+	}
+	return count, size, nil
+}
+
 func (p *EMLProvider) getFilePathsPerFolder(rules transferRules) (map[string][]string, error) {
 	filePaths, err := getFilePathsWithSuffix(p.root, ".eml")
 	if err != nil {
@@ -68,7 +102,7 @@ func (p *EMLProvider) getFilePathsPerFolder(rules transferRules) (map[string][]s
 
 	filePathsMap := map[string][]string{}
 	for _, filePath := range filePaths {
-		folder := filepath.Base(filepath.Dir(filepath.Join(p.root, filePath)))
+		folder := getMailboxFolderName(filepath.Dir(filepath.Join(p.root, filePath)))
 		_, err := rules.getRuleBySourceMailboxName(folder)
 		if err != nil {
 			log.WithField("msg", filePath).Trace("Message skipped due to folder name")
@@ -81,7 +115,7 @@ func (p *EMLProvider) getFilePathsPerFolder(rules transferRules) (map[string][]s
 	return filePathsMap, nil
 }
 
-func (p *EMLProvider) exportMessages(rule *Rule, filePaths []string, progress *Progress, ch chan<- Message) {
+func (p *EMLProvider) exportMessages(rule *Rule, filePaths []string, checksums map[string]string, progress *Progress, ch chan<- Message) {
 	count := uint(len(filePaths))
 
 	for _, filePath := range filePaths {
@@ -89,7 +123,12 @@ func (p *EMLProvider) exportMessages(rule *Rule, filePaths []string, progress *P
 			break
 		}
 
+		if progress.skipTransferred(filePath, rule) {
+			continue
+		}
+
 		msg, err := p.exportMessage(rule, filePath)
+		msg.Checksum = checksums[filePath]
 
 		// Read and check time in body only if the rule specifies it
 		// to not waste energy.
@@ -23,6 +23,7 @@ import (
 	"path/filepath"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 )
 
 // DefaultMailboxes returns the default mailboxes for default rules if no other is found.
@@ -48,6 +49,10 @@ func (p *EMLProvider) TransferFrom(rules transferRules, progress *Progress, ch <
 		return
 	}
 
+	if err := writeMailboxMetadata(p.root, rules); err != nil {
+		log.WithError(err).Warn("Cannot write mailbox metadata")
+	}
+
 	for msg := range ch {
 		for progress.shouldStop() {
 			break
@@ -76,13 +81,42 @@ func (p *EMLProvider) writeFile(msg Message) error {
 		fileName += ".eml"
 	}
 
+	dateFolder := ""
+	if p.dateLayout {
+		dateFolder = getDateFolder(msg.Body)
+	}
+
 	var err error
+	written := []string{}
 	for _, mailbox := range msg.Targets {
-		path := filepath.Join(p.root, mailbox.Name, fileName)
+		folder := filepath.Join(mailbox.Name, dateFolder)
+		if dateFolder != "" {
+			if localErr := os.MkdirAll(filepath.Join(p.root, folder), os.ModePerm); localErr != nil {
+				err = multierror.Append(err, localErr)
+				continue
+			}
+		}
 
+		path := filepath.Join(p.root, folder, fileName)
 		if localErr := ioutil.WriteFile(path, msg.Body, 0600); localErr != nil {
 			err = multierror.Append(err, localErr)
+			continue
+		}
+		written = append(written, filepath.Join(folder, fileName))
+	}
+
+	if p.checksums && len(written) > 0 {
+		if hashErr := p.writeChecksum(msg, written); hashErr != nil {
+			err = multierror.Append(err, hashErr)
 		}
 	}
 	return err
 }
+
+func (p *EMLProvider) writeChecksum(msg Message, paths []string) error {
+	hash, err := canonicalMessageHash(msg.Body)
+	if err != nil {
+		return errors.Wrap(err, "failed to compute checksum")
+	}
+	return appendChecksum(p.root, hash, paths)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -114,6 +115,40 @@ func TestEMLProviderTransferFromTo(t *testing.T) {
 	})
 }
 
+func TestEMLProviderDateLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "eml")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	provider := newTestEMLProvider(dir)
+	provider.SetDateLayout(true)
+	r.NoError(t, os.MkdirAll(filepath.Join(dir, "Foo"), os.ModePerm))
+
+	body := append([]byte("Date: Mon, 02 Jan 2006 15:04:05 +0000\n"), getTestMsgBody("msg")...)
+	r.NoError(t, provider.writeFile(Message{ID: "msg", Body: body, Targets: []Mailbox{{Name: "Foo"}}}))
+	r.NoError(t, provider.writeFile(Message{ID: "nodate", Body: getTestMsgBody("nodate"), Targets: []Mailbox{{Name: "Foo"}}}))
+
+	checkEMLFileStructure(t, dir, []string{
+		filepath.Join("Foo", "2006", "01", "msg.eml"),
+		filepath.Join("Foo", "nodate.eml"),
+	})
+
+	mailboxes, err := provider.Mailboxes(false, false)
+	r.NoError(t, err)
+	r.Equal(t, []Mailbox{{Name: "Foo"}}, mailboxes)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupEMLRules(rules)
+
+	filePathsPerFolder, err := provider.getFilePathsPerFolder(rules)
+	r.NoError(t, err)
+	r.Equal(t, map[string][]string{"Foo": {
+		filepath.Join("Foo", "2006", "01", "msg.eml"),
+		filepath.Join("Foo", "nodate.eml"),
+	}}, filePathsPerFolder)
+}
+
 func setupEMLRules(rules transferRules) {
 	_ = rules.setRule(Mailbox{Name: "Inbox"}, []Mailbox{{Name: "Inbox"}}, 0, 0)
 	_ = rules.setRule(Mailbox{Name: "Foo"}, []Mailbox{{Name: "Foo"}}, 0, 0)
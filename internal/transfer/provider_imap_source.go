@@ -49,6 +49,47 @@ func (p *IMAPProvider) TransferTo(rules transferRules, progress *Progress, ch ch
 	}
 }
 
+// Estimate fetches sizes of messages of active rules.
+func (p *IMAPProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	for _, rule := range rules.getSortedRules() {
+		if !rule.Active {
+			continue
+		}
+
+		mailbox, err := p.selectIn(rule.SourceMailbox.Name)
+		if err != nil {
+			return 0, 0, err
+		}
+		if mailbox.Messages == 0 {
+			continue
+		}
+
+		seqSet := &imap.SeqSet{}
+		seqSet.AddRange(1, mailbox.Messages)
+
+		items := []imap.FetchItem{imap.FetchRFC822Size}
+		if rule.HasTimeLimit() {
+			items = append(items, imap.FetchEnvelope)
+		}
+
+		rule := rule
+		err = p.fetch(rule.SourceMailbox.Name, seqSet, items, func(imapMessage *imap.Message) {
+			if rule.HasTimeLimit() {
+				t := imapMessage.Envelope.Date.Unix()
+				if t != 0 && !rule.isTimeInRange(t) {
+					return
+				}
+			}
+			count++
+			size += uint64(imapMessage.Size)
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return count, size, nil
+}
+
 func (p *IMAPProvider) loadMessageInfoMap(rules transferRules, progress *Progress) map[string]map[string]imapMessageInfo {
 	res := map[string]map[string]imapMessageInfo{}
 
@@ -68,17 +109,20 @@ func (p *IMAPProvider) loadMessageInfoMap(rules transferRules, progress *Progres
 			continue
 		}
 
-		messagesInfo := p.loadMessagesInfo(rule, progress, mailbox.UidValidity, mailbox.Messages)
+		messagesInfo, transferredCount := p.loadMessagesInfo(rule, progress, mailbox.UidValidity, mailbox.Messages)
 		res[rule.SourceMailbox.Name] = messagesInfo
-		progress.updateCount(rule.SourceMailbox.Name, uint(len(messagesInfo)))
+		progress.updateCount(rule.SourceMailbox.Name, uint(len(messagesInfo))+transferredCount)
 	}
 	progress.countsFinal()
 
 	return res
 }
 
-func (p *IMAPProvider) loadMessagesInfo(rule *Rule, progress *Progress, uidValidity, count uint32) map[string]imapMessageInfo {
+// loadMessagesInfo returns info of messages to transfer and count of messages
+// skipped as they were already transferred by the previous run.
+func (p *IMAPProvider) loadMessagesInfo(rule *Rule, progress *Progress, uidValidity, count uint32) (map[string]imapMessageInfo, uint) {
 	messagesInfo := map[string]imapMessageInfo{}
+	transferredIDs := map[string]bool{}
 
 	pageStart := uint32(1)
 	pageEnd := imapPageSize
@@ -111,6 +155,10 @@ func (p *IMAPProvider) loadMessagesInfo(rule *Rule, progress *Progress, uidValid
 				}
 			}
 			id := getUniqueMessageID(rule.SourceMailbox.Name, uidValidity, imapMessage.Uid)
+			if transferredIDs[id] || progress.skipTransferred(id, rule) {
+				transferredIDs[id] = true
+				return
+			}
 			// We use ID as key to ensure we have every unique message only once.
 			// Some IMAP servers responded twice the same message...
 			messagesInfo[id] = imapMessageInfo{
@@ -133,7 +181,7 @@ func (p *IMAPProvider) loadMessagesInfo(rule *Rule, progress *Progress, uidValid
 		pageEnd += imapPageSize
 	}
 
-	return messagesInfo
+	return messagesInfo, uint(len(transferredIDs))
 }
 
 func (p *IMAPProvider) transferTo(rule *Rule, messagesInfo map[string]imapMessageInfo, progress *Progress, ch chan<- Message) {
@@ -209,20 +257,30 @@ func (p *IMAPProvider) exportMessages(rule *Rule, progress *Progress, ch chan<-
 }
 
 func (p *IMAPProvider) exportMessage(rule *Rule, id string, imapMessage *imap.Message, body []byte) Message {
-	unread := true
-	for _, flag := range imapMessage.Flags {
-		if flag == imap.SeenFlag {
-			unread = false
-		}
-	}
-
-	return Message{
+	msg := Message{
 		ID:      id,
-		Unread:  unread,
+		Unread:  true,
 		Body:    body,
 		Source:  rule.SourceMailbox,
 		Targets: rule.TargetMailboxes,
 	}
+
+	for _, flag := range imapMessage.Flags {
+		switch flag {
+		case imap.SeenFlag:
+			msg.Unread = false
+		case imap.AnsweredFlag:
+			msg.Answered = true
+		case imap.FlaggedFlag:
+			msg.Starred = true
+		case imap.DeletedFlag:
+			msg.Deleted = true
+		case imap.DraftFlag:
+			msg.Draft = true
+		}
+	}
+
+	return msg
 }
 
 func getUniqueMessageID(mailboxName string, uidValidity, uid uint32) string {
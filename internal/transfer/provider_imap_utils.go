@@ -143,7 +143,7 @@ func (p *IMAPProvider) auth() error { //nolint[funlen]
 	var client *imapClient.Client
 	var err error
 	host, _, _ := net.SplitHostPort(p.addr)
-	if host == "127.0.0.1" {
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
 		client, err = imapClient.Dial(p.addr)
 	} else {
 		client, err = imapClient.DialTLS(p.addr, nil)
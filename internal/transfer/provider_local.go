@@ -32,6 +32,12 @@ func NewLocalProvider(root string) *LocalProvider {
 	}
 }
 
+// SetChecksums sets whether canonical hashes recorded during export
+// are passed along with messages. Only EML export records them.
+func (p *LocalProvider) SetChecksums(enabled bool) {
+	p.emlProvider.SetChecksums(enabled)
+}
+
 // ID is used for generating transfer ID by combining source and target ID.
 // We want to keep the same rules for import from or export to local files
 // no matter exact path, therefore it returns constant.
@@ -40,3 +40,16 @@ func (p *LocalProvider) TransferTo(rules transferRules, progress *Progress, ch c
 
 	wg.Wait()
 }
+
+// Estimate returns count and size of both EML and MBOX messages.
+func (p *LocalProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	emlCount, emlSize, err := p.emlProvider.Estimate(rules)
+	if err != nil {
+		return 0, 0, err
+	}
+	mboxCount, mboxSize, err := p.mboxProvider.Estimate(rules)
+	if err != nil {
+		return 0, 0, err
+	}
+	return emlCount + mboxCount, emlSize + mboxSize, nil
+}
@@ -71,6 +71,26 @@ func TestLocalProviderTransferTo(t *testing.T) {
 	})
 }
 
+func TestLocalProviderEstimate(t *testing.T) {
+	provider := newTestLocalProvider("")
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupEMLMBOXRules(rules)
+
+	count, size, err := provider.Estimate(rules)
+	r.NoError(t, err)
+	r.Equal(t, uint(2), count)
+	r.Equal(t, uint64(90+122), size)
+
+	rules.unsetRule(Mailbox{Name: "Inbox"})
+
+	count, size, err = provider.Estimate(rules)
+	r.NoError(t, err)
+	r.Equal(t, uint(1), count)
+	r.Equal(t, uint64(90), size)
+}
+
 func setupEMLMBOXRules(rules transferRules) {
 	_ = rules.setRule(Mailbox{Name: "Inbox"}, []Mailbox{{Name: "Inbox"}}, 0, 0)
 	_ = rules.setRule(Mailbox{Name: "Foo"}, []Mailbox{{Name: "Foo"}}, 0, 0)
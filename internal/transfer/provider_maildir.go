@@ -0,0 +1,186 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const maildirInbox = "INBOX"
+
+// MaildirProvider implements import and export to/from Maildir structure.
+// Mailbox is a folder with cur, new and tmp subfolders. The root itself
+// is INBOX; other mailboxes are either Maildir++ folders with dot in name,
+// e.g., .Work.Projects used by Dovecot, or nested folders, e.g.,
+// Work/Projects used by offlineimap. Export creates Maildir++ layout.
+type MaildirProvider struct {
+	root string
+}
+
+// NewMaildirProvider creates MaildirProvider.
+func NewMaildirProvider(root string) *MaildirProvider {
+	return &MaildirProvider{
+		root: root,
+	}
+}
+
+// IsMaildirPath returns whether the path is root of Maildir structure.
+func IsMaildirPath(path string) bool {
+	return isMaildirFolder(path)
+}
+
+// ID is used for generating transfer ID by combining source and target ID.
+func (p *MaildirProvider) ID() string {
+	return "maildir"
+}
+
+// Mailboxes returns all Maildir folders under the root.
+func (p *MaildirProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox, error) {
+	// Special case for exporting--we don't know the path before setup if finished.
+	if p.root == "" {
+		return nil, nil
+	}
+
+	folders, err := getMaildirFolders(p.root)
+	if err != nil {
+		return nil, err
+	}
+
+	mailboxes := []Mailbox{}
+	for _, name := range sortedMaildirNames(folders) {
+		if !includeEmpty {
+			filePaths, err := getMaildirFilePaths(filepath.Join(p.root, folders[name]))
+			if err != nil {
+				return nil, err
+			}
+			if len(filePaths) == 0 {
+				continue
+			}
+		}
+
+		mailboxes = append(mailboxes, Mailbox{
+			Name:        name,
+			IsExclusive: true,
+		})
+	}
+	return mailboxes, nil
+}
+
+// isMaildirFolder returns whether the folder has cur or new subfolder.
+// Empty tmp is often not kept by backups, so it is not required.
+func isMaildirFolder(path string) bool {
+	for _, sub := range []string{"cur", "new"} {
+		if info, err := os.Stat(filepath.Join(path, sub)); err == nil && info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// getMaildirFolders returns relative paths of all Maildir folders
+// under root by their mailbox names.
+func getMaildirFolders(root string) (map[string]string, error) {
+	folders := map[string]string{}
+	if isMaildirFolder(root) {
+		folders[maildirInbox] = "."
+	}
+	return folders, collectMaildirFolders(root, "", folders)
+}
+
+func collectMaildirFolders(root, prefix string, folders map[string]string) error {
+	files, err := ioutil.ReadDir(filepath.Join(root, prefix))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		switch file.Name() {
+		case "cur", "new", "tmp":
+			continue
+		}
+
+		relPath := filepath.Join(prefix, file.Name())
+		if isMaildirFolder(filepath.Join(root, relPath)) {
+			folders[maildirFolderName(relPath)] = relPath
+		}
+		if err := collectMaildirFolders(root, relPath, folders); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maildirFolderName converts relative path of Maildir folder to mailbox
+// name, e.g., .Work.Projects or Work/Projects to Work/Projects.
+func maildirFolderName(relPath string) string {
+	parts := []string{}
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if strings.HasPrefix(part, ".") {
+			parts = append(parts, strings.Split(strings.TrimPrefix(part, "."), ".")...)
+		} else {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// maildirFolderPath converts mailbox name to relative path of Maildir++ folder.
+func maildirFolderPath(name string) string {
+	if strings.EqualFold(name, maildirInbox) {
+		return "."
+	}
+	return "." + strings.ReplaceAll(name, "/", ".")
+}
+
+func sortedMaildirNames(folders map[string]string) []string {
+	names := make([]string, 0, len(folders))
+	for name := range folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getMaildirFilePaths returns sorted paths of messages in cur and new
+// relative to the Maildir folder. Messages in tmp are not delivered yet.
+func getMaildirFilePaths(folder string) ([]string, error) {
+	filePaths := []string{}
+	for _, sub := range []string{"cur", "new"} {
+		files, err := ioutil.ReadDir(filepath.Join(folder, sub))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if !file.IsDir() && !strings.HasPrefix(file.Name(), ".") {
+				filePaths = append(filePaths, filepath.Join(sub, file.Name()))
+			}
+		}
+	}
+	sort.Strings(filePaths)
+	return filePaths, nil
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TransferTo exports messages based on rules to channel.
+func (p *MaildirProvider) TransferTo(rules transferRules, progress *Progress, ch chan<- Message) {
+	log.Info("Started transfer from Maildir to channel")
+	defer log.Info("Finished transfer from Maildir to channel")
+
+	filePathsPerFolder, err := p.getFilePathsPerFolder(rules)
+	if err != nil {
+		progress.fatal(err)
+		return
+	}
+
+	for folderName, filePaths := range filePathsPerFolder {
+		if progress.shouldStop() {
+			break
+		}
+
+		progress.updateCount(folderName, uint(len(filePaths)))
+	}
+	progress.countsFinal()
+
+	for folderName, filePaths := range filePathsPerFolder {
+		// No error guaranteed by getFilePathsPerFolder.
+		rule, _ := rules.getRuleBySourceMailboxName(folderName)
+		log.WithField("rule", rule).Debug("Processing rule")
+		p.exportMessages(rule, filePaths, progress, ch)
+	}
+}
+
+// Estimate returns count and size of messages of active rules.
+func (p *MaildirProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	filePathsPerFolder, err := p.getFilePathsPerFolder(rules)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, filePaths := range filePathsPerFolder {
+		for _, filePath := range filePaths {
+			info, err := os.Stat(filepath.Join(p.root, filePath))
+			if err != nil {
+				return 0, 0, err
+			}
+			count++
+			size += uint64(info.Size())
+		}
+	}
+	return count, size, nil
+}
+
+// getFilePathsPerFolder returns paths of messages relative to the root
+// for mailboxes with active rule.
+func (p *MaildirProvider) getFilePathsPerFolder(rules transferRules) (map[string][]string, error) {
+	folders, err := getMaildirFolders(p.root)
+	if err != nil {
+		return nil, err
+	}
+
+	filePathsMap := map[string][]string{}
+	for name, folder := range folders {
+		rule, err := rules.getRuleBySourceMailboxName(name)
+		if err != nil || !rule.Active {
+			log.WithField("mailbox", name).Trace("Mailbox skipped due to rule")
+			continue
+		}
+
+		filePaths, err := getMaildirFilePaths(filepath.Join(p.root, folder))
+		if err != nil {
+			return nil, err
+		}
+		for _, filePath := range filePaths {
+			filePathsMap[name] = append(filePathsMap[name], filepath.Join(folder, filePath))
+		}
+	}
+	return filePathsMap, nil
+}
+
+func (p *MaildirProvider) exportMessages(rule *Rule, filePaths []string, progress *Progress, ch chan<- Message) {
+	count := uint(len(filePaths))
+
+	for _, filePath := range filePaths {
+		if progress.shouldStop() {
+			break
+		}
+
+		// Flags in the info part and move from new to cur do not
+		// change the message, therefore they are not part of ID.
+		id := maildirMessageID(filePath)
+
+		if progress.skipTransferred(id, rule) {
+			continue
+		}
+
+		msg, err := p.exportMessage(rule, id, filePath)
+
+		// Read and check time in body only if the rule specifies it
+		// to not waste energy.
+		if err == nil && rule.HasTimeLimit() {
+			msgTime, msgTimeErr := getMessageTime(msg.Body)
+			if msgTimeErr != nil {
+				err = msgTimeErr
+			} else if !rule.isTimeInRange(msgTime) {
+				log.WithField("msg", id).Debug("Message skipped due to time")
+				count--
+				progress.updateCount(rule.SourceMailbox.Name, count)
+				continue
+			}
+		}
+
+		// addMessage is called after time check to not report message
+		// which should not be exported but any error from reading body
+		// or parsing time is reported as an error.
+		progress.addMessage(id, rule)
+		progress.messageExported(id, msg.Body, err)
+		if err == nil {
+			ch <- msg
+		}
+	}
+}
+
+func (p *MaildirProvider) exportMessage(rule *Rule, id, filePath string) (Message, error) {
+	body, err := ioutil.ReadFile(filepath.Join(p.root, filePath)) //nolint[gosec]
+	if err != nil {
+		return Message{}, errors.Wrap(err, "failed to read file")
+	}
+
+	msg := Message{
+		ID:      id,
+		Body:    body,
+		Source:  rule.SourceMailbox,
+		Targets: rule.TargetMailboxes,
+	}
+	applyMaildirFlags(&msg, filepath.Base(filePath))
+	return msg, nil
+}
+
+// maildirMessageID returns path of the message without cur or new folder
+// and without the info part of the file name.
+func maildirMessageID(filePath string) string {
+	folder, fileName := filepath.Split(filePath)
+	if idx := strings.LastIndex(fileName, maildirInfoSeparator); idx >= 0 {
+		fileName = fileName[:idx]
+	}
+	return filepath.ToSlash(filepath.Join(filepath.Dir(filepath.Clean(folder)), fileName))
+}
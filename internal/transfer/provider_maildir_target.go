@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// maildirDeliveries makes unique names of files delivered in the same microsecond.
+var maildirDeliveries uint64 //nolint[gochecknoglobals]
+
+// DefaultMailboxes returns the default mailboxes for default rules if no other is found.
+func (p *MaildirProvider) DefaultMailboxes(sourceMailbox Mailbox) []Mailbox {
+	return []Mailbox{{
+		Name: sourceMailbox.Name,
+	}}
+}
+
+// CreateMailbox does nothing. Folders are created dynamically during the import.
+func (p *MaildirProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
+	return mailbox, nil
+}
+
+// TransferFrom imports messages from channel.
+func (p *MaildirProvider) TransferFrom(rules transferRules, progress *Progress, ch <-chan Message) {
+	log.Info("Started transfer from channel to Maildir")
+	defer log.Info("Finished transfer from channel to Maildir")
+
+	for rule := range rules.iterateActiveRules() {
+		for _, mailbox := range rule.TargetMailboxes {
+			if err := p.createFolder(mailbox.Name); err != nil {
+				progress.fatal(err)
+				return
+			}
+		}
+	}
+
+	for msg := range ch {
+		if progress.shouldStop() {
+			break
+		}
+
+		err := p.writeMessage(msg)
+		progress.messageImported(msg.ID, "", err)
+	}
+}
+
+// createFolder creates Maildir++ folder with cur, new and tmp.
+func (p *MaildirProvider) createFolder(name string) error {
+	folder := filepath.Join(p.root, maildirFolderPath(name))
+	for _, sub := range []string{"cur", "new", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(folder, sub), 0700); err != nil {
+			return err
+		}
+	}
+
+	// Marks subfolder for Maildir++ compatible servers.
+	if folder == filepath.Clean(p.root) {
+		return nil
+	}
+	marker := filepath.Join(folder, "maildirfolder")
+	if _, err := os.Stat(marker); os.IsNotExist(err) {
+		return ioutil.WriteFile(marker, nil, 0600)
+	}
+	return nil
+}
+
+// writeMessage delivers the message to all target mailboxes. As in any
+// Maildir delivery, the file is written to tmp first and then moved.
+func (p *MaildirProvider) writeMessage(msg Message) error {
+	var err error
+	for _, mailbox := range msg.Targets {
+		if localErr := p.createFolder(mailbox.Name); localErr != nil {
+			err = multierror.Append(err, localErr)
+			continue
+		}
+
+		folder := filepath.Join(p.root, maildirFolderPath(mailbox.Name))
+		fileName := newMaildirFileName()
+		tmpPath := filepath.Join(folder, "tmp", fileName)
+		if localErr := ioutil.WriteFile(tmpPath, msg.Body, 0600); localErr != nil {
+			err = multierror.Append(err, localErr)
+			continue
+		}
+
+		curPath := filepath.Join(folder, "cur", fileName+maildirFlags(msg))
+		if localErr := os.Rename(tmpPath, curPath); localErr != nil {
+			_ = os.Remove(tmpPath)
+			err = multierror.Append(err, localErr)
+		}
+	}
+	return err
+}
+
+// newMaildirFileName returns unique name in the usual form
+// <seconds>.M<microseconds>P<pid>Q<deliveries>.<host>.
+func newMaildirFileName() string {
+	now := time.Now()
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	host = strings.NewReplacer("/", "\\057", ":", "\\072").Replace(host)
+
+	return fmt.Sprintf("%d.M%dP%dQ%d.%s",
+		now.Unix(), now.Nanosecond()/1000, os.Getpid(), atomic.AddUint64(&maildirDeliveries, 1), host)
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	r "github.com/stretchr/testify/require"
+)
+
+// newTestMaildir creates Maildir with INBOX, Maildir++ folder Foo and
+// nested folder Bar/Baz as created by offlineimap. File names contain
+// colon which is not allowed in testdata on every OS.
+func newTestMaildir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "maildir")
+	r.NoError(t, err)
+
+	files := map[string]string{
+		"new/1600000001.M1P1.host":              "inbox-new",
+		"cur/1600000002.M1P1.host:2,S":          "inbox-seen",
+		"tmp/1600000003.M1P1.host":              "",
+		".Foo/cur/1600000004.M1P1.host:2,FRS":   "foo",
+		".Foo/maildirfolder":                    "",
+		"Bar/Baz/cur/1600000005.M1P1.host:2,DT": "baz",
+		".Empty/cur/.keep":                      "",
+	}
+	for path, subject := range files {
+		path = filepath.Join(dir, path)
+		r.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		if subject == "" {
+			continue
+		}
+		r.NoError(t, ioutil.WriteFile(path, getTestMsgBody(subject), 0600))
+	}
+	return dir
+}
+
+func TestIsMaildirPath(t *testing.T) {
+	dir := newTestMaildir(t)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	r.True(t, IsMaildirPath(dir))
+	r.True(t, IsMaildirPath(filepath.Join(dir, ".Foo")))
+	r.False(t, IsMaildirPath(filepath.Join(dir, "Bar")))
+	r.False(t, IsMaildirPath("testdata/eml"))
+}
+
+func TestMaildirFolderName(t *testing.T) {
+	r.Equal(t, "Foo", maildirFolderName(".Foo"))
+	r.Equal(t, "Foo/Bar", maildirFolderName(".Foo.Bar"))
+	r.Equal(t, "Foo/Bar", maildirFolderName(filepath.Join("Foo", "Bar")))
+
+	r.Equal(t, ".", maildirFolderPath("Inbox"))
+	r.Equal(t, ".Foo.Bar", maildirFolderPath("Foo/Bar"))
+}
+
+func TestMaildirProviderMailboxes(t *testing.T) {
+	dir := newTestMaildir(t)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	provider := NewMaildirProvider(dir)
+
+	mailboxes, err := provider.Mailboxes(true, false)
+	r.NoError(t, err)
+	r.Equal(t, []Mailbox{
+		{Name: "Bar/Baz", IsExclusive: true},
+		{Name: "Empty", IsExclusive: true},
+		{Name: "Foo", IsExclusive: true},
+		{Name: "INBOX", IsExclusive: true},
+	}, mailboxes)
+
+	mailboxes, err = provider.Mailboxes(false, false)
+	r.NoError(t, err)
+	r.Len(t, mailboxes, 3)
+}
+
+func TestMaildirProviderTransferTo(t *testing.T) {
+	dir := newTestMaildir(t)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	provider := NewMaildirProvider(dir)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupMaildirRules(rules)
+
+	count, _, err := provider.Estimate(rules)
+	r.NoError(t, err)
+	r.Equal(t, uint(4), count)
+
+	testTransferTo(t, rules, provider, []string{
+		"1600000001.M1P1.host",
+		"1600000002.M1P1.host",
+		".Foo/1600000004.M1P1.host",
+		"Bar/Baz/1600000005.M1P1.host",
+	})
+}
+
+func TestMaildirProviderTransferFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maildir")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	provider := NewMaildirProvider(dir)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupMaildirRules(rules)
+
+	testTransferFrom(t, rules, provider, []Message{
+		{ID: "1", Body: getTestMsgBody("one"), Targets: []Mailbox{{Name: "Inbox"}}, Unread: true},
+		{ID: "2", Body: getTestMsgBody("two"), Targets: []Mailbox{{Name: "Foo/Bar"}}, Starred: true, Answered: true},
+	})
+
+	checkMaildirFiles(t, filepath.Join(dir, "cur"), []string{":2,"})
+	checkMaildirFiles(t, filepath.Join(dir, ".Foo.Bar", "cur"), []string{":2,FRS"})
+	checkMaildirFiles(t, filepath.Join(dir, ".Foo.Bar", "tmp"), []string{})
+
+	_, err = os.Stat(filepath.Join(dir, ".Foo.Bar", "maildirfolder"))
+	r.NoError(t, err)
+}
+
+func TestMaildirProviderTransferFromTo(t *testing.T) {
+	sourceDir := newTestMaildir(t)
+	defer os.RemoveAll(sourceDir) //nolint[errcheck]
+
+	targetDir, err := ioutil.TempDir("", "maildir")
+	r.NoError(t, err)
+	defer os.RemoveAll(targetDir) //nolint[errcheck]
+
+	source := NewMaildirProvider(sourceDir)
+	target := NewMaildirProvider(targetDir)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupMaildirRules(rules)
+
+	testTransferFromTo(t, rules, source, target, 5*time.Second)
+
+	checkMaildirFiles(t, filepath.Join(targetDir, "cur"), []string{":2,", ":2,S"})
+	checkMaildirFiles(t, filepath.Join(targetDir, ".Foo", "cur"), []string{":2,FRS"})
+	checkMaildirFiles(t, filepath.Join(targetDir, ".Bar.Baz", "cur"), []string{":2,DT"})
+}
+
+func setupMaildirRules(rules transferRules) {
+	_ = rules.setRule(Mailbox{Name: "INBOX"}, []Mailbox{{Name: "Inbox"}}, 0, 0)
+	_ = rules.setRule(Mailbox{Name: "Foo"}, []Mailbox{{Name: "Foo"}}, 0, 0)
+	_ = rules.setRule(Mailbox{Name: "Bar/Baz"}, []Mailbox{{Name: "Bar/Baz"}}, 0, 0)
+}
+
+// checkMaildirFiles checks info parts of file names in the folder.
+func checkMaildirFiles(t *testing.T, folder string, expectedInfos []string) {
+	files, err := ioutil.ReadDir(folder)
+	r.NoError(t, err)
+
+	infos := []string{}
+	for _, file := range files {
+		infos = append(infos, file.Name()[strings.LastIndex(file.Name(), maildirInfoSeparator):])
+	}
+	r.ElementsMatch(t, expectedInfos, infos)
+}
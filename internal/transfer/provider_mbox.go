@@ -18,21 +18,58 @@
 package transfer
 
 import (
+	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+// mboxPartSuffix matches the suffix of the second and further parts of
+// split MBOX files, e.g. `Inbox.part002`.
+var mboxPartSuffix = regexp.MustCompile(`\.part\d+$`) //nolint[gochecknoglobals]
+
 // MBOXProvider implements import and export to/from MBOX structure.
 type MBOXProvider struct {
 	root string
+
+	// Export starts a new numbered file once the current one would exceed
+	// splitSize bytes or has splitCount messages. Zero means no limit.
+	splitSize  uint64
+	splitCount uint
+	parts      map[string]*mboxPart
 }
 
 func NewMBOXProvider(root string) *MBOXProvider {
 	return &MBOXProvider{
-		root: root,
+		root:  root,
+		parts: map[string]*mboxPart{},
 	}
 }
 
+// SetSplit sets limits of exported MBOX files. Once a file would exceed
+// maxSize bytes or has maxCount messages, next messages of the mailbox
+// are written to a new file with the part number, e.g. `Inbox.part002.mbox`.
+func (p *MBOXProvider) SetSplit(maxSize uint64, maxCount uint) {
+	p.splitSize = maxSize
+	p.splitCount = maxCount
+}
+
+// getMBOXFileName returns file name of the part of the mailbox.
+// The first part has no number so not split export looks the same.
+func getMBOXFileName(mailboxName string, part int) string {
+	if part <= 1 {
+		return mailboxName + ".mbox"
+	}
+	return fmt.Sprintf("%s.part%03d.mbox", mailboxName, part)
+}
+
+// getMBOXMailboxName returns name of the mailbox stored in the file;
+// all parts of a split mailbox belong to the same mailbox.
+func getMBOXMailboxName(filePath string) string {
+	name := strings.TrimSuffix(filepath.Base(filePath), ".mbox")
+	return mboxPartSuffix.ReplaceAllString(name, "")
+}
+
 // ID is used for generating transfer ID by combining source and target ID.
 // We want to keep the same rules for import from or export to local files
 // no matter exact path, therefore it returns constant. The same as EML.
@@ -50,9 +87,13 @@ func (p *MBOXProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox,
 	}
 
 	mailboxes := []Mailbox{}
+	found := map[string]bool{}
 	for _, filePath := range filePaths {
-		fileName := filepath.Base(filePath)
-		mailboxName := strings.TrimSuffix(fileName, ".mbox")
+		mailboxName := getMBOXMailboxName(filePath)
+		if found[mailboxName] {
+			continue
+		}
+		found[mailboxName] = true
 
 		mailboxes = append(mailboxes, Mailbox{
 			ID:          "",
@@ -61,6 +102,7 @@ func (p *MBOXProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox,
 			IsExclusive: false,
 		})
 	}
+	applyMailboxMetadata(p.root, mailboxes)
 
 	return mailboxes, nil
 }
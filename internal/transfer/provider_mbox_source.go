@@ -23,7 +23,6 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
-	"strings"
 
 	"github.com/emersion/go-mbox"
 	"github.com/pkg/errors"
@@ -47,12 +46,14 @@ func (p *MBOXProvider) TransferTo(rules transferRules, progress *Progress, ch ch
 	for folderName, filePaths := range filePathsPerFolder {
 		// No error guaranteed by getFilePathsPerFolder.
 		rule, _ := rules.getRuleBySourceMailboxName(folderName)
+		count := uint(0)
 		for _, filePath := range filePaths {
 			if progress.shouldStop() {
 				break
 			}
-			p.updateCount(rule, progress, filePath)
+			count += p.countMessages(progress, filePath)
 		}
+		progress.updateCount(rule.SourceMailbox.Name, count)
 	}
 	progress.countsFinal()
 
@@ -60,15 +61,64 @@ func (p *MBOXProvider) TransferTo(rules transferRules, progress *Progress, ch ch
 		// No error guaranteed by getFilePathsPerFolder.
 		rule, _ := rules.getRuleBySourceMailboxName(folderName)
 		log.WithField("rule", rule).Debug("Processing rule")
+		// Parts of split mailbox are counted together.
+		count := uint(0)
 		for _, filePath := range filePaths {
 			if progress.shouldStop() {
 				break
 			}
-			p.transferTo(rule, progress, ch, filePath)
+			count += p.transferTo(rule, progress, ch, filePath)
+			progress.updateCount(rule.SourceMailbox.Name, count)
 		}
 	}
 }
 
+// Estimate returns count of messages and size of MBOX files of active rules.
+func (p *MBOXProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	filePathsPerFolder, err := p.getFilePathsPerFolder(rules)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for folderName, filePaths := range filePathsPerFolder {
+		// No error guaranteed by getFilePathsPerFolder.
+		if rule, _ := rules.getRuleBySourceMailboxName(folderName); !rule.Active {
+			continue
+		}
+		for _, filePath := range filePaths {
+			fileCount, fileSize, err := p.estimateFile(filePath)
+			if err != nil {
+				return 0, 0, err
+			}
+			count += fileCount
+			size += fileSize
+		}
+	}
+	return count, size, nil
+}
+
+func (p *MBOXProvider) estimateFile(filePath string) (count uint, size uint64, err error) {
+	mboxFile, err := os.Open(filepath.Join(p.root, filePath)) //nolint[gosec]
+	if err != nil {
+		return 0, 0, err
+	}
+	defer mboxFile.Close() //nolint[errcheck]
+
+	info, err := mboxFile.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	mboxReader := mbox.NewReader(mboxFile)
+	for {
+		if _, err := mboxReader.NextMessage(); err != nil {
+			break
+		}
+		count++
+	}
+	return count, uint64(info.Size()), nil
+}
+
 func (p *MBOXProvider) getFilePathsPerFolder(rules transferRules) (map[string][]string, error) {
 	filePaths, err := getFilePathsWithSuffix(p.root, ".mbox")
 	if err != nil {
@@ -77,8 +127,7 @@ func (p *MBOXProvider) getFilePathsPerFolder(rules transferRules) (map[string][]
 
 	filePathsMap := map[string][]string{}
 	for _, filePath := range filePaths {
-		fileName := filepath.Base(filePath)
-		folder := strings.TrimSuffix(fileName, ".mbox")
+		folder := getMBOXMailboxName(filePath)
 		_, err := rules.getRuleBySourceMailboxName(folder)
 		if err != nil {
 			log.WithField("msg", filePath).Trace("Mailbox skipped due to folder name")
@@ -90,13 +139,13 @@ func (p *MBOXProvider) getFilePathsPerFolder(rules transferRules) (map[string][]
 	return filePathsMap, nil
 }
 
-func (p *MBOXProvider) updateCount(rule *Rule, progress *Progress, filePath string) {
+func (p *MBOXProvider) countMessages(progress *Progress, filePath string) uint {
 	mboxReader := p.openMbox(progress, filePath)
 	if mboxReader == nil {
-		return
+		return 0
 	}
 
-	count := 0
+	count := uint(0)
 	for {
 		_, err := mboxReader.NextMessage()
 		if err != nil {
@@ -104,17 +153,18 @@ func (p *MBOXProvider) updateCount(rule *Rule, progress *Progress, filePath stri
 		}
 		count++
 	}
-	progress.updateCount(rule.SourceMailbox.Name, uint(count))
+	return count
 }
 
-func (p *MBOXProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Message, filePath string) {
+// transferTo exports messages of one file and returns their count.
+func (p *MBOXProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Message, filePath string) uint {
 	mboxReader := p.openMbox(progress, filePath)
 	if mboxReader == nil {
-		return
+		return 0
 	}
 
 	index := 0
-	count := 0
+	count := uint(0)
 	for {
 		if progress.shouldStop() {
 			break
@@ -131,6 +181,11 @@ func (p *MBOXProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Mess
 			break
 		}
 
+		if progress.skipTransferred(id, rule) {
+			count++
+			continue
+		}
+
 		msg, err := p.exportMessage(rule, id, msgReader)
 
 		// Read and check time in body only if the rule specifies it
@@ -157,7 +212,7 @@ func (p *MBOXProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Mess
 			ch <- msg
 		}
 	}
-	progress.updateCount(rule.SourceMailbox.Name, uint(count))
+	return count
 }
 
 func (p *MBOXProvider) exportMessage(rule *Rule, id string, msgReader io.Reader) (Message, error) {
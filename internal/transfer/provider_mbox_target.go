@@ -44,6 +44,10 @@ func (p *MBOXProvider) TransferFrom(rules transferRules, progress *Progress, ch
 	log.Info("Started transfer from channel to MBOX")
 	defer log.Info("Finished transfer from channel to MBOX")
 
+	if err := writeMailboxMetadata(p.root, rules); err != nil {
+		log.WithError(err).Warn("Cannot write mailbox metadata")
+	}
+
 	for msg := range ch {
 		if progress.shouldStop() {
 			break
@@ -57,41 +61,102 @@ func (p *MBOXProvider) TransferFrom(rules transferRules, progress *Progress, ch
 func (p *MBOXProvider) writeMessage(msg Message) error {
 	var multiErr error
 	for _, mailbox := range msg.Targets {
-		mboxName := filepath.Base(mailbox.Name)
-		if !strings.HasSuffix(mboxName, ".mbox") {
-			mboxName += ".mbox"
-		}
+		mailboxName := strings.TrimSuffix(filepath.Base(mailbox.Name), ".mbox")
+		part := p.getPart(mailboxName, uint64(len(msg.Body)))
 
-		mboxPath := filepath.Join(p.root, mboxName)
-		mboxFile, err := os.OpenFile(mboxPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-		if err != nil {
+		mboxPath := filepath.Join(p.root, getMBOXFileName(mailboxName, part.index))
+		if err := writeMBOXMessage(mboxPath, msg.Body); err != nil {
 			multiErr = multierror.Append(multiErr, err)
 			continue
 		}
 
-		msgFrom := ""
-		msgTime := time.Now()
-		if header, err := getMessageHeader(msg.Body); err == nil {
-			if date, err := header.Date(); err == nil {
-				msgTime = date
-			}
-			if addresses, err := header.AddressList("from"); err == nil && len(addresses) > 0 {
-				msgFrom = addresses[0].Address
-			}
+		part.count++
+		if info, err := os.Stat(mboxPath); err == nil {
+			part.size = uint64(info.Size())
 		}
+	}
+	return multiErr
+}
 
-		mboxWriter := mbox.NewWriter(mboxFile)
-		messageWriter, err := mboxWriter.CreateMessage(msgFrom, msgTime)
-		if err != nil {
-			multiErr = multierror.Append(multiErr, err)
-			continue
+func writeMBOXMessage(mboxPath string, body []byte) error {
+	mboxFile, err := os.OpenFile(mboxPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600) //nolint[gosec]
+	if err != nil {
+		return err
+	}
+
+	msgFrom := ""
+	msgTime := time.Now()
+	if header, err := getMessageHeader(body); err == nil {
+		if date, err := header.Date(); err == nil {
+			msgTime = date
+		}
+		if addresses, err := header.AddressList("from"); err == nil && len(addresses) > 0 {
+			msgFrom = addresses[0].Address
 		}
+	}
 
-		_, err = messageWriter.Write(msg.Body)
-		if err != nil {
-			multiErr = multierror.Append(multiErr, err)
-			continue
+	mboxWriter := mbox.NewWriter(mboxFile)
+	messageWriter, err := mboxWriter.CreateMessage(msgFrom, msgTime)
+	if err == nil {
+		_, err = messageWriter.Write(body)
+	}
+	// Closing the writer ends the message by an empty line separating it
+	// from the next one appended later.
+	if err == nil {
+		err = mboxWriter.Close()
+	}
+	if closeErr := mboxFile.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// mboxPart is the file currently written for a mailbox.
+type mboxPart struct {
+	index int
+	size  uint64
+	count uint
+}
+
+// getPart returns the part to which the message of msgSize should be
+// written, starting a new one when the current one is full. An empty
+// part takes any message, even bigger than the limit.
+func (p *MBOXProvider) getPart(mailboxName string, msgSize uint64) *mboxPart {
+	part, ok := p.parts[mailboxName]
+	if !ok {
+		part = p.findLastPart(mailboxName)
+		p.parts[mailboxName] = part
+	}
+
+	if part.count > 0 || part.size > 0 {
+		isFull := (p.splitSize > 0 && part.size+msgSize > p.splitSize) ||
+			(p.splitCount > 0 && part.count >= p.splitCount)
+		if isFull {
+			*part = mboxPart{index: part.index + 1}
 		}
 	}
-	return multiErr
+	return part
+}
+
+// findLastPart returns the last existing part of the mailbox, so another
+// export to the same folder continues where the previous one ended.
+func (p *MBOXProvider) findLastPart(mailboxName string) *mboxPart {
+	part := &mboxPart{index: 1}
+	for {
+		if _, err := os.Stat(filepath.Join(p.root, getMBOXFileName(mailboxName, part.index+1))); err != nil {
+			break
+		}
+		part.index++
+	}
+
+	if p.splitSize == 0 && p.splitCount == 0 {
+		return part
+	}
+
+	fileName := getMBOXFileName(mailboxName, part.index)
+	if count, size, err := p.estimateFile(fileName); err == nil {
+		part.count = count
+		part.size = size
+	}
+	return part
 }
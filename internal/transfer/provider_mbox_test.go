@@ -113,6 +113,74 @@ func TestMBOXProviderTransferFromTo(t *testing.T) {
 	})
 }
 
+func TestMBOXProviderTransferFromSplit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mbox")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupMBOXRules(rules)
+
+	messages := []Message{}
+	for i := 1; i <= 5; i++ {
+		messages = append(messages, Message{
+			ID:      fmt.Sprintf("Foo.mbox:%d", i),
+			Body:    getTestMsgBody(fmt.Sprintf("msg%d", i)),
+			Targets: []Mailbox{{Name: "Foo"}},
+		})
+	}
+
+	provider := newTestMBOXProvider(dir)
+	provider.SetSplit(0, 2)
+	testTransferFrom(t, rules, provider, messages[:3])
+
+	// Next export continues in the last part.
+	provider = newTestMBOXProvider(dir)
+	provider.SetSplit(0, 2)
+	testTransferFrom(t, rules, provider, messages[3:])
+
+	checkMBOXFileStructure(t, dir, []string{
+		"Foo.mbox",
+		"Foo.part002.mbox",
+		"Foo.part003.mbox",
+	})
+
+	// Parts are imported back as one mailbox.
+	mailboxes, err := provider.Mailboxes(true, false)
+	r.NoError(t, err)
+	r.Equal(t, []Mailbox{{Name: "Foo"}}, mailboxes)
+
+	count, _, err := provider.Estimate(rules)
+	r.NoError(t, err)
+	r.Equal(t, uint(5), count)
+}
+
+func TestMBOXProviderSplitBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mbox")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupMBOXRules(rules)
+
+	body := getTestMsgBody("msg")
+	provider := newTestMBOXProvider(dir)
+	// Two messages fit, third does not.
+	provider.SetSplit(uint64(2*len(body)+200), 0)
+	testTransferFrom(t, rules, provider, []Message{
+		{ID: "Foo.mbox:1", Body: body, Targets: []Mailbox{{Name: "Foo"}}},
+		{ID: "Foo.mbox:2", Body: body, Targets: []Mailbox{{Name: "Foo"}}},
+		{ID: "Foo.mbox:3", Body: body, Targets: []Mailbox{{Name: "Foo"}}},
+	})
+
+	checkMBOXFileStructure(t, dir, []string{
+		"Foo.mbox",
+		"Foo.part002.mbox",
+	})
+}
+
 func setupMBOXRules(rules transferRules) {
 	_ = rules.setRule(Mailbox{Name: "Inbox"}, []Mailbox{{Name: "Inbox"}}, 0, 0)
 	_ = rules.setRule(Mailbox{Name: "Foo"}, []Mailbox{{Name: "Foo"}}, 0, 0)
@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotEnoughTargets is returned when multi-target is created with less
+// than two targets.
+var ErrNotEnoughTargets = errors.New("multi-target export needs at least two targets")
+
+// MultiTargetProvider implements export to several targets in one pass.
+// Every message is read from the source only once and written to all
+// targets. Mailboxes and rules are taken from the first target, so targets
+// should use the same names of mailboxes as the source, e.g. EML, MBOX,
+// archive or remote storage. Message is transferred once all targets
+// wrote it without error.
+type MultiTargetProvider struct {
+	targets []TargetProvider
+}
+
+// NewMultiTargetProvider creates MultiTargetProvider.
+func NewMultiTargetProvider(targets ...TargetProvider) (*MultiTargetProvider, error) {
+	if len(targets) < 2 {
+		return nil, ErrNotEnoughTargets
+	}
+	return &MultiTargetProvider{
+		targets: targets,
+	}, nil
+}
+
+// ID is used for generating transfer ID by combining source and target ID.
+func (p *MultiTargetProvider) ID() string {
+	ids := []string{}
+	for _, target := range p.targets {
+		ids = append(ids, target.ID())
+	}
+	return "multi-" + strings.Join(ids, "-")
+}
+
+// Mailboxes returns mailboxes of the first target.
+func (p *MultiTargetProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox, error) {
+	return p.targets[0].Mailboxes(includeEmpty, includeAllMail)
+}
+
+// DefaultMailboxes returns the default mailboxes of the first target.
+func (p *MultiTargetProvider) DefaultMailboxes(sourceMailbox Mailbox) []Mailbox {
+	return p.targets[0].DefaultMailboxes(sourceMailbox)
+}
+
+// CreateMailbox creates the mailbox in all targets and returns the one
+// of the first target.
+func (p *MultiTargetProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
+	created, err := p.targets[0].CreateMailbox(mailbox)
+	if err != nil {
+		return Mailbox{}, err
+	}
+	for _, target := range p.targets[1:] {
+		if _, err := target.CreateMailbox(mailbox); err != nil {
+			return Mailbox{}, err
+		}
+	}
+	return created, nil
+}
+
+// SetDateLayout sets date layout of all targets supporting it.
+func (p *MultiTargetProvider) SetDateLayout(enabled bool) {
+	for _, target := range p.targets {
+		if target, ok := target.(dateLayouter); ok {
+			target.SetDateLayout(enabled)
+		}
+	}
+}
+
+// SetNameNormalization sets name normalization of all targets supporting it.
+func (p *MultiTargetProvider) SetNameNormalization(normalization NameNormalization) {
+	for _, target := range p.targets {
+		if target, ok := target.(nameNormalizer); ok {
+			target.SetNameNormalization(normalization)
+		}
+	}
+}
+
+// SetChecksums sets checksums of all targets supporting it.
+func (p *MultiTargetProvider) SetChecksums(enabled bool) {
+	for _, target := range p.targets {
+		if target, ok := target.(checksummer); ok {
+			target.SetChecksums(enabled)
+		}
+	}
+}
+
+// TransferFrom passes every message from channel to all targets.
+func (p *MultiTargetProvider) TransferFrom(rules transferRules, progress *Progress, ch <-chan Message) {
+	log.Info("Started transfer from channel to multiple targets")
+	defer log.Info("Finished transfer from channel to multiple targets")
+
+	progress.setImportsPerMessage(len(p.targets))
+
+	wg := &sync.WaitGroup{}
+	targetChs := make([]chan Message, len(p.targets))
+	for i, target := range p.targets {
+		targetChs[i] = make(chan Message)
+		wg.Add(1)
+		go func(target TargetProvider, targetCh chan Message) {
+			defer wg.Done()
+			target.TransferFrom(rules, progress, targetCh)
+			// Target stops reading when transfer is stopped, the rest
+			// of messages is dropped so other targets are not blocked.
+			for range targetCh {
+			}
+		}(target, targetChs[i])
+	}
+
+	for msg := range ch {
+		for _, targetCh := range targetChs {
+			targetCh <- msg
+		}
+	}
+
+	for _, targetCh := range targetChs {
+		close(targetCh)
+	}
+	wg.Wait()
+}
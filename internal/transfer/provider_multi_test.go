@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	a "github.com/stretchr/testify/assert"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestMultiTargetProviderNotEnoughTargets(t *testing.T) {
+	_, err := NewMultiTargetProvider(newTestEMLProvider(""))
+	r.Equal(t, ErrNotEnoughTargets, err)
+}
+
+func TestMultiTargetProviderTransferFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multi")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	archivePath := filepath.Join(dir, "export.zip")
+	archive, err := NewArchiveProvider(archivePath)
+	r.NoError(t, err)
+	emlDir := filepath.Join(dir, "eml")
+	provider, err := NewMultiTargetProvider(newTestEMLProvider(emlDir), archive)
+	r.NoError(t, err)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupEMLRules(rules)
+
+	testTransferFrom(t, rules, provider, []Message{
+		{ID: "Foo/msg.eml", Body: getTestMsgBody("msg"), Targets: []Mailbox{{Name: "Foo"}}},
+		{ID: "msg2", Body: getTestMsgBody("msg"), Targets: []Mailbox{{Name: "Inbox"}}},
+	})
+
+	checkEMLFileStructure(t, emlDir, []string{"Foo/msg.eml", "Inbox/msg2.eml"})
+	r.Equal(t, []string{"Foo/msg.eml", "Inbox/msg2.eml"}, readArchiveFileNames(t, archivePath))
+}
+
+func TestMultiTargetProviderOneTargetFails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "multi")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	// EML provider cannot create folders under a regular file.
+	brokenPath := filepath.Join(dir, "file")
+	r.NoError(t, ioutil.WriteFile(brokenPath, []byte{}, 0600))
+	provider, err := NewMultiTargetProvider(newTestEMLProvider(filepath.Join(dir, "eml")), newTestEMLProvider(brokenPath))
+	r.NoError(t, err)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupEMLRules(rules)
+
+	progress := newProgress(log, nil)
+	drainProgressUpdateChannel(&progress)
+
+	ch := make(chan Message)
+	go func() {
+		progress.addMessage("msg", nil)
+		progress.messageExported("msg", []byte(""), nil)
+		ch <- Message{ID: "msg", Body: getTestMsgBody("msg"), Targets: []Mailbox{{Name: "Foo"}}}
+		close(ch)
+	}()
+	go func() {
+		provider.TransferFrom(rules, &progress, ch)
+		progress.finish()
+	}()
+
+	a.Eventually(t, func() bool {
+		return progress.updateCh == nil
+	}, time.Second, 10*time.Millisecond, "Waiting for imported messages timed out")
+
+	failed, imported, _, _, _ := progress.GetCounts()
+	r.Equal(t, uint(1), failed)
+	r.Equal(t, uint(0), imported)
+}
@@ -0,0 +1,299 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"github.com/ProtonMail/proton-bridge/pkg/transferplugin"
+	"github.com/pkg/errors"
+)
+
+// errPluginStopped is returned to the plugin source to stop listing messages.
+var errPluginStopped = errors.New("transfer stopped")
+
+// PluginSourceProvider implements import from source registered by plugin.
+type PluginSourceProvider struct {
+	name   string
+	source transferplugin.Source
+}
+
+// PluginTargetProvider implements export to target registered by plugin.
+type PluginTargetProvider struct {
+	name   string
+	target transferplugin.Target
+}
+
+// IsPluginSource returns whether any plugin can import from the location.
+func IsPluginSource(location string) bool {
+	_, err := transferplugin.FindSource(location)
+	return err == nil
+}
+
+// NewPluginSourceProvider creates source by the first plugin matching the location.
+func NewPluginSourceProvider(location string) (*PluginSourceProvider, error) {
+	plugin, err := transferplugin.FindSource(location)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := plugin.NewSource(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "plugin %s failed to open source", plugin.Name)
+	}
+
+	return &PluginSourceProvider{
+		name:   plugin.Name,
+		source: source,
+	}, nil
+}
+
+// NewPluginTargetProvider creates target by the first plugin matching the location.
+func NewPluginTargetProvider(location string) (*PluginTargetProvider, error) {
+	plugin, err := transferplugin.FindTarget(location)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := plugin.NewTarget(location)
+	if err != nil {
+		return nil, errors.Wrapf(err, "plugin %s failed to open target", plugin.Name)
+	}
+
+	return &PluginTargetProvider{
+		name:   plugin.Name,
+		target: target,
+	}, nil
+}
+
+// ID is used for generating transfer ID by combining source and target ID.
+func (p *PluginSourceProvider) ID() string {
+	return "plugin-" + p.name + "-" + p.source.ID()
+}
+
+// Mailboxes returns all mailboxes of the source.
+func (p *PluginSourceProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox, error) {
+	mailboxes, err := p.source.Mailboxes()
+	if err != nil {
+		return nil, err
+	}
+	return fromPluginMailboxes(mailboxes), nil
+}
+
+// Estimate returns count and size of messages of active rules. Messages
+// are read if the plugin cannot count them.
+func (p *PluginSourceProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	counter, canCount := p.source.(transferplugin.Counter)
+
+	for _, rule := range rules.getSortedRules() {
+		if !rule.Active {
+			continue
+		}
+
+		mailbox := toPluginMailbox(rule.SourceMailbox)
+		if canCount {
+			mailboxCount, mailboxSize, err := counter.Count(mailbox)
+			if err != nil {
+				return 0, 0, err
+			}
+			count += mailboxCount
+			size += mailboxSize
+			continue
+		}
+
+		if err := p.source.Messages(mailbox, func(msg transferplugin.Message) error {
+			count++
+			size += uint64(len(msg.Body))
+			return nil
+		}); err != nil {
+			return 0, 0, err
+		}
+	}
+	return count, size, nil
+}
+
+// TransferTo exports messages based on rules to channel.
+func (p *PluginSourceProvider) TransferTo(rules transferRules, progress *Progress, ch chan<- Message) {
+	log.WithField("plugin", p.name).Info("Started transfer from plugin to channel")
+	defer log.WithField("plugin", p.name).Info("Finished transfer from plugin to channel")
+
+	activeRules := []*Rule{}
+	for _, rule := range rules.getSortedRules() {
+		if rule.Active {
+			activeRules = append(activeRules, rule)
+		}
+	}
+
+	// Without counter the counts are known only after the transfer.
+	counter, canCount := p.source.(transferplugin.Counter)
+	if canCount {
+		for _, rule := range activeRules {
+			count, _, err := counter.Count(toPluginMailbox(rule.SourceMailbox))
+			if err != nil {
+				progress.fatal(err)
+				return
+			}
+			progress.updateCount(rule.SourceMailbox.Name, count)
+		}
+		progress.countsFinal()
+	}
+
+	for _, rule := range activeRules {
+		if progress.shouldStop() {
+			break
+		}
+
+		log.WithField("rule", rule).Debug("Processing rule")
+		count, err := p.transferTo(rule, progress, ch)
+		if err != nil && err != errPluginStopped {
+			progress.fatal(err)
+			return
+		}
+		progress.updateCount(rule.SourceMailbox.Name, count)
+	}
+
+	if !canCount {
+		progress.countsFinal()
+	}
+}
+
+func (p *PluginSourceProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Message) (count uint, err error) {
+	err = p.source.Messages(toPluginMailbox(rule.SourceMailbox), func(msg transferplugin.Message) error {
+		if progress.shouldStop() {
+			return errPluginStopped
+		}
+
+		if progress.skipTransferred(msg.ID, rule) {
+			count++
+			return nil
+		}
+
+		var err error
+		if rule.HasTimeLimit() {
+			msgTime, msgTimeErr := getMessageTime(msg.Body)
+			if msgTimeErr != nil {
+				err = msgTimeErr
+			} else if !rule.isTimeInRange(msgTime) {
+				log.WithField("msg", msg.ID).Debug("Message skipped due to time")
+				return nil
+			}
+		}
+
+		// Counting only messages filtered by time to update count to correct total.
+		count++
+
+		progress.addMessage(msg.ID, rule)
+		progress.messageExported(msg.ID, msg.Body, err)
+		if err == nil {
+			ch <- Message{
+				ID:      msg.ID,
+				Unread:  msg.Unread,
+				Body:    msg.Body,
+				Source:  rule.SourceMailbox,
+				Targets: rule.TargetMailboxes,
+				Starred: msg.Starred,
+				Draft:   msg.Draft,
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// ID is used for generating transfer ID by combining source and target ID.
+func (p *PluginTargetProvider) ID() string {
+	return "plugin-" + p.name + "-" + p.target.ID()
+}
+
+// Mailboxes returns all mailboxes of the target.
+func (p *PluginTargetProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox, error) {
+	mailboxes, err := p.target.Mailboxes()
+	if err != nil {
+		return nil, err
+	}
+	return fromPluginMailboxes(mailboxes), nil
+}
+
+// DefaultMailboxes returns mailbox with the same name as the source one,
+// plugin target has to create it during the import.
+func (p *PluginTargetProvider) DefaultMailboxes(sourceMailbox Mailbox) []Mailbox {
+	return []Mailbox{{
+		Name:        sourceMailbox.Name,
+		IsExclusive: sourceMailbox.IsExclusive,
+	}}
+}
+
+// CreateMailbox creates mailbox by the plugin.
+func (p *PluginTargetProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
+	created, err := p.target.CreateMailbox(toPluginMailbox(mailbox))
+	if err != nil {
+		return Mailbox{}, err
+	}
+	return fromPluginMailbox(created), nil
+}
+
+// TransferFrom imports messages from channel.
+func (p *PluginTargetProvider) TransferFrom(rules transferRules, progress *Progress, ch <-chan Message) {
+	log.WithField("plugin", p.name).Info("Started transfer from channel to plugin")
+	defer log.WithField("plugin", p.name).Info("Finished transfer from channel to plugin")
+
+	for msg := range ch {
+		if progress.shouldStop() {
+			break
+		}
+
+		targets := make([]transferplugin.Mailbox, 0, len(msg.Targets))
+		for _, mailbox := range msg.Targets {
+			targets = append(targets, toPluginMailbox(mailbox))
+		}
+
+		importID, err := p.target.Import(transferplugin.Message{
+			ID:        msg.ID,
+			Body:      msg.Body,
+			Unread:    msg.Unread,
+			Starred:   msg.Starred,
+			Draft:     msg.Draft,
+			Mailboxes: targets,
+		})
+		progress.messageImported(msg.ID, importID, err)
+	}
+}
+
+func toPluginMailbox(mailbox Mailbox) transferplugin.Mailbox {
+	return transferplugin.Mailbox{
+		ID:          mailbox.ID,
+		Name:        mailbox.Name,
+		Color:       mailbox.Color,
+		IsExclusive: mailbox.IsExclusive,
+	}
+}
+
+func fromPluginMailbox(mailbox transferplugin.Mailbox) Mailbox {
+	return Mailbox{
+		ID:          mailbox.ID,
+		Name:        mailbox.Name,
+		Color:       mailbox.Color,
+		IsExclusive: mailbox.IsExclusive,
+	}
+}
+
+func fromPluginMailboxes(pluginMailboxes []transferplugin.Mailbox) []Mailbox {
+	mailboxes := make([]Mailbox, 0, len(pluginMailboxes))
+	for _, mailbox := range pluginMailboxes {
+		mailboxes = append(mailboxes, fromPluginMailbox(mailbox))
+	}
+	return mailboxes
+}
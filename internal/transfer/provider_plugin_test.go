@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/transferplugin"
+	r "github.com/stretchr/testify/require"
+)
+
+// testPluginStore is both source and target keeping messages in memory.
+type testPluginStore struct {
+	mailboxes []transferplugin.Mailbox
+	messages  map[string][]transferplugin.Message
+}
+
+func (s *testPluginStore) ID() string { return "memory" }
+
+func (s *testPluginStore) Mailboxes() ([]transferplugin.Mailbox, error) { return s.mailboxes, nil }
+
+func (s *testPluginStore) Messages(mailbox transferplugin.Mailbox, fn func(transferplugin.Message) error) error {
+	for _, msg := range s.messages[mailbox.Name] {
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *testPluginStore) CreateMailbox(mailbox transferplugin.Mailbox) (transferplugin.Mailbox, error) {
+	mailbox.ID = strings.ToLower(mailbox.Name)
+	s.mailboxes = append(s.mailboxes, mailbox)
+	return mailbox, nil
+}
+
+func (s *testPluginStore) Import(msg transferplugin.Message) (string, error) {
+	for _, mailbox := range msg.Mailboxes {
+		s.messages[mailbox.Name] = append(s.messages[mailbox.Name], msg)
+	}
+	return "imported-" + msg.ID, nil
+}
+
+func registerTestPlugin(t *testing.T, store *testPluginStore) func() {
+	r.NoError(t, transferplugin.Register(transferplugin.Plugin{
+		Name:      "memory",
+		Match:     func(location string) bool { return strings.HasPrefix(location, "memory://") },
+		NewSource: func(string) (transferplugin.Source, error) { return store, nil },
+		NewTarget: func(string) (transferplugin.Target, error) { return store, nil },
+	}))
+	return func() { transferplugin.Unregister("memory") }
+}
+
+func TestPluginProviderNotFound(t *testing.T) {
+	r.False(t, IsPluginSource("memory://test"))
+
+	_, err := NewPluginSourceProvider("memory://test")
+	r.Equal(t, transferplugin.ErrNoPlugin, err)
+
+	_, err = NewPluginTargetProvider("memory://test")
+	r.Equal(t, transferplugin.ErrNoPlugin, err)
+}
+
+func TestPluginSourceProvider(t *testing.T) {
+	store := &testPluginStore{
+		mailboxes: []transferplugin.Mailbox{{Name: "Inbox", IsExclusive: true}, {Name: "Foo", IsExclusive: true}},
+		messages: map[string][]transferplugin.Message{
+			"Inbox": {{ID: "1", Body: getTestMsgBody("one")}, {ID: "2", Body: getTestMsgBody("two"), Unread: true}},
+			"Foo":   {{ID: "3", Body: getTestMsgBody("three")}},
+		},
+	}
+	defer registerTestPlugin(t, store)()
+
+	r.True(t, IsPluginSource("memory://test"))
+	provider, err := NewPluginSourceProvider("memory://test")
+	r.NoError(t, err)
+	r.Equal(t, "plugin-memory-memory", provider.ID())
+
+	mailboxes, err := provider.Mailboxes(false, false)
+	r.NoError(t, err)
+	r.Equal(t, []Mailbox{{Name: "Inbox", IsExclusive: true}, {Name: "Foo", IsExclusive: true}}, mailboxes)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupMBOXRules(rules)
+
+	count, size, err := provider.Estimate(rules)
+	r.NoError(t, err)
+	r.Equal(t, uint(3), count)
+	r.Equal(t, uint64(3*len(getTestMsgBody("one"))+2), size)
+
+	testTransferTo(t, rules, provider, []string{"1", "2", "3"})
+}
+
+func TestPluginTargetProvider(t *testing.T) {
+	store := &testPluginStore{messages: map[string][]transferplugin.Message{}}
+	defer registerTestPlugin(t, store)()
+
+	provider, err := NewPluginTargetProvider("memory://test")
+	r.NoError(t, err)
+
+	r.Equal(t, []Mailbox{{Name: "Foo"}}, provider.DefaultMailboxes(Mailbox{Name: "Foo"}))
+
+	mailbox, err := provider.CreateMailbox(Mailbox{Name: "Foo", IsExclusive: true})
+	r.NoError(t, err)
+	r.Equal(t, Mailbox{ID: "foo", Name: "Foo", IsExclusive: true}, mailbox)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupMBOXRules(rules)
+
+	messages := []Message{}
+	for i := 0; i < 3; i++ {
+		messages = append(messages, Message{
+			ID:      fmt.Sprintf("%d", i),
+			Body:    getTestMsgBody("msg"),
+			Starred: i == 0,
+			Targets: []Mailbox{{Name: "Foo"}},
+		})
+	}
+	testTransferFrom(t, rules, provider, messages)
+
+	r.Len(t, store.messages["Foo"], 3)
+	r.True(t, store.messages["Foo"][0].Starred)
+	r.Equal(t, []transferplugin.Mailbox{{Name: "Foo"}}, store.messages["Foo"][0].Mailboxes)
+}
@@ -19,6 +19,7 @@ package transfer
 
 import (
 	"sort"
+	"sync"
 
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
@@ -33,8 +34,20 @@ type PMAPIProvider struct {
 	addressID     string
 	keyRing       *crypto.KeyRing
 
-	importMsgReqMap  map[string]*pmapi.ImportMsgReq // Key is msg transfer ID.
-	importMsgReqSize int
+	nameNormalization NameNormalization
+
+	throttling     ThrottlingProfile
+	throttlingLock sync.RWMutex
+
+	// checksums enables verification of imported messages against
+	// their canonical hashes by downloading them back.
+	checksums bool
+
+	// dedup, if set, skips import of messages already in the account.
+	dedup *dedupIndex
+
+	// concurrency is the number of workers uploading messages.
+	concurrency int
 }
 
 // NewPMAPIProvider returns new PMAPIProvider.
@@ -45,8 +58,9 @@ func NewPMAPIProvider(config *pmapi.ClientConfig, clientManager ClientManager, u
 		userID:        userID,
 		addressID:     addressID,
 
-		importMsgReqMap:  map[string]*pmapi.ImportMsgReq{},
-		importMsgReqSize: 0,
+		nameNormalization: NameNormalizationStrip,
+		throttling:        ThrottlingNormal,
+		concurrency:       DefaultConcurrency,
 	}
 
 	if addressID != "" {
@@ -60,6 +74,57 @@ func NewPMAPIProvider(config *pmapi.ClientConfig, clientManager ClientManager, u
 	return provider, nil
 }
 
+// SetNameNormalization sets how names of created mailboxes are normalized.
+func (p *PMAPIProvider) SetNameNormalization(normalization NameNormalization) {
+	p.nameNormalization = normalization
+}
+
+// SetChecksums sets whether imported messages with known canonical hash
+// are downloaded back and verified.
+func (p *PMAPIProvider) SetChecksums(enabled bool) {
+	p.checksums = enabled
+}
+
+// SetConcurrency sets the number of workers uploading messages in parallel.
+// Messages from the same source mailbox are always uploaded by the same
+// worker, so their order is kept. Therefore more workers help only when
+// importing more mailboxes; one mailbox is not uploaded any faster.
+func (p *PMAPIProvider) SetConcurrency(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	p.concurrency = workers
+}
+
+// SetThrottlingProfile sets how hard the provider pushes the API.
+// The profile is downshifted automatically once rate limiting is hit.
+func (p *PMAPIProvider) SetThrottlingProfile(profile ThrottlingProfile) {
+	p.throttlingLock.Lock()
+	defer p.throttlingLock.Unlock()
+
+	p.throttling = profile
+}
+
+func (p *PMAPIProvider) getThrottlingProfile() ThrottlingProfile {
+	p.throttlingLock.RLock()
+	defer p.throttlingLock.RUnlock()
+
+	return p.throttling
+}
+
+// downshiftThrottling switches to the next gentler profile.
+func (p *PMAPIProvider) downshiftThrottling() {
+	p.throttlingLock.Lock()
+	defer p.throttlingLock.Unlock()
+
+	gentler := p.throttling.gentler()
+	if gentler.Name == p.throttling.Name {
+		return
+	}
+	log.WithField("from", p.throttling.Name).WithField("to", gentler.Name).Warning("Rate limited, downshifting throttling profile")
+	p.throttling = gentler
+}
+
 func (p *PMAPIProvider) client() pmapi.Client {
 	return p.clientManager.GetClient(p.userID)
 }
@@ -109,6 +174,7 @@ func (p *PMAPIProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox,
 			ID:          label.ID,
 			Name:        label.Name,
 			Color:       label.Color,
+			Order:       label.Order,
 			IsExclusive: label.Exclusive == 1,
 		})
 	}
@@ -27,7 +27,13 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-const pmapiListPageSize = 150
+const (
+	pmapiListPageSize = 150
+
+	// pmapiAttachmentWorkers is the number of attachments of one message
+	// downloaded in parallel during export.
+	pmapiAttachmentWorkers = 4
+)
 
 // TransferTo exports messages based on rules to channel.
 func (p *PMAPIProvider) TransferTo(rules transferRules, progress *Progress, ch chan<- Message) {
@@ -44,11 +50,57 @@ func (p *PMAPIProvider) TransferTo(rules transferRules, progress *Progress, ch c
 		p.loadCounts(rules, progress)
 	}()
 
+	// With filter, only matching messages are counted by transferTo.
+	matchedCounts := map[*Rule]uint{}
 	for rule := range rules.iterateActiveRules() {
-		p.transferTo(rule, progress, ch, rules.skipEncryptedMessages)
+		matched := p.transferTo(rule, progress, ch, rules.skipEncryptedMessages, rules.globalFilter)
+		if rules.globalFilter != nil {
+			matchedCounts[rule] = matched
+			progress.updateCount(rule.SourceMailbox.Name, matched)
+		}
 	}
 
 	wg.Wait()
+
+	// Counts could be loaded after the rule was already transferred.
+	for rule, matched := range matchedCounts {
+		progress.updateCount(rule.SourceMailbox.Name, matched)
+	}
+}
+
+// Estimate loads counts of messages of active rules. Size is extrapolated
+// from the first page of messages to not list the whole mailbox.
+func (p *PMAPIProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	for _, rule := range rules.getSortedRules() {
+		if !rule.Active {
+			continue
+		}
+
+		messages, total, err := p.listMessages(&pmapi.MessagesFilter{
+			AddressID:   p.addressID,
+			LabelID:     rule.SourceMailbox.ID,
+			Begin:       rule.FromTime,
+			End:         rule.ToTime,
+			PageSize:    p.getThrottlingProfile().ListPageSize,
+			Page:        0,
+			Attachments: rules.globalFilter.attachmentsFilter(),
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		if len(messages) == 0 {
+			continue
+		}
+
+		pageSize := uint64(0)
+		for _, message := range messages {
+			pageSize += uint64(message.Size)
+		}
+
+		count += uint(total)
+		size += pageSize * uint64(total) / uint64(len(messages))
+	}
+	return count, size, nil
 }
 
 func (p *PMAPIProvider) loadCounts(rules transferRules, progress *Progress) {
@@ -60,11 +112,12 @@ func (p *PMAPIProvider) loadCounts(rules transferRules, progress *Progress) {
 		rule := rule
 		progress.callWrap(func() error {
 			_, total, err := p.listMessages(&pmapi.MessagesFilter{
-				AddressID: p.addressID,
-				LabelID:   rule.SourceMailbox.ID,
-				Begin:     rule.FromTime,
-				End:       rule.ToTime,
-				Limit:     0,
+				AddressID:   p.addressID,
+				LabelID:     rule.SourceMailbox.ID,
+				Begin:       rule.FromTime,
+				End:         rule.ToTime,
+				Limit:       0,
+				Attachments: rules.globalFilter.attachmentsFilter(),
 			})
 			if err != nil {
 				log.WithError(err).Warning("Problem to load counts")
@@ -77,7 +130,13 @@ func (p *PMAPIProvider) loadCounts(rules transferRules, progress *Progress) {
 	progress.countsFinal()
 }
 
-func (p *PMAPIProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Message, skipEncryptedMessages bool) {
+// transferTo exports messages of the rule passing the filter and returns
+// their count.
+func (p *PMAPIProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Message, skipEncryptedMessages bool, filter *messageFilter) uint {
+	matched := uint(0)
+	// Page size cannot change between pages even if the profile is
+	// downshifted meanwhile, otherwise some messages would be skipped.
+	pageSize := p.getThrottlingProfile().ListPageSize
 	page := 0
 	for {
 		if progress.shouldStop() {
@@ -93,14 +152,15 @@ func (p *PMAPIProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Mes
 			// filtering by both mentioned fields at the same time.
 			desc := false
 			pmapiMessages, total, err := p.listMessages(&pmapi.MessagesFilter{
-				AddressID: p.addressID,
-				LabelID:   rule.SourceMailbox.ID,
-				Begin:     rule.FromTime,
-				End:       rule.ToTime,
-				PageSize:  pmapiListPageSize,
-				Page:      page,
-				Sort:      "ID",
-				Desc:      &desc,
+				AddressID:   p.addressID,
+				LabelID:     rule.SourceMailbox.ID,
+				Begin:       rule.FromTime,
+				End:         rule.ToTime,
+				PageSize:    pageSize,
+				Page:        page,
+				Sort:        "ID",
+				Desc:        &desc,
+				Attachments: filter.attachmentsFilter(),
 			})
 			if err != nil {
 				return err
@@ -112,14 +172,22 @@ func (p *PMAPIProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Mes
 				"count": len(pmapiMessages),
 			}).Debug("Listing messages")
 
-			isLastPage = len(pmapiMessages) < pmapiListPageSize
+			isLastPage = len(pmapiMessages) < pageSize
 
 			for _, pmapiMessage := range pmapiMessages {
 				if progress.shouldStop() {
 					break
 				}
 
+				if !filter.match(pmapiMessage) {
+					continue
+				}
+				matched++
+
 				msgID := fmt.Sprintf("%s_%s", rule.SourceMailbox.ID, pmapiMessage.ID)
+				if progress.skipTransferred(msgID, rule) {
+					continue
+				}
 				progress.addMessage(msgID, rule)
 				msg, err := p.exportMessage(rule, progress, pmapiMessage.ID, msgID, skipEncryptedMessages)
 				progress.messageExported(msgID, msg.Body, err)
@@ -137,6 +205,15 @@ func (p *PMAPIProvider) transferTo(rule *Rule, progress *Progress, ch chan<- Mes
 			break
 		}
 	}
+	return matched
+}
+
+func (p *PMAPIProvider) buildMessage(msg *pmapi.Message) (*pkgMessage.Builder, []byte, error) {
+	msgBuilder := pkgMessage.NewBuilder(p.client(), msg)
+	msgBuilder.EncryptedToHTML = false
+	msgBuilder.AttachmentWorkers = pmapiAttachmentWorkers
+	_, body, err := msgBuilder.BuildMessage()
+	return msgBuilder, body, err
 }
 
 func (p *PMAPIProvider) exportMessage(rule *Rule, progress *Progress, pmapiMsgID, msgID string, skipEncryptedMessages bool) (Message, error) {
@@ -147,9 +224,7 @@ func (p *PMAPIProvider) exportMessage(rule *Rule, progress *Progress, pmapiMsgID
 		return err
 	})
 
-	msgBuilder := pkgMessage.NewBuilder(p.client(), msg)
-	msgBuilder.EncryptedToHTML = false
-	_, body, err := msgBuilder.BuildMessage()
+	msgBuilder, body, err := p.buildMessage(msg)
 	if err != nil {
 		return Message{
 			Body: body, // Keep body to show details about the message to user.
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"time"
 
 	pkgMessage "github.com/ProtonMail/proton-bridge/pkg/message"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
@@ -49,6 +50,15 @@ func (p *PMAPIProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
 		return Mailbox{}, errors.New("mailbox is already created")
 	}
 
+	name, err := normalizeMailboxName(mailbox.Name, p.nameNormalization)
+	if err != nil {
+		return Mailbox{}, errors.Wrap(err, fmt.Sprintf("failed to create mailbox %s", mailbox.Name))
+	}
+	if name != mailbox.Name {
+		log.WithField("name", mailbox.Name).WithField("normalized", name).Info("Mailbox name normalized")
+		mailbox.Name = name
+	}
+
 	exclusive := 0
 	if mailbox.IsExclusive {
 		exclusive = 1
@@ -57,6 +67,7 @@ func (p *PMAPIProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
 	label, err := p.client().CreateLabel(&pmapi.Label{
 		Name:      mailbox.Name,
 		Color:     mailbox.Color,
+		Order:     mailbox.Order,
 		Exclusive: exclusive,
 		Type:      pmapi.LabelTypeMailbox,
 	})
@@ -67,34 +78,40 @@ func (p *PMAPIProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
 	return mailbox, nil
 }
 
-// TransferFrom imports messages from channel.
+// TransferFrom imports messages from channel. Messages are uploaded by
+// the pool of workers, see SetConcurrency.
 func (p *PMAPIProvider) TransferFrom(rules transferRules, progress *Progress, ch <-chan Message) {
-	log.Info("Started transfer from channel to PMAPI")
+	log.WithField("concurrency", p.concurrency).Info("Started transfer from channel to PMAPI")
 	defer log.Info("Finished transfer from channel to PMAPI")
 
-	// Cache has to be cleared before each transfer to not contain
-	// old stuff from previous cancelled run.
-	p.importMsgReqMap = map[string]*pmapi.ImportMsgReq{}
-	p.importMsgReqSize = 0
+	p.runImportWorkers(p.concurrency, ch, func(worker <-chan Message) {
+		// Batch is per worker and new for each transfer to not contain
+		// old stuff from previous cancelled run.
+		batch := newPMAPIImportBatch()
 
-	for msg := range ch {
-		if progress.shouldStop() {
-			break
-		}
+		for msg := range worker {
+			// Worker has to read everything so the dispatcher is not blocked.
+			if progress.shouldStop() {
+				continue
+			}
 
-		if p.isMessageDraft(msg) {
-			p.transferDraft(rules, progress, msg)
-		} else {
-			p.transferMessage(rules, progress, msg)
+			if p.isMessageDraft(msg) {
+				p.transferDraft(rules, progress, msg)
+			} else {
+				p.transferMessage(rules, progress, batch, msg)
+			}
 		}
-	}
 
-	if len(p.importMsgReqMap) > 0 {
-		p.importMessages(progress)
-	}
+		if len(batch.msgIDs) > 0 {
+			p.importMessages(progress, batch)
+		}
+	})
 }
 
 func (p *PMAPIProvider) isMessageDraft(msg Message) bool {
+	if msg.Draft {
+		return true
+	}
 	for _, target := range msg.Targets {
 		if target.ID == pmapi.DraftLabel {
 			return true
@@ -155,19 +172,31 @@ func (p *PMAPIProvider) importDraft(msg Message, globalMailbox *Mailbox) (string
 	return draft.ID, nil
 }
 
-func (p *PMAPIProvider) transferMessage(rules transferRules, progress *Progress, msg Message) {
+func (p *PMAPIProvider) transferMessage(rules transferRules, progress *Progress, batch *pmapiImportBatch, msg Message) {
+	hash := ""
+	if p.dedup != nil {
+		var isDuplicate bool
+		if hash, isDuplicate = p.findDuplicate(progress, msg); isDuplicate {
+			progress.messageDuplicate(msg.ID)
+			return
+		}
+	}
+
 	importMsgReq, err := p.generateImportMsgReq(msg, rules.globalMailbox)
 	if err != nil {
 		progress.messageImported(msg.ID, "", err)
 		return
 	}
 
-	importMsgReqSize := len(importMsgReq.Body)
-	if p.importMsgReqSize+importMsgReqSize > pmapiImportBatchMaxSize || len(p.importMsgReqMap) == pmapiImportBatchMaxItems {
-		p.importMessages(progress)
+	throttling := p.getThrottlingProfile()
+	if batch.size+len(importMsgReq.Body) > throttling.ImportBatchMaxSize || len(batch.msgIDs) >= throttling.ImportBatchMaxItems {
+		p.importMessages(progress, batch)
+	}
+	checksum := ""
+	if p.checksums {
+		checksum = msg.Checksum
 	}
-	p.importMsgReqMap[msg.ID] = importMsgReq
-	p.importMsgReqSize += importMsgReqSize
+	batch.add(msg.ID, importMsgReq, checksum, hash)
 }
 
 func (p *PMAPIProvider) generateImportMsgReq(msg Message, globalMailbox *Mailbox) (*pmapi.ImportMsgReq, error) {
@@ -186,23 +215,50 @@ func (p *PMAPIProvider) generateImportMsgReq(msg Message, globalMailbox *Mailbox
 		unread = 1
 	}
 
+	targets := msg.Targets
+	if globalMailbox != nil {
+		targets = append(append([]Mailbox{}, targets...), *globalMailbox)
+	}
+
 	labelIDs := []string{}
-	for _, target := range msg.Targets {
+	for _, target := range targets {
 		// Frontend should not set All Mail to Rules, but to be sure...
 		if target.ID != pmapi.AllMailLabel {
 			labelIDs = append(labelIDs, target.ID)
 		}
 	}
-	if globalMailbox != nil {
-		labelIDs = append(labelIDs, globalMailbox.ID)
+
+	// Flags are computed from the original folders so deleted messages
+	// still know whether they were sent or received.
+	flags := computeMessageFlags(labelIDs)
+
+	// Message can be in only one folder, so deleted message goes to Trash
+	// instead of the folder picked by the rule. Labels are kept.
+	if msg.Deleted {
+		labelIDs = []string{pmapi.TrashLabel}
+		for _, target := range targets {
+			if target.ID != pmapi.AllMailLabel && !isFolder(target) {
+				labelIDs = append(labelIDs, target.ID)
+			}
+		}
+	}
+
+	if msg.Starred {
+		labelIDs = append(labelIDs, pmapi.StarredLabel)
+	}
+
+	isReplied := 0
+	if msg.Answered {
+		isReplied = 1
 	}
 
 	return &pmapi.ImportMsgReq{
 		AddressID: p.addressID,
 		Body:      body,
 		Unread:    unread,
+		IsReplied: isReplied,
 		Time:      message.Time,
-		Flags:     computeMessageFlags(labelIDs),
+		Flags:     flags,
 		LabelIDs:  labelIDs,
 	}, nil
 }
@@ -234,6 +290,16 @@ func (p *PMAPIProvider) encryptMessage(msg *pmapi.Message, attachmentReaders []i
 	return pkgMessage.BuildEncrypted(msg, attachmentReaders, p.keyRing)
 }
 
+// isFolder returns whether the mailbox is exclusive, i.e. whether message
+// can be in no other such mailbox at the same time.
+func isFolder(mailbox Mailbox) bool {
+	switch mailbox.ID {
+	case pmapi.InboxLabel, pmapi.SentLabel, pmapi.DraftLabel, pmapi.ArchiveLabel, pmapi.SpamLabel, pmapi.TrashLabel:
+		return true
+	}
+	return mailbox.IsExclusive
+}
+
 func computeMessageFlags(labels []string) (flag int64) {
 	for _, labelID := range labels {
 		switch labelID {
@@ -254,46 +320,91 @@ func computeMessageFlags(labels []string) (flag int64) {
 	return flag
 }
 
-func (p *PMAPIProvider) importMessages(progress *Progress) {
+func (p *PMAPIProvider) importMessages(progress *Progress, batch *pmapiImportBatch) {
+	defer batch.reset()
+
 	if progress.shouldStop() {
 		return
 	}
 
-	importMsgIDs := []string{}
-	importMsgRequests := []*pmapi.ImportMsgReq{}
-	for msgID, req := range p.importMsgReqMap {
-		importMsgIDs = append(importMsgIDs, msgID)
-		importMsgRequests = append(importMsgRequests, req)
+	if backoff := batch.backoff(); backoff > 0 {
+		log.WithField("backoff", backoff).Debug("Worker is backing off after failed imports")
+		time.Sleep(backoff)
 	}
 
-	log.WithField("msgIDs", importMsgIDs).WithField("size", p.importMsgReqSize).Debug("Importing messages")
-	results, err := p.importRequest(importMsgRequests)
+	log.WithField("msgIDs", batch.msgIDs).WithField("size", batch.size).Debug("Importing messages")
+	results, err := p.importRequest(batch.requests)
 
 	// In case the whole request failed, try to import every message one by one.
 	if err != nil || len(results) == 0 {
+		batch.requestFailed()
 		log.WithError(err).Warning("Importing messages failed, trying one by one")
-		for msgID, req := range p.importMsgReqMap {
-			importedID, err := p.importMessage(progress, req)
-			progress.messageImported(msgID, importedID, err)
+		for index := range batch.msgIDs {
+			importedID, err := p.importMessage(progress, batch.requests[index])
+			p.messageImported(progress, batch, index, importedID, err)
 		}
 		return
 	}
+	batch.requestSucceeded()
 
 	// In case request passed but some messages failed, try to import the failed ones alone.
 	for index, result := range results {
-		msgID := importMsgIDs[index]
 		if result.Error != nil {
-			log.WithError(result.Error).WithField("msg", msgID).Warning("Importing message failed, trying alone")
-			req := importMsgRequests[index]
-			importedID, err := p.importMessage(progress, req)
-			progress.messageImported(msgID, importedID, err)
+			log.WithError(result.Error).WithField("msg", batch.msgIDs[index]).Warning("Importing message failed, trying alone")
+			importedID, err := p.importMessage(progress, batch.requests[index])
+			p.messageImported(progress, batch, index, importedID, err)
 		} else {
-			progress.messageImported(msgID, result.MessageID, nil)
+			p.messageImported(progress, batch, index, result.MessageID, nil)
 		}
 	}
+}
+
+// messageImported verifies the imported message from the batch, records
+// its hash for deduplication and reports the result to the progress.
+func (p *PMAPIProvider) messageImported(progress *Progress, batch *pmapiImportBatch, index int, importedID string, importErr error) {
+	err := p.verifyImportedMessage(progress, batch.checksums[index], importedID, importErr)
+	if err == nil {
+		p.dedup.add(batch.hashes[index])
+	}
+	progress.messageImported(batch.msgIDs[index], importedID, err)
+}
+
+// verifyImportedMessage downloads the imported message back and compares
+// its canonical hash with the one recorded during export. Messages without
+// recorded hash or which failed to import are passed through.
+func (p *PMAPIProvider) verifyImportedMessage(progress *Progress, expected, importedID string, importErr error) error {
+	if importErr != nil || expected == "" {
+		return importErr
+	}
+
+	var msg *pmapi.Message
+	var err error
+	progress.callWrap(func() error {
+		msg, err = p.getMessage(importedID)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to download message for checksum verification")
+	}
+	if msg == nil {
+		return errors.New("checksum not verified, transfer was stopped")
+	}
+
+	_, body, err := p.buildMessage(msg)
+	if err != nil {
+		return errors.Wrap(err, "failed to build message for checksum verification")
+	}
+
+	actual, err := canonicalMessageHash(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to compute checksum")
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch after upload: expected %s, got %s", expected, actual)
+	}
 
-	p.importMsgReqMap = map[string]*pmapi.ImportMsgReq{}
-	p.importMsgReqSize = 0
+	log.WithField("msg", importedID).Debug("Checksum verified")
+	return nil
 }
 
 func (p *PMAPIProvider) importMessage(progress *Progress, req *pmapi.ImportMsgReq) (importedID string, importedErr error) {
@@ -23,6 +23,7 @@ import (
 	"testing"
 	"time"
 
+	transfermocks "github.com/ProtonMail/proton-bridge/internal/transfer/mocks"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	gomock "github.com/golang/mock/gomock"
 	r "github.com/stretchr/testify/require"
@@ -42,16 +43,16 @@ func TestPMAPIProviderMailboxes(t *testing.T) {
 		wantMailboxes  []Mailbox
 	}{
 		{true, false, []Mailbox{
-			{ID: "folder1", Name: "One", Color: "red", IsExclusive: true},
-			{ID: "folder2", Name: "Two", Color: "orange", IsExclusive: true},
-			{ID: "label2", Name: "Bar", Color: "green", IsExclusive: false},
-			{ID: "label1", Name: "Foo", Color: "blue", IsExclusive: false},
+			{ID: "folder1", Name: "One", Color: "red", Order: 1, IsExclusive: true},
+			{ID: "folder2", Name: "Two", Color: "orange", Order: 2, IsExclusive: true},
+			{ID: "label2", Name: "Bar", Color: "green", Order: 1, IsExclusive: false},
+			{ID: "label1", Name: "Foo", Color: "blue", Order: 2, IsExclusive: false},
 		}},
 		{false, true, []Mailbox{
 			{ID: pmapi.AllMailLabel, Name: "All Mail", IsExclusive: true},
-			{ID: "folder1", Name: "One", Color: "red", IsExclusive: true},
-			{ID: "folder2", Name: "Two", Color: "orange", IsExclusive: true},
-			{ID: "label1", Name: "Foo", Color: "blue", IsExclusive: false},
+			{ID: "folder1", Name: "One", Color: "red", Order: 1, IsExclusive: true},
+			{ID: "folder2", Name: "Two", Color: "orange", Order: 2, IsExclusive: true},
+			{ID: "label1", Name: "Foo", Color: "blue", Order: 2, IsExclusive: false},
 		}},
 	}
 	for _, tc := range tests {
@@ -145,6 +146,40 @@ func TestPMAPIProviderTransferFromTo(t *testing.T) {
 	testTransferFromTo(t, rules, source, target, 5*time.Second)
 }
 
+func TestPMAPIProviderImportRequestLabels(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	setupPMAPIClientExpectationForImport(&m)
+	provider, err := NewPMAPIProvider(m.pmapiConfig, m.clientManager, "user", "addressID")
+	r.NoError(t, err)
+
+	globalMailbox := &Mailbox{ID: "global", Name: "Imported"}
+	targets := []Mailbox{{ID: "folder1", IsExclusive: true}, {ID: "label1"}}
+
+	tests := []struct {
+		msg          Message
+		wantLabelIDs []string
+		wantFlags    int64
+	}{
+		{Message{Targets: targets}, []string{"folder1", "label1", "global"}, pmapi.FlagReceived},
+		{Message{Targets: targets, Starred: true}, []string{"folder1", "label1", "global", pmapi.StarredLabel}, pmapi.FlagReceived},
+		{Message{Targets: targets, Deleted: true}, []string{pmapi.TrashLabel, "label1", "global"}, pmapi.FlagReceived},
+		{Message{Targets: []Mailbox{{ID: pmapi.SentLabel}}, Deleted: true, Starred: true}, []string{pmapi.TrashLabel, "global", pmapi.StarredLabel}, pmapi.FlagSent},
+	}
+	for _, tc := range tests {
+		tc := tc
+		tc.msg.ID = "msg1"
+		tc.msg.Body = getTestMsgBody("msg1")
+		t.Run(fmt.Sprintf("%v", tc.wantLabelIDs), func(t *testing.T) {
+			req, err := provider.generateImportMsgReq(tc.msg, globalMailbox)
+			r.NoError(t, err)
+			r.Equal(t, tc.wantLabelIDs, req.LabelIDs)
+			r.Equal(t, tc.wantFlags, req.Flags)
+		})
+	}
+}
+
 func setupPMAPIRules(rules transferRules) {
 	_ = rules.setRule(Mailbox{ID: pmapi.InboxLabel}, []Mailbox{{ID: pmapi.InboxLabel}}, 0, 0)
 }
@@ -198,3 +233,33 @@ func setupPMAPIClientExpectationForImportDraft(m *mocks) {
 		return msg, nil
 	})
 }
+
+func TestPMAPIProviderWaitsForRateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientManager := transfermocks.NewMockClientManager(ctrl)
+	clientManager.EXPECT().GetRateLimitDelay().Return(100 * time.Millisecond)
+	provider := &PMAPIProvider{clientManager: clientManager}
+
+	start := time.Now()
+	err := provider.ensureConnection(func() error { return nil })
+	r.NoError(t, err)
+	r.True(t, time.Since(start) >= 100*time.Millisecond)
+}
+
+func TestPMAPIProviderDownshiftsThrottlingWhenRateLimited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	clientManager := transfermocks.NewMockClientManager(ctrl)
+	clientManager.EXPECT().GetRateLimitDelay().Return(time.Millisecond)
+	clientManager.EXPECT().GetRateLimitDelay().Return(time.Duration(0))
+	provider := &PMAPIProvider{clientManager: clientManager, throttling: ThrottlingAggressive}
+
+	r.NoError(t, provider.ensureConnection(func() error { return nil }))
+	r.Equal(t, ThrottlingNormal, provider.getThrottlingProfile())
+
+	r.NoError(t, provider.ensureConnection(func() error { return nil }))
+	r.Equal(t, ThrottlingNormal, provider.getThrottlingProfile())
+}
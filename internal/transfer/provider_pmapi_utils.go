@@ -27,27 +27,69 @@ import (
 
 const (
 	pmapiRetries          = 10
+	pmapiRetryBackoff     = time.Second
+	pmapiRetryBackoffMax  = time.Minute
 	pmapiReconnectTimeout = 30 * time.Minute
 	pmapiReconnectSleep   = time.Minute
 )
 
+// ensureConnection calls the callback until it passes. Every worker
+// retries on its own and waits longer after each failed attempt, so
+// failing workers do not hammer the API all at the same time.
 func (p *PMAPIProvider) ensureConnection(callback func() error) error {
 	var callErr error
 	for i := 1; i <= pmapiRetries; i++ {
+		p.waitForRateLimit()
 		callErr = callback()
 		if callErr == nil {
 			return nil
 		}
 
+		// Request refused by the API would be refused again.
+		if _, ok := callErr.(*pmapi.ErrUnprocessableEntity); ok {
+			return callErr
+		}
+
 		log.WithField("attempt", i).WithError(callErr).Warning("API call failed, trying reconnect")
 		err := p.tryReconnect()
 		if err != nil {
 			return err
 		}
+
+		if i < pmapiRetries {
+			time.Sleep(retryBackoff(i))
+		}
 	}
 	return errors.Wrap(callErr, "too many retries")
 }
 
+// retryBackoff returns exponentially growing delay before the next attempt.
+func retryBackoff(attempt int) time.Duration {
+	backoff := pmapiRetryBackoff
+	for i := 1; i < attempt && backoff < pmapiRetryBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > pmapiRetryBackoffMax {
+		backoff = pmapiRetryBackoffMax
+	}
+	return backoff
+}
+
+// waitForRateLimit holds the request until the time requested by the server
+// passes, so other transfer workers do not keep hitting the API meanwhile.
+// Being rate limited also means the current throttling profile is too
+// aggressive, so the provider downshifts to a gentler one.
+func (p *PMAPIProvider) waitForRateLimit() {
+	if delay := p.clientManager.GetRateLimitDelay(); delay > 0 {
+		log.WithField("delay", delay).Debug("Waiting for rate limit")
+		p.downshiftThrottling()
+		time.Sleep(delay)
+	}
+	if delay := p.getThrottlingProfile().RequestDelay; delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
 func (p *PMAPIProvider) tryReconnect() error {
 	start := time.Now()
 	var previousErr error
@@ -86,6 +128,12 @@ func (p *PMAPIProvider) getMessage(msgID string) (message *pmapi.Message, err er
 }
 
 func (p *PMAPIProvider) importRequest(req []*pmapi.ImportMsgReq) (res []*pmapi.ImportMsgRes, err error) {
+	size := 0
+	for _, r := range req {
+		size += len(r.Body)
+	}
+	bandwidth.wait(uint64(size))
+
 	err = p.ensureConnection(func() error {
 		res, err = p.client().Import(req)
 		return err
@@ -102,6 +150,7 @@ func (p *PMAPIProvider) createDraft(message *pmapi.Message, parent string, actio
 }
 
 func (p *PMAPIProvider) createAttachment(att *pmapi.Attachment, r io.Reader, sig io.Reader) (created *pmapi.Attachment, err error) {
+	r = &bandwidthReader{Reader: r}
 	err = p.ensureConnection(func() error {
 		created, err = p.client().CreateAttachment(att, r, sig)
 		return err
@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+)
+
+// DefaultConcurrency is the default number of workers uploading messages.
+const DefaultConcurrency = 4
+
+// pmapiImportBatch collects messages imported by one request. Slices are
+// used instead of map to import messages in the order they came.
+type pmapiImportBatch struct {
+	msgIDs    []string // Message transfer IDs.
+	requests  []*pmapi.ImportMsgReq
+	checksums []string // Expected checksums, empty if not verified.
+	hashes    []string // Hashes recorded for deduplication, if enabled.
+	size      int
+
+	// failures counts requests of this worker which failed as a whole
+	// in a row. It is not reset with the batch so the worker keeps backing
+	// off on its own while other workers continue at full speed.
+	failures int
+}
+
+func newPMAPIImportBatch() *pmapiImportBatch {
+	batch := &pmapiImportBatch{}
+	batch.reset()
+	return batch
+}
+
+func (b *pmapiImportBatch) add(msgID string, req *pmapi.ImportMsgReq, checksum, hash string) {
+	b.msgIDs = append(b.msgIDs, msgID)
+	b.requests = append(b.requests, req)
+	b.checksums = append(b.checksums, checksum)
+	b.hashes = append(b.hashes, hash)
+	b.size += len(req.Body)
+}
+
+func (b *pmapiImportBatch) reset() {
+	b.msgIDs = []string{}
+	b.requests = []*pmapi.ImportMsgReq{}
+	b.checksums = []string{}
+	b.hashes = []string{}
+	b.size = 0
+}
+
+// backoff returns how long the worker waits before the next import request.
+func (b *pmapiImportBatch) backoff() time.Duration {
+	if b.failures == 0 {
+		return 0
+	}
+	return retryBackoff(b.failures)
+}
+
+func (b *pmapiImportBatch) requestFailed() {
+	if b.failures < pmapiRetries {
+		b.failures++
+	}
+}
+
+func (b *pmapiImportBatch) requestSucceeded() {
+	b.failures = 0
+}
+
+// runImportWorkers distributes messages from ch between workers and blocks
+// until all of them are done. All messages from the same source mailbox
+// go to the same worker which processes them one after another. That keeps
+// their order but it also means one big mailbox is uploaded by one worker.
+func (p *PMAPIProvider) runImportWorkers(workers int, ch <-chan Message, work func(<-chan Message)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	wg := &sync.WaitGroup{}
+	workerChs := make([]chan Message, workers)
+	for i := range workerChs {
+		workerChs[i] = make(chan Message)
+		wg.Add(1)
+		go func(workerCh <-chan Message) {
+			defer wg.Done()
+			work(workerCh)
+		}(workerChs[i])
+	}
+
+	for msg := range ch {
+		workerChs[importWorkerIndex(msg, workers)] <- msg
+	}
+
+	for _, workerCh := range workerChs {
+		close(workerCh)
+	}
+	wg.Wait()
+}
+
+func importWorkerIndex(msg Message, workers int) int {
+	if workers == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(msg.Source.ID))
+	_, _ = h.Write([]byte(msg.Source.Name))
+	return int(h.Sum32() % uint32(workers))
+}
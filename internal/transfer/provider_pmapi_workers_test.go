@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	gomock "github.com/golang/mock/gomock"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestPMAPIProviderTransferFromKeepsOrderPerFolder(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	subjectRegexp := regexp.MustCompile(`Subject: (\S+)`)
+	importedLock := &sync.Mutex{}
+	imported := map[string][]int{}
+
+	m.pmapiClient.EXPECT().KeyRingForAddressID(gomock.Any()).Return(m.keyring, nil).AnyTimes()
+	m.pmapiClient.EXPECT().Import(gomock.Any()).DoAndReturn(func(requests []*pmapi.ImportMsgReq) ([]*pmapi.ImportMsgRes, error) {
+		importedLock.Lock()
+		defer importedLock.Unlock()
+
+		results := []*pmapi.ImportMsgRes{}
+		for _, request := range requests {
+			subject := string(subjectRegexp.FindSubmatch(request.Body)[1])
+			var folder string
+			var index int
+			_, _ = fmt.Sscanf(subject, "%1s-%d", &folder, &index)
+			imported[folder] = append(imported[folder], index)
+			results = append(results, &pmapi.ImportMsgRes{MessageID: subject})
+		}
+		return results, nil
+	}).AnyTimes()
+
+	provider, err := NewPMAPIProvider(m.pmapiConfig, m.clientManager, "user", "addressID")
+	r.NoError(t, err)
+	provider.SetConcurrency(3)
+	provider.SetThrottlingProfile(ThrottlingProfile{
+		Name:                "test",
+		ImportBatchMaxItems: 2,
+		ImportBatchMaxSize:  pmapiImportBatchMaxSize,
+	})
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupPMAPIRules(rules)
+
+	messages := []Message{}
+	for index := 0; index < 5; index++ {
+		for _, folder := range []string{"a", "b", "c", "d"} {
+			id := fmt.Sprintf("%s-%d", folder, index)
+			messages = append(messages, Message{
+				ID:      id,
+				Body:    getTestMsgBody(id),
+				Source:  Mailbox{ID: folder, Name: folder},
+				Targets: []Mailbox{{ID: pmapi.InboxLabel}},
+			})
+		}
+	}
+
+	testTransferFrom(t, rules, provider, messages)
+
+	r.Len(t, imported, 4)
+	for folder, indexes := range imported {
+		r.Equal(t, []int{0, 1, 2, 3, 4}, indexes, "folder %s", folder)
+	}
+}
+
+func TestImportWorkerIndex(t *testing.T) {
+	msg := Message{Source: Mailbox{ID: "folder", Name: "Folder"}}
+	r.Equal(t, 0, importWorkerIndex(msg, 1))
+	r.Equal(t, importWorkerIndex(msg, 8), importWorkerIndex(Message{ID: "other", Source: msg.Source}, 8))
+}
+
+func TestImportBatchBackoff(t *testing.T) {
+	batch := newPMAPIImportBatch()
+	r.Equal(t, time.Duration(0), batch.backoff())
+
+	batch.requestFailed()
+	batch.requestFailed()
+	r.Equal(t, 2*time.Second, batch.backoff())
+
+	// Failures are counted per worker, not per batch.
+	batch.reset()
+	r.Equal(t, 2*time.Second, batch.backoff())
+
+	batch.requestSucceeded()
+	r.Equal(t, time.Duration(0), batch.backoff())
+}
+
+func TestRetryBackoff(t *testing.T) {
+	r.Equal(t, time.Second, retryBackoff(1))
+	r.Equal(t, 4*time.Second, retryBackoff(3))
+	r.Equal(t, time.Minute, retryBackoff(pmapiRetries))
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/ProtonMail/proton-bridge/pkg/pst"
+)
+
+// PSTProvider implements import from Outlook personal folders file (.pst).
+// Folders are imported as folders and Outlook categories as labels.
+type PSTProvider struct {
+	path string
+}
+
+// NewPSTProvider creates PSTProvider for the file at path.
+func NewPSTProvider(path string) *PSTProvider {
+	return &PSTProvider{
+		path: path,
+	}
+}
+
+// IsPSTPath returns whether path has extension of PST file.
+func IsPSTPath(path string) bool {
+	return strings.HasSuffix(strings.ToLower(path), ".pst")
+}
+
+// ID is used for generating transfer ID by combining source and target ID.
+func (p *PSTProvider) ID() string {
+	return "pst"
+}
+
+// Mailboxes returns all folders of the file and all categories used
+// by its messages. Folders of nested folders are joined by slash.
+func (p *PSTProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox, error) {
+	file, err := pst.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() //nolint[errcheck]
+
+	folders, err := file.Folders()
+	if err != nil {
+		return nil, err
+	}
+
+	mailboxes := []Mailbox{}
+	folderNames := map[string]bool{}
+	for _, folder := range folders {
+		if !includeEmpty && folder.MessageCount == 0 {
+			continue
+		}
+		name := pstFolderName(folder)
+		folderNames[name] = true
+		mailboxes = append(mailboxes, Mailbox{
+			Name:        name,
+			IsExclusive: true,
+		})
+	}
+
+	categories, err := file.Categories()
+	if err != nil {
+		return nil, err
+	}
+	// Colors of categories are stored in Outlook settings, not in messages.
+	for i, category := range categories {
+		// Rules are matched by name, folder has precedence.
+		if folderNames[category] {
+			log.WithField("category", category).Warn("Category skipped due to folder with the same name")
+			continue
+		}
+		mailboxes = append(mailboxes, Mailbox{
+			Name:        category,
+			Color:       pmapi.LabelColors[i%len(pmapi.LabelColors)],
+			IsExclusive: false,
+		})
+	}
+
+	return mailboxes, nil
+}
+
+func pstFolderName(folder pst.Folder) string {
+	return strings.Join(folder.Path, "/")
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pst"
+)
+
+// pstPart is MIME entity; either leaf with body or multipart with parts.
+type pstPart struct {
+	header  textproto.MIMEHeader
+	body    []byte
+	subtype string
+	parts   []pstPart
+}
+
+// buildPSTMessage creates RFC822 message from the message of PST file.
+// PST files keep only properties of the message, so the MIME structure is
+// built again. Original headers are used when the message was received.
+func buildPSTMessage(msg *pst.Message) ([]byte, error) {
+	root := newPSTRootPart(msg)
+	var body bytes.Buffer
+	if err := root.writeBody(&body); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if msg.Headers != "" {
+		writePSTTransportHeaders(&b, msg.Headers)
+	} else {
+		writePSTHeaders(&b, msg)
+	}
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	for _, key := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if value := root.header.Get(key); value != "" {
+			fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+		}
+	}
+	b.WriteString("\r\n")
+	b.Write(body.Bytes())
+
+	return b.Bytes(), nil
+}
+
+// writePSTTransportHeaders writes original headers without the ones
+// describing MIME structure, which is different now.
+func writePSTTransportHeaders(w io.Writer, headers string) {
+	skip := false
+	for _, line := range strings.Split(strings.ReplaceAll(headers, "\r\n", "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+
+		// Folded line belongs to the previous header.
+		if line[0] != ' ' && line[0] != '\t' {
+			key := strings.ToLower(strings.SplitN(line, ":", 2)[0])
+			skip = strings.HasPrefix(key, "content-") || key == "mime-version"
+		}
+		if !skip {
+			fmt.Fprintf(w, "%s\r\n", line)
+		}
+	}
+}
+
+func writePSTHeaders(w io.Writer, msg *pst.Message) {
+	if msg.From != nil {
+		fmt.Fprintf(w, "From: %s\r\n", msg.From)
+	}
+	for _, field := range []struct {
+		key       string
+		addresses []*mail.Address
+	}{
+		{"To", msg.To},
+		{"Cc", msg.Cc},
+		{"Bcc", msg.Bcc},
+	} {
+		if len(field.addresses) == 0 {
+			continue
+		}
+		addresses := make([]string, len(field.addresses))
+		for i, address := range field.addresses {
+			addresses[i] = address.String()
+		}
+		fmt.Fprintf(w, "%s: %s\r\n", field.key, strings.Join(addresses, ", "))
+	}
+
+	fmt.Fprintf(w, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	if !msg.Date.IsZero() {
+		fmt.Fprintf(w, "Date: %s\r\n", msg.Date.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	}
+	if msg.MessageID != "" {
+		fmt.Fprintf(w, "Message-Id: %s\r\n", msg.MessageID)
+	}
+	if msg.InReplyTo != "" {
+		fmt.Fprintf(w, "In-Reply-To: %s\r\n", msg.InReplyTo)
+	}
+}
+
+func newPSTRootPart(msg *pst.Message) pstPart {
+	var texts []pstPart
+	if msg.Body != "" || msg.HTML == "" {
+		texts = append(texts, newPSTTextPart("text/plain", msg.Body))
+	}
+	if msg.HTML != "" {
+		texts = append(texts, newPSTTextPart("text/html", msg.HTML))
+	}
+
+	content := texts[0]
+	if len(texts) > 1 {
+		content = newPSTMultipart("alternative", texts)
+	}
+
+	if len(msg.Attachments) == 0 {
+		return content
+	}
+
+	parts := []pstPart{content}
+	for _, att := range msg.Attachments {
+		parts = append(parts, newPSTAttachmentPart(att))
+	}
+	return newPSTMultipart("mixed", parts)
+}
+
+func newPSTTextPart(contentType, text string) pstPart {
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", mime.FormatMediaType(contentType, map[string]string{"charset": "utf-8"}))
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+
+	var b bytes.Buffer
+	qp := quotedprintable.NewWriter(&b)
+	_, _ = qp.Write([]byte(text))
+	_ = qp.Close()
+
+	return pstPart{header: header, body: b.Bytes()}
+}
+
+func newPSTAttachmentPart(att pst.Attachment) pstPart {
+	contentType := att.MIMEType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(att.Name))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	disposition := "attachment"
+	header := textproto.MIMEHeader{}
+	if att.ContentID != "" {
+		disposition = "inline"
+		header.Set("Content-Id", "<"+strings.Trim(att.ContentID, "<>")+">")
+	}
+
+	params := map[string]string{}
+	if att.Name != "" {
+		params["filename"] = att.Name
+	}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, params))
+	header.Set("Content-Transfer-Encoding", "base64")
+
+	encoded := base64.StdEncoding.EncodeToString(att.Data)
+	var b bytes.Buffer
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+
+	return pstPart{header: header, body: b.Bytes()}
+}
+
+func newPSTMultipart(subtype string, parts []pstPart) pstPart {
+	return pstPart{header: textproto.MIMEHeader{}, subtype: subtype, parts: parts}
+}
+
+// writeBody writes body of the part. Boundary of multipart is set
+// to the header, therefore the header has to be written after the body.
+func (part *pstPart) writeBody(w io.Writer) error {
+	if part.subtype == "" {
+		_, err := w.Write(part.body)
+		return err
+	}
+
+	var b bytes.Buffer
+	mw := multipart.NewWriter(&b)
+	part.header.Set("Content-Type", mime.FormatMediaType("multipart/"+part.subtype, map[string]string{"boundary": mw.Boundary()}))
+
+	for i := range part.parts {
+		child := &part.parts[i]
+
+		var childBody bytes.Buffer
+		if err := child.writeBody(&childBody); err != nil {
+			return err
+		}
+
+		pw, err := mw.CreatePart(child.header)
+		if err != nil {
+			return err
+		}
+		if _, err := pw.Write(childBody.Bytes()); err != nil {
+			return err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pst"
+)
+
+// TransferTo exports messages based on rules to channel.
+func (p *PSTProvider) TransferTo(rules transferRules, progress *Progress, ch chan<- Message) {
+	log.Info("Started transfer from PST to channel")
+	defer log.Info("Finished transfer from PST to channel")
+
+	file, err := pst.Open(p.path)
+	if err != nil {
+		progress.fatal(err)
+		return
+	}
+	defer file.Close() //nolint[errcheck]
+
+	messagesPerFolder, err := p.getMessagesPerFolder(file, rules)
+	if err != nil {
+		progress.fatal(err)
+		return
+	}
+
+	for _, folder := range messagesPerFolder {
+		progress.updateCount(folder.rule.SourceMailbox.Name, uint(len(folder.nids)))
+	}
+	progress.countsFinal()
+
+	for _, folder := range messagesPerFolder {
+		log.WithField("rule", folder.rule).Debug("Processing rule")
+		p.transferTo(file, folder, rules, progress, ch)
+		if progress.shouldStop() {
+			break
+		}
+	}
+}
+
+// Estimate returns count and size of messages in folders of active rules.
+func (p *PSTProvider) Estimate(rules transferRules) (count uint, size uint64, err error) {
+	file, err := pst.Open(p.path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close() //nolint[errcheck]
+
+	messagesPerFolder, err := p.getMessagesPerFolder(file, rules)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, folder := range messagesPerFolder {
+		for _, nid := range folder.nids {
+			messageSize, err := file.MessageSize(nid)
+			if err != nil {
+				return 0, 0, err
+			}
+			count++
+			size += messageSize
+		}
+	}
+	return count, size, nil
+}
+
+type pstFolderMessages struct {
+	rule *Rule
+	nids []uint32
+}
+
+// getMessagesPerFolder returns messages of folders with active rule
+// in the order of folders in the file.
+func (p *PSTProvider) getMessagesPerFolder(file *pst.File, rules transferRules) ([]pstFolderMessages, error) {
+	folders, err := file.Folders()
+	if err != nil {
+		return nil, err
+	}
+
+	messagesPerFolder := []pstFolderMessages{}
+	for _, folder := range folders {
+		rule, err := rules.getRuleBySourceMailboxName(pstFolderName(folder))
+		if err != nil || !rule.Active {
+			log.WithField("folder", pstFolderName(folder)).Trace("Folder skipped due to rule")
+			continue
+		}
+
+		nids, err := file.MessageIDs(folder)
+		if err != nil {
+			return nil, err
+		}
+		messagesPerFolder = append(messagesPerFolder, pstFolderMessages{rule: rule, nids: nids})
+	}
+	return messagesPerFolder, nil
+}
+
+func (p *PSTProvider) transferTo(file *pst.File, folder pstFolderMessages, rules transferRules, progress *Progress, ch chan<- Message) {
+	rule := folder.rule
+	count := 0
+	for _, nid := range folder.nids {
+		if progress.shouldStop() {
+			break
+		}
+
+		// NID is unique only in one file.
+		id := fmt.Sprintf("%s:%d", filepath.Base(p.path), nid)
+
+		if progress.skipTransferred(id, rule) {
+			count++
+			continue
+		}
+
+		pstMsg, err := file.Message(nid)
+		if err == nil && rule.HasTimeLimit() && !rule.isTimeInRange(pstMsg.Date.Unix()) {
+			log.WithField("msg", id).Debug("Message skipped due to time")
+			continue
+		}
+
+		// Counting only messages filtered by time to update count to correct total.
+		count++
+
+		var msg Message
+		if err == nil {
+			msg, err = p.exportMessage(rules, rule, id, pstMsg)
+		}
+
+		progress.addMessage(id, rule)
+		progress.messageExported(id, msg.Body, err)
+		if err == nil {
+			ch <- msg
+		}
+	}
+	progress.updateCount(rule.SourceMailbox.Name, uint(count))
+}
+
+func (p *PSTProvider) exportMessage(rules transferRules, rule *Rule, id string, pstMsg *pst.Message) (Message, error) {
+	body, err := buildPSTMessage(pstMsg)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		ID:      id,
+		Unread:  !pstMsg.Read,
+		Body:    body,
+		Source:  rule.SourceMailbox,
+		Targets: append(append([]Mailbox{}, rule.TargetMailboxes...), categoryTargets(rules, pstMsg.Categories)...),
+		Starred: pstMsg.Flagged,
+		Draft:   pstMsg.Unsent,
+	}, nil
+}
+
+// categoryTargets returns labels from active rules of categories.
+// Exclusive targets are ignored as the folder decides where the message goes.
+func categoryTargets(rules transferRules, categories []string) (targets []Mailbox) {
+	for _, category := range categories {
+		rule, err := rules.getRuleBySourceMailboxName(category)
+		if err != nil || !rule.Active || rule.SourceMailbox.IsExclusive {
+			continue
+		}
+		for _, mailbox := range rule.TargetMailboxes {
+			if !mailbox.IsExclusive {
+				targets = append(targets, mailbox)
+			}
+		}
+	}
+	return targets
+}
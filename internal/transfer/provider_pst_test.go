@@ -0,0 +1,194 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/ProtonMail/proton-bridge/pkg/pst"
+	r "github.com/stretchr/testify/require"
+)
+
+const testPSTPath = "testdata/pst/Outlook.pst"
+
+func TestIsPSTPath(t *testing.T) {
+	r.True(t, IsPSTPath("/home/user/Outlook.pst"))
+	r.True(t, IsPSTPath("backup.PST"))
+	r.False(t, IsPSTPath("/home/user/Outlook.ost"))
+	r.False(t, IsPSTPath("/home/user/pst"))
+}
+
+func TestPSTProviderMailboxes(t *testing.T) {
+	provider := NewPSTProvider(testPSTPath)
+
+	mailboxes, err := provider.Mailboxes(true, false)
+	r.NoError(t, err)
+	r.Equal(t, []Mailbox{
+		{Name: "Inbox", IsExclusive: true},
+		{Name: "Inbox/Projects", IsExclusive: true},
+		{Name: "Sent Items", IsExclusive: true},
+		{Name: "Important", Color: pmapi.LabelColors[0]},
+		{Name: "Work", Color: pmapi.LabelColors[1]},
+	}, mailboxes)
+
+	mailboxes, err = provider.Mailboxes(false, false)
+	r.NoError(t, err)
+	r.Len(t, mailboxes, 4)
+}
+
+func TestPSTProviderEstimate(t *testing.T) {
+	provider := NewPSTProvider(testPSTPath)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupPSTRules(rules)
+
+	count, size, err := provider.Estimate(rules)
+	r.NoError(t, err)
+	r.Equal(t, uint(13), count)
+	r.Equal(t, uint64(12345+12*100), size)
+}
+
+func TestPSTProviderTransferTo(t *testing.T) {
+	provider := NewPSTProvider(testPSTPath)
+
+	rules, rulesClose := newTestRules(t)
+	defer rulesClose()
+	setupPSTRules(rules)
+
+	progress := newProgress(log, nil)
+	drainProgressUpdateChannel(&progress)
+
+	ch := make(chan Message)
+	go func() {
+		provider.TransferTo(rules, &progress, ch)
+		close(ch)
+	}()
+
+	messages := []Message{}
+	for msg := range ch {
+		messages = append(messages, msg)
+	}
+	r.Empty(t, progress.GetFailedMessages())
+	r.Len(t, messages, 13)
+
+	first := messages[0]
+	r.Equal(t, "Outlook.pst:4202500", first.ID)
+	r.False(t, first.Unread)
+	r.True(t, first.Starred)
+	r.False(t, first.Draft)
+	r.Equal(t, Mailbox{Name: "Inbox", IsExclusive: true}, first.Source)
+	r.Equal(t, []Mailbox{
+		{ID: "inbox", Name: "Inbox", IsExclusive: true},
+		{ID: "work", Name: "Work"},
+	}, first.Targets)
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(first.Body))
+	r.NoError(t, err)
+	r.Equal(t, "RE: Hello", parsed.Header.Get("Subject"))
+	r.Equal(t, "Test", parsed.Header.Get("X-Mailer"))
+	r.Contains(t, parsed.Header.Get("Content-Type"), "multipart/mixed")
+
+	last := messages[len(messages)-1]
+	r.True(t, last.Unread)
+	r.False(t, last.Starred)
+	r.Equal(t, []Mailbox{{ID: "projects", Name: "Projects", IsExclusive: true}}, last.Targets)
+}
+
+func TestBuildPSTMessage(t *testing.T) {
+	date := time.Date(2020, 6, 15, 10, 30, 0, 0, time.UTC)
+	body, err := buildPSTMessage(&pst.Message{
+		Subject:   "Café",
+		From:      &mail.Address{Name: "Bob", Address: "bob@example.com"},
+		To:        []*mail.Address{{Address: "carol@example.com"}, {Name: "Dave", Address: "dave@example.com"}},
+		Date:      date,
+		MessageID: "<id@example.com>",
+		Body:      "plain",
+		HTML:      "<p>html</p>",
+		Attachments: []pst.Attachment{
+			{Name: "logo.png", ContentID: "logo", Data: []byte("PNG")},
+			{Name: "report.bin", Data: []byte("data")},
+		},
+	})
+	r.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(body))
+	r.NoError(t, err)
+
+	dec := &mime.WordDecoder{}
+	subject, err := dec.DecodeHeader(msg.Header.Get("Subject"))
+	r.NoError(t, err)
+	r.Equal(t, "Café", subject)
+	r.Equal(t, "\"Bob\" <bob@example.com>", msg.Header.Get("From"))
+	r.Equal(t, "<carol@example.com>, \"Dave\" <dave@example.com>", msg.Header.Get("To"))
+	r.Equal(t, "<id@example.com>", msg.Header.Get("Message-Id"))
+
+	msgDate, err := msg.Header.Date()
+	r.NoError(t, err)
+	r.True(t, date.Equal(msgDate))
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	r.NoError(t, err)
+	r.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var contentTypes []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		r.NoError(t, err)
+		contentTypes = append(contentTypes, strings.SplitN(part.Header.Get("Content-Type"), ";", 2)[0])
+	}
+	r.Equal(t, []string{"multipart/alternative", "image/png", "application/octet-stream"}, contentTypes)
+}
+
+func TestBuildPSTMessageWithTransportHeaders(t *testing.T) {
+	body, err := buildPSTMessage(&pst.Message{
+		Headers: "Subject: Hello\r\nContent-Type: multipart/related;\r\n\tboundary=old\r\nX-Folded: a\r\n b\r\nMIME-Version: 1.0\r\n",
+		Body:    "plain",
+	})
+	r.NoError(t, err)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(body))
+	r.NoError(t, err)
+	r.Equal(t, "Hello", msg.Header.Get("Subject"))
+	r.Equal(t, "a b", msg.Header.Get("X-Folded"))
+	r.Equal(t, "text/plain; charset=utf-8", msg.Header.Get("Content-Type"))
+	r.Len(t, msg.Header["Mime-Version"], 1)
+
+	text, err := ioutil.ReadAll(msg.Body)
+	r.NoError(t, err)
+	r.Equal(t, "plain", string(text))
+}
+
+func setupPSTRules(rules transferRules) {
+	_ = rules.setRule(Mailbox{Name: "Inbox", IsExclusive: true}, []Mailbox{{ID: "inbox", Name: "Inbox", IsExclusive: true}}, 0, 0)
+	_ = rules.setRule(Mailbox{Name: "Inbox/Projects", IsExclusive: true}, []Mailbox{{ID: "projects", Name: "Projects", IsExclusive: true}}, 0, 0)
+	_ = rules.setRule(Mailbox{Name: "Work"}, []Mailbox{{ID: "work", Name: "Work"}}, 0, 0)
+}
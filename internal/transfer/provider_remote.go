@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"net/http"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// remoteTimeout is timeout of one request to the remote storage.
+const remoteTimeout = 5 * time.Minute
+
+// remoteStorage is a storage to which files can be uploaded.
+type remoteStorage interface {
+	// id returns identifier of the storage used for transfer ID.
+	id() string
+
+	// createFolder creates folder with given name, if needed.
+	createFolder(name string) error
+
+	// upload stores file under given name (slash-separated path).
+	upload(name string, body []byte) error
+}
+
+// RemoteProvider implements export to EML files uploaded to a remote storage,
+// such as WebDAV server or S3-compatible object store, without any local copy.
+type RemoteProvider struct {
+	storage remoteStorage
+}
+
+func newRemoteHTTPClient() *http.Client {
+	return &http.Client{Timeout: remoteTimeout}
+}
+
+// ID is used for generating transfer ID by combining source and target ID.
+func (p *RemoteProvider) ID() string {
+	return p.storage.id()
+}
+
+// Mailboxes returns nothing, remote storage is used only as target.
+func (p *RemoteProvider) Mailboxes(includeEmpty, includeAllMail bool) ([]Mailbox, error) {
+	return nil, nil
+}
+
+// DefaultMailboxes returns the default mailboxes for default rules if no other is found.
+func (p *RemoteProvider) DefaultMailboxes(sourceMailbox Mailbox) []Mailbox {
+	return []Mailbox{{
+		Name: sourceMailbox.Name,
+	}}
+}
+
+// CreateMailbox does nothing. Folders are created right before the transfer.
+func (p *RemoteProvider) CreateMailbox(mailbox Mailbox) (Mailbox, error) {
+	return mailbox, nil
+}
+
+// TransferFrom uploads messages from channel.
+func (p *RemoteProvider) TransferFrom(rules transferRules, progress *Progress, ch <-chan Message) {
+	log.Info("Started transfer from channel to remote storage")
+	defer log.Info("Finished transfer from channel to remote storage")
+
+	if err := p.createFolders(rules); err != nil {
+		progress.fatal(err)
+		return
+	}
+
+	for msg := range ch {
+		if progress.shouldStop() {
+			break
+		}
+
+		err := p.uploadMessage(msg)
+		progress.messageImported(msg.ID, "", err)
+	}
+}
+
+func (p *RemoteProvider) createFolders(rules transferRules) error {
+	created := map[string]bool{}
+	for _, rule := range rules.getSortedRules() {
+		if !rule.Active {
+			continue
+		}
+		for _, mailbox := range rule.TargetMailboxes {
+			if created[mailbox.Name] {
+				continue
+			}
+			if err := p.storage.createFolder(mailbox.Name); err != nil {
+				return err
+			}
+			created[mailbox.Name] = true
+		}
+	}
+	return nil
+}
+
+func (p *RemoteProvider) uploadMessage(msg Message) error {
+	fileName := filepath.Base(msg.ID)
+	if filepath.Ext(fileName) != ".eml" {
+		fileName += ".eml"
+	}
+
+	var err error
+	for _, mailbox := range msg.Targets {
+		if localErr := p.storage.upload(path.Join(mailbox.Name, fileName), msg.Body); localErr != nil {
+			err = multierror.Append(err, localErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,197 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// S3Config holds settings of S3-compatible object store.
+type S3Config struct {
+	Endpoint  string // For example https://s3.eu-central-1.amazonaws.com.
+	Region    string
+	Bucket    string
+	Prefix    string // Optional path inside the bucket.
+	AccessKey string
+	SecretKey string
+}
+
+type s3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Provider creates RemoteProvider uploading to S3-compatible object store.
+// Path-style addressing is used, which is supported by most implementations.
+func NewS3Provider(config S3Config) (*RemoteProvider, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid S3 endpoint")
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return nil, errors.New("S3 endpoint has to start with http:// or https://")
+	}
+	if config.Bucket == "" {
+		return nil, errors.New("S3 bucket is not set")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	return &RemoteProvider{
+		storage: &s3Storage{
+			config:   config,
+			endpoint: endpoint,
+			client:   newRemoteHTTPClient(),
+		},
+	}, nil
+}
+
+func (s *s3Storage) id() string {
+	return "s3:" + s.endpoint.Host + "/" + s.config.Bucket + "/" + s.config.Prefix
+}
+
+// createFolder does nothing, object stores have no folders.
+func (s *s3Storage) createFolder(name string) error {
+	return nil
+}
+
+func (s *s3Storage) upload(name string, body []byte) error {
+	key := name
+	if s.config.Prefix != "" {
+		key = s.config.Prefix + "/" + name
+	}
+
+	reqURL := *s.endpoint
+	reqURL.Path = strings.TrimSuffix(reqURL.Path, "/") + "/" + s.config.Bucket + "/" + key
+	reqURL.RawPath = awsURIEncodePath(reqURL.Path)
+
+	req, err := http.NewRequest(http.MethodPut, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "message/rfc822")
+
+	payloadHash := sha256.Sum256(body)
+	signS3Request(req, hex.EncodeToString(payloadHash[:]), s.config.Region, s.config.AccessKey, s.config.SecretKey, time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to upload %s: %s", name, res.Status)
+	}
+	return nil
+}
+
+// signS3Request signs the request using AWS Signature Version 4.
+// All headers already set in the request are signed.
+func signS3Request(req *http.Request, payloadHash, region, accessKey, secretKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	scope := date + "/" + region + "/s3/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	headerNames := make([]string, 0, len(headers))
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	canonicalHeaders := ""
+	for _, name := range headerNames {
+		canonicalHeaders += name + ":" + headers[name] + "\n"
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		accessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncodePath encodes every byte except unreserved characters
+// and slashes as required by AWS signature.
+func awsURIEncodePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
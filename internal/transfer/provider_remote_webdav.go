@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type webDAVStorage struct {
+	baseURL  *url.URL
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAVProvider creates RemoteProvider uploading to WebDAV server.
+// Messages are stored in folders under the collection at `rawURL`.
+func NewWebDAVProvider(rawURL, username, password string) (*RemoteProvider, error) {
+	baseURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid WebDAV URL")
+	}
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, errors.New("WebDAV URL has to start with http:// or https://")
+	}
+	baseURL.Path = strings.TrimSuffix(baseURL.Path, "/")
+
+	return &RemoteProvider{
+		storage: &webDAVStorage{
+			baseURL:  baseURL,
+			username: username,
+			password: password,
+			client:   newRemoteHTTPClient(),
+		},
+	}, nil
+}
+
+func (s *webDAVStorage) id() string {
+	return "webdav:" + s.baseURL.String()
+}
+
+func (s *webDAVStorage) createFolder(name string) error {
+	// Collections have to be created one level by one.
+	folderPath := ""
+	for _, part := range strings.Split(name, "/") {
+		folderPath += "/" + part
+		res, err := s.do("MKCOL", folderPath+"/", nil)
+		if err != nil {
+			return err
+		}
+		// 405 Method Not Allowed means the collection already exists.
+		if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("failed to create folder %s: %s", folderPath, res.Status)
+		}
+	}
+	return nil
+}
+
+func (s *webDAVStorage) upload(name string, body []byte) error {
+	res, err := s.do(http.MethodPut, "/"+name, body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to upload %s: %s", name, res.Status)
+	}
+	return nil
+}
+
+func (s *webDAVStorage) do(method, filePath string, body []byte) (*http.Response, error) {
+	reqURL := *s.baseURL
+	reqURL.Path += filePath
+
+	req, err := http.NewRequest(method, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = res.Body.Close()
+	return res, nil
+}
@@ -116,6 +116,7 @@ type messageReport struct {
 	BodyHash        string
 	SourceMailbox   string
 	TargetMailboxes []string
+	Duplicate       bool
 	Error           string
 
 	// Private information for user.
@@ -132,6 +133,7 @@ func newMessageReportFromMessageStatus(messageStatus *MessageStatus, includePriv
 		BodyHash:        messageStatus.bodyHash,
 		SourceMailbox:   messageStatus.rule.SourceMailbox.Name,
 		TargetMailboxes: messageStatus.rule.TargetMailboxNames(),
+		Duplicate:       messageStatus.duplicate,
 		Error:           messageStatus.GetErrorMessage(),
 	}
 
@@ -48,6 +48,9 @@ type transferRules struct {
 	globalFromTime int64
 	globalToTime   int64
 
+	// globalFilter selects messages of all rules in the export phase.
+	globalFilter *messageFilter
+
 	// skipEncryptedMessages determines whether message which cannot
 	// be decrypted should be exported or skipped.
 	skipEncryptedMessages bool
@@ -91,6 +94,10 @@ func (r *transferRules) setGlobalTimeLimit(fromTime, toTime int64) {
 	r.globalToTime = toTime
 }
 
+func (r *transferRules) setGlobalFilter(filter *messageFilter) {
+	r.globalFilter = filter
+}
+
 func (r *transferRules) propagateGlobalTime() {
 	if r.globalFromTime == 0 && r.globalToTime == 0 {
 		return
@@ -0,0 +1,157 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// transferState keeps IDs of messages which were already transferred,
+// so paused or interrupted transfer can continue where it stopped,
+// even after restart or crash of the app. Every transferred message
+// is written to the checkpoint store right away.
+type transferState struct {
+	lock        sync.Locker
+	transferID  string
+	checkpoints *checkpointStore
+	done        map[string]bool
+}
+
+// loadState loads state of the transfer with `transferID` from the checkpoint
+// store. When the store cannot be opened, the state is kept in memory only.
+func loadState(rulesDir, transferID string) *transferState {
+	state := &transferState{
+		lock:       &sync.Mutex{},
+		transferID: transferID,
+		done:       map[string]bool{},
+	}
+
+	checkpoints, err := openCheckpointStore(rulesDir)
+	if err != nil {
+		log.WithError(err).Warn("Problem to open checkpoint store, progress will not be persisted")
+		return state
+	}
+	state.checkpoints = checkpoints
+
+	if done, err := checkpoints.load(transferID); err != nil {
+		log.WithError(err).Warn("Problem to read transfer state")
+	} else {
+		state.done = done
+	}
+
+	state.migrateLegacyState(rulesDir)
+
+	return state
+}
+
+// migrateLegacyState moves IDs from JSON state file used by older versions
+// to the checkpoint store so unfinished transfer can continue after update.
+func (s *transferState) migrateLegacyState(rulesDir string) {
+	filePath := filepath.Join(rulesDir, fmt.Sprintf("state_%s.json", s.transferID))
+
+	f, err := os.Open(filePath) //nolint[gosec]
+	if err != nil {
+		return
+	}
+
+	var done map[string]bool
+	err = json.NewDecoder(f).Decode(&done)
+	_ = f.Close()
+	if err != nil {
+		log.WithError(err).Warn("Problem to unmarshal legacy transfer state")
+		return
+	}
+
+	messageIDs := make([]string, 0, len(done))
+	for messageID := range done {
+		messageIDs = append(messageIDs, messageID)
+		s.done[messageID] = true
+	}
+	if err := s.checkpoints.markDone(s.transferID, messageIDs...); err != nil {
+		log.WithError(err).Warn("Problem to migrate legacy transfer state")
+		return
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		log.WithError(err).Warn("Problem to remove legacy transfer state")
+	}
+}
+
+func (s *transferState) count() int {
+	if s == nil {
+		return 0
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.done)
+}
+
+func (s *transferState) isDone(messageID string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.done[messageID]
+}
+
+func (s *transferState) markDone(messageID string) {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.done[messageID] = true
+
+	if s.checkpoints == nil {
+		return
+	}
+	if err := s.checkpoints.markDone(s.transferID, messageID); err != nil {
+		log.WithError(err).Warn("Problem to write transfer state")
+	}
+}
+
+// clear forgets all transferred messages, e.g., once the transfer
+// is finished, and removes them from the checkpoint store.
+func (s *transferState) clear() {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.done = map[string]bool{}
+
+	if s.checkpoints == nil {
+		return
+	}
+	if err := s.checkpoints.clear(s.transferID); err != nil {
+		log.WithError(err).Warn("Problem to remove transfer state")
+	}
+}
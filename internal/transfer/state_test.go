@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestTransferState(t *testing.T) {
+	path, err := ioutil.TempDir("", "state")
+	r.NoError(t, err)
+	defer os.RemoveAll(path) //nolint[errcheck]
+
+	state := loadState(path, "transfer")
+	r.Equal(t, 0, state.count())
+
+	state.markDone("msg1")
+	state.markDone("msg2")
+	r.True(t, state.isDone("msg1"))
+	r.False(t, state.isDone("msg3"))
+
+	// Saved right away.
+	state2 := loadState(path, "transfer")
+	r.Equal(t, 2, state2.count())
+	r.True(t, state2.isDone("msg2"))
+
+	state2.clear()
+	r.Equal(t, 0, state2.count())
+	r.Equal(t, 0, loadState(path, "transfer").count())
+}
+
+func TestTransferStateMigratesLegacyFile(t *testing.T) {
+	path, err := ioutil.TempDir("", "state")
+	r.NoError(t, err)
+	defer os.RemoveAll(path) //nolint[errcheck]
+
+	legacyPath := filepath.Join(path, "state_transfer.json")
+	r.NoError(t, ioutil.WriteFile(legacyPath, []byte(`{"msg1":true,"msg2":true}`), 0600))
+
+	state := loadState(path, "transfer")
+	r.Equal(t, 2, state.count())
+	r.NoFileExists(t, legacyPath)
+
+	r.True(t, loadState(path, "transfer").isDone("msg1"))
+}
+
+func TestProgressContinuesFromState(t *testing.T) {
+	path, err := ioutil.TempDir("", "state")
+	r.NoError(t, err)
+	defer os.RemoveAll(path) //nolint[errcheck]
+
+	progress := newProgress(log, nil)
+	progress.state = loadState(path, "transfer")
+	drainProgressUpdateChannel(&progress)
+
+	progress.addMessage("msg1", nil)
+	progress.messageExported("msg1", []byte(""), nil)
+	progress.messageImported("msg1", "", nil)
+	progress.Stop()
+	progress.finish()
+
+	progress2 := newProgress(log, nil)
+	progress2.state = loadState(path, "transfer")
+	drainProgressUpdateChannel(&progress2)
+
+	r.True(t, progress2.skipTransferred("msg1", nil))
+	r.False(t, progress2.skipTransferred("msg2", nil))
+	progress2.addMessage("msg2", nil)
+	progress2.messageExported("msg2", []byte(""), nil)
+	progress2.messageImported("msg2", "", nil)
+	progress2.finish()
+
+	failed, imported, exported, added, _ := progress2.GetCounts()
+	r.Equal(t, []uint{0, 2, 2, 2}, []uint{failed, imported, exported, added})
+
+	// Finished transfer starts from scratch next time.
+	r.Equal(t, 0, loadState(path, "transfer").count())
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transfer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ThrottlingProfile describes how hard the import and export push
+// the ProtonMail API.
+type ThrottlingProfile struct {
+	Name string
+
+	// ImportBatchMaxItems is the maximum number of messages in one import request.
+	ImportBatchMaxItems int
+	// ImportBatchMaxSize is the maximum size of one import request in bytes.
+	ImportBatchMaxSize int
+	// ListPageSize is the number of messages listed in one request during export.
+	ListPageSize int
+	// RequestDelay is the pause before every API request.
+	RequestDelay time.Duration
+}
+
+// Throttling profiles ordered from the most aggressive one to the gentlest.
+var (
+	ThrottlingAggressive = ThrottlingProfile{ //nolint[gochecknoglobals]
+		Name:                "aggressive",
+		ImportBatchMaxItems: pmapiImportBatchMaxItems,
+		ImportBatchMaxSize:  pmapiImportBatchMaxSize,
+		ListPageSize:        pmapiListPageSize,
+		RequestDelay:        0,
+	}
+	ThrottlingNormal = ThrottlingProfile{ //nolint[gochecknoglobals]
+		Name:                "normal",
+		ImportBatchMaxItems: pmapiImportBatchMaxItems,
+		ImportBatchMaxSize:  pmapiImportBatchMaxSize,
+		ListPageSize:        pmapiListPageSize,
+		RequestDelay:        200 * time.Millisecond,
+	}
+	ThrottlingGentle = ThrottlingProfile{ //nolint[gochecknoglobals]
+		Name:                "gentle",
+		ImportBatchMaxItems: 5,
+		ImportBatchMaxSize:  10 * 1000 * 1000, // 10 MB
+		ListPageSize:        50,
+		RequestDelay:        time.Second,
+	}
+
+	throttlingProfiles = []ThrottlingProfile{ThrottlingAggressive, ThrottlingNormal, ThrottlingGentle} //nolint[gochecknoglobals]
+)
+
+// ThrottlingProfileNames returns names of all available profiles.
+func ThrottlingProfileNames() []string {
+	names := []string{}
+	for _, profile := range throttlingProfiles {
+		names = append(names, profile.Name)
+	}
+	return names
+}
+
+// GetThrottlingProfile returns the profile with the given name.
+func GetThrottlingProfile(name string) (ThrottlingProfile, error) {
+	for _, profile := range throttlingProfiles {
+		if profile.Name == strings.ToLower(name) {
+			return profile, nil
+		}
+	}
+	return ThrottlingProfile{}, fmt.Errorf("unknown throttling profile %q, use one of %s", name, strings.Join(ThrottlingProfileNames(), ", "))
+}
+
+// gentler returns the next gentler profile, or the same one if there
+// is none gentler.
+func (p ThrottlingProfile) gentler() ThrottlingProfile {
+	for i, profile := range throttlingProfiles {
+		if profile.Name == p.Name && i+1 < len(throttlingProfiles) {
+			return throttlingProfiles[i+1]
+		}
+	}
+	return p
+}
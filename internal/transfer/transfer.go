@@ -36,6 +36,7 @@ type Transfer struct {
 	id              string
 	logDir          string
 	rules           transferRules
+	state           *transferState
 	source          SourceProvider
 	target          TargetProvider
 	rulesCache      []*Rule
@@ -57,6 +58,7 @@ func New(panicHandler PanicHandler, metrics MetricsManager, logDir, rulesDir str
 		id:           transferID,
 		logDir:       logDir,
 		rules:        rules,
+		state:        loadState(rulesDir, transferID),
 		source:       source,
 		target:       target,
 	}
@@ -95,6 +97,52 @@ func (t *Transfer) SetSkipEncryptedMessages(skip bool) {
 	t.rules.setSkipEncryptedMessages(skip)
 }
 
+// SetNameNormalization sets how names of mailboxes created at the target
+// are normalized. It has effect only for targets with naming rules.
+func (t *Transfer) SetNameNormalization(normalization NameNormalization) {
+	if target, ok := t.target.(nameNormalizer); ok {
+		target.SetNameNormalization(normalization)
+	}
+}
+
+// SetDateLayout sets whether exported files are organized into `YYYY/MM`
+// subfolders. It has effect only for targets storing files in folders.
+func (t *Transfer) SetDateLayout(enabled bool) {
+	if target, ok := t.target.(dateLayouter); ok {
+		target.SetDateLayout(enabled)
+	}
+}
+
+// SetChecksums sets whether exported messages record a canonical hash
+// and imported messages are verified against it after upload.
+func (t *Transfer) SetChecksums(enabled bool) {
+	if source, ok := t.source.(checksummer); ok {
+		source.SetChecksums(enabled)
+	}
+	if target, ok := t.target.(checksummer); ok {
+		target.SetChecksums(enabled)
+	}
+}
+
+// SetDeduplication sets whether imported messages already present in
+// the target are skipped. Hashes of imported messages are kept across
+// transfers, so running the same import again does not duplicate them.
+func (t *Transfer) SetDeduplication(enabled bool) error {
+	target, ok := t.target.(deduplicator)
+	if !ok {
+		if enabled {
+			return ErrDeduplicationNotSupported
+		}
+		return nil
+	}
+	if !enabled {
+		target.setDedupIndex(nil)
+		return nil
+	}
+	target.setDedupIndex(loadDedupIndex(t.state.checkpoints, t.target.ID()))
+	return nil
+}
+
 // SetGlobalMailbox sets mailbox that is applied to every message in
 // the import phase.
 func (t *Transfer) SetGlobalMailbox(mailbox *Mailbox) {
@@ -107,6 +155,29 @@ func (t *Transfer) SetGlobalTimeLimit(fromTime, toTime int64) {
 	t.rules.setGlobalTimeLimit(fromTime, toTime)
 }
 
+// SetMessageFilter sets filter applied to messages of all rules. Empty
+// filter removes it. Only export from ProtonMail can be filtered.
+func (t *Transfer) SetMessageFilter(filter MessageFilter) error {
+	if filter.IsEmpty() {
+		t.rules.setGlobalFilter(nil)
+		return nil
+	}
+	if _, ok := t.source.(*PMAPIProvider); !ok {
+		return ErrFilterNotSupported
+	}
+
+	mailboxes, err := t.SourceMailboxes()
+	if err != nil {
+		return err
+	}
+	compiled, err := newMessageFilter(filter, mailboxes)
+	if err != nil {
+		return err
+	}
+	t.rules.setGlobalFilter(compiled)
+	return nil
+}
+
 // SetRule sets sourceMailbox for transfer.
 func (t *Transfer) SetRule(sourceMailbox Mailbox, targetMailboxes []Mailbox, fromTime, toTime int64) error {
 	t.rulesCache = nil
@@ -169,6 +240,30 @@ func (t *Transfer) ChangeTarget(target TargetProvider) {
 	t.target = target
 }
 
+// HasSavedState returns whether there is a saved state of the previously
+// paused or interrupted transfer which will be continued by Start.
+func (t *Transfer) HasSavedState() bool {
+	return t.state.count() > 0
+}
+
+// ResetState forgets the saved state so the next Start transfers
+// everything again.
+func (t *Transfer) ResetState() {
+	t.state.clear()
+}
+
+// Estimate enumerates active rules at the source and returns the total
+// count and approximate size of messages and the expected duration.
+func (t *Transfer) Estimate() (Estimate, error) {
+	t.rules.propagateGlobalTime()
+
+	count, size, err := t.source.Estimate(t.rules)
+	if err != nil {
+		return Estimate{}, err
+	}
+	return newEstimate(count, size), nil
+}
+
 // Start starts the transfer from source to target.
 func (t *Transfer) Start() *Progress {
 	log.Debug("Transfer started")
@@ -180,6 +275,7 @@ func (t *Transfer) Start() *Progress {
 	log := log.WithField("id", t.id)
 	reportFile := newFileReport(t.logDir, t.id)
 	progress := newProgress(log, reportFile)
+	progress.state = t.state
 
 	ch := make(chan Message)
 
@@ -20,6 +20,7 @@ package transfer
 import (
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	transfermocks "github.com/ProtonMail/proton-bridge/internal/transfer/mocks"
@@ -55,6 +56,7 @@ func initMocks(t *testing.T) mocks {
 	}
 
 	m.clientManager.EXPECT().GetClient("user").Return(m.pmapiClient).AnyTimes()
+	m.clientManager.EXPECT().GetRateLimitDelay().Return(time.Duration(0)).AnyTimes()
 
 	return m
 }
@@ -18,6 +18,8 @@
 package transfer
 
 import (
+	"time"
+
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 )
 
@@ -36,4 +38,5 @@ type MetricsManager interface {
 type ClientManager interface {
 	GetClient(userID string) pmapi.Client
 	CheckConnection() error
+	GetRateLimitDelay() time.Duration
 }
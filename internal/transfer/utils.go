@@ -20,12 +20,15 @@ package transfer
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"net/mail"
 	"net/textproto"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -48,6 +51,16 @@ func getFolderNamesWithFileSuffix(root, fileSuffix string) ([]string, error) {
 
 	hasFileWithSuffix := fileSuffix == ""
 	for _, file := range files {
+		if file.IsDir() && isYearFolderName(file.Name()) {
+			hasFile, err := hasFileWithSuffixInMonthFolders(filepath.Join(root, file.Name()), fileSuffix)
+			if err != nil {
+				return nil, err
+			}
+			if hasFile {
+				hasFileWithSuffix = true
+				continue
+			}
+		}
 		if file.IsDir() {
 			subfolders, err := getFolderNamesWithFileSuffix(filepath.Join(root, file.Name()), fileSuffix)
 			if err != nil {
@@ -78,6 +91,68 @@ func getFolderNamesWithFileSuffix(root, fileSuffix string) ([]string, error) {
 	return folders, nil
 }
 
+// getDateFolder returns `YYYY/MM` subfolder for the message based on its
+// date, or empty string if the message has no date.
+func getDateFolder(body []byte) string {
+	msgTime, err := getMessageTime(body)
+	if err != nil || msgTime == 0 {
+		return ""
+	}
+	t := time.Unix(msgTime, 0).UTC()
+	return filepath.Join(fmt.Sprintf("%04d", t.Year()), fmt.Sprintf("%02d", t.Month()))
+}
+
+// getMailboxFolderName returns name of the mailbox folder for the directory
+// of a file, skipping `YYYY/MM` subfolders of the date-based layout.
+func getMailboxFolderName(dir string) string {
+	month := filepath.Base(dir)
+	year := filepath.Base(filepath.Dir(dir))
+	if isMonthFolderName(month) && isYearFolderName(year) {
+		return filepath.Base(filepath.Dir(filepath.Dir(dir)))
+	}
+	return filepath.Base(dir)
+}
+
+func isYearFolderName(name string) bool {
+	if len(name) != 4 {
+		return false
+	}
+	_, err := strconv.ParseUint(name, 10, 16)
+	return err == nil
+}
+
+func isMonthFolderName(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	month, err := strconv.Atoi(name)
+	return err == nil && month >= 1 && month <= 12
+}
+
+// hasFileWithSuffixInMonthFolders returns whether any month folder of
+// the year folder contains a file with the suffix.
+func hasFileWithSuffixInMonthFolders(yearFolder, fileSuffix string) (bool, error) {
+	months, err := ioutil.ReadDir(yearFolder)
+	if err != nil {
+		return false, err
+	}
+	for _, month := range months {
+		if !month.IsDir() || !isMonthFolderName(month.Name()) {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(yearFolder, month.Name()))
+		if err != nil {
+			return false, err
+		}
+		for _, file := range files {
+			if !file.IsDir() && (fileSuffix == "" || strings.HasSuffix(file.Name(), fileSuffix)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
 // getFilePathsWithSuffix collects all file names with `suffix` under `root`.
 // File names will be with relative path based to `root`.
 func getFilePathsWithSuffix(root, suffix string) ([]string, error) {
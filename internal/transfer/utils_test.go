@@ -71,6 +71,13 @@ func TestGetFolderNames(t *testing.T) {
 	}
 }
 
+func TestGetMailboxFolderName(t *testing.T) {
+	r.Equal(t, "Foo", getMailboxFolderName(filepath.Join("root", "Foo")))
+	r.Equal(t, "Foo", getMailboxFolderName(filepath.Join("root", "Foo", "2020", "05")))
+	r.Equal(t, "13", getMailboxFolderName(filepath.Join("root", "Foo", "2020", "13")))
+	r.Equal(t, "05", getMailboxFolderName(filepath.Join("root", "Foo", "05")))
+}
+
 func TestGetFilePathsWithSuffix(t *testing.T) {
 	root, clean := createTestingFolderStructure(t)
 	defer clean()
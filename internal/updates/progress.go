@@ -35,6 +35,9 @@ type Progress struct {
 }
 
 func (s *Progress) Update() {
+	if s.channel == nil {
+		return
+	}
 	s.channel <- *s
 }
 
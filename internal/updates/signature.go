@@ -19,12 +19,14 @@ package updates
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 
 	"golang.org/x/crypto/openpgp"
 )
@@ -94,6 +96,40 @@ func verifyBytes(fileReader, signatureReader io.Reader) (err error) {
 	return
 }
 
+// verifyChecksum checks that file has the given SHA-256 checksum.
+// Empty checksum is accepted for version files created before checksums were added.
+func verifyChecksum(pathToFile, expectedChecksum string) error {
+	if expectedChecksum == "" {
+		return nil
+	}
+
+	checksum, err := fileChecksum(pathToFile)
+	if err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(checksum, expectedChecksum) {
+		return ErrChecksumMismatch
+	}
+
+	return nil
+}
+
+func fileChecksum(pathToFile string) (string, error) {
+	f, err := os.Open(pathToFile) //nolint[gosec]
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // from opengpg/read_test.go
 func getPubKey() (el openpgp.EntityList, err error) {
 	if pubkeyRing != nil && len(pubkeyRing) != 0 {
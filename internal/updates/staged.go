@@ -0,0 +1,126 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package updates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+const (
+	stagedVersionFileName = "staged_version"
+
+	// stageCheckInterval is how often the new version is looked for
+	// when silent updates are enabled.
+	stageCheckInterval = 6 * time.Hour
+)
+
+// StageUpdate downloads, verifies and unpacks the latest version, if it is
+// newer and rolled out for this install, so it can be applied on next start
+// by ApplyStagedUpdate. It returns whether a new version was staged.
+func (u *Updates) StageUpdate() (bool, error) {
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		log.Debug("Staged updates are not supported on ", runtime.GOOS)
+		return false, nil
+	}
+
+	isUpToDate, latestVersion, err := u.CheckIsUpToDate()
+	if err != nil || isUpToDate {
+		return false, err
+	}
+
+	if !u.IsRolledOut(latestVersion) {
+		log.WithField("version", latestVersion.Version).Debug("Newer version is not rolled out for this install yet")
+		return false, nil
+	}
+
+	if u.getStagedVersion() == latestVersion.Version {
+		return false, nil
+	}
+
+	if err := u.downloadAndUnpack(&Progress{}, latestVersion, u.stageDir()); err != nil {
+		return false, err
+	}
+
+	if err := ioutil.WriteFile(u.stagedVersionPath(), []byte(latestVersion.Version), 0600); err != nil {
+		return false, err
+	}
+
+	log.WithField("version", latestVersion.Version).Info("Update staged, it will be applied on next start")
+	return true, nil
+}
+
+// StageUpdatesInBackground periodically stages new versions while isEnabled
+// returns true. It blocks forever.
+func (u *Updates) StageUpdatesInBackground(isEnabled func() bool) {
+	for {
+		if isEnabled() {
+			if _, err := u.StageUpdate(); err != nil {
+				log.WithError(err).Warn("Cannot stage update")
+			}
+		}
+		time.Sleep(stageCheckInterval)
+	}
+}
+
+// ApplyStagedUpdate installs the version staged by StageUpdate, if any.
+// When applied is true, the app should quit (or restart when restart is true)
+// to let the update finish.
+func (u *Updates) ApplyStagedUpdate() (applied, restart bool, err error) {
+	stagedVersion := u.getStagedVersion()
+	if stagedVersion == "" {
+		return false, false, nil
+	}
+
+	// Staged update is applied only once, even when it fails,
+	// so a broken update cannot block every start of the app.
+	if err := os.Remove(u.stagedVersionPath()); err != nil {
+		return false, false, err
+	}
+
+	if isNewer, _ := isFirstVersionNewer(stagedVersion, u.version); !isNewer {
+		log.WithField("version", stagedVersion).Debug("Staged version is not newer, skipping")
+		return false, false, nil
+	}
+
+	log.WithField("version", stagedVersion).Info("Applying staged update")
+
+	status := &Progress{}
+	u.install(status, u.stageDir())
+	if status.Err != nil {
+		return false, false, status.Err
+	}
+
+	return true, status.Description == InfoRestartApp, nil
+}
+
+func (u *Updates) getStagedVersion() string {
+	version, err := ioutil.ReadFile(u.stagedVersionPath())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(version))
+}
+
+func (u *Updates) stagedVersionPath() string {
+	return filepath.Join(u.stageDir(), stagedVersionFileName)
+}
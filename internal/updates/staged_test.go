@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package updates
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyStagedUpdateNothingStaged(t *testing.T) {
+	updates := newTestUpdates("1.1.5")
+	updates.updateTempDir = newTestStageDir(t)
+	defer os.RemoveAll(updates.updateTempDir) //nolint[errcheck]
+
+	applied, restart, err := updates.ApplyStagedUpdate()
+	require.NoError(t, err)
+	require.False(t, applied)
+	require.False(t, restart)
+}
+
+func TestApplyStagedUpdateOlderVersion(t *testing.T) {
+	updates := newTestUpdates("1.1.6")
+	updates.updateTempDir = newTestStageDir(t)
+	defer os.RemoveAll(updates.updateTempDir) //nolint[errcheck]
+	require.NoError(t, os.MkdirAll(updates.stageDir(), 0700))
+	require.NoError(t, ioutil.WriteFile(updates.stagedVersionPath(), []byte("1.1.5\n"), 0600))
+	require.Equal(t, "1.1.5", updates.getStagedVersion())
+
+	applied, _, err := updates.ApplyStagedUpdate()
+	require.NoError(t, err)
+	require.False(t, applied)
+
+	// Staged version is used only once.
+	require.Equal(t, "", updates.getStagedVersion())
+}
+
+func newTestStageDir(t *testing.T) string {
+	dir, err := ioutil.TempDir(testUpdateDir, "stage")
+	require.NoError(t, err)
+	return dir
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package updates
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+)
+
+const tlsPinsFileName = "tls_pins.json"
+
+// ErrOldTLSPinManifest is returned when the downloaded manifest has lower
+// version than the one applied before, e.g. when a mirror serves an old copy.
+var ErrOldTLSPinManifest = errors.New("TLS pin manifest is older than the stored one") //nolint[gochecknoglobals]
+
+// LoadTLSPinsOverride applies TLS pins from the local override file, if it
+// exists. The file is meant for administrators and is therefore not signed.
+func (u *Updates) LoadTLSPinsOverride(path string) (loaded bool, err error) {
+	data, err := ioutil.ReadFile(path) //nolint[gosec]
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	manifest, err := pmapi.ParseTLSPinManifest(data)
+	if err != nil {
+		return false, err
+	}
+
+	log.WithField("path", path).Warn("Using unsigned local TLS pin override")
+	applyTLSPins(manifest)
+	return true, nil
+}
+
+// LoadStoredTLSPins applies the signed manifest stored by the last successful
+// UpdateTLSPins, so rotated pins are in use before the update server is reached.
+func (u *Updates) LoadStoredTLSPins(path string) error {
+	manifest, err := loadStoredTLSPins(path)
+	if err != nil || manifest == nil {
+		return err
+	}
+
+	applyTLSPins(manifest)
+	return nil
+}
+
+// UpdateTLSPins downloads the signed manifest of trusted TLS pins from the
+// update server or its mirrors and applies it when it is newer than the one
+// stored in path. Built-in or stored pins stay in use when it fails.
+func (u *Updates) UpdateTLSPins(path string) (err error) {
+	var data, signature []byte
+	for _, manifestURL := range u.mirrorURLs(tlsPinsFileURL()) {
+		if data, err = downloadToBytes(manifestURL); err != nil {
+			log.WithError(err).WithField("url", manifestURL).Warn("Cannot download TLS pin manifest")
+			continue
+		}
+		if signature, err = downloadToBytes(manifestURL + sigExtension); err != nil {
+			log.WithError(err).WithField("url", manifestURL).Warn("Cannot download TLS pin manifest signature")
+			continue
+		}
+		break
+	}
+	if err != nil {
+		return err
+	}
+
+	manifest, err := parseSignedTLSPins(data, signature)
+	if err != nil {
+		return err
+	}
+
+	storedVersion := 0
+	if stored, err := loadStoredTLSPins(path); err != nil {
+		log.WithError(err).Warn("Ignoring invalid stored TLS pin manifest")
+	} else if stored != nil {
+		storedVersion = stored.Version
+	}
+
+	if isNew, err := isNewTLSPinManifest(manifest, storedVersion); err != nil || !isNew {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path+sigExtension, signature, 0600); err != nil {
+		return err
+	}
+
+	applyTLSPins(manifest)
+	return nil
+}
+
+// isNewTLSPinManifest reports whether the manifest should replace the stored
+// one. The same version is not an error; mirrors keep serving the latest one.
+func isNewTLSPinManifest(manifest *pmapi.TLSPinManifest, storedVersion int) (bool, error) {
+	if manifest.Version < storedVersion {
+		return false, ErrOldTLSPinManifest
+	}
+	return manifest.Version > storedVersion, nil
+}
+
+// loadStoredTLSPins returns nil when no manifest was stored yet. The stored
+// manifest is verified again so the file cannot be used as an unsigned override.
+func loadStoredTLSPins(path string) (*pmapi.TLSPinManifest, error) {
+	data, err := ioutil.ReadFile(path) //nolint[gosec]
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := ioutil.ReadFile(path + sigExtension) //nolint[gosec]
+	if err != nil {
+		return nil, err
+	}
+
+	return parseSignedTLSPins(data, signature)
+}
+
+func parseSignedTLSPins(data, signature []byte) (*pmapi.TLSPinManifest, error) {
+	if err := verifyBytes(bytes.NewReader(data), bytes.NewReader(signature)); err != nil {
+		return nil, err
+	}
+	return pmapi.ParseTLSPinManifest(data)
+}
+
+func applyTLSPins(manifest *pmapi.TLSPinManifest) {
+	log.WithField("version", manifest.Version).WithField("count", len(manifest.Pins)).Info("Updating trusted TLS pins")
+	pmapi.UpdateTrustedAPIPins(manifest.Pins)
+}
+
+func tlsPinsFileURL() string {
+	return strings.Join([]string{Host, DownloadPath, tlsPinsFileName}, "/")
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package updates
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTLSPinsOverride(t *testing.T) {
+	defer pmapi.UpdateTrustedAPIPins(nil)
+
+	dir, err := ioutil.TempDir("", "tls-pins")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	path := filepath.Join(dir, tlsPinsFileName)
+	updates := newTestUpdates("1")
+
+	loaded, err := updates.LoadTLSPinsOverride(path)
+	require.NoError(t, err)
+	require.False(t, loaded)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"Pins": ["pin-sha256=\"drtmcR2kFkM8qJClsuWgUzxgBkePfRCkRpqUesyDmeE=\""]}`), 0600))
+	loaded, err = updates.LoadTLSPinsOverride(path)
+	require.NoError(t, err)
+	require.True(t, loaded)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"Pins": []}`), 0600))
+	_, err = updates.LoadTLSPinsOverride(path)
+	require.Error(t, err)
+}
+
+func TestLoadStoredTLSPinsMissing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-pins")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	path := filepath.Join(dir, tlsPinsFileName)
+	require.NoError(t, newTestUpdates("1").LoadStoredTLSPins(path))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"Version": 1, "Pins": ["pin-sha256=\"drtmcR2kFkM8qJClsuWgUzxgBkePfRCkRpqUesyDmeE=\""]}`), 0600))
+	require.NoError(t, ioutil.WriteFile(path+sigExtension, []byte("not a signature"), 0600))
+	require.Error(t, newTestUpdates("1").LoadStoredTLSPins(path))
+}
+
+func TestIsNewTLSPinManifest(t *testing.T) {
+	manifest := &pmapi.TLSPinManifest{Version: 2}
+
+	isNew, err := isNewTLSPinManifest(manifest, 1)
+	require.NoError(t, err)
+	require.True(t, isNew)
+
+	isNew, err = isNewTLSPinManifest(manifest, 2)
+	require.NoError(t, err)
+	require.False(t, isNew)
+
+	_, err = isNewTLSPinManifest(manifest, 3)
+	require.Equal(t, ErrOldTLSPinManifest, err)
+}
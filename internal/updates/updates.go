@@ -51,6 +51,7 @@ var (
 
 	ErrDownloadFailed     = errors.New("error happened during download") //nolint[gochecknoglobals]
 	ErrUpdateVerifyFailed = errors.New("cannot verify signature")        //nolint[gochecknoglobals]
+	ErrChecksumMismatch   = errors.New("checksum does not match")        //nolint[gochecknoglobals]
 )
 
 type Updates struct {
@@ -69,6 +70,8 @@ type Updates struct {
 	linuxFileBaseName   string       // Prefix of linux package names.
 	macAppBundleName    string       // Name of Mac app file in the bundle for update procedure.
 	cachedNewerVersion  *VersionInfo // To have info about latest version even when the internet connection drops.
+	rolloutBucket       int          // Number in range [0, 100) deciding whether this install is part of staged rollout.
+	mirrors             []string     // Alternative hosts used when Host is not reachable.
 }
 
 // NewBridge inits Updates struct for bridge.
@@ -118,6 +121,14 @@ func (u *Updates) CreateJSONAndSign(deployDir, goos string) error {
 	versionFileName := filepath.Base(u.versionFileURL(goos))
 	versionFilePath := filepath.Join(deployDir, versionFileName)
 
+	updateFileName := filepath.Base(versionInfo.UpdateFile)
+	updateFilePath := filepath.Join(deployDir, updateFileName)
+	checksum, err := fileChecksum(updateFilePath)
+	if err != nil {
+		return err
+	}
+	versionInfo.UpdateFileSHA256 = checksum
+
 	txt, err := json.Marshal(versionInfo)
 	if err != nil {
 		return err
@@ -131,8 +142,6 @@ func (u *Updates) CreateJSONAndSign(deployDir, goos string) error {
 		return err
 	}
 
-	updateFileName := filepath.Base(versionInfo.UpdateFile)
-	updateFilePath := filepath.Join(deployDir, updateFileName)
 	if err := singAndVerify(updateFilePath); err != nil {
 		return err
 	}
@@ -140,6 +149,9 @@ func (u *Updates) CreateJSONAndSign(deployDir, goos string) error {
 	return nil
 }
 
+// CheckIsUpToDate returns whether the local version is the latest one.
+// Newer version can be still in staged rollout; use IsRolledOut before
+// offering it to the user.
 func (u *Updates) CheckIsUpToDate() (isUpToDate bool, latestVersion VersionInfo, err error) {
 	localVersion := u.GetLocalVersion()
 	latestVersion, err = u.getLatestVersion()
@@ -151,6 +163,18 @@ func (u *Updates) CheckIsUpToDate() (isUpToDate bool, latestVersion VersionInfo,
 	return !localIsOld, latestVersion, err
 }
 
+// IsRolledOut returns whether the version is offered to this install already.
+func (u *Updates) IsRolledOut(version VersionInfo) bool {
+	return version.IsInRollout(u.rolloutBucket)
+}
+
+// SetRolloutBucket sets number in range [0, 100) which is compared with
+// rollout percentage of new versions. It should be random but stable
+// for one install so the same installs get the update first.
+func (u *Updates) SetRolloutBucket(bucket int) {
+	u.rolloutBucket = bucket
+}
+
 func (u *Updates) GetDownloadLink() string {
 	latestVersion, err := u.getLatestVersion()
 	if err != nil || latestVersion.InstallerFile == "" {
@@ -201,16 +225,43 @@ func (u *Updates) getLocalVersion(goos string) VersionInfo {
 	return versionInfo
 }
 
-func (u *Updates) getLatestVersion() (latestVersion VersionInfo, err error) {
-	version, err := downloadToBytes(u.versionFileURL(runtime.GOOS))
-	if err != nil {
-		if u.cachedNewerVersion != nil {
-			return *u.cachedNewerVersion, nil
+// SetMirrors sets alternative hosts (e.g. `https://mirror.example.com`)
+// which replace Host when it cannot be reached. Files downloaded from mirrors
+// are verified the same way as those from Host.
+func (u *Updates) SetMirrors(mirrors []string) {
+	u.mirrors = nil
+	for _, mirror := range mirrors {
+		if mirror = strings.TrimSuffix(strings.TrimSpace(mirror), "/"); mirror != "" {
+			u.mirrors = append(u.mirrors, mirror)
 		}
-		return
 	}
+}
 
-	signature, err := downloadToBytes(u.signatureFileURL(runtime.GOOS))
+// mirrorURLs returns the URL followed by the same URL on all mirrors.
+func (u *Updates) mirrorURLs(url string) []string {
+	urls := []string{url}
+	if !strings.HasPrefix(url, Host+"/") {
+		return urls
+	}
+	for _, mirror := range u.mirrors {
+		urls = append(urls, mirror+strings.TrimPrefix(url, Host))
+	}
+	return urls
+}
+
+func (u *Updates) getLatestVersion() (latestVersion VersionInfo, err error) {
+	var version, signature []byte
+	for _, versionURL := range u.mirrorURLs(u.versionFileURL(runtime.GOOS)) {
+		if version, err = downloadToBytes(versionURL); err != nil {
+			log.WithError(err).WithField("url", versionURL).Warn("Cannot download version file")
+			continue
+		}
+		if signature, err = downloadToBytes(versionURL + sigExtension); err != nil {
+			log.WithError(err).WithField("url", versionURL).Warn("Cannot download version file signature")
+			continue
+		}
+		break
+	}
 	if err != nil {
 		if u.cachedNewerVersion != nil {
 			return *u.cachedNewerVersion, nil
@@ -235,10 +286,6 @@ func (u *Updates) landingPageURL() string {
 	return strings.Join([]string{Host, u.landingPagePath}, "/")
 }
 
-func (u *Updates) signatureFileURL(goos string) string {
-	return u.versionFileURL(goos) + sigExtension
-}
-
 func (u *Updates) versionFileURL(goos string) string {
 	return strings.Join([]string{Host, DownloadPath, u.versionFileBaseName + "_" + goos + ".json"}, "/")
 }
@@ -258,7 +305,7 @@ func (u *Updates) updateFileURL(goos string) string {
 	return strings.Join([]string{Host, DownloadPath, u.updateFileBaseName + "_" + goos + ".tgz"}, "/")
 }
 
-func (u *Updates) StartUpgrade(currentStatus chan<- Progress) { // nolint[funlen]
+func (u *Updates) StartUpgrade(currentStatus chan<- Progress) {
 	status := &Progress{channel: currentStatus}
 	defer status.Update()
 
@@ -269,50 +316,71 @@ func (u *Updates) StartUpgrade(currentStatus chan<- Progress) { // nolint[funlen
 		return
 	}
 
+	dir := u.upgradeDir()
+	if status.Err = u.downloadAndUnpack(status, verInfo, dir); status.Err != nil {
+		return
+	}
+
+	u.install(status, dir)
+}
+
+// upgradeDir is where StartUpgrade unpacks the update. Background staging
+// uses stageDir instead so it cannot clear files of a running upgrade.
+func (u *Updates) upgradeDir() string {
+	return filepath.Join(u.updateTempDir, "upgrade")
+}
+
+func (u *Updates) stageDir() string {
+	return filepath.Join(u.updateTempDir, "staged")
+}
+
+func (u *Updates) downloadAndUnpack(status *Progress, verInfo VersionInfo, dir string) error {
 	if verInfo.UpdateFile == "" {
 		log.Warn("Empty update URL. Update manually.")
-		status.Err = ErrDownloadFailed
-		return
+		return ErrDownloadFailed
 	}
 
 	// Download.
 	status.UpdateDescription(InfoDownloading)
-	if status.Err = mkdirAllClear(u.updateTempDir); status.Err != nil {
-		return
+	if err := mkdirAllClear(dir); err != nil {
+		return err
 	}
 	var updateTar string
-	updateTar, status.Err = downloadWithSignature(
-		status,
-		verInfo.UpdateFile,
-		u.updateTempDir,
-	)
-	if status.Err != nil {
-		return
+	var err error
+	for _, updateURL := range u.mirrorURLs(verInfo.UpdateFile) {
+		if updateTar, err = downloadWithSignature(status, updateURL, dir); err == nil {
+			break
+		}
+		log.WithError(err).WithField("url", updateURL).Warn("Cannot download update")
+	}
+	if err != nil {
+		return err
 	}
 
 	// Check signature.
 	status.UpdateDescription(InfoVerifying)
-	status.Err = verifyFile(updateTar)
-	if status.Err != nil {
-		log.Warnf("Cannot verify update file %s: %v", updateTar, status.Err)
-		status.Err = ErrUpdateVerifyFailed
-		return
+	if err := verifyFile(updateTar); err != nil {
+		log.Warnf("Cannot verify update file %s: %v", updateTar, err)
+		return ErrUpdateVerifyFailed
+	}
+	if err := verifyChecksum(updateTar, verInfo.UpdateFileSHA256); err != nil {
+		log.Warnf("Cannot verify checksum of update file %s: %v", updateTar, err)
+		return err
 	}
 
 	// Untar.
 	status.UpdateDescription(InfoUnpacking)
-	status.Err = untarToDir(updateTar, u.updateTempDir, status)
-	if status.Err != nil {
-		return
-	}
+	return untarToDir(updateTar, dir, status)
+}
 
-	// Run upgrade (OS specific).
+// install runs the upgrade (OS specific) from update already unpacked in dir.
+func (u *Updates) install(status *Progress, dir string) {
 	status.UpdateDescription(InfoUpgrading)
 	switch runtime.GOOS {
 	case "windows": //nolint[goconst]
 		installerFile := strings.Split(u.winInstallerFile, "/")[1]
 		cmd := exec.Command("./" + installerFile) // nolint[gosec]
-		cmd.Dir = u.updateTempDir
+		cmd.Dir = dir
 		status.Err = cmd.Start()
 	case "darwin": //nolint[goconst]
 		// current path is better then appDir = filepath.Join("/Applications")
@@ -325,7 +393,7 @@ func (u *Updates) StartUpgrade(currentStatus chan<- Progress) { // nolint[funlen
 		localPath = filepath.Dir(localPath) // Contents
 		localPath = filepath.Dir(localPath) // .app
 
-		updatePath := filepath.Join(u.updateTempDir, u.macAppBundleName)
+		updatePath := filepath.Join(dir, u.macAppBundleName)
 		log.Warn("localPath ", localPath)
 		log.Warn("updatePath ", updatePath)
 		status.Err = syncFolders(localPath, updatePath)
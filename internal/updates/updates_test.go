@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -83,6 +84,14 @@ func TestCheckBridgeIsNotUpToDate(t *testing.T) {
 	require.True(t, !isUpToDate, "Bridge should not be up to date")
 }
 
+func TestIsRolledOut(t *testing.T) {
+	updates := newTestUpdates("1.1.5")
+	updates.SetRolloutBucket(50)
+
+	require.False(t, updates.IsRolledOut(VersionInfo{Version: "1.1.6", RolloutPercentage: 10}))
+	require.True(t, updates.IsRolledOut(VersionInfo{Version: "1.1.6", RolloutPercentage: 60}))
+}
+
 func TestGetLocalVersion(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("skipping test because local version for windows is currently not supported by tests.")
@@ -182,3 +191,41 @@ func newTestUpdates(version string) *Updates {
 	u.releaseFixedBugs = "• fixed foo"
 	return u
 }
+
+func TestGetLatestVersionFromMirror(t *testing.T) {
+	defer func(host string) { Host = host }(Host)
+	mirror := Host
+	Host = "http://localhost:1"
+
+	updates := newTestUpdates("1")
+	updates.SetMirrors([]string{"", mirror + "/"})
+	version, err := updates.getLatestVersion()
+	require.NoError(t, err)
+	require.Equal(t, "1.1.6", version.Version)
+}
+
+func TestMirrorURLs(t *testing.T) {
+	updates := newTestUpdates("1")
+	updates.SetMirrors([]string{" https://mirror.example.com/ ", ""})
+
+	require.Equal(t, []string{
+		Host + "/download/file.tgz",
+		"https://mirror.example.com/download/file.tgz",
+	}, updates.mirrorURLs(Host+"/download/file.tgz"))
+
+	require.Equal(t, []string{"https://other.example.com/file.tgz"}, updates.mirrorURLs("https://other.example.com/file.tgz"))
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	f, err := ioutil.TempFile(testUpdateDir, "checksum")
+	require.NoError(t, err)
+	_, err = f.WriteString("hello")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	sha256Hello := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	require.NoError(t, verifyChecksum(f.Name(), sha256Hello))
+	require.NoError(t, verifyChecksum(f.Name(), strings.ToUpper(sha256Hello)))
+	require.NoError(t, verifyChecksum(f.Name(), ""))
+	require.Equal(t, ErrChecksumMismatch, verifyChecksum(f.Name(), "00"))
+}
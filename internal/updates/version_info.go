@@ -37,6 +37,19 @@ type VersionInfo struct {
 	DebFile       string `json:",omitempty"` // debian package file
 	RpmFile       string `json:",omitempty"` // red hat package file
 	PkgFile       string `json:",omitempty"` // arch PKGBUILD file
+
+	UpdateFileSHA256 string `json:",omitempty"` // hex encoded SHA-256 checksum of UpdateFile
+
+	RolloutPercentage int `json:",omitempty"` // percentage of installs offered the update; zero means all
+}
+
+// IsInRollout returns whether the install with given rollout bucket
+// (number in range [0, 100)) should be offered this version.
+func (info *VersionInfo) IsInRollout(bucket int) bool {
+	if info.RolloutPercentage <= 0 || info.RolloutPercentage >= 100 {
+		return true
+	}
+	return bucket < info.RolloutPercentage
 }
 
 func (info *VersionInfo) GetDownloadLink() string {
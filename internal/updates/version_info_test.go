@@ -0,0 +1,44 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package updates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsInRollout(t *testing.T) {
+	tests := []struct {
+		percentage int
+		bucket     int
+		want       bool
+	}{
+		{0, 0, true},
+		{0, 99, true},
+		{100, 99, true},
+		{10, 0, true},
+		{10, 9, true},
+		{10, 10, false},
+		{10, 99, false},
+	}
+	for _, tc := range tests {
+		info := VersionInfo{RolloutPercentage: tc.percentage}
+		require.Equal(t, tc.want, info.IsInRollout(tc.bucket), "percentage %d bucket %d", tc.percentage, tc.bucket)
+	}
+}
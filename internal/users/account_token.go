@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package users
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// ErrIncompleteAccountToken is returned when the account token file misses a required value.
+var ErrIncompleteAccountToken = errors.New("account token must contain refresh token and mailbox password")
+
+// AccountToken holds everything needed to add an account without interactive
+// login, e.g. when provisioning bridge instances by automation.
+type AccountToken struct {
+	// RefreshToken is in the `UID:RefreshToken` format, the same as stored in the keychain.
+	RefreshToken    string
+	MailboxPassword string
+}
+
+// LoadAccountToken reads the account token from the JSON file at path.
+func LoadAccountToken(path string) (*AccountToken, error) {
+	data, err := ioutil.ReadFile(path) //nolint[gosec]
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read account token file")
+	}
+
+	token := &AccountToken{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, errors.Wrap(err, "failed to parse account token file")
+	}
+
+	if token.RefreshToken == "" || token.MailboxPassword == "" {
+		return nil, ErrIncompleteAccountToken
+	}
+
+	return token, nil
+}
+
+// AddUserFromToken authorizes the account using the pre-obtained refresh token
+// and saves it to the credentials store, from where it is loaded on next start.
+// The API rotates the refresh token, so the one from the token cannot be used again.
+func AddUserFromToken(
+	clientManager ClientManager,
+	credStorer CredentialsStorer,
+	token *AccountToken,
+	useOnlyActiveAddresses bool,
+) (userID string, err error) {
+	authClient := clientManager.GetAnonymousClient()
+	// The anonymous client is removed from the list without deleting the authentication.
+	defer authClient.Logout()
+
+	auth, err := authClient.AuthRefresh(token.RefreshToken)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to authorize with refresh token")
+	}
+
+	apiUser, hashedPassphrase, err := getAPIUser(authClient, token.MailboxPassword)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get API user")
+	}
+
+	var emails []string //nolint[prealloc]
+	if useOnlyActiveAddresses {
+		emails = authClient.Addresses().ActiveEmails()
+	} else {
+		emails = authClient.Addresses().AllEmails()
+	}
+
+	if _, err = credStorer.Add(apiUser.ID, apiUser.Name, auth.GenToken(), hashedPassphrase, emails); err != nil {
+		return "", errors.Wrap(err, "failed to add user to credentials store")
+	}
+
+	return apiUser.ID, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package credentials
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var (
+	ErrAppPasswordExists   = errors.New("app password with this name already exists")
+	ErrAppPasswordNotFound = errors.New("app password not found")
+)
+
+// AppPassword is an additional bridge password, typically one per mail client,
+// which can be revoked without reconfiguring other clients.
+type AppPassword struct {
+	Name     string
+	Password string
+	Created  int64
+	LastUsed int64 // Zero if never used.
+}
+
+func marshalAppPasswords(appPasswords []AppPassword) string {
+	if len(appPasswords) == 0 {
+		return ""
+	}
+
+	// JSON never contains a null byte, so it is safe to use with the separator.
+	b, err := json.Marshal(appPasswords)
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal app passwords")
+		return ""
+	}
+
+	return string(b)
+}
+
+func unmarshalAppPasswords(data string) (appPasswords []AppPassword, err error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	if err := json.Unmarshal([]byte(data), &appPasswords); err != nil {
+		return nil, ErrWrongFormat
+	}
+
+	return appPasswords, nil
+}
+
+func (s *Credentials) addAppPassword(name string) (*AppPassword, error) {
+	for _, appPassword := range s.AppPasswords {
+		if appPassword.Name == name {
+			return nil, ErrAppPasswordExists
+		}
+	}
+
+	appPassword := AppPassword{
+		Name:     name,
+		Password: generatePassword(),
+		Created:  time.Now().Unix(),
+	}
+	s.AppPasswords = append(s.AppPasswords, appPassword)
+
+	return &appPassword, nil
+}
+
+func (s *Credentials) revokeAppPassword(name string) error {
+	for i, appPassword := range s.AppPasswords {
+		if appPassword.Name == name {
+			s.AppPasswords = append(s.AppPasswords[:i], s.AppPasswords[i+1:]...)
+			return nil
+		}
+	}
+
+	return ErrAppPasswordNotFound
+}
+
+func (s *Credentials) setAppPasswordLastUsed(name string, lastUsed int64) error {
+	for i := range s.AppPasswords {
+		if s.AppPasswords[i].Name == name {
+			s.AppPasswords[i].LastUsed = lastUsed
+			return nil
+		}
+	}
+
+	return ErrAppPasswordNotFound
+}
+
+// GetAppPassword returns app password with given name or nil if it does not exist.
+func (s *Credentials) GetAppPassword(name string) *AppPassword {
+	for i := range s.AppPasswords {
+		if s.AppPasswords[i].Name == name {
+			return &s.AppPasswords[i]
+		}
+	}
+
+	return nil
+}
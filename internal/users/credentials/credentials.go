@@ -33,7 +33,8 @@ import (
 const (
 	sep = "\x00"
 
-	itemLengthBridge       = 9
+	itemLengthBridge       = 13
+	itemLengthBridgeOld    = 9 // Before IMAP and SMTP could be disabled per account.
 	itemLengthImportExport = 6 // Old format for Import-Export.
 )
 
@@ -53,7 +54,11 @@ type Credentials struct {
 	Version string
 	Timestamp int64
 	IsHidden, // Deprecated.
-	IsCombinedAddressMode bool
+	IsCombinedAddressMode,
+	IsIMAPDisabled,
+	IsSMTPDisabled bool
+	AppPasswords []AppPassword
+	Proxy        string // Overrides the proxy used to reach the API; see pmapi.SetUserProxy.
 }
 
 func (s *Credentials) Marshal() string {
@@ -67,6 +72,10 @@ func (s *Credentials) Marshal() string {
 		"",                // 6
 		"",                // 7
 		"",                // 8
+		"",                // 9
+		"",                // 10
+		"",                // 11
+		s.Proxy,           // 12
 	}
 
 	items[6] = fmt.Sprint(s.Timestamp)
@@ -79,6 +88,16 @@ func (s *Credentials) Marshal() string {
 		items[8] = "1"
 	}
 
+	if s.IsIMAPDisabled {
+		items[9] = "1"
+	}
+
+	if s.IsSMTPDisabled {
+		items[10] = "1"
+	}
+
+	items[11] = marshalAppPasswords(s.AppPasswords)
+
 	str := strings.Join(items, sep)
 	return base64.StdEncoding.EncodeToString([]byte(str))
 }
@@ -90,7 +109,8 @@ func (s *Credentials) Unmarshal(secret string) error {
 	}
 	items := strings.Split(string(b), sep)
 
-	if len(items) != itemLengthBridge && len(items) != itemLengthImportExport {
+	isBridge := len(items) >= itemLengthBridgeOld && len(items) <= itemLengthBridge
+	if !isBridge && len(items) != itemLengthImportExport {
 		return ErrWrongFormat
 	}
 
@@ -99,8 +119,8 @@ func (s *Credentials) Unmarshal(secret string) error {
 	s.APIToken = items[2]
 	s.MailboxPassword = items[3]
 
-	switch len(items) {
-	case itemLengthBridge:
+	switch {
+	case isBridge:
 		s.BridgePassword = items[4]
 		s.Version = items[5]
 		if _, err = fmt.Sscan(items[6], &s.Timestamp); err != nil {
@@ -112,8 +132,21 @@ func (s *Credentials) Unmarshal(secret string) error {
 		if s.IsCombinedAddressMode = false; items[8] == "1" {
 			s.IsCombinedAddressMode = true
 		}
+		// Items added later are optional to keep loading older credentials.
+		if len(items) > 10 {
+			s.IsIMAPDisabled = items[9] == "1"
+			s.IsSMTPDisabled = items[10] == "1"
+		}
+		if len(items) > 11 {
+			if s.AppPasswords, err = unmarshalAppPasswords(items[11]); err != nil {
+				return err
+			}
+		}
+		if len(items) > 12 {
+			s.Proxy = items[12]
+		}
 
-	case itemLengthImportExport:
+	default:
 		s.Version = items[4]
 		if _, err = fmt.Sscan(items[5], &s.Timestamp); err != nil {
 			s.Timestamp = 0
@@ -131,14 +164,29 @@ func (s *Credentials) EmailList() []string {
 }
 
 func (s *Credentials) CheckPassword(password string) error {
-	if subtle.ConstantTimeCompare([]byte(s.BridgePassword), []byte(password)) != 1 {
-		log.WithFields(logrus.Fields{
-			"userID": s.UserID,
-		}).Debug("Incorrect bridge password")
+	_, err := s.MatchPassword(password)
+	return err
+}
 
-		return fmt.Errorf("backend/credentials: incorrect password")
+// MatchPassword checks the password against the bridge password and all app
+// passwords. It returns name of the matching app password or empty string
+// when the main bridge password was used.
+func (s *Credentials) MatchPassword(password string) (appPasswordName string, err error) {
+	if subtle.ConstantTimeCompare([]byte(s.BridgePassword), []byte(password)) == 1 {
+		return "", nil
 	}
-	return nil
+
+	for _, appPassword := range s.AppPasswords {
+		if subtle.ConstantTimeCompare([]byte(appPassword.Password), []byte(password)) == 1 {
+			return appPassword.Name, nil
+		}
+	}
+
+	log.WithFields(logrus.Fields{
+		"userID": s.UserID,
+	}).Debug("Incorrect bridge password")
+
+	return "", fmt.Errorf("backend/credentials: incorrect password")
 }
 
 func (s *Credentials) Logout() {
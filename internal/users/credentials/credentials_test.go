@@ -65,3 +65,79 @@ func TestUnmarshallImportExport(t *testing.T) {
 	r.NoError(t, haveCredentials.Unmarshal(encoded))
 	r.Equal(t, wantCredentials, haveCredentials)
 }
+
+func TestUnmarshallBridgeWithoutProtocolSwitches(t *testing.T) {
+	items := []string{
+		wantCredentials.Name,
+		wantCredentials.Emails,
+		wantCredentials.APIToken,
+		wantCredentials.MailboxPassword,
+		wantCredentials.BridgePassword,
+		"k11",
+		fmt.Sprint(wantCredentials.Timestamp),
+		"",
+		"",
+	}
+
+	str := strings.Join(items, sep)
+	encoded := base64.StdEncoding.EncodeToString([]byte(str))
+
+	haveCredentials := Credentials{UserID: "1"}
+	r.NoError(t, haveCredentials.Unmarshal(encoded))
+	r.Equal(t, wantCredentials, haveCredentials)
+}
+
+func TestUnmarshallBridgeWithDisabledProtocols(t *testing.T) {
+	want := wantCredentials
+	want.IsIMAPDisabled = true
+	want.IsSMTPDisabled = true
+
+	haveCredentials := Credentials{UserID: "1"}
+	r.NoError(t, haveCredentials.Unmarshal(want.Marshal()))
+	r.Equal(t, want, haveCredentials)
+}
+
+func TestUnmarshallBridgeWithProxy(t *testing.T) {
+	want := wantCredentials
+	want.Proxy = "socks5://127.0.0.1:1080"
+
+	haveCredentials := Credentials{UserID: "1"}
+	r.NoError(t, haveCredentials.Unmarshal(want.Marshal()))
+	r.Equal(t, want, haveCredentials)
+}
+
+func TestUnmarshallBridgeWithAppPasswords(t *testing.T) {
+	want := wantCredentials
+	want.AppPasswords = []AppPassword{
+		{Name: "thunderbird", Password: "pass1", Created: 1},
+		{Name: "phone", Password: "pass2", Created: 2, LastUsed: 3},
+	}
+
+	haveCredentials := Credentials{UserID: "1"}
+	r.NoError(t, haveCredentials.Unmarshal(want.Marshal()))
+	r.Equal(t, want, haveCredentials)
+}
+
+func TestMatchPassword(t *testing.T) {
+	creds := wantCredentials
+	creds.AppPasswords = nil
+
+	appPassword, err := creds.addAppPassword("thunderbird")
+	r.NoError(t, err)
+
+	_, err = creds.addAppPassword("thunderbird")
+	r.Equal(t, ErrAppPasswordExists, err)
+
+	name, err := creds.MatchPassword(wantCredentials.BridgePassword)
+	r.NoError(t, err)
+	r.Equal(t, "", name)
+
+	name, err = creds.MatchPassword(appPassword.Password)
+	r.NoError(t, err)
+	r.Equal(t, "thunderbird", name)
+
+	r.NoError(t, creds.revokeAppPassword("thunderbird"))
+	_, err = creds.MatchPassword(appPassword.Password)
+	r.Error(t, err)
+	r.Equal(t, ErrAppPasswordNotFound, creds.revokeAppPassword("thunderbird"))
+}
@@ -71,6 +71,10 @@ func (s *Store) Add(userID, userName, apiToken, mailboxPassword string, emails [
 		log.Info("Updating credentials of existing user")
 		creds.BridgePassword = currentCredentials.BridgePassword
 		creds.IsCombinedAddressMode = currentCredentials.IsCombinedAddressMode
+		creds.IsIMAPDisabled = currentCredentials.IsIMAPDisabled
+		creds.IsSMTPDisabled = currentCredentials.IsSMTPDisabled
+		creds.AppPasswords = currentCredentials.AppPasswords
+		creds.Proxy = currentCredentials.Proxy
 		creds.Timestamp = currentCredentials.Timestamp
 	} else {
 		log.Info("Generating credentials for new user")
@@ -101,6 +105,123 @@ func (s *Store) SwitchAddressMode(userID string) error {
 	return s.saveCredentials(credentials)
 }
 
+// SetIMAPEnabled allows or denies access to the account over IMAP.
+func (s *Store) SetIMAPEnabled(userID string, enabled bool) error {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	credentials, err := s.get(userID)
+	if err != nil {
+		return err
+	}
+
+	credentials.IsIMAPDisabled = !enabled
+
+	return s.saveCredentials(credentials)
+}
+
+// SetSMTPEnabled allows or denies sending from the account over SMTP.
+func (s *Store) SetSMTPEnabled(userID string, enabled bool) error {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	credentials, err := s.get(userID)
+	if err != nil {
+		return err
+	}
+
+	credentials.IsSMTPDisabled = !enabled
+
+	return s.saveCredentials(credentials)
+}
+
+// SetProxy sets the proxy override of the account.
+func (s *Store) SetProxy(userID, proxy string) error {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	credentials, err := s.get(userID)
+	if err != nil {
+		return err
+	}
+
+	credentials.Proxy = proxy
+
+	return s.saveCredentials(credentials)
+}
+
+// AddAppPassword generates a new named bridge password for the account.
+func (s *Store) AddAppPassword(userID, name string) (*AppPassword, error) {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	credentials, err := s.get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	appPassword, err := credentials.addAppPassword(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.saveCredentials(credentials); err != nil {
+		return nil, err
+	}
+
+	return appPassword, nil
+}
+
+// RevokeAppPassword removes the named bridge password from the account.
+func (s *Store) RevokeAppPassword(userID, name string) error {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	credentials, err := s.get(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := credentials.revokeAppPassword(name); err != nil {
+		return err
+	}
+
+	return s.saveCredentials(credentials)
+}
+
+// RotateBridgePassword replaces the main bridge password of the account with
+// a newly generated one. App passwords are kept.
+func (s *Store) RotateBridgePassword(userID string) error {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	credentials, err := s.get(userID)
+	if err != nil {
+		return err
+	}
+
+	credentials.BridgePassword = generatePassword()
+
+	return s.saveCredentials(credentials)
+}
+
+// UpdateAppPasswordLastUsed sets the time when the named bridge password was last used.
+func (s *Store) UpdateAppPasswordLastUsed(userID, name string, lastUsed int64) error {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	credentials, err := s.get(userID)
+	if err != nil {
+		return err
+	}
+
+	if err := credentials.setAppPasswordLastUsed(name, lastUsed); err != nil {
+		return err
+	}
+
+	return s.saveCredentials(credentials)
+}
+
 func (s *Store) UpdateEmails(userID string, emails []string) error {
 	storeLocker.Lock()
 	defer storeLocker.Unlock()
@@ -263,6 +384,17 @@ func (s *Store) get(userID string) (creds *Credentials, err error) {
 	return credentials, nil
 }
 
+// Import saves credentials as they are, replacing existing ones for the same user.
+// It is meant for moving credentials between machines.
+func (s *Store) Import(creds *Credentials) error {
+	storeLocker.Lock()
+	defer storeLocker.Unlock()
+
+	log.WithField("user", creds.UserID).Trace("Importing credentials")
+
+	return s.saveCredentials(creds)
+}
+
 // saveCredentials encrypts and saves password to the keychain store.
 func (s *Store) saveCredentials(credentials *Credentials) (err error) {
 	if err = s.checkKeychain(); err != nil {
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package users
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	maxFailedLogins      = 5
+	maxFailedLoginsTotal = 100
+	failedLoginsWindow   = 10 * time.Minute
+	loginLockoutDuration = 15 * time.Minute
+)
+
+// ErrLoginLockedOut is sent to IMAP and SMTP when there were too many failed
+// login attempts to the account recently.
+var ErrLoginLockedOut = errors.New("too many failed login attempts, try again later")
+
+// loginLimiter protects the bridge password against guessing, e.g. by other
+// users of the same machine. Failures are counted per client, usually one
+// connection, so one misconfigured client does not lock out the others.
+// After maxFailedLogins failures within failedLoginsWindow the client is
+// refused for loginLockoutDuration. Because a new connection is a new client,
+// all clients are refused after maxFailedLoginsTotal failures in the window.
+type loginLimiter struct {
+	lock    sync.Mutex
+	clients map[string]*failedLogins
+	total   failedLogins
+}
+
+type failedLogins struct {
+	failures    []time.Time
+	lockedUntil time.Time
+}
+
+func (f *failedLogins) isLockedOut(now time.Time) bool {
+	return now.Before(f.lockedUntil)
+}
+
+func (f *failedLogins) isExpired(now time.Time) bool {
+	return !f.isLockedOut(now) && len(f.recent(now)) == 0
+}
+
+func (f *failedLogins) recent(now time.Time) []time.Time {
+	recent := []time.Time{}
+	for _, failure := range f.failures {
+		if now.Sub(failure) < failedLoginsWindow {
+			recent = append(recent, failure)
+		}
+	}
+	return recent
+}
+
+// add records failed login and returns true if it caused a lockout.
+func (f *failedLogins) add(now time.Time, max int) bool {
+	f.failures = append(f.recent(now), now)
+
+	if len(f.failures) < max {
+		return false
+	}
+
+	f.failures = nil
+	f.lockedUntil = now.Add(loginLockoutDuration)
+	return true
+}
+
+func (l *loginLimiter) isLockedOut(client string, now time.Time) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.total.isLockedOut(now) {
+		return true
+	}
+
+	failures, ok := l.clients[client]
+	return ok && failures.isLockedOut(now)
+}
+
+// addFailure records failed login of the client. It returns whether it caused
+// a lockout of the client and whether it caused a lockout of all clients.
+func (l *loginLimiter) addFailure(client string, now time.Time) (clientLockedOut, allLockedOut bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.clients == nil {
+		l.clients = map[string]*failedLogins{}
+	}
+
+	// Every connection is a new client; forget those which are not relevant anymore.
+	for key, failures := range l.clients {
+		if failures.isExpired(now) {
+			delete(l.clients, key)
+		}
+	}
+
+	failures, ok := l.clients[client]
+	if !ok {
+		failures = &failedLogins{}
+		l.clients[client] = failures
+	}
+
+	return failures.add(now, maxFailedLogins), l.total.add(now, maxFailedLoginsTotal)
+}
+
+// reset forgets failures of the client after it logged in successfully.
+// Failures of other clients still count towards the total.
+func (l *loginLimiter) reset(client string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	delete(l.clients, client)
+}
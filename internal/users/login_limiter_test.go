@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package users
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestLoginLimiterLocksOutAfterTooManyFailures(t *testing.T) {
+	limiter := &loginLimiter{}
+	now := time.Now()
+
+	for i := 1; i < maxFailedLogins; i++ {
+		r.False(t, clientLockedOut(limiter.addFailure("client", now)))
+		r.False(t, limiter.isLockedOut("client", now))
+	}
+
+	r.True(t, clientLockedOut(limiter.addFailure("client", now)))
+	r.True(t, limiter.isLockedOut("client", now))
+	r.True(t, limiter.isLockedOut("client", now.Add(loginLockoutDuration-time.Second)))
+	r.False(t, limiter.isLockedOut("client", now.Add(loginLockoutDuration)))
+}
+
+func TestLoginLimiterDoesNotLockOutOtherClients(t *testing.T) {
+	limiter := &loginLimiter{}
+	now := time.Now()
+
+	for i := 0; i < maxFailedLogins; i++ {
+		limiter.addFailure("client", now)
+	}
+
+	r.True(t, limiter.isLockedOut("client", now))
+	r.False(t, limiter.isLockedOut("other", now))
+}
+
+func TestLoginLimiterLocksOutAllClientsAfterTooManyFailures(t *testing.T) {
+	limiter := &loginLimiter{}
+	now := time.Now()
+
+	for i := 1; i < maxFailedLoginsTotal; i++ {
+		_, allLockedOut := limiter.addFailure(fmt.Sprintf("client-%d", i), now)
+		r.False(t, allLockedOut)
+	}
+
+	_, allLockedOut := limiter.addFailure("client", now)
+	r.True(t, allLockedOut)
+	r.True(t, limiter.isLockedOut("other", now))
+	r.False(t, limiter.isLockedOut("other", now.Add(loginLockoutDuration)))
+}
+
+func TestLoginLimiterForgetsOldFailures(t *testing.T) {
+	limiter := &loginLimiter{}
+	now := time.Now()
+
+	for i := 1; i < maxFailedLogins; i++ {
+		r.False(t, clientLockedOut(limiter.addFailure("client", now)))
+	}
+
+	r.False(t, clientLockedOut(limiter.addFailure("client", now.Add(failedLoginsWindow))))
+	r.False(t, limiter.isLockedOut("client", now.Add(failedLoginsWindow)))
+}
+
+func TestLoginLimiterReset(t *testing.T) {
+	limiter := &loginLimiter{}
+	now := time.Now()
+
+	for i := 1; i < maxFailedLogins; i++ {
+		r.False(t, clientLockedOut(limiter.addFailure("client", now)))
+	}
+
+	limiter.reset("client")
+
+	r.False(t, clientLockedOut(limiter.addFailure("client", now)))
+	r.False(t, limiter.isLockedOut("client", now))
+}
+
+func TestCheckUnknownUserLogin(t *testing.T) {
+	users := &Users{}
+
+	for i := 1; i < maxFailedLogins; i++ {
+		r.NoError(t, users.CheckUnknownUserLogin("client"))
+	}
+
+	r.Equal(t, ErrLoginLockedOut, users.CheckUnknownUserLogin("client"))
+	r.Equal(t, ErrLoginLockedOut, users.CheckUnknownUserLogin("client"))
+	r.NoError(t, users.CheckUnknownUserLogin("other"))
+}
+
+func clientLockedOut(clientLockedOut, _ bool) bool {
+	return clientLockedOut
+}
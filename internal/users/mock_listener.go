@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 	time "time"
 
+	listener "github.com/ProtonMail/proton-bridge/pkg/listener"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -46,6 +47,18 @@ func (mr *MockListenerMockRecorder) SetLimit(eventName, limit interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLimit", reflect.TypeOf((*MockListener)(nil).SetLimit), eventName, limit)
 }
 
+// SetPriority mocks base method
+func (m *MockListener) SetPriority(eventName string, priority listener.Priority) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetPriority", eventName, priority)
+}
+
+// SetPriority indicates an expected call of SetPriority
+func (mr *MockListenerMockRecorder) SetPriority(eventName, priority interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPriority", reflect.TypeOf((*MockListener)(nil).SetPriority), eventName, priority)
+}
+
 // Add mocks base method
 func (m *MockListener) Add(eventName string, channel chan<- string) {
 	m.ctrl.T.Helper()
@@ -10,6 +10,7 @@ import (
 	pmapi "github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockConfiger is a mock of Configer interface
@@ -201,6 +202,48 @@ func (mr *MockClientManagerMockRecorder) GetAuthUpdateChannel() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuthUpdateChannel", reflect.TypeOf((*MockClientManager)(nil).GetAuthUpdateChannel))
 }
 
+// GetClockSkew mocks base method
+func (m *MockClientManager) GetClockSkew() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetClockSkew")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetClockSkew indicates an expected call of GetClockSkew
+func (mr *MockClientManagerMockRecorder) GetClockSkew() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetClockSkew", reflect.TypeOf((*MockClientManager)(nil).GetClockSkew))
+}
+
+// GetRateLimitDelay mocks base method
+func (m *MockClientManager) GetRateLimitDelay() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRateLimitDelay")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// GetRateLimitDelay indicates an expected call of GetRateLimitDelay
+func (mr *MockClientManagerMockRecorder) GetRateLimitDelay() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRateLimitDelay", reflect.TypeOf((*MockClientManager)(nil).GetRateLimitDelay))
+}
+
+// GetRequestStats mocks base method
+func (m *MockClientManager) GetRequestStats() []pmapi.EndpointStats {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRequestStats")
+	ret0, _ := ret[0].([]pmapi.EndpointStats)
+	return ret0
+}
+
+// GetRequestStats indicates an expected call of GetRequestStats
+func (mr *MockClientManagerMockRecorder) GetRequestStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRequestStats", reflect.TypeOf((*MockClientManager)(nil).GetRequestStats))
+}
+
 // GetClient mocks base method
 func (m *MockClientManager) GetClient(arg0 string) pmapi.Client {
 	m.ctrl.T.Helper()
@@ -227,6 +270,20 @@ func (mr *MockClientManagerMockRecorder) SetUserAgent(arg0, arg1, arg2 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserAgent", reflect.TypeOf((*MockClientManager)(nil).SetUserAgent), arg0, arg1, arg2)
 }
 
+// SetUserProxy mocks base method
+func (m *MockClientManager) SetUserProxy(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserProxy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserProxy indicates an expected call of SetUserProxy
+func (mr *MockClientManagerMockRecorder) SetUserProxy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserProxy", reflect.TypeOf((*MockClientManager)(nil).SetUserProxy), arg0, arg1)
+}
+
 // MockCredentialsStorer is a mock of CredentialsStorer interface
 type MockCredentialsStorer struct {
 	ctrl     *gomock.Controller
@@ -265,6 +322,21 @@ func (mr *MockCredentialsStorerMockRecorder) Add(arg0, arg1, arg2, arg3, arg4 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockCredentialsStorer)(nil).Add), arg0, arg1, arg2, arg3, arg4)
 }
 
+// AddAppPassword mocks base method
+func (m *MockCredentialsStorer) AddAppPassword(arg0, arg1 string) (*credentials.AppPassword, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAppPassword", arg0, arg1)
+	ret0, _ := ret[0].(*credentials.AppPassword)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAppPassword indicates an expected call of AddAppPassword
+func (mr *MockCredentialsStorerMockRecorder) AddAppPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAppPassword", reflect.TypeOf((*MockCredentialsStorer)(nil).AddAppPassword), arg0, arg1)
+}
+
 // Delete mocks base method
 func (m *MockCredentialsStorer) Delete(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -323,6 +395,76 @@ func (mr *MockCredentialsStorerMockRecorder) Logout(arg0 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockCredentialsStorer)(nil).Logout), arg0)
 }
 
+// RevokeAppPassword mocks base method
+func (m *MockCredentialsStorer) RevokeAppPassword(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAppPassword", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAppPassword indicates an expected call of RevokeAppPassword
+func (mr *MockCredentialsStorerMockRecorder) RevokeAppPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAppPassword", reflect.TypeOf((*MockCredentialsStorer)(nil).RevokeAppPassword), arg0, arg1)
+}
+
+// RotateBridgePassword mocks base method
+func (m *MockCredentialsStorer) RotateBridgePassword(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateBridgePassword", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RotateBridgePassword indicates an expected call of RotateBridgePassword
+func (mr *MockCredentialsStorerMockRecorder) RotateBridgePassword(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateBridgePassword", reflect.TypeOf((*MockCredentialsStorer)(nil).RotateBridgePassword), arg0)
+}
+
+// SetIMAPEnabled mocks base method
+func (m *MockCredentialsStorer) SetIMAPEnabled(arg0 string, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIMAPEnabled", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetIMAPEnabled indicates an expected call of SetIMAPEnabled
+func (mr *MockCredentialsStorerMockRecorder) SetIMAPEnabled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIMAPEnabled", reflect.TypeOf((*MockCredentialsStorer)(nil).SetIMAPEnabled), arg0, arg1)
+}
+
+// SetProxy mocks base method
+func (m *MockCredentialsStorer) SetProxy(arg0, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetProxy", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetProxy indicates an expected call of SetProxy
+func (mr *MockCredentialsStorerMockRecorder) SetProxy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetProxy", reflect.TypeOf((*MockCredentialsStorer)(nil).SetProxy), arg0, arg1)
+}
+
+// SetSMTPEnabled mocks base method
+func (m *MockCredentialsStorer) SetSMTPEnabled(arg0 string, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSMTPEnabled", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSMTPEnabled indicates an expected call of SetSMTPEnabled
+func (mr *MockCredentialsStorerMockRecorder) SetSMTPEnabled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSMTPEnabled", reflect.TypeOf((*MockCredentialsStorer)(nil).SetSMTPEnabled), arg0, arg1)
+}
+
 // SwitchAddressMode mocks base method
 func (m *MockCredentialsStorer) SwitchAddressMode(arg0 string) error {
 	m.ctrl.T.Helper()
@@ -337,6 +479,20 @@ func (mr *MockCredentialsStorerMockRecorder) SwitchAddressMode(arg0 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwitchAddressMode", reflect.TypeOf((*MockCredentialsStorer)(nil).SwitchAddressMode), arg0)
 }
 
+// UpdateAppPasswordLastUsed mocks base method
+func (m *MockCredentialsStorer) UpdateAppPasswordLastUsed(arg0, arg1 string, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAppPasswordLastUsed", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAppPasswordLastUsed indicates an expected call of UpdateAppPasswordLastUsed
+func (mr *MockCredentialsStorerMockRecorder) UpdateAppPasswordLastUsed(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAppPasswordLastUsed", reflect.TypeOf((*MockCredentialsStorer)(nil).UpdateAppPasswordLastUsed), arg0, arg1, arg2)
+}
+
 // UpdateEmails mocks base method
 func (m *MockCredentialsStorer) UpdateEmails(arg0 string, arg1 []string) error {
 	m.ctrl.T.Helper()
@@ -18,6 +18,8 @@
 package users
 
 import (
+	"time"
+
 	"github.com/ProtonMail/proton-bridge/internal/store"
 	"github.com/ProtonMail/proton-bridge/internal/users/credentials"
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
@@ -38,6 +40,13 @@ type CredentialsStorer interface {
 	Add(userID, userName, apiToken, mailboxPassword string, emails []string) (*credentials.Credentials, error)
 	Get(userID string) (*credentials.Credentials, error)
 	SwitchAddressMode(userID string) error
+	SetIMAPEnabled(userID string, enabled bool) error
+	SetSMTPEnabled(userID string, enabled bool) error
+	SetProxy(userID, proxy string) error
+	AddAppPassword(userID, name string) (*credentials.AppPassword, error)
+	RevokeAppPassword(userID, name string) error
+	RotateBridgePassword(userID string) error
+	UpdateAppPasswordLastUsed(userID, name string, lastUsed int64) error
 	UpdateEmails(userID string, emails []string) error
 	UpdatePassword(userID, password string) error
 	UpdateToken(userID, apiToken string) error
@@ -53,6 +62,10 @@ type ClientManager interface {
 	GetAuthUpdateChannel() chan pmapi.ClientAuth
 	CheckConnection() error
 	SetUserAgent(clientName, clientVersion, os string)
+	GetRateLimitDelay() time.Duration
+	GetRequestStats() []pmapi.EndpointStats
+	GetClockSkew() time.Duration
+	SetUserProxy(userID, proxy string) error
 }
 
 type StoreMaker interface {
@@ -21,6 +21,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ProtonMail/proton-bridge/internal/events"
 	"github.com/ProtonMail/proton-bridge/internal/store"
@@ -32,9 +33,18 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// appPasswordLastUsedPrecision is how often the last usage of app password is saved.
+const appPasswordLastUsedPrecision = time.Hour
+
 // ErrLoggedOutUser is sent to IMAP and SMTP if user exists, password is OK but user is logged out from the app.
 var ErrLoggedOutUser = errors.New("account is logged out, use the app to login again")
 
+// ErrIMAPDisabled is sent to IMAP if user exists, password is OK but IMAP is disabled for the account.
+var ErrIMAPDisabled = errors.New("IMAP is disabled for this account")
+
+// ErrSMTPDisabled is sent to SMTP if user exists, password is OK but SMTP is disabled for the account.
+var ErrSMTPDisabled = errors.New("SMTP is disabled for this account")
+
 // User is a struct on top of API client and credentials store.
 type User struct {
 	log           *logrus.Entry
@@ -53,6 +63,8 @@ type User struct {
 
 	lock         sync.RWMutex
 	isAuthorized bool
+
+	loginLimiter loginLimiter
 }
 
 // newUser creates a new user.
@@ -106,14 +118,25 @@ func (u *User) init(idleUpdates chan imapBackend.Update) (err error) {
 	}
 	u.creds = creds
 
+	// The proxy override has to be set before the first request of the user.
+	if u.creds.Proxy != "" {
+		if err := u.clientManager.SetUserProxy(u.userID, u.creds.Proxy); err != nil {
+			u.log.WithError(err).Warn("Could not set proxy override")
+		}
+	}
+
 	// Try to authorise the user if they aren't already authorised.
 	// Note: we still allow users to set up accounts if the internet is off.
-	if authErr := u.authorizeIfNecessary(false); authErr != nil {
+	if _, authErr := u.authorizeIfNecessary(); authErr != nil {
 		switch errors.Cause(authErr) {
 		case pmapi.ErrAPINotReachable, pmapi.ErrUpgradeApplication, ErrLoggedOutUser:
 			u.log.WithError(authErr).Warn("Could not authorize user")
 		default:
-			if logoutErr := u.logout(); logoutErr != nil {
+			logout := u.logout
+			if isReloginRequired(authErr) {
+				logout = u.requireRelogin
+			}
+			if logoutErr := logout(); logoutErr != nil {
 				u.log.WithError(logoutErr).Warn("Could not logout user")
 			}
 			return errors.Wrap(authErr, "failed to authorize user")
@@ -151,13 +174,26 @@ func (u *User) SetIMAPIdleUpdateChannel() {
 // authorizeIfNecessary checks whether user is logged in and is connected to api auth channel.
 // If user is not already connected to the api auth channel (for example there was no internet during start),
 // it tries to connect it.
-func (u *User) authorizeIfNecessary(emitEvent bool) (err error) {
+// When the user was logged out, it returns the event to notify about it. The caller
+// emits it after releasing the user lock because the event is delivered synchronously
+// and its listeners usually need the user.
+func (u *User) authorizeIfNecessary() (logoutEvent string, err error) {
 	// If user is connected and has an auth channel, then perfect, nothing to do here.
 	if u.creds.IsConnected() && u.isAuthorized {
 		// The keyring  unlock is triggered here to resolve state where apiClient
 		// is authenticated (we have auth token) but it was not possible to download
 		// and unlock the keys (internet not reachable).
-		return u.unlockIfNecessary()
+		if err = u.unlockIfNecessary(); err != nil && isReloginRequired(err) {
+			// Retrying the unlock with the same mailbox password would fail forever.
+			u.log.WithError(err).Warn("Stored mailbox password no longer unlocks the keys")
+			u.logoutCredentials()
+			u.refreshFromCredentials()
+			u.closeEventLoop()
+			u.isAuthorized = false
+
+			return events.ReloginRequiredEvent, err
+		}
+		return "", err
 	}
 
 	if !u.creds.IsConnected() {
@@ -173,19 +209,39 @@ func (u *User) authorizeIfNecessary(emitEvent bool) (err error) {
 			u.listener.Emit(events.InternetOffEvent, "")
 
 		default:
-			if errLogout := u.credStorer.Logout(u.userID); errLogout != nil {
-				u.log.WithField("err", errLogout).Error("Could not log user out from credentials store")
-			}
+			u.logoutCredentials()
 		}
 	}
 
-	if emitEvent && err != nil &&
+	if err != nil &&
 		errors.Cause(err) != pmapi.ErrUpgradeApplication &&
 		errors.Cause(err) != pmapi.ErrAPINotReachable {
-		u.listener.Emit(events.LogoutEvent, u.userID)
+		if isReloginRequired(err) {
+			return events.ReloginRequiredEvent, err
+		}
+		return events.LogoutEvent, err
 	}
 
-	return err
+	return "", err
+}
+
+// isReloginRequired returns whether err means the stored credentials are no longer
+// valid (typically after the account or mailbox password was changed elsewhere)
+// and the user has to log in again.
+func isReloginRequired(err error) bool {
+	switch errors.Cause(err) {
+	case pmapi.ErrAuthRevoked, pmapi.ErrNoKeysUnlocked:
+		return true
+	}
+	return false
+}
+
+// logoutCredentials logs the user out only in the credentials store.
+// It must be used instead of Logout when the user lock is already held.
+func (u *User) logoutCredentials() {
+	if errLogout := u.credStorer.Logout(u.userID); errLogout != nil {
+		u.log.WithField("err", errLogout).Error("Could not log user out from credentials store")
+	}
 }
 
 // unlockIfNecessary will not trigger keyring unlocking if it was already successfully unlocked.
@@ -383,51 +439,455 @@ func (u *User) GetBridgePassword() string {
 }
 
 // CheckBridgeLogin checks whether the user is logged in and the bridge
-// IMAP/SMTP password is correct.
-func (u *User) CheckBridgeLogin(password string) error {
+// IMAP/SMTP password is correct. The client identifies the connection trying
+// to log in so failed attempts of one client do not lock out the others.
+func (u *User) CheckBridgeLogin(client, password string) error {
 	if isApplicationOutdated {
 		u.listener.Emit(events.UpgradeApplicationEvent, "")
 		return pmapi.ErrUpgradeApplication
 	}
 
+	appPasswordName, logoutEvent, err := u.checkBridgeLogin(client, password)
+	if logoutEvent != "" {
+		u.listener.Emit(logoutEvent, u.userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if appPasswordName != "" {
+		u.updateAppPasswordLastUsed(appPasswordName)
+	}
+
+	return nil
+}
+
+func (u *User) checkBridgeLogin(client, password string) (appPasswordName, logoutEvent string, err error) {
 	u.lock.RLock()
 	defer u.lock.RUnlock()
 
-	// True here because users should be notified by popup of auth failure.
-	if err := u.authorizeIfNecessary(true); err != nil {
+	if u.loginLimiter.isLockedOut(client, time.Now()) {
+		u.log.WithField("client", client).Warn("Login refused, client is locked out after too many failed attempts")
+		return "", "", ErrLoginLockedOut
+	}
+
+	// Users should be notified by popup of auth failure, see logoutEvent.
+	if logoutEvent, err = u.authorizeIfNecessary(); err != nil {
 		u.log.WithError(err).Error("Failed to authorize user")
-		return err
+		return "", logoutEvent, err
+	}
+
+	if appPasswordName, err = u.creds.MatchPassword(password); err != nil {
+		clientLockedOut, allLockedOut := u.loginLimiter.addFailure(client, time.Now())
+		if clientLockedOut {
+			u.log.WithField("client", client).Warn("Too many failed login attempts, locking out client")
+		}
+		if allLockedOut {
+			u.log.Warn("Too many failed login attempts, locking out account")
+			u.listener.Emit(events.LoginLockoutEvent, u.creds.Name)
+		}
+		return "", "", err
+	}
+
+	u.loginLimiter.reset(client)
+
+	return appPasswordName, "", nil
+}
+
+// updateAppPasswordLastUsed saves the time of the last login with the app
+// password. To not write to the keychain on every login, the time is saved
+// only when the previous one is older than appPasswordLastUsedPrecision.
+func (u *User) updateAppPasswordLastUsed(name string) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	appPassword := u.creds.GetAppPassword(name)
+	if appPassword == nil {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(time.Unix(appPassword.LastUsed, 0)) < appPasswordLastUsedPrecision {
+		return
+	}
+
+	if err := u.credStorer.UpdateAppPasswordLastUsed(u.userID, name, now.Unix()); err != nil {
+		u.log.WithError(err).Warn("Failed to update last usage of app password")
+		return
+	}
+
+	u.refreshFromCredentials()
+}
+
+// GetAppPasswords returns all named bridge passwords of the account.
+func (u *User) GetAppPasswords() []credentials.AppPassword {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return append([]credentials.AppPassword{}, u.creds.AppPasswords...)
+}
+
+// AddAppPassword generates a new named bridge password, usually for one mail client.
+func (u *User) AddAppPassword(name string) (string, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.log.WithField("name", name).Info("Adding app password")
+
+	appPassword, err := u.credStorer.AddAppPassword(u.userID, name)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to add app password")
+	}
+
+	u.refreshFromCredentials()
+
+	return appPassword.Password, nil
+}
+
+// RevokeAppPassword removes the named bridge password. It is not known which
+// connection used which password, therefore all connections are closed and
+// clients with a valid password simply log in again.
+func (u *User) RevokeAppPassword(name string) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.log.WithField("name", name).Info("Revoking app password")
+
+	if err := u.credStorer.RevokeAppPassword(u.userID, name); err != nil {
+		return errors.Wrap(err, "failed to revoke app password")
+	}
+
+	u.refreshFromCredentials()
+	u.closeAllConnections()
+
+	return nil
+}
+
+// RotateBridgePassword replaces the main bridge password with a newly generated
+// one and closes all connections so clients have to log in with the new one.
+func (u *User) RotateBridgePassword() (string, error) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.log.Info("Rotating bridge password")
+
+	if err := u.credStorer.RotateBridgePassword(u.userID); err != nil {
+		return "", errors.Wrap(err, "failed to rotate bridge password")
+	}
+
+	u.refreshFromCredentials()
+	u.closeAllConnections()
+
+	return u.creds.BridgePassword, nil
+}
+
+// ListLabels returns folders and labels with their colors and order.
+func (u *User) ListLabels() ([]*pmapi.Label, error) {
+	if u.store == nil {
+		return nil, errors.New("store is not initialised")
+	}
+
+	return u.store.ListLabels()
+}
+
+// ListSavedSearches returns saved searches shown as virtual mailboxes.
+func (u *User) ListSavedSearches() ([]store.SavedSearch, error) {
+	if u.store == nil {
+		return nil, errors.New("store is not initialised")
+	}
+
+	return u.store.ListSavedSearches()
+}
+
+// AddSavedSearch creates a virtual mailbox with all messages matching the query.
+func (u *User) AddSavedSearch(name, query string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.AddSavedSearch(name, query)
+}
+
+// RemoveSavedSearch removes the virtual mailbox of the saved search.
+func (u *User) RemoveSavedSearch(name string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.RemoveSavedSearch(name)
+}
+
+// GetFilters returns the local filtering script.
+func (u *User) GetFilters() (string, error) {
+	if u.store == nil {
+		return "", errors.New("store is not initialised")
+	}
+
+	return u.store.GetFilters()
+}
+
+// SetFilters sets the local filtering script applied on newly received messages.
+func (u *User) SetFilters(script string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetFilters(script)
+}
+
+// IsDraftsUploadEnabled returns whether drafts saved by email clients are uploaded to the server.
+func (u *User) IsDraftsUploadEnabled() bool {
+	if u.store == nil {
+		return true
+	}
+
+	return u.store.IsDraftsUploadEnabled()
+}
+
+// SetDraftsUploadEnabled sets whether drafts saved by email clients are uploaded to the server.
+func (u *User) SetDraftsUploadEnabled(enabled bool) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetDraftsUploadEnabled(enabled)
+}
+
+// IsAutoCreateMailboxesEnabled returns whether missing folders and labels are created on copy or append.
+func (u *User) IsAutoCreateMailboxesEnabled() bool {
+	if u.store == nil {
+		return false
+	}
+
+	return u.store.IsAutoCreateMailboxesEnabled()
+}
+
+// SetAutoCreateMailboxesEnabled sets whether missing folders and labels are created on copy or append.
+func (u *User) SetAutoCreateMailboxesEnabled(enabled bool) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetAutoCreateMailboxesEnabled(enabled)
+}
+
+// IsCrossAccountCopyEnabled returns whether messages copied from another account are imported.
+func (u *User) IsCrossAccountCopyEnabled() bool {
+	if u.store == nil {
+		return false
+	}
+
+	return u.store.IsCrossAccountCopyEnabled()
+}
+
+// SetCrossAccountCopyEnabled sets whether messages copied from another account are imported.
+func (u *User) SetCrossAccountCopyEnabled(enabled bool) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetCrossAccountCopyEnabled(enabled)
+}
+
+// IsSpamTrainingEnabled returns whether moves to or from Spam train the spam filter.
+func (u *User) IsSpamTrainingEnabled() bool {
+	if u.store == nil {
+		return true
+	}
+
+	return u.store.IsSpamTrainingEnabled()
+}
+
+// SetSpamTrainingEnabled sets whether moves to or from Spam train the spam filter.
+func (u *User) SetSpamTrainingEnabled(enabled bool) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetSpamTrainingEnabled(enabled)
+}
+
+// GetExpungePolicy returns what happens with messages deleted by email clients.
+func (u *User) GetExpungePolicy() string {
+	if u.store == nil {
+		return string(store.ExpungePolicyDelete)
+	}
+
+	return string(u.store.GetExpungePolicy())
+}
+
+// SetExpungePolicy sets what happens with messages deleted by email clients.
+func (u *User) SetExpungePolicy(policy string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetExpungePolicy(store.ExpungePolicy(policy))
+}
+
+// ListDeletedMessages returns permanently deleted messages which can be recovered.
+func (u *User) ListDeletedMessages() ([]store.Tombstone, error) {
+	if u.store == nil {
+		return nil, errors.New("store is not initialised")
+	}
+
+	return u.store.ListTombstones()
+}
+
+// RecoverDeletedMessage imports the permanently deleted message again.
+func (u *User) RecoverDeletedMessage(messageID string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.RecoverTombstone(messageID)
+}
+
+// ListMessageProblems returns the last sync or build errors of messages.
+func (u *User) ListMessageProblems() ([]store.Problem, error) {
+	if u.store == nil {
+		return nil, errors.New("store is not initialised")
+	}
+
+	return u.store.ListProblems()
+}
+
+// SetMessageSkipped adds the message to or removes it from the skip-list
+// of messages which are not downloaded anymore.
+func (u *User) SetMessageSkipped(messageID string, skipped bool) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetMessageSkipped(messageID, skipped)
+}
+
+// BumpUIDValidity makes clients download the mailbox again with new UIDs.
+func (u *User) BumpUIDValidity(mailboxName string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.BumpUIDValidity(mailboxName)
+}
+
+// GetAutoCopy returns the address added to every sent message and whether
+// it is added as bcc or cc.
+func (u *User) GetAutoCopy() (address, mode string) {
+	if u.store == nil {
+		return "", store.AutoCopyBCC
+	}
+
+	return u.store.GetAutoCopy()
+}
+
+// SetAutoCopy sets the address added to every sent message as bcc or cc.
+// Empty address turns it off.
+func (u *User) SetAutoCopy(address, mode string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetAutoCopy(address, mode)
+}
+
+// GetFooter returns the plain text and HTML footer appended to every sent message.
+func (u *User) GetFooter() (plain, html string) {
+	if u.store == nil {
+		return "", ""
+	}
+
+	return u.store.GetFooter()
+}
+
+// SetFooter sets the footer appended to every sent message. Empty values
+// turn it off.
+func (u *User) SetFooter(plain, html string) error {
+	if u.store == nil {
+		return errors.New("store is not initialised")
+	}
+
+	return u.store.SetFooter(plain, html)
+}
+
+// GetAddressStats returns counts of messages sent and received per address.
+func (u *User) GetAddressStats() ([]store.AddressStats, error) {
+	if u.store == nil {
+		return nil, errors.New("store is not initialised")
+	}
+
+	return u.store.GetAddressStats()
+}
+
+// ListCachedRecipients returns recipients with public keys cached for sending.
+func (u *User) ListCachedRecipients() ([]store.CachedRecipient, error) {
+	if u.store == nil {
+		return nil, errors.New("store is not initialised")
+	}
+
+	return u.store.ListCachedRecipients(), nil
+}
+
+// FlushRecipientKeyCache forgets cached public keys of recipients.
+func (u *User) FlushRecipientKeyCache() (int, error) {
+	if u.store == nil {
+		return 0, errors.New("store is not initialised")
+	}
+
+	return u.store.FlushRecipientKeyCache(), nil
+}
+
+// GetSyncHistory returns the last syncs of the account, the most recent first.
+func (u *User) GetSyncHistory() ([]store.SyncRun, error) {
+	if u.store == nil {
+		return nil, errors.New("store is not initialised")
 	}
 
-	return u.creds.CheckPassword(password)
+	return u.store.GetSyncHistory()
+}
+
+// GetEventLoopStatus returns latency and backlog of processing of events.
+func (u *User) GetEventLoopStatus() (store.EventLoopStatus, error) {
+	if u.store == nil {
+		return store.EventLoopStatus{}, errors.New("store is not initialised")
+	}
+
+	return u.store.GetEventLoopStatus(), nil
 }
 
 // UpdateUser updates user details from API and saves to the credentials.
 func (u *User) UpdateUser() error {
+	logoutEvent, err := u.updateUser()
+	if logoutEvent != "" {
+		u.listener.Emit(logoutEvent, u.userID)
+	}
+	return err
+}
+
+func (u *User) updateUser() (logoutEvent string, err error) {
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
-	if err := u.authorizeIfNecessary(true); err != nil {
-		return errors.Wrap(err, "cannot update user")
+	if logoutEvent, err = u.authorizeIfNecessary(); err != nil {
+		return logoutEvent, errors.Wrap(err, "cannot update user")
 	}
 
-	_, err := u.client().UpdateUser()
-	if err != nil {
-		return err
+	if _, err = u.client().UpdateUser(); err != nil {
+		return "", err
 	}
 
 	if err = u.client().ReloadKeys([]byte(u.creds.MailboxPassword)); err != nil {
-		return errors.Wrap(err, "failed to reload keys")
+		return "", errors.Wrap(err, "failed to reload keys")
 	}
 
 	emails := u.client().Addresses().ActiveEmails()
 	if err := u.credStorer.UpdateEmails(u.userID, emails); err != nil {
-		return err
+		return "", err
 	}
 
 	u.refreshFromCredentials()
 
-	return nil
+	return "", nil
 }
 
 // SwitchAddressMode changes mode from combined to split and vice versa. The mode to switch to is determined by the
@@ -463,9 +923,109 @@ func (u *User) SwitchAddressMode() (err error) {
 	return err
 }
 
+// IsIMAPEnabled returns whether mail clients can read the account over IMAP.
+func (u *User) IsIMAPEnabled() bool {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return !u.creds.IsIMAPDisabled
+}
+
+// IsSMTPEnabled returns whether mail clients can send from the account over SMTP.
+func (u *User) IsSMTPEnabled() bool {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return !u.creds.IsSMTPDisabled
+}
+
+// GetProxy returns the proxy override of the account, pmapi.DirectConnection,
+// or empty string if the proxy from environment is used.
+func (u *User) GetProxy() string {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	return u.creds.Proxy
+}
+
+// SetProxy changes the proxy used to reach the API for this account only,
+// e.g. to route a work account through a corporate proxy.
+func (u *User) SetProxy(proxy string) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.log.WithField("isSet", proxy != "").Info("Changing proxy override")
+
+	if err := pmapi.ValidateUserProxy(proxy); err != nil {
+		return err
+	}
+
+	if err := u.credStorer.SetProxy(u.userID, proxy); err != nil {
+		return errors.Wrap(err, "failed to save proxy override")
+	}
+
+	u.refreshFromCredentials()
+
+	return u.clientManager.SetUserProxy(u.userID, proxy)
+}
+
+// SetIMAPEnabled allows or denies IMAP access, e.g. for send-only service accounts.
+// Active IMAP connections are closed when access is denied.
+func (u *User) SetIMAPEnabled(enabled bool) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.log.WithField("enabled", enabled).Info("Changing IMAP access")
+
+	if err := u.credStorer.SetIMAPEnabled(u.userID, enabled); err != nil {
+		return errors.Wrap(err, "failed to save IMAP access")
+	}
+
+	u.refreshFromCredentials()
+
+	if !enabled {
+		u.closeIMAPConnections()
+	}
+
+	return nil
+}
+
+// SetSMTPEnabled allows or denies SMTP access, e.g. for receive-only service accounts.
+// Active SMTP connections are closed when access is denied.
+func (u *User) SetSMTPEnabled(enabled bool) error {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.log.WithField("enabled", enabled).Info("Changing SMTP access")
+
+	if err := u.credStorer.SetSMTPEnabled(u.userID, enabled); err != nil {
+		return errors.Wrap(err, "failed to save SMTP access")
+	}
+
+	u.refreshFromCredentials()
+
+	if !enabled {
+		u.closeSMTPConnections()
+	}
+
+	return nil
+}
+
 // logout is the same as Logout, but for internal purposes (logged out from
 // the server) which emits LogoutEvent to notify other parts of the app.
 func (u *User) logout() error {
+	return u.logoutAndEmit(events.LogoutEvent)
+}
+
+// requireRelogin is the same as logout, but used when the session was revoked
+// by a password change. It emits ReloginRequiredEvent instead of LogoutEvent so
+// frontends can guide the user through logging in again. As with any logout,
+// the local store is kept and reused once the user logs in.
+func (u *User) requireRelogin() error {
+	return u.logoutAndEmit(events.ReloginRequiredEvent)
+}
+
+func (u *User) logoutAndEmit(event string) error {
 	u.lock.Lock()
 	wasConnected := u.creds.IsConnected()
 	u.lock.Unlock()
@@ -473,7 +1033,7 @@ func (u *User) logout() error {
 	err := u.Logout()
 
 	if wasConnected {
-		u.listener.Emit(events.LogoutEvent, u.userID)
+		u.listener.Emit(event, u.userID)
 		u.listener.Emit(events.UserRefreshEvent, u.userID)
 	}
 
@@ -543,6 +1103,26 @@ func (u *User) closeAllConnections() {
 	}
 }
 
+// closeIMAPConnections closes IMAP connections of all user addresses
+// and keeps SMTP ones open.
+func (u *User) closeIMAPConnections() {
+	for _, address := range u.creds.EmailList() {
+		u.listener.Emit(events.CloseIMAPConnectionEvent, address)
+	}
+
+	if u.store != nil {
+		u.store.SetIMAPUpdateChannel(nil)
+	}
+}
+
+// closeSMTPConnections closes SMTP connections of all user addresses
+// and keeps IMAP ones open.
+func (u *User) closeSMTPConnections() {
+	for _, address := range u.creds.EmailList() {
+		u.listener.Emit(events.CloseSMTPConnectionEvent, address)
+	}
+}
+
 // CloseConnection emits closeConnection event on `address` which should close all active connection.
 func (u *User) CloseConnection(address string) {
 	u.listener.Emit(events.CloseConnectionEvent, address)
@@ -104,6 +104,67 @@ func TestUserSwitchAddressMode(t *testing.T) {
 	waitForEvents()
 }
 
+func TestUserSetIMAPEnabled(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	user := testNewUser(m)
+	defer cleanUpUserData(user)
+
+	assert.True(t, user.IsIMAPEnabled())
+	waitForEvents()
+
+	credentialsIMAPDisabled := *testCredentials
+	credentialsIMAPDisabled.IsIMAPDisabled = true
+
+	gomock.InOrder(
+		m.credentialsStore.EXPECT().SetIMAPEnabled("user", false).Return(nil),
+		m.credentialsStore.EXPECT().Get("user").Return(&credentialsIMAPDisabled, nil),
+		m.eventListener.EXPECT().Emit(events.CloseIMAPConnectionEvent, "user@pm.me"),
+	)
+
+	assert.NoError(t, user.SetIMAPEnabled(false))
+	assert.False(t, user.IsIMAPEnabled())
+	assert.True(t, user.IsSMTPEnabled())
+
+	gomock.InOrder(
+		m.credentialsStore.EXPECT().SetIMAPEnabled("user", true).Return(nil),
+		m.credentialsStore.EXPECT().Get("user").Return(testCredentials, nil),
+	)
+
+	assert.NoError(t, user.SetIMAPEnabled(true))
+	assert.True(t, user.IsIMAPEnabled())
+
+	waitForEvents()
+}
+
+func TestUserSetProxy(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	user := testNewUser(m)
+	defer cleanUpUserData(user)
+
+	assert.Equal(t, "", user.GetProxy())
+	waitForEvents()
+
+	credentialsWithProxy := *testCredentials
+	credentialsWithProxy.Proxy = "socks5://127.0.0.1:1080"
+
+	gomock.InOrder(
+		m.credentialsStore.EXPECT().SetProxy("user", "socks5://127.0.0.1:1080").Return(nil),
+		m.credentialsStore.EXPECT().Get("user").Return(&credentialsWithProxy, nil),
+		m.clientManager.EXPECT().SetUserProxy("user", "socks5://127.0.0.1:1080").Return(nil),
+	)
+
+	assert.NoError(t, user.SetProxy("socks5://127.0.0.1:1080"))
+	assert.Equal(t, "socks5://127.0.0.1:1080", user.GetProxy())
+
+	assert.Error(t, user.SetProxy("ftp://proxy"))
+
+	waitForEvents()
+}
+
 func TestLogoutUser(t *testing.T) {
 	m := initMocks(t)
 	defer m.ctrl.Finish()
@@ -158,7 +219,7 @@ func TestCheckBridgeLoginOK(t *testing.T) {
 		m.pmapiClient.EXPECT().Unlock([]byte("pass")).Return(nil),
 	)
 
-	err := user.CheckBridgeLogin(testCredentials.BridgePassword)
+	err := user.CheckBridgeLogin("client", testCredentials.BridgePassword)
 
 	waitForEvents()
 
@@ -178,11 +239,11 @@ func TestCheckBridgeLoginTwiceOK(t *testing.T) {
 		m.pmapiClient.EXPECT().IsUnlocked().Return(true),
 	)
 
-	err := user.CheckBridgeLogin(testCredentials.BridgePassword)
+	err := user.CheckBridgeLogin("client", testCredentials.BridgePassword)
 	waitForEvents()
 	assert.NoError(t, err)
 
-	err = user.CheckBridgeLogin(testCredentials.BridgePassword)
+	err = user.CheckBridgeLogin("client", testCredentials.BridgePassword)
 	waitForEvents()
 	assert.NoError(t, err)
 }
@@ -198,7 +259,7 @@ func TestCheckBridgeLoginUpgradeApplication(t *testing.T) {
 
 	isApplicationOutdated = true
 
-	err := user.CheckBridgeLogin("any-pass")
+	err := user.CheckBridgeLogin("client", "any-pass")
 	waitForEvents()
 	assert.Equal(t, pmapi.ErrUpgradeApplication, err)
 
@@ -226,9 +287,14 @@ func TestCheckBridgeLoginLoggedOut(t *testing.T) {
 
 	defer cleanUpUserData(user)
 
-	m.eventListener.EXPECT().Emit(events.LogoutEvent, "user")
+	// Logout is a critical event delivered synchronously; its listeners
+	// need the user, so it must be emitted after the user lock is released.
+	m.eventListener.EXPECT().Emit(events.LogoutEvent, "user").Do(func(string, string) {
+		user.lock.Lock()
+		defer user.lock.Unlock()
+	})
 
-	err = user.CheckBridgeLogin(testCredentialsDisconnected.BridgePassword)
+	err = user.CheckBridgeLogin("client", testCredentialsDisconnected.BridgePassword)
 	waitForEvents()
 	assert.Equal(t, ErrLoggedOutUser, err)
 }
@@ -245,7 +311,60 @@ func TestCheckBridgeLoginBadPassword(t *testing.T) {
 		m.pmapiClient.EXPECT().Unlock([]byte("pass")).Return(nil),
 	)
 
-	err := user.CheckBridgeLogin("wrong!")
+	err := user.CheckBridgeLogin("client", "wrong!")
 	waitForEvents()
 	assert.Equal(t, "backend/credentials: incorrect password", err.Error())
 }
+
+func TestUserSetSMTPEnabled(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	user := testNewUser(m)
+	defer cleanUpUserData(user)
+
+	assert.True(t, user.IsSMTPEnabled())
+	waitForEvents()
+
+	credentialsSMTPDisabled := *testCredentials
+	credentialsSMTPDisabled.IsSMTPDisabled = true
+
+	// Only SMTP connections are closed, IMAP clients stay connected.
+	gomock.InOrder(
+		m.credentialsStore.EXPECT().SetSMTPEnabled("user", false).Return(nil),
+		m.credentialsStore.EXPECT().Get("user").Return(&credentialsSMTPDisabled, nil),
+		m.eventListener.EXPECT().Emit(events.CloseSMTPConnectionEvent, "user@pm.me"),
+	)
+
+	assert.NoError(t, user.SetSMTPEnabled(false))
+	assert.False(t, user.IsSMTPEnabled())
+	assert.True(t, user.IsIMAPEnabled())
+
+	waitForEvents()
+}
+
+func TestUserRotateBridgePassword(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	user := testNewUser(m)
+	defer cleanUpUserData(user)
+
+	waitForEvents()
+
+	credentialsRotated := *testCredentials
+	credentialsRotated.BridgePassword = "fedcba9876543210"
+
+	gomock.InOrder(
+		m.credentialsStore.EXPECT().RotateBridgePassword("user").Return(nil),
+		m.credentialsStore.EXPECT().Get("user").Return(&credentialsRotated, nil),
+		m.eventListener.EXPECT().Emit(events.CloseConnectionEvent, "user@pm.me"),
+	)
+
+	password, err := user.RotateBridgePassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "fedcba9876543210", password)
+	assert.Equal(t, "fedcba9876543210", user.GetBridgePassword())
+
+	waitForEvents()
+}
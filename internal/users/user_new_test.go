@@ -99,6 +99,29 @@ func TestNewUserAuthRefreshFails(t *testing.T) {
 	checkNewUserHasCredentials(testCredentialsDisconnected, m)
 }
 
+func TestNewUserAuthRevoked(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	m.clientManager.EXPECT().GetClient("user").Return(m.pmapiClient).MinTimes(1)
+	m.eventListener.EXPECT().Emit(events.ReloginRequiredEvent, "user")
+	m.eventListener.EXPECT().Emit(events.UserRefreshEvent, "user")
+	m.eventListener.EXPECT().Emit(events.CloseConnectionEvent, "user@pm.me")
+
+	gomock.InOrder(
+		m.credentialsStore.EXPECT().Get("user").Return(testCredentials, nil),
+		m.credentialsStore.EXPECT().Get("user").Return(testCredentials, nil),
+		m.pmapiClient.EXPECT().AuthRefresh("token").Return(nil, pmapi.ErrAuthRevoked),
+		m.credentialsStore.EXPECT().Logout("user").Return(nil),
+
+		m.pmapiClient.EXPECT().Logout(),
+		m.credentialsStore.EXPECT().Logout("user").Return(nil),
+		m.credentialsStore.EXPECT().Get("user").Return(testCredentialsDisconnected, nil),
+	)
+
+	checkNewUserHasCredentials(testCredentialsDisconnected, m)
+}
+
 func TestNewUserUnlockFails(t *testing.T) {
 	m := initMocks(t)
 	defer m.ctrl.Finish()
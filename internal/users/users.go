@@ -21,6 +21,7 @@ package users
 import (
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ProtonMail/proton-bridge/internal/events"
 	"github.com/ProtonMail/proton-bridge/internal/metrics"
@@ -63,6 +64,10 @@ type Users struct {
 	// The user stores should send idle updates on this channel.
 	idleUpdates chan imapBackend.Update
 
+	// unknownUserLimiter limits login attempts with usernames which do not
+	// belong to any account; those of accounts are limited by each user.
+	unknownUserLimiter loginLimiter
+
 	lock sync.RWMutex
 
 	// stopAll can be closed to stop all goroutines from looping (watchAppOutdated, watchAPIAuths, heartbeat etc).
@@ -172,7 +177,14 @@ func (u *Users) watchAPIAuths() {
 
 			if auth.Auth != nil {
 				user.updateAuthToken(auth.Auth)
-			} else if err := user.logout(); err != nil {
+				continue
+			}
+
+			logout := user.logout
+			if auth.Revoked {
+				logout = user.requireRelogin
+			}
+			if err := logout(); err != nil {
 				log.WithError(err).
 					WithField("userID", auth.UserID).
 					Error("User logout failed while watching API auths")
@@ -193,10 +205,20 @@ func (u *Users) closeAllConnections() {
 // Login authenticates a user by username/password, returning an authorised client and an auth object.
 // The authorisation scope may not yet be full if the user has 2FA enabled.
 func (u *Users) Login(username, password string) (authClient pmapi.Client, auth *pmapi.Auth, err error) {
+	return u.LoginWithHumanVerification(username, password, "", "")
+}
+
+// LoginWithHumanVerification authenticates a user like Login, sending also
+// the token obtained by passing human verification requested by the API
+// (see pmapi.ErrHumanVerificationRequired).
+func (u *Users) LoginWithHumanVerification(username, password, tokenType, token string) (authClient pmapi.Client, auth *pmapi.Auth, err error) {
 	u.crashBandicoot(username)
 
 	// We need to use anonymous client because we don't yet have userID and so can't save auth tokens yet.
 	authClient = u.clientManager.GetAnonymousClient()
+	if token != "" {
+		authClient.SetHumanVerificationToken(tokenType, token)
+	}
 
 	authInfo, err := authClient.AuthInfo(username)
 	if err != nil {
@@ -392,6 +414,25 @@ func (u *Users) GetUser(query string) (*User, error) {
 	return nil, errors.New("user " + query + " not found")
 }
 
+// CheckUnknownUserLogin records failed login of the client with username which
+// does not belong to any account. Guessing usernames is limited the same way as
+// guessing the bridge password and ErrLoginLockedOut is returned once the client
+// tried too many of them.
+func (u *Users) CheckUnknownUserLogin(client string) error {
+	now := time.Now()
+
+	if u.unknownUserLimiter.isLockedOut(client, now) {
+		return ErrLoginLockedOut
+	}
+
+	if clientLockedOut, allLockedOut := u.unknownUserLimiter.addFailure(client, now); clientLockedOut || allLockedOut {
+		log.WithField("client", client).Warn("Too many login attempts with unknown username, locking out")
+		return ErrLoginLockedOut
+	}
+
+	return nil
+}
+
 // ClearData closes all connections (to release db files and so on) and clears all data.
 func (u *Users) ClearData() error {
 	var result *multierror.Error
@@ -491,6 +532,16 @@ func (u *Users) CheckConnection() error {
 	return u.clientManager.CheckConnection()
 }
 
+// GetRequestStats returns latency and error counters of API requests.
+func (u *Users) GetRequestStats() []pmapi.EndpointStats {
+	return u.clientManager.GetRequestStats()
+}
+
+// GetClockSkew returns the difference between local and server clock.
+func (u *Users) GetClockSkew() time.Duration {
+	return u.clientManager.GetClockSkew()
+}
+
 // StopWatchers stops all goroutines.
 func (u *Users) StopWatchers() {
 	close(u.stopAll)
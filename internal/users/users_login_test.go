@@ -218,6 +218,44 @@ func TestUsersFinishLoginConnectedUser(t *testing.T) {
 	assert.Equal(t, "user is already connected", err.Error())
 }
 
+func TestAddUserFromToken(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	token := &AccountToken{RefreshToken: "uid:tok", MailboxPassword: testCredentials.MailboxPassword}
+
+	gomock.InOrder(
+		m.clientManager.EXPECT().GetAnonymousClient().Return(m.pmapiClient),
+		m.pmapiClient.EXPECT().AuthRefresh("uid:tok").Return(refreshWithToken("fromFile"), nil),
+		m.pmapiClient.EXPECT().AuthSalt().Return("", nil),
+		m.pmapiClient.EXPECT().Unlock([]byte(testCredentials.MailboxPassword)).Return(nil),
+		m.pmapiClient.EXPECT().CurrentUser().Return(testPMAPIUser, nil),
+		m.pmapiClient.EXPECT().Addresses().Return([]*pmapi.Address{testPMAPIAddress}),
+		m.credentialsStore.EXPECT().Add("user", "username", ":fromFile", testCredentials.MailboxPassword, []string{testPMAPIAddress.Email}),
+		m.pmapiClient.EXPECT().Logout(),
+	)
+
+	userID, err := AddUserFromToken(m.clientManager, m.credentialsStore, token, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "user", userID)
+}
+
+func TestAddUserFromTokenRevoked(t *testing.T) {
+	m := initMocks(t)
+	defer m.ctrl.Finish()
+
+	token := &AccountToken{RefreshToken: "uid:tok", MailboxPassword: testCredentials.MailboxPassword}
+
+	gomock.InOrder(
+		m.clientManager.EXPECT().GetAnonymousClient().Return(m.pmapiClient),
+		m.pmapiClient.EXPECT().AuthRefresh("uid:tok").Return(nil, pmapi.ErrAuthRevoked),
+		m.pmapiClient.EXPECT().Logout(),
+	)
+
+	_, err := AddUserFromToken(m.clientManager, m.credentialsStore, token, true)
+	assert.True(t, errors.Is(err, pmapi.ErrAuthRevoked))
+}
+
 func checkUsersFinishLogin(t *testing.T, m mocks, auth *pmapi.Auth, mailboxPassword string, expectedUserID string, expectedErr error) *User {
 	users := testNewUsers(t, m)
 	defer cleanUpUsersData(users)
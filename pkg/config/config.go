@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ProtonMail/go-appdir"
 	"github.com/hashicorp/go-multierror"
@@ -85,8 +86,10 @@ func (c *Config) CreateDirs() error {
 	return nil
 }
 
-// ClearData removes all files except the lock file.
-// The lock file will be removed when the Bridge stops.
+// ClearData removes all files except the lock file and IMAP UID maps.
+// The lock file will be removed when the Bridge stops. UID maps are kept
+// so rebuilt databases assign the same UIDs and clients don't have to
+// download all messages again.
 func (c *Config) ClearData() error {
 	dirs := []string{
 		c.appDirs.UserLogs(),
@@ -94,7 +97,7 @@ func (c *Config) ClearData() error {
 		c.appDirs.UserCache(),
 	}
 	shouldRemove := func(filePath string) bool {
-		return filePath != c.GetLockPath()
+		return filePath != c.GetLockPath() && !strings.HasSuffix(filePath, uidMapSuffix)
 	}
 	return c.removeAllExcept(dirs, shouldRemove)
 }
@@ -203,6 +206,10 @@ func (c *Config) GetDBDir() string {
 	return c.appDirsVersion.UserCache()
 }
 
+// uidMapSuffix is the suffix of files with IMAP UIDs which the store keeps
+// next to its database.
+const uidMapSuffix = ".uids.json"
+
 // GetEventsPath returns path to events file containing the last processed event IDs.
 func (c *Config) GetEventsPath() string {
 	return filepath.Join(c.appDirsVersion.UserCache(), "events.json")
@@ -228,6 +235,40 @@ func (c *Config) GetPreferencesPath() string {
 	return filepath.Join(c.appDirsVersion.UserCache(), "prefs.json")
 }
 
+// GetTLSPinsPath returns path to the file overriding trusted TLS pins of the API.
+func (c *Config) GetTLSPinsPath() string {
+	return filepath.Join(c.appDirs.UserConfig(), "tls_pins.json")
+}
+
+// GetSignedTLSPinsPath returns path to the last signed manifest of trusted TLS
+// pins downloaded from the update server. Its signature is stored next to it.
+func (c *Config) GetSignedTLSPinsPath() string {
+	return filepath.Join(c.appDirs.UserConfig(), "tls_pins_signed.json")
+}
+
+// GetLocalSocketPath returns path to unix socket of the protocol server,
+// e.g. imap or smtp, used by local clients without the bridge password.
+func (c *Config) GetLocalSocketPath(protocol string) string {
+	return filepath.Join(c.appDirs.UserConfig(), protocol+".sock")
+}
+
+// GetKeychainPath returns path to the encrypted file keychain used when
+// there is no native keychain, e.g. on headless servers.
+func (c *Config) GetKeychainPath() string {
+	return filepath.Join(c.appDirs.UserConfig(), "keychain.json")
+}
+
+// GetCheckpointsPath returns path to database with progress of import-export
+// transfers. It is kept outside of the cache so it survives updates.
+func (c *Config) GetCheckpointsPath() string {
+	return filepath.Join(c.appDirs.UserConfig(), "checkpoints.db")
+}
+
+// GetPluginsDir returns folder with Go plugins adding import-export providers.
+func (c *Config) GetPluginsDir() string {
+	return filepath.Join(c.appDirs.UserConfig(), "plugins")
+}
+
 // GetTransferDir returns folder for import-export rules files.
 func (c *Config) GetTransferDir() string {
 	return c.appDirsVersion.UserCache()
@@ -83,6 +83,7 @@ func TestClearDataLinux(t *testing.T) {
 		"cache",
 		"cache/c2",
 		"cache/c2/bridge-test.lock",
+		"cache/c2/mailbox-user@pm.me.uids.json",
 		"config",
 		"logs",
 	})
@@ -99,6 +100,7 @@ func TestClearDataWindows(t *testing.T) {
 		"cache",
 		"cache/c2",
 		"cache/c2/bridge-test.lock",
+		"cache/c2/mailbox-user@pm.me.uids.json",
 		"config",
 	})
 }
@@ -118,6 +120,7 @@ func TestClearOldDataLinux(t *testing.T) {
 		"cache/c2/bridge-test.lock",
 		"cache/c2/events.json",
 		"cache/c2/mailbox-user@pm.me.db",
+		"cache/c2/mailbox-user@pm.me.uids.json",
 		"cache/c2/prefs.json",
 		"cache/c2/updates",
 		"cache/c2/user_info.json",
@@ -148,6 +151,7 @@ func TestClearOldDataWindows(t *testing.T) {
 		"cache/c2/bridge-test.lock",
 		"cache/c2/events.json",
 		"cache/c2/mailbox-user@pm.me.db",
+		"cache/c2/mailbox-user@pm.me.uids.json",
 		"cache/c2/prefs.json",
 		"cache/c2/updates",
 		"cache/c2/user_info.json",
@@ -212,6 +216,7 @@ func createTestStructure(m mocks, baseDir, logsDir, configDir, cacheDir, version
 	require.NoError(m.t, ioutil.WriteFile(filepath.Join(versionedCacheDir, "user_info.json"), []byte("Hello"), 0755))
 	require.NoError(m.t, ioutil.WriteFile(filepath.Join(versionedCacheDir, testAppName+".lock"), []byte("Hello"), 0755))
 	require.NoError(m.t, ioutil.WriteFile(filepath.Join(versionedCacheDir, "mailbox-user@pm.me.db"), []byte("Hello"), 0755))
+	require.NoError(m.t, ioutil.WriteFile(filepath.Join(versionedCacheDir, "mailbox-user@pm.me.uids.json"), []byte("Hello"), 0755))
 }
 
 func checkFileNames(t *testing.T, dir string, expectedFileNames []string) {
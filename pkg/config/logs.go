@@ -125,6 +125,18 @@ func SetupLog(cfg logConfiger, levelFlag string) (debugClient, debugServer bool)
 	return debugClient, debugServer
 }
 
+// SetLogLevel changes the log level at runtime. Unlike SetupLog it keeps
+// the current formatter and output, therefore the switch between file and
+// stdout or debugging of IMAP and SMTP servers still requires restart.
+func SetLogLevel(levelFlag string) {
+	level, _ := getLogLevelAndFile(levelFlag)
+	if level == logrus.GetLevel() {
+		return
+	}
+	log.WithField("level", level).Info("Changing log level")
+	logrus.SetLevel(level)
+}
+
 func setLogFile(logDir, logPrefix string) {
 	if logFile != nil {
 		return
@@ -154,6 +154,7 @@ func TestClearLogsLinux(t *testing.T) {
 		"cache/c2/bridge-test.lock",
 		"cache/c2/events.json",
 		"cache/c2/mailbox-user@pm.me.db",
+		"cache/c2/mailbox-user@pm.me.uids.json",
 		"cache/c2/prefs.json",
 		"cache/c2/updates",
 		"cache/c2/user_info.json",
@@ -189,6 +190,7 @@ func TestClearLogsWindows(t *testing.T) {
 		"cache/c2/bridge-test.lock",
 		"cache/c2/events.json",
 		"cache/c2/mailbox-user@pm.me.db",
+		"cache/c2/mailbox-user@pm.me.uids.json",
 		"cache/c2/prefs.json",
 		"cache/c2/updates",
 		"cache/c2/user_info.json",
@@ -62,6 +62,39 @@ func (p *Preferences) load() error {
 	return json.NewDecoder(f).Decode(&p.cache)
 }
 
+// Reload drops the cached values and loads them again from the file.
+// It returns keys which values were changed, added or removed.
+func (p *Preferences) Reload() (changed []string, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() //nolint[errcheck]
+
+	newCache := map[string]string{}
+	if err := json.NewDecoder(f).Decode(&newCache); err != nil {
+		return nil, err
+	}
+
+	for key, value := range newCache {
+		if oldValue, ok := p.cache[key]; !ok || oldValue != value {
+			changed = append(changed, key)
+		}
+	}
+	for key := range p.cache {
+		if _, ok := newCache[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+
+	p.cache = newCache
+
+	return changed, nil
+}
+
 func (p *Preferences) save() error {
 	if p.cache == nil {
 		return errors.New("cannot save preferences: cache is nil")
@@ -85,6 +118,18 @@ func (p *Preferences) SetDefault(key, value string) {
 	}
 }
 
+// GetAll returns copy of all stored values.
+func (p *Preferences) GetAll() map[string]string {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	values := make(map[string]string, len(p.cache))
+	for key, value := range p.cache {
+		values[key] = value
+	}
+	return values
+}
+
 func (p *Preferences) Get(key string) string {
 	p.lock.RLock()
 	defer p.lock.RUnlock()
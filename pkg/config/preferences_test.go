@@ -92,6 +92,27 @@ func TestPreferencesSetBool(t *testing.T) {
 	checkSavedPreferences(t, "{\"falseBool\":\"false\",\"trueBool\":\"true\"}")
 }
 
+func TestPreferencesReload(t *testing.T) {
+	pref := newTestPreferences(t)
+	require.NoError(t, ioutil.WriteFile(testPrefFilePath, []byte("{\"str\":\"value\",\"int\":\"43\",\"bool\":\"true\",\"new\":\"x\"}"), 0700))
+
+	changed, err := pref.Reload()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"int", "new", "falseBool"}, changed)
+	require.Equal(t, 43, pref.GetInt("int"))
+	require.Equal(t, "x", pref.Get("new"))
+	require.Equal(t, "", pref.Get("falseBool"))
+}
+
+func TestPreferencesReloadBadFile(t *testing.T) {
+	pref := newTestPreferences(t)
+	require.NoError(t, ioutil.WriteFile(testPrefFilePath, []byte("{\"str\":\"val"), 0700))
+
+	_, err := pref.Reload()
+	require.Error(t, err)
+	require.Equal(t, 42, pref.GetInt("int"))
+}
+
 func newTestEmptyPreferences(t *testing.T) *Preferences {
 	require.NoError(t, os.RemoveAll(testPrefFilePath))
 	return NewPreferences(testPrefFilePath)
@@ -25,6 +25,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"net"
 	"os"
@@ -50,7 +51,7 @@ var tlsTemplate = x509.Certificate{ //nolint[gochecknoglobals]
 	ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
 	BasicConstraintsValid: true,
 	IsCA:                  true,
-	IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
 	NotBefore:             time.Now(),
 	NotAfter:              time.Now().Add(20 * 365 * 24 * time.Hour),
 }
@@ -104,6 +105,43 @@ func GetTLSConfig(cfg tlsConfiger) (tlsConfig *tls.Config, err error) {
 	return tlsConfig, err
 }
 
+// GetTLSClientConfig returns TLS config trusting the existing certificate
+// of the running instance. Unlike GetTLSConfig, it never generates a new one.
+func GetTLSClientConfig(cfg tlsConfiger) (*tls.Config, error) {
+	tlsConfig, err := loadTLSConfig(cfg.GetTLSCertPath(), cfg.GetTLSKeyPath())
+	if err != nil && err != ErrTLSCertExpireSoon {
+		return nil, err
+	}
+
+	tlsConfig.ServerName = "127.0.0.1"
+
+	caCertPool := x509.NewCertPool()
+	caCertPool.AddCert(tlsConfig.Certificates[0].Leaf)
+	tlsConfig.RootCAs = caCertPool
+
+	return tlsConfig, nil
+}
+
+// GetTLSConfigWithClientAuth returns a copy of the server TLS config which
+// accepts only clients with a certificate issued by one of the CAs from the
+// PEM file.
+func GetTLSConfigWithClientAuth(tlsConfig *tls.Config, clientCAPath string) (*tls.Config, error) {
+	pemCerts, err := ioutil.ReadFile(clientCAPath) //nolint[gosec]
+	if err != nil {
+		return nil, err
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(pemCerts) {
+		return nil, fmt.Errorf("no certificate found in %s", clientCAPath)
+	}
+
+	serverConfig := tlsConfig.Clone()
+	serverConfig.ClientCAs = clientCAs
+	serverConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return serverConfig, nil
+}
+
 func loadTLSConfig(certPath, keyPath string) (tlsConfig *tls.Config, err error) {
 	c, err := tls.LoadX509KeyPair(certPath, keyPath)
 	if err != nil {
@@ -18,6 +18,8 @@
 package config
 
 import (
+	"crypto/tls"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -60,4 +62,35 @@ func TestTLSKeyRenewal(t *testing.T) {
 	require.Equal(t, len(cert.Certificates), 1)
 	now, notValidAfter = time.Now(), cert.Certificates[0].Leaf.NotAfter
 	require.False(t, now.After(notValidAfter), "new certificate expected to be valid at %v but have valid until %v", now, notValidAfter)
+
+	// Both loopback addresses can be used for listening.
+	require.NoError(t, cert.Certificates[0].Leaf.VerifyHostname("127.0.0.1"))
+	require.NoError(t, cert.Certificates[0].Leaf.VerifyHostname("::1"))
+}
+
+func TestGetTLSConfigWithClientAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls-client-ca")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	caPath := filepath.Join(dir, "ca.pem")
+	serverConfig, err := GenerateTLSConfig(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+	require.NoError(t, err)
+	ca, err := GenerateTLSConfig(caPath, filepath.Join(dir, "ca-key.pem"))
+	require.NoError(t, err)
+
+	clientAuthConfig, err := GetTLSConfigWithClientAuth(serverConfig, caPath)
+	require.NoError(t, err)
+	require.Equal(t, tls.RequireAndVerifyClientCert, clientAuthConfig.ClientAuth)
+	require.Equal(t, serverConfig.Certificates, clientAuthConfig.Certificates)
+	require.Len(t, clientAuthConfig.ClientCAs.Subjects(), 1)                                       //nolint[staticcheck]
+	require.Equal(t, ca.Certificates[0].Leaf.RawSubject, clientAuthConfig.ClientCAs.Subjects()[0]) //nolint[staticcheck]
+
+	// The original config is not changed.
+	require.NotEqual(t, tls.RequireAndVerifyClientCert, serverConfig.ClientAuth)
+
+	_, err = GetTLSConfigWithClientAuth(serverConfig, filepath.Join(dir, "ca-key.pem"))
+	require.Error(t, err)
+	_, err = GetTLSConfigWithClientAuth(serverConfig, filepath.Join(dir, "missing.pem"))
+	require.Error(t, err)
 }
@@ -0,0 +1,180 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+)
+
+const (
+	// chunkedPrefix marks the main entry of a secret stored in several chunks.
+	// The main entry then holds only the number of chunks. Colon cannot
+	// appear in base64, so it cannot be mistaken for a regular secret.
+	chunkedPrefix = "chunked:"
+
+	chunkURLSeparator = "#chunk"
+)
+
+// chunkedHelper wraps a helper which cannot store secrets over a certain size
+// and transparently splits larger secrets across several entries.
+type chunkedHelper struct {
+	credentials.Helper
+	maxSecretSize int
+}
+
+func newChunkedHelper(helper credentials.Helper, maxSecretSize int) *chunkedHelper {
+	return &chunkedHelper{
+		Helper:        helper,
+		maxSecretSize: maxSecretSize,
+	}
+}
+
+func (h *chunkedHelper) Add(cred *credentials.Credentials) error {
+	h.deleteStaleChunks(cred.ServerURL)
+
+	if len(cred.Secret) <= h.maxSecretSize {
+		return h.Helper.Add(cred)
+	}
+
+	chunks := splitSecret(cred.Secret, h.maxSecretSize)
+
+	for i, chunk := range chunks {
+		if err := h.Helper.Add(&credentials.Credentials{
+			ServerURL: chunkURL(cred.ServerURL, i),
+			Username:  cred.Username,
+			Secret:    chunk,
+		}); err != nil {
+			h.deleteChunks(cred.ServerURL, i)
+			return err
+		}
+	}
+
+	if err := h.Helper.Add(&credentials.Credentials{
+		ServerURL: cred.ServerURL,
+		Username:  cred.Username,
+		Secret:    chunkedPrefix + strconv.Itoa(len(chunks)),
+	}); err != nil {
+		h.deleteChunks(cred.ServerURL, len(chunks))
+		return err
+	}
+
+	return nil
+}
+
+func (h *chunkedHelper) Get(serverURL string) (username, secret string, err error) {
+	if username, secret, err = h.Helper.Get(serverURL); err != nil {
+		return
+	}
+
+	count, ok := parseChunkCount(secret)
+	if !ok {
+		return
+	}
+
+	var builder strings.Builder
+	for i := 0; i < count; i++ {
+		_, chunk, err := h.Helper.Get(chunkURL(serverURL, i))
+		if err != nil {
+			return "", "", err
+		}
+		builder.WriteString(chunk)
+	}
+
+	return username, builder.String(), nil
+}
+
+func (h *chunkedHelper) Delete(serverURL string) error {
+	if _, secret, err := h.Helper.Get(serverURL); err == nil {
+		if count, ok := parseChunkCount(secret); ok {
+			h.deleteChunks(serverURL, count)
+		}
+	}
+
+	return h.Helper.Delete(serverURL)
+}
+
+// List hides entries of the chunks, only main entries are listed.
+func (h *chunkedHelper) List() (map[string]string, error) {
+	userIDByURL, err := h.Helper.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for itemURL := range userIDByURL {
+		if strings.Contains(itemURL, chunkURLSeparator) {
+			delete(userIDByURL, itemURL)
+		}
+	}
+
+	return userIDByURL, nil
+}
+
+// deleteStaleChunks removes chunks of the previous value, including the ones
+// left behind by an interrupted Add, so they cannot be mixed with a new value.
+func (h *chunkedHelper) deleteStaleChunks(serverURL string) {
+	userIDByURL, err := h.Helper.List()
+	if err != nil {
+		log.WithError(err).Warn("Failed to list keychain chunks")
+		return
+	}
+
+	for itemURL := range userIDByURL {
+		if !strings.HasPrefix(itemURL, serverURL+chunkURLSeparator) {
+			continue
+		}
+		if err := h.Helper.Delete(itemURL); err != nil {
+			log.WithError(err).WithField("chunk", itemURL).Warn("Failed to delete keychain chunk")
+		}
+	}
+}
+
+func (h *chunkedHelper) deleteChunks(serverURL string, count int) {
+	for i := 0; i < count; i++ {
+		if err := h.Helper.Delete(chunkURL(serverURL, i)); err != nil {
+			log.WithError(err).WithField("chunk", i).Warn("Failed to delete keychain chunk")
+		}
+	}
+}
+
+func chunkURL(serverURL string, index int) string {
+	return serverURL + chunkURLSeparator + strconv.Itoa(index)
+}
+
+func parseChunkCount(secret string) (int, bool) {
+	if !strings.HasPrefix(secret, chunkedPrefix) {
+		return 0, false
+	}
+
+	count, err := strconv.Atoi(strings.TrimPrefix(secret, chunkedPrefix))
+	if err != nil || count <= 0 {
+		return 0, false
+	}
+
+	return count, true
+}
+
+func splitSecret(secret string, size int) (chunks []string) {
+	for len(secret) > size {
+		chunks = append(chunks, secret[:size])
+		secret = secret[size:]
+	}
+	return append(chunks, secret)
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkedHelperOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chunked-keychain")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	fileHelper, err := openFileKeychain(filepath.Join(dir, "keychain.json"), []byte("passphrase"))
+	require.NoError(t, err)
+	helper := newChunkedHelper(fileHelper, 4)
+
+	// Chunk left behind by an interrupted Add.
+	require.NoError(t, fileHelper.Add(&credentials.Credentials{ServerURL: chunkURL("url/user", 5), Username: "user", Secret: "stale"}))
+
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "url/user", Username: "user", Secret: "0123456789"}))
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "url/user", Username: "user", Secret: "abc"}))
+
+	_, secret, err := helper.Get("url/user")
+	require.NoError(t, err)
+	require.Equal(t, "abc", secret)
+
+	list, err := fileHelper.List()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"url/user": "user"}, list)
+}
@@ -0,0 +1,276 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Parameters of scrypt deriving the key of the file keychain.
+const (
+	fileKeychainSaltSize = 16
+	fileKeychainScryptN  = 1 << 15
+	fileKeychainScryptR  = 8
+	fileKeychainScryptP  = 1
+	fileKeychainKeySize  = 32
+)
+
+var (
+	ErrFileKeychainNoPath   = errors.New("file keychain needs path of the file")
+	ErrFileKeychainNoSecret = errors.New("file keychain needs passphrase or key file")
+	ErrFileKeychainLocked   = errors.New("file keychain cannot be decrypted, wrong passphrase or key file")
+
+	fileOptions     FileKeychainOptions //nolint[gochecknoglobals]
+	fileOptionsLock sync.RWMutex        //nolint[gochecknoglobals]
+)
+
+// FileKeychainOptions configures the keychain stored in an encrypted file.
+// The file is protected either by the passphrase or by the content of
+// the key file; when both are set, the key file is used.
+type FileKeychainOptions struct {
+	Path       string
+	KeyFile    string
+	Passphrase string
+}
+
+// IsConfigured returns whether the file keychain can be used.
+func (opts FileKeychainOptions) IsConfigured() bool {
+	return opts.Path != "" && (opts.KeyFile != "" || opts.Passphrase != "")
+}
+
+// SetFileKeychain configures the file keychain. It is used when it is
+// the preferred helper or when no native keychain is available.
+func SetFileKeychain(opts FileKeychainOptions) {
+	fileOptionsLock.Lock()
+	defer fileOptionsLock.Unlock()
+	fileOptions = opts
+}
+
+func getFileKeychainOptions() FileKeychainOptions {
+	fileOptionsLock.RLock()
+	defer fileOptionsLock.RUnlock()
+	return fileOptions
+}
+
+// encryptedFile is the content of the keychain file. Data holds encrypted
+// JSON of entries by server URL.
+type encryptedFile struct {
+	Salt  []byte
+	Nonce []byte
+	Data  []byte
+}
+
+type fileEntry struct {
+	Username string
+	Secret   string
+}
+
+// fileKeychain is a helper storing all credentials in one file encrypted
+// by AES-GCM. It is meant for systems without any native keychain, e.g.
+// headless servers or containers.
+type fileKeychain struct {
+	path string
+	salt []byte
+	aead cipher.AEAD
+}
+
+func newFileKeychain() (credentials.Helper, error) {
+	opts := getFileKeychainOptions()
+	if opts.Path == "" {
+		return nil, ErrFileKeychainNoPath
+	}
+
+	secret := []byte(opts.Passphrase)
+	if opts.KeyFile != "" {
+		var err error
+		if secret, err = ioutil.ReadFile(opts.KeyFile); err != nil {
+			return nil, errors.Wrap(err, "failed to read key file")
+		}
+	}
+	if len(secret) == 0 {
+		return nil, ErrFileKeychainNoSecret
+	}
+
+	log.WithField("path", opts.Path).Debug("Creating file keychain")
+	return openFileKeychain(opts.Path, secret)
+}
+
+// openFileKeychain derives the key from the secret and checks it can
+// decrypt the existing file. The file is created if it does not exist yet.
+func openFileKeychain(path string, secret []byte) (*fileKeychain, error) {
+	h := &fileKeychain{path: path}
+
+	file, err := h.readFile()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if file != nil {
+		h.salt = file.Salt
+	} else {
+		h.salt = make([]byte, fileKeychainSaltSize)
+		if _, err := rand.Read(h.salt); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := scrypt.Key(secret, h.salt, fileKeychainScryptN, fileKeychainScryptR, fileKeychainScryptP, fileKeychainKeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if h.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+
+	if file == nil {
+		return h, h.save(map[string]fileEntry{})
+	}
+	if _, err := h.decrypt(file); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *fileKeychain) readFile() (*encryptedFile, error) {
+	b, err := ioutil.ReadFile(h.path)
+	if err != nil {
+		return nil, err
+	}
+	file := &encryptedFile{}
+	if err := json.Unmarshal(b, file); err != nil {
+		return nil, errors.Wrap(err, "failed to parse file keychain")
+	}
+	return file, nil
+}
+
+func (h *fileKeychain) decrypt(file *encryptedFile) (map[string]fileEntry, error) {
+	data, err := h.aead.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, ErrFileKeychainLocked
+	}
+	entries := map[string]fileEntry{}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, errors.Wrap(err, "failed to parse decrypted file keychain")
+	}
+	return entries, nil
+}
+
+func (h *fileKeychain) load() (map[string]fileEntry, error) {
+	file, err := h.readFile()
+	if os.IsNotExist(err) {
+		return map[string]fileEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return h.decrypt(file)
+}
+
+// save writes entries to a temporary file first so the keychain is never
+// left half written.
+func (h *fileKeychain) save(entries map[string]fileEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	file := &encryptedFile{
+		Salt:  h.salt,
+		Nonce: make([]byte, h.aead.NonceSize()),
+	}
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return err
+	}
+	file.Data = h.aead.Seal(nil, file.Nonce, data, nil)
+
+	b, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0700); err != nil {
+		return err
+	}
+	tmpPath := h.path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, b, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, h.path)
+}
+
+func (h *fileKeychain) Add(cred *credentials.Credentials) error {
+	entries, err := h.load()
+	if err != nil {
+		return err
+	}
+	entries[cred.ServerURL] = fileEntry{
+		Username: cred.Username,
+		Secret:   cred.Secret,
+	}
+	return h.save(entries)
+}
+
+func (h *fileKeychain) Delete(serverURL string) error {
+	entries, err := h.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[serverURL]; !ok {
+		return credentials.NewErrCredentialsNotFound()
+	}
+	delete(entries, serverURL)
+	return h.save(entries)
+}
+
+func (h *fileKeychain) Get(serverURL string) (string, string, error) {
+	entries, err := h.load()
+	if err != nil {
+		return "", "", err
+	}
+	entry, ok := entries[serverURL]
+	if !ok {
+		return "", "", credentials.NewErrCredentialsNotFound()
+	}
+	return entry.Username, entry.Secret, nil
+}
+
+func (h *fileKeychain) List() (map[string]string, error) {
+	entries, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+	userIDByURL := make(map[string]string, len(entries))
+	for serverURL, entry := range entries {
+		userIDByURL[serverURL] = entry.Username
+	}
+	return userIDByURL, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package keychain
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileKeychain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-keychain")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+	path := filepath.Join(dir, "keychain.json")
+
+	helper, err := openFileKeychain(path, []byte("passphrase"))
+	require.NoError(t, err)
+
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "url/user1", Username: "user1", Secret: "secret1"}))
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "url/user2", Username: "user2", Secret: "secret2"}))
+	require.NoError(t, helper.Delete("url/user2"))
+	require.True(t, credentials.IsErrCredentialsNotFound(helper.Delete("url/user2")))
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(content), "secret1")
+
+	_, err = openFileKeychain(path, []byte("wrong"))
+	require.Equal(t, ErrFileKeychainLocked, err)
+
+	helper, err = openFileKeychain(path, []byte("passphrase"))
+	require.NoError(t, err)
+
+	userID, secret, err := helper.Get("url/user1")
+	require.NoError(t, err)
+	require.Equal(t, "user1", userID)
+	require.Equal(t, "secret1", secret)
+
+	list, err := helper.List()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"url/user1": "user1"}, list)
+}
+
+func TestPreferredFileKeychain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-keychain")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("key"), 0600))
+
+	SetPreferredHelper(FileHelper)
+	defer SetPreferredHelper("")
+
+	SetFileKeychain(FileKeychainOptions{Path: filepath.Join(dir, "keychain.json")})
+	defer SetFileKeychain(FileKeychainOptions{})
+	_, err = NewAccess("bridge")
+	require.Equal(t, ErrFileKeychainNoSecret, err)
+
+	SetFileKeychain(FileKeychainOptions{Path: filepath.Join(dir, "keychain.json"), KeyFile: keyFile})
+	access, err := NewAccess("bridge")
+	require.NoError(t, err)
+
+	require.NoError(t, access.Put("user", "secret"))
+	userIDs, err := access.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{"user"}, userIDs)
+}
@@ -39,16 +39,71 @@ var (
 	ErrMacKeychainList     = errors.New("function `osxkeychain.List()` is not valid function for mac keychain. Use `Access.ListKeychain()` instead")
 	ErrNoKeychainInstalled = errors.New("no keychain management installed on this system")
 	accessLocker           = &sync.Mutex{} //nolint[gochecknoglobals]
+
+	preferredHelper string //nolint[gochecknoglobals]
+
+	helperFactories = map[string]HelperFactory{ //nolint[gochecknoglobals]
+		FileHelper: newFileKeychain,
+	}
+	helperFactoriesLock sync.RWMutex //nolint[gochecknoglobals]
 )
 
-// NewAccess creates a new native keychain.
+// Names of keychain helpers the user can choose from where the system
+// offers more than one.
+const (
+	PassHelper          = "pass"
+	SecretServiceHelper = "secret-service"
+	FileHelper          = "file"
+)
+
+// HelperFactory creates a helper or returns an error when the helper cannot
+// be used on the system.
+type HelperFactory func() (credentials.Helper, error)
+
+// RegisterHelper adds a helper which is used instead of the native keychain
+// when it is preferred.
+func RegisterHelper(name string, factory HelperFactory) {
+	helperFactoriesLock.Lock()
+	defer helperFactoriesLock.Unlock()
+	helperFactories[name] = factory
+}
+
+func getHelperFactory(name string) (HelperFactory, bool) {
+	helperFactoriesLock.RLock()
+	defer helperFactoriesLock.RUnlock()
+	factory, ok := helperFactories[name]
+	return factory, ok
+}
+
+// SetPreferredHelper makes NewAccess try the helper with the given name
+// first. Empty name or a helper not available on the system means default.
+func SetPreferredHelper(name string) {
+	preferredHelper = name
+}
+
+// newHelper creates the preferred registered helper or the native keychain.
+// The file keychain is used when there is no native one but it is configured.
+func newHelper() (credentials.Helper, error) {
+	if factory, ok := getHelperFactory(preferredHelper); ok {
+		return factory()
+	}
+
+	helper, err := newKeychain()
+	if err == ErrNoKeychainInstalled && getFileKeychainOptions().IsConfigured() {
+		log.Warn("No native keychain, using file keychain")
+		return newFileKeychain()
+	}
+	return helper, err
+}
+
+// NewAccess creates a new keychain, native one unless other is preferred.
 func NewAccess(appName string) (*Access, error) {
-	newHelper, err := newKeychain()
+	helper, err := newHelper()
 	if err != nil {
 		return nil, err
 	}
 	return &Access{
-		helper:            newHelper,
+		helper:            helper,
 		KeychainURL:       "protonmail/" + appName + "/users",
 		KeychainOldURL:    "protonmail/users",
 		KeychainMacURL:    "ProtonMail" + strings.Title(appName) + "Service",
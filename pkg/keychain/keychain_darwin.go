@@ -40,6 +40,11 @@ func newKeychain() (credentials.Helper, error) {
 	return &osxkeychain{}, nil
 }
 
+// AvailableHelpers returns nothing as macOS Keychain is the only option.
+func AvailableHelpers() []string {
+	return nil
+}
+
 func newQuery(serviceName, username string) mackeychain.Item {
 	query := mackeychain.NewItem()
 	query.SetSecClass(mackeychain.SecClassGenericPassword)
@@ -108,6 +113,11 @@ func (s *osxkeychain) Get(serverURL string) (userID string, secret string, err e
 
 // ListKeychain lists items in our services.
 func (s *Access) ListKeychain() (userIDByURL map[string]string, err error) {
+	// Other helpers than macOS Keychain can list items by themselves.
+	if _, ok := s.helper.(*osxkeychain); !ok {
+		return s.helper.List()
+	}
+
 	// Pick up correct service name and trim '/'.
 	serviceName, _, err := splitServiceAndID(s.KeychainOldName("not-id"))
 	if err != nil {
@@ -24,24 +24,48 @@ import (
 )
 
 func newKeychain() (credentials.Helper, error) {
-	log.Debug("Creating pass")
-	passHelper := &pass.Pass{}
-	passErr := checkPassIsUsable(passHelper)
-	if passErr == nil {
-		return passHelper, nil
-	}
-
-	log.Debug("Creating secretservice")
-	sserviceHelper := &secretservice.Secretservice{}
-	_, sserviceErr := sserviceHelper.List()
-	if sserviceErr == nil {
-		return sserviceHelper, nil
+	var passErr, sserviceErr error
+	for _, name := range helpersInOrder() {
+		switch name {
+		case PassHelper:
+			log.Debug("Creating pass")
+			passHelper := &pass.Pass{}
+			if passErr = checkPassIsUsable(passHelper); passErr == nil {
+				return passHelper, nil
+			}
+		case SecretServiceHelper:
+			log.Debug("Creating secretservice")
+			sserviceHelper := &secretservice.Secretservice{}
+			if _, sserviceErr = sserviceHelper.List(); sserviceErr == nil {
+				return sserviceHelper, nil
+			}
+		}
 	}
 
 	log.Error("No keychain! Pass: ", passErr, ", secretService: ", sserviceErr)
 	return nil, ErrNoKeychainInstalled
 }
 
+// helpersInOrder returns pass before secret service unless the user
+// prefers the other one.
+func helpersInOrder() []string {
+	if preferredHelper == SecretServiceHelper {
+		return []string{SecretServiceHelper, PassHelper}
+	}
+	return []string{PassHelper, SecretServiceHelper}
+}
+
+// AvailableHelpers returns names of helpers which are usable right now.
+func AvailableHelpers() (names []string) {
+	if checkPassIsUsable(&pass.Pass{}) == nil {
+		names = append(names, PassHelper)
+	}
+	if _, err := (&secretservice.Secretservice{}).List(); err == nil {
+		names = append(names, SecretServiceHelper)
+	}
+	return names
+}
+
 func checkPassIsUsable(passHelper *pass.Pass) (err error) {
 	creds := &credentials.Credentials{
 		ServerURL: "initCheck/pass",
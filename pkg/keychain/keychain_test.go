@@ -19,8 +19,10 @@ package keychain
 
 import (
 	"encoding/base64"
+	"errors"
 	"testing"
 
+	"github.com/docker/docker-credential-helpers/credentials"
 	"github.com/stretchr/testify/require"
 )
 
@@ -150,3 +152,70 @@ func TestInsertReadRemove(t *testing.T) { // nolint[funlen]
 		require.NotContains(t, actualList, id)
 	}
 }
+
+// memoryHelper is an in-memory keychain refusing too large secrets like wincred does.
+type memoryHelper struct {
+	maxSecretSize int
+	creds         map[string]*credentials.Credentials
+}
+
+func (h *memoryHelper) Add(cred *credentials.Credentials) error {
+	if len(cred.Secret) > h.maxSecretSize {
+		return errors.New("secret too large")
+	}
+	h.creds[cred.ServerURL] = cred
+	return nil
+}
+
+func (h *memoryHelper) Delete(serverURL string) error {
+	if _, ok := h.creds[serverURL]; !ok {
+		return credentials.NewErrCredentialsNotFound()
+	}
+	delete(h.creds, serverURL)
+	return nil
+}
+
+func (h *memoryHelper) Get(serverURL string) (string, string, error) {
+	cred, ok := h.creds[serverURL]
+	if !ok {
+		return "", "", credentials.NewErrCredentialsNotFound()
+	}
+	return cred.Username, cred.Secret, nil
+}
+
+func (h *memoryHelper) List() (map[string]string, error) {
+	userIDByURL := map[string]string{}
+	for serverURL, cred := range h.creds {
+		userIDByURL[serverURL] = cred.Username
+	}
+	return userIDByURL, nil
+}
+
+func TestChunkedHelper(t *testing.T) {
+	memory := &memoryHelper{maxSecretSize: 10, creds: map[string]*credentials.Credentials{}}
+	helper := newChunkedHelper(memory, 10)
+
+	small := "c2hvcnQ="
+	large := base64.StdEncoding.EncodeToString([]byte("secret which does not fit into one entry"))
+
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "users/small", Username: "small", Secret: small}))
+	require.NoError(t, helper.Add(&credentials.Credentials{ServerURL: "users/large", Username: "large", Secret: large}))
+	require.True(t, len(memory.creds) > 2)
+
+	list, err := helper.List()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"users/small": "small", "users/large": "large"}, list)
+
+	username, secret, err := helper.Get("users/small")
+	require.NoError(t, err)
+	require.Equal(t, "small", username)
+	require.Equal(t, small, secret)
+
+	username, secret, err = helper.Get("users/large")
+	require.NoError(t, err)
+	require.Equal(t, "large", username)
+	require.Equal(t, large, secret)
+
+	require.NoError(t, helper.Delete("users/large"))
+	require.Len(t, memory.creds, 1)
+}
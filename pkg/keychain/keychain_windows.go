@@ -22,9 +22,19 @@ import (
 	"github.com/docker/docker-credential-helpers/wincred"
 )
 
+// maxWincredSecretSize is the maximum size of a credential blob allowed
+// by Windows Credential Manager (CRED_MAX_CREDENTIAL_BLOB_SIZE).
+const maxWincredSecretSize = 5 * 512
+
 func newKeychain() (credentials.Helper, error) {
 	log.Debug("Creating wincred")
-	return &wincred.Wincred{}, nil
+	// Credentials of accounts with many addresses do not fit into one entry.
+	return newChunkedHelper(&wincred.Wincred{}, maxWincredSecretSize), nil
+}
+
+// AvailableHelpers returns nothing as Windows Credential Manager is the only option.
+func AvailableHelpers() []string {
+	return nil
 }
 
 func (s *Access) KeychainName(userID string) string {
@@ -26,9 +26,31 @@ import (
 
 var log = logrus.WithField("pkg", "bridgeUtils/listener") //nolint[gochecknoglobals]
 
+// syncDeliveryTimeout is the longest time Emit waits for one listener
+// of a critical event. After that the event is delivered asynchronously.
+const syncDeliveryTimeout = 5 * time.Second
+
+// Priority of the event decides how it is delivered.
+type Priority int
+
+const (
+	// PriorityNormal events are delivered asynchronously and dropped
+	// when nobody listens, unless buffered by SetBuffer.
+	PriorityNormal Priority = iota
+
+	// PriorityHigh events are delivered asynchronously and always buffered
+	// until somebody listens, so they cannot be missed during start.
+	PriorityHigh
+
+	// PriorityCritical events are high priority events which are delivered
+	// synchronously: Emit returns once all listeners received the event.
+	PriorityCritical
+)
+
 // Listener has a list of channels watching for updates.
 type Listener interface {
 	SetLimit(eventName string, limit time.Duration)
+	SetPriority(eventName string, priority Priority)
 	Add(eventName string, channel chan<- string)
 	Remove(eventName string, channel chan<- string)
 	Emit(eventName string, data string)
@@ -37,21 +59,23 @@ type Listener interface {
 }
 
 type listener struct {
-	channels  map[string][]chan<- string
-	limits    map[string]time.Duration
-	lastEmits map[string]map[string]time.Time
-	buffered  map[string][]string
-	lock      *sync.RWMutex
+	channels   map[string][]chan<- string
+	limits     map[string]time.Duration
+	lastEmits  map[string]map[string]time.Time
+	buffered   map[string][]string
+	priorities map[string]Priority
+	lock       *sync.RWMutex
 }
 
 // New returns a new Listener which initially has no topics.
 func New() Listener {
 	return &listener{
-		channels:  nil,
-		limits:    make(map[string]time.Duration),
-		lastEmits: make(map[string]map[string]time.Time),
-		buffered:  make(map[string][]string),
-		lock:      &sync.RWMutex{},
+		channels:   nil,
+		limits:     make(map[string]time.Duration),
+		lastEmits:  make(map[string]map[string]time.Time),
+		buffered:   make(map[string][]string),
+		priorities: make(map[string]Priority),
+		lock:       &sync.RWMutex{},
 	}
 }
 
@@ -66,6 +90,23 @@ func (l *listener) SetLimit(eventName string, limit time.Duration) {
 	l.limits[eventName] = limit
 }
 
+// SetPriority sets the priority for the `eventName`. Events have PriorityNormal
+// by default. Higher priorities also buffer the event, see SetBuffer.
+func (l *listener) SetPriority(eventName string, priority Priority) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if priority == PriorityNormal {
+		delete(l.priorities, eventName)
+		return
+	}
+
+	l.priorities[eventName] = priority
+	if _, ok := l.buffered[eventName]; !ok {
+		l.buffered[eventName] = []string{}
+	}
+}
+
 // Add adds an event listener.
 func (l *listener) Add(eventName string, channel chan<- string) {
 	l.lock.Lock()
@@ -94,33 +135,62 @@ func (l *listener) Remove(eventName string, channel chan<- string) {
 }
 
 // Emit emits an event in parallel to all listeners (channels).
+// Critical events are emitted synchronously, see PriorityCritical.
 func (l *listener) Emit(eventName string, data string) {
 	l.emit(eventName, data, false)
 }
 
 func (l *listener) emit(eventName, data string, isReEmit bool) {
-	l.lock.RLock()
-	defer l.lock.RUnlock()
+	// Write lock because limits and buffers are updated.
+	l.lock.Lock()
 
 	if !l.shouldEmit(eventName, data) {
+		l.lock.Unlock()
 		log.Warn("Emit of ", eventName, " with data ", data, " skipped")
 		return
 	}
 
-	if _, ok := l.channels[eventName]; ok {
-		for i, handler := range l.channels[eventName] {
-			go func(handler chan<- string, i int) {
-				handler <- data
-				log.Debugf("emitted %s data %s -> %d", eventName, data, i)
-			}(handler, i)
+	handlers, ok := l.channels[eventName]
+	if !ok {
+		if !isReEmit {
+			if bufferedData, ok := l.buffered[eventName]; ok {
+				l.buffered[eventName] = append(bufferedData, data)
+				log.Debugf("Buffering event %s data %s", eventName, data)
+			} else {
+				log.Warnf("No channel is listening to %s data %s", eventName, data)
+			}
 		}
-	} else if !isReEmit {
-		if bufferedData, ok := l.buffered[eventName]; ok {
-			l.buffered[eventName] = append(bufferedData, data)
-			log.Debugf("Buffering event %s data %s", eventName, data)
-		} else {
-			log.Warnf("No channel is listening to %s data %s", eventName, data)
+		l.lock.Unlock()
+		return
+	}
+
+	// Handlers are copied so the lock is not held while sending; listener
+	// of a critical event might need the lock before it is able to receive.
+	handlers = append([]chan<- string{}, handlers...)
+	isSync := l.priorities[eventName] == PriorityCritical
+	l.lock.Unlock()
+
+	for i, handler := range handlers {
+		if isSync {
+			deliverSync(handler, eventName, data, i)
+			continue
 		}
+		go func(handler chan<- string, i int) {
+			handler <- data
+			log.Debugf("emitted %s data %s -> %d", eventName, data, i)
+		}(handler, i)
+	}
+}
+
+func deliverSync(handler chan<- string, eventName, data string, i int) {
+	select {
+	case handler <- data:
+		log.Debugf("emitted synchronously %s data %s -> %d", eventName, data, i)
+	case <-time.After(syncDeliveryTimeout):
+		log.Warnf("Listener %d of %s is not receiving, emitting data %s asynchronously", i, eventName, data)
+		go func() {
+			handler <- data
+		}()
 	}
 }
 
@@ -159,19 +229,27 @@ func (l *listener) clearLastEmits() {
 }
 
 func (l *listener) SetBuffer(eventName string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
 	if _, ok := l.buffered[eventName]; !ok {
 		l.buffered[eventName] = []string{}
 	}
 }
 
 func (l *listener) RetryEmit(eventName string) {
+	l.lock.Lock()
 	if _, ok := l.channels[eventName]; !ok || len(l.channels[eventName]) == 0 {
+		l.lock.Unlock()
 		return
 	}
-	if bufferedData, ok := l.buffered[eventName]; ok {
-		for _, data := range bufferedData {
-			l.emit(eventName, data, true)
-		}
+	bufferedData, ok := l.buffered[eventName]
+	if ok {
 		l.buffered[eventName] = []string{}
 	}
+	l.lock.Unlock()
+
+	for _, data := range bufferedData {
+		l.emit(eventName, data, true)
+	}
 }
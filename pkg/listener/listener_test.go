@@ -172,3 +172,34 @@ func checkChannelNotEmitted(t testing.TB, channel chan string) {
 	case <-time.After(minEventReceiveTime):
 	}
 }
+
+func TestHighPriorityIsBuffered(t *testing.T) {
+	listener := New()
+	listener.SetPriority("event", PriorityHigh)
+
+	listener.Emit("event", "hello!")
+
+	channel := make(chan string)
+	listener.Add("event", channel)
+	listener.RetryEmit("event")
+
+	checkChannelEmitted(t, channel, "hello!")
+}
+
+func TestCriticalIsSynchronous(t *testing.T) {
+	listener := New()
+	listener.SetPriority("event", PriorityCritical)
+
+	channel := make(chan string, 1)
+	listener.Add("event", channel)
+
+	listener.Emit("event", "hello!")
+
+	// Emit returns after the event is received, no waiting is needed.
+	select {
+	case data := <-channel:
+		require.Equal(t, "hello!", data)
+	default:
+		t.Fatal("critical event was not delivered synchronously")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package localsocket provides unix socket listeners which accept only
+// connections from processes running under the same user as the bridge.
+// Such connections are trusted and do not need the bridge password.
+package localsocket
+
+import (
+	"errors"
+	"net"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+var log = logrus.WithField("pkg", "localsocket") //nolint[gochecknoglobals]
+
+// ErrUnsupported is returned when peer credentials cannot be checked on this platform.
+var ErrUnsupported = errors.New("local socket authentication is not supported on this platform")
+
+var errClosed = errors.New("use of closed listener")
+
+// Listen creates unix socket at path readable and writable only by the current user.
+// Accepted connections are checked to come from the same user; others are closed.
+func Listen(path string) (net.Listener, error) {
+	if !Supported {
+		return nil, ErrUnsupported
+	}
+
+	// Socket left behind by previous run would make listen fail.
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+
+	return &peerListener{Listener: l, uid: os.Getuid()}, nil
+}
+
+// IsLocal returns whether the address belongs to connection accepted by local socket.
+func IsLocal(addr net.Addr) bool {
+	return addr != nil && addr.Network() == "unix"
+}
+
+// peerListener closes connections of other users before they are handed over.
+type peerListener struct {
+	net.Listener
+
+	uid int
+}
+
+func (pl *peerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := pl.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		uid, err := peerUID(conn)
+		if err == nil && uid == pl.uid {
+			return conn, nil
+		}
+
+		log.WithError(err).WithField("uid", uid).Warn("Rejecting local socket connection of other user")
+		_ = conn.Close()
+	}
+}
+
+// peerUID returns user ID of the process on the other side of the unix connection.
+func peerUID(conn net.Conn) (int, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return -1, errors.New("not a unix connection")
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	uid := -1
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		uid, credErr = getPeerUID(int(fd))
+	}); err != nil {
+		return -1, err
+	}
+
+	return uid, credErr
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package localsocket
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestListenAcceptsSameUser(t *testing.T) {
+	if !Supported {
+		t.Skip("Local socket is not supported")
+	}
+
+	dir, err := ioutil.TempDir("", "localsocket")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	path := filepath.Join(dir, "test.sock")
+
+	// Stale file must not block the listener.
+	r.NoError(t, ioutil.WriteFile(path, nil, 0600))
+
+	l, err := Listen(path)
+	r.NoError(t, err)
+	defer l.Close() //nolint[errcheck]
+
+	info, err := os.Stat(path)
+	r.NoError(t, err)
+	r.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	go func() {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			_, _ = conn.Write([]byte("x"))
+			_ = conn.Close()
+		}
+	}()
+
+	conn, err := l.Accept()
+	r.NoError(t, err)
+	defer conn.Close() //nolint[errcheck]
+
+	r.True(t, IsLocal(conn.LocalAddr()))
+
+	buf := make([]byte, 1)
+	_, err = conn.Read(buf)
+	r.NoError(t, err)
+	r.Equal(t, byte('x'), buf[0])
+}
+
+func TestIsLocal(t *testing.T) {
+	r.False(t, IsLocal(nil))
+	r.False(t, IsLocal(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1143}))
+	r.True(t, IsLocal(&net.UnixAddr{Name: "/tmp/imap.sock", Net: "unix"}))
+}
+
+func TestMerge(t *testing.T) {
+	first, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(t, err)
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	r.NoError(t, err)
+
+	l := Merge(first, second)
+	r.Equal(t, first.Addr(), l.Addr())
+
+	for _, addr := range []net.Addr{first.Addr(), second.Addr()} {
+		go func(addr net.Addr) {
+			if conn, err := net.Dial("tcp", addr.String()); err == nil {
+				_ = conn.Close()
+			}
+		}(addr)
+
+		conn, err := l.Accept()
+		r.NoError(t, err)
+		r.Equal(t, addr, conn.LocalAddr())
+		_ = conn.Close()
+	}
+
+	r.NoError(t, l.Close())
+	_, err = l.Accept()
+	r.Error(t, err)
+}
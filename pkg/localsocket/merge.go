@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package localsocket
+
+import (
+	"net"
+	"sync"
+)
+
+// Merge returns listener accepting connections from all given listeners.
+// It lets a server which can be served only once listen also on local socket.
+// Its address is the address of the first listener.
+func Merge(listeners ...net.Listener) net.Listener {
+	ml := &mergedListener{
+		listeners: listeners,
+		accepted:  make(chan acceptResult),
+		done:      make(chan struct{}),
+	}
+
+	for _, l := range listeners {
+		go ml.acceptFrom(l)
+	}
+
+	return ml
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+type mergedListener struct {
+	listeners []net.Listener
+	accepted  chan acceptResult
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (ml *mergedListener) acceptFrom(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+
+		select {
+		case ml.accepted <- acceptResult{conn: conn, err: err}:
+		case <-ml.done:
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (ml *mergedListener) Accept() (net.Conn, error) {
+	select {
+	case res := <-ml.accepted:
+		return res.conn, res.err
+	case <-ml.done:
+		return nil, errClosed
+	}
+}
+
+func (ml *mergedListener) Close() (err error) {
+	ml.closeOnce.Do(func() {
+		close(ml.done)
+		for _, l := range ml.listeners {
+			if closeErr := l.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	})
+	return
+}
+
+func (ml *mergedListener) Addr() net.Addr {
+	return ml.listeners[0].Addr()
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package localsocket
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Supported is true when peer credentials of unix connections can be checked.
+const Supported = true
+
+// Values from sys/un.h and sys/ucred.h.
+const (
+	solLocal      = 0
+	localPeerCred = 0x1
+	xucredVersion = 0
+)
+
+type xucred struct {
+	Version uint32
+	UID     uint32
+	Ngroups int16
+	Groups  [16]uint32
+}
+
+func getPeerUID(fd int) (int, error) {
+	var cred xucred
+	size := uint32(unsafe.Sizeof(cred))
+
+	_, _, errno := syscall.Syscall6(
+		syscall.SYS_GETSOCKOPT,
+		uintptr(fd), solLocal, localPeerCred,
+		uintptr(unsafe.Pointer(&cred)), uintptr(unsafe.Pointer(&size)), 0,
+	)
+	if errno != 0 {
+		return -1, errno
+	}
+	if cred.Version != xucredVersion {
+		return -1, syscall.EINVAL
+	}
+	return int(cred.UID), nil
+}
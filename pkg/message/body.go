@@ -47,26 +47,40 @@ func WriteBody(w io.Writer, kr *crypto.KeyRing, m *pmapi.Message) error {
 }
 
 func WriteAttachmentBody(w io.Writer, kr *crypto.KeyRing, m *pmapi.Message, att *pmapi.Attachment, r io.Reader) (err error) {
-	// Decrypt it
-	var dr io.Reader
-	dr, err = att.Decrypt(r, kr)
+	dr, err := DecryptAttachment(kr, att, r)
+	if err != nil {
+		return
+	}
+
+	return EncodeAttachmentBody(w, dr)
+}
+
+// DecryptAttachment returns reader of decrypted attachment. When attachment
+// is encrypted with a different key, the whole encrypted message is returned and
+// the attachment is renamed to make it clear to the user.
+// It must be called before the attachment header is written.
+func DecryptAttachment(kr *crypto.KeyRing, att *pmapi.Attachment, r io.Reader) (io.Reader, error) {
+	dr, err := att.Decrypt(r, kr)
 	if err == openpgperrors.ErrKeyIncorrect {
 		// Do not fail if attachment is encrypted with a different key.
-		dr = r
-		err = nil
 		att.Name += ".gpg"
 		att.MIMEType = "application/pgp-encrypted" //nolint
+		return dr, nil
 	} else if err != nil && err != openpgperrors.ErrSignatureExpired {
-		err = fmt.Errorf("cannot decrypt attachment: %v", err)
-		return
+		return nil, fmt.Errorf("cannot decrypt attachment: %v", err)
 	}
 
-	// Encode it.
+	return dr, nil
+}
+
+// EncodeAttachmentBody writes data from r to w in base64 transfer encoding
+// without keeping the whole attachment in memory.
+func EncodeAttachmentBody(w io.Writer, r io.Reader) (err error) {
 	ww := textwrapper.NewRFC822(w)
 	bw := base64.NewEncoder(base64.StdEncoding, ww)
 
 	var n int64
-	if n, err = io.Copy(bw, dr); err != nil {
+	if n, err = io.Copy(bw, r); err != nil {
 		err = fmt.Errorf("cannot write attachment: %v (wrote %v bytes)", err, n)
 	}
 
@@ -20,7 +20,6 @@ package message
 import (
 	"bytes"
 	"encoding/base64"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
@@ -39,13 +38,20 @@ type Builder struct {
 	cl  pmapi.Client
 	msg *pmapi.Message
 
-	EncryptedToHTML       bool
+	EncryptedToHTML bool
+
+	// AttachmentWorkers is the number of attachments downloaded in parallel.
+	// With the default value one, attachments are downloaded one by one
+	// while the message is being written.
+	AttachmentWorkers int
+
 	successfullyDecrypted bool
+	prefetched            map[string][]byte
 }
 
 // NewBuilder initiated with client and message meta info.
 func NewBuilder(client pmapi.Client, message *pmapi.Message) *Builder {
-	return &Builder{cl: client, msg: message, EncryptedToHTML: true, successfullyDecrypted: false}
+	return &Builder{cl: client, msg: message, EncryptedToHTML: true, AttachmentWorkers: 1, successfullyDecrypted: false}
 }
 
 // fetchMessage will update original PM message if successful
@@ -82,7 +88,19 @@ func (bld *Builder) writeMessageBody(w io.Writer) error {
 	return err
 }
 
-func (bld *Builder) writeAttachmentBody(w io.Writer, att *pmapi.Attachment) error {
+// writeAttachmentPart writes attachment as a new part of mw. Attachments
+// which were not prefetched are decrypted and encoded on the fly directly
+// to the part so the whole attachment is never held in memory.
+func (bld *Builder) writeAttachmentPart(mw *multipart.Writer, att *pmapi.Attachment) error {
+	if body, ok := bld.prefetched[att.ID]; ok {
+		p, err := mw.CreatePart(GetAttachmentHeader(att))
+		if err != nil {
+			return err
+		}
+		_, err = p.Write(body)
+		return err
+	}
+
 	// Retrieve encrypted attachment
 	r, err := bld.cl.GetAttachment(att.ID)
 	if err != nil {
@@ -90,10 +108,27 @@ func (bld *Builder) writeAttachmentBody(w io.Writer, att *pmapi.Attachment) erro
 	}
 	defer r.Close() //nolint[errcheck]
 
-	if err := bld.WriteAttachmentBody(w, att, r); err != nil {
+	kr, err := bld.cl.KeyRingForAddressID(bld.msg.AddressID)
+	if err != nil {
+		return err
+	}
+
+	// Decryption can change the attachment name and type, therefore it has
+	// to happen before the header is written.
+	dr, decErr := DecryptAttachment(kr, att, r)
+
+	p, err := mw.CreatePart(GetAttachmentHeader(att))
+	if err != nil {
+		return err
+	}
+
+	if decErr == nil {
+		decErr = EncodeAttachmentBody(p, dr)
+	}
+	if decErr != nil {
 		// Returning an error here makes e-mail clients like Thunderbird behave
 		// badly, trying to retrieve the message again and again
-		log.Warnln("Cannot write attachment body:", err)
+		log.Warnln("Cannot write attachment body:", decErr)
 	}
 	return nil
 }
@@ -119,16 +154,9 @@ func (bld *Builder) writeRelatedPart(p io.Writer, inlines []*pmapi.Attachment) e
 	_, _ = buf.WriteTo(p)
 
 	for _, inline := range inlines {
-		buf = &bytes.Buffer{}
-		if err = bld.writeAttachmentBody(buf, inline); err != nil {
-			return err
-		}
-
-		h := GetAttachmentHeader(inline)
-		if p, err = related.CreatePart(h); err != nil {
+		if err = bld.writeAttachmentPart(related, inline); err != nil {
 			return err
 		}
-		_, _ = buf.WriteTo(p)
 	}
 
 	_ = related.Close()
@@ -137,78 +165,85 @@ func (bld *Builder) writeRelatedPart(p io.Writer, inlines []*pmapi.Attachment) e
 
 // BuildMessage converts PM message to body structure (not RFC3501) and bytes
 // of RC822 message. If successful the original PM message will contain decrypted body.
-func (bld *Builder) BuildMessage() (structure *BodyStructure, message []byte, err error) { //nolint[funlen]
-	if err = bld.fetchMessage(); err != nil {
+func (bld *Builder) BuildMessage() (structure *BodyStructure, message []byte, err error) {
+	bodyBuf := &bytes.Buffer{}
+	if err = bld.WriteMessage(bodyBuf); err != nil {
 		return nil, nil, err
 	}
 
-	bodyBuf := &bytes.Buffer{}
+	// wee need to copy buffer before building body structure
+	message = bodyBuf.Bytes()
+	structure, err = NewBodyStructure(bodyBuf)
+	return structure, message, err
+}
+
+// WriteMessage writes RFC822 message to w. Attachments are streamed to w
+// as they are downloaded and decrypted, which keeps memory usage low even
+// for big messages when w is not a buffer (e.g. a file).
+// If successful the original PM message will contain decrypted body.
+func (bld *Builder) WriteMessage(w io.Writer) (err error) {
+	if err = bld.fetchMessage(); err != nil {
+		return err
+	}
+
+	if bld.msg.MIMEType != pmapi.ContentTypeMultipartMixed {
+		bld.prefetchAttachments()
+		defer func() { bld.prefetched = nil }()
+	}
 
 	mainHeader := GetHeader(bld.msg)
 	mainHeader.Set("Content-Type", "multipart/mixed; boundary="+GetBoundary(bld.msg))
-	if err = WriteHeader(bodyBuf, mainHeader); err != nil {
-		return nil, nil, err
+	if err = WriteHeader(w, mainHeader); err != nil {
+		return err
 	}
-	_, _ = io.WriteString(bodyBuf, "\r\n")
+	_, _ = io.WriteString(w, "\r\n")
 
 	// NOTE: Do we really need extra encapsulation? i.e. Bridge-IMAP message is always multipart/mixed
 
 	if bld.msg.MIMEType == pmapi.ContentTypeMultipartMixed {
-		_, _ = io.WriteString(bodyBuf, "\r\n--"+GetBoundary(bld.msg)+"\r\n")
-		if err = bld.writeMessageBody(bodyBuf); err != nil {
-			return nil, nil, err
+		_, _ = io.WriteString(w, "\r\n--"+GetBoundary(bld.msg)+"\r\n")
+		if err = bld.writeMessageBody(w); err != nil {
+			return err
 		}
-		_, _ = io.WriteString(bodyBuf, "\r\n--"+GetBoundary(bld.msg)+"--\r\n")
+		_, err = io.WriteString(w, "\r\n--"+GetBoundary(bld.msg)+"--\r\n")
+		return err
+	}
+
+	mw := multipart.NewWriter(w)
+	_ = mw.SetBoundary(GetBoundary(bld.msg))
+
+	var partWriter io.Writer
+	atts, inlines := SeparateInlineAttachments(bld.msg)
+
+	if len(inlines) > 0 {
+		relatedHeader := GetRelatedHeader(bld.msg)
+		if partWriter, err = mw.CreatePart(relatedHeader); err != nil {
+			return err
+		}
+		_ = bld.writeRelatedPart(partWriter, inlines)
 	} else {
-		mw := multipart.NewWriter(bodyBuf)
-		_ = mw.SetBoundary(GetBoundary(bld.msg))
-
-		var partWriter io.Writer
-		atts, inlines := SeparateInlineAttachments(bld.msg)
-
-		if len(inlines) > 0 {
-			relatedHeader := GetRelatedHeader(bld.msg)
-			if partWriter, err = mw.CreatePart(relatedHeader); err != nil {
-				return nil, nil, err
-			}
-			_ = bld.writeRelatedPart(partWriter, inlines)
-		} else {
-			buf := &bytes.Buffer{}
-			if err = bld.writeMessageBody(buf); err != nil {
-				return nil, nil, err
-			}
-
-			// Write the body part
-			bodyHeader := GetBodyHeader(bld.msg)
-			if partWriter, err = mw.CreatePart(bodyHeader); err != nil {
-				return nil, nil, err
-			}
-
-			_, _ = buf.WriteTo(partWriter)
+		buf := &bytes.Buffer{}
+		if err = bld.writeMessageBody(buf); err != nil {
+			return err
 		}
 
-		// Write the attachments parts
-		for _, att := range atts {
-			buf := &bytes.Buffer{}
-			if err = bld.writeAttachmentBody(buf, att); err != nil {
-				return nil, nil, err
-			}
+		// Write the body part
+		bodyHeader := GetBodyHeader(bld.msg)
+		if partWriter, err = mw.CreatePart(bodyHeader); err != nil {
+			return err
+		}
 
-			attachmentHeader := GetAttachmentHeader(att)
-			if partWriter, err = mw.CreatePart(attachmentHeader); err != nil {
-				return nil, nil, err
-			}
+		_, _ = buf.WriteTo(partWriter)
+	}
 
-			_, _ = buf.WriteTo(partWriter)
+	// Write the attachments parts
+	for _, att := range atts {
+		if err = bld.writeAttachmentPart(mw, att); err != nil {
+			return err
 		}
-
-		_ = mw.Close()
 	}
 
-	// wee need to copy buffer before building body structure
-	message = bodyBuf.Bytes()
-	structure, err = NewBodyStructure(bodyBuf)
-	return structure, message, err
+	return mw.Close()
 }
 
 // SuccessfullyDecrypted is true when message was fetched and decrypted successfully
@@ -239,36 +274,18 @@ func (bld *Builder) WriteBody(w io.Writer) error {
 }
 
 // WriteAttachmentBody decrypts and writes the attachments
-func (bld *Builder) WriteAttachmentBody(w io.Writer, att *pmapi.Attachment, attReader io.Reader) (err error) {
+func (bld *Builder) WriteAttachmentBody(w io.Writer, att *pmapi.Attachment, attReader io.Reader) error {
 	kr, err := bld.cl.KeyRingForAddressID(bld.msg.AddressID)
 	if err != nil {
 		return err
 	}
-	// Decrypt it
-	var dr io.Reader
-	dr, err = att.Decrypt(attReader, kr)
-	if err == openpgperrors.ErrKeyIncorrect {
-		// Do not fail if attachment is encrypted with a different key
-		dr = attReader
-		err = nil
-		att.Name += ".gpg"
-		att.MIMEType = "application/pgp-encrypted"
-	} else if err != nil && err != openpgperrors.ErrSignatureExpired {
-		err = fmt.Errorf("cannot decrypt attachment: %v", err)
-		return err
-	}
-
-	// transfer encoding
-	ww := textwrapper.NewRFC822(w)
-	bw := base64.NewEncoder(base64.StdEncoding, ww)
 
-	var n int64
-	if n, err = io.Copy(bw, dr); err != nil {
-		err = fmt.Errorf("cannot write attachment: %v (wrote %v bytes)", err, n)
+	dr, err := DecryptAttachment(kr, att, attReader)
+	if err != nil {
+		return err
 	}
 
-	_ = bw.Close()
-	return err
+	return EncodeAttachmentBody(w, dr)
 }
 
 func BuildEncrypted(m *pmapi.Message, readers []io.Reader, kr *crypto.KeyRing) ([]byte, error) { //nolint[funlen]
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/parallel"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+)
+
+const (
+	// attachmentFetchRetries is how many times download of one attachment
+	// is attempted before the error is returned.
+	attachmentFetchRetries = 3
+
+	// attachmentFetchRetryDelay is the delay before the first retry,
+	// doubled with every next attempt.
+	attachmentFetchRetryDelay = 500 * time.Millisecond
+
+	// maxPrefetchAttachmentSize is the biggest attachment kept in memory by
+	// prefetching. Bigger attachments are streamed when the message is written.
+	maxPrefetchAttachmentSize = 5 * 1024 * 1024
+)
+
+// prefetchAttachments downloads, decrypts and encodes all attachments of the
+// message concurrently using at most AttachmentWorkers workers. Results are
+// stored in the builder and written in the original order when the message
+// is being built. Attachments which failed are left out and handled the same
+// way as without prefetching.
+func (bld *Builder) prefetchAttachments() {
+	if bld.AttachmentWorkers <= 1 || len(bld.msg.Attachments) <= 1 {
+		return
+	}
+
+	input := make([]interface{}, len(bld.msg.Attachments))
+	for i, att := range bld.msg.Attachments {
+		input[i] = att
+	}
+
+	processCallback := func(value interface{}) (interface{}, error) {
+		att := value.(*pmapi.Attachment)
+		if att.Size > maxPrefetchAttachmentSize {
+			return nil, nil
+		}
+		body, err := bld.fetchAttachmentBody(att)
+		if err != nil {
+			log.WithError(err).WithField("attID", att.ID).Warn("Cannot prefetch attachment")
+			return nil, nil
+		}
+		return body, nil
+	}
+
+	bld.prefetched = make(map[string][]byte)
+	collectCallback := func(idx int, value interface{}) error {
+		if body, ok := value.([]byte); ok {
+			bld.prefetched[bld.msg.Attachments[idx].ID] = body
+		}
+		return nil
+	}
+
+	_ = parallel.RunParallel(bld.AttachmentWorkers, input, processCallback, collectCallback)
+}
+
+// fetchAttachmentBody returns decrypted attachment in transfer encoding.
+func (bld *Builder) fetchAttachmentBody(att *pmapi.Attachment) ([]byte, error) {
+	data, err := bld.getAttachmentWithRetry(att.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	if err := bld.WriteAttachmentBody(buf, att, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (bld *Builder) getAttachmentWithRetry(attID string) (data []byte, err error) {
+	delay := attachmentFetchRetryDelay
+	for attempt := 1; ; attempt++ {
+		if data, err = bld.getAttachment(attID); err == nil || attempt >= attachmentFetchRetries {
+			return
+		}
+		log.WithError(err).WithField("attempt", attempt).Debug("Retrying attachment download")
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func (bld *Builder) getAttachment(attID string) ([]byte, error) {
+	r, err := bld.cl.GetAttachment(attID)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() //nolint[errcheck]
+
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	pmapimocks "github.com/ProtonMail/proton-bridge/pkg/pmapi/mocks"
+	"github.com/golang/mock/gomock"
+	r "github.com/stretchr/testify/require"
+)
+
+func TestGetAttachmentWithRetry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := pmapimocks.NewMockClient(ctrl)
+	gomock.InOrder(
+		client.EXPECT().GetAttachment("attID").Return(nil, errors.New("temporary failure")),
+		client.EXPECT().GetAttachment("attID").Return(ioutil.NopCloser(strings.NewReader("data")), nil),
+	)
+
+	bld := NewBuilder(client, &pmapi.Message{})
+	data, err := bld.getAttachmentWithRetry("attID")
+	r.NoError(t, err)
+	r.Equal(t, "data", string(data))
+}
+
+func TestGetAttachmentWithRetryGivesUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	client := pmapimocks.NewMockClient(ctrl)
+	client.EXPECT().GetAttachment("attID").Return(nil, errors.New("permanent failure")).Times(attachmentFetchRetries)
+
+	bld := NewBuilder(client, &pmapi.Message{})
+	_, err := bld.getAttachmentWithRetry("attID")
+	r.Error(t, err)
+}
+
+func TestWriteAttachmentPartUsesPrefetched(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// No call to the client is expected.
+	client := pmapimocks.NewMockClient(ctrl)
+
+	bld := NewBuilder(client, &pmapi.Message{})
+	bld.prefetched = map[string][]byte{"attID": []byte("encoded")}
+
+	buf := &strings.Builder{}
+	mw := multipart.NewWriter(buf)
+	r.NoError(t, bld.writeAttachmentPart(mw, &pmapi.Attachment{ID: "attID", Name: "file.txt"}))
+	r.NoError(t, mw.Close())
+	r.Contains(t, buf.String(), "file.txt")
+	r.Contains(t, buf.String(), "\r\n\r\nencoded\r\n")
+}
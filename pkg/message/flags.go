@@ -18,6 +18,9 @@
 package message
 
 import (
+	"strings"
+	"time"
+
 	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
 	"github.com/emersion/go-imap"
 )
@@ -29,6 +32,36 @@ var (
 	ThunderbirdNonJunkFlag = imap.CanonicalFlag("NonJunk")
 )
 
+// SnoozedFlagPrefix starts keyword which snoozes the message until the date
+// in the rest of the keyword, e.g. $Snoozed-20201231.
+const SnoozedFlagPrefix = "$Snoozed-"
+
+const (
+	snoozedFlagDateLayout = "20060102"
+
+	// snoozedHour is the hour of the day when snoozed messages come back.
+	snoozedHour = 8
+)
+
+// ParseSnoozedFlag returns time until which the message should be snoozed
+// when the flag is a snooze keyword.
+func ParseSnoozedFlag(flag string) (until time.Time, ok bool) {
+	if len(flag) <= len(SnoozedFlagPrefix) || !strings.EqualFold(flag[:len(SnoozedFlagPrefix)], SnoozedFlagPrefix) {
+		return time.Time{}, false
+	}
+
+	date, err := time.ParseInLocation(snoozedFlagDateLayout, flag[len(SnoozedFlagPrefix):], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return date.Add(snoozedHour * time.Hour), true
+}
+
+func getSnoozedFlag(until time.Time) string {
+	return SnoozedFlagPrefix + until.Local().Format(snoozedFlagDateLayout)
+}
+
 func GetFlags(m *pmapi.Message) (flags []string) {
 	if m.Unread == 0 {
 		flags = append(flags, imap.SeenFlag)
@@ -50,6 +83,9 @@ func GetFlags(m *pmapi.Message) (flags []string) {
 			flags = append(flags, AppleMailJunkFlag, ThunderbirdJunkFlag)
 			hasSpam = true
 		}
+		if l == pmapi.SnoozedLabel && m.SnoozeTime != 0 {
+			flags = append(flags, getSnoozedFlag(time.Unix(m.SnoozeTime, 0)))
+		}
 	}
 
 	if !hasSpam {
@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSnoozedFlag(t *testing.T) {
+	until, ok := ParseSnoozedFlag("$snoozed-20201231")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2020, 12, 31, 8, 0, 0, 0, time.Local), until)
+
+	for _, flag := range []string{"$Snoozed-", "$Snoozed-tomorrow", "$Snoozed-2020-12-31", "$Junk", "Snoozed-20201231"} {
+		_, ok := ParseSnoozedFlag(flag)
+		assert.False(t, ok, flag)
+	}
+}
+
+func TestGetFlagsSnoozed(t *testing.T) {
+	until := time.Date(2020, 12, 31, 8, 0, 0, 0, time.Local)
+	m := &pmapi.Message{
+		Flags:      pmapi.FlagReceived,
+		LabelIDs:   []string{pmapi.AllMailLabel, pmapi.SnoozedLabel},
+		SnoozeTime: until.Unix(),
+	}
+
+	flag := getSnoozedFlag(until)
+	assert.Contains(t, GetFlags(m), flag)
+
+	parsed, ok := ParseSnoozedFlag(flag)
+	assert.True(t, ok)
+	assert.Equal(t, until, parsed)
+}
@@ -21,6 +21,7 @@ import (
 	"mime"
 	"net/mail"
 	"net/textproto"
+	"strconv"
 	"strings"
 	"time"
 
@@ -37,6 +38,13 @@ func GetHeader(msg *pmapi.Message) textproto.MIMEHeader { //nolint[funlen]
 		h = textproto.MIMEHeader(msg.Header)
 	}
 
+	// Spam headers are trusted only when added by us, not by the sender.
+	for key := range h {
+		if strings.HasPrefix(key, spamHeaderPrefix) {
+			h.Del(key)
+		}
+	}
+
 	// Add or rewrite fields.
 	h.Set("Subject", pmmime.EncodeHeader(msg.Subject))
 	if msg.Sender != nil {
@@ -91,9 +99,55 @@ func GetHeader(msg *pmapi.Message) textproto.MIMEHeader { //nolint[funlen]
 		}
 	}
 
+	if msg.Has(pmapi.FlagReceived) {
+		setSpamFields(h, msg)
+	}
+
 	return h
 }
 
+const spamHeaderPrefix = "X-Pm-Spam-"
+
+// setSpamFields exposes the spam classification of received message so users
+// can build client-side filtering rules on it.
+func setSpamFields(h textproto.MIMEHeader, msg *pmapi.Message) {
+	h.Set(spamHeaderPrefix+"Score", strconv.Itoa(msg.SpamScore))
+
+	switch {
+	case msg.Has(pmapi.FlagSpamManual):
+		h.Set(spamHeaderPrefix+"Verdict", "spam; manual")
+	case msg.Has(pmapi.FlagSpamAuto):
+		h.Set(spamHeaderPrefix+"Verdict", "spam; auto")
+	case msg.Has(pmapi.FlagHamManual):
+		h.Set(spamHeaderPrefix+"Verdict", "ham; manual")
+	default:
+		h.Set(spamHeaderPrefix+"Verdict", "ham")
+	}
+
+	switch {
+	case msg.Has(pmapi.FlagPhishingManual):
+		h.Set(spamHeaderPrefix+"Phishing", "yes; manual")
+	case msg.Has(pmapi.FlagPhishingAuto):
+		h.Set(spamHeaderPrefix+"Phishing", "yes; auto")
+	default:
+		h.Set(spamHeaderPrefix+"Phishing", "no")
+	}
+
+	failures := []string{}
+	if msg.Has(pmapi.FlagSpfFail) {
+		failures = append(failures, "spf")
+	}
+	if msg.Has(pmapi.FlagDkimFail) {
+		failures = append(failures, "dkim")
+	}
+	if msg.Has(pmapi.FlagDmarcFail) {
+		failures = append(failures, "dmarc")
+	}
+	if len(failures) > 0 {
+		h.Set(spamHeaderPrefix+"Auth-Failures", strings.Join(failures, ", "))
+	}
+}
+
 func SetBodyContentFields(h *textproto.MIMEHeader, m *pmapi.Message) {
 	h.Set("Content-Type", m.MIMEType+"; charset=utf-8")
 	h.Set("Content-Disposition", "inline")
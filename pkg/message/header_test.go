@@ -0,0 +1,55 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package message
+
+import (
+	"net/mail"
+	"testing"
+
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHeaderSpamFields(t *testing.T) {
+	msg := &pmapi.Message{
+		Flags:     pmapi.FlagReceived | pmapi.FlagSpamAuto | pmapi.FlagPhishingAuto | pmapi.FlagSpfFail | pmapi.FlagDmarcFail,
+		SpamScore: 101,
+		Header: mail.Header{
+			"X-Pm-Spam-Verdict": {"ham"},
+			"X-Pm-Spam-Foo":     {"bar"},
+		},
+	}
+
+	h := GetHeader(msg)
+	assert.Equal(t, "101", h.Get("X-Pm-Spam-Score"))
+	assert.Equal(t, "spam; auto", h.Get("X-Pm-Spam-Verdict"))
+	assert.Equal(t, "yes; auto", h.Get("X-Pm-Spam-Phishing"))
+	assert.Equal(t, "spf, dmarc", h.Get("X-Pm-Spam-Auth-Failures"))
+	assert.Empty(t, h.Get("X-Pm-Spam-Foo"))
+}
+
+func TestGetHeaderSpamFieldsOnlyForReceived(t *testing.T) {
+	msg := &pmapi.Message{
+		Flags:  pmapi.FlagSent,
+		Header: mail.Header{"X-Pm-Spam-Verdict": {"ham"}},
+	}
+
+	h := GetHeader(msg)
+	assert.Empty(t, h.Get("X-Pm-Spam-Score"))
+	assert.Empty(t, h.Get("X-Pm-Spam-Verdict"))
+}
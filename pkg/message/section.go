@@ -338,6 +338,25 @@ func (bs *BodyStructure) GetSectionContent(wholeMail io.ReadSeeker, sectionPath
 	*/
 }
 
+// GetSectionReader returns reader of the section including its header
+// so big sections can be streamed without copying them to memory.
+func (bs *BodyStructure) GetSectionReader(wholeMail io.ReaderAt, sectionPath []int) (*io.SectionReader, error) {
+	info, err := bs.getInfo(sectionPath)
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(wholeMail, int64(info.start), int64(info.size)), nil
+}
+
+// GetSectionContentReader returns reader of the section without its header.
+func (bs *BodyStructure) GetSectionContentReader(wholeMail io.ReaderAt, sectionPath []int) (*io.SectionReader, error) {
+	info, err := bs.getInfo(sectionPath)
+	if err != nil {
+		return nil, err
+	}
+	return io.NewSectionReader(wholeMail, int64(info.start+info.size-info.bsize), int64(info.bsize)), nil
+}
+
 func (bs *BodyStructure) GetSectionHeader(sectionPath []int) (header textproto.MIMEHeader, err error) {
 	info, err := bs.getInfo(sectionPath)
 	if err != nil {
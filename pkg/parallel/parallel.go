@@ -18,10 +18,25 @@
 package parallel
 
 import (
+	"runtime"
 	"sync"
 	"time"
 )
 
+// ScaledWorkers returns the number of workers for a pool so it grows with
+// the number of usable CPUs, but is at least one and at most max.
+func ScaledWorkers(perCPU, max int) int {
+	workers := perCPU * runtime.GOMAXPROCS(0)
+	switch {
+	case workers < 1:
+		return 1
+	case workers > max:
+		return max
+	default:
+		return workers
+	}
+}
+
 // parallelJob is to be used for passing items between input, worker and
 // collector. `idx` is there to know the original order.
 type parallelJob struct {
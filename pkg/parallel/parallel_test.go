@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"runtime"
 	"testing"
 	"time"
 
@@ -129,3 +130,11 @@ func processSleep(value interface{}) (interface{}, error) {
 func collectNil(idx int, value interface{}) error {
 	return nil
 }
+
+func TestScaledWorkers(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(2))
+
+	r.Equal(t, 10, ScaledWorkers(5, 100))
+	r.Equal(t, 8, ScaledWorkers(5, 8))
+	r.Equal(t, 1, ScaledWorkers(0, 8))
+}
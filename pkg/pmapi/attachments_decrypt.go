@@ -0,0 +1,175 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/pkg/errors"
+	openpgperrors "golang.org/x/crypto/openpgp/errors"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// attachmentReadBufferSize is how much of the encrypted attachment is read
+// ahead while it is being decrypted.
+const attachmentReadBufferSize = 64 * 1024
+
+// decryptAttachment returns reader which decrypts the attachment data as it
+// is read. Neither the encrypted nor the decrypted attachment is loaded into
+// memory as a whole. Signatures are not verified.
+//
+// When no key in kr can decrypt the session key, ErrKeyIncorrect is returned
+// together with a reader of the original encrypted message, i.e. the key
+// packets followed by the untouched data, so that it can still be handed
+// over to the user as is.
+func decryptAttachment(kr *crypto.KeyRing, keyPackets []byte, data io.Reader) (io.Reader, error) {
+	if kr == nil {
+		return nil, ErrNoKeyringAvailable
+	}
+
+	// Everything read ahead from data is kept until the session key is
+	// decrypted to be able to return the encrypted message in case it is not.
+	raw := &packetRecorder{r: data}
+	raw.start()
+
+	rec := &packetRecorder{
+		r: io.MultiReader(bytes.NewReader(keyPackets), bufio.NewReaderSize(raw, attachmentReadBufferSize)),
+	}
+	packets := packet.NewReader(rec)
+
+	// Key packets are kept in serialized form because that's what keyring
+	// needs to decrypt the session key.
+	var encryptedKeys [][]byte
+	for {
+		rec.start()
+		p, err := packets.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch p := p.(type) {
+		case *packet.EncryptedKey:
+			encryptedKeys = append(encryptedKeys, rec.stop())
+		case *packet.SymmetricallyEncrypted:
+			rec.stop()
+			decrypted, err := decryptDataPacket(kr, encryptedKeys, p)
+			consumed := raw.stop()
+			if err == openpgperrors.ErrKeyIncorrect {
+				return io.MultiReader(bytes.NewReader(keyPackets), bytes.NewReader(consumed), data), err
+			}
+			return decrypted, err
+		}
+	}
+}
+
+func decryptDataPacket(kr *crypto.KeyRing, encryptedKeys [][]byte, se *packet.SymmetricallyEncrypted) (io.Reader, error) {
+	for _, encryptedKey := range encryptedKeys {
+		sessionKey, err := kr.DecryptSessionKey(encryptedKey)
+		if err != nil {
+			continue
+		}
+
+		cipherFunc, err := sessionKey.GetCipherFunc()
+		if err != nil {
+			continue
+		}
+
+		decrypted, err := se.Decrypt(cipherFunc, sessionKey.Key)
+		if err == openpgperrors.ErrKeyIncorrect {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		return newLiteralDataReader(decrypted)
+	}
+
+	return nil, openpgperrors.ErrKeyIncorrect
+}
+
+// newLiteralDataReader skips to the literal data inside the decrypted packet,
+// decompressing it if needed.
+func newLiteralDataReader(decrypted io.ReadCloser) (io.Reader, error) {
+	packets := packet.NewReader(decrypted)
+	for {
+		p, err := packets.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		switch p := p.(type) {
+		case *packet.Compressed:
+			if err := packets.Push(p.Body); err != nil {
+				return nil, err
+			}
+		case *packet.LiteralData:
+			return &literalDataReader{body: p.Body, decrypted: decrypted}, nil
+		case *packet.OnePassSignature:
+			continue
+		default:
+			return nil, errors.Errorf("unexpected packet %T in attachment", p)
+		}
+	}
+}
+
+// literalDataReader checks the integrity of the decrypted data once the
+// whole literal data is read.
+type literalDataReader struct {
+	body      io.Reader
+	decrypted io.ReadCloser
+}
+
+func (r *literalDataReader) Read(b []byte) (int, error) {
+	n, err := r.body.Read(b)
+	if err == io.EOF {
+		if closeErr := r.decrypted.Close(); closeErr != nil {
+			return n, closeErr
+		}
+	}
+	return n, err
+}
+
+// packetRecorder keeps bytes read from r while recording is started.
+type packetRecorder struct {
+	r         io.Reader
+	buf       bytes.Buffer
+	recording bool
+}
+
+func (rec *packetRecorder) Read(b []byte) (int, error) {
+	n, err := rec.r.Read(b)
+	if rec.recording {
+		_, _ = rec.buf.Write(b[:n])
+	}
+	return n, err
+}
+
+func (rec *packetRecorder) start() {
+	rec.buf.Reset()
+	rec.recording = true
+}
+
+func (rec *packetRecorder) stop() []byte {
+	rec.recording = false
+	recorded := append([]byte{}, rec.buf.Bytes()...)
+	rec.buf.Reset()
+	return recorded
+}
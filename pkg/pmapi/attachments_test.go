@@ -31,9 +31,10 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
 	pmmime "github.com/ProtonMail/proton-bridge/pkg/mime"
-
 	"github.com/stretchr/testify/assert"
+	openpgperrors "golang.org/x/crypto/openpgp/errors"
 )
 
 var testAttachment = &Attachment{
@@ -221,3 +222,30 @@ func decryptAndCheck(t *testing.T, data io.Reader) {
 	assert.Nil(t, err)
 	assert.Equal(t, testAttachmentCleartext, string(b))
 }
+
+func TestAttachment_DecryptWithWrongKey(t *testing.T) {
+	key, err := crypto.GenerateKey("name", "name@example.com", "x25519", 0)
+	assert.NoError(t, err)
+	kr, err := crypto.NewKeyRing(key)
+	assert.NoError(t, err)
+
+	dataBytes, _ := base64.StdEncoding.DecodeString(testAttachmentEncrypted)
+	r, err := testAttachment.Decrypt(bytes.NewReader(dataBytes), kr)
+	assert.Equal(t, openpgperrors.ErrKeyIncorrect, err)
+
+	// The whole encrypted message must be returned untouched.
+	keyPackets, _ := base64.StdEncoding.DecodeString(testAttachment.KeyPackets)
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, append(keyPackets, dataBytes...), b)
+}
+
+func TestAttachment_DecryptTampered(t *testing.T) {
+	dataBytes, _ := base64.StdEncoding.DecodeString(testAttachmentEncrypted)
+	dataBytes[len(dataBytes)-1] ^= 0xff
+
+	r, err := testAttachment.Decrypt(bytes.NewReader(dataBytes), testPrivateKeyRing)
+	assert.NoError(t, err)
+	_, err = ioutil.ReadAll(r)
+	assert.Error(t, err)
+}
@@ -214,6 +214,14 @@ func (c *client) sendAuth(auth *Auth) {
 	c.cm.HandleAuth(ClientAuth{UserID: c.userID, Auth: auth})
 }
 
+// sendRevokedAuth tells the ClientManager that the session was revoked by the API,
+// for example because the account password was changed, and the user has to log in again.
+func (c *client) sendRevokedAuth() {
+	c.log.Debug("Client is sending revoked auth to ClientManager")
+
+	c.cm.HandleAuth(ClientAuth{UserID: c.userID, Revoked: true})
+}
+
 // AuthInfo gets authentication info for a user.
 func (c *client) AuthInfo(username string) (info *AuthInfo, err error) {
 	infoReq := &AuthInfoReq{
@@ -392,6 +400,9 @@ func (c *client) AuthRefresh(uidAndRefreshToken string) (auth *Auth, err error)
 		return
 	}
 	if err = res.Err(); err != nil {
+		if isAuthRevoked(res.Res) {
+			err = ErrAuthRevoked
+		}
 		return
 	}
 
@@ -408,6 +419,21 @@ func (c *client) AuthRefresh(uidAndRefreshToken string) (auth *Auth, err error)
 	return auth, err
 }
 
+// isAuthRevoked returns whether the API refused the refresh token for good.
+// That is not the case for temporary errors, after which the refresh can be retried.
+func isAuthRevoked(res Res) bool {
+	if res.Code == InvalidRefreshToken {
+		return true
+	}
+
+	switch res.StatusCode {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusUnprocessableEntity:
+		return res.Code != HumanVerificationRequired
+	}
+
+	return false
+}
+
 func (c *client) AuthSalt() (string, error) {
 	salts, err := c.GetKeySalts()
 	if err != nil {
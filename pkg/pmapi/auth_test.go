@@ -309,6 +309,23 @@ func TestClient_AuthRefresh_HasUID(t *testing.T) {
 	Equals(t, exp, auth)
 }
 
+func TestClient_AuthRefresh_Revoked(t *testing.T) {
+	finish, c := newTestServerCallbacks(t,
+		func(tb testing.TB, w http.ResponseWriter, r *http.Request) string {
+			Ok(tb, checkMethodAndPath(r, "POST", "/auth/refresh"))
+
+			w.Header().Set("content-type", "application/json;charset=utf-8")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{"Code": 10013, "Error": "Invalid refresh token"}`))
+			return ""
+		},
+	)
+	defer finish()
+
+	_, err := c.AuthRefresh(testUID + ":" + testRefreshToken)
+	Equals(t, ErrAuthRevoked, err)
+}
+
 func TestClient_Logout(t *testing.T) {
 	finish, c := newTestServerCallbacks(t,
 		func(tb testing.TB, w http.ResponseWriter, r *http.Request) string {
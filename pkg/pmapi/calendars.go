@@ -0,0 +1,282 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/sirupsen/logrus"
+)
+
+// Calendar is a calendar of the user.
+type Calendar struct {
+	ID          string
+	Name        string
+	Description string
+	Color       string
+	Display     int
+	Flags       int
+}
+
+// CalendarMember is a membership of one of the user addresses in a calendar.
+type CalendarMember struct {
+	ID          string
+	CalendarID  string
+	AddressID   string
+	Email       string
+	Permissions int
+}
+
+// CalendarKey is a private key of the calendar locked by the calendar passphrase.
+type CalendarKey struct {
+	ID           string
+	CalendarID   string
+	PassphraseID string
+	PrivateKey   string
+	Flags        int
+}
+
+// CalendarMemberPassphrase is the calendar passphrase encrypted
+// to the address key of the member.
+type CalendarMemberPassphrase struct {
+	MemberID   string
+	Passphrase string
+	Signature  string
+}
+
+type CalendarPassphrase struct {
+	ID                string
+	MemberPassphrases []CalendarMemberPassphrase
+}
+
+// CalendarBootstrap contains everything needed to unlock the calendar keys.
+type CalendarBootstrap struct {
+	Keys       []CalendarKey
+	Passphrase CalendarPassphrase
+	Members    []CalendarMember
+}
+
+// CalendarEventCard is one part of the event. Same as with contacts, the event
+// is split into several cards, each being iCalendar with a subset of properties.
+// Encrypted card is base64 encoded data packet without the key packet.
+type CalendarEventCard struct {
+	Type      int
+	Data      string
+	Signature string
+	Author    string
+}
+
+type CalendarEvent struct {
+	ID                string
+	CalendarID        string
+	UID               string
+	SharedEventID     string
+	CreateTime        int64
+	ModifyTime        int64
+	StartTime         int64
+	EndTime           int64
+	SharedKeyPacket   string
+	CalendarKeyPacket string
+	SharedEvents      []CalendarEventCard
+	CalendarEvents    []CalendarEventCard
+}
+
+var ErrNoCalendarMember = errors.New("none of the addresses is member of the calendar")
+
+//====================== READ ===========================
+
+// ListCalendars gets all calendars of the user.
+func (c *client) ListCalendars() (calendars []*Calendar, err error) {
+	req, err := c.NewRequest("GET", "/calendar/v1", nil)
+	if err != nil {
+		return
+	}
+
+	var res struct {
+		Res
+		Calendars []*Calendar
+	}
+	if err = c.DoJSON(req, &res); err != nil {
+		return
+	}
+
+	calendars, err = res.Calendars, res.Err()
+	return
+}
+
+// GetCalendarBootstrap gets keys, passphrase and members of the calendar.
+func (c *client) GetCalendarBootstrap(calendarID string) (bootstrap *CalendarBootstrap, err error) {
+	req, err := c.NewRequest("GET", "/calendar/v1/"+calendarID+"/bootstrap", nil)
+	if err != nil {
+		return
+	}
+
+	var res struct {
+		Res
+		CalendarBootstrap
+	}
+	if err = c.DoJSON(req, &res); err != nil {
+		return
+	}
+
+	bootstrap, err = &res.CalendarBootstrap, res.Err()
+	return
+}
+
+// ListCalendarEvents gets one page of events of the calendar.
+func (c *client) ListCalendarEvents(calendarID string, page int, pageSize int) (events []*CalendarEvent, err error) {
+	v := url.Values{}
+	v.Set("Page", strconv.Itoa(page))
+	if pageSize > 0 {
+		v.Set("PageSize", strconv.Itoa(pageSize))
+	}
+
+	req, err := c.NewRequest("GET", "/calendar/v1/"+calendarID+"/events?"+v.Encode(), nil)
+	if err != nil {
+		return
+	}
+
+	var res struct {
+		Res
+		Events []*CalendarEvent
+	}
+	if err = c.DoJSON(req, &res); err != nil {
+		return
+	}
+
+	events, err = res.Events, res.Err()
+	return
+}
+
+//====================== DECRYPT ===========================
+
+// UnlockCalendarKeyRing decrypts the calendar passphrase with the key of the
+// member address and returns keyring with unlocked calendar keys.
+func (c *client) UnlockCalendarKeyRing(bootstrap *CalendarBootstrap) (*crypto.KeyRing, error) {
+	for _, member := range bootstrap.Members {
+		addrKeyRing, ok := c.addrKeyRing[member.AddressID]
+		if !ok {
+			continue
+		}
+		for _, memberPassphrase := range bootstrap.Passphrase.MemberPassphrases {
+			if memberPassphrase.MemberID != member.ID {
+				continue
+			}
+			passphrase, err := decrypt(addrKeyRing, memberPassphrase.Passphrase)
+			if err != nil {
+				return nil, err
+			}
+			return unlockCalendarKeys(bootstrap.Keys, bootstrap.Passphrase.ID, []byte(passphrase))
+		}
+	}
+
+	return nil, ErrNoCalendarMember
+}
+
+func unlockCalendarKeys(keys []CalendarKey, passphraseID string, passphrase []byte) (*crypto.KeyRing, error) {
+	kr, err := crypto.NewKeyRing(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range keys {
+		if key.PassphraseID != passphraseID {
+			continue
+		}
+
+		lockedKey, err := crypto.NewKeyFromArmored(key.PrivateKey)
+		if err != nil {
+			logrus.WithError(err).WithField("keyID", key.ID).Warn("Cannot parse calendar key")
+			continue
+		}
+
+		unlockedKey, err := lockedKey.Unlock(passphrase)
+		if err != nil {
+			logrus.WithError(err).WithField("keyID", key.ID).Warn("Cannot unlock calendar key")
+			continue
+		}
+
+		if err := kr.AddKey(unlockedKey); err != nil {
+			return nil, err
+		}
+	}
+
+	if kr.CountEntities() == 0 {
+		return nil, errors.New("no calendar key could be unlocked")
+	}
+
+	return kr, nil
+}
+
+// DecryptCalendarEvent returns iCalendar data of all cards of the event.
+// Shared cards are encrypted with session key from the shared key packet,
+// calendar cards (e.g. attendee status) with the calendar key packet.
+// Signatures are not verified.
+func DecryptCalendarEvent(kr *crypto.KeyRing, event *CalendarEvent) ([]string, error) {
+	shared, err := decryptCalendarEventCards(kr, event.SharedKeyPacket, event.SharedEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar, err := decryptCalendarEventCards(kr, event.CalendarKeyPacket, event.CalendarEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(shared, calendar...), nil
+}
+
+func decryptCalendarEventCards(kr *crypto.KeyRing, keyPacket string, cards []CalendarEventCard) ([]string, error) {
+	var sessionKey *crypto.SessionKey
+
+	data := make([]string, 0, len(cards))
+	for _, card := range cards {
+		if !isEncryptedCardType(card.Type) {
+			data = append(data, card.Data)
+			continue
+		}
+
+		if sessionKey == nil {
+			rawKeyPacket, err := base64.StdEncoding.DecodeString(keyPacket)
+			if err != nil {
+				return nil, err
+			}
+			if sessionKey, err = kr.DecryptSessionKey(rawKeyPacket); err != nil {
+				return nil, err
+			}
+		}
+
+		dataPacket, err := base64.StdEncoding.DecodeString(card.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		plain, err := sessionKey.Decrypt(dataPacket)
+		if err != nil {
+			return nil, err
+		}
+
+		data = append(data, plain.GetString())
+	}
+
+	return data, nil
+}
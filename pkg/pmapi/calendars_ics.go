@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// icsMaxLineLength is the maximal length of line in octets, see RFC 5545 3.1.
+const icsMaxLineLength = 75
+
+var errNoVEvent = errors.New("calendar card does not contain any event")
+
+// CalendarEventToVEvent merges decrypted cards of one event (see
+// DecryptCalendarEvent) into a single VEVENT component. Properties repeated
+// in several cards (e.g. UID or DTSTAMP) are included only once.
+func CalendarEventToVEvent(cards []string) (string, error) {
+	var properties, components []string
+	seen := map[string]bool{}
+
+	add := func(list *[]string, item string) {
+		if !seen[item] {
+			seen[item] = true
+			*list = append(*list, item)
+		}
+	}
+
+	for _, card := range cards {
+		hasEvent := false
+		inEvent := false
+		depth := 0
+		var nested []string
+
+		for _, line := range unfoldICSLines(card) {
+			upper := strings.ToUpper(line)
+
+			if !inEvent {
+				if upper == "BEGIN:VEVENT" {
+					inEvent, hasEvent = true, true
+				}
+				continue
+			}
+
+			if depth == 0 && upper == "END:VEVENT" {
+				inEvent = false
+				continue
+			}
+
+			if strings.HasPrefix(upper, "BEGIN:") {
+				depth++
+			}
+
+			if depth == 0 {
+				add(&properties, line)
+				continue
+			}
+
+			nested = append(nested, line)
+
+			if strings.HasPrefix(upper, "END:") {
+				depth--
+				if depth == 0 {
+					add(&components, strings.Join(nested, "\n"))
+					nested = nil
+				}
+			}
+		}
+
+		if !hasEvent {
+			return "", errNoVEvent
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("BEGIN:VEVENT\r\n")
+	for _, property := range properties {
+		b.WriteString(foldICSLine(property))
+	}
+	for _, component := range components {
+		for _, line := range strings.Split(component, "\n") {
+			b.WriteString(foldICSLine(line))
+		}
+	}
+	b.WriteString("END:VEVENT\r\n")
+
+	return b.String(), nil
+}
+
+// unfoldICSLines returns content lines with continuation lines joined.
+func unfoldICSLines(data string) (lines []string) {
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return
+}
+
+// foldICSLine splits the line into lines not longer than icsMaxLineLength
+// octets without breaking multi-byte characters. Result ends with CRLF.
+func foldICSLine(line string) string {
+	var b strings.Builder
+
+	limit := icsMaxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with space which counts to the limit.
+		limit = icsMaxLineLength - 1
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+
+	return b.String()
+}
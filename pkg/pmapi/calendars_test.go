@@ -0,0 +1,159 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const testListCalendarsResponseBody = `{
+    "Code": 1000,
+    "Calendars": [
+        {
+            "ID": "calendarID",
+            "Name": "My calendar",
+            "Description": "",
+            "Color": "#7272a7",
+            "Display": 1,
+            "Flags": 1
+        }
+    ]
+}`
+
+func TestCalendar_ListCalendars(t *testing.T) {
+	s, c := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Ok(t, checkMethodAndPath(r, "GET", "/calendar/v1"))
+
+		fmt.Fprint(w, testListCalendarsResponseBody)
+	}))
+	defer s.Close()
+
+	calendars, err := c.ListCalendars()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Calendar{{
+		ID:      "calendarID",
+		Name:    "My calendar",
+		Color:   "#7272a7",
+		Display: 1,
+		Flags:   1,
+	}}, calendars)
+}
+
+func TestCalendar_ListCalendarEvents(t *testing.T) {
+	s, c := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Ok(t, checkMethodAndPath(r, "GET", "/calendar/v1/calendarID/events?Page=1&PageSize=100"))
+
+		fmt.Fprint(w, `{"Code": 1000, "Events": [{"ID": "eventID", "CalendarID": "calendarID", "UID": "uid"}]}`)
+	}))
+	defer s.Close()
+
+	events, err := c.ListCalendarEvents("calendarID", 1, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []*CalendarEvent{{ID: "eventID", CalendarID: "calendarID", UID: "uid"}}, events)
+}
+
+func TestCalendar_UnlockAndDecryptEvent(t *testing.T) {
+	addrKey, err := crypto.GenerateKey("name", "name@example.com", "x25519", 0)
+	assert.NoError(t, err)
+	addrKeyRing, err := crypto.NewKeyRing(addrKey)
+	assert.NoError(t, err)
+
+	passphrase := "calendar passphrase"
+	encryptedPassphrase, err := addrKeyRing.Encrypt(crypto.NewPlainMessageFromString(passphrase), nil)
+	assert.NoError(t, err)
+	armoredPassphrase, err := encryptedPassphrase.GetArmored()
+	assert.NoError(t, err)
+
+	calendarKey, err := crypto.GenerateKey("calendar", "calendar@example.com", "x25519", 0)
+	assert.NoError(t, err)
+	lockedCalendarKey, err := calendarKey.Lock([]byte(passphrase))
+	assert.NoError(t, err)
+	armoredCalendarKey, err := lockedCalendarKey.Armor()
+	assert.NoError(t, err)
+
+	c := newTestClient(newTestClientManager(testClientConfig))
+	c.addrKeyRing["addressID"] = addrKeyRing
+
+	calendarKeyRing, err := c.UnlockCalendarKeyRing(&CalendarBootstrap{
+		Keys:       []CalendarKey{{ID: "keyID", PassphraseID: "passphraseID", PrivateKey: armoredCalendarKey}},
+		Passphrase: CalendarPassphrase{ID: "passphraseID", MemberPassphrases: []CalendarMemberPassphrase{{MemberID: "memberID", Passphrase: armoredPassphrase}}},
+		Members:    []CalendarMember{{ID: "memberID", AddressID: "addressID"}},
+	})
+	assert.NoError(t, err)
+
+	sessionKey, err := crypto.GenerateSessionKey()
+	assert.NoError(t, err)
+	keyPacket, err := calendarKeyRing.EncryptSessionKey(sessionKey)
+	assert.NoError(t, err)
+	dataPacket, err := sessionKey.Encrypt(crypto.NewPlainMessageFromString("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nSUMMARY:Lunch\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.NoError(t, err)
+
+	cards, err := DecryptCalendarEvent(calendarKeyRing, &CalendarEvent{
+		SharedKeyPacket: base64.StdEncoding.EncodeToString(keyPacket),
+		SharedEvents: []CalendarEventCard{
+			{Type: CardSigned, Data: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:uid\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"},
+			{Type: CardEncrypted | CardSigned, Data: base64.StdEncoding.EncodeToString(dataPacket)},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, cards, 2)
+	assert.Contains(t, cards[1], "SUMMARY:Lunch")
+}
+
+func TestCalendar_UnlockWithoutMember(t *testing.T) {
+	c := newTestClient(newTestClientManager(testClientConfig))
+
+	_, err := c.UnlockCalendarKeyRing(&CalendarBootstrap{
+		Members: []CalendarMember{{ID: "memberID", AddressID: "otherAddressID"}},
+	})
+	assert.Equal(t, ErrNoCalendarMember, err)
+}
+
+func TestCalendar_CalendarEventToVEvent(t *testing.T) {
+	longDescription := strings.Repeat("Příliš žluťoučký kůň ", 10)
+
+	vevent, err := CalendarEventToVEvent([]string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:uid\r\nDTSTAMP:20200101T120000Z\r\nDTSTART;TZID=Europe/Zurich:20200102T120000\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+		"BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nUID:uid\r\nDTSTAMP:20200101T120000Z\r\nSUMMARY:Lunch\r\nDESCRIPTION:" + longDescription + "\r\nBEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(vevent, "BEGIN:VEVENT\r\n"))
+	assert.True(t, strings.HasSuffix(vevent, "BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\nEND:VEVENT\r\n"))
+	assert.Equal(t, 1, strings.Count(vevent, "UID:uid"))
+	assert.Equal(t, 1, strings.Count(vevent, "DTSTAMP:"))
+	assert.Contains(t, vevent, "SUMMARY:Lunch\r\n")
+	assert.NotContains(t, vevent, "VERSION")
+
+	for _, line := range strings.Split(vevent, "\r\n") {
+		assert.True(t, len(line) <= icsMaxLineLength, line)
+	}
+	assert.Contains(t, strings.Join(unfoldICSLines(vevent), "\n"), "DESCRIPTION:"+longDescription+"\n")
+}
+
+func TestCalendar_CalendarEventToVEventWithoutEvent(t *testing.T) {
+	_, err := CalendarEventToVEvent([]string{"BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"})
+	assert.Equal(t, errNoVEvent, err)
+}
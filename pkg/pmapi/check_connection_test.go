@@ -75,7 +75,7 @@ func checkCheckConnection(t *testing.T, path string, expectedErrMessage string)
 
 	ch := make(chan error)
 
-	go checkConnection(client, "http://localhost:"+testServerPort+"/"+path, ch)
+	go checkConnection(client, "http://localhost:"+testServerPort+"/"+path, ch, nil)
 
 	timeout := time.After(testRequestTimeout + time.Second)
 	select {
@@ -47,6 +47,8 @@ const (
 	ForceUpgradeInvalidAPI    = 5004
 	ForceUpgradeBadAppVersion = 5005
 	APIOffline                = 7001
+	HumanVerificationRequired = 9001
+	InvalidRefreshToken       = 10013
 	ImportMessageTooLong      = 36022
 	BansRequests              = 85131
 )
@@ -54,6 +56,7 @@ const (
 // The output errors.
 var (
 	ErrInvalidToken       = errors.New("refresh token invalid")
+	ErrAuthRevoked        = errors.New("session was revoked, please log in again")
 	ErrAPINotReachable    = errors.New("cannot reach the server")
 	ErrUpgradeApplication = errors.New("application upgrade required")
 	ErrConnectionSlow     = errors.New("request canceled because connection speed was too slow")
@@ -121,6 +124,9 @@ type client struct {
 	addrKeyRing map[string]*crypto.KeyRing
 	keyRingLock sync.Locker
 
+	hvTokenType string
+	hvToken     string
+
 	log *logrus.Entry
 }
 
@@ -128,7 +134,7 @@ type client struct {
 func newClient(cm *ClientManager, userID string) *client {
 	return &client{
 		cm:            cm,
-		hc:            getHTTPClient(cm.config, cm.roundTripper, cm.cookieJar),
+		hc:            getHTTPClient(cm.config, &userRoundTripper{cm: cm, userID: userID}, cm.cookieJar),
 		userID:        userID,
 		requestLocker: &sync.Mutex{},
 		refreshLocker: &sync.Mutex{},
@@ -246,6 +252,8 @@ func (c *client) doBuffered(req *http.Request, bodyBuffer []byte, retryUnauthori
 		req.Header.Set("Authorization", "Bearer "+c.accessToken)
 	}
 
+	c.setHumanVerificationHeaders(req)
+
 	c.log.Debugln("Requesting ", req.Method, req.URL.RequestURI())
 	if logrus.GetLevel() == logrus.TraceLevel {
 		head := ""
@@ -259,7 +267,11 @@ func (c *client) doBuffered(req *http.Request, bodyBuffer []byte, retryUnauthori
 	}
 
 	hasBody := len(bodyBuffer) > 0
-	if res, err = c.hc.Do(req); err != nil {
+	endpoint := getEndpoint(req)
+	start := time.Now()
+	res, err = c.hc.Do(req)
+	c.cm.requestStats.record(endpoint, time.Since(start), isRequestFailed(res, err))
+	if err != nil {
 		if res == nil {
 			c.log.WithError(err).Error("Cannot get response")
 			err = ErrAPINotReachable
@@ -273,6 +285,7 @@ func (c *client) doBuffered(req *http.Request, bodyBuffer []byte, retryUnauthori
 			crypto.UpdateTime(serverTime.Unix())
 		}
 	}
+	c.cm.noteServerTime(res, start)
 
 	if res.StatusCode == http.StatusUnauthorized {
 		if hasBody {
@@ -294,6 +307,8 @@ func (c *client) doBuffered(req *http.Request, bodyBuffer []byte, retryUnauthori
 		if headerAfter, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil && headerAfter > 0 {
 			retryAfter = headerAfter
 		}
+		c.cm.noteRateLimit(time.Duration(retryAfter) * time.Second)
+
 		// To avoid spikes when all clients retry at the same time, we add some random wait.
 		retryAfter += rand.Intn(10)
 
@@ -302,6 +317,7 @@ func (c *client) doBuffered(req *http.Request, bodyBuffer []byte, retryUnauthori
 			req.Body = ioutil.NopCloser(r)
 		}
 
+		c.cm.requestStats.recordRetry(endpoint)
 		c.log.Warningf("Retrying %s after %ds induced by http code %d", req.URL.Path, retryAfter, res.StatusCode)
 		time.Sleep(time.Duration(retryAfter) * time.Second)
 		_, _ = io.Copy(ioutil.Discard, res.Body)
@@ -393,6 +409,7 @@ func (c *client) doJSONBuffered(req *http.Request, reqBodyBuffer []byte, data in
 	if err := json.Unmarshal(resBody, errCode); err == nil {
 		if errCode.Code == BansRequests {
 			retryAfter := 3
+			c.cm.requestStats.recordRetry(getEndpoint(req))
 			c.log.Warningf("Retrying %s after %ds induced by API code %d", req.URL.Path, retryAfter, errCode.Code)
 			time.Sleep(time.Duration(retryAfter) * time.Second)
 			if len(reqBodyBuffer) > 0 {
@@ -472,7 +489,11 @@ func (c *client) refreshAccessToken() (err error) {
 	}
 
 	if _, err := c.AuthRefresh(refreshToken); err != nil {
-		if err != ErrAPINotReachable {
+		switch err {
+		case ErrAPINotReachable:
+		case ErrAuthRevoked:
+			c.sendRevokedAuth()
+		default:
 			c.sendAuth(nil)
 		}
 		return errors.Wrap(err, "failed to refresh auth")
@@ -105,7 +105,11 @@ func TestClient_DoRetryAfter(t *testing.T) {
 	)
 	defer finish()
 
+	var notifiedRetryAfter time.Duration
+	c.cm.SetRateLimitNotifier(func(retryAfter time.Duration) { notifiedRetryAfter = retryAfter })
+
 	require.Nil(t, c.SendSimpleMetric("some_category", "some_action", "some_label"))
+	require.Equal(t, time.Second, notifiedRetryAfter)
 	waitedTime := secondAttemptTime.Sub(testStart)
 	isInRange := 1*time.Second < waitedTime && waitedTime <= 11*time.Second
 	require.True(t, isInRange, "Waited time: %v", waitedTime)
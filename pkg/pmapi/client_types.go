@@ -29,6 +29,7 @@ type Client interface {
 	AuthInfo(username string) (*AuthInfo, error)
 	AuthRefresh(token string) (*Auth, error)
 	Auth2FA(twoFactorCode string, auth *Auth) (*Auth2FA, error)
+	SetHumanVerificationToken(tokenType, token string)
 	AuthSalt() (salt string, err error)
 	Logout()
 	DeleteAuth() error
@@ -60,6 +61,10 @@ type Client interface {
 	UnlabelMessages(apiIDs []string, labelID string) error
 	MarkMessagesRead(apiIDs []string) error
 	MarkMessagesUnread(apiIDs []string) error
+	MarkMessagesSpam(apiIDs []string) error
+	MarkMessagesHam(apiIDs []string) error
+	SnoozeMessages(apiIDs []string, until int64) error
+	UnsnoozeMessages(apiIDs []string) error
 
 	ListLabels() ([]*Label, error)
 	CreateLabel(label *Label) (*Label, error)
@@ -73,8 +78,14 @@ type Client interface {
 	GetMailSettings() (MailSettings, error)
 	GetContactEmailByEmail(string, int, int) ([]ContactEmail, error)
 	GetContactByID(string) (Contact, error)
+	GetContactsForExport(page int, pageSize int) ([]Contact, error)
 	DecryptAndVerifyCards([]Card) ([]Card, error)
 
+	ListCalendars() ([]*Calendar, error)
+	GetCalendarBootstrap(calendarID string) (*CalendarBootstrap, error)
+	ListCalendarEvents(calendarID string, page int, pageSize int) ([]*CalendarEvent, error)
+	UnlockCalendarKeyRing(bootstrap *CalendarBootstrap) (*crypto.KeyRing, error)
+
 	GetAttachment(id string) (att io.ReadCloser, err error)
 	CreateAttachment(att *Attachment, r io.Reader, sig io.Reader) (created *Attachment, err error)
 	DeleteAttachment(attID string) (err error)
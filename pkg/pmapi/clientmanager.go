@@ -39,6 +39,9 @@ type ClientManager struct {
 	config       *ClientConfig
 	roundTripper http.RoundTripper
 
+	userTransports       map[string]*http.Transport
+	userTransportsLocker sync.RWMutex
+
 	clients       map[string]Client
 	clientsLocker sync.Locker
 	cookieJar     http.CookieJar
@@ -61,6 +64,16 @@ type ClientManager struct {
 
 	idGen idGen
 
+	rateLimitNotifier func(retryAfter time.Duration)
+	rateLimitedUntil  time.Time
+	rateLimitLocker   sync.RWMutex
+
+	requestStats requestStats
+
+	clockSkew         time.Duration
+	clockSkewLocker   sync.RWMutex
+	clockSkewNotifier func(skew time.Duration)
+
 	log *logrus.Entry
 }
 
@@ -75,6 +88,10 @@ func (i *idGen) next() int {
 type ClientAuth struct {
 	UserID string
 	Auth   *Auth
+
+	// Revoked is set when the API refused to refresh the session (the Auth is nil then),
+	// which happens e.g. after the account password was changed.
+	Revoked bool
 }
 
 // tokenExpiration manages the expiration of an access token.
@@ -89,6 +106,8 @@ func NewClientManager(config *ClientConfig) (cm *ClientManager) {
 		config:       config,
 		roundTripper: http.DefaultTransport,
 
+		userTransports: make(map[string]*http.Transport),
+
 		clients:       make(map[string]Client),
 		clientsLocker: &sync.Mutex{},
 
@@ -132,6 +151,42 @@ func (cm *ClientManager) SetCookieJar(jar http.CookieJar) {
 	cm.cookieJar = jar
 }
 
+// SetRateLimitNotifier sets the callback which is called every time the API
+// asks to slow down. The callback receives the duration requested by the server.
+func (cm *ClientManager) SetRateLimitNotifier(notifier func(retryAfter time.Duration)) {
+	cm.rateLimitNotifier = notifier
+}
+
+// GetRateLimitDelay returns how long the server asked to wait before the next
+// request, or zero if there is no active rate limit.
+func (cm *ClientManager) GetRateLimitDelay() time.Duration {
+	cm.rateLimitLocker.RLock()
+	defer cm.rateLimitLocker.RUnlock()
+
+	if delay := time.Until(cm.rateLimitedUntil); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+func (cm *ClientManager) noteRateLimit(retryAfter time.Duration) {
+	cm.rateLimitLocker.Lock()
+	if until := time.Now().Add(retryAfter); until.After(cm.rateLimitedUntil) {
+		cm.rateLimitedUntil = until
+	}
+	cm.rateLimitLocker.Unlock()
+
+	if cm.rateLimitNotifier != nil {
+		cm.rateLimitNotifier(retryAfter)
+	}
+}
+
+// GetRequestStats returns latency and error counters of requests made by
+// all clients of this manager, sorted by endpoint.
+func (cm *ClientManager) GetRequestStats() []EndpointStats {
+	return cm.requestStats.get()
+}
+
 // SetRoundTripper sets the roundtripper used by clients created by this client manager.
 func (cm *ClientManager) SetRoundTripper(rt http.RoundTripper) {
 	cm.roundTripper = rt
@@ -203,7 +258,7 @@ func (cm *ClientManager) GetRootURL() string {
 	cm.hostLocker.RLock()
 	defer cm.hostLocker.RUnlock()
 
-	return fmt.Sprintf("%v://%v", cm.scheme, cm.host)
+	return fmt.Sprintf("%v://%v", cm.scheme, urlHost(cm.host))
 }
 
 // getHost returns the host to make requests to.
@@ -318,10 +373,11 @@ func (cm *ClientManager) CheckConnection() error {
 	retAPI := make(chan error)
 
 	// vpn_status endpoint is fast and returns only OK. We check the connection only.
-	go checkConnection(client, "https://protonstatus.com/vpn_status", retStatus)
+	go checkConnection(client, "https://protonstatus.com/vpn_status", retStatus, nil)
 
 	// Check of API reachability also uses a fast endpoint.
-	go checkConnection(client, cm.GetRootURL()+"/tests/ping", retAPI)
+	// It is also a good occasion to check the local clock on start.
+	go checkConnection(client, cm.GetRootURL()+"/tests/ping", retAPI, cm.noteServerTime)
 
 	errStatus := <-retStatus
 	errAPI := <-retAPI
@@ -350,11 +406,12 @@ func (cm *ClientManager) CheckConnection() error {
 func CheckConnection() error {
 	client := &http.Client{Timeout: time.Second * 10}
 	retStatus := make(chan error)
-	go checkConnection(client, "https://protonstatus.com/vpn_status", retStatus)
+	go checkConnection(client, "https://protonstatus.com/vpn_status", retStatus, nil)
 	return <-retStatus
 }
 
-func checkConnection(client *http.Client, url string, errorChannel chan error) {
+func checkConnection(client *http.Client, url string, errorChannel chan error, noteServerTime func(*http.Response, time.Time)) {
+	sentAt := time.Now()
 	resp, err := client.Get(url)
 	if err != nil {
 		errorChannel <- err
@@ -363,6 +420,10 @@ func checkConnection(client *http.Client, url string, errorChannel chan error) {
 
 	_ = resp.Body.Close()
 
+	if noteServerTime != nil {
+		noteServerTime(resp, sentAt)
+	}
+
 	if resp.StatusCode != 200 {
 		errorChannel <- fmt.Errorf("HTTP status code %d", resp.StatusCode)
 		return
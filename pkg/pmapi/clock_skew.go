@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClockSkewThreshold is the difference between local and server clock from
+// which the skew is reported. Smaller differences are caused by network latency
+// and the one second resolution of the Date header.
+const ClockSkewThreshold = 2 * time.Minute
+
+// SetClockSkewNotifier sets the callback which is called when the local clock
+// starts to differ significantly from the server clock. The callback receives
+// the measured skew; positive skew means the local clock is ahead.
+func (cm *ClientManager) SetClockSkewNotifier(notifier func(skew time.Duration)) {
+	cm.clockSkewNotifier = notifier
+}
+
+// GetClockSkew returns the difference between local and server clock measured
+// from the last response of the server.
+func (cm *ClientManager) GetClockSkew() time.Duration {
+	cm.clockSkewLocker.RLock()
+	defer cm.clockSkewLocker.RUnlock()
+
+	return cm.clockSkew
+}
+
+// IsClockSkewed returns whether the local clock differs from the server clock
+// enough to break authentication or TLS.
+func IsClockSkewed(skew time.Duration) bool {
+	return skew > ClockSkewThreshold || skew < -ClockSkewThreshold
+}
+
+// noteServerTime updates the clock skew from the Date header of the response
+// to the request sent at the given time. The server time is compared with the
+// middle of the request to not count the latency as skew.
+func (cm *ClientManager) noteServerTime(res *http.Response, sentAt time.Time) {
+	resDate := res.Header.Get("Date")
+	if resDate == "" {
+		return
+	}
+
+	serverTime, err := http.ParseTime(resDate)
+	if err != nil {
+		return
+	}
+
+	receivedAt := time.Now()
+	localTime := sentAt.Add(receivedAt.Sub(sentAt) / 2)
+	skew := localTime.Sub(serverTime).Round(time.Second)
+
+	cm.clockSkewLocker.Lock()
+	wasSkewed := IsClockSkewed(cm.clockSkew)
+	cm.clockSkew = skew
+	cm.clockSkewLocker.Unlock()
+
+	if IsClockSkewed(skew) && !wasSkewed {
+		cm.log.WithField("skew", skew).Warn("Local clock differs from server clock")
+		if cm.clockSkewNotifier != nil {
+			cm.clockSkewNotifier(skew)
+		}
+	}
+}
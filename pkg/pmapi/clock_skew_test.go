@@ -0,0 +1,61 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	a "github.com/stretchr/testify/assert"
+)
+
+func newDateResponse(serverTime time.Time) *http.Response {
+	res := &http.Response{Header: http.Header{}}
+	res.Header.Set("Date", serverTime.UTC().Format(http.TimeFormat))
+	return res
+}
+
+func TestClockSkew(t *testing.T) {
+	cm := newTestClientManager(testClientConfig)
+
+	var notified []time.Duration
+	cm.SetClockSkewNotifier(func(skew time.Duration) {
+		notified = append(notified, skew)
+	})
+
+	cm.noteServerTime(newDateResponse(time.Now()), time.Now())
+	a.False(t, IsClockSkewed(cm.GetClockSkew()))
+
+	// Local clock one hour ahead is reported only once.
+	cm.noteServerTime(newDateResponse(time.Now().Add(-time.Hour)), time.Now())
+	cm.noteServerTime(newDateResponse(time.Now().Add(-time.Hour)), time.Now())
+	a.InDelta(t, time.Hour.Seconds(), cm.GetClockSkew().Seconds(), 2)
+	a.Len(t, notified, 1)
+
+	// Response without date does not change the skew.
+	cm.noteServerTime(&http.Response{Header: http.Header{}}, time.Now())
+	a.True(t, IsClockSkewed(cm.GetClockSkew()))
+
+	cm.noteServerTime(newDateResponse(time.Now()), time.Now())
+	a.False(t, IsClockSkewed(cm.GetClockSkew()))
+
+	cm.noteServerTime(newDateResponse(time.Now().Add(10*time.Minute)), time.Now())
+	a.Len(t, notified, 2)
+	a.True(t, notified[1] < 0)
+}
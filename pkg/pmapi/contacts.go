@@ -58,7 +58,9 @@ type ContactEmail struct {
 	LabelIDs  []string
 }
 
-var errVerificationFailed = errors.New("signature verification failed")
+// ErrCardVerificationFailed is returned together with decrypted cards when
+// the signature of any of them does not match.
+var ErrCardVerificationFailed = errors.New("signature verification failed")
 
 //================= Public utility functions ======================
 
@@ -98,7 +100,7 @@ func (c *client) DecryptAndVerifyCards(cards []Card) ([]Card, error) {
 		if isSignedCardType(card.Type) {
 			err := c.verify(card.Data, card.Signature)
 			if err != nil {
-				return cards, errVerificationFailed
+				return cards, ErrCardVerificationFailed
 			}
 		}
 	}
@@ -18,12 +18,14 @@
 package pmapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
 	"testing"
 
+	"github.com/ProtonMail/go-vcard"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -675,3 +677,34 @@ func TestClient_Decrypt(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, testCardsCleartext[0].Data, cardCleartext[0].Data)
 }
+
+func TestContact_ContactToVCard(t *testing.T) {
+	cards := []Card{
+		{
+			Type: CardSigned,
+			Data: "BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Jane Doe\r\nUID:proton-web-1\r\nitem1.EMAIL:jane@example.com\r\nEND:VCARD\r\n",
+		},
+		{
+			Type: EncryptedSignedCard,
+			Data: "BEGIN:VCARD\r\nVERSION:4.0\r\nUID:proton-web-1\r\nFN:Jane Doe\r\nTEL:+41 22 000 00 00\r\nNOTE:Met at the conference\r\nEND:VCARD\r\n",
+		},
+	}
+
+	data, err := ContactToVCard(cards)
+	assert.NoError(t, err)
+
+	card, err := vcard.NewDecoder(bytes.NewReader(data)).Decode()
+	assert.NoError(t, err)
+	assert.Equal(t, "4.0", card.Value(vcard.FieldVersion))
+	assert.Len(t, card[vcard.FieldFormattedName], 1)
+	assert.Len(t, card[vcard.FieldUID], 1)
+	assert.Equal(t, "jane@example.com", card.Value(vcard.FieldEmail))
+	assert.Equal(t, "item1", card.Get(vcard.FieldEmail).Group)
+	assert.Equal(t, "+41 22 000 00 00", card.Value(vcard.FieldTelephone))
+	assert.Equal(t, "Met at the conference", card.Value(vcard.FieldNote))
+}
+
+func TestContact_ContactToVCardInvalid(t *testing.T) {
+	_, err := ContactToVCard([]Card{{Type: CardSigned, Data: "not a vcard"}})
+	assert.Error(t, err)
+}
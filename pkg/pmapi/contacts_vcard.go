@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/ProtonMail/go-vcard"
+)
+
+// ContactToVCard merges all cards of the contact into one vCard 4.0.
+// Cards must be decrypted already, see DecryptAndVerifyCards.
+// Cards usually repeat some properties (e.g. UID or FN) which are
+// included only once in the result.
+func ContactToVCard(cards []Card) ([]byte, error) {
+	merged := vcard.Card{}
+
+	for _, card := range cards {
+		parsedCard, err := vcard.NewDecoder(strings.NewReader(card.Data)).Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		for key, fields := range parsedCard {
+			if strings.EqualFold(key, vcard.FieldVersion) {
+				continue
+			}
+			for _, field := range fields {
+				if !hasVCardField(merged, key, field) {
+					merged.Add(key, field)
+				}
+			}
+		}
+	}
+
+	merged.SetValue(vcard.FieldVersion, "4.0")
+
+	var b bytes.Buffer
+	if err := vcard.NewEncoder(&b).Encode(merged); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func hasVCardField(card vcard.Card, key string, field *vcard.Field) bool {
+	for _, existing := range card[key] {
+		if existing.Group == field.Group && existing.Value == field.Value {
+			return true
+		}
+	}
+	return false
+}
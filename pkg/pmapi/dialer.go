@@ -24,6 +24,10 @@ import (
 	"time"
 )
 
+// happyEyeballsDelay is how long to wait for the primary address family
+// before the other one is tried too.
+const happyEyeballsDelay = 300 * time.Millisecond
+
 type TLSDialer interface {
 	DialTLS(network, address string) (conn net.Conn, err error)
 }
@@ -59,7 +63,13 @@ func NewBasicTLSDialer() *BasicTLSDialer {
 
 // DialTLS returns a connection to the given address using the given network.
 func (b *BasicTLSDialer) DialTLS(network, address string) (conn net.Conn, err error) {
-	dialer := &net.Dialer{Timeout: 30 * time.Second} // Alternative Routes spec says this should be a 30s timeout.
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second, // Alternative Routes spec says this should be a 30s timeout.
+
+		// Servers resolving to both IPv4 and IPv6 addresses are dialed in
+		// parallel (RFC 6555) so a broken stack does not stall the connection.
+		FallbackDelay: happyEyeballsDelay,
+	}
 
 	var tlsConfig *tls.Config = nil
 
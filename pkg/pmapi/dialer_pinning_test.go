@@ -59,6 +59,7 @@ func TestTLSPinBackup(t *testing.T) {
 	cm := newTestClientManager(testLiveConfig)
 	cm.host = liveAPI
 	called, p := createAndSetPinningDialer(cm)
+	p.pinChecker.trustedPins = append([]string{}, TrustedAPIPins...)
 	p.pinChecker.trustedPins[1] = p.pinChecker.trustedPins[0]
 	p.pinChecker.trustedPins[0] = ""
 
@@ -75,6 +76,7 @@ func _TestTLSPinNoMatch(t *testing.T) { // nolint[unused]
 	cm.host = liveAPI
 
 	called, p := createAndSetPinningDialer(cm)
+	p.pinChecker.trustedPins = append([]string{}, TrustedAPIPins...)
 	for i := 0; i < len(p.pinChecker.trustedPins); i++ {
 		p.pinChecker.trustedPins[i] = "testing"
 	}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const humanVerificationURL = "https://verify.protonmail.com/"
+
+// ErrHumanVerificationRequired is returned when the API refuses to process
+// the request until the user passes human verification (e.g. captcha).
+type ErrHumanVerificationRequired struct {
+	Methods []string `json:"HumanVerificationMethods"`
+	Token   string   `json:"HumanVerificationToken"`
+}
+
+func (err *ErrHumanVerificationRequired) Error() string {
+	return "human verification required"
+}
+
+// VerificationURL returns the address of the page where the user can pass
+// the verification and obtain the token to be sent with the next request.
+func (err *ErrHumanVerificationRequired) VerificationURL() string {
+	query := url.Values{}
+	query.Set("methods", strings.Join(err.Methods, ","))
+	query.Set("token", err.Token)
+	return humanVerificationURL + "?" + query.Encode()
+}
+
+// TokenType returns the verification method to be used. Captcha is preferred
+// when offered because it does not need any other contact of the user.
+func (err *ErrHumanVerificationRequired) TokenType() string {
+	for _, method := range err.Methods {
+		if method == "captcha" {
+			return method
+		}
+	}
+	if len(err.Methods) > 0 {
+		return err.Methods[0]
+	}
+	return "captcha"
+}
+
+func newErrHumanVerificationRequired(details json.RawMessage) error {
+	err := &ErrHumanVerificationRequired{}
+	// Without details the user can still try to log in later.
+	_ = json.Unmarshal(details, err)
+	return err
+}
+
+// SetHumanVerificationToken sets the token obtained by passing the human
+// verification. It is sent with all following requests of the client.
+func (c *client) SetHumanVerificationToken(tokenType, token string) {
+	c.hvTokenType = tokenType
+	c.hvToken = token
+}
+
+func (c *client) setHumanVerificationHeaders(req *http.Request) {
+	if c.hvToken == "" {
+		return
+	}
+	req.Header.Set("x-pm-human-verification-token-type", c.hvTokenType)
+	req.Header.Set("x-pm-human-verification-token", c.hvToken)
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"net/http"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestClient_HumanVerification(t *testing.T) {
+	finish, c := newTestServerCallbacks(t,
+		func(tb testing.TB, w http.ResponseWriter, req *http.Request) string {
+			r.Equal(tb, "captcha", req.Header.Get("x-pm-human-verification-token-type"))
+			r.Equal(tb, "solved", req.Header.Get("x-pm-human-verification-token"))
+
+			w.Header().Set("content-type", "application/json;charset=utf-8")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = w.Write([]byte(`{
+				"Code": 9001,
+				"Error": "Human verification required",
+				"Details": {
+					"HumanVerificationMethods": ["email", "captcha"],
+					"HumanVerificationToken": "abc"
+				}
+			}`))
+			return ""
+		},
+	)
+	defer finish()
+
+	c.SetHumanVerificationToken("captcha", "solved")
+	_, err := c.AuthInfo("user")
+
+	hvErr, ok := err.(*ErrHumanVerificationRequired)
+	r.True(t, ok, "unexpected error %v", err)
+	r.Equal(t, []string{"email", "captcha"}, hvErr.Methods)
+	r.Equal(t, "captcha", hvErr.TokenType())
+	r.Equal(t, "https://verify.protonmail.com/?methods=email%2Ccaptcha&token=abc", hvErr.VerificationURL())
+}
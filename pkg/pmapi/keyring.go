@@ -155,7 +155,7 @@ func (keys *PMKeys) UnlockAll(passphrase []byte, userKey *crypto.KeyRing) (kr *c
 	}
 
 	if kr.CountEntities() == 0 {
-		err = errors.New("no keys could be unlocked")
+		err = ErrNoKeysUnlocked
 		return
 	}
 
@@ -165,6 +165,10 @@ func (keys *PMKeys) UnlockAll(passphrase []byte, userKey *crypto.KeyRing) (kr *c
 // ErrNoKeyringAvailable represents an error caused by a keyring being nil or having no entities.
 var ErrNoKeyringAvailable = errors.New("no keyring available")
 
+// ErrNoKeysUnlocked is returned when none of the keys can be unlocked with the given
+// passphrase, typically because the mailbox password was changed on another device.
+var ErrNoKeysUnlocked = errors.New("no keys could be unlocked")
+
 func (c *client) encrypt(plain string, signer *crypto.KeyRing) (armored string, err error) {
 	return encrypt(c.userKeyRing, plain, signer)
 }
@@ -258,22 +262,6 @@ func encryptAttachment(kr *crypto.KeyRing, data io.Reader, filename string) (enc
 	return bytes.NewReader(packets), nil
 }
 
-func decryptAttachment(kr *crypto.KeyRing, keyPackets []byte, data io.Reader) (decrypted io.Reader, err error) {
-	if kr == nil {
-		return nil, ErrNoKeyringAvailable
-	}
-	dataBytes, err := ioutil.ReadAll(data)
-	if err != nil {
-		return
-	}
-	pgpSplitMessage := crypto.NewPGPSplitMessage(keyPackets, dataBytes)
-	plainMessage, err := kr.DecryptAttachment(pgpSplitMessage)
-	if err != nil {
-		return
-	}
-	return plainMessage.NewReader(), nil
-}
-
 func signAttachment(encrypter *crypto.KeyRing, data io.Reader) (signature io.Reader, err error) {
 	if encrypter == nil {
 		return nil, ErrNoKeyringAvailable
@@ -31,6 +31,7 @@ const (
 	SentLabel      = "7"
 	DraftLabel     = "8"
 	StarredLabel   = "10"
+	SnoozedLabel   = "16"
 
 	LabelTypeMailbox      = 1
 	LabelTypeContactGroup = 2
@@ -39,7 +40,7 @@ const (
 // IsSystemLabel checks if a label is a pre-defined system label.
 func IsSystemLabel(label string) bool {
 	switch label {
-	case InboxLabel, DraftLabel, SentLabel, TrashLabel, SpamLabel, ArchiveLabel, StarredLabel, AllMailLabel, AllSentLabel, AllDraftsLabel:
+	case InboxLabel, DraftLabel, SentLabel, TrashLabel, SpamLabel, ArchiveLabel, StarredLabel, SnoozedLabel, AllMailLabel, AllSentLabel, AllDraftsLabel:
 		return true
 	}
 	return false
@@ -171,6 +171,7 @@ type Message struct {
 	Size           int64
 	NumAttachments int
 	ExpirationTime int64 // Unix time
+	SnoozeTime     int64 `json:",omitempty"` // Unix time, only when snoozed
 	SpamScore      int
 	AddressID      string
 	Body           string `json:",omitempty"`
@@ -743,6 +744,16 @@ func (c *client) MarkMessagesUnread(ids []string) error {
 	return c.doMessagesAction("unread", ids)
 }
 
+// MarkMessagesSpam reports the messages as spam to train the spam filter.
+func (c *client) MarkMessagesSpam(ids []string) error {
+	return c.doMessagesAction("spam", ids)
+}
+
+// MarkMessagesHam reports the messages as not spam to train the spam filter.
+func (c *client) MarkMessagesHam(ids []string) error {
+	return c.doMessagesAction("ham", ids)
+}
+
 func (c *client) DeleteMessages(ids []string) error {
 	return c.doMessagesAction("delete", ids)
 }
@@ -816,6 +827,46 @@ func (c *client) unlabelMessages(ids []string, label string) (err error) {
 	return
 }
 
+type SnoozeMessagesReq struct {
+	IDs        []string
+	SnoozeTime int64
+}
+
+// SnoozeMessages hides the given messages until the given time when
+// they are brought back to the inbox as unread.
+func (c *client) SnoozeMessages(ids []string, until int64) (err error) {
+	for len(ids) > messageIDPageSize {
+		var requestIDs []string
+		requestIDs, ids = ids[:messageIDPageSize], ids[messageIDPageSize:]
+		if err = c.snoozeMessages(requestIDs, until); err != nil {
+			return
+		}
+	}
+
+	return c.snoozeMessages(ids, until)
+}
+
+func (c *client) snoozeMessages(ids []string, until int64) (err error) {
+	snoozeReq := &SnoozeMessagesReq{IDs: ids, SnoozeTime: until}
+	req, err := c.NewJSONRequest("PUT", "/messages/snooze", snoozeReq)
+	if err != nil {
+		return
+	}
+
+	var res MessagesActionRes
+	if err = c.DoJSON(req, &res); err != nil {
+		return
+	}
+
+	err = res.Err()
+	return
+}
+
+// UnsnoozeMessages brings the given snoozed messages back right away.
+func (c *client) UnsnoozeMessages(ids []string) error {
+	return c.doMessagesAction("unsnooze", ids)
+}
+
 func (c *client) EmptyFolder(labelID, addressID string) (err error) {
 	if labelID == "" {
 		return errors.New("pmapi: labelID parameter is empty string")
@@ -338,6 +338,21 @@ func (mr *MockClientMockRecorder) GetAttachment(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAttachment", reflect.TypeOf((*MockClient)(nil).GetAttachment), arg0)
 }
 
+// GetCalendarBootstrap mocks base method
+func (m *MockClient) GetCalendarBootstrap(arg0 string) (*pmapi.CalendarBootstrap, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalendarBootstrap", arg0)
+	ret0, _ := ret[0].(*pmapi.CalendarBootstrap)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCalendarBootstrap indicates an expected call of GetCalendarBootstrap
+func (mr *MockClientMockRecorder) GetCalendarBootstrap(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalendarBootstrap", reflect.TypeOf((*MockClient)(nil).GetCalendarBootstrap), arg0)
+}
+
 // GetContactByID mocks base method
 func (m *MockClient) GetContactByID(arg0 string) (pmapi.Contact, error) {
 	m.ctrl.T.Helper()
@@ -368,6 +383,21 @@ func (mr *MockClientMockRecorder) GetContactEmailByEmail(arg0, arg1, arg2 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContactEmailByEmail", reflect.TypeOf((*MockClient)(nil).GetContactEmailByEmail), arg0, arg1, arg2)
 }
 
+// GetContactsForExport mocks base method
+func (m *MockClient) GetContactsForExport(arg0, arg1 int) ([]pmapi.Contact, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContactsForExport", arg0, arg1)
+	ret0, _ := ret[0].([]pmapi.Contact)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContactsForExport indicates an expected call of GetContactsForExport
+func (mr *MockClientMockRecorder) GetContactsForExport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContactsForExport", reflect.TypeOf((*MockClient)(nil).GetContactsForExport), arg0, arg1)
+}
+
 // GetEvent mocks base method
 func (m *MockClient) GetEvent(arg0 string) (*pmapi.Event, error) {
 	m.ctrl.T.Helper()
@@ -501,6 +531,36 @@ func (mr *MockClientMockRecorder) LabelMessages(arg0, arg1 interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LabelMessages", reflect.TypeOf((*MockClient)(nil).LabelMessages), arg0, arg1)
 }
 
+// ListCalendarEvents mocks base method
+func (m *MockClient) ListCalendarEvents(arg0 string, arg1 int, arg2 int) ([]*pmapi.CalendarEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCalendarEvents", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*pmapi.CalendarEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCalendarEvents indicates an expected call of ListCalendarEvents
+func (mr *MockClientMockRecorder) ListCalendarEvents(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCalendarEvents", reflect.TypeOf((*MockClient)(nil).ListCalendarEvents), arg0, arg1, arg2)
+}
+
+// ListCalendars mocks base method
+func (m *MockClient) ListCalendars() ([]*pmapi.Calendar, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCalendars")
+	ret0, _ := ret[0].([]*pmapi.Calendar)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCalendars indicates an expected call of ListCalendars
+func (mr *MockClientMockRecorder) ListCalendars() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCalendars", reflect.TypeOf((*MockClient)(nil).ListCalendars))
+}
+
 // ListLabels mocks base method
 func (m *MockClient) ListLabels() ([]*pmapi.Label, error) {
 	m.ctrl.T.Helper()
@@ -544,6 +604,20 @@ func (mr *MockClientMockRecorder) Logout() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockClient)(nil).Logout))
 }
 
+// MarkMessagesHam mocks base method
+func (m *MockClient) MarkMessagesHam(arg0 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkMessagesHam", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkMessagesHam indicates an expected call of MarkMessagesHam
+func (mr *MockClientMockRecorder) MarkMessagesHam(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMessagesHam", reflect.TypeOf((*MockClient)(nil).MarkMessagesHam), arg0)
+}
+
 // MarkMessagesRead mocks base method
 func (m *MockClient) MarkMessagesRead(arg0 []string) error {
 	m.ctrl.T.Helper()
@@ -558,6 +632,20 @@ func (mr *MockClientMockRecorder) MarkMessagesRead(arg0 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMessagesRead", reflect.TypeOf((*MockClient)(nil).MarkMessagesRead), arg0)
 }
 
+// MarkMessagesSpam mocks base method
+func (m *MockClient) MarkMessagesSpam(arg0 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkMessagesSpam", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkMessagesSpam indicates an expected call of MarkMessagesSpam
+func (mr *MockClientMockRecorder) MarkMessagesSpam(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkMessagesSpam", reflect.TypeOf((*MockClient)(nil).MarkMessagesSpam), arg0)
+}
+
 // MarkMessagesUnread mocks base method
 func (m *MockClient) MarkMessagesUnread(arg0 []string) error {
 	m.ctrl.T.Helper()
@@ -644,6 +732,32 @@ func (mr *MockClientMockRecorder) SendSimpleMetric(arg0, arg1, arg2 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSimpleMetric", reflect.TypeOf((*MockClient)(nil).SendSimpleMetric), arg0, arg1, arg2)
 }
 
+// SetHumanVerificationToken mocks base method
+func (m *MockClient) SetHumanVerificationToken(arg0, arg1 string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetHumanVerificationToken", arg0, arg1)
+}
+
+// SetHumanVerificationToken indicates an expected call of SetHumanVerificationToken
+func (mr *MockClientMockRecorder) SetHumanVerificationToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHumanVerificationToken", reflect.TypeOf((*MockClient)(nil).SetHumanVerificationToken), arg0, arg1)
+}
+
+// SnoozeMessages mocks base method
+func (m *MockClient) SnoozeMessages(arg0 []string, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnoozeMessages", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SnoozeMessages indicates an expected call of SnoozeMessages
+func (mr *MockClientMockRecorder) SnoozeMessages(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnoozeMessages", reflect.TypeOf((*MockClient)(nil).SnoozeMessages), arg0, arg1)
+}
+
 // UnlabelMessages mocks base method
 func (m *MockClient) UnlabelMessages(arg0 []string, arg1 string) error {
 	m.ctrl.T.Helper()
@@ -672,6 +786,35 @@ func (mr *MockClientMockRecorder) Unlock(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockClient)(nil).Unlock), arg0)
 }
 
+// UnlockCalendarKeyRing mocks base method
+func (m *MockClient) UnlockCalendarKeyRing(arg0 *pmapi.CalendarBootstrap) (*crypto.KeyRing, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockCalendarKeyRing", arg0)
+	ret0, _ := ret[0].(*crypto.KeyRing)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockCalendarKeyRing indicates an expected call of UnlockCalendarKeyRing
+func (mr *MockClientMockRecorder) UnlockCalendarKeyRing(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockCalendarKeyRing", reflect.TypeOf((*MockClient)(nil).UnlockCalendarKeyRing), arg0)
+}
+
+// UnsnoozeMessages mocks base method
+func (m *MockClient) UnsnoozeMessages(arg0 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnsnoozeMessages", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnsnoozeMessages indicates an expected call of UnsnoozeMessages
+func (mr *MockClientMockRecorder) UnsnoozeMessages(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnsnoozeMessages", reflect.TypeOf((*MockClient)(nil).UnsnoozeMessages), arg0)
+}
+
 // UpdateLabel mocks base method
 func (m *MockClient) UpdateLabel(arg0 *pmapi.Label) (*pmapi.Label, error) {
 	m.ctrl.T.Helper()
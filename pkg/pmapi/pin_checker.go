@@ -34,6 +34,8 @@ import (
 )
 
 type pinChecker struct {
+	// trustedPins are pins to check against; nil means to use current
+	// trusted API pins which can be updated at runtime.
 	trustedPins []string
 	sentReports []sentReport
 }
@@ -61,7 +63,7 @@ func (p *pinChecker) checkCertificate(conn net.Conn) error {
 	for _, peerCert := range connState.PeerCertificates {
 		fingerprint := certFingerprint(peerCert)
 
-		for _, pin := range p.trustedPins {
+		for _, pin := range p.getTrustedPins() {
 			if pin == fingerprint {
 				return nil
 			}
@@ -71,6 +73,13 @@ func (p *pinChecker) checkCertificate(conn net.Conn) error {
 	return ErrTLSMismatch
 }
 
+func (p *pinChecker) getTrustedPins() []string {
+	if p.trustedPins == nil {
+		return getTrustedAPIPins()
+	}
+	return p.trustedPins
+}
+
 func certFingerprint(cert *x509.Certificate) string {
 	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
 	return fmt.Sprintf(`pin-sha256=%q`, base64.StdEncoding.EncodeToString(hash[:]))
@@ -86,7 +95,7 @@ func (p *pinChecker) reportCertIssue(remoteURI, host, port string, connState tls
 		certChain = marshalCert7468(connState.PeerCertificates)
 	}
 
-	r := newTLSReport(host, port, connState.ServerName, certChain, p.trustedPins, appVersion)
+	r := newTLSReport(host, port, connState.ServerName, certChain, p.getTrustedPins(), appVersion)
 
 	if !p.hasRecentlySentReport(r) {
 		p.recordReport(r)
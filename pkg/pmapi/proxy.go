@@ -20,6 +20,7 @@ package pmapi
 import (
 	"context"
 	"encoding/base64"
+	"net"
 	"strings"
 	"sync"
 	"time"
@@ -162,7 +163,7 @@ func (p *proxyProvider) canReach(url string) bool {
 	logrus.WithField("url", url).Debug("Trying to ping proxy")
 
 	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
-		url = "https://" + url
+		url = "https://" + urlHost(url)
 	}
 
 	dialer := NewPinningTLSDialer(NewBasicTLSDialer())
@@ -180,6 +181,15 @@ func (p *proxyProvider) canReach(url string) bool {
 	return true
 }
 
+// urlHost returns the host in the form usable in URL, i.e. it puts IPv6
+// literals, which can be received as proxies, into brackets.
+func urlHost(host string) string {
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		return "[" + host + "]"
+	}
+	return host
+}
+
 // defaultDoHLookup is the default implementation of the proxy manager's DoH lookup.
 // It looks up DNS TXT records for the given query URL using the given DoH provider.
 // It returns a list of all found TXT records.
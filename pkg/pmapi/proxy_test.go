@@ -466,3 +466,13 @@ func blockAPI() {
 func unblockAPI() {
 	rootURL = testAPIURLBackup
 }
+
+func TestProxyProvider_IPv6Proxy(t *testing.T) {
+	require.Equal(t, "api.protonmail.ch", urlHost("api.protonmail.ch"))
+	require.Equal(t, "127.0.0.1", urlHost("127.0.0.1"))
+	require.Equal(t, "[2001:db8::1]", urlHost("2001:db8::1"))
+
+	cm := newTestClientManager(testClientConfig)
+	cm.host = "2001:db8::1"
+	require.Equal(t, "https://[2001:db8::1]", cm.GetRootURL())
+}
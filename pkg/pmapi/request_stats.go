@@ -0,0 +1,145 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// minIDLength is the length from which the path segment is considered
+// to be an ID and is not part of the endpoint name.
+const minIDLength = 16
+
+// EndpointStats holds counters of requests to one API endpoint.
+// Latency is measured until the response headers are received,
+// i.e. it does not include reading of the body.
+type EndpointStats struct {
+	Endpoint     string
+	Requests     int
+	Errors       int
+	Retries      int
+	TotalLatency time.Duration
+	MaxLatency   time.Duration
+}
+
+// AverageLatency returns the mean latency of all requests to the endpoint.
+func (s EndpointStats) AverageLatency() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Requests)
+}
+
+type requestStats struct {
+	endpoints map[string]*EndpointStats
+	lock      sync.Mutex
+}
+
+func (rs *requestStats) getOrCreate(endpoint string) *EndpointStats {
+	if rs.endpoints == nil {
+		rs.endpoints = map[string]*EndpointStats{}
+	}
+	stats, ok := rs.endpoints[endpoint]
+	if !ok {
+		stats = &EndpointStats{Endpoint: endpoint}
+		rs.endpoints[endpoint] = stats
+	}
+	return stats
+}
+
+func (rs *requestStats) record(endpoint string, latency time.Duration, failed bool) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	stats := rs.getOrCreate(endpoint)
+	stats.Requests++
+	stats.TotalLatency += latency
+	if latency > stats.MaxLatency {
+		stats.MaxLatency = latency
+	}
+	if failed {
+		stats.Errors++
+	}
+}
+
+func (rs *requestStats) recordRetry(endpoint string) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	rs.getOrCreate(endpoint).Retries++
+}
+
+func (rs *requestStats) get() []EndpointStats {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	all := make([]EndpointStats, 0, len(rs.endpoints))
+	for _, stats := range rs.endpoints {
+		all = append(all, *stats)
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].Endpoint < all[j].Endpoint })
+
+	return all
+}
+
+// isRequestFailed returns whether the request failed on the network or the
+// server. Unauthorized and too many requests are part of the normal flow and
+// are handled by refreshing the token or by retry.
+func isRequestFailed(res *http.Response, err error) bool {
+	if err != nil || res == nil {
+		return true
+	}
+	switch res.StatusCode {
+	case http.StatusUnauthorized, http.StatusTooManyRequests:
+		return false
+	}
+	return res.StatusCode >= http.StatusBadRequest
+}
+
+// getEndpoint returns method and path of the request with IDs replaced by
+// placeholder so requests to the same endpoint are counted together.
+func getEndpoint(req *http.Request) string {
+	segments := strings.Split(req.URL.Path, "/")
+	for i, segment := range segments {
+		if isIDSegment(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return req.Method + " " + strings.Join(segments, "/")
+}
+
+func isIDSegment(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	if len(segment) >= minIDLength {
+		return true
+	}
+	for _, r := range segment {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
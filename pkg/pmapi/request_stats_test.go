@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestStats_GetEndpoint(t *testing.T) {
+	tests := []struct {
+		method, url, want string
+	}{
+		{"GET", "/labels/1", "GET /labels/{id}"},
+		{"GET", "/messages/count", "GET /messages/count"},
+		{"GET", "/messages/MIdBDFOpinfGGiSmaz5SlCdnRrqinO9WWrSYTKEUcEVZVD2WeNh72EH-hvc3s7jvw==", "GET /messages/{id}"},
+		{"PUT", "/contacts/delete", "PUT /contacts/delete"},
+		{"GET", "/calendar/v1/abcdefghijklmnopqrstuvwx/events?Page=1", "GET /calendar/v1/{id}/events"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.url, nil)
+		assert.Equal(t, test.want, getEndpoint(req))
+	}
+}
+
+func TestRequestStats_Record(t *testing.T) {
+	stats := requestStats{}
+	stats.record("GET /b", 100*time.Millisecond, false)
+	stats.record("GET /a", 300*time.Millisecond, true)
+	stats.record("GET /a", 100*time.Millisecond, false)
+	stats.recordRetry("GET /a")
+
+	all := stats.get()
+	assert.Equal(t, []EndpointStats{
+		{Endpoint: "GET /a", Requests: 2, Errors: 1, Retries: 1, TotalLatency: 400 * time.Millisecond, MaxLatency: 300 * time.Millisecond},
+		{Endpoint: "GET /b", Requests: 1, TotalLatency: 100 * time.Millisecond, MaxLatency: 100 * time.Millisecond},
+	}, all)
+	assert.Equal(t, 200*time.Millisecond, all[0].AverageLatency())
+	assert.Equal(t, time.Duration(0), EndpointStats{}.AverageLatency())
+}
+
+func TestRequestStats_CountedByClient(t *testing.T) {
+	s, c := newTestServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/labels" {
+			fmt.Fprint(w, `{"Code": 1000, "Labels": []}`)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	_, err := c.ListLabels()
+	assert.NoError(t, err)
+	_, err = c.GetMessage("msgID")
+	assert.Error(t, err)
+
+	all := c.cm.GetRequestStats()
+	assert.Len(t, all, 2)
+	assert.Equal(t, "GET /labels", all[0].Endpoint)
+	assert.Equal(t, 1, all[0].Requests)
+	assert.Equal(t, 0, all[0].Errors)
+	assert.Equal(t, "GET /messages/msgID", all[1].Endpoint)
+	assert.Equal(t, 1, all[1].Errors)
+}
@@ -18,6 +18,7 @@
 package pmapi
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/pkg/errors"
@@ -41,6 +42,11 @@ type Res struct {
 
 // Err returns error if the response is an error. Otherwise, returns nil.
 func (res Res) Err() error {
+	// Human verification is reported together with HTTP 422.
+	if res.Code == HumanVerificationRequired && res.ResError != nil {
+		return newErrHumanVerificationRequired(res.Details)
+	}
+
 	if res.StatusCode == http.StatusUnprocessableEntity {
 		return &ErrUnprocessableEntity{errors.New(res.Error)}
 	}
@@ -67,6 +73,9 @@ func (res Res) Err() error {
 
 type ResError struct {
 	Error string
+
+	// Details holds additional data specific to the error code.
+	Details json.RawMessage
 }
 
 // Error is an API error.
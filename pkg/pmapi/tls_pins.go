@@ -0,0 +1,86 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	updatedAPIPins     []string     //nolint[gochecknoglobals]
+	updatedAPIPinsLock sync.RWMutex //nolint[gochecknoglobals]
+
+	pinRegexp = regexp.MustCompile(`^pin-sha256="([A-Za-z0-9+/=]+)"$`) //nolint[gochecknoglobals]
+)
+
+// TLSPinManifest is the list of trusted pins distributed independently of
+// the application so certificate rotations do not need a new release.
+// Version increases with every published manifest so an old signed manifest
+// cannot be replayed to bring back revoked pins.
+type TLSPinManifest struct {
+	Version int
+	Pins    []string
+}
+
+// ParseTLSPinManifest parses the manifest and checks that all pins are valid.
+func ParseTLSPinManifest(data []byte) (*TLSPinManifest, error) {
+	manifest := &TLSPinManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, errors.Wrap(err, "failed to parse TLS pin manifest")
+	}
+
+	if len(manifest.Pins) == 0 {
+		return nil, errors.New("TLS pin manifest contains no pins")
+	}
+
+	for _, pin := range manifest.Pins {
+		match := pinRegexp.FindStringSubmatch(pin)
+		if match == nil {
+			return nil, errors.Errorf("invalid TLS pin %q", pin)
+		}
+		if hash, err := base64.StdEncoding.DecodeString(match[1]); err != nil || len(hash) != 32 {
+			return nil, errors.Errorf("invalid TLS pin hash %q", pin)
+		}
+	}
+
+	return manifest, nil
+}
+
+// UpdateTrustedAPIPins replaces the built-in TrustedAPIPins used by all
+// pinning dialers, including the already existing ones.
+func UpdateTrustedAPIPins(pins []string) {
+	updatedAPIPinsLock.Lock()
+	defer updatedAPIPinsLock.Unlock()
+
+	updatedAPIPins = pins
+}
+
+func getTrustedAPIPins() []string {
+	updatedAPIPinsLock.RLock()
+	defer updatedAPIPinsLock.RUnlock()
+
+	if len(updatedAPIPins) > 0 {
+		return updatedAPIPins
+	}
+	return TrustedAPIPins
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func TestParseTLSPinManifest(t *testing.T) {
+	manifest, err := ParseTLSPinManifest([]byte(`{"Version": 3, "Pins": ["pin-sha256=\"drtmcR2kFkM8qJClsuWgUzxgBkePfRCkRpqUesyDmeE=\""]}`))
+	r.NoError(t, err)
+	r.Equal(t, 3, manifest.Version)
+	r.Equal(t, []string{TrustedAPIPins[0]}, manifest.Pins)
+
+	for _, data := range []string{
+		`not json`,
+		`{"Pins": []}`,
+		`{"Pins": ["sha256=\"drtmcR2kFkM8qJClsuWgUzxgBkePfRCkRpqUesyDmeE=\""]}`,
+		`{"Pins": ["pin-sha256=\"c2hvcnQ=\""]}`,
+	} {
+		_, err := ParseTLSPinManifest([]byte(data))
+		r.Error(t, err, data)
+	}
+}
+
+func TestUpdateTrustedAPIPins(t *testing.T) {
+	defer UpdateTrustedAPIPins(nil)
+
+	pc := newPinChecker(nil)
+	r.Equal(t, TrustedAPIPins, pc.getTrustedPins())
+
+	UpdateTrustedAPIPins([]string{"new pin"})
+	r.Equal(t, []string{"new pin"}, pc.getTrustedPins())
+
+	UpdateTrustedAPIPins(nil)
+	r.Equal(t, TrustedAPIPins, pc.getTrustedPins())
+}
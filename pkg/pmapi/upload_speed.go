@@ -0,0 +1,74 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// MeasureUploadSpeed sends the given amount of synthetic data to the API and
+// returns the approximate upload throughput in bytes per second. The data is
+// random so that compression on the way does not make the result better.
+func (cm *ClientManager) MeasureUploadSpeed(size int) (float64, error) {
+	client := getHTTPClient(cm.config, cm.roundTripper, cm.cookieJar)
+	return measureUploadSpeed(client, cm.GetRootURL()+"/tests/ping", size)
+}
+
+func measureUploadSpeed(client *http.Client, url string, size int) (float64, error) {
+	body := &countingReader{reader: io.LimitReader(rand.Reader, int64(size))}
+
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = int64(size)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	// The endpoint does not need to accept the data, the status is not
+	// important. The response comes after the server received the body.
+	start := time.Now()
+	res, err := client.Do(req)
+	elapsed := time.Since(start)
+	if res != nil {
+		_ = res.Body.Close()
+	}
+
+	if body.count == 0 {
+		if err == nil {
+			err = errors.New("no data was sent")
+		}
+		return 0, err
+	}
+
+	return float64(body.count) / elapsed.Seconds(), nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += n
+	return n, err
+}
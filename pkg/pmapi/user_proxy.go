@@ -0,0 +1,132 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pmapi
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// DirectConnection is the proxy override which connects to the API without
+// any proxy, even when one is configured in the environment.
+const DirectConnection = "direct"
+
+var errProxyOverrideNotSupported = errors.New("proxy override is not supported by the used transport")
+
+// userRoundTripper sends requests of one user through the transport with
+// user's proxy settings, or through the shared one if there is no override.
+type userRoundTripper struct {
+	cm     *ClientManager
+	userID string
+}
+
+func (rt *userRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt.cm.getUserRoundTripper(rt.userID).RoundTrip(req)
+}
+
+func (rt *userRoundTripper) CloseIdleConnections() {
+	type closeIdler interface {
+		CloseIdleConnections()
+	}
+
+	if transport, ok := rt.cm.getUserRoundTripper(rt.userID).(closeIdler); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
+// SetUserProxy overrides the proxy used by the client of the given user.
+// The proxy is an URL with http, https or socks5 scheme, or DirectConnection.
+// Empty proxy removes the override so the proxy from environment is used.
+func (cm *ClientManager) SetUserProxy(userID, proxy string) error {
+	proxyFunc, err := parseUserProxy(proxy)
+	if err != nil {
+		return err
+	}
+
+	cm.userTransportsLocker.Lock()
+	defer cm.userTransportsLocker.Unlock()
+
+	if previous, ok := cm.userTransports[userID]; ok {
+		previous.CloseIdleConnections()
+		delete(cm.userTransports, userID)
+	}
+
+	if proxyFunc == nil {
+		return nil
+	}
+
+	transport, ok := cm.roundTripper.(*http.Transport)
+	if !ok {
+		return errProxyOverrideNotSupported
+	}
+
+	transport = transport.Clone()
+	transport.Proxy = proxyFunc
+	cm.userTransports[userID] = transport
+
+	cm.log.WithField("userID", userID).Info("Using proxy override")
+
+	return nil
+}
+
+func (cm *ClientManager) getUserRoundTripper(userID string) http.RoundTripper {
+	cm.userTransportsLocker.RLock()
+	defer cm.userTransportsLocker.RUnlock()
+
+	if transport, ok := cm.userTransports[userID]; ok {
+		return transport
+	}
+
+	return cm.roundTripper
+}
+
+// ValidateUserProxy returns error if the proxy cannot be used as override.
+func ValidateUserProxy(proxy string) error {
+	_, err := parseUserProxy(proxy)
+	return err
+}
+
+// parseUserProxy returns the proxy function for transport, or nil when
+// the proxy from environment should be used.
+func parseUserProxy(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy {
+	case "":
+		return nil, nil
+	case DirectConnection:
+		return func(*http.Request) (*url.URL, error) { return nil, nil }, nil
+	}
+
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid proxy URL")
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme %q, use http, https or socks5", proxyURL.Scheme)
+	}
+
+	if proxyURL.Host == "" {
+		return nil, errors.New("proxy URL has no host")
+	}
+
+	return http.ProxyURL(proxyURL), nil
+}
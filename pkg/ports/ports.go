@@ -46,6 +46,15 @@ func isOccupied(port string) bool {
 	return false
 }
 
+// IsLoopbackHost returns whether the host is reachable only from this computer.
+func IsLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
 // FindFreePortFrom finds first empty port, starting with `startPort`.
 func FindFreePortFrom(startPort int) int {
 	loopedOnce := false
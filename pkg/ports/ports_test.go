@@ -54,3 +54,12 @@ func TestFindFreePortFromNextOne(t *testing.T) {
 
 	_ = dummyserver.Close()
 }
+
+func TestIsLoopbackHost(t *testing.T) {
+	for _, host := range []string{"127.0.0.1", "::1", "localhost"} {
+		require.True(t, IsLoopbackHost(host), host)
+	}
+	for _, host := range []string{"0.0.0.0", "192.168.1.10", "fe80::1", "example.com"} {
+		require.False(t, IsLoopbackHost(host), host)
+	}
+}
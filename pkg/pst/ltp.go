@@ -0,0 +1,390 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pst
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Lists, tables and properties layer: heap on node (HN), B-tree on heap
+// (BTH), property context (PC) and table context (TC).
+
+const (
+	hnSignature   = 0xEC
+	clientSigTC   = 0x7C
+	clientSigBTH  = 0xB5
+	clientSigPC   = 0xBC
+	nidTypeMask   = 0x1F
+	maxBTHDepth   = 8
+	tcInfoColsOff = 22
+)
+
+// heap is a heap on node; it is the base of both property and table context.
+type heap struct {
+	n         *nodeData
+	clientSig byte
+	userRoot  uint32
+}
+
+func newHeap(n *nodeData) (*heap, error) {
+	if len(n.blocks) == 0 || len(n.blocks[0]) < 12 || n.blocks[0][2] != hnSignature {
+		return nil, errors.Wrapf(ErrCorrupted, "node %#x is not a heap", n.nid)
+	}
+
+	return &heap{
+		n:         n,
+		clientSig: n.blocks[0][3],
+		userRoot:  binary.LittleEndian.Uint32(n.blocks[0][4:]),
+	}, nil
+}
+
+// get returns allocation of the heap referenced by heap ID.
+func (h *heap) get(hid uint32) ([]byte, error) {
+	if hid&nidTypeMask != 0 {
+		return nil, errors.Wrapf(ErrCorrupted, "%#x is not a heap ID", hid)
+	}
+
+	index := int(hid>>5) & 0x7FF
+	blockIndex := int(hid >> 16)
+	if blockIndex >= len(h.n.blocks) {
+		return nil, errors.Wrapf(ErrCorrupted, "heap ID %#x out of node %#x", hid, h.n.nid)
+	}
+
+	block := h.n.blocks[blockIndex]
+	if len(block) < 2 {
+		return nil, errors.Wrapf(ErrCorrupted, "heap block of node %#x", h.n.nid)
+	}
+	pageMap := int(binary.LittleEndian.Uint16(block))
+	if pageMap+4 > len(block) {
+		return nil, errors.Wrapf(ErrCorrupted, "heap page map of node %#x", h.n.nid)
+	}
+
+	allocs := int(binary.LittleEndian.Uint16(block[pageMap:]))
+	if index == 0 || index > allocs || pageMap+4+2*(allocs+1) > len(block) {
+		return nil, errors.Wrapf(ErrCorrupted, "heap ID %#x of node %#x", hid, h.n.nid)
+	}
+
+	start := int(binary.LittleEndian.Uint16(block[pageMap+4+2*(index-1):]))
+	end := int(binary.LittleEndian.Uint16(block[pageMap+4+2*index:]))
+	if start > end || end > len(block) {
+		return nil, errors.Wrapf(ErrCorrupted, "heap ID %#x of node %#x", hid, h.n.nid)
+	}
+
+	return block[start:end], nil
+}
+
+// value returns data referenced by HNID, which is either heap ID
+// or ID of subnode for bigger values.
+func (h *heap) value(hnid uint32) ([]byte, error) {
+	if hnid == 0 {
+		return nil, nil
+	}
+	if hnid&nidTypeMask == 0 {
+		return h.get(hnid)
+	}
+	return h.n.subnodeData(hnid)
+}
+
+// bthRecord is a key with data of B-tree on heap.
+type bthRecord struct {
+	key  []byte
+	data []byte
+}
+
+// bthRecords returns all leaf records of B-tree on heap with header at hid.
+func (h *heap) bthRecords(hid uint32) (records []bthRecord, keySize, dataSize int, err error) {
+	header, err := h.get(hid)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(header) < 8 || header[0] != clientSigBTH {
+		return nil, 0, 0, errors.Wrapf(ErrCorrupted, "wrong B-tree header in node %#x", h.n.nid)
+	}
+
+	keySize, dataSize = int(header[1]), int(header[2])
+	levels := int(header[3])
+	root := binary.LittleEndian.Uint32(header[4:])
+	if root == 0 {
+		return nil, keySize, dataSize, nil
+	}
+
+	records, err = h.collectBTH(root, levels, keySize, dataSize, 0)
+	return records, keySize, dataSize, err
+}
+
+func (h *heap) collectBTH(hid uint32, level, keySize, dataSize, depth int) ([]bthRecord, error) {
+	if depth > maxBTHDepth {
+		return nil, errors.Wrapf(ErrCorrupted, "B-tree of node %#x is too deep", h.n.nid)
+	}
+
+	data, err := h.get(hid)
+	if err != nil {
+		return nil, err
+	}
+
+	recordSize := keySize + dataSize
+	if level > 0 {
+		recordSize = keySize + 4
+	}
+	if recordSize == 0 {
+		return nil, errors.Wrapf(ErrCorrupted, "B-tree of node %#x", h.n.nid)
+	}
+
+	var records []bthRecord
+	for off := 0; off+recordSize <= len(data); off += recordSize {
+		record := data[off : off+recordSize]
+		if level == 0 {
+			records = append(records, bthRecord{key: record[:keySize], data: record[keySize:]})
+			continue
+		}
+
+		children, err := h.collectBTH(binary.LittleEndian.Uint32(record[keySize:]), level-1, keySize, dataSize, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, children...)
+	}
+	return records, nil
+}
+
+// isStoredInline returns whether value of property context fits into
+// the record instead of being referenced by HNID.
+func isStoredInline(propType uint16) bool {
+	switch propType {
+	case ptInt16, ptInt32, ptFloat, ptError, ptBoolean:
+		return true
+	}
+	return false
+}
+
+// isVariableSize returns whether values in table context are referenced by HNID.
+func isVariableSize(propType uint16) bool {
+	switch propType {
+	case ptString8, ptUnicode, ptBinary, ptGUID:
+		return true
+	}
+	return propType&ptMultiValue != 0
+}
+
+// readPC reads property context of the node.
+func (n *nodeData) readPC() (properties, error) {
+	h, err := newHeap(n)
+	if err != nil {
+		return properties{}, err
+	}
+	if h.clientSig != clientSigPC {
+		return properties{}, errors.Wrapf(ErrCorrupted, "node %#x is not a property context", n.nid)
+	}
+
+	records, keySize, dataSize, err := h.bthRecords(h.userRoot)
+	if err != nil {
+		return properties{}, err
+	}
+	if keySize != 2 || dataSize != 6 {
+		return properties{}, errors.Wrapf(ErrCorrupted, "wrong property context of node %#x", n.nid)
+	}
+
+	props := properties{unicode: n.f.unicode, values: map[uint16]property{}}
+	for _, record := range records {
+		id := binary.LittleEndian.Uint16(record.key)
+		propType := binary.LittleEndian.Uint16(record.data)
+		hnid := binary.LittleEndian.Uint32(record.data[2:])
+
+		data := record.data[2:6]
+		if !isStoredInline(propType) {
+			if data, err = h.value(hnid); err != nil {
+				return properties{}, err
+			}
+		}
+
+		props.values[id] = property{propType: propType, data: data}
+	}
+	return props, nil
+}
+
+// column describes one column of table context.
+type column struct {
+	id       uint16
+	propType uint16
+	offset   int
+	size     int
+	cebBit   int
+}
+
+// table is a table context, e.g., list of subfolders, messages or recipients.
+type table struct {
+	h         *heap
+	columns   []column
+	rowSize   int
+	cebOffset int
+	rowIDs    []uint32
+	rowBlocks [][]byte
+}
+
+// readTC reads table context of the node.
+func (n *nodeData) readTC() (*table, error) {
+	h, err := newHeap(n)
+	if err != nil {
+		return nil, err
+	}
+	if h.clientSig != clientSigTC {
+		return nil, errors.Wrapf(ErrCorrupted, "node %#x is not a table context", n.nid)
+	}
+
+	info, err := h.get(h.userRoot)
+	if err != nil {
+		return nil, err
+	}
+	if len(info) < tcInfoColsOff || info[0] != clientSigTC {
+		return nil, errors.Wrapf(ErrCorrupted, "wrong table context of node %#x", n.nid)
+	}
+
+	count := int(info[1])
+	if len(info) < tcInfoColsOff+8*count {
+		return nil, errors.Wrapf(ErrCorrupted, "wrong table context of node %#x", n.nid)
+	}
+
+	t := &table{
+		h:         h,
+		cebOffset: int(binary.LittleEndian.Uint16(info[6:])),
+		rowSize:   int(binary.LittleEndian.Uint16(info[8:])),
+	}
+	for i := 0; i < count; i++ {
+		desc := info[tcInfoColsOff+8*i:]
+		tag := binary.LittleEndian.Uint32(desc)
+		t.columns = append(t.columns, column{
+			id:       uint16(tag >> 16),
+			propType: uint16(tag),
+			offset:   int(binary.LittleEndian.Uint16(desc[4:])),
+			size:     int(desc[6]),
+			cebBit:   int(desc[7]),
+		})
+	}
+
+	if err := t.readRowIndex(binary.LittleEndian.Uint32(info[10:])); err != nil {
+		return nil, err
+	}
+	if err := t.readRowMatrix(binary.LittleEndian.Uint32(info[14:])); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// readRowIndex reads IDs of rows ordered by their position in the row matrix.
+// Row ID is the node ID of the object in the row, e.g., a message.
+func (t *table) readRowIndex(hid uint32) error {
+	if hid == 0 {
+		return nil
+	}
+
+	records, keySize, _, err := t.h.bthRecords(hid)
+	if err != nil {
+		return err
+	}
+	if keySize != 4 {
+		return errors.Wrapf(ErrCorrupted, "wrong row index of node %#x", t.h.n.nid)
+	}
+
+	type row struct {
+		id    uint32
+		index uint32
+	}
+	rows := make([]row, 0, len(records))
+	for _, record := range records {
+		r := row{id: binary.LittleEndian.Uint32(record.key)}
+		switch len(record.data) {
+		case 4:
+			r.index = binary.LittleEndian.Uint32(record.data)
+		case 2:
+			r.index = uint32(binary.LittleEndian.Uint16(record.data))
+		}
+		rows = append(rows, r)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].index < rows[j].index })
+
+	for _, r := range rows {
+		t.rowIDs = append(t.rowIDs, r.id)
+	}
+	return nil
+}
+
+// readRowMatrix reads rows which are either in one heap allocation
+// or in subnode where rows do not span across data blocks.
+func (t *table) readRowMatrix(hnid uint32) error {
+	switch {
+	case hnid == 0:
+		return nil
+	case hnid&nidTypeMask == 0:
+		data, err := t.h.get(hnid)
+		if err != nil {
+			return err
+		}
+		t.rowBlocks = [][]byte{data}
+	default:
+		sub, err := t.h.n.openSubnode(hnid)
+		if err != nil {
+			return err
+		}
+		t.rowBlocks = sub.blocks
+	}
+	return nil
+}
+
+// row returns values of the row at the position in the row matrix.
+func (t *table) row(index int) (properties, error) {
+	if t.rowSize <= 0 {
+		return properties{}, errors.Wrapf(ErrCorrupted, "wrong row size of node %#x", t.h.n.nid)
+	}
+
+	var data []byte
+	for _, block := range t.rowBlocks {
+		perBlock := len(block) / t.rowSize
+		if index < perBlock {
+			data = block[index*t.rowSize : (index+1)*t.rowSize]
+			break
+		}
+		index -= perBlock
+	}
+	if data == nil {
+		return properties{}, errors.Wrapf(ErrNotFound, "row of node %#x", t.h.n.nid)
+	}
+
+	props := properties{unicode: t.h.n.f.unicode, values: map[uint16]property{}}
+	for _, col := range t.columns {
+		cebByte := t.cebOffset + col.cebBit/8
+		if cebByte >= len(data) || data[cebByte]&(0x80>>uint(col.cebBit%8)) == 0 {
+			continue
+		}
+		if col.offset+col.size > len(data) {
+			return properties{}, errors.Wrapf(ErrCorrupted, "wrong column of node %#x", t.h.n.nid)
+		}
+
+		value := data[col.offset : col.offset+col.size]
+		if isVariableSize(col.propType) {
+			var err error
+			if value, err = t.h.value(binary.LittleEndian.Uint32(value)); err != nil {
+				return properties{}, err
+			}
+		}
+		props.values[col.id] = property{propType: col.propType, data: value}
+	}
+	return props, nil
+}
@@ -0,0 +1,556 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pst
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Special node IDs and node types of the messaging layer.
+const (
+	nidMessageStore   = 0x21
+	nidNameToIDMap    = 0x61
+	nidRootFolder     = 0x122
+	nidRecipientTable = 0x692
+
+	nidTypeAttachment     = 0x05
+	nidTypeHierarchyTable = 0x0D
+	nidTypeContentsTable  = 0x0E
+
+	maxFolderDepth = 64
+)
+
+// Property IDs of the messaging layer.
+const (
+	propSubject                     = 0x0037
+	propClientSubmitTime            = 0x0039
+	propSentRepresentingName        = 0x0042
+	propSentRepresentingEmail       = 0x0065
+	propTransportMessageHeaders     = 0x007D
+	propRecipientType               = 0x0C15
+	propSenderName                  = 0x0C1A
+	propSenderEmail                 = 0x0C1F
+	propMessageDeliveryTime         = 0x0E06
+	propMessageFlags                = 0x0E07
+	propMessageSize                 = 0x0E08
+	propBody                        = 0x1000
+	propHTML                        = 0x1013
+	propInternetMessageID           = 0x1035
+	propInReplyToID                 = 0x1042
+	propFlagStatus                  = 0x1090
+	propDisplayName                 = 0x3001
+	propEmailAddress                = 0x3003
+	propIPMSubtreeEntryID           = 0x35E0
+	propContentCount                = 0x3602
+	propAttachDataBinary            = 0x3701
+	propAttachFilename              = 0x3704
+	propAttachMethod                = 0x3705
+	propAttachLongFilename          = 0x3707
+	propAttachMIMETag               = 0x370E
+	propAttachContentID             = 0x3712
+	propSMTPAddress                 = 0x39FE
+	propInternetCodepage            = 0x3FDE
+	propSenderSMTPAddress           = 0x5D01
+	propSentRepresentingSMTPAddress = 0x5D02
+
+	propNameIDStreamGUID   = 0x0002
+	propNameIDStreamEntry  = 0x0003
+	propNameIDStreamString = 0x0004
+)
+
+const (
+	messageFlagRead   = 0x01
+	messageFlagUnsent = 0x08
+	flagStatusFlagged = 2
+	attachMethodEmbed = 5
+
+	recipientTo  = 1
+	recipientCc  = 2
+	recipientBcc = 3
+
+	// nameIDPublicStrings is index of PS_PUBLIC_STRINGS in name to ID map.
+	nameIDPublicStrings = 2
+	// nameIDFirstGUID is index of the first GUID in the GUID stream.
+	nameIDFirstGUID = 3
+)
+
+// psPublicStrings is PS_PUBLIC_STRINGS property set GUID as stored on disk.
+var psPublicStrings = []byte{0x29, 0x03, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0xC0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x46} //nolint[gochecknoglobals]
+
+// Folder is a mail folder of the PST file.
+type Folder struct {
+	NID          uint32
+	Name         string
+	Path         []string // Names of all parent folders including this one.
+	MessageCount int
+}
+
+// Message is a mail message of the PST file.
+type Message struct {
+	NID uint32
+
+	Subject   string
+	Headers   string // Transport headers, if the message was received.
+	From      *mail.Address
+	To        []*mail.Address
+	Cc        []*mail.Address
+	Bcc       []*mail.Address
+	Date      time.Time
+	MessageID string
+	InReplyTo string
+
+	Read    bool
+	Unsent  bool
+	Flagged bool
+
+	Body        string
+	HTML        string
+	Categories  []string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to the message.
+type Attachment struct {
+	Name      string
+	MIMEType  string
+	ContentID string
+	Data      []byte
+}
+
+// Folders returns all mail folders below the top of personal folders,
+// parents before their subfolders.
+func (f *File) Folders() ([]Folder, error) {
+	root, err := f.ipmSubtree()
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []Folder
+	if err := f.collectFolders(root, nil, &folders, 0); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+// ipmSubtree returns NID of the top of personal folders; other folders
+// of the root folder are used internally by Outlook.
+func (f *File) ipmSubtree() (uint32, error) {
+	store, err := f.openNode(nidMessageStore)
+	if err != nil {
+		return 0, err
+	}
+
+	props, err := store.readPC()
+	if err != nil {
+		return 0, err
+	}
+
+	// Entry ID consists of four flag bytes, sixteen bytes of store UID and NID.
+	if entryID := props.binary(propIPMSubtreeEntryID); len(entryID) >= 24 {
+		return binary.LittleEndian.Uint32(entryID[20:]), nil
+	}
+	return nidRootFolder, nil
+}
+
+func (f *File) collectFolders(parent uint32, path []string, folders *[]Folder, depth int) error {
+	if depth > maxFolderDepth {
+		return errors.Wrapf(ErrCorrupted, "folder %#x is too deep", parent)
+	}
+
+	hierarchy, err := f.openNode(parent&^nidTypeMask | nidTypeHierarchyTable)
+	if errors.Cause(err) == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	tc, err := hierarchy.readTC()
+	if err != nil {
+		return err
+	}
+
+	for _, nid := range tc.rowIDs {
+		folder, err := f.openNode(nid)
+		if err != nil {
+			return err
+		}
+		props, err := folder.readPC()
+		if err != nil {
+			return err
+		}
+
+		name := props.string(propDisplayName)
+		folderPath := append(append([]string{}, path...), name)
+		*folders = append(*folders, Folder{
+			NID:          nid,
+			Name:         name,
+			Path:         folderPath,
+			MessageCount: int(props.int(propContentCount)),
+		})
+
+		if err := f.collectFolders(nid, folderPath, folders, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MessageIDs returns NIDs of all messages in the folder.
+func (f *File) MessageIDs(folder Folder) ([]uint32, error) {
+	contents, err := f.openNode(folder.NID&^nidTypeMask | nidTypeContentsTable)
+	if errors.Cause(err) == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	tc, err := contents.readTC()
+	if err != nil {
+		return nil, err
+	}
+	return tc.rowIDs, nil
+}
+
+// MessageSize returns size of the message as reported by Outlook.
+func (f *File) MessageSize(nid uint32) (uint64, error) {
+	props, err := f.properties(nid)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(props.int(propMessageSize)), nil
+}
+
+// Categories returns sorted names of all categories assigned to messages.
+func (f *File) Categories() ([]string, error) {
+	keywords, err := f.namedProperty("Keywords")
+	if errors.Cause(err) == ErrNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	folders, err := f.Folders()
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	for _, folder := range folders {
+		nids, err := f.MessageIDs(folder)
+		if err != nil {
+			return nil, err
+		}
+		for _, nid := range nids {
+			props, err := f.properties(nid)
+			if err != nil {
+				return nil, err
+			}
+			for _, category := range props.strings(keywords) {
+				found[category] = true
+			}
+		}
+	}
+
+	categories := make([]string, 0, len(found))
+	for category := range found {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories, nil
+}
+
+func (f *File) properties(nid uint32) (properties, error) {
+	n, err := f.openNode(nid)
+	if err != nil {
+		return properties{}, err
+	}
+	return n.readPC()
+}
+
+// Message reads the message with all its recipients and attachments.
+func (f *File) Message(nid uint32) (*Message, error) {
+	n, err := f.openNode(nid)
+	if err != nil {
+		return nil, err
+	}
+	props, err := n.readPC()
+	if err != nil {
+		return nil, err
+	}
+
+	flags := props.int(propMessageFlags)
+	msg := &Message{
+		NID:       nid,
+		Subject:   normalizeSubject(props.string(propSubject)),
+		Headers:   props.string(propTransportMessageHeaders),
+		From:      sender(props),
+		Date:      props.time(propClientSubmitTime),
+		MessageID: props.string(propInternetMessageID),
+		InReplyTo: props.string(propInReplyToID),
+		Read:      flags&messageFlagRead != 0,
+		Unsent:    flags&messageFlagUnsent != 0,
+		Flagged:   props.int(propFlagStatus) == flagStatusFlagged,
+		Body:      props.string(propBody),
+	}
+	if msg.Date.IsZero() {
+		msg.Date = props.time(propMessageDeliveryTime)
+	}
+
+	if html, ok := props.values[propHTML]; ok {
+		if html.propType == ptBinary {
+			msg.HTML = decodeCodepage(html.data, int(props.int(propInternetCodepage)))
+		} else {
+			msg.HTML = props.string(propHTML)
+		}
+	}
+
+	if keywords, err := f.namedProperty("Keywords"); err == nil {
+		msg.Categories = props.strings(keywords)
+	} else if errors.Cause(err) != ErrNotFound {
+		return nil, err
+	}
+
+	if err := msg.readRecipients(n); err != nil {
+		return nil, errors.Wrap(err, "failed to read recipients")
+	}
+	if err := msg.readAttachments(n); err != nil {
+		return nil, errors.Wrap(err, "failed to read attachments")
+	}
+	return msg, nil
+}
+
+// normalizeSubject removes the marker of subject prefix length, e.g., "RE: ".
+func normalizeSubject(subject string) string {
+	if len(subject) >= 2 && subject[0] == 0x01 {
+		return subject[2:]
+	}
+	return subject
+}
+
+// sender returns who the message is sent on behalf of, or the real sender.
+func sender(props properties) *mail.Address {
+	if addr := address(props, propSentRepresentingName, propSentRepresentingSMTPAddress, propSentRepresentingEmail); addr != nil {
+		return addr
+	}
+	return address(props, propSenderName, propSenderSMTPAddress, propSenderEmail)
+}
+
+// address prefers SMTP address because the other one can be an Exchange
+// distinguished name.
+func address(props properties, nameID, smtpID, emailID uint16) *mail.Address {
+	email := props.string(smtpID)
+	if email == "" {
+		email = props.string(emailID)
+	}
+	if email == "" || !strings.Contains(email, "@") {
+		return nil
+	}
+	return &mail.Address{Name: props.string(nameID), Address: email}
+}
+
+func (msg *Message) readRecipients(n *nodeData) error {
+	sub, err := n.openSubnode(nidRecipientTable)
+	if errors.Cause(err) == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	tc, err := sub.readTC()
+	if err != nil {
+		return err
+	}
+
+	for i := range tc.rowIDs {
+		props, err := tc.row(i)
+		if err != nil {
+			return err
+		}
+
+		addr := address(props, propDisplayName, propSMTPAddress, propEmailAddress)
+		if addr == nil {
+			continue
+		}
+
+		switch props.int(propRecipientType) {
+		case recipientTo:
+			msg.To = append(msg.To, addr)
+		case recipientCc:
+			msg.Cc = append(msg.Cc, addr)
+		case recipientBcc:
+			msg.Bcc = append(msg.Bcc, addr)
+		}
+	}
+	return nil
+}
+
+// readAttachments reads attached files. Embedded messages are skipped.
+func (msg *Message) readAttachments(n *nodeData) error {
+	// Subnodes are in map; keep the order of attachments stable.
+	nids := []uint32{}
+	for nid := range n.subnodes {
+		if nid&nidTypeMask == nidTypeAttachment {
+			nids = append(nids, nid)
+		}
+	}
+	sort.Slice(nids, func(i, j int) bool { return nids[i] < nids[j] })
+
+	for _, nid := range nids {
+		sub, err := n.openSubnode(nid)
+		if err != nil {
+			return err
+		}
+		props, err := sub.readPC()
+		if err != nil {
+			return err
+		}
+
+		if props.int(propAttachMethod) == attachMethodEmbed {
+			continue
+		}
+
+		name := props.string(propAttachLongFilename)
+		if name == "" {
+			name = props.string(propAttachFilename)
+		}
+		msg.Attachments = append(msg.Attachments, Attachment{
+			Name:      name,
+			MIMEType:  props.string(propAttachMIMETag),
+			ContentID: props.string(propAttachContentID),
+			Data:      props.binary(propAttachDataBinary),
+		})
+	}
+	return nil
+}
+
+// namedProperty returns property ID of named property from PS_PUBLIC_STRINGS.
+func (f *File) namedProperty(name string) (uint16, error) {
+	if f.namedProps == nil {
+		if err := f.readNameToIDMap(); err != nil {
+			return 0, err
+		}
+	}
+
+	id, ok := f.namedProps[name]
+	if !ok {
+		return 0, errors.Wrapf(ErrNotFound, "named property %s", name)
+	}
+	return id, nil
+}
+
+func (f *File) readNameToIDMap() error {
+	f.namedProps = map[string]uint16{}
+
+	n, err := f.openNode(nidNameToIDMap)
+	if errors.Cause(err) == ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	props, err := n.readPC()
+	if err != nil {
+		return err
+	}
+
+	guids := props.binary(propNameIDStreamGUID)
+	entries := props.binary(propNameIDStreamEntry)
+	names := props.binary(propNameIDStreamString)
+
+	for off := 0; off+8 <= len(entries); off += 8 {
+		nameOffset := int(binary.LittleEndian.Uint32(entries[off:]))
+		guidAndKind := binary.LittleEndian.Uint16(entries[off+4:])
+		index := binary.LittleEndian.Uint16(entries[off+6:])
+
+		// The lowest bit says whether the name is string or number.
+		if guidAndKind&1 == 0 || !isPublicStrings(guids, int(guidAndKind>>1)) {
+			continue
+		}
+		if nameOffset+4 > len(names) {
+			continue
+		}
+		length := int(binary.LittleEndian.Uint32(names[nameOffset:]))
+		if nameOffset+4+length > len(names) {
+			continue
+		}
+
+		f.namedProps[decodeUnicode(names[nameOffset+4:nameOffset+4+length])] = 0x8000 + index
+	}
+	return nil
+}
+
+func isPublicStrings(guids []byte, guidIndex int) bool {
+	if guidIndex == nameIDPublicStrings {
+		return true
+	}
+	if guidIndex < nameIDFirstGUID {
+		return false
+	}
+	off := 16 * (guidIndex - nameIDFirstGUID)
+	return off+16 <= len(guids) && bytes.Equal(guids[off:off+16], psPublicStrings)
+}
+
+// decodeCodepage converts HTML body stored as binary in the code page
+// of the message to UTF-8.
+func decodeCodepage(data []byte, codepage int) string {
+	var enc encoding.Encoding
+	switch codepage {
+	case 1250:
+		enc = charmap.Windows1250
+	case 1251:
+		enc = charmap.Windows1251
+	case 1252:
+		enc = charmap.Windows1252
+	case 1253:
+		enc = charmap.Windows1253
+	case 1254:
+		enc = charmap.Windows1254
+	case 1255:
+		enc = charmap.Windows1255
+	case 1256:
+		enc = charmap.Windows1256
+	case 1257:
+		enc = charmap.Windows1257
+	case 1258:
+		enc = charmap.Windows1258
+	case 28591:
+		enc = charmap.ISO8859_1
+	case 28592:
+		enc = charmap.ISO8859_2
+	case 28605:
+		enc = charmap.ISO8859_15
+	case 20866:
+		enc = charmap.KOI8R
+	}
+
+	if enc != nil {
+		if decoded, err := enc.NewDecoder().Bytes(data); err == nil {
+			return strings.TrimRight(string(decoded), "\x00")
+		}
+	}
+	return strings.TrimRight(string(data), "\x00")
+}
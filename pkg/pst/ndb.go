@@ -0,0 +1,334 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pst
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// Node database layer: B-trees of nodes (NBT) and blocks (BBT), data blocks
+// and subnodes. Layouts of ANSI and Unicode files differ only in sizes of IDs
+// and offsets, and in a few paddings.
+
+const (
+	pageSize    = 512
+	maxBTDepth  = 16
+	maxXBDepth  = 4
+	ptypeBBT    = 0x80
+	ptypeNBT    = 0x81
+	btypeXBlock = 0x01
+	btypeSBlock = 0x02
+
+	bidInternal = 0x02
+)
+
+// node is a leaf entry of the node B-tree or of subnode block.
+type node struct {
+	nid     uint32
+	bidData uint64
+	bidSub  uint64
+}
+
+// idSize is the size of block IDs and file offsets.
+func (f *File) idSize() int {
+	if f.unicode {
+		return 8
+	}
+	return 4
+}
+
+func (f *File) readID(b []byte) uint64 {
+	if f.unicode {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return uint64(binary.LittleEndian.Uint32(b))
+}
+
+// readBTPage returns entries of B-tree page at offset and its level;
+// level zero means leaf page.
+func (f *File) readBTPage(offset uint64, ptype byte) (entries [][]byte, level int, err error) {
+	page, err := f.readAt(offset, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entriesSize, meta := 496, 496
+	if f.unicode {
+		entriesSize, meta = 488, 488
+	}
+	trailer := meta + 4
+	if f.unicode {
+		trailer = meta + 8
+	}
+
+	count, entrySize, level := int(page[meta]), int(page[meta+2]), int(page[meta+3])
+	if page[trailer] != ptype || entrySize < f.minBTEntrySize(ptype, level) || count*entrySize > entriesSize {
+		return nil, 0, errors.Wrapf(ErrCorrupted, "wrong B-tree page at %d", offset)
+	}
+
+	for i := 0; i < count; i++ {
+		entries = append(entries, page[i*entrySize:(i+1)*entrySize])
+	}
+	return entries, level, nil
+}
+
+// minBTEntrySize returns how many bytes of B-tree page entry are read.
+// Intermediate entries have key and child page reference, leaf NBT entries
+// have node ID and block IDs of data and subnodes, and leaf BBT entries have
+// block ID, offset and size.
+func (f *File) minBTEntrySize(ptype byte, level int) int {
+	if level == 0 && ptype == ptypeBBT {
+		return 2*f.idSize() + 2
+	}
+	return 3 * f.idSize()
+}
+
+// lookupBT returns leaf entry with the key from B-tree with root page at offset.
+// Block IDs are compared without the lowest bit which is reserved.
+func (f *File) lookupBT(root uint64, ptype byte, key uint64) ([]byte, error) {
+	keyOf := func(entry []byte) uint64 {
+		k := f.readID(entry)
+		if ptype == ptypeNBT {
+			return k & 0xFFFFFFFF
+		}
+		return k &^ 1
+	}
+	if ptype == ptypeBBT {
+		key &^= 1
+	}
+
+	offset := root
+	for depth := 0; depth < maxBTDepth; depth++ {
+		entries, level, err := f.readBTPage(offset, ptype)
+		if err != nil {
+			return nil, err
+		}
+
+		if level == 0 {
+			for _, entry := range entries {
+				if keyOf(entry) == key {
+					return entry, nil
+				}
+			}
+			return nil, ErrNotFound
+		}
+
+		// Each entry of intermediate page has the lowest key of its child.
+		var next []byte
+		for _, entry := range entries {
+			if keyOf(entry) > key {
+				break
+			}
+			next = entry
+		}
+		if next == nil {
+			return nil, ErrNotFound
+		}
+		offset = f.readID(next[2*f.idSize():])
+	}
+
+	return nil, errors.Wrap(ErrCorrupted, "B-tree is too deep")
+}
+
+// node returns entry of the node B-tree.
+func (f *File) node(nid uint32) (node, error) {
+	entry, err := f.lookupBT(f.nbtRoot, ptypeNBT, uint64(nid))
+	if err != nil {
+		return node{}, errors.Wrapf(err, "node %#x", nid)
+	}
+
+	size := f.idSize()
+	return node{
+		nid:     nid,
+		bidData: f.readID(entry[size:]),
+		bidSub:  f.readID(entry[2*size:]),
+	}, nil
+}
+
+// block returns content of the block. Data blocks are decrypted,
+// internal blocks are never encrypted.
+func (f *File) block(bid uint64) ([]byte, error) {
+	entry, err := f.lookupBT(f.bbtRoot, ptypeBBT, bid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "block %#x", bid)
+	}
+
+	size := f.idSize()
+	offset := f.readID(entry[size:])
+	length := binary.LittleEndian.Uint16(entry[2*size:])
+
+	data, err := f.readAt(offset, int(length))
+	if err != nil {
+		return nil, err
+	}
+
+	if bid&bidInternal == 0 && f.crypt == cryptPermute {
+		decryptPermute(data)
+	}
+	return data, nil
+}
+
+// dataBlocks returns data blocks of the node data. Data bigger than one
+// block is referenced by XBLOCK or by XXBLOCK with XBLOCKs.
+func (f *File) dataBlocks(bid uint64) ([][]byte, error) {
+	return f.dataBlocksAtDepth(bid, 0)
+}
+
+func (f *File) dataBlocksAtDepth(bid uint64, depth int) ([][]byte, error) {
+	if bid == 0 {
+		return nil, nil
+	}
+
+	data, err := f.block(bid)
+	if err != nil {
+		return nil, err
+	}
+
+	if bid&bidInternal == 0 {
+		return [][]byte{data}, nil
+	}
+
+	if depth >= maxXBDepth || len(data) < 8 || data[0] != btypeXBlock {
+		return nil, errors.Wrapf(ErrCorrupted, "wrong XBLOCK %#x", bid)
+	}
+
+	size := f.idSize()
+	count := int(binary.LittleEndian.Uint16(data[2:]))
+	if 8+count*size > len(data) {
+		return nil, errors.Wrapf(ErrCorrupted, "wrong XBLOCK %#x", bid)
+	}
+
+	var blocks [][]byte
+	for i := 0; i < count; i++ {
+		children, err := f.dataBlocksAtDepth(f.readID(data[8+i*size:]), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, children...)
+	}
+	return blocks, nil
+}
+
+// subnodes returns entries of subnode blocks SLBLOCK and SIBLOCK.
+func (f *File) subnodes(bid uint64) (map[uint32]node, error) {
+	subnodes := map[uint32]node{}
+	if bid == 0 {
+		return subnodes, nil
+	}
+	return subnodes, f.readSubnodes(bid, subnodes, 0)
+}
+
+func (f *File) readSubnodes(bid uint64, subnodes map[uint32]node, depth int) error {
+	data, err := f.block(bid)
+	if err != nil {
+		return err
+	}
+
+	if depth >= maxXBDepth || len(data) < 4 || data[0] != btypeSBlock {
+		return errors.Wrapf(ErrCorrupted, "wrong subnode block %#x", bid)
+	}
+
+	size := f.idSize()
+	level := data[1]
+	count := int(binary.LittleEndian.Uint16(data[2:]))
+	start := 4
+	if f.unicode {
+		start = 8
+	}
+
+	entrySize := 3 * size
+	if level > 0 {
+		entrySize = 2 * size
+	}
+	if start+count*entrySize > len(data) {
+		return errors.Wrapf(ErrCorrupted, "wrong subnode block %#x", bid)
+	}
+
+	for i := 0; i < count; i++ {
+		entry := data[start+i*entrySize:]
+		nid := uint32(f.readID(entry))
+
+		if level > 0 {
+			if err := f.readSubnodes(f.readID(entry[size:]), subnodes, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		subnodes[nid] = node{
+			nid:     nid,
+			bidData: f.readID(entry[size:]),
+			bidSub:  f.readID(entry[2*size:]),
+		}
+	}
+	return nil
+}
+
+// nodeData is content of node or subnode together with its subnodes.
+type nodeData struct {
+	f        *File
+	nid      uint32
+	blocks   [][]byte
+	subnodes map[uint32]node
+}
+
+func (f *File) openNode(nid uint32) (*nodeData, error) {
+	n, err := f.node(nid)
+	if err != nil {
+		return nil, err
+	}
+	return f.openNodeEntry(n)
+}
+
+func (f *File) openNodeEntry(n node) (*nodeData, error) {
+	blocks, err := f.dataBlocks(n.bidData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "node %#x", n.nid)
+	}
+
+	subnodes, err := f.subnodes(n.bidSub)
+	if err != nil {
+		return nil, errors.Wrapf(err, "subnodes of %#x", n.nid)
+	}
+
+	return &nodeData{f: f, nid: n.nid, blocks: blocks, subnodes: subnodes}, nil
+}
+
+func (n *nodeData) openSubnode(nid uint32) (*nodeData, error) {
+	sub, ok := n.subnodes[nid]
+	if !ok {
+		return nil, errors.Wrapf(ErrNotFound, "subnode %#x of %#x", nid, n.nid)
+	}
+	return n.f.openNodeEntry(sub)
+}
+
+// subnodeData returns concatenated data of the subnode.
+func (n *nodeData) subnodeData(nid uint32) ([]byte, error) {
+	sub, err := n.openSubnode(nid)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	for _, block := range sub.blocks {
+		data = append(data, block...)
+	}
+	return data, nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pst
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Property types.
+const (
+	ptInt16      = 0x0002
+	ptInt32      = 0x0003
+	ptFloat      = 0x0004
+	ptError      = 0x000A
+	ptBoolean    = 0x000B
+	ptInt64      = 0x0014
+	ptString8    = 0x001E
+	ptUnicode    = 0x001F
+	ptSysTime    = 0x0040
+	ptGUID       = 0x0048
+	ptBinary     = 0x0102
+	ptMultiValue = 0x1000
+)
+
+// fileTimeEpoch is the difference between 1601 and 1970 in 100 ns units.
+const fileTimeEpoch = 116444736000000000
+
+type property struct {
+	propType uint16
+	data     []byte
+}
+
+// properties are values of property context or of one row of table context.
+type properties struct {
+	unicode bool
+	values  map[uint16]property
+}
+
+func (p properties) has(id uint16) bool {
+	_, ok := p.values[id]
+	return ok
+}
+
+func (p properties) int(id uint16) int64 {
+	prop, ok := p.values[id]
+	if !ok {
+		return 0
+	}
+
+	switch {
+	case prop.propType == ptInt16 && len(prop.data) >= 2:
+		return int64(int16(binary.LittleEndian.Uint16(prop.data)))
+	case prop.propType == ptInt32 && len(prop.data) >= 4:
+		return int64(int32(binary.LittleEndian.Uint32(prop.data)))
+	case prop.propType == ptInt64 && len(prop.data) >= 8:
+		return int64(binary.LittleEndian.Uint64(prop.data))
+	case prop.propType == ptBoolean && len(prop.data) >= 1:
+		return int64(prop.data[0])
+	}
+	return 0
+}
+
+func (p properties) bool(id uint16) bool {
+	return p.int(id) != 0
+}
+
+func (p properties) time(id uint16) time.Time {
+	prop, ok := p.values[id]
+	if !ok || prop.propType != ptSysTime || len(prop.data) < 8 {
+		return time.Time{}
+	}
+
+	fileTime := int64(binary.LittleEndian.Uint64(prop.data))
+	if fileTime <= fileTimeEpoch {
+		return time.Time{}
+	}
+	return time.Unix(0, (fileTime-fileTimeEpoch)*100).UTC()
+}
+
+func (p properties) binary(id uint16) []byte {
+	return p.values[id].data
+}
+
+func (p properties) string(id uint16) string {
+	prop, ok := p.values[id]
+	if !ok {
+		return ""
+	}
+
+	switch prop.propType {
+	case ptUnicode:
+		return decodeUnicode(prop.data)
+	case ptString8, ptBinary:
+		return decodeString8(prop.data)
+	}
+	return ""
+}
+
+// strings returns values of multi-valued string property. Data start with
+// count of values and their offsets.
+func (p properties) strings(id uint16) []string {
+	prop, ok := p.values[id]
+	if !ok || prop.propType&ptMultiValue == 0 || len(prop.data) < 4 {
+		return nil
+	}
+
+	count := int(binary.LittleEndian.Uint32(prop.data))
+	if count*4+4 > len(prop.data) {
+		return nil
+	}
+
+	values := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		start := int(binary.LittleEndian.Uint32(prop.data[4+4*i:]))
+		end := len(prop.data)
+		if i+1 < count {
+			end = int(binary.LittleEndian.Uint32(prop.data[4+4*(i+1):]))
+		}
+		if start > end || end > len(prop.data) {
+			return values
+		}
+
+		if prop.propType&^ptMultiValue == ptUnicode {
+			values = append(values, decodeUnicode(prop.data[start:end]))
+		} else {
+			values = append(values, decodeString8(prop.data[start:end]))
+		}
+	}
+	return values
+}
+
+func decodeUnicode(data []byte) string {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(data[2*i:])
+	}
+	return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+}
+
+// decodeString8 decodes strings of ANSI files. Their code page is not known
+// for sure, Windows-1252 is the most common one.
+func decodeString8(data []byte) string {
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(data)
+	if err != nil {
+		decoded = data
+	}
+	return strings.TrimRight(string(decoded), "\x00")
+}
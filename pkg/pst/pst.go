@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pst reads Outlook personal folders files (.pst).
+//
+// Only the parts needed to migrate mail are implemented: the node database
+// (NDB) with B-trees, blocks and subnodes, the lists, tables and properties
+// layer (LTP) with heaps, property contexts and table contexts, and a small
+// part of the messaging layer with folders, messages, recipients and
+// attachments. Both ANSI and Unicode files are supported; of encryptions only
+// none and compressible, which is the default of Outlook.
+package pst
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// Errors returned when the file cannot be read.
+var (
+	ErrNotPST                = errors.New("not a PST file")
+	ErrUnsupportedVersion    = errors.New("unsupported PST version")
+	ErrUnsupportedEncryption = errors.New("unsupported PST encryption")
+	ErrCorrupted             = errors.New("PST file is corrupted")
+	ErrNotFound              = errors.New("node not found")
+)
+
+const (
+	cryptNone    = 0x00
+	cryptPermute = 0x01
+)
+
+// File is opened PST file.
+type File struct {
+	r       io.ReaderAt
+	closer  io.Closer
+	unicode bool
+	crypt   byte
+
+	nbtRoot uint64
+	bbtRoot uint64
+
+	namedProps map[string]uint16
+}
+
+// Open opens PST file at path.
+func Open(path string) (*File, error) {
+	f, err := os.Open(path) //nolint[gosec]
+	if err != nil {
+		return nil, err
+	}
+
+	pst, err := NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	pst.closer = f
+
+	return pst, nil
+}
+
+// NewReader reads PST file from r.
+func NewReader(r io.ReaderAt) (*File, error) {
+	header := make([]byte, 564)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, ErrNotPST
+	}
+
+	if string(header[0:4]) != "!BDN" || string(header[8:10]) != "SM" {
+		return nil, ErrNotPST
+	}
+
+	f := &File{r: r}
+
+	switch version := binary.LittleEndian.Uint16(header[10:]); {
+	case version == 14 || version == 15:
+		f.nbtRoot = uint64(binary.LittleEndian.Uint32(header[188:]))
+		f.bbtRoot = uint64(binary.LittleEndian.Uint32(header[196:]))
+		f.crypt = header[461]
+	case version == 23:
+		f.unicode = true
+		f.nbtRoot = binary.LittleEndian.Uint64(header[224:])
+		f.bbtRoot = binary.LittleEndian.Uint64(header[240:])
+		f.crypt = header[513]
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedVersion, "version %d", version)
+	}
+
+	if f.crypt != cryptNone && f.crypt != cryptPermute {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	return f, nil
+}
+
+// Close closes the underlying file if it was opened by Open.
+func (f *File) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+// IsUnicode returns whether the file uses Unicode format of Outlook 2003 and newer.
+func (f *File) IsUnicode() bool {
+	return f.unicode
+}
+
+func (f *File) readAt(offset uint64, size int) ([]byte, error) {
+	buf := make([]byte, size)
+	if _, err := f.r.ReadAt(buf, int64(offset)); err != nil {
+		return nil, errors.Wrapf(ErrCorrupted, "cannot read %d bytes at %d: %v", size, offset, err)
+	}
+	return buf, nil
+}
@@ -0,0 +1,381 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pst
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	r "github.com/stretchr/testify/require"
+)
+
+const (
+	testIPMSubtree = 0x8022
+	testInbox      = 0x8042
+	testProjects   = 0x8062
+	testSent       = 0x8082
+
+	testInboxMessages = 12
+)
+
+var testDate = time.Date(2020, 6, 15, 10, 30, 0, 0, time.UTC) //nolint[gochecknoglobals]
+
+func testMessageNID(folder uint32, index int) uint32 {
+	return (folder>>5)<<12 | uint32(index)<<5 | 0x04
+}
+
+func fileTime(t time.Time) []byte {
+	return le64(uint64(t.UnixNano()/100) + fileTimeEpoch)
+}
+
+func multiUnicode(values ...string) []byte {
+	data := le32(uint32(len(values)))
+	offset := 4 + 4*len(values)
+	var strs []byte
+	for _, value := range values {
+		data = append(data, le32(uint32(offset+len(strs)))...)
+		strs = append(strs, utf16le(value)...)
+	}
+	return append(data, strs...)
+}
+
+// newTestPST creates file with folders Inbox, Inbox/Projects and Sent Items.
+// The first message in Inbox has all supported properties; others are simple
+// and are there to make the B-trees deeper than one page.
+func newTestPST() []byte {
+	w := newTestWriter()
+
+	storeEntryID := append(make([]byte, 20), le32(testIPMSubtree)...)
+	w.addPC(nidMessageStore, []testProp{
+		unicodeProp(propDisplayName, "Outlook Data File"),
+		{id: propIPMSubtreeEntryID, propType: ptBinary, value: storeEntryID},
+	})
+
+	entries := append(append(le32(0), le16(nameIDPublicStrings<<1|1)...), le16(0)...)
+	entries = append(entries, append(append(le32(0x8510), le16(1<<1)...), le16(1)...)...)
+	names := append(le32(uint32(len(utf16le("Keywords")))), utf16le("Keywords")...)
+	w.addPC(nidNameToIDMap, []testProp{
+		{id: propNameIDStreamGUID, propType: ptBinary, value: []byte{}},
+		{id: propNameIDStreamEntry, propType: ptBinary, value: entries},
+		{id: propNameIDStreamString, propType: ptBinary, value: names},
+	})
+
+	addTestFolder(w, testIPMSubtree, "Top of Personal Folders", []uint32{testInbox, testSent}, 0)
+	addTestFolder(w, testInbox, "Inbox", []uint32{testProjects}, testInboxMessages)
+	addTestFolder(w, testProjects, "Projects", nil, 1)
+	addTestFolder(w, testSent, "Sent Items", nil, 0)
+
+	addTestFullMessage(w, testMessageNID(testInbox, 0))
+	for i := 1; i < testInboxMessages; i++ {
+		addTestMessage(w, testMessageNID(testInbox, i), fmt.Sprintf("Message %d", i))
+	}
+	addTestMessage(w, testMessageNID(testProjects, 0), "Project")
+
+	return w.bytes()
+}
+
+func addTestFolder(w *testWriter, nid uint32, name string, children []uint32, count int) {
+	w.addPC(nid, []testProp{
+		unicodeProp(propDisplayName, name),
+		int32Prop(propContentCount, uint32(count)),
+		{id: 0x360A, propType: ptBoolean, value: []byte{boolByte(len(children) > 0)}},
+	})
+
+	rows := make([][][]byte, len(children))
+	for i := range children {
+		rows[i] = [][]byte{utf16le("child")}
+	}
+	w.addTC(nid&^nidTypeMask|nidTypeHierarchyTable, []testProp{{id: propDisplayName, propType: ptUnicode}}, children, rows)
+
+	var ids []uint32
+	rows = nil
+	for i := 0; i < count; i++ {
+		ids = append(ids, testMessageNID(nid, i))
+		rows = append(rows, [][]byte{utf16le("subject"), fileTime(testDate)})
+	}
+	w.addTC(nid&^nidTypeMask|nidTypeContentsTable, []testProp{
+		{id: propSubject, propType: ptUnicode},
+		{id: propMessageDeliveryTime, propType: ptSysTime},
+	}, ids, rows)
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func addTestMessage(w *testWriter, nid uint32, subject string) {
+	w.addPC(nid, []testProp{
+		unicodeProp(propSubject, subject),
+		unicodeProp(propBody, "Body of "+subject),
+		unicodeProp(propSenderName, "Alice"),
+		unicodeProp(propSenderEmail, "alice@example.com"),
+		{id: propMessageDeliveryTime, propType: ptSysTime, value: fileTime(testDate)},
+		int32Prop(propMessageFlags, 0),
+		int32Prop(propMessageSize, 100),
+	})
+}
+
+func addTestFullMessage(w *testWriter, nid uint32) {
+	data, subnodes := testPC([]testProp{
+		unicodeProp(propSubject, "\x01\x04RE: Hello"),
+		unicodeProp(propTransportMessageHeaders, "Subject: RE: Hello\r\nX-Mailer: Test\r\n"),
+		unicodeProp(propSentRepresentingName, "Bob"),
+		unicodeProp(propSentRepresentingEmail, "/O=EXCHANGE/CN=BOB"),
+		unicodeProp(propSentRepresentingSMTPAddress, "bob@example.com"),
+		unicodeProp(propSenderName, "Alice"),
+		unicodeProp(propSenderEmail, "alice@example.com"),
+		{id: propClientSubmitTime, propType: ptSysTime, value: fileTime(testDate)},
+		{id: propMessageDeliveryTime, propType: ptSysTime, value: fileTime(testDate.Add(time.Hour))},
+		int32Prop(propMessageFlags, messageFlagRead),
+		int32Prop(propMessageSize, 12345),
+		int32Prop(propFlagStatus, flagStatusFlagged),
+		unicodeProp(propInternetMessageID, "<reply@example.com>"),
+		unicodeProp(propInReplyToID, "<hello@example.com>"),
+		unicodeProp(propBody, "Hello plain"),
+		{id: propHTML, propType: ptBinary, value: []byte("<p>caf\xe9</p>")},
+		int32Prop(propInternetCodepage, 1252),
+		{id: 0x8000, propType: ptMultiValue | ptUnicode, value: multiUnicode("Work", "Important")},
+	})
+
+	recipientColumns := []testProp{
+		{id: propRecipientType, propType: ptInt32},
+		{id: propDisplayName, propType: ptUnicode},
+		{id: propEmailAddress, propType: ptUnicode},
+		{id: propSMTPAddress, propType: ptUnicode},
+	}
+	recipientRows := [][][]byte{
+		{le32(recipientTo), utf16le("Carol"), utf16le("carol@example.com"), nil},
+		{le32(recipientCc), utf16le("Dave"), utf16le("/O=EXCHANGE/CN=DAVE"), utf16le("dave@example.com")},
+		{le32(recipientBcc), nil, utf16le("eve@example.com"), nil},
+		{le32(recipientTo), utf16le("Nobody"), utf16le("/O=EXCHANGE/CN=NOBODY"), nil},
+	}
+	recipients, recipientSubnodes := testTC(recipientColumns, []uint32{1, 2, 3, 4}, recipientRows)
+	subnodes = append(subnodes, testSubnode{nid: nidRecipientTable, data: recipients, subnodes: recipientSubnodes})
+
+	big := bytes.Repeat([]byte("0123456789abcdef"), 1250)
+	attachments := [][]testProp{
+		{
+			unicodeProp(propAttachLongFilename, "report.bin"),
+			unicodeProp(propAttachFilename, "REPORT~1.BIN"),
+			unicodeProp(propAttachMIMETag, "application/octet-stream"),
+			int32Prop(propAttachMethod, 1),
+			{id: propAttachDataBinary, propType: ptBinary, value: big},
+		},
+		{
+			unicodeProp(propAttachFilename, "logo.png"),
+			unicodeProp(propAttachContentID, "logo@example.com"),
+			int32Prop(propAttachMethod, 1),
+			{id: propAttachDataBinary, propType: ptBinary, value: []byte("PNG")},
+		},
+		{
+			unicodeProp(propDisplayName, "Forwarded"),
+			int32Prop(propAttachMethod, attachMethodEmbed),
+		},
+	}
+	for i, props := range attachments {
+		data, sub := testPC(props)
+		subnodes = append(subnodes, testSubnode{nid: uint32(0x80+i)<<5 | nidTypeAttachment, data: data, subnodes: sub})
+	}
+
+	w.addNode(nid, data, subnodes)
+}
+
+func openTestPST(t *testing.T) *File {
+	f, err := NewReader(bytes.NewReader(newTestPST()))
+	r.NoError(t, err)
+	r.True(t, f.IsUnicode())
+	return f
+}
+
+func TestOpenNotPST(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte("From foo@example.com")))
+	r.Equal(t, ErrNotPST, err)
+}
+
+func TestOpenUnsupported(t *testing.T) {
+	data := newTestPST()
+
+	version := append([]byte{}, data...)
+	version[10] = 99
+	_, err := NewReader(bytes.NewReader(version))
+	r.Equal(t, ErrUnsupportedVersion, errors.Cause(err))
+
+	crypt := append([]byte{}, data...)
+	crypt[513] = 0x02
+	_, err = NewReader(bytes.NewReader(crypt))
+	r.Equal(t, ErrUnsupportedEncryption, err)
+}
+
+func TestFolders(t *testing.T) {
+	f := openTestPST(t)
+
+	folders, err := f.Folders()
+	r.NoError(t, err)
+	r.Equal(t, []Folder{
+		{NID: testInbox, Name: "Inbox", Path: []string{"Inbox"}, MessageCount: testInboxMessages},
+		{NID: testProjects, Name: "Projects", Path: []string{"Inbox", "Projects"}, MessageCount: 1},
+		{NID: testSent, Name: "Sent Items", Path: []string{"Sent Items"}, MessageCount: 0},
+	}, folders)
+
+	ids, err := f.MessageIDs(folders[0])
+	r.NoError(t, err)
+	r.Len(t, ids, testInboxMessages)
+	for i, id := range ids {
+		r.Equal(t, testMessageNID(testInbox, i), id)
+	}
+
+	ids, err = f.MessageIDs(folders[2])
+	r.NoError(t, err)
+	r.Empty(t, ids)
+}
+
+func TestMessage(t *testing.T) {
+	f := openTestPST(t)
+
+	size, err := f.MessageSize(testMessageNID(testInbox, 0))
+	r.NoError(t, err)
+	r.Equal(t, uint64(12345), size)
+
+	msg, err := f.Message(testMessageNID(testInbox, 0))
+	r.NoError(t, err)
+
+	r.Equal(t, "RE: Hello", msg.Subject)
+	r.Equal(t, "Subject: RE: Hello\r\nX-Mailer: Test\r\n", msg.Headers)
+	r.Equal(t, &mail.Address{Name: "Bob", Address: "bob@example.com"}, msg.From)
+	r.Equal(t, []*mail.Address{{Name: "Carol", Address: "carol@example.com"}}, msg.To)
+	r.Equal(t, []*mail.Address{{Name: "Dave", Address: "dave@example.com"}}, msg.Cc)
+	r.Equal(t, []*mail.Address{{Address: "eve@example.com"}}, msg.Bcc)
+	r.Equal(t, testDate, msg.Date)
+	r.Equal(t, "<reply@example.com>", msg.MessageID)
+	r.Equal(t, "<hello@example.com>", msg.InReplyTo)
+	r.True(t, msg.Read)
+	r.False(t, msg.Unsent)
+	r.True(t, msg.Flagged)
+	r.Equal(t, "Hello plain", msg.Body)
+	r.Equal(t, "<p>café</p>", msg.HTML)
+	r.Equal(t, []string{"Work", "Important"}, msg.Categories)
+
+	r.Len(t, msg.Attachments, 2)
+	r.Equal(t, "report.bin", msg.Attachments[0].Name)
+	r.Equal(t, "application/octet-stream", msg.Attachments[0].MIMEType)
+	r.Equal(t, bytes.Repeat([]byte("0123456789abcdef"), 1250), msg.Attachments[0].Data)
+	r.Equal(t, Attachment{Name: "logo.png", ContentID: "logo@example.com", Data: []byte("PNG")}, msg.Attachments[1])
+}
+
+func TestCategories(t *testing.T) {
+	f := openTestPST(t)
+
+	categories, err := f.Categories()
+	r.NoError(t, err)
+	r.Equal(t, []string{"Important", "Work"}, categories)
+}
+
+func TestSimpleMessage(t *testing.T) {
+	f := openTestPST(t)
+
+	msg, err := f.Message(testMessageNID(testInbox, testInboxMessages-1))
+	r.NoError(t, err)
+
+	r.Equal(t, fmt.Sprintf("Message %d", testInboxMessages-1), msg.Subject)
+	r.Equal(t, &mail.Address{Name: "Alice", Address: "alice@example.com"}, msg.From)
+	r.Equal(t, testDate, msg.Date)
+	r.False(t, msg.Read)
+	r.False(t, msg.Flagged)
+	r.Empty(t, msg.Categories)
+	r.Empty(t, msg.Attachments)
+}
+
+func TestMessageNotFound(t *testing.T) {
+	f := openTestPST(t)
+
+	_, err := f.Message(testMessageNID(testSent, 0))
+	r.Equal(t, ErrNotFound, errors.Cause(err))
+}
+
+func TestCorruptedBTPage(t *testing.T) {
+	data := newTestPST()
+
+	pages := 0
+	for offset := 0; offset+pageSize <= len(data); offset += pageSize {
+		ptype := data[offset+496]
+		if data[offset+497] != ptype || (ptype != ptypeNBT && ptype != ptypeBBT) {
+			continue
+		}
+		pages++
+
+		// Entries too short to hold IDs must not be read.
+		corrupted := append([]byte{}, data...)
+		corrupted[offset+490] = 7
+
+		f, err := NewReader(bytes.NewReader(corrupted))
+		r.NoError(t, err)
+
+		var errs []error
+		r.NotPanics(t, func() { errs = readAll(f) }, "page at %d", offset)
+		r.NotEmpty(t, errs, "page at %d", offset)
+		for _, err := range errs {
+			r.Equal(t, ErrCorrupted, errors.Cause(err), "page at %d", offset)
+		}
+	}
+	r.True(t, pages > 2)
+}
+
+// readAll reads all folders and messages and returns all errors.
+func readAll(f *File) (errs []error) {
+	if _, err := f.Categories(); err != nil {
+		errs = append(errs, err)
+	}
+
+	folders, err := f.Folders()
+	if err != nil {
+		return append(errs, err)
+	}
+
+	for _, folder := range folders {
+		ids, err := f.MessageIDs(folder)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, id := range ids {
+			if _, err := f.Message(id); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+func TestDecryptPermute(t *testing.T) {
+	data := []byte("Hello, World!")
+	encrypted := make([]byte, len(data))
+	for i, b := range data {
+		encrypted[i] = permuteTable[b]
+	}
+	r.NotEqual(t, data, encrypted)
+
+	decryptPermute(encrypted)
+	r.Equal(t, data, encrypted)
+}
@@ -0,0 +1,435 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package pst
+
+import (
+	"encoding/binary"
+	"sort"
+	"unicode/utf16"
+)
+
+// testWriter creates Unicode PST files with compressible encryption for tests.
+// It writes only structures used by the reader; checksums, allocation maps
+// and other bookkeeping of Outlook are left empty.
+type testWriter struct {
+	file    []byte
+	nodes   [][]byte // Leaf entries of the node B-tree.
+	blocks  [][]byte // Leaf entries of the block B-tree.
+	nextBID uint64
+}
+
+type testSubnode struct {
+	nid      uint32
+	data     []byte
+	subnodes []testSubnode
+}
+
+const testMaxBlockSize = 8176
+
+func newTestWriter() *testWriter {
+	return &testWriter{file: make([]byte, 564), nextBID: 4}
+}
+
+func (w *testWriter) align(size int) {
+	for len(w.file)%size != 0 {
+		w.file = append(w.file, 0)
+	}
+}
+
+// addBlock writes the block and returns its ID. Data blocks are encrypted.
+func (w *testWriter) addBlock(data []byte, internal bool) uint64 {
+	bid := w.nextBID
+	w.nextBID += 4
+	if internal {
+		bid |= bidInternal
+	}
+
+	stored := append([]byte{}, data...)
+	if !internal {
+		for i, b := range stored {
+			stored[i] = permuteTable[b]
+		}
+	}
+
+	w.align(64)
+	offset := uint64(len(w.file))
+	w.file = append(w.file, stored...)
+	w.file = append(w.file, make([]byte, 16)...) // Block trailer.
+
+	entry := make([]byte, 24)
+	binary.LittleEndian.PutUint64(entry, bid)
+	binary.LittleEndian.PutUint64(entry[8:], offset)
+	binary.LittleEndian.PutUint16(entry[16:], uint16(len(data)))
+	binary.LittleEndian.PutUint16(entry[18:], 2)
+	w.blocks = append(w.blocks, entry)
+
+	return bid
+}
+
+// addData writes data into one block or into more blocks referenced by XBLOCK.
+func (w *testWriter) addData(data []byte) uint64 {
+	if len(data) <= testMaxBlockSize {
+		return w.addBlock(data, false)
+	}
+
+	var bids []uint64
+	for start := 0; start < len(data); start += testMaxBlockSize {
+		end := start + testMaxBlockSize
+		if end > len(data) {
+			end = len(data)
+		}
+		bids = append(bids, w.addBlock(data[start:end], false))
+	}
+
+	xblock := make([]byte, 8+8*len(bids))
+	xblock[0], xblock[1] = btypeXBlock, 1
+	binary.LittleEndian.PutUint16(xblock[2:], uint16(len(bids)))
+	binary.LittleEndian.PutUint32(xblock[4:], uint32(len(data)))
+	for i, bid := range bids {
+		binary.LittleEndian.PutUint64(xblock[8+8*i:], bid)
+	}
+	return w.addBlock(xblock, true)
+}
+
+// addSubnodes writes SLBLOCK with subnodes or, if there are many of them,
+// SIBLOCK pointing to more SLBLOCKs.
+func (w *testWriter) addSubnodes(subnodes []testSubnode) uint64 {
+	if len(subnodes) == 0 {
+		return 0
+	}
+	sort.Slice(subnodes, func(i, j int) bool { return subnodes[i].nid < subnodes[j].nid })
+
+	const perBlock = 3
+	if len(subnodes) > perBlock {
+		var children []testSubnode
+		var bids []uint64
+		for start := 0; start < len(subnodes); start += perBlock {
+			end := start + perBlock
+			if end > len(subnodes) {
+				end = len(subnodes)
+			}
+			children = append(children, subnodes[start])
+			bids = append(bids, w.addSubnodes(subnodes[start:end]))
+		}
+
+		siblock := make([]byte, 8+16*len(bids))
+		siblock[0], siblock[1] = btypeSBlock, 1
+		binary.LittleEndian.PutUint16(siblock[2:], uint16(len(bids)))
+		for i, bid := range bids {
+			binary.LittleEndian.PutUint64(siblock[8+16*i:], uint64(children[i].nid))
+			binary.LittleEndian.PutUint64(siblock[16+16*i:], bid)
+		}
+		return w.addBlock(siblock, true)
+	}
+
+	slblock := make([]byte, 8+24*len(subnodes))
+	slblock[0], slblock[1] = btypeSBlock, 0
+	binary.LittleEndian.PutUint16(slblock[2:], uint16(len(subnodes)))
+	for i, sub := range subnodes {
+		binary.LittleEndian.PutUint64(slblock[8+24*i:], uint64(sub.nid))
+		binary.LittleEndian.PutUint64(slblock[16+24*i:], w.addData(sub.data))
+		binary.LittleEndian.PutUint64(slblock[24+24*i:], w.addSubnodes(sub.subnodes))
+	}
+	return w.addBlock(slblock, true)
+}
+
+func (w *testWriter) addNode(nid uint32, data []byte, subnodes []testSubnode) {
+	entry := make([]byte, 32)
+	binary.LittleEndian.PutUint64(entry, uint64(nid))
+	binary.LittleEndian.PutUint64(entry[8:], w.addData(data))
+	binary.LittleEndian.PutUint64(entry[16:], w.addSubnodes(subnodes))
+	w.nodes = append(w.nodes, entry)
+}
+
+func (w *testWriter) addPC(nid uint32, props []testProp) {
+	data, subnodes := testPC(props)
+	w.addNode(nid, data, subnodes)
+}
+
+func (w *testWriter) addTC(nid uint32, columns []testProp, rowIDs []uint32, rows [][][]byte) {
+	data, subnodes := testTC(columns, rowIDs, rows)
+	w.addNode(nid, data, subnodes)
+}
+
+// addBTree writes pages of B-tree from the leaf entries and returns offset
+// of the root page.
+func (w *testWriter) addBTree(entries [][]byte, ptype byte) uint64 {
+	sort.Slice(entries, func(i, j int) bool {
+		return binary.LittleEndian.Uint64(entries[i]) < binary.LittleEndian.Uint64(entries[j])
+	})
+
+	for level := 0; ; level++ {
+		perPage := 488 / len(entries[0])
+
+		var parents [][]byte
+		for start := 0; start < len(entries); start += perPage {
+			end := start + perPage
+			if end > len(entries) {
+				end = len(entries)
+			}
+			offset := w.addBTPage(entries[start:end], ptype, level)
+
+			parent := make([]byte, 24)
+			copy(parent, entries[start][:8])
+			binary.LittleEndian.PutUint64(parent[16:], offset)
+			parents = append(parents, parent)
+		}
+
+		if len(parents) == 1 {
+			return binary.LittleEndian.Uint64(parents[0][16:])
+		}
+		entries = parents
+	}
+}
+
+func (w *testWriter) addBTPage(entries [][]byte, ptype byte, level int) uint64 {
+	page := make([]byte, pageSize)
+	for i, entry := range entries {
+		copy(page[i*len(entry):], entry)
+	}
+	page[488] = byte(len(entries))
+	page[489] = byte(488 / len(entries[0]))
+	page[490] = byte(len(entries[0]))
+	page[491] = byte(level)
+	page[496], page[497] = ptype, ptype
+
+	w.align(pageSize)
+	offset := uint64(len(w.file))
+	w.file = append(w.file, page...)
+	return offset
+}
+
+// bytes finishes the file with B-trees and header.
+func (w *testWriter) bytes() []byte {
+	nbt := w.addBTree(w.nodes, ptypeNBT)
+	bbt := w.addBTree(w.blocks, ptypeBBT)
+
+	copy(w.file, "!BDN")
+	copy(w.file[8:], "SM")
+	binary.LittleEndian.PutUint16(w.file[10:], 23)
+	binary.LittleEndian.PutUint64(w.file[224:], nbt)
+	binary.LittleEndian.PutUint64(w.file[240:], bbt)
+	w.file[513] = cryptPermute
+
+	return w.file
+}
+
+// testHeap builds heap on node with one block.
+type testHeap struct {
+	allocs   [][]byte
+	subnodes []testSubnode
+}
+
+func (h *testHeap) alloc(data []byte) uint32 {
+	h.allocs = append(h.allocs, data)
+	return uint32(len(h.allocs)) << 5
+}
+
+// value stores bigger values into subnodes as Outlook does.
+func (h *testHeap) value(data []byte) uint32 {
+	if len(data) < 3580 {
+		return h.alloc(data)
+	}
+	nid := uint32(len(h.subnodes)+1)<<5 | nidTypeMask
+	h.subnodes = append(h.subnodes, testSubnode{nid: nid, data: data})
+	return nid
+}
+
+func (h *testHeap) bth(records [][]byte, keySize, dataSize int) uint32 {
+	var data []byte
+	for _, record := range records {
+		data = append(data, record...)
+	}
+
+	var root uint32
+	if len(data) > 0 {
+		root = h.alloc(data)
+	}
+	header := []byte{clientSigBTH, byte(keySize), byte(dataSize), 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(header[4:], root)
+	return h.alloc(header)
+}
+
+func (h *testHeap) bytes(clientSig byte, userRoot uint32) []byte {
+	block := make([]byte, 12)
+	block[2], block[3] = hnSignature, clientSig
+	binary.LittleEndian.PutUint32(block[4:], userRoot)
+
+	offsets := []uint16{uint16(len(block))}
+	for _, alloc := range h.allocs {
+		block = append(block, alloc...)
+		offsets = append(offsets, uint16(len(block)))
+	}
+	if len(block)%2 != 0 {
+		block = append(block, 0)
+	}
+
+	binary.LittleEndian.PutUint16(block, uint16(len(block)))
+	pageMap := make([]byte, 4+2*len(offsets))
+	binary.LittleEndian.PutUint16(pageMap, uint16(len(h.allocs)))
+	for i, offset := range offsets {
+		binary.LittleEndian.PutUint16(pageMap[4+2*i:], offset)
+	}
+	return append(block, pageMap...)
+}
+
+type testProp struct {
+	id       uint16
+	propType uint16
+	value    []byte
+}
+
+// testPC returns data and subnodes of property context.
+func testPC(props []testProp) ([]byte, []testSubnode) {
+	sort.Slice(props, func(i, j int) bool { return props[i].id < props[j].id })
+
+	h := &testHeap{}
+	records := make([][]byte, 0, len(props))
+	for _, prop := range props {
+		record := make([]byte, 8)
+		binary.LittleEndian.PutUint16(record, prop.id)
+		binary.LittleEndian.PutUint16(record[2:], prop.propType)
+		if isStoredInline(prop.propType) {
+			copy(record[4:], prop.value)
+		} else {
+			binary.LittleEndian.PutUint32(record[4:], h.value(prop.value))
+		}
+		records = append(records, record)
+	}
+
+	root := h.bth(records, 2, 6)
+	return h.bytes(clientSigPC, root), h.subnodes
+}
+
+// testTC returns data and subnodes of table context. Each row must have
+// value of every column; the row ID is the first value of the row.
+func testTC(columns []testProp, rowIDs []uint32, rows [][][]byte) ([]byte, []testSubnode) {
+	h := &testHeap{}
+
+	sizeOf := func(propType uint16) int {
+		switch propType {
+		case ptInt16:
+			return 2
+		case ptBoolean:
+			return 1
+		case ptSysTime, ptInt64:
+			return 8
+		}
+		return 4
+	}
+
+	// Row ID column is first, then columns ordered by size as Outlook does.
+	columns = append([]testProp{{id: 0x67F2, propType: ptInt32}}, columns...)
+	order := make([]int, len(columns))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order[1:], func(i, j int) bool {
+		return sizeOf(columns[order[1+i]].propType) > sizeOf(columns[order[1+j]].propType)
+	})
+
+	offsets := make([]int, len(columns))
+	rowSize := 0
+	for _, i := range order {
+		offsets[i] = rowSize
+		rowSize += sizeOf(columns[i].propType)
+	}
+	cebOffset := rowSize
+	rowSize += (len(columns) + 7) / 8
+
+	var matrix []byte
+	indexRecords := make([][]byte, len(rowIDs))
+	for r, rowID := range rowIDs {
+		row := make([]byte, rowSize)
+		values := append([][]byte{le32(rowID)}, rows[r]...)
+		for c, col := range columns {
+			if values[c] == nil {
+				continue
+			}
+			row[cebOffset+c/8] |= 0x80 >> uint(c%8)
+			if isVariableSize(col.propType) {
+				binary.LittleEndian.PutUint32(row[offsets[c]:], h.value(values[c]))
+			} else {
+				copy(row[offsets[c]:offsets[c]+sizeOf(col.propType)], values[c])
+			}
+		}
+		matrix = append(matrix, row...)
+
+		record := make([]byte, 8)
+		binary.LittleEndian.PutUint32(record, rowID)
+		binary.LittleEndian.PutUint32(record[4:], uint32(r))
+		indexRecords[r] = record
+	}
+	sort.Slice(indexRecords, func(i, j int) bool {
+		return binary.LittleEndian.Uint32(indexRecords[i]) < binary.LittleEndian.Uint32(indexRecords[j])
+	})
+
+	info := make([]byte, tcInfoColsOff+8*len(columns))
+	info[0], info[1] = clientSigTC, byte(len(columns))
+	binary.LittleEndian.PutUint16(info[6:], uint16(cebOffset))
+	binary.LittleEndian.PutUint16(info[8:], uint16(rowSize))
+	binary.LittleEndian.PutUint32(info[10:], h.bth(indexRecords, 4, 4))
+	if len(matrix) > 0 {
+		binary.LittleEndian.PutUint32(info[14:], h.value(matrix))
+	}
+	for c, col := range columns {
+		desc := info[tcInfoColsOff+8*c:]
+		binary.LittleEndian.PutUint32(desc, uint32(col.id)<<16|uint32(col.propType))
+		binary.LittleEndian.PutUint16(desc[4:], uint16(offsets[c]))
+		desc[6] = byte(sizeOf(col.propType))
+		desc[7] = byte(c)
+	}
+
+	root := h.alloc(info)
+	return h.bytes(clientSigTC, root), h.subnodes
+}
+
+func le16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.LittleEndian.PutUint16(b, v)
+	return b
+}
+
+func le32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.LittleEndian.PutUint32(b, v)
+	return b
+}
+
+func le64(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, v)
+	return b
+}
+
+func utf16le(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(units))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[2*i:], u)
+	}
+	return b
+}
+
+func unicodeProp(id uint16, s string) testProp {
+	return testProp{id: id, propType: ptUnicode, value: utf16le(s)}
+}
+
+func int32Prop(id uint16, v uint32) testProp {
+	return testProp{id: id, propType: ptInt32, value: le32(v)}
+}
@@ -0,0 +1,456 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package qrcode encodes short texts, such as email client settings, into
+// QR codes with medium error correction level which can be printed to
+// a terminal or saved as an image.
+package qrcode
+
+import (
+	"errors"
+)
+
+// ErrTooLong is returned when data does not fit into supported versions.
+var ErrTooLong = errors.New("data is too long for QR code")
+
+// quietZone is the number of light modules around the code.
+const quietZone = 4
+
+// version describes blocks of one QR code version with error correction
+// level M. Versions up to 10 fit over 200 bytes which is enough for
+// settings of one account.
+type version struct {
+	eccPerBlock int
+	blocks      []int // Number of data codewords in each block.
+	alignment   []int // Row and column coordinates of alignment patterns.
+}
+
+var versions = []version{ //nolint[gochecknoglobals]
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v version) dataCodewords() (n int) {
+	for _, blockLen := range v.blocks {
+		n += blockLen
+	}
+	return
+}
+
+// Code is a matrix of dark and light modules.
+type Code struct {
+	// Size is the number of modules on each side without quiet zone.
+	Size int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Encode returns the smallest QR code containing data in byte mode.
+func Encode(data []byte) (*Code, error) {
+	for idx, v := range versions {
+		number := idx + 1
+		countBits := 8
+		if number >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*v.dataCodewords() {
+			continue
+		}
+
+		codewords := v.interleave(encodeData(data, countBits, v.dataCodewords()))
+
+		code := newCode(number)
+		code.drawFunctionPatterns(number, v)
+		code.drawCodewords(codewords)
+		code.applyBestMask()
+
+		return code, nil
+	}
+
+	return nil, ErrTooLong
+}
+
+// encodeData returns data codewords with byte mode header and padding.
+func encodeData(data []byte, countBits, capacity int) []byte {
+	bits := &bitBuffer{}
+	bits.append(0x4, 4)
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	terminator := 8*capacity - bits.len
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-bits.len%8)%8)
+
+	for pad := 0xec; len(bits.bytes) < capacity; pad ^= 0xec ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	return bits.bytes
+}
+
+// interleave splits data into blocks, adds error correction to each of
+// them and interleaves all codewords as they are placed in the code.
+func (v version) interleave(data []byte) (result []byte) {
+	var blocks, eccs [][]byte
+	for _, blockLen := range v.blocks {
+		blocks = append(blocks, data[:blockLen])
+		eccs = append(eccs, rsEncode(data[:blockLen], v.eccPerBlock))
+		data = data[blockLen:]
+	}
+
+	longest := v.blocks[len(v.blocks)-1]
+	for i := 0; i < longest; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.eccPerBlock; i++ {
+		for _, ecc := range eccs {
+			result = append(result, ecc[i])
+		}
+	}
+
+	return result
+}
+
+type bitBuffer struct {
+	bytes []byte
+	len   int
+}
+
+func (b *bitBuffer) append(value, count int) {
+	for i := count - 1; i >= 0; i-- {
+		if b.len%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if (value>>uint(i))&1 == 1 {
+			b.bytes[b.len/8] |= 0x80 >> uint(b.len%8)
+		}
+		b.len++
+	}
+}
+
+func newCode(number int) *Code {
+	size := 17 + 4*number
+	code := &Code{Size: size}
+	for i := 0; i < size; i++ {
+		code.modules = append(code.modules, make([]bool, size))
+		code.isFunction = append(code.isFunction, make([]bool, size))
+	}
+	return code
+}
+
+// Dark returns whether the module in the given column and row is dark.
+func (code *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= code.Size || y >= code.Size {
+		return false
+	}
+	return code.modules[y][x]
+}
+
+func (code *Code) setFunction(x, y int, dark bool) {
+	code.modules[y][x] = dark
+	code.isFunction[y][x] = true
+}
+
+func (code *Code) drawFunctionPatterns(number int, v version) {
+	for i := 0; i < code.Size; i++ {
+		code.setFunction(6, i, i%2 == 0)
+		code.setFunction(i, 6, i%2 == 0)
+	}
+
+	code.drawFinder(3, 3)
+	code.drawFinder(code.Size-4, 3)
+	code.drawFinder(3, code.Size-4)
+
+	last := len(v.alignment) - 1
+	for i, x := range v.alignment {
+		for j, y := range v.alignment {
+			// Skip the three corners with finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			code.drawAlignment(x, y)
+		}
+	}
+
+	// Reserve format areas now, real values are drawn after masking.
+	code.drawFormat(0)
+	code.drawVersion(number)
+}
+
+func (code *Code) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || yy < 0 || xx >= code.Size || yy >= code.Size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			code.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+func (code *Code) drawAlignment(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			code.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// drawFormat draws both copies of error correction level M and the mask.
+func (code *Code) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool { return (bits>>uint(i))&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		code.setFunction(8, i, bit(i))
+	}
+	code.setFunction(8, 7, bit(6))
+	code.setFunction(8, 8, bit(7))
+	code.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		code.setFunction(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		code.setFunction(code.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		code.setFunction(8, code.Size-15+i, bit(i))
+	}
+	code.setFunction(8, code.Size-8, true)
+}
+
+// formatBits returns BCH protected format information. Level M is zero.
+func formatBits(mask int) int {
+	data := mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+func (code *Code) drawVersion(number int) {
+	if number < 7 {
+		return
+	}
+
+	bits := versionBits(number)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>uint(i))&1 == 1
+		a, b := code.Size-11+i%3, i/3
+		code.setFunction(a, b, dark)
+		code.setFunction(b, a, dark)
+	}
+}
+
+// versionBits returns BCH protected version information.
+func versionBits(number int) int {
+	rem := number
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1f25)
+	}
+	return number<<12 | rem
+}
+
+// drawCodewords places bits in two module wide columns going up and down
+// from the bottom right corner, skipping function patterns.
+func (code *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < code.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = code.Size - 1 - vert
+				}
+				if code.isFunction[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				code.modules[y][x] = (codewords[i/8]>>uint(7-i%8))&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+func maskApplies(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask flips data modules; applying the same mask again reverts it.
+func (code *Code) applyMask(mask int) {
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.isFunction[y][x] && maskApplies(mask, x, y) {
+				code.modules[y][x] = !code.modules[y][x]
+			}
+		}
+	}
+}
+
+func (code *Code) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormat(mask)
+		if penalty := code.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		code.applyMask(mask)
+	}
+
+	code.applyMask(best)
+	code.drawFormat(best)
+}
+
+// penalty scores how hard the code is to read, see ISO/IEC 18004 7.8.3.
+func (code *Code) penalty() (result int) {
+	line := make([]bool, code.Size)
+	for y := 0; y < code.Size; y++ {
+		result += linePenalty(code.modules[y])
+	}
+	for x := 0; x < code.Size; x++ {
+		for y := 0; y < code.Size; y++ {
+			line[y] = code.modules[y][x]
+		}
+		result += linePenalty(line)
+	}
+
+	dark := 0
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if code.modules[y][x] {
+				dark++
+			}
+			if x+1 < code.Size && y+1 < code.Size &&
+				code.modules[y][x] == code.modules[y][x+1] &&
+				code.modules[y][x] == code.modules[y+1][x] &&
+				code.modules[y][x] == code.modules[y+1][x+1] {
+				result += 3
+			}
+		}
+	}
+
+	total := code.Size * code.Size
+	result += 10 * (abs(dark*100/total-50) / 5)
+
+	return result
+}
+
+// linePenalty scores runs of the same colour and patterns looking like
+// finder patterns in one row or column.
+func linePenalty(line []bool) (result int) {
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			result += run - 2
+		}
+		run = 1
+	}
+
+	finder := []bool{true, false, true, true, true, false, true}
+	for i := 0; i+len(finder) <= len(line); i++ {
+		if !matches(line[i:], finder) {
+			continue
+		}
+		if isLight(line, i-4, i) || isLight(line, i+len(finder), i+len(finder)+4) {
+			result += 40
+		}
+	}
+
+	return result
+}
+
+func matches(line, pattern []bool) bool {
+	for i, dark := range pattern {
+		if line[i] != dark {
+			return false
+		}
+	}
+	return true
+}
+
+// isLight returns whether all modules in range are light. Modules outside
+// of the code are light as they are in the quiet zone.
+func isLight(line []bool, from, to int) bool {
+	for i := from; i < to; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
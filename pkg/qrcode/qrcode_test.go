@@ -0,0 +1,172 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// Data and error correction of "HELLO WORLD" in version 1-M.
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	require.Equal(t, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}, rsEncode(data, 10))
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	want := []int{0x5412, 0x5125, 0x5e7c, 0x5b4b, 0x45f9, 0x40ce, 0x4f97, 0x4aa0}
+	for mask, bits := range want {
+		require.Equal(t, bits, formatBits(mask), "mask %d", mask)
+	}
+	require.Equal(t, 0x07c94, versionBits(7))
+	require.Equal(t, 0x0a4d3, versionBits(10))
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		length, size int
+	}{
+		{1, 21},
+		{14, 21},
+		{15, 25},
+		{122, 45},
+		{123, 49},
+		{213, 57},
+	}
+	for _, test := range tests {
+		data := bytes.Repeat([]byte("a"), test.length)
+		code, err := Encode(data)
+		require.NoError(t, err)
+		require.Equal(t, test.size, code.Size, "length %d", test.length)
+		require.Equal(t, data, decode(t, code), "length %d", test.length)
+	}
+
+	_, err := Encode(bytes.Repeat([]byte("a"), 214))
+	require.Equal(t, ErrTooLong, err)
+}
+
+func TestRender(t *testing.T) {
+	code, err := Encode([]byte("IMAP: 127.0.0.1:1143"))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(code.String(), "\n"), "\n")
+	require.Len(t, lines, (code.Size+2*quietZone+1)/2)
+	require.Equal(t, strings.Repeat("█", code.Size+2*quietZone), lines[0])
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, code.WritePNG(buf, 4))
+	img, err := png.Decode(buf)
+	require.NoError(t, err)
+	require.Equal(t, (code.Size+2*quietZone)*4, img.Bounds().Dx())
+}
+
+// decode reads the mask from format information, unmasks and reads data
+// codewords back to check placement and error correction.
+func decode(t *testing.T, code *Code) []byte {
+	number := (code.Size - 17) / 4
+	v := versions[number-1]
+
+	format := 0
+	for i := 0; i < 8; i++ {
+		if code.Dark(code.Size-1-i, 8) {
+			format |= 1 << uint(i)
+		}
+	}
+	for i := 8; i < 15; i++ {
+		if code.Dark(8, code.Size-15+i) {
+			format |= 1 << uint(i)
+		}
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == format {
+			mask = m
+		}
+	}
+	require.NotEqual(t, -1, mask, "unknown format bits %015b", format)
+
+	code.applyMask(mask)
+	defer code.applyMask(mask)
+
+	var codewords []byte
+	bits := &bitBuffer{}
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < code.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = code.Size - 1 - vert
+				}
+				if code.isFunction[y][x] {
+					continue
+				}
+				dark := 0
+				if code.modules[y][x] {
+					dark = 1
+				}
+				bits.append(dark, 1)
+			}
+		}
+	}
+	codewords = bits.bytes[:bits.len/8]
+
+	total := v.dataCodewords() + len(v.blocks)*v.eccPerBlock
+	require.Len(t, codewords, total)
+
+	blocks := make([][]byte, len(v.blocks))
+	i := 0
+	for pos := 0; pos < v.blocks[len(v.blocks)-1]; pos++ {
+		for b, blockLen := range v.blocks {
+			if pos < blockLen {
+				blocks[b] = append(blocks[b], codewords[i])
+				i++
+			}
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		var ecc []byte
+		for pos := 0; pos < v.eccPerBlock; pos++ {
+			ecc = append(ecc, codewords[i+pos*len(blocks)+b])
+		}
+		require.Equal(t, rsEncode(block, v.eccPerBlock), ecc)
+		data = append(data, block...)
+	}
+
+	require.Equal(t, byte(0x40), data[0]&0xf0, "byte mode")
+	if number < 10 {
+		length := int(data[0]&0x0f)<<4 | int(data[1]>>4)
+		return shiftNibble(data[1:])[:length]
+	}
+	length := int(data[0]&0x0f)<<12 | int(data[1])<<4 | int(data[2]>>4)
+	return shiftNibble(data[2:])[:length]
+}
+
+func shiftNibble(data []byte) (result []byte) {
+	for i := 0; i+1 < len(data); i++ {
+		result = append(result, data[i]<<4|data[i+1]>>4)
+	}
+	return
+}
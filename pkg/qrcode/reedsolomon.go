@@ -0,0 +1,76 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package qrcode
+
+// gfExp and gfLog are exponent and logarithm tables of GF(256) with
+// the primitive polynomial x^8 + x^4 + x^3 + x^2 + 1 used by QR codes.
+var gfExp, gfLog = func() (exp [512]byte, log [256]byte) { //nolint[gochecknoglobals]
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < len(exp); i++ {
+		exp[i] = exp[i-255]
+	}
+	return
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// rsGenerator returns coefficients of (x - a^0)(x - a^1)...(x - a^(n-1))
+// without the leading one, highest power first.
+func rsGenerator(n int) []byte {
+	gen := make([]byte, n)
+	gen[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			gen[j] = gfMul(gen[j], root)
+			if j+1 < n {
+				gen[j] ^= gen[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return gen
+}
+
+// rsEncode returns n error correction codewords for data.
+func rsEncode(data []byte, n int) []byte {
+	gen := rsGenerator(n)
+	ecc := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ ecc[0]
+		copy(ecc, ecc[1:])
+		ecc[n-1] = 0
+		for i := range ecc {
+			ecc[i] ^= gfMul(gen[i], factor)
+		}
+	}
+	return ecc
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package qrcode
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// String renders the code with half block characters, two rows of modules
+// per line. Light modules are drawn with the foreground colour, so it is
+// readable on terminals with dark background.
+func (code *Code) String() string {
+	var b strings.Builder
+	for y := -quietZone; y < code.Size+quietZone; y += 2 {
+		for x := -quietZone; x < code.Size+quietZone; x++ {
+			top, bottom := !code.Dark(x, y), !code.Dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Image returns the code with quiet zone where each module has scale pixels.
+func (code *Code) Image(scale int) image.Image {
+	side := (code.Size + 2*quietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for py := 0; py < side; py++ {
+		for px := 0; px < side; px++ {
+			c := color.White
+			if code.Dark(px/scale-quietZone, py/scale-quietZone) {
+				c = color.Black
+			}
+			img.Set(px, py, c)
+		}
+	}
+	return img
+}
+
+// WritePNG writes the code as PNG image with the given scale.
+func (code *Code) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, code.Image(scale))
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package sieve
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenIdentifier
+	tokenTag
+	tokenString
+	tokenNumber
+	tokenSpecial
+)
+
+type token struct {
+	typ   tokenType
+	value string
+	line  int
+}
+
+func (t token) String() string {
+	if t.typ == tokenEOF {
+		return "end of script"
+	}
+	return fmt.Sprintf("%q on line %d", t.value, t.line)
+}
+
+// tokenize splits the script into tokens, skipping whitespace and comments.
+func tokenize(script string) (tokens []token, err error) { //nolint[funlen]
+	runes := []rune(script)
+	line := 1
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case r == '\n':
+			line++
+			i++
+
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := strings.Index(string(runes[i+2:]), "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment on line %d", line)
+			}
+			comment := []rune(string(runes[i+2:])[:end])
+			line += strings.Count(string(comment), "\n")
+			i += 2 + len(comment) + 2
+
+		case r == '"':
+			start := line
+			value := strings.Builder{}
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				if runes[i] == '\n' {
+					line++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string on line %d", start)
+			}
+			i++
+			tokens = append(tokens, token{typ: tokenString, value: value.String(), line: start})
+
+		case strings.ContainsRune("[](){},;", r):
+			tokens = append(tokens, token{typ: tokenSpecial, value: string(r), line: line})
+			i++
+
+		case r == ':' || isIdentifierRune(r):
+			typ := tokenIdentifier
+			if r == ':' {
+				typ = tokenTag
+				i++
+			}
+			start := i
+			for i < len(runes) && isIdentifierRune(runes[i]) {
+				i++
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected %q on line %d", r, line)
+			}
+			value := string(runes[start:i])
+			if typ == tokenIdentifier && unicode.IsDigit(runes[start]) {
+				typ = tokenNumber
+			}
+			tokens = append(tokens, token{typ: typ, value: strings.ToLower(value), line: line})
+
+		default:
+			return nil, fmt.Errorf("unexpected %q on line %d", r, line)
+		}
+	}
+
+	return append(tokens, token{typ: tokenEOF, line: line}), nil
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -0,0 +1,447 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package sieve implements a subset of Sieve (RFC 5228) used for local
+// filtering of incoming messages.
+//
+// Supported are control commands require, if, elsif, else and stop, actions
+// keep, fileinto and addflag (only \Seen and \Flagged), and tests header,
+// address, exists, size, allof, anyof, not, true and false with comparisons
+// :is, :contains and :matches. Comparisons are always case-insensitive.
+package sieve
+
+import (
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+)
+
+// ActionType is the type of action which should be applied to the message.
+type ActionType int
+
+const (
+	// ActionFileInto moves the message to the mailbox in Argument.
+	ActionFileInto ActionType = iota
+	// ActionAddFlag sets the IMAP flag in Argument.
+	ActionAddFlag
+)
+
+// Action is one result of script evaluation.
+type Action struct {
+	Type     ActionType
+	Argument string
+}
+
+// Message provides the values tested by the script.
+type Message interface {
+	// HeaderValues returns decoded values of the header field.
+	HeaderValues(name string) []string
+	// Addresses returns parsed addresses of the header field.
+	Addresses(name string) []*mail.Address
+	// Size returns the size of the message in bytes.
+	Size() int64
+}
+
+// Script is a parsed Sieve script.
+type Script struct {
+	commands []command
+}
+
+// Parse parses the script. Unsupported commands and tests are reported
+// as errors rather than ignored, so rules never silently do nothing.
+func Parse(script string) (*Script, error) {
+	tokens, err := tokenize(script)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	commands, err := p.parseCommands(false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Script{commands: commands}, nil
+}
+
+// Evaluate returns actions which the script applies to the message.
+// Implicit keep is not returned as it requires no action.
+func (s *Script) Evaluate(msg Message) (actions []Action) {
+	runCommands(s.commands, msg, &actions)
+	return
+}
+
+type commandType int
+
+const (
+	commandIf commandType = iota
+	commandAction
+	commandStop
+)
+
+type command struct {
+	typ      commandType
+	action   Action
+	branches []branch
+}
+
+type branch struct {
+	test     test // Nil for else.
+	commands []command
+}
+
+// runCommands returns true when stop was reached.
+func runCommands(commands []command, msg Message, actions *[]Action) bool {
+	for _, cmd := range commands {
+		switch cmd.typ {
+		case commandStop:
+			return true
+		case commandAction:
+			*actions = append(*actions, cmd.action)
+		case commandIf:
+			for _, b := range cmd.branches {
+				if b.test != nil && !b.test.matches(msg) {
+					continue
+				}
+				if runCommands(b.commands, msg, actions) {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isSpecial(value string) bool {
+	t := p.peek()
+	return t.typ == tokenSpecial && t.value == value
+}
+
+func (p *parser) expectSpecial(value string) error {
+	if t := p.next(); t.typ != tokenSpecial || t.value != value {
+		return fmt.Errorf("expected %q, got %v", value, t)
+	}
+	return nil
+}
+
+func (p *parser) parseCommands(inBlock bool) (commands []command, err error) {
+	for {
+		t := p.peek()
+		if t.typ == tokenEOF {
+			if inBlock {
+				return nil, fmt.Errorf("missing \"}\" at %v", t)
+			}
+			return commands, nil
+		}
+		if inBlock && p.isSpecial("}") {
+			p.next()
+			return commands, nil
+		}
+
+		cmd, err := p.parseCommand()
+		if err != nil {
+			return nil, err
+		}
+		if cmd != nil {
+			commands = append(commands, *cmd)
+		}
+	}
+}
+
+func (p *parser) parseCommand() (*command, error) { //nolint[funlen]
+	t := p.next()
+	if t.typ != tokenIdentifier {
+		return nil, fmt.Errorf("expected command, got %v", t)
+	}
+
+	var cmd *command
+
+	switch t.value {
+	case "require":
+		if _, err := p.parseStringList(); err != nil {
+			return nil, err
+		}
+	case "if":
+		return p.parseIf()
+	case "stop":
+		cmd = &command{typ: commandStop}
+	case "keep":
+	case "fileinto":
+		mailbox, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		cmd = &command{typ: commandAction, action: Action{Type: ActionFileInto, Argument: mailbox}}
+	case "addflag":
+		flags, err := p.parseStringList()
+		if err != nil {
+			return nil, err
+		}
+		if len(flags) != 1 {
+			return nil, fmt.Errorf("addflag supports exactly one flag on line %d", t.line)
+		}
+		flag, err := canonicalFlag(flags[0])
+		if err != nil {
+			return nil, fmt.Errorf("%v on line %d", err, t.line)
+		}
+		cmd = &command{typ: commandAction, action: Action{Type: ActionAddFlag, Argument: flag}}
+	default:
+		return nil, fmt.Errorf("unsupported command %v", t)
+	}
+
+	if err := p.expectSpecial(";"); err != nil {
+		return nil, err
+	}
+
+	return cmd, nil
+}
+
+func (p *parser) parseIf() (*command, error) {
+	cmd := &command{typ: commandIf}
+
+	for {
+		test, err := p.parseTest()
+		if err != nil {
+			return nil, err
+		}
+		block, err := p.parseBlock()
+		if err != nil {
+			return nil, err
+		}
+		cmd.branches = append(cmd.branches, branch{test: test, commands: block})
+
+		t := p.peek()
+		if t.typ != tokenIdentifier || (t.value != "elsif" && t.value != "else") {
+			return cmd, nil
+		}
+		p.next()
+
+		if t.value == "else" {
+			block, err := p.parseBlock()
+			if err != nil {
+				return nil, err
+			}
+			cmd.branches = append(cmd.branches, branch{commands: block})
+			return cmd, nil
+		}
+	}
+}
+
+func (p *parser) parseBlock() ([]command, error) {
+	if err := p.expectSpecial("{"); err != nil {
+		return nil, err
+	}
+	return p.parseCommands(true)
+}
+
+func (p *parser) parseString() (string, error) {
+	t := p.next()
+	if t.typ != tokenString {
+		return "", fmt.Errorf("expected string, got %v", t)
+	}
+	return t.value, nil
+}
+
+func (p *parser) parseStringList() ([]string, error) {
+	if !p.isSpecial("[") {
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return []string{s}, nil
+	}
+	p.next()
+
+	var list []string
+	for {
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+
+		if p.isSpecial("]") {
+			p.next()
+			return list, nil
+		}
+		if err := p.expectSpecial(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseTestList() ([]test, error) {
+	if err := p.expectSpecial("("); err != nil {
+		return nil, err
+	}
+
+	var tests []test
+	for {
+		t, err := p.parseTest()
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, t)
+
+		if p.isSpecial(")") {
+			p.next()
+			return tests, nil
+		}
+		if err := p.expectSpecial(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseTest() (test, error) { //nolint[funlen]
+	t := p.next()
+	if t.typ != tokenIdentifier {
+		return nil, fmt.Errorf("expected test, got %v", t)
+	}
+
+	switch t.value {
+	case "true":
+		return constTest(true), nil
+	case "false":
+		return constTest(false), nil
+	case "not":
+		inner, err := p.parseTest()
+		if err != nil {
+			return nil, err
+		}
+		return notTest{inner}, nil
+	case "allof", "anyof":
+		tests, err := p.parseTestList()
+		if err != nil {
+			return nil, err
+		}
+		return listTest{tests: tests, all: t.value == "allof"}, nil
+	case "exists":
+		names, err := p.parseStringList()
+		if err != nil {
+			return nil, err
+		}
+		return existsTest{names: names}, nil
+	case "size":
+		return p.parseSizeTest()
+	case "header", "address":
+		return p.parseHeaderTest(t.value == "address")
+	default:
+		return nil, fmt.Errorf("unsupported test %v", t)
+	}
+}
+
+func (p *parser) parseSizeTest() (test, error) {
+	tag := p.next()
+	if tag.typ != tokenTag || (tag.value != "over" && tag.value != "under") {
+		return nil, fmt.Errorf("expected :over or :under, got %v", tag)
+	}
+
+	t := p.next()
+	if t.typ != tokenNumber {
+		return nil, fmt.Errorf("expected number, got %v", t)
+	}
+	limit, err := parseNumber(t.value)
+	if err != nil {
+		return nil, fmt.Errorf("%v on line %d", err, t.line)
+	}
+
+	return sizeTest{limit: limit, over: tag.value == "over"}, nil
+}
+
+func (p *parser) parseHeaderTest(isAddress bool) (test, error) {
+	ht := headerTest{match: matchIs, part: partAll, isAddress: isAddress}
+
+	for p.peek().typ == tokenTag {
+		tag := p.next()
+		switch tag.value {
+		case "is":
+			ht.match = matchIs
+		case "contains":
+			ht.match = matchContains
+		case "matches":
+			ht.match = matchMatches
+		case "all", "localpart", "domain":
+			if !isAddress {
+				return nil, fmt.Errorf("unsupported tag %v", tag)
+			}
+			ht.part = addressPart(tag.value)
+		default:
+			return nil, fmt.Errorf("unsupported tag %v", tag)
+		}
+	}
+
+	var err error
+	if ht.names, err = p.parseStringList(); err != nil {
+		return nil, err
+	}
+	if ht.keys, err = p.parseStringList(); err != nil {
+		return nil, err
+	}
+
+	return ht, nil
+}
+
+func parseNumber(value string) (int64, error) {
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(value, "k"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(value, "m"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(value, "g"):
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		value = value[:len(value)-1]
+	}
+
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("wrong number %q", value)
+	}
+	return number * multiplier, nil
+}
+
+func canonicalFlag(flag string) (string, error) {
+	switch strings.ToLower(flag) {
+	case `\seen`:
+		return `\Seen`, nil
+	case `\flagged`:
+		return `\Flagged`, nil
+	}
+	return "", fmt.Errorf("unsupported flag %q", flag)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package sieve
+
+import (
+	"net/mail"
+	"net/textproto"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+type testMessage struct {
+	header textproto.MIMEHeader
+	size   int64
+}
+
+func (m testMessage) HeaderValues(name string) []string {
+	return m.header[textproto.CanonicalMIMEHeaderKey(name)]
+}
+
+func (m testMessage) Addresses(name string) []*mail.Address {
+	var addresses []*mail.Address
+	for _, value := range m.HeaderValues(name) {
+		list, _ := mail.ParseAddressList(value)
+		addresses = append(addresses, list...)
+	}
+	return addresses
+}
+
+func (m testMessage) Size() int64 {
+	return m.size
+}
+
+func newTestMessage() testMessage {
+	return testMessage{
+		header: textproto.MIMEHeader{
+			"From":    {"The Boss <boss@company.com>"},
+			"To":      {"me@pm.me, team@company.com"},
+			"Subject": {"Invoice 2020/12"},
+		},
+		size: 2 << 20,
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		script      string
+		wantActions []Action
+	}{
+		{`keep;`, nil},
+		{
+			`require ["fileinto", "imap4flags"];
+			if header :contains "subject" "invoice" { fileinto "Folders/Invoices"; }`,
+			[]Action{{ActionFileInto, "Folders/Invoices"}},
+		},
+		{
+			`if address :domain :is "from" "COMPANY.com" { addflag "\\Flagged"; stop; }
+			fileinto "Labels/Other";`,
+			[]Action{{ActionAddFlag, `\Flagged`}},
+		},
+		{
+			`# Comment
+			if address :localpart "to" "team" { addflag "\\Seen"; }
+			/* Multi
+			   line */
+			fileinto "Labels/All";`,
+			[]Action{{ActionAddFlag, `\Seen`}, {ActionFileInto, "Labels/All"}},
+		},
+		{
+			`if allof(exists "subject", header :matches "subject" "invoice ????/*") { fileinto "A"; }
+			elsif true { fileinto "B"; }`,
+			[]Action{{ActionFileInto, "A"}},
+		},
+		{
+			`if anyof(not exists "subject", size :under 1M) { fileinto "A"; }
+			elsif size :over 1M { fileinto "B"; }
+			else { fileinto "C"; }`,
+			[]Action{{ActionFileInto, "B"}},
+		},
+		{
+			`if header :is ["to", "cc"] ["nobody@pm.me", "team@company.com"] { fileinto "A"; } else { fileinto "B"; }`,
+			[]Action{{ActionFileInto, "B"}},
+		},
+		{
+			`if address :is ["to", "cc"] ["nobody@pm.me", "team@company.com"] { fileinto "A"; } else { fileinto "B"; }`,
+			[]Action{{ActionFileInto, "A"}},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.script, func(t *testing.T) {
+			script, err := Parse(tc.script)
+			r.NoError(t, err)
+			r.Equal(t, tc.wantActions, script.Evaluate(newTestMessage()))
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	scripts := []string{
+		`discard;`,
+		`fileinto "A"`,
+		`if header :contains "subject" "a" { fileinto "A";`,
+		`if body :contains "a" { keep; }`,
+		`addflag "\\Answered";`,
+		`if header :domain "from" "a" { keep; }`,
+		`if size :over big { keep; }`,
+		`fileinto "unterminated;`,
+		`/* unterminated`,
+	}
+
+	for _, script := range scripts {
+		_, err := Parse(script)
+		r.Error(t, err, script)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package sieve
+
+import (
+	"net/mail"
+	"strings"
+)
+
+type test interface {
+	matches(msg Message) bool
+}
+
+type constTest bool
+
+func (t constTest) matches(_ Message) bool {
+	return bool(t)
+}
+
+type notTest struct {
+	test test
+}
+
+func (t notTest) matches(msg Message) bool {
+	return !t.test.matches(msg)
+}
+
+type listTest struct {
+	tests []test
+	all   bool
+}
+
+func (t listTest) matches(msg Message) bool {
+	for _, inner := range t.tests {
+		if inner.matches(msg) != t.all {
+			return !t.all
+		}
+	}
+	return t.all
+}
+
+type existsTest struct {
+	names []string
+}
+
+func (t existsTest) matches(msg Message) bool {
+	for _, name := range t.names {
+		if len(msg.HeaderValues(name)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+type sizeTest struct {
+	limit int64
+	over  bool
+}
+
+func (t sizeTest) matches(msg Message) bool {
+	if t.over {
+		return msg.Size() > t.limit
+	}
+	return msg.Size() < t.limit
+}
+
+type matchType int
+
+const (
+	matchIs matchType = iota
+	matchContains
+	matchMatches
+)
+
+type addressPart string
+
+const (
+	partAll       addressPart = "all"
+	partLocalPart addressPart = "localpart"
+	partDomain    addressPart = "domain"
+)
+
+// headerTest implements both header and address tests.
+// It matches when any value of any header matches any key.
+type headerTest struct {
+	names     []string
+	keys      []string
+	match     matchType
+	part      addressPart
+	isAddress bool
+}
+
+func (t headerTest) matches(msg Message) bool {
+	for _, name := range t.names {
+		for _, value := range t.values(msg, name) {
+			for _, key := range t.keys {
+				if compare(t.match, value, key) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (t headerTest) values(msg Message, name string) []string {
+	if !t.isAddress {
+		return msg.HeaderValues(name)
+	}
+
+	var values []string
+	for _, address := range msg.Addresses(name) {
+		values = append(values, getAddressPart(address, t.part))
+	}
+	return values
+}
+
+func getAddressPart(address *mail.Address, part addressPart) string {
+	at := strings.LastIndex(address.Address, "@")
+
+	switch {
+	case part == partLocalPart && at >= 0:
+		return address.Address[:at]
+	case part == partDomain && at >= 0:
+		return address.Address[at+1:]
+	case part == partDomain:
+		return ""
+	default:
+		return address.Address
+	}
+}
+
+func compare(match matchType, value, key string) bool {
+	value, key = strings.ToLower(value), strings.ToLower(key)
+
+	switch match {
+	case matchContains:
+		return strings.Contains(value, key)
+	case matchMatches:
+		return wildcardMatch([]rune(value), []rune(key))
+	default:
+		return value == key
+	}
+}
+
+// wildcardMatch matches value against pattern with wildcards
+// * (any sequence) and ? (any single character).
+func wildcardMatch(value, pattern []rune) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := 0; i <= len(value); i++ {
+				if wildcardMatch(value[i:], pattern[1:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(value) == 0 {
+				return false
+			}
+		default:
+			if len(value) == 0 || value[0] != pattern[0] {
+				return false
+			}
+		}
+		value, pattern = value[1:], pattern[1:]
+	}
+	return len(value) == 0
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transferplugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"plugin"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// Load opens all Go plugins (.so files) in dir. Plugins register their
+// providers from init functions. Missing directory is not an error.
+func Load(dir string) (loaded []string, err error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var result error
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".so" {
+			continue
+		}
+
+		path := filepath.Join(dir, file.Name())
+		if _, err := plugin.Open(path); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to load plugin %s", file.Name()))
+			continue
+		}
+		loaded = append(loaded, path)
+	}
+	return loaded, result
+}
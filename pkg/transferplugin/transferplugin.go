@@ -0,0 +1,186 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package transferplugin allows adding sources and targets of Import-Export
+// transfers, e.g., archives of other mail clients or proprietary formats.
+//
+// Plugin registers itself by calling Register, usually from init function.
+// It can be compiled into the app or built as Go plugin (-buildmode=plugin)
+// and placed into plugins directory from which the app loads it by Load.
+//
+// Plugin works only with RFC822 messages and mailboxes; rules, progress
+// and errors of the transfer are handled by the app.
+package transferplugin
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Errors returned when plugin cannot be registered or found.
+var (
+	ErrInvalidPlugin     = errors.New("plugin needs name, match function and source or target")
+	ErrAlreadyRegistered = errors.New("plugin with the same name is already registered")
+	ErrNoPlugin          = errors.New("no plugin supports the location")
+)
+
+// Mailbox is folder (exclusive) or label (non-exclusive) of messages.
+type Mailbox struct {
+	ID          string
+	Name        string
+	Color       string
+	IsExclusive bool
+}
+
+// Message is RFC822 message with its state.
+type Message struct {
+	// ID has to be unique in the source to be able to resume transfers.
+	ID   string
+	Body []byte
+
+	Unread  bool
+	Starred bool
+	Draft   bool
+
+	// Mailboxes are targets of the message when passed to target.
+	// Source sets nothing, the app decides the target by rules.
+	Mailboxes []Mailbox
+}
+
+// Source provides messages to import into ProtonMail.
+type Source interface {
+	// ID is used to keep rules and state of the transfer. It should be
+	// the same for the same location.
+	ID() string
+
+	// Mailboxes returns all mailboxes with messages.
+	Mailboxes() ([]Mailbox, error)
+
+	// Messages calls fn for every message in the mailbox. Error from fn
+	// means the transfer was stopped and it should be returned.
+	Messages(mailbox Mailbox, fn func(Message) error) error
+}
+
+// Counter is optionally implemented by Source which can count messages
+// without reading them. It makes estimations and progress precise.
+type Counter interface {
+	Count(mailbox Mailbox) (count uint, size uint64, err error)
+}
+
+// Target stores messages exported from ProtonMail.
+type Target interface {
+	// ID is used to keep rules and state of the transfer.
+	ID() string
+
+	// Mailboxes returns all existing mailboxes.
+	Mailboxes() ([]Mailbox, error)
+
+	// CreateMailbox creates mailbox and returns it with ID, if any.
+	// Target has to accept also mailboxes which are not created yet.
+	CreateMailbox(Mailbox) (Mailbox, error)
+
+	// Import stores the message to all its mailboxes and returns its ID
+	// at the target, if any.
+	Import(Message) (string, error)
+}
+
+// Plugin describes one provider. At least one of NewSource and NewTarget
+// has to be set.
+type Plugin struct {
+	Name string
+
+	// Match returns whether the plugin handles the location, e.g.,
+	// by extension of the path or by URL scheme.
+	Match func(location string) bool
+
+	NewSource func(location string) (Source, error)
+	NewTarget func(location string) (Target, error)
+}
+
+var (
+	pluginsLock sync.RWMutex //nolint[gochecknoglobals]
+	plugins     = []Plugin{} //nolint[gochecknoglobals]
+)
+
+// Register adds the plugin. Locations are matched in the order
+// of registration.
+func Register(plugin Plugin) error {
+	if plugin.Name == "" || plugin.Match == nil || (plugin.NewSource == nil && plugin.NewTarget == nil) {
+		return ErrInvalidPlugin
+	}
+
+	pluginsLock.Lock()
+	defer pluginsLock.Unlock()
+
+	for _, registered := range plugins {
+		if registered.Name == plugin.Name {
+			return ErrAlreadyRegistered
+		}
+	}
+	plugins = append(plugins, plugin)
+	return nil
+}
+
+// Unregister removes the plugin with the name.
+func Unregister(name string) {
+	pluginsLock.Lock()
+	defer pluginsLock.Unlock()
+
+	for i, registered := range plugins {
+		if registered.Name == name {
+			plugins = append(plugins[:i], plugins[i+1:]...)
+			return
+		}
+	}
+}
+
+// Names returns sorted names of registered plugins.
+func Names() []string {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+
+	names := make([]string, 0, len(plugins))
+	for _, plugin := range plugins {
+		names = append(names, plugin.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FindSource returns the first plugin which can be source for the location.
+func FindSource(location string) (Plugin, error) {
+	return find(location, func(plugin Plugin) bool { return plugin.NewSource != nil })
+}
+
+// FindTarget returns the first plugin which can be target for the location.
+func FindTarget(location string) (Plugin, error) {
+	return find(location, func(plugin Plugin) bool { return plugin.NewTarget != nil })
+}
+
+func find(location string, supports func(Plugin) bool) (Plugin, error) {
+	pluginsLock.RLock()
+	defer pluginsLock.RUnlock()
+
+	for _, plugin := range plugins {
+		if supports(plugin) && plugin.Match(location) {
+			return plugin, nil
+		}
+	}
+	return Plugin{}, ErrNoPlugin
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package transferplugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	r "github.com/stretchr/testify/require"
+)
+
+func newTestPlugin(name, prefix string) Plugin {
+	return Plugin{
+		Name:      name,
+		Match:     func(location string) bool { return strings.HasPrefix(location, prefix) },
+		NewSource: func(string) (Source, error) { return nil, nil },
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer Unregister("notes")
+
+	r.Equal(t, ErrInvalidPlugin, Register(Plugin{Name: "notes"}))
+	r.Equal(t, ErrInvalidPlugin, Register(Plugin{Name: "notes", Match: func(string) bool { return true }}))
+
+	r.NoError(t, Register(newTestPlugin("notes", "notes://")))
+	r.Equal(t, ErrAlreadyRegistered, Register(newTestPlugin("notes", "other://")))
+	r.Equal(t, []string{"notes"}, Names())
+
+	Unregister("notes")
+	r.Empty(t, Names())
+}
+
+func TestFind(t *testing.T) {
+	defer Unregister("notes")
+	defer Unregister("all")
+
+	r.NoError(t, Register(newTestPlugin("notes", "notes://")))
+	all := newTestPlugin("all", "")
+	all.NewSource = nil
+	all.NewTarget = func(string) (Target, error) { return nil, nil }
+	r.NoError(t, Register(all))
+
+	plugin, err := FindSource("notes://archive.nsf")
+	r.NoError(t, err)
+	r.Equal(t, "notes", plugin.Name)
+
+	_, err = FindSource("/home/user/mail")
+	r.Equal(t, ErrNoPlugin, err)
+
+	plugin, err = FindTarget("notes://archive.nsf")
+	r.NoError(t, err)
+	r.Equal(t, "all", plugin.Name)
+}
+
+func TestLoad(t *testing.T) {
+	loaded, err := Load("/nonexistent/plugins")
+	r.NoError(t, err)
+	r.Empty(t, loaded)
+
+	dir, err := ioutil.TempDir("", "plugins")
+	r.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint[errcheck]
+
+	r.NoError(t, ioutil.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a plugin"), 0600))
+	r.NoError(t, ioutil.WriteFile(filepath.Join(dir, "broken.so"), []byte("not a plugin"), 0600))
+
+	loaded, err = Load(dir)
+	r.Error(t, err)
+	r.Empty(t, loaded)
+}
@@ -77,6 +77,50 @@ func (c *fakeCredStore) SwitchAddressMode(userID string) error {
 	return nil
 }
 
+func (c *fakeCredStore) SetIMAPEnabled(userID string, enabled bool) error {
+	c.credentials[userID].IsIMAPDisabled = !enabled
+	return nil
+}
+
+func (c *fakeCredStore) SetSMTPEnabled(userID string, enabled bool) error {
+	c.credentials[userID].IsSMTPDisabled = !enabled
+	return nil
+}
+
+func (c *fakeCredStore) SetProxy(userID, proxy string) error {
+	c.credentials[userID].Proxy = proxy
+	return nil
+}
+
+func (c *fakeCredStore) AddAppPassword(userID, name string) (*credentials.AppPassword, error) {
+	appPassword := credentials.AppPassword{Name: name, Password: bridgePassword + "-" + name}
+	c.credentials[userID].AppPasswords = append(c.credentials[userID].AppPasswords, appPassword)
+	return &appPassword, nil
+}
+
+func (c *fakeCredStore) RevokeAppPassword(userID, name string) error {
+	creds := c.credentials[userID]
+	for i, appPassword := range creds.AppPasswords {
+		if appPassword.Name == name {
+			creds.AppPasswords = append(creds.AppPasswords[:i], creds.AppPasswords[i+1:]...)
+			return nil
+		}
+	}
+	return credentials.ErrAppPasswordNotFound
+}
+
+func (c *fakeCredStore) RotateBridgePassword(userID string) error {
+	c.credentials[userID].BridgePassword = bridgePassword + "-rotated"
+	return nil
+}
+
+func (c *fakeCredStore) UpdateAppPasswordLastUsed(userID, name string, lastUsed int64) error {
+	if appPassword := c.credentials[userID].GetAppPassword(name); appPassword != nil {
+		appPassword.LastUsed = lastUsed
+	}
+	return nil
+}
+
 func (c *fakeCredStore) UpdateEmails(userID string, emails []string) error {
 	return nil
 }
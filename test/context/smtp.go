@@ -60,7 +60,7 @@ func (ctx *TestContext) withSMTPServer() {
 	useSSL := pref.GetBool(preferences.SMTPSSLKey)
 
 	backend := smtp.NewSMTPBackend(ph, ctx.listener, pref, ctx.bridge)
-	server := smtp.NewSMTPServer(true, port, useSSL, tls, backend, ctx.listener)
+	server := smtp.NewSMTPServer(true, bridge.Host, port, useSSL, tls, backend, ctx.listener)
 
 	go server.ListenAndServe()
 	require.NoError(ctx.t, waitForPort(port, 5*time.Second))
@@ -97,6 +97,9 @@ func (api *FakePMAPI) Auth2FA(twoFactorCode string, auth *pmapi.Auth) (*pmapi.Au
 	}, nil
 }
 
+func (api *FakePMAPI) SetHumanVerificationToken(tokenType, token string) {
+}
+
 func (api *FakePMAPI) AuthRefresh(token string) (*pmapi.Auth, error) {
 	if api.lastToken == "" {
 		api.lastToken = token
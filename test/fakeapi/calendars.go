@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Proton Technologies AG
+//
+// This file is part of ProtonMail Bridge.
+//
+// ProtonMail Bridge is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// ProtonMail Bridge is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with ProtonMail Bridge.  If not, see <https://www.gnu.org/licenses/>.
+
+package fakeapi
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/proton-bridge/pkg/pmapi"
+)
+
+func (api *FakePMAPI) ListCalendars() ([]*pmapi.Calendar, error) {
+	if err := api.checkAndRecordCall(GET, "/calendar/v1", nil); err != nil {
+		return nil, err
+	}
+	return []*pmapi.Calendar{}, nil
+}
+
+func (api *FakePMAPI) GetCalendarBootstrap(calendarID string) (*pmapi.CalendarBootstrap, error) {
+	if err := api.checkAndRecordCall(GET, "/calendar/v1/"+calendarID+"/bootstrap", nil); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("calendar %s does not exist", calendarID)
+}
+
+func (api *FakePMAPI) ListCalendarEvents(calendarID string, page int, pageSize int) ([]*pmapi.CalendarEvent, error) {
+	v := url.Values{}
+	v.Set("Page", strconv.Itoa(page))
+	if pageSize > 0 {
+		v.Set("PageSize", strconv.Itoa(pageSize))
+	}
+	if err := api.checkAndRecordCall(GET, "/calendar/v1/"+calendarID+"/events?"+v.Encode(), nil); err != nil {
+		return nil, err
+	}
+	return []*pmapi.CalendarEvent{}, nil
+}
+
+func (api *FakePMAPI) UnlockCalendarKeyRing(bootstrap *pmapi.CalendarBootstrap) (*crypto.KeyRing, error) {
+	return nil, pmapi.ErrNoCalendarMember
+}
@@ -42,6 +42,18 @@ func (api *FakePMAPI) GetContactEmailByEmail(email string, page int, pageSize in
 	return []pmapi.ContactEmail{}, nil
 }
 
+func (api *FakePMAPI) GetContactsForExport(page int, pageSize int) ([]pmapi.Contact, error) {
+	v := url.Values{}
+	v.Set("Page", strconv.Itoa(page))
+	if pageSize > 0 {
+		v.Set("PageSize", strconv.Itoa(pageSize))
+	}
+	if err := api.checkAndRecordCall(GET, "/contacts/export?"+v.Encode(), nil); err != nil {
+		return nil, err
+	}
+	return []pmapi.Contact{}, nil
+}
+
 func (api *FakePMAPI) GetContactByID(contactID string) (pmapi.Contact, error) {
 	if err := api.checkAndRecordCall(GET, "/contacts/"+contactID, nil); err != nil {
 		return pmapi.Contact{}, err
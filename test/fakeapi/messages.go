@@ -374,6 +374,51 @@ func (api *FakePMAPI) MarkMessagesUnread(apiIDs []string) error {
 	return nil
 }
 
+func (api *FakePMAPI) MarkMessagesSpam(apiIDs []string) error {
+	return api.updateMessages(PUT, "/messages/spam", &pmapi.MessagesActionReq{
+		IDs: apiIDs,
+	}, apiIDs, func(message *pmapi.Message) error {
+		message.Flags = (message.Flags &^ pmapi.FlagHamManual) | pmapi.FlagSpamManual
+		return nil
+	})
+}
+
+func (api *FakePMAPI) MarkMessagesHam(apiIDs []string) error {
+	return api.updateMessages(PUT, "/messages/ham", &pmapi.MessagesActionReq{
+		IDs: apiIDs,
+	}, apiIDs, func(message *pmapi.Message) error {
+		message.Flags = (message.Flags &^ pmapi.FlagSpamManual) | pmapi.FlagHamManual
+		return nil
+	})
+}
+
+func (api *FakePMAPI) SnoozeMessages(apiIDs []string, until int64) error {
+	return api.updateMessages(PUT, "/messages/snooze", &pmapi.SnoozeMessagesReq{
+		IDs:        apiIDs,
+		SnoozeTime: until,
+	}, apiIDs, func(message *pmapi.Message) error {
+		message.LabelIDs = removeItem(message.LabelIDs, pmapi.InboxLabel)
+		if !hasItem(message.LabelIDs, pmapi.SnoozedLabel) {
+			message.LabelIDs = append(message.LabelIDs, pmapi.SnoozedLabel)
+		}
+		message.SnoozeTime = until
+		return nil
+	})
+}
+
+func (api *FakePMAPI) UnsnoozeMessages(apiIDs []string) error {
+	return api.updateMessages(PUT, "/messages/unsnooze", &pmapi.MessagesActionReq{
+		IDs: apiIDs,
+	}, apiIDs, func(message *pmapi.Message) error {
+		if !hasItem(message.LabelIDs, pmapi.SnoozedLabel) {
+			return errWasNotUpdated
+		}
+		message.LabelIDs = append(removeItem(message.LabelIDs, pmapi.SnoozedLabel), pmapi.InboxLabel)
+		message.SnoozeTime = 0
+		return nil
+	})
+}
+
 func (api *FakePMAPI) updateMessages(method method, path string, request interface{}, apiIDs []string, updateCallback func(*pmapi.Message) error) error { //nolint[unparam]
 	if err := api.checkAndRecordCall(method, path, request); err != nil {
 		return err
@@ -15,3 +15,12 @@ Feature: IMAP select into mailbox
   Scenario: Select into non-existing mailbox
     When IMAP client selects "qwerty"
     Then IMAP response is "IMAP error: NO mailbox qwerty does not exist"
+
+  Scenario: Unselect mailbox
+    Given IMAP client selects "INBOX"
+    When IMAP client unselects
+    Then IMAP response is "OK"
+
+  Scenario: Unselect without selected mailbox
+    When IMAP client unselects
+    Then IMAP response is "IMAP error: NO No mailbox selected"
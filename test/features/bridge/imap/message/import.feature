@@ -85,3 +85,15 @@ Feature: IMAP import messages
       """
     Then IMAP response is "OK"
 
+
+  Scenario: Import message using non-synchronizing literal
+    When IMAP client imports message to "INBOX" using non-synchronizing literal
+      """
+      From: Bridge Test <bridgetest@pm.test>
+      To: Internal Bridge <bridgetest@protonmail.com>
+      Subject: Message sent with LITERAL+
+
+      Hello
+
+      """
+    Then IMAP response is "OK"
@@ -27,6 +27,7 @@ func IMAPActionsMailboxFeatureContext(s *godog.Suite) {
 	s.Step(`^IMAP client deletes mailbox "([^"]*)"$`, imapClientDeletesMailbox)
 	s.Step(`^IMAP client lists mailboxes$`, imapClientListsMailboxes)
 	s.Step(`^IMAP client selects "([^"]*)"$`, imapClientSelects)
+	s.Step(`^IMAP client unselects$`, imapClientUnselects)
 	s.Step(`^IMAP client gets info of "([^"]*)"$`, imapClientGetsInfoOf)
 	s.Step(`^IMAP client gets status of "([^"]*)"$`, imapClientGetsStatusOf)
 }
@@ -61,6 +62,12 @@ func imapClientSelects(mailboxName string) error {
 	return nil
 }
 
+func imapClientUnselects() error {
+	res := ctx.GetIMAPClient("imap").Unselect()
+	ctx.SetIMAPLastResponse("imap", res)
+	return nil
+}
+
 func imapClientGetsInfoOf(mailboxName string) error {
 	res := ctx.GetIMAPClient("imap").GetMailboxInfo(mailboxName)
 	ctx.SetIMAPLastResponse("imap", res)
@@ -35,6 +35,7 @@ func IMAPActionsMessagesFeatureContext(s *godog.Suite) {
 	s.Step(`^IMAP client moves messages "([^"]*)" to "([^"]*)"$`, imapClientMovesMessagesTo)
 	s.Step(`^IMAP client imports message to "([^"]*)"$`, imapClientCreatesMessage)
 	s.Step(`^IMAP client imports message to "([^"]*)" with encoding "([^"]*)"$`, imapClientCreatesMessageWithEncoding)
+	s.Step(`^IMAP client imports message to "([^"]*)" using non-synchronizing literal$`, imapClientCreatesMessageNonSync)
 	s.Step(`^IMAP client creates message "([^"]*)" from "([^"]*)" to "([^"]*)" with body "([^"]*)" in "([^"]*)"$`, imapClientCreatesMessageFromToWithBody)
 	s.Step(`^IMAP client creates message "([^"]*)" from "([^"]*)" to address "([^"]*)" of "([^"]*)" with body "([^"]*)" in "([^"]*)"$`, imapClientCreatesMessageFromToAddressOfUserWithBody)
 	s.Step(`^IMAP client creates message "([^"]*)" from address "([^"]*)" of "([^"]*)" to "([^"]*)" with body "([^"]*)" in "([^"]*)"$`, imapClientCreatesMessageFromAddressOfUserToWithBody)
@@ -96,6 +97,12 @@ func imapClientCreatesMessage(mailboxName string, message *gherkin.DocString) er
 	return imapClientCreatesMessageWithEncoding(mailboxName, "utf8", message)
 }
 
+func imapClientCreatesMessageNonSync(mailboxName string, message *gherkin.DocString) error {
+	res := ctx.GetIMAPClient("imap").AppendNonSync(mailboxName, message.Content)
+	ctx.SetIMAPLastResponse("imap", res)
+	return nil
+}
+
 func imapClientCreatesMessageWithEncoding(mailboxName, encodingName string, message *gherkin.DocString) error {
 	encoding, _ := charset.Lookup(encodingName)
 
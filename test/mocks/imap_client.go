@@ -98,6 +98,10 @@ func (c *IMAPClient) Select(mailboxName string) *IMAPResponse {
 	return c.SendCommand(fmt.Sprintf("SELECT \"%s\"", mailboxName)) //nolint[gosec]
 }
 
+func (c *IMAPClient) Unselect() *IMAPResponse {
+	return c.SendCommand("UNSELECT")
+}
+
 func (c *IMAPClient) CreateMailbox(mailboxName string) *IMAPResponse {
 	return c.SendCommand(fmt.Sprintf("CREATE \"%s\"", mailboxName))
 }
@@ -167,6 +171,12 @@ func (c *IMAPClient) Append(mailboxName, msg string) *IMAPResponse {
 	return c.SendCommand(cmd)
 }
 
+// AppendNonSync appends message using non-synchronizing literal (LITERAL+).
+func (c *IMAPClient) AppendNonSync(mailboxName, msg string) *IMAPResponse {
+	cmd := fmt.Sprintf("APPEND \"%s\" (\\Seen) \"25-Mar-2021 00:30:00 +0100\" {%d+}\r\n%s", mailboxName, len(msg), msg)
+	return c.SendCommand(cmd)
+}
+
 func (c *IMAPClient) AppendBody(mailboxName, subject, from, to, body string) *IMAPResponse {
 	msg := fmt.Sprintf("Subject: %s\r\n", subject)
 	msg += fmt.Sprintf("From: %s\r\n", from)